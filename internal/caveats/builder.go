@@ -17,6 +17,24 @@ func CaveatAsExpr(caveat *core.ContextualizedCaveat) *core.CaveatExpression {
 	}
 }
 
+// RelationshipCaveatAsExpr wraps the caveat of a relationship into a caveat expression, recording
+// the resource type and relation of the relationship as the source of the caveat.
+func RelationshipCaveatAsExpr(relationship *core.RelationTuple) *core.CaveatExpression {
+	if relationship.Caveat == nil {
+		return nil
+	}
+
+	return &core.CaveatExpression{
+		OperationOrCaveat: &core.CaveatExpression_Caveat{
+			Caveat: relationship.Caveat,
+		},
+		SourceRelation: &core.RelationReference{
+			Namespace: relationship.ResourceAndRelation.Namespace,
+			Relation:  relationship.ResourceAndRelation.Relation,
+		},
+	}
+}
+
 // CaveatForTesting returns a new ContextualizedCaveat for testing, with empty context.
 func CaveatForTesting(name string) *core.ContextualizedCaveat {
 	return &core.ContextualizedCaveat{
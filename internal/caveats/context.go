@@ -0,0 +1,55 @@
+package caveats
+
+import (
+	"golang.org/x/exp/maps"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// ContextOverride is caveat context which applies only to caveats found on relationships of a
+// namespace or, if Relation is non-empty, on relationships of a specific relation within the
+// namespace.
+type ContextOverride struct {
+	Namespace string
+	Relation  string
+	Context   map[string]any
+}
+
+// ContextOverrides are the caveat context overrides for an evaluation.
+//
+// The context for a caveat found on a relationship is merged from the following, with each
+// taking precedence over those before it for any conflicting keys:
+//  1. the context given for the entire evaluation
+//  2. the overrides for the namespace of the relationship
+//  3. the overrides for the relation of the relationship
+//  4. the context written on the relationship itself
+//
+// Overrides do not apply to caveats whose source relationship is unknown.
+type ContextOverrides []ContextOverride
+
+// contextFor returns the context for a caveat found on a relationship of the given source
+// relation, before the context written on the relationship is applied.
+func (co ContextOverrides) contextFor(context map[string]any, source *core.RelationReference) map[string]any {
+	merged := maps.Clone(context)
+	if merged == nil {
+		merged = map[string]any{}
+	}
+
+	if source == nil {
+		return merged
+	}
+
+	for _, override := range co {
+		if override.Namespace == source.Namespace && override.Relation == "" {
+			maps.Copy(merged, override.Context)
+		}
+	}
+
+	for _, override := range co {
+		if override.Namespace == source.Namespace && override.Relation != "" && override.Relation == source.Relation {
+			maps.Copy(merged, override.Context)
+		}
+	}
+
+	return merged
+}
@@ -31,9 +31,23 @@ func RunCaveatExpression(
 	context map[string]any,
 	reader datastore.CaveatReader,
 	debugOption RunCaveatExpressionDebugOption,
+) (ExpressionResult, error) {
+	return RunCaveatExpressionWithOverrides(ctx, expr, context, nil, reader, debugOption)
+}
+
+// RunCaveatExpressionWithOverrides runs a caveat expression over the given context and returns
+// the result, with the context of each caveat found on a relationship overridden for the
+// namespace and relation of that relationship, as described on ContextOverrides.
+func RunCaveatExpressionWithOverrides(
+	ctx context.Context,
+	expr *core.CaveatExpression,
+	context map[string]any,
+	overrides ContextOverrides,
+	reader datastore.CaveatReader,
+	debugOption RunCaveatExpressionDebugOption,
 ) (ExpressionResult, error) {
 	env := caveats.NewEnvironment()
-	return runExpression(ctx, env, expr, context, reader, debugOption)
+	return runExpression(ctx, env, expr, context, overrides, reader, debugOption)
 }
 
 // ExpressionResult is the result of a caveat expression being run.
@@ -85,6 +99,7 @@ func runExpression(
 	env *caveats.Environment,
 	expr *core.CaveatExpression,
 	context map[string]any,
+	overrides ContextOverrides,
 	reader datastore.CaveatReader,
 	debugOption RunCaveatExpressionDebugOption,
 ) (ExpressionResult, error) {
@@ -100,11 +115,7 @@ func runExpression(
 		}
 
 		// Create a combined context, with the written context taking precedence over that specified.
-		untypedFullContext := maps.Clone(context)
-		if untypedFullContext == nil {
-			untypedFullContext = map[string]any{}
-		}
-
+		untypedFullContext := overrides.contextFor(context, expr.SourceRelation)
 		relationshipContext := expr.GetCaveat().GetContext().AsMap()
 		maps.Copy(untypedFullContext, relationshipContext)
 
@@ -120,7 +131,7 @@ func runExpression(
 
 		result, err := caveats.EvaluateCaveat(compiled, typedParameters)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("could not evaluate caveat `%s`: %w", caveat.Name, err)
 		}
 
 		return result, nil
@@ -152,7 +163,7 @@ func runExpression(
 	}
 
 	for _, child := range cop.Children {
-		childResult, err := runExpression(ctx, env, child, context, reader, debugOption)
+		childResult, err := runExpression(ctx, env, child, context, overrides, reader, debugOption)
 		if err != nil {
 			return nil, err
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/memdb"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 var (
@@ -201,3 +202,83 @@ func TestRunCaveatExpressions(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCaveatExpressionsWithOverrides(t *testing.T) {
+	overrides := caveats.ContextOverrides{
+		{Namespace: "document", Context: map[string]any{"first": "42", "second": "hello"}},
+		{Namespace: "document", Relation: "editor", Context: map[string]any{"first": "12"}},
+		{Namespace: "folder", Relation: "viewer", Context: map[string]any{"first": "12"}},
+	}
+
+	tcs := []struct {
+		name          string
+		expression    *core.CaveatExpression
+		context       map[string]any
+		expectedValue bool
+	}{
+		{
+			"namespace override takes precedence over context",
+			caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("document:foo#viewer@user:tom"), "firstCaveat")),
+			map[string]any{"first": "12"},
+			true,
+		},
+		{
+			"relation override takes precedence over namespace override",
+			caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("document:foo#editor@user:tom"), "firstCaveat")),
+			map[string]any{"first": "42"},
+			false,
+		},
+		{
+			"written context takes precedence over relation override",
+			caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("document:foo#editor@user:tom"), "firstCaveat", map[string]any{"first": "42"})),
+			nil,
+			true,
+		},
+		{
+			"override for another relation does not apply",
+			caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("folder:foo#editor@user:tom"), "firstCaveat")),
+			map[string]any{"first": "42"},
+			true,
+		},
+		{
+			"overrides do not apply without a source relation",
+			caveatexpr("firstCaveat"),
+			map[string]any{"first": "12"},
+			false,
+		},
+		{
+			"overrides apply per caveat within an expression",
+			caveatAnd(
+				caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("document:foo#viewer@user:tom"), "firstCaveat")),
+				caveatInvert(
+					caveats.RelationshipCaveatAsExpr(tuple.WithCaveat(tuple.MustParse("folder:foo#viewer@user:tom"), "firstCaveat")),
+				),
+			),
+			map[string]any{"first": "42"},
+			true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			req.NoError(err)
+
+			ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+				caveat firstCaveat(first int) {
+					first == 42
+				}
+				`, nil, req)
+			headRevision, err := ds.HeadRevision(context.Background())
+			req.NoError(err)
+
+			reader := ds.SnapshotReader(headRevision)
+
+			result, err := caveats.RunCaveatExpressionWithOverrides(context.Background(), tc.expression, tc.context, overrides, reader, caveats.RunCaveatExpressionNoDebugging)
+			req.NoError(err)
+			req.Equal(tc.expectedValue, result.Value())
+		})
+	}
+}
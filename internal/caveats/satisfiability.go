@@ -0,0 +1,91 @@
+package caveats
+
+import (
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// maxSatisfiabilityCaveats is the maximum number of distinct caveats in an expression for which
+// IsSatisfiable will enumerate all possible results. Expressions referencing more caveats are
+// assumed to be satisfiable.
+const maxSatisfiabilityCaveats = 12
+
+// IsSatisfiable returns false if the given caveat expression can never evaluate to true, regardless
+// of the context under which it is evaluated, such as `caveat && !caveat`. A nil expression is
+// always satisfiable.
+//
+// The check is purely structural: each distinct caveat (by name, context and source relation) is
+// treated as an independent boolean, and the bodies of the caveats are not inspected. As a result,
+// expressions whose caveats contradict one another via their bodies are reported as satisfiable.
+func IsSatisfiable(expr *core.CaveatExpression) bool {
+	if expr == nil {
+		return true
+	}
+
+	var leaves []*core.CaveatExpression
+	collectCaveatLeaves(expr, &leaves)
+	if len(leaves) > maxSatisfiabilityCaveats {
+		return true
+	}
+
+	for assignment := uint(0); assignment < 1<<len(leaves); assignment++ {
+		if evaluateAssignment(expr, leaves, assignment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func collectCaveatLeaves(expr *core.CaveatExpression, leaves *[]*core.CaveatExpression) {
+	if expr.GetCaveat() != nil {
+		if leafIndex(expr, *leaves) < 0 {
+			*leaves = append(*leaves, expr)
+		}
+		return
+	}
+
+	for _, child := range expr.GetOperation().GetChildren() {
+		collectCaveatLeaves(child, leaves)
+	}
+}
+
+func leafIndex(leaf *core.CaveatExpression, leaves []*core.CaveatExpression) int {
+	for index, existing := range leaves {
+		if existing.EqualVT(leaf) {
+			return index
+		}
+	}
+	return -1
+}
+
+func evaluateAssignment(expr *core.CaveatExpression, leaves []*core.CaveatExpression, assignment uint) bool {
+	if expr.GetCaveat() != nil {
+		return assignment&(1<<leafIndex(expr, leaves)) != 0
+	}
+
+	op := expr.GetOperation()
+	switch op.Op {
+	case core.CaveatOperation_AND:
+		for _, child := range op.Children {
+			if !evaluateAssignment(child, leaves, assignment) {
+				return false
+			}
+		}
+		return true
+
+	case core.CaveatOperation_OR:
+		for _, child := range op.Children {
+			if evaluateAssignment(child, leaves, assignment) {
+				return true
+			}
+		}
+		return false
+
+	case core.CaveatOperation_NOT:
+		return !evaluateAssignment(op.Children[0], leaves, assignment)
+
+	default:
+		// Unknown operations are treated as always satisfiable.
+		return true
+	}
+}
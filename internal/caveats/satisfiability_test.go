@@ -0,0 +1,59 @@
+package caveats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func TestIsSatisfiable(t *testing.T) {
+	context, err := structpb.NewStruct(map[string]any{"a": 1})
+	require.NoError(t, err)
+
+	first := CaveatExprForTesting("first")
+	second := CaveatExprForTesting("second")
+
+	tcs := []struct {
+		name     string
+		expr     *core.CaveatExpression
+		expected bool
+	}{
+		{"nil", nil, true},
+		{"single caveat", first, true},
+		{"inverted caveat", Invert(first), true},
+		{"caveat and its inversion", And(first, Invert(first)), false},
+		{"caveat or its inversion", Or(first, Invert(first)), true},
+		{"subtracted from itself", Subtract(first, first), false},
+		{"different caveats", And(first, Invert(second)), true},
+		{
+			"same caveat with different context",
+			And(first, Invert(CaveatAsExpr(&core.ContextualizedCaveat{CaveatName: "first", Context: context}))),
+			true,
+		},
+		{
+			"nested contradiction",
+			And(Or(first, second), And(Invert(first), Invert(second))),
+			false,
+		},
+		{
+			"union with contradiction",
+			Or(And(first, Invert(first)), second),
+			true,
+		},
+		{
+			"union of contradictions",
+			Or(Subtract(first, first), Subtract(second, second)),
+			false,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, IsSatisfiable(tc.expr))
+		})
+	}
+}
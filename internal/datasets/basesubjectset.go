@@ -190,6 +190,24 @@ func (bss BaseSubjectSet[T]) UnionWithSet(other BaseSubjectSet[T]) {
 	bss.UnionWith(other.AsSlice())
 }
 
+// UnionWithSetDetectingConflicts performs a union operation between this set and the other set,
+// modifying this set *in place*, exactly as UnionWithSet does. In addition, it returns those
+// subjects affected by the union whose combined caveat expression can never be satisfied, such as
+// when the same subject was found with contradictory caveats.
+func (bss BaseSubjectSet[T]) UnionWithSetDetectingConflicts(other BaseSubjectSet[T]) []T {
+	added := other.AsSlice()
+	bss.UnionWith(added)
+
+	var conflicting []T
+	for _, fs := range added {
+		merged, ok := bss.Get(fs.GetSubjectId())
+		if ok && !caveats.IsSatisfiable(merged.GetCaveatExpression()) {
+			conflicting = append(conflicting, merged)
+		}
+	}
+	return conflicting
+}
+
 // Get returns the found subject with the given ID in the set, if any.
 func (bss BaseSubjectSet[T]) Get(id string) (T, bool) {
 	if id == tuple.PublicWildcard {
@@ -235,6 +253,17 @@ func (bss BaseSubjectSet[T]) UnsafeRemoveExact(foundSubject T) {
 	delete(bss.concrete, foundSubject.GetSubjectId())
 }
 
+// UnsafeAddExact adds the subject as-is, replacing any existing subject with the same ID, with
+// no wildcard handling. This should ONLY be used to reconstruct a set from its members.
+func (bss BaseSubjectSet[T]) UnsafeAddExact(foundSubject T) {
+	if foundSubject.GetSubjectId() == tuple.PublicWildcard {
+		bss.wildcard.setOrNil(&foundSubject)
+		return
+	}
+
+	bss.concrete[foundSubject.GetSubjectId()] = foundSubject
+}
+
 // WithParentCaveatExpression returns a copy of the subject set with the parent caveat expression applied
 // to all members of this set.
 func (bss BaseSubjectSet[T]) WithParentCaveatExpression(parentCaveatExpr *core.CaveatExpression) BaseSubjectSet[T] {
@@ -18,9 +18,12 @@ type Changes[R datastore.Revision, K comparable] struct {
 }
 
 type changeRecord[R datastore.Revision] struct {
-	rev          R
-	tupleTouches map[string]*core.RelationTuple
-	tupleDeletes map[string]*core.RelationTuple
+	rev                R
+	tupleTouches       map[string]*core.RelationTuple
+	tupleDeletes       map[string]*core.RelationTuple
+	definitionsChanged map[string]datastore.SchemaDefinition
+	namespacesDeleted  map[string]struct{}
+	caveatsDeleted     map[string]struct{}
 }
 
 // NewChanges creates a new Changes object for change tracking and de-duplication.
@@ -38,16 +41,7 @@ func (ch Changes[R, K]) AddChange(
 	tpl *core.RelationTuple,
 	op core.RelationTupleUpdate_Operation,
 ) {
-	k := ch.keyFunc(rev)
-	revisionChanges, ok := ch.records[k]
-	if !ok {
-		revisionChanges = changeRecord[R]{
-			rev,
-			make(map[string]*core.RelationTuple),
-			make(map[string]*core.RelationTuple),
-		}
-		ch.records[k] = revisionChanges
-	}
+	revisionChanges := ch.recordForRevision(rev)
 
 	tplKey := tuple.StringWithoutCaveat(tpl)
 
@@ -68,6 +62,64 @@ func (ch Changes[R, K]) AddChange(
 	}
 }
 
+// AddChangedDefinition adds a namespace or caveat definition written at the given revision. A
+// definition written after being deleted in the same revision is considered changed.
+func (ch Changes[R, K]) AddChangedDefinition(rev R, def datastore.SchemaDefinition) {
+	record := ch.recordForRevision(rev)
+	record.definitionsChanged[definitionKey(def)] = def
+
+	switch def.(type) {
+	case *core.NamespaceDefinition:
+		delete(record.namespacesDeleted, def.GetName())
+	case *core.CaveatDefinition:
+		delete(record.caveatsDeleted, def.GetName())
+	}
+}
+
+// AddDeletedNamespace adds a namespace deleted at the given revision. A namespace also written in
+// the same revision is considered changed, rather than deleted.
+func (ch Changes[R, K]) AddDeletedNamespace(rev R, nsName string) {
+	record := ch.recordForRevision(rev)
+	if _, ok := record.definitionsChanged[definitionKey(&core.NamespaceDefinition{Name: nsName})]; !ok {
+		record.namespacesDeleted[nsName] = struct{}{}
+	}
+}
+
+// AddDeletedCaveat adds a caveat deleted at the given revision. A caveat also written in the same
+// revision is considered changed, rather than deleted.
+func (ch Changes[R, K]) AddDeletedCaveat(rev R, caveatName string) {
+	record := ch.recordForRevision(rev)
+	if _, ok := record.definitionsChanged[definitionKey(&core.CaveatDefinition{Name: caveatName})]; !ok {
+		record.caveatsDeleted[caveatName] = struct{}{}
+	}
+}
+
+func (ch Changes[R, K]) recordForRevision(rev R) changeRecord[R] {
+	k := ch.keyFunc(rev)
+	revisionChanges, ok := ch.records[k]
+	if !ok {
+		revisionChanges = changeRecord[R]{
+			rev,
+			make(map[string]*core.RelationTuple),
+			make(map[string]*core.RelationTuple),
+			make(map[string]datastore.SchemaDefinition),
+			make(map[string]struct{}),
+			make(map[string]struct{}),
+		}
+		ch.records[k] = revisionChanges
+	}
+	return revisionChanges
+}
+
+// definitionKey returns the key for a definition, distinguishing namespaces and caveats, whose
+// names may overlap.
+func definitionKey(def datastore.SchemaDefinition) string {
+	if _, ok := def.(*core.CaveatDefinition); ok {
+		return "caveat:" + def.GetName()
+	}
+	return "namespace:" + def.GetName()
+}
+
 // AsRevisionChanges returns the list of changes processed so far as a datastore watch
 // compatible, ordered, changelist.
 func (ch Changes[R, K]) AsRevisionChanges(lessThanFunc func(lhs, rhs K) bool) []datastore.RevisionChanges {
@@ -96,7 +148,46 @@ func (ch Changes[R, K]) AsRevisionChanges(lessThanFunc func(lhs, rhs K) bool) []
 				Tuple:     tpl,
 			})
 		}
+
+		definitionKeys := make([]string, 0, len(revisionChangeRecord.definitionsChanged))
+		for key := range revisionChangeRecord.definitionsChanged {
+			definitionKeys = append(definitionKeys, key)
+		}
+		sort.Strings(definitionKeys)
+		for _, key := range definitionKeys {
+			changes[i].ChangedDefinitions = append(changes[i].ChangedDefinitions, revisionChangeRecord.definitionsChanged[key])
+		}
+
+		changes[i].DeletedNamespaces = sortedKeys(revisionChangeRecord.namespacesDeleted)
+		changes[i].DeletedCaveats = sortedKeys(revisionChangeRecord.caveatsDeleted)
 	}
 
 	return changes
 }
+
+func sortedKeys(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SendCheckpoint sends a checkpoint at the revision to the updates of a watch, unless updates are
+// already buffered. A checkpoint is superseded by the next one, so it is dropped rather than taking
+// buffer space from changes, which would disconnect a consumer that has fallen behind.
+func SendCheckpoint(updates chan<- *datastore.RevisionChanges, rev datastore.Revision) {
+	if len(updates) > 0 {
+		return
+	}
+
+	select {
+	case updates <- &datastore.RevisionChanges{Revision: rev, IsCheckpoint: true}:
+	default:
+	}
+}
@@ -319,3 +319,49 @@ func canonicalize(in []datastore.RevisionChanges) []datastore.RevisionChanges {
 
 	return out
 }
+
+func TestSchemaChanges(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	ch := NewChanges(revision.DecimalKeyFunc)
+
+	docs := &core.NamespaceDefinition{Name: "docs"}
+	users := &core.NamespaceDefinition{Name: "users"}
+	someCaveat := &core.CaveatDefinition{Name: "somecaveat"}
+
+	// Revision 1 creates definitions alongside a relationship.
+	ch.AddChange(ctx, rev1, tuple.MustParse(tuple1), core.RelationTupleUpdate_TOUCH)
+	ch.AddChangedDefinition(rev1, users)
+	ch.AddChangedDefinition(rev1, docs)
+	ch.AddChangedDefinition(rev1, someCaveat)
+
+	// Revision 2 updates a namespace, which deletes and rewrites it, and deletes the rest.
+	ch.AddDeletedNamespace(rev2, "docs")
+	ch.AddChangedDefinition(rev2, docs)
+	ch.AddDeletedNamespace(rev2, "users")
+	ch.AddDeletedCaveat(rev2, "somecaveat")
+
+	// A namespace and caveat of the same name are tracked independently.
+	ch.AddChangedDefinition(revOneMillion, &core.CaveatDefinition{Name: "docs"})
+	ch.AddDeletedNamespace(revOneMillion, "docs")
+
+	require.Equal([]datastore.RevisionChanges{
+		{
+			Revision:           rev1,
+			Changes:            []*core.RelationTupleUpdate{touch(tuple1)},
+			ChangedDefinitions: []datastore.SchemaDefinition{someCaveat, docs, users},
+		},
+		{
+			Revision:           rev2,
+			ChangedDefinitions: []datastore.SchemaDefinition{docs},
+			DeletedNamespaces:  []string{"users"},
+			DeletedCaveats:     []string{"somecaveat"},
+		},
+		{
+			Revision:           revOneMillion,
+			ChangedDefinitions: []datastore.SchemaDefinition{&core.CaveatDefinition{Name: "docs"}},
+			DeletedNamespaces:  []string{"docs"},
+		},
+	}, ch.AsRevisionChanges(revision.DecimalKeyLessThanFunc))
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"time"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// NewRevisionDiff returns the diff of the given created and deleted relationships, for datastores
+// which find them by comparing the rows live at each of the revisions. A relationship rewritten
+// without change, such as by a TOUCH, is found in both the created and deleted relationships; such
+// pairs are dropped, as the relationship did not change between the revisions. A relationship
+// rewritten with a different caveat or expiration is reported as both deleted and created.
+func NewRevisionDiff(created, deleted []*core.RelationTuple) *datastore.RevisionDiff {
+	createdKeys := util.NewSet[string]()
+	for _, tpl := range created {
+		createdKeys.Add(diffKey(tpl))
+	}
+
+	deletedKeys := util.NewSet[string]()
+	for _, tpl := range deleted {
+		deletedKeys.Add(diffKey(tpl))
+	}
+
+	diff := &datastore.RevisionDiff{}
+	for _, tpl := range created {
+		if !deletedKeys.Has(diffKey(tpl)) {
+			diff.Created = append(diff.Created, tpl)
+		}
+	}
+
+	for _, tpl := range deleted {
+		if !createdKeys.Has(diffKey(tpl)) {
+			diff.Deleted = append(diff.Deleted, tpl)
+		}
+	}
+
+	return diff
+}
+
+// diffKey returns the key under which the relationship is compared, which includes its caveat
+// and expiration.
+func diffKey(tpl *core.RelationTuple) string {
+	if tpl.ExpiresAt == nil {
+		return tuple.MustString(tpl)
+	}
+	return tuple.MustString(tpl) + "@" + tpl.ExpiresAt.AsTime().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestNewRevisionDiff(t *testing.T) {
+	touched := tuple.MustParse("docs:1#reader@user:1")
+	created := tuple.MustParse("docs:2#reader@user:2")
+	deleted := tuple.MustParse("docs:3#reader@user:3")
+	recaveated := tuple.MustParse("docs:4#reader@user:4[somecaveat]")
+	uncaveated := tuple.MustParse("docs:4#reader@user:4")
+	permanent := tuple.MustParse("docs:5#reader@user:5")
+	expiring := tuple.WithExpiration(permanent, time.Now().Add(time.Hour))
+
+	diff := NewRevisionDiff(
+		[]*core.RelationTuple{touched, created, uncaveated, expiring},
+		[]*core.RelationTuple{deleted, touched.CloneVT(), recaveated, permanent},
+	)
+
+	require.Equal(t, []*core.RelationTuple{created, uncaveated, expiring}, diff.Created)
+	require.Equal(t, []*core.RelationTuple{deleted, recaveated, permanent}, diff.Deleted)
+}
@@ -20,6 +20,14 @@ var (
 		Buckets:   []float64{0.01, 0.1, 0.5, 1, 5, 10, 25, 60, 120},
 	})
 
+	gcReclaimedRowsHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "gc_reclaimed_rows",
+		Help:      "The number of rows reclaimed by a single pass of datastore garbage collection.",
+		Buckets:   prometheus.ExponentialBuckets(1, 10, 8),
+	})
+
 	gcRelationshipsCounter = prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "spicedb",
 		Subsystem: "datastore",
@@ -47,6 +55,7 @@ var (
 func RegisterGCMetrics() error {
 	for _, metric := range []prometheus.Collector{
 		gcDurationHistogram,
+		gcReclaimedRowsHistogram,
 		gcRelationshipsCounter,
 		gcTransactionsCounter,
 		gcNamespacesCounter,
@@ -138,6 +147,7 @@ func collect(gc GarbageCollector, window, timeout time.Duration) error {
 			Interface("collected", collected).
 			Msg("datastore garbage collection completed")
 
+		gcReclaimedRowsHistogram.Observe(float64(collected.Relationships + collected.Transactions + collected.Namespaces))
 		gcRelationshipsCounter.Add(float64(collected.Relationships))
 		gcTransactionsCounter.Add(float64(collected.Transactions))
 		gcNamespacesCounter.Add(float64(collected.Namespaces))
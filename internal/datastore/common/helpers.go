@@ -3,8 +3,12 @@ package common
 import (
 	"context"
 	"fmt"
+	"time"
 
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -31,6 +35,270 @@ func UpdateTuplesInDatastore(ctx context.Context, ds datastore.Datastore, update
 	})
 }
 
+// UpdateRelationshipsCaveat sets the caveat of all live relationships matching the filter to the
+// given caveat, or removes the caveat from them if the caveat is nil, returning the number of
+// relationships that were changed. The named caveat must exist.
+//
+// The relationships are rewritten within the given transaction, so they remain visible at every
+// revision: readers observe either the previous caveat or the new one.
+func UpdateRelationshipsCaveat(
+	ctx context.Context,
+	rwt datastore.ReadWriteTransaction,
+	filter *v1.RelationshipFilter,
+	caveat *core.ContextualizedCaveat,
+) (uint64, error) {
+	if caveat != nil {
+		if _, _, err := rwt.ReadCaveatByName(ctx, caveat.CaveatName); err != nil {
+			return 0, err
+		}
+	}
+
+	iter, err := rwt.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(filter))
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	var updates []*core.RelationTupleUpdate
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if proto.Equal(tpl.Caveat, caveat) {
+			continue
+		}
+
+		updated := tpl.CloneVT()
+		updated.Caveat = caveat.CloneVT()
+		updates = append(updates, tuple.Touch(updated))
+	}
+	if iter.Err() != nil {
+		return 0, iter.Err()
+	}
+
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	if err := rwt.WriteRelationships(ctx, updates); err != nil {
+		return 0, err
+	}
+
+	return uint64(len(updates)), nil
+}
+
+// EnsureNoRelationshipsForNamespaces returns an ErrNamespaceHasRelationships for the first of the
+// given namespaces that is referenced by a live relationship, either as the resource type or as
+// the subject type.
+func EnsureNoRelationshipsForNamespaces(ctx context.Context, reader datastore.Reader, nsNames ...string) error {
+	for _, nsName := range nsNames {
+		found, err := datastore.NamespaceHasRelationships(ctx, reader, nsName)
+		if err != nil {
+			return err
+		}
+
+		// The relationships are only counted for the error.
+		if found {
+			count, err := countRelationshipsReferencingNamespace(ctx, reader, nsName)
+			if err != nil {
+				return err
+			}
+			return datastore.NewNamespaceHasRelationshipsErr(nsName, count)
+		}
+	}
+
+	return nil
+}
+
+func countRelationshipsReferencingNamespace(ctx context.Context, reader datastore.Reader, nsName string) (uint64, error) {
+	var count uint64
+
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: nsName})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		count++
+	}
+	if iter.Err() != nil {
+		return 0, iter.Err()
+	}
+
+	reverseIter, err := reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{SubjectType: nsName})
+	if err != nil {
+		return 0, err
+	}
+	defer reverseIter.Close()
+
+	for tpl := reverseIter.Next(); tpl != nil; tpl = reverseIter.Next() {
+		// Relationships with both the resource and subject in the namespace were already counted.
+		if tpl.ResourceAndRelation.Namespace != nsName {
+			count++
+		}
+	}
+	if reverseIter.Err() != nil {
+		return 0, reverseIter.Err()
+	}
+
+	return count, nil
+}
+
+// RemoveSubject deletes, in a single transaction, all live relationships whose subject is exactly
+// the given subject, across all resource types, returning the number of relationships deleted and
+// the revision at which they were deleted. This is typically used to remove a user entirely, such
+// as for compliance with a data removal request.
+func RemoveSubject(ctx context.Context, ds datastore.Datastore, subject *core.ObjectAndRelation) (uint64, datastore.Revision, error) {
+	var deleted uint64
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		count, err := rwt.DeleteRelationshipsForSubject(ctx, subject)
+		deleted = count
+		return err
+	})
+	if err != nil {
+		return 0, datastore.NoRevision, err
+	}
+
+	return deleted, revision, nil
+}
+
+// PreviewRemoveSubject returns the number of live relationships at the head revision which
+// RemoveSubject would delete for the given subject, without deleting them, along with the
+// revision at which they were counted.
+func PreviewRemoveSubject(ctx context.Context, ds datastore.Datastore, subject *core.ObjectAndRelation) (uint64, datastore.Revision, error) {
+	revision, err := ds.HeadRevision(ctx)
+	if err != nil {
+		return 0, datastore.NoRevision, err
+	}
+
+	count, err := CountRelationshipsForSubject(ctx, ds.SnapshotReader(revision), subject)
+	if err != nil {
+		return 0, datastore.NoRevision, err
+	}
+
+	return count, revision, nil
+}
+
+// CountRelationshipsForSubject returns the number of live relationships whose subject is exactly
+// the given subject.
+func CountRelationshipsForSubject(ctx context.Context, reader datastore.Reader, subject *core.ObjectAndRelation) (uint64, error) {
+	var count uint64
+	err := forEachRelationshipForSubject(ctx, reader, subject, func(*core.RelationTuple) {
+		count++
+	})
+	return count, err
+}
+
+// DeleteRelationshipsForSubject deletes all live relationships whose subject is exactly the given
+// subject, returning the number deleted. It is the implementation of
+// ReadWriteTransaction.DeleteRelationshipsForSubject for datastores which do not provide a more
+// efficient one.
+func DeleteRelationshipsForSubject(ctx context.Context, rwt datastore.ReadWriteTransaction, subject *core.ObjectAndRelation) (uint64, error) {
+	var mutations []*core.RelationTupleUpdate
+	err := forEachRelationshipForSubject(ctx, rwt, subject, func(tpl *core.RelationTuple) {
+		mutations = append(mutations, tuple.Delete(tpl))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(mutations) == 0 {
+		return 0, nil
+	}
+
+	if err := rwt.WriteRelationships(ctx, mutations); err != nil {
+		return 0, err
+	}
+
+	return uint64(len(mutations)), nil
+}
+
+func forEachRelationshipForSubject(ctx context.Context, reader datastore.Reader, subject *core.ObjectAndRelation, fn func(tpl *core.RelationTuple)) error {
+	relationFilter := datastore.SubjectRelationFilter{}
+	if subject.Relation == datastore.Ellipsis || subject.Relation == "" {
+		relationFilter = relationFilter.WithEllipsisRelation()
+	} else {
+		relationFilter = relationFilter.WithNonEllipsisRelation(subject.Relation)
+	}
+
+	iter, err := reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        subject.Namespace,
+		OptionalSubjectIds: []string{subject.ObjectId},
+		RelationFilter:     relationFilter,
+	})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		fn(tpl)
+	}
+	return iter.Err()
+}
+
+// MergeSubject repoints, in a single transaction, all live relationships whose subject is exactly
+// oldSubject to newSubject, across all resource types, returning the number of relationships
+// repointed and the revision at which they were. This is typically used when two accounts are
+// merged into one. Relationships which newSubject already has are kept as they are.
+func MergeSubject(ctx context.Context, ds datastore.Datastore, oldSubject, newSubject *core.ObjectAndRelation) (uint64, datastore.Revision, error) {
+	var rewritten uint64
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		count, err := rwt.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+		rewritten = count
+		return err
+	})
+	if err != nil {
+		return 0, datastore.NoRevision, err
+	}
+
+	return rewritten, revision, nil
+}
+
+// RewriteRelationshipsSubject repoints all live relationships whose subject is exactly oldSubject
+// to newSubject, returning the number repointed. It is the implementation of
+// ReadWriteTransaction.RewriteRelationshipsSubject for datastores which do not provide a more
+// efficient one. Each relationship is deleted and recreated with the new subject and the same
+// caveat and expiration.
+func RewriteRelationshipsSubject(ctx context.Context, rwt datastore.ReadWriteTransaction, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	if proto.Equal(oldSubject, newSubject) {
+		return 0, nil
+	}
+
+	var existing []*core.RelationTuple
+	err := forEachRelationshipForSubject(ctx, rwt, oldSubject, func(tpl *core.RelationTuple) {
+		existing = append(existing, tpl)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(existing) == 0 {
+		return 0, nil
+	}
+
+	mutations := make([]*core.RelationTupleUpdate, 0, len(existing)*2)
+	for _, tpl := range existing {
+		mutations = append(mutations, tuple.Delete(tpl))
+
+		// The clone keeps the caveat and expiration of the relationship being repointed.
+		rewritten := tpl.CloneVT()
+		rewritten.Subject = newSubject.CloneVT()
+
+		exists, err := datastore.RelationshipExists(ctx, rwt, rewritten)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			mutations = append(mutations, tuple.Create(rewritten))
+		}
+	}
+
+	if err := rwt.WriteRelationships(ctx, mutations); err != nil {
+		return 0, err
+	}
+
+	return uint64(len(existing)), nil
+}
+
 // CreateRelationshipExistsError is an error returned when attempting to CREATE an already-existing
 // relationship.
 type CreateRelationshipExistsError struct {
@@ -69,3 +337,12 @@ func ContextualizedCaveatFrom(name string, context map[string]any) (*core.Contex
 	}
 	return caveat, nil
 }
+
+// ExpirationFrom converts the expiration of a relationship read from a datastore, if any, into
+// its expires_at timestamp.
+func ExpirationFrom(expiresAt *time.Time) *timestamppb.Timestamp {
+	if expiresAt == nil {
+		return nil
+	}
+	return timestamppb.New(*expiresAt)
+}
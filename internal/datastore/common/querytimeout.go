@@ -0,0 +1,23 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+type queryTimeoutKey struct{}
+
+// ContextWithQueryTimeout returns a context which overrides the statement timeout configured on
+// the datastore for queries issued with it, such as for reads which are known to be expensive.
+// A timeout of zero disables the statement timeout entirely.
+//
+// Only datastores which support a statement timeout make use of the override.
+func ContextWithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// QueryTimeoutFromContext returns the statement timeout override set on the context, if any.
+func QueryTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration)
+	return timeout, ok
+}
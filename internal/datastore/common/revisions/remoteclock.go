@@ -100,3 +100,30 @@ func (rcr *RemoteClockRevisions) CheckRevision(ctx context.Context, dsRevision d
 
 	return nil
 }
+
+// RevisionAtTime returns the revision representing the specified point in time. As revisions
+// for these datastores are timestamps, any point in time within the GC window maps directly
+// to a revision; points in the future are clamped to the current time.
+func (rcr *RemoteClockRevisions) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "RevisionAtTime")
+	defer span.End()
+
+	now, err := rcr.nowFunc(ctx)
+	if err != nil {
+		return revision.NoRevision, err
+	}
+
+	nowNanos := now.IntPart()
+	atNanos := at.UnixNano()
+
+	if atNanos < (nowNanos - rcr.gcWindowNanos) {
+		log.Debug().Stringer("now", now).Time("at", at).Msg("timestamp before gc window")
+		return revision.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	if atNanos >= nowNanos {
+		return now, nil
+	}
+
+	return revision.NewFromDecimal(decimal.NewFromInt(atNanos)), nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 )
 
@@ -152,3 +153,49 @@ func TestRemoteClockCheckRevisions(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoteClockRevisionAtTime(t *testing.T) {
+	testCases := []struct {
+		name             string
+		gcWindow         time.Duration
+		currentTime      int64
+		atSeconds        int64
+		expectedSeconds  int64
+		expectStaleError bool
+	}{
+		{"now", 1 * time.Hour, 12345, 12345, 12345, false},
+		{"recent past", 1 * time.Hour, 12345, 12000, 12000, false},
+		{"future is clamped", 1 * time.Hour, 12345, 12400, 12345, false},
+		{"before gc window", 1 * time.Second, 12345, 12343, 0, true},
+		{"very old", 1 * time.Hour, 12345, 8744, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			rcr := NewRemoteClockRevisions(tc.gcWindow, 0, 0, 0)
+
+			remoteClock := clock.NewMock()
+			rcr.clockFn = remoteClock
+			rcr.SetNowFunc(func(ctx context.Context) (revision.Decimal, error) {
+				return revision.NewFromDecimal(
+					decimal.NewFromInt(remoteClock.Now().UnixNano()),
+				), nil
+			})
+
+			remoteClock.Set(time.Unix(tc.currentTime, 0))
+
+			rev, err := rcr.RevisionAtTime(context.Background(), time.Unix(tc.atSeconds, 0))
+			if tc.expectStaleError {
+				require.ErrorAs(err, &datastore.ErrTimestampBeforeGCWindow{})
+				return
+			}
+
+			require.NoError(err)
+			require.True(revision.NewFromDecimal(
+				decimal.NewFromInt(tc.expectedSeconds * 1_000_000_000),
+			).Equal(rev))
+		})
+	}
+}
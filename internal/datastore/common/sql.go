@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math"
 	"runtime"
+	"sort"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
@@ -15,6 +17,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 var (
@@ -32,6 +35,10 @@ var (
 	// ObjIDKey is a tracing attribute representing the resource object ID.
 	ObjIDKey = attribute.Key("authzed.com/spicedb/sql/objId")
 
+	// ObjIDPrefixKey is a tracing attribute representing a prefix of the resource
+	// object ID.
+	ObjIDPrefixKey = attribute.Key("authzed.com/spicedb/sql/objIdPrefix")
+
 	// SubNamespaceNameKey is a tracing attribute representing the subject object
 	// type.
 	SubNamespaceNameKey = attribute.Key("authzed.com/spicedb/sql/subNamespaceName")
@@ -85,6 +92,35 @@ func (sqf SchemaQueryFilterer) FilterToResourceType(resourceType string) SchemaQ
 	return sqf
 }
 
+// FilterToResourceTypes returns a new SchemaQueryFilterer that is limited to resources of any of
+// the specified types.
+func (sqf SchemaQueryFilterer) FilterToResourceTypes(resourceTypes []string) SchemaQueryFilterer {
+	if len(resourceTypes) == 0 {
+		panic("must specify at least one resource type")
+	}
+
+	inClause := fmt.Sprintf("%s IN (", sqf.schema.ColNamespace)
+	args := make([]any, 0, len(resourceTypes))
+
+	for index, resourceType := range resourceTypes {
+		if len(resourceType) == 0 {
+			panic("got empty resource type")
+		}
+
+		if index > 0 {
+			inClause += ", "
+		}
+
+		inClause += "?"
+
+		args = append(args, resourceType)
+		sqf.tracerAttributes = append(sqf.tracerAttributes, ObjNamespaceNameKey.String(resourceType))
+	}
+
+	sqf.queryBuilder = sqf.queryBuilder.Where(inClause+")", args...)
+	return sqf
+}
+
 // FilterToResourceID returns a new SchemaQueryFilterer that is limited to resources with the
 // specified ID.
 func (sqf SchemaQueryFilterer) FilterToResourceID(objectID string) SchemaQueryFilterer {
@@ -123,6 +159,17 @@ func (sqf SchemaQueryFilterer) FilterToResourceIDs(resourceIds []string) SchemaQ
 	return sqf
 }
 
+// FilterToResourceIDPrefix returns a new SchemaQueryFilterer that is limited to resources whose
+// ID starts with the specified prefix. LIKE metacharacters in the prefix are matched literally.
+func (sqf SchemaQueryFilterer) FilterToResourceIDPrefix(prefix string) SchemaQueryFilterer {
+	sqf.queryBuilder = sqf.queryBuilder.Where(sq.Like{sqf.schema.ColObjectID: likeEscaper.Replace(prefix) + "%"})
+	sqf.tracerAttributes = append(sqf.tracerAttributes, ObjIDPrefixKey.String(prefix))
+	return sqf
+}
+
+// likeEscaper escapes the metacharacters of a LIKE pattern, using the default escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // FilterToRelation returns a new SchemaQueryFilterer that is limited to resources with the
 // specified relation.
 func (sqf SchemaQueryFilterer) FilterToRelation(relation string) SchemaQueryFilterer {
@@ -144,6 +191,10 @@ func (sqf SchemaQueryFilterer) FilterWithRelationshipsFilter(filter datastore.Re
 		sqf = sqf.FilterToResourceIDs(filter.OptionalResourceIds)
 	}
 
+	if filter.OptionalResourceIDPrefix != "" {
+		sqf = sqf.FilterToResourceIDPrefix(filter.OptionalResourceIDPrefix)
+	}
+
 	if filter.OptionalSubjectsFilter != nil {
 		sqf = sqf.FilterWithSubjectsFilter(*filter.OptionalSubjectsFilter)
 	}
@@ -157,6 +208,9 @@ func (sqf SchemaQueryFilterer) FilterWithRelationshipsFilter(filter datastore.Re
 
 // FilterWithSubjectsFilter returns a new SchemaQueryFilterer that is limited to resources with
 // subjects that match the specified filter.
+//
+// The subject columns are filtered in the order (namespace, object ID, relation), matching the
+// prefix of the reverse indexes on the relationship tables.
 func (sqf SchemaQueryFilterer) FilterWithSubjectsFilter(filter datastore.SubjectsFilter) SchemaQueryFilterer {
 	sqf.queryBuilder = sqf.queryBuilder.Where(sq.Eq{sqf.schema.ColUsersetNamespace: filter.SubjectType})
 	sqf.tracerAttributes = append(sqf.tracerAttributes, SubNamespaceNameKey.String(filter.SubjectType))
@@ -185,7 +239,16 @@ func (sqf SchemaQueryFilterer) FilterWithSubjectsFilter(filter datastore.Subject
 			sqf.tracerAttributes = append(sqf.tracerAttributes, SubObjectIDKey.String(subjectID))
 		}
 
-		sqf.queryBuilder = sqf.queryBuilder.Where(inClause+")", args...)
+		if filter.IncludeWildcardSubjects {
+			// The subject type is already filtered above, so only the wildcard ID need be matched.
+			sqf.queryBuilder = sqf.queryBuilder.Where(sq.Or{
+				sq.Expr(inClause+")", args...),
+				sq.Eq{sqf.schema.ColUsersetObjectID: tuple.PublicWildcard},
+			})
+			sqf.tracerAttributes = append(sqf.tracerAttributes, SubObjectIDKey.String(tuple.PublicWildcard))
+		} else {
+			sqf.queryBuilder = sqf.queryBuilder.Where(inClause+")", args...)
+		}
 	}
 
 	if !filter.RelationFilter.IsEmpty() {
@@ -257,7 +320,7 @@ func (sqf SchemaQueryFilterer) filterToUsersets(usersets []*core.ObjectAndRelati
 		orClause = append(orClause, sq.Eq{
 			sqf.schema.ColUsersetNamespace: userset.Namespace,
 			sqf.schema.ColUsersetObjectID:  userset.ObjectId,
-			sqf.schema.ColUsersetRelation:  userset.Relation,
+			sqf.schema.ColUsersetRelation:  stringz.DefaultEmpty(userset.Relation, datastore.Ellipsis),
 		})
 	}
 
@@ -273,6 +336,42 @@ func (sqf SchemaQueryFilterer) limit(limit uint64) SchemaQueryFilterer {
 	return sqf
 }
 
+// orderBy returns a new SchemaQueryFilterer which returns its results in the specified order.
+func (sqf SchemaQueryFilterer) orderBy(order options.SortOrder) SchemaQueryFilterer {
+	resourceColumns := []string{sqf.schema.ColNamespace, sqf.schema.ColObjectID, sqf.schema.ColRelation}
+	subjectColumns := []string{sqf.schema.ColUsersetNamespace, sqf.schema.ColUsersetObjectID, sqf.schema.ColUsersetRelation}
+
+	var columns []string
+	switch order {
+	case options.Unsorted:
+		return sqf
+	case options.ByResource:
+		columns = append(resourceColumns, subjectColumns...)
+	case options.ByRelation:
+		columns = append([]string{sqf.schema.ColRelation, sqf.schema.ColNamespace, sqf.schema.ColObjectID}, subjectColumns...)
+	case options.BySubject:
+		columns = append(subjectColumns, resourceColumns...)
+	default:
+		panic(fmt.Sprintf("unknown sort order: %d", order))
+	}
+
+	sqf.queryBuilder = sqf.queryBuilder.OrderBy(columns...)
+	return sqf
+}
+
+// QueryBuilder returns the query built by the filterer, with the usersets, sort order and limit of
+// the given options applied, for queries which select more than the relationship columns and so
+// cannot be executed by a TupleQuerySplitter. The usersets are not split into batches.
+func (sqf SchemaQueryFilterer) QueryBuilder(opts ...options.QueryOptionsOption) sq.SelectBuilder {
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	sqf = sqf.filterToUsersets(queryOpts.Usersets).orderBy(queryOpts.Sort)
+	if queryOpts.Limit != nil {
+		sqf = sqf.limit(*queryOpts.Limit)
+	}
+	return sqf.queryBuilder
+}
+
 // TupleQuerySplitter is a tuple query runner shared by SQL implementations of the datastore.
 type TupleQuerySplitter struct {
 	Executor         ExecuteQueryFunc
@@ -296,8 +395,13 @@ func (tqs TupleQuerySplitter) SplitAndExecuteQuery(
 		remainingLimit = int(*queryOpts.Limit)
 	}
 
+	query = query.orderBy(queryOpts.Sort)
+
+	batchCount := 0
 	remainingUsersets := queryOpts.Usersets
 	for remaining := 1; remaining > 0; remaining = len(remainingUsersets) {
+		batchCount++
+
 		upperBound := uint16(len(remainingUsersets))
 		if upperBound > tqs.UsersetBatchSize {
 			upperBound = tqs.UsersetBatchSize
@@ -324,6 +428,16 @@ func (tqs TupleQuerySplitter) SplitAndExecuteQuery(
 		remainingUsersets = remainingUsersets[upperBound:]
 	}
 
+	// Each batch is sorted by the datastore, so results from multiple batches must be merged.
+	if queryOpts.Sort != options.Unsorted && batchCount > 1 {
+		sort.SliceStable(tuples, func(i, j int) bool {
+			return queryOpts.Sort.LessThan(tuples[i], tuples[j])
+		})
+		if len(tuples) > remainingLimit {
+			tuples = tuples[:remainingLimit]
+		}
+	}
+
 	iter := datastore.NewSliceRelationshipIterator(tuples)
 	runtime.SetFinalizer(iter, datastore.BuildFinalizerFunction())
 	return iter, nil
@@ -9,6 +9,7 @@ import (
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/stretchr/testify/require"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -44,6 +45,14 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE object_id IN (?, ?)",
 			[]any{"someresourceid", "anotherresourceid"},
 		},
+		{
+			"resource ID prefix filter",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceIDPrefix(`2024_50%\`)
+			},
+			"SELECT * WHERE object_id LIKE ?",
+			[]any{`2024\_50\%\\%`},
+		},
 		{
 			"resource type filter",
 			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
@@ -52,6 +61,14 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE ns = ?",
 			[]any{"sometype"},
 		},
+		{
+			"resource types filter",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceTypes([]string{"sometype", "anothertype"})
+			},
+			"SELECT * WHERE ns IN (?, ?)",
+			[]any{"sometype", "anothertype"},
+		},
 		{
 			"resource filter",
 			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
@@ -135,6 +152,18 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE subject_ns = ? AND subject_object_id IN (?, ?)",
 			[]any{"somesubjectype", "somesubjectid", "anothersubjectid"},
 		},
+		{
+			"subjects filter with IDs and wildcard",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterWithSubjectsFilter(datastore.SubjectsFilter{
+					SubjectType:             "somesubjectype",
+					OptionalSubjectIds:      []string{"somesubjectid", "anothersubjectid"},
+					IncludeWildcardSubjects: true,
+				})
+			},
+			"SELECT * WHERE subject_ns = ? AND (subject_object_id IN (?, ?) OR subject_object_id = ?)",
+			[]any{"somesubjectype", "somesubjectid", "anothersubjectid", "*"},
+		},
 		{
 			"subjects filter with single ellipsis relation",
 			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
@@ -180,6 +209,18 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE subject_ns = ? AND subject_object_id IN (?, ?) AND (subject_relation = ? OR subject_relation = ?)",
 			[]any{"somesubjectype", "somesubjectid", "anothersubjectid", "...", "somesubrel"},
 		},
+		{
+			"reverse query",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterWithSubjectsFilter(datastore.SubjectsFilter{
+					SubjectType:        "somesubjectype",
+					OptionalSubjectIds: []string{"somesubjectid"},
+					RelationFilter:     datastore.SubjectRelationFilter{}.WithEllipsisRelation(),
+				}).FilterToResourceType("someresourcetype").FilterToRelation("somerelation")
+			},
+			"SELECT * WHERE subject_ns = ? AND subject_object_id IN (?) AND subject_relation = ? AND ns = ? AND relation = ?",
+			[]any{"somesubjectype", "somesubjectid", "...", "someresourcetype", "somerelation"},
+		},
 		{
 			"v1 subject filter with namespace",
 			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
@@ -287,6 +328,38 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE ns = ? AND relation = ? AND object_id IN (?, ?) AND subject_ns = ? AND subject_object_id IN (?, ?) AND (subject_relation = ? OR subject_relation = ?)",
 			[]any{"someresourcetype", "somerelation", "someid", "anotherid", "somesubjectype", "somesubjectid", "anothersubjectid", "...", "somesubrel"},
 		},
+		{
+			"sorted by resource",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceType("someresourcetype").orderBy(options.ByResource)
+			},
+			"SELECT * WHERE ns = ? ORDER BY ns, object_id, relation, subject_ns, subject_object_id, subject_relation",
+			[]any{"someresourcetype"},
+		},
+		{
+			"sorted by relation",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceType("someresourcetype").orderBy(options.ByRelation)
+			},
+			"SELECT * WHERE ns = ? ORDER BY relation, ns, object_id, subject_ns, subject_object_id, subject_relation",
+			[]any{"someresourcetype"},
+		},
+		{
+			"sorted by subject",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceType("someresourcetype").orderBy(options.BySubject)
+			},
+			"SELECT * WHERE ns = ? ORDER BY subject_ns, subject_object_id, subject_relation, ns, object_id, relation",
+			[]any{"someresourcetype"},
+		},
+		{
+			"unsorted",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceType("someresourcetype").orderBy(options.Unsorted)
+			},
+			"SELECT * WHERE ns = ?",
+			[]any{"someresourcetype"},
+		},
 	}
 
 	for _, test := range tests {
@@ -19,6 +19,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
 	"github.com/authzed/spicedb/internal/datastore/crdb/migrations"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -59,6 +60,10 @@ const (
 	colCaveatContextName = "caveat_name"
 	colCaveatContext     = "caveat_context"
 
+	// colMVCCTimestamp is the hidden column holding the HLC timestamp of the transaction which
+	// last wrote the row.
+	colMVCCTimestamp = "crdb_internal_mvcc_timestamp"
+
 	errUnableToInstantiate = "unable to instantiate datastore: %w"
 	errRevision            = "unable to find revision: %w"
 
@@ -251,7 +256,7 @@ func (cds *crdbDatastore) SnapshotReader(rev datastore.Revision) datastore.Reade
 	}
 
 	querySplitter := common.TupleQuerySplitter{
-		Executor:         pgxcommon.NewPGXExecutor(createTxFunc),
+		Executor:         pgxcommon.NewPGXExecutor(createTxFunc, 0, 0),
 		UsersetBatchSize: cds.usersetBatchSize,
 	}
 
@@ -263,7 +268,12 @@ func noCleanup(context.Context) {}
 func (cds *crdbDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	if options.NewRWTOptionsWithOptions(opts...).IdempotencyKey != "" {
+		return datastore.NoRevision, datastore.NewIdempotencyKeysUnsupportedErr(Engine)
+	}
+
 	var commitTimestamp revision.Decimal
 	if err := cds.execute(ctx, func(ctx context.Context) error {
 		return cds.pool.BeginTxFunc(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
@@ -272,7 +282,7 @@ func (cds *crdbDatastore) ReadWriteTx(
 			}
 
 			querySplitter := common.TupleQuerySplitter{
-				Executor:         pgxcommon.NewPGXExecutor(longLivedTx),
+				Executor:         pgxcommon.NewPGXExecutor(longLivedTx, 0, 0),
 				UsersetBatchSize: cds.usersetBatchSize,
 			}
 
@@ -344,6 +354,10 @@ func (cds *crdbDatastore) IsReady(ctx context.Context) (bool, error) {
 	return version == headMigration, nil
 }
 
+func (cds *crdbDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	return pgxcommon.CheckPoolHealth(ctx, cds.pool)
+}
+
 func (cds *crdbDatastore) Close() error {
 	cds.pool.Close()
 	return nil
@@ -34,7 +34,7 @@ import (
 
 func TestCRDBDatastore(t *testing.T) {
 	b := testdatastore.RunCRDBForTesting(t, "")
-	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+	tester := test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
 		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
 			ds, err := NewCRDBDatastore(
 				uri,
@@ -48,7 +48,14 @@ func TestCRDBDatastore(t *testing.T) {
 		})
 
 		return ds, nil
-	}))
+	})
+	test.All(t, tester)
+
+	// Rows are deleted outright, so a relationship read at a past revision has no deleting
+	// revision.
+	t.Run("RelationshipRevisionsDeletedAt", func(t *testing.T) {
+		test.RelationshipRevisionsDeletedAtTest(t, tester, false)
+	})
 }
 
 func TestCRDBDatastoreWithFollowerReads(t *testing.T) {
@@ -2,23 +2,27 @@ package crdb
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v4"
+	"github.com/shopspring/decimal"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/options"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
 const (
 	errUnableToReadConfig     = "unable to read namespace config: %w"
 	errUnableToListNamespaces = "unable to list namespaces: %w"
+	errUnableToReadRevisions  = "unable to read relationship revisions: %w"
 )
 
 var (
@@ -35,6 +39,18 @@ var (
 		colCaveatContext,
 	).From(tableTuple)
 
+	queryTuplesWithRevisions = psql.Select(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+		colCaveatContextName,
+		colCaveatContext,
+		colMVCCTimestamp,
+	).From(tableTuple)
+
 	schema = common.SchemaInformation{
 		ColNamespace:        colNamespace,
 		ColObjectID:         colObjectID,
@@ -85,7 +101,7 @@ func (cr *crdbReader) ReadNamespace(
 	return config, revisionFromTimestamp(timestamp), nil
 }
 
-func (cr *crdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (cr *crdbReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	var nsDefs []*core.NamespaceDefinition
 	if err := cr.execute(ctx, func(ctx context.Context) error {
 		tx, txCleanup, err := cr.txSource(ctx)
@@ -104,6 +120,7 @@ func (cr *crdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefi
 		return nil, fmt.Errorf(errUnableToListNamespaces, err)
 	}
 
+	nsDefs = options.NewListNamespacesOptionsWithOptions(opts...).Apply(nsDefs)
 	for _, nsDef := range nsDefs {
 		cr.addOverlapKey(nsDef.Name)
 	}
@@ -156,6 +173,96 @@ func (cr *crdbReader) QueryRelationships(
 	return iter, nil
 }
 
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader. Every write
+// of a row, including a touch, sets its MVCC timestamp to the commit timestamp of the writing
+// transaction, which is the revision of that transaction. Deleted rows are not retained, so
+// DeletedAt is always NoRevision.
+func (cr *crdbReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	query, args, err := common.NewSchemaQueryFilterer(schema, queryTuplesWithRevisions).
+		FilterWithRelationshipsFilter(filter).
+		QueryBuilder(opts...).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	var versions []datastore.RelationshipVersion
+	if err := cr.execute(ctx, func(ctx context.Context) error {
+		tx, txCleanup, err := cr.txSource(ctx)
+		if err != nil {
+			return err
+		}
+		defer txCleanup(ctx)
+
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		versions = nil
+		for rows.Next() {
+			tpl := &core.RelationTuple{
+				ResourceAndRelation: &core.ObjectAndRelation{},
+				Subject:             &core.ObjectAndRelation{},
+			}
+			var caveatName sql.NullString
+			var caveatContext map[string]any
+			var timestamp decimal.Decimal
+			if err := rows.Scan(
+				&tpl.ResourceAndRelation.Namespace,
+				&tpl.ResourceAndRelation.ObjectId,
+				&tpl.ResourceAndRelation.Relation,
+				&tpl.Subject.Namespace,
+				&tpl.Subject.ObjectId,
+				&tpl.Subject.Relation,
+				&caveatName,
+				&caveatContext,
+				&timestamp,
+			); err != nil {
+				return err
+			}
+
+			tpl.Caveat, err = common.ContextualizedCaveatFrom(caveatName.String, caveatContext)
+			if err != nil {
+				return err
+			}
+
+			versions = append(versions, datastore.RelationshipVersion{
+				Relationship: tpl,
+				CreatedAt:    revision.NewFromDecimal(timestamp),
+				DeletedAt:    datastore.NoRevision,
+			})
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	return versions, nil
+}
+
+func (cr *crdbReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).FilterToResourceTypes(resourceTypes)
+
+	if err := cr.execute(ctx, func(ctx context.Context) error {
+		iter, err = cr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
 func (cr *crdbReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -292,4 +399,7 @@ func (cr *crdbReader) addOverlapKey(namespace string) {
 	cr.keyer.addKey(cr.overlapKeySet, namespace)
 }
 
-var _ datastore.Reader = &crdbReader{}
+var (
+	_ datastore.Reader                      = &crdbReader{}
+	_ datastore.RelationshipRevisionsReader = &crdbReader{}
+)
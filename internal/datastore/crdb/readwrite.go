@@ -11,6 +11,7 @@ import (
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -86,6 +87,8 @@ var (
 )
 
 func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	mutations = datastore.CanonicalizeSubjectRelations(mutations)
+
 	bulkWrite := queryWriteTuple
 	var bulkWriteCount int64
 
@@ -95,6 +98,9 @@ func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations [
 	// Process the actual updates
 	for _, mutation := range mutations {
 		rel := mutation.Tuple
+		if mutation.Operation != core.RelationTupleUpdate_DELETE && mutation.Tuple.ExpiresAt != nil {
+			return datastore.NewRelationshipExpirationUnsupportedErr(Engine)
+		}
 
 		var caveatContext map[string]any
 		var caveatName string
@@ -225,6 +231,14 @@ func (rwt *crdbReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1
 	return nil
 }
 
+func (rwt *crdbReadWriteTXN) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	return common.DeleteRelationshipsForSubject(ctx, rwt, subject)
+}
+
+func (rwt *crdbReadWriteTXN) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	return common.RewriteRelationshipsSubject(ctx, rwt, oldSubject, newSubject)
+}
+
 func (rwt *crdbReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
 	query := queryWriteNamespace
 
@@ -250,7 +264,7 @@ func (rwt *crdbReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...
 	return nil
 }
 
-func (rwt *crdbReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt *crdbReadWriteTXN) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
 	// For each namespace, check they exist and collect predicates for the
 	// "WHERE" clause to delete the namespaces and associated tuples.
 	nsClauses := make([]sq.Sqlizer, 0, len(nsNames))
@@ -266,7 +280,13 @@ func (rwt *crdbReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...st
 
 		for _, nsName := range nsNames {
 			nsClauses = append(nsClauses, sq.Eq{colNamespace: nsName, colTimestamp: timestamp})
-			tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName})
+			tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName}, sq.Eq{colUsersetNamespace: nsName})
+		}
+	}
+
+	if delOption == datastore.DeleteNamespacesOnlyIfEmpty {
+		if err := common.EnsureNoRelationshipsForNamespaces(ctx, rwt, nsNames...); err != nil {
+			return err
 		}
 	}
 
@@ -114,6 +114,12 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 					}
 				}
 
+				// The changes through the resolved timestamp have all been emitted, unless one is
+				// still pending at the timestamp itself.
+				if !hasPendingAt(pendingChanges, resolved) {
+					common.SendCheckpoint(updates, resolved)
+				}
+
 				continue
 			}
 
@@ -183,3 +189,18 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 	}()
 	return updates, errs
 }
+
+// hasPendingAt returns whether any of the pending changes is at or before the revision.
+func hasPendingAt(pendingChanges map[string]*datastore.RevisionChanges, rev datastore.Revision) bool {
+	for _, pending := range pendingChanges {
+		if !pending.Revision.GreaterThan(rev) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffRevisions is not yet supported by the CockroachDB datastore.
+func (cds *crdbDatastore) DiffRevisions(_ context.Context, _, _ datastore.Revision) (*datastore.RevisionDiff, error) {
+	return nil, datastore.NewRevisionDiffUnsupportedErr(Engine)
+}
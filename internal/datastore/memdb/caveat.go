@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/go-memdb"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/util"
@@ -91,6 +92,45 @@ func (r *memdbReader) ListCaveats(_ context.Context, caveatNames ...string) ([]*
 	return caveats, nil
 }
 
+// ListCaveatsWithOptions lists the caveats matching the options, iterating them in name order.
+func (r *memdbReader) ListCaveatsWithOptions(_ context.Context, opts ...options.ListCaveatsOptionsOption) ([]*core.CaveatDefinition, error) {
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := options.NewListCaveatsOptionsWithOptions(opts...)
+
+	// The ID index is ordered by name, so the caveats are iterated in name order.
+	it, err := tx.LowerBound(tableCaveats, indexID)
+	if err != nil {
+		return nil, err
+	}
+
+	var caveats []*core.CaveatDefinition
+	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
+		if listOpts.CaveatLimit != nil && uint64(len(caveats)) >= *listOpts.CaveatLimit {
+			break
+		}
+
+		rawCaveat := foundRaw.(*caveat)
+		if !listOpts.Matches(rawCaveat.name) {
+			continue
+		}
+
+		definition, err := rawCaveat.Unwrap()
+		if err != nil {
+			return nil, err
+		}
+		caveats = append(caveats, definition)
+	}
+
+	return caveats, nil
+}
+
 func (rwt *memdbReadWriteTx) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
 	rwt.lockOrPanic()
 	defer rwt.Unlock()
@@ -137,3 +177,5 @@ func (rwt *memdbReadWriteTx) DeleteCaveats(ctx context.Context, names []string)
 	}
 	return nil
 }
+
+var _ datastore.CaveatPageLister = &memdbReader{}
@@ -0,0 +1,87 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// ValidateConsistency implements datastore.ConsistencyValidator.
+//
+// Relationships in memdb are not versioned by transaction, but held in a snapshot per revision,
+// so only the head snapshot is validated: no relationship may be stored more than once, and the
+// namespaces of every live relationship must be defined.
+func (mdb *memdbDatastore) ValidateConsistency(ctx context.Context) error {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.db == nil {
+		return fmt.Errorf("memdb datastore is already closed")
+	}
+
+	txn := mdb.db.Txn(false)
+	defer txn.Abort()
+
+	namespaceIt, err := txn.LowerBound(tableNamespace, indexID)
+	if err != nil {
+		return err
+	}
+
+	definedNamespaces := util.NewSet[string]()
+	for row := namespaceIt.Next(); row != nil; row = namespaceIt.Next() {
+		definedNamespaces.Add(row.(*namespace).name)
+	}
+
+	it, err := txn.LowerBound(tableRelationship, indexID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	foundRelationships := util.NewSet[string]()
+	var violations []datastore.ConsistencyViolation
+	for row := it.Next(); row != nil; row = it.Next() {
+		rel := row.(*relationship)
+
+		tpl, err := rel.RelationTuple()
+		if err != nil {
+			return err
+		}
+
+		if !foundRelationships.Add(rel.String()) {
+			violations = append(violations, datastore.ConsistencyViolation{
+				Relationship: tpl,
+				Reason:       "relationship is stored more than once",
+			})
+		}
+
+		if rel.expiredAt(now) {
+			continue
+		}
+
+		if !definedNamespaces.Has(rel.namespace) {
+			violations = append(violations, datastore.ConsistencyViolation{
+				Relationship: tpl,
+				Reason:       fmt.Sprintf("resource object definition `%s` is not defined", rel.namespace),
+			})
+		}
+
+		if !definedNamespaces.Has(rel.subjectNamespace) {
+			violations = append(violations, datastore.ConsistencyViolation{
+				Relationship: tpl,
+				Reason:       fmt.Sprintf("subject object definition `%s` is not defined", rel.subjectNamespace),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return datastore.NewConsistencyViolationsErr(violations)
+	}
+
+	return nil
+}
+
+var _ datastore.ConsistencyValidator = &memdbDatastore{}
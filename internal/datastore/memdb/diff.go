@@ -0,0 +1,134 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const errDiffRevisions = "unable to diff revisions: %w"
+
+func (mdb *memdbDatastore) DiffRevisions(ctx context.Context, startRevisionRaw, endRevisionRaw datastore.Revision) (*datastore.RevisionDiff, error) {
+	startRevision := startRevisionRaw.(revision.Decimal)
+	endRevision := endRevisionRaw.(revision.Decimal)
+
+	if startRevision.GreaterThan(endRevision) {
+		return nil, fmt.Errorf(errDiffRevisions, fmt.Errorf("start revision %s is after end revision %s", startRevision, endRevision))
+	}
+
+	changed, err := mdb.changedRelationships(startRevision, endRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	// The changelog only records the latest form of each relationship, so the form at each of the
+	// revisions is read from its snapshot.
+	startReader := mdb.SnapshotReader(startRevision)
+	endReader := mdb.SnapshotReader(endRevision)
+
+	var created, deleted []*core.RelationTuple
+	for _, tpl := range changed {
+		before, err := readRelationship(ctx, startReader, tpl)
+		if err != nil {
+			return nil, err
+		}
+		if before != nil {
+			deleted = append(deleted, before)
+		}
+
+		after, err := readRelationship(ctx, endReader, tpl)
+		if err != nil {
+			return nil, err
+		}
+		if after != nil {
+			created = append(created, after)
+		}
+	}
+
+	return common.NewRevisionDiff(created, deleted), nil
+}
+
+// changedRelationships returns each relationship written or deleted by transactions after the
+// start revision, up to and including the end revision, in the order they were first changed.
+func (mdb *memdbDatastore) changedRelationships(startRevision, endRevision revision.Decimal) ([]*core.RelationTuple, error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if err := mdb.checkRevisionLocalCallerMustLock(startRevision); err != nil {
+		return nil, err
+	}
+	if err := mdb.checkRevisionLocalCallerMustLock(endRevision); err != nil {
+		return nil, err
+	}
+
+	if mdb.db == nil {
+		return nil, fmt.Errorf("memdb datastore is already closed")
+	}
+
+	loadTxn := mdb.db.Txn(false)
+	defer loadTxn.Abort()
+
+	it, err := loadTxn.LowerBound(tableChangelog, indexRevision, startRevision.IntPart()+1)
+	if err != nil {
+		return nil, fmt.Errorf(errDiffRevisions, err)
+	}
+
+	seen := map[string]struct{}{}
+	var changed []*core.RelationTuple
+	for changeRaw := it.Next(); changeRaw != nil; changeRaw = it.Next() {
+		change := changeRaw.(*changelog)
+		if change.revisionNanos > endRevision.IntPart() {
+			break
+		}
+
+		for _, mutation := range change.changes.Changes {
+			key := tuple.StringWithoutCaveat(mutation.Tuple)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+			changed = append(changed, mutation.Tuple)
+		}
+	}
+
+	return changed, nil
+}
+
+// readRelationship returns the relationship with the same resource, relation and subject as the
+// given relationship, or nil if there is none.
+func readRelationship(ctx context.Context, reader datastore.Reader, tpl *core.RelationTuple) (*core.RelationTuple, error) {
+	relationFilter := datastore.SubjectRelationFilter{}
+	if tpl.Subject.Relation == datastore.Ellipsis || tpl.Subject.Relation == "" {
+		relationFilter = relationFilter.WithEllipsisRelation()
+	} else {
+		relationFilter = relationFilter.WithNonEllipsisRelation(tpl.Subject.Relation)
+	}
+
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             tpl.ResourceAndRelation.Namespace,
+		OptionalResourceIds:      []string{tpl.ResourceAndRelation.ObjectId},
+		OptionalResourceRelation: tpl.ResourceAndRelation.Relation,
+		OptionalSubjectsFilter: &datastore.SubjectsFilter{
+			SubjectType:        tpl.Subject.Namespace,
+			OptionalSubjectIds: []string{tpl.Subject.ObjectId},
+			RelationFilter:     relationFilter,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errDiffRevisions, err)
+	}
+	defer iter.Close()
+
+	found := iter.Next()
+	if iter.Err() != nil {
+		return nil, fmt.Errorf(errDiffRevisions, iter.Err())
+	}
+
+	return found, nil
+}
@@ -0,0 +1,96 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const errReadHistory = "unable to read relationship history: %w"
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader.
+//
+// Relationships in memdb are held in a snapshot per revision, so the versions are rebuilt from
+// the changelog: each TOUCH of the relationship replaces the version live before it, and each
+// DELETE ends it. Versions deleted before the GC window are omitted.
+func (mdb *memdbDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.db == nil {
+		return nil, fmt.Errorf("memdb datastore is already closed")
+	}
+
+	loadTxn := mdb.db.Txn(false)
+	defer loadTxn.Abort()
+
+	it, err := loadTxn.LowerBound(tableChangelog, indexRevision, int64(0))
+	if err != nil {
+		return nil, fmt.Errorf(errReadHistory, err)
+	}
+
+	key := tuple.StringWithoutCaveat(withEllipsisSubjectRelation(tpl))
+
+	var versions []datastore.RelationshipVersion
+	var live *datastore.RelationshipVersion
+	for changeRaw := it.Next(); changeRaw != nil; changeRaw = it.Next() {
+		change := changeRaw.(*changelog)
+		changeRevision := revision.NewFromDecimal(decimal.NewFromInt(change.revisionNanos))
+
+		for _, mutation := range change.changes.Changes {
+			if tuple.StringWithoutCaveat(mutation.Tuple) != key {
+				continue
+			}
+
+			if live != nil {
+				live.DeletedAt = changeRevision
+				versions = append(versions, *live)
+				live = nil
+			}
+
+			if mutation.Operation == core.RelationTupleUpdate_TOUCH {
+				live = &datastore.RelationshipVersion{
+					Relationship: mutation.Tuple,
+					CreatedAt:    changeRevision,
+					DeletedAt:    datastore.NoRevision,
+				}
+			}
+		}
+	}
+
+	if live != nil {
+		versions = append(versions, *live)
+	}
+
+	now := revisionFromTimestamp(time.Now().UTC())
+	oldest := revision.NewFromDecimal(now.Add(mdb.negativeGCWindow))
+	retained := make([]datastore.RelationshipVersion, 0, len(versions))
+	for _, version := range versions {
+		if version.DeletedAt != datastore.NoRevision && version.DeletedAt.(revision.Decimal).LessThan(oldest) {
+			continue
+		}
+		retained = append(retained, version)
+	}
+
+	return retained, nil
+}
+
+func withEllipsisSubjectRelation(tpl *core.RelationTuple) *core.RelationTuple {
+	if tpl.Subject.Relation != "" {
+		return tpl
+	}
+
+	return &core.RelationTuple{
+		ResourceAndRelation: tpl.ResourceAndRelation,
+		Subject:             tuple.ObjectAndRelation(tpl.Subject.Namespace, tpl.Subject.ObjectId, datastore.Ellipsis),
+	}
+}
+
+var _ datastore.RelationshipHistoryReader = &memdbDatastore{}
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/go-memdb"
 	"github.com/shopspring/decimal"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -58,12 +59,14 @@ func NewMemdbDatastore(
 
 	negativeGCWindow := decimal.NewFromInt(gcWindow.Nanoseconds()).Mul(decimal.NewFromInt(-1))
 
+	now := time.Now().UTC()
 	return &memdbDatastore{
 		db: db,
 		revisions: []snapshot{
 			{
-				revision: revisionFromTimestamp(time.Now().UTC()).Decimal,
-				db:       db,
+				revision:    revisionFromTimestamp(now).Decimal,
+				committedAt: now,
+				db:          db,
 			},
 		},
 
@@ -82,6 +85,9 @@ type memdbDatastore struct {
 	revisions      []snapshot
 	activeWriteTxn *memdb.Txn
 
+	// writesInProgress is the number of calls to ReadWriteTx which have not yet returned.
+	writesInProgress int
+
 	negativeGCWindow   decimal.Decimal
 	quantizationPeriod decimal.Decimal
 	watchBufferLength  uint16
@@ -89,8 +95,9 @@ type memdbDatastore struct {
 }
 
 type snapshot struct {
-	revision decimal.Decimal
-	db       *memdb.MemDB
+	revision    decimal.Decimal
+	committedAt time.Time
+	db          *memdb.MemDB
 }
 
 func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datastore.Reader {
@@ -100,11 +107,11 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 	defer mdb.RUnlock()
 
 	if len(mdb.revisions) == 0 {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready"), time.Time{}}
 	}
 
 	if err := mdb.checkRevisionLocalCallerMustLock(dr); err != nil {
-		return &memdbReader{nil, nil, err}
+		return &memdbReader{nil, nil, err, time.Time{}}
 	}
 
 	revIndex := sort.Search(len(mdb.revisions), func(i int) bool {
@@ -118,7 +125,7 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 
 	rev := mdb.revisions[revIndex]
 	if rev.db == nil {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed"), time.Time{}}
 	}
 
 	roTxn := rev.db.Txn(false)
@@ -126,13 +133,36 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 		return roTxn, nil
 	}
 
-	return &memdbReader{noopTryLocker{}, txSrc, nil}
+	return &memdbReader{noopTryLocker{}, txSrc, nil, revisionTime(dr)}
 }
 
 func (mdb *memdbDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+	if config.IdempotencyKey != "" {
+		existing, err := mdb.revisionForIdempotencyKey(config.IdempotencyKey)
+		if err != nil {
+			return datastore.NoRevision, err
+		}
+		if existing != datastore.NoRevision {
+			return existing, nil
+		}
+	}
+
+	// Watches only checkpoint while no write is in progress, as its revision is allocated before
+	// it commits.
+	mdb.Lock()
+	mdb.writesInProgress++
+	mdb.Unlock()
+	defer func() {
+		mdb.Lock()
+		mdb.writesInProgress--
+		mdb.Unlock()
+	}()
+
 	for i := 0; i < numRetries; i++ {
 		var tx *memdb.Txn
 		createTxOnce := sync.Once{}
@@ -161,8 +191,16 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		}
 
 		newRevision := mdb.newRevisionID()
-		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil}, newRevision}
-		if err := f(rwt); err != nil {
+		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil, revisionTime(newRevision)}, newRevision}
+		err := f(rwt)
+		if err == nil && config.IdempotencyKey != "" {
+			// Ensure a transaction exists, as the idempotency key is recorded in the changelog.
+			_, err = txSrc()
+		}
+		if err == nil {
+			err = mdb.collectExpiredRelationships(txSrc, revisionTime(newRevision))
+		}
+		if err != nil {
 			mdb.Lock()
 			if tx != nil {
 				tx.Abort()
@@ -193,8 +231,19 @@ func (mdb *memdbDatastore) ReadWriteTx(
 			Changes:  nil,
 		}
 		if tx != nil {
+			if config.IdempotencyKey != "" {
+				// Another transaction with the same key may have committed while this one was running.
+				existing, err := mdb.revisionForIdempotencyKeyLocalCallerMustLock(tx, config.IdempotencyKey)
+				if err != nil || existing != datastore.NoRevision {
+					tx.Abort()
+					mdb.activeWriteTxn = nil
+					return existing, err
+				}
+			}
+
 			for _, change := range tx.Changes() {
-				if change.Table == tableRelationship {
+				switch change.Table {
+				case tableRelationship:
 					if change.After != nil {
 						rt, err := change.After.(*relationship).RelationTuple()
 						if err != nil {
@@ -215,12 +264,35 @@ func (mdb *memdbDatastore) ReadWriteTx(
 							Tuple:     rt,
 						})
 					}
+
+				case tableNamespace:
+					if change.After != nil {
+						nsDef := &corev1.NamespaceDefinition{}
+						if err := nsDef.UnmarshalVT(change.After.(*namespace).configBytes); err != nil {
+							return datastore.NoRevision, err
+						}
+						newChanges.ChangedDefinitions = append(newChanges.ChangedDefinitions, nsDef)
+					} else if change.Before != nil {
+						newChanges.DeletedNamespaces = append(newChanges.DeletedNamespaces, change.Before.(*namespace).name)
+					}
+
+				case tableCaveats:
+					if change.After != nil {
+						caveatDef, err := change.After.(*caveat).Unwrap()
+						if err != nil {
+							return datastore.NoRevision, err
+						}
+						newChanges.ChangedDefinitions = append(newChanges.ChangedDefinitions, caveatDef)
+					} else if change.Before != nil {
+						newChanges.DeletedCaveats = append(newChanges.DeletedCaveats, change.Before.(*caveat).name)
+					}
 				}
 			}
 
 			change := &changelog{
-				revisionNanos: newRevision.IntPart(),
-				changes:       newChanges,
+				revisionNanos:  newRevision.IntPart(),
+				changes:        newChanges,
+				idempotencyKey: config.IdempotencyKey,
 			}
 			if err := tx.Insert(tableChangelog, change); err != nil {
 				return datastore.NoRevision, fmt.Errorf("error writing changelog: %w", err)
@@ -236,13 +308,81 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		}
 
 		snap := mdb.db.Snapshot()
-		mdb.revisions = append(mdb.revisions, snapshot{newRevision.Decimal, snap})
+		mdb.revisions = append(mdb.revisions, snapshot{newRevision.Decimal, time.Now().UTC(), snap})
 		return newRevision, nil
 	}
 
 	return datastore.NoRevision, errors.New("serialization max retries exceeded")
 }
 
+// collectExpiredRelationships deletes the relationships which have expired as of the time of
+// the transaction's revision. Expired relationships are not read at that revision or any later
+// one, and deleting them records their deletion in the changelog, from which they are reported
+// by Watch and DiffRevisions.
+func (mdb *memdbDatastore) collectExpiredRelationships(txSrc txFactory, at time.Time) error {
+	mdb.RLock()
+	db := mdb.db
+	mdb.RUnlock()
+	if db == nil {
+		return nil
+	}
+
+	// Check for expired relationships before starting a transaction, so that transactions which
+	// do not write are not recorded in the changelog.
+	expired, err := expiredRelationships(db.Txn(false), at)
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+
+	tx, err := txSrc()
+	if err != nil {
+		return err
+	}
+
+	expired, err = expiredRelationships(tx, at)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range expired {
+		if err := tx.Delete(tableRelationship, rel); err != nil {
+			return fmt.Errorf("error deleting expired relationship: %w", err)
+		}
+	}
+	return nil
+}
+
+func (mdb *memdbDatastore) revisionForIdempotencyKey(idempotencyKey string) (datastore.Revision, error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.db == nil {
+		return datastore.NoRevision, fmt.Errorf("datastore is closed")
+	}
+
+	return mdb.revisionForIdempotencyKeyLocalCallerMustLock(mdb.db.Txn(false), idempotencyKey)
+}
+
+// revisionForIdempotencyKeyLocalCallerMustLock returns the revision of the transaction committed
+// with the given idempotency key, or NoRevision if there is none within the GC window.
+func (mdb *memdbDatastore) revisionForIdempotencyKeyLocalCallerMustLock(tx *memdb.Txn, idempotencyKey string) (datastore.Revision, error) {
+	found, err := tx.First(tableChangelog, indexIdempotencyKey, idempotencyKey)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+	if found == nil {
+		return datastore.NoRevision, nil
+	}
+
+	existing := revision.NewFromDecimal(decimal.NewFromInt(found.(*changelog).revisionNanos))
+	if err := mdb.checkRevisionLocalCallerMustLock(existing); err != nil {
+		// The transaction has fallen outside of the GC window, so the key may be reused.
+		return datastore.NoRevision, nil
+	}
+
+	return existing, nil
+}
+
 func (mdb *memdbDatastore) IsReady(ctx context.Context) (bool, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
@@ -250,8 +390,22 @@ func (mdb *memdbDatastore) IsReady(ctx context.Context) (bool, error) {
 	return len(mdb.revisions) > 0, nil
 }
 
+func (mdb *memdbDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.db == nil {
+		return datastore.Unreachable(fmt.Errorf("datastore is closed"))
+	}
+
+	return datastore.Healthy()
+}
+
 func (mdb *memdbDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
+	return &datastore.Features{
+		Watch:       datastore.Feature{Enabled: true},
+		WatchSchema: datastore.Feature{Enabled: true},
+	}, nil
 }
 
 func (mdb *memdbDatastore) Close() error {
@@ -260,10 +414,12 @@ func (mdb *memdbDatastore) Close() error {
 
 	// TODO Make this nil once we have removed all access to closed datastores
 	if db := mdb.db; db != nil {
+		now := time.Now().UTC()
 		mdb.revisions = []snapshot{
 			{
-				revision: revisionFromTimestamp(time.Now().UTC()).Decimal,
-				db:       db,
+				revision:    revisionFromTimestamp(now).Decimal,
+				committedAt: now,
+				db:          db,
 			},
 		}
 	} else {
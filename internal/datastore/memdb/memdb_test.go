@@ -15,6 +15,7 @@ import (
 	test "github.com/authzed/spicedb/pkg/datastore/test"
 	ns "github.com/authzed/spicedb/pkg/namespace"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 type memDBTest struct{}
@@ -27,6 +28,11 @@ func TestMemdbDatastore(t *testing.T) {
 	test.All(t, memDBTest{})
 }
 
+func TestRelationshipRevisionsDeletedAt(t *testing.T) {
+	// Past revisions are read from their snapshot, which does not record later deletions.
+	test.RelationshipRevisionsDeletedAtTest(t, memDBTest{}, false)
+}
+
 func TestConcurrentWritePanic(t *testing.T) {
 	require := require.New(t)
 
@@ -77,3 +83,41 @@ func TestConcurrentWritePanic(t *testing.T) {
 	}, 1*time.Second, 10*time.Millisecond)
 	require.ErrorIs(err, recoverErr)
 }
+
+func TestValidateConsistencyReportsViolations(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	ctx := context.Background()
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, ns.Namespace("user"), ns.Namespace("document", ns.Relation("viewer", nil)))
+	})
+	require.NoError(err)
+
+	validator := ds.(datastore.ConsistencyValidator)
+	require.NoError(validator.ValidateConsistency(ctx))
+
+	// Store a relationship over an undefined namespace, bypassing the checks made on writes.
+	mdb := ds.(*memdbDatastore)
+	txn := mdb.db.Txn(true)
+	require.NoError(txn.Insert(tableRelationship, &relationship{
+		namespace:        "folder",
+		resourceID:       "somefolder",
+		relation:         "viewer",
+		subjectNamespace: "user",
+		subjectObjectID:  "tom",
+		subjectRelation:  datastore.Ellipsis,
+	}))
+	txn.Commit()
+
+	err = validator.ValidateConsistency(ctx)
+	require.Error(err)
+
+	var violationsErr datastore.ErrConsistencyViolations
+	require.ErrorAs(err, &violationsErr)
+	require.Len(violationsErr.Violations(), 1)
+	require.Equal("folder:somefolder#viewer@user:tom", tuple.MustString(violationsErr.Violations()[0].Relationship))
+	require.Contains(err.Error(), "resource object definition `folder` is not defined")
+}
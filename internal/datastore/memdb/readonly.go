@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 	"github.com/jzelinskie/stringz"
@@ -11,6 +14,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 type txFactory func() (*memdb.Txn, error)
@@ -19,6 +23,10 @@ type memdbReader struct {
 	TryLocker
 	txSource txFactory
 	initErr  error
+
+	// revisionTime is the time of the revision being read, as of which expired relationships
+	// are treated as deleted.
+	revisionTime time.Time
 }
 
 // QueryRelationships reads relationships starting from the resource side.
@@ -41,6 +49,91 @@ func (r *memdbReader) QueryRelationships(
 
 	queryOpts := options.NewQueryOptionsWithOptions(opts...)
 
+	filteredIterator, err := r.relationshipsIterator(tx, filter, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if queryOpts.Sort != options.Unsorted {
+		return sortedTupleIterator(filteredIterator, queryOpts.Sort, queryOpts.Limit)
+	}
+
+	iter := &memdbTupleIterator{
+		it:    filteredIterator,
+		limit: queryOpts.Limit,
+	}
+
+	runtime.SetFinalizer(iter, func(iter *memdbTupleIterator) {
+		if !iter.closed {
+			panic("Tuple iterator garbage collected before Close() was called")
+		}
+	})
+
+	return iter, nil
+}
+
+// QueryRelationshipsWithRevisions reads relationships starting from the resource side, along
+// with the revision of the transaction which wrote each. Past revisions are read from their
+// snapshot, which does not record later deletions, so DeletedAt is always NoRevision.
+func (r *memdbReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	if r.initErr != nil {
+		return nil, r.initErr
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return nil, err
+	}
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	filteredIterator, err := r.relationshipsIterator(tx, filter, queryOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []datastore.RelationshipVersion
+	for foundRaw := filteredIterator.Next(); foundRaw != nil; foundRaw = filteredIterator.Next() {
+		found := foundRaw.(*relationship)
+		rt, err := found.RelationTuple()
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, datastore.RelationshipVersion{
+			Relationship: rt,
+			CreatedAt:    found.createdRevision,
+			DeletedAt:    datastore.NoRevision,
+		})
+
+		if queryOpts.Sort == options.Unsorted && queryOpts.Limit != nil && uint64(len(versions)) >= *queryOpts.Limit {
+			break
+		}
+	}
+
+	if queryOpts.Sort != options.Unsorted {
+		sort.Slice(versions, func(i, j int) bool {
+			return queryOpts.Sort.LessThan(versions[i].Relationship, versions[j].Relationship)
+		})
+
+		if queryOpts.Limit != nil && uint64(len(versions)) > *queryOpts.Limit {
+			versions = versions[:*queryOpts.Limit]
+		}
+	}
+
+	return versions, nil
+}
+
+// relationshipsIterator returns an iterator over the live relationships matching the filter and
+// the usersets of the query options.
+func (r *memdbReader) relationshipsIterator(tx *memdb.Txn, filter datastore.RelationshipsFilter, queryOpts *options.QueryOptions) (memdb.ResultIterator, error) {
 	bestIterator, err := iteratorForFilter(tx, filter)
 	if err != nil {
 		return nil, err
@@ -49,12 +142,104 @@ func (r *memdbReader) QueryRelationships(
 	matchingRelationshipsFilterFunc := filterFuncForFilters(
 		filter.ResourceType,
 		filter.OptionalResourceIds,
+		filter.OptionalResourceIDPrefix,
 		filter.OptionalResourceRelation,
 		filter.OptionalSubjectsFilter,
 		filter.OptionalCaveatName,
 		queryOpts.Usersets,
 	)
-	filteredIterator := memdb.NewFilterIterator(bestIterator, matchingRelationshipsFilterFunc)
+	return memdb.NewFilterIterator(bestIterator, r.filterExpired(matchingRelationshipsFilterFunc)), nil
+}
+
+// RelationshipExists looks up the given relationship directly by its identifying fields.
+func (r *memdbReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	if r.initErr != nil {
+		return false, r.initErr
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return false, err
+	}
+
+	found, err := tx.First(
+		tableRelationship,
+		indexID,
+		tpl.ResourceAndRelation.Namespace,
+		tpl.ResourceAndRelation.ObjectId,
+		tpl.ResourceAndRelation.Relation,
+		tpl.Subject.Namespace,
+		tpl.Subject.ObjectId,
+		stringz.DefaultEmpty(tpl.Subject.Relation, datastore.Ellipsis),
+	)
+	if err != nil {
+		return false, fmt.Errorf("error loading existing relationship: %w", err)
+	}
+
+	return found != nil && !found.(*relationship).expiredAt(r.revisionTime), nil
+}
+
+// NamespaceHasRelationships scans the relationships of the namespace, returning at the first live
+// relationship found.
+func (r *memdbReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	if r.initErr != nil {
+		return false, r.initErr
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return false, err
+	}
+
+	return hasReferencingRelationships(tx, nsName, r.revisionTime)
+}
+
+// QueryRelationshipsForResourceTypes reads all relationships for any of the given resource types.
+func (r *memdbReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	if r.initErr != nil {
+		return nil, r.initErr
+	}
+
+	if len(resourceTypes) == 0 {
+		panic("must specify at least one resource type")
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return nil, err
+	}
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	iterator, err := tx.Get(tableRelationship, indexID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get iterator for resource types: %w", err)
+	}
+
+	matchingUsersetsFilterFunc := filterFuncForFilters("", nil, "", "", nil, "", queryOpts.Usersets)
+	filteredIterator := memdb.NewFilterIterator(iterator, r.filterExpired(func(tupleRaw interface{}) bool {
+		if !stringz.SliceContains(resourceTypes, tupleRaw.(*relationship).namespace) {
+			return true
+		}
+		return matchingUsersetsFilterFunc(tupleRaw)
+	}))
+
+	if queryOpts.Sort != options.Unsorted {
+		return sortedTupleIterator(filteredIterator, queryOpts.Sort, queryOpts.Limit)
+	}
 
 	iter := &memdbTupleIterator{
 		it:    filteredIterator,
@@ -108,12 +293,13 @@ func (r *memdbReader) ReverseQueryRelationships(
 	matchingRelationshipsFilterFunc := filterFuncForFilters(
 		filterObjectType,
 		nil,
+		"",
 		filterRelation,
 		&subjectsFilter,
 		"",
 		nil,
 	)
-	filteredIterator := memdb.NewFilterIterator(iterator, matchingRelationshipsFilterFunc)
+	filteredIterator := memdb.NewFilterIterator(iterator, r.filterExpired(matchingRelationshipsFilterFunc))
 
 	iter := &memdbTupleIterator{
 		it:    filteredIterator,
@@ -163,8 +349,8 @@ func (r *memdbReader) ReadNamespace(ctx context.Context, nsName string) (ns *cor
 	return loaded, found.updated, nil
 }
 
-// ListNamespaces lists all namespaces defined.
-func (r *memdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+// ListNamespaces lists the namespaces defined, ordered by name.
+func (r *memdbReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	if r.initErr != nil {
 		return nil, r.initErr
 	}
@@ -177,15 +363,25 @@ func (r *memdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefi
 		return nil, err
 	}
 
+	listOpts := options.NewListNamespacesOptionsWithOptions(opts...)
+
 	var nsDefs []*core.NamespaceDefinition
 
+	// The ID index is ordered by name, so the namespaces are iterated in name order.
 	it, err := tx.LowerBound(tableNamespace, indexID)
 	if err != nil {
 		return nil, err
 	}
 
 	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
+		if listOpts.NamespaceLimit != nil && uint64(len(nsDefs)) >= *listOpts.NamespaceLimit {
+			break
+		}
+
 		found := foundRaw.(*namespace)
+		if !listOpts.Matches(found.name) {
+			continue
+		}
 
 		loaded := &core.NamespaceDefinition{}
 		if err := loaded.UnmarshalVT(found.configBytes); err != nil {
@@ -252,7 +448,12 @@ func (r *memdbReader) lockOrPanic() {
 func iteratorForFilter(txn *memdb.Txn, filter datastore.RelationshipsFilter) (memdb.ResultIterator, error) {
 	index := indexNamespace
 	args := []any{filter.ResourceType}
-	if filter.OptionalResourceRelation != "" {
+	switch {
+	case filter.OptionalResourceIDPrefix != "":
+		// Scan only the resource IDs starting with the prefix.
+		args = append(args, filter.OptionalResourceIDPrefix)
+		index = indexNamespaceAndResourceID + "_prefix"
+	case filter.OptionalResourceRelation != "":
 		args = append(args, filter.OptionalResourceRelation)
 		index = indexNamespaceAndRelation
 	}
@@ -265,14 +466,34 @@ func iteratorForFilter(txn *memdb.Txn, filter datastore.RelationshipsFilter) (me
 	return iter, err
 }
 
+// filterExpired wraps the filter function to also filter out relationships which have expired
+// as of the revision being read.
+func (r *memdbReader) filterExpired(filterFunc memdb.FilterFunc) memdb.FilterFunc {
+	return func(tupleRaw interface{}) bool {
+		if tupleRaw.(*relationship).expiredAt(r.revisionTime) {
+			return true
+		}
+		return filterFunc(tupleRaw)
+	}
+}
+
 func filterFuncForFilters(
 	optionalResourceType string,
 	optionalResourceIds []string,
+	optionalResourceIDPrefix string,
 	optionalRelation string,
 	optionalSubjectsFilter *datastore.SubjectsFilter,
 	optionalCaveatFilter string,
 	usersets []*core.ObjectAndRelation,
 ) memdb.FilterFunc {
+	var subjectIds []string
+	if optionalSubjectsFilter != nil {
+		subjectIds = optionalSubjectsFilter.OptionalSubjectIds
+		if optionalSubjectsFilter.IncludeWildcardSubjects && len(subjectIds) > 0 {
+			subjectIds = append(append(make([]string, 0, len(subjectIds)+1), subjectIds...), tuple.PublicWildcard)
+		}
+	}
+
 	return func(tupleRaw interface{}) bool {
 		tuple := tupleRaw.(*relationship)
 
@@ -281,6 +502,8 @@ func filterFuncForFilters(
 			return true
 		case len(optionalResourceIds) > 0 && !stringz.SliceContains(optionalResourceIds, tuple.resourceID):
 			return true
+		case optionalResourceIDPrefix != "" && !strings.HasPrefix(tuple.resourceID, optionalResourceIDPrefix):
+			return true
 		case optionalRelation != "" && optionalRelation != tuple.relation:
 			return true
 		case optionalCaveatFilter != "" && (tuple.caveat == nil || tuple.caveat.caveatName != optionalCaveatFilter):
@@ -300,7 +523,7 @@ func filterFuncForFilters(
 			switch {
 			case optionalSubjectsFilter.SubjectType != tuple.subjectNamespace:
 				return true
-			case len(optionalSubjectsFilter.OptionalSubjectIds) > 0 && !stringz.SliceContains(optionalSubjectsFilter.OptionalSubjectIds, tuple.subjectObjectID):
+			case len(subjectIds) > 0 && !stringz.SliceContains(subjectIds, tuple.subjectObjectID):
 				return true
 			case len(relations) > 0 && !stringz.SliceContains(relations, tuple.subjectRelation):
 				return true
@@ -312,7 +535,7 @@ func filterFuncForFilters(
 			for _, filter := range usersets {
 				if filter.Namespace == tuple.subjectNamespace &&
 					filter.ObjectId == tuple.subjectObjectID &&
-					filter.Relation == tuple.subjectRelation {
+					stringz.DefaultEmpty(filter.Relation, datastore.Ellipsis) == tuple.subjectRelation {
 					found = true
 					break
 				}
@@ -324,6 +547,31 @@ func filterFuncForFilters(
 	}
 }
 
+// sortedTupleIterator reads all of the relationships from the iterator and returns an iterator
+// over them in the given sort order, applying the limit after sorting.
+func sortedTupleIterator(it memdb.ResultIterator, order options.SortOrder, limit *uint64) (datastore.RelationshipIterator, error) {
+	var tuples []*core.RelationTuple
+	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
+		rt, err := foundRaw.(*relationship).RelationTuple()
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, rt)
+	}
+
+	sort.Slice(tuples, func(i, j int) bool {
+		return order.LessThan(tuples[i], tuples[j])
+	})
+
+	if limit != nil && uint64(len(tuples)) > *limit {
+		tuples = tuples[:*limit]
+	}
+
+	iter := datastore.NewSliceRelationshipIterator(tuples)
+	runtime.SetFinalizer(iter, datastore.BuildFinalizerFunction())
+	return iter, nil
+}
+
 type memdbTupleIterator struct {
 	closed bool
 	it     memdb.ResultIterator
@@ -359,7 +607,12 @@ func (mti *memdbTupleIterator) Close() {
 	mti.closed = true
 }
 
-var _ datastore.Reader = &memdbReader{}
+var (
+	_ datastore.Reader                        = &memdbReader{}
+	_ datastore.RelationshipExistenceChecker  = &memdbReader{}
+	_ datastore.RelationshipRevisionsReader   = &memdbReader{}
+	_ datastore.NamespaceRelationshipsChecker = &memdbReader{}
+)
 
 type TryLocker interface {
 	TryLock() bool
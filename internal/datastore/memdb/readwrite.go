@@ -3,6 +3,7 @@ package memdb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/hashicorp/go-memdb"
@@ -29,11 +30,32 @@ func (rwt *memdbReadWriteTx) WriteRelationships(ctx context.Context, mutations [
 		return err
 	}
 
-	return rwt.write(tx, mutations...)
+	return rwt.write(tx, datastore.CanonicalizeSubjectRelations(mutations)...)
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter.
+func (rwt *memdbReadWriteTx) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	rwt.lockOrPanic()
+	defer rwt.Unlock()
+
+	tx, err := rwt.txSource()
+	if err != nil {
+		return nil, err
+	}
+
+	return rwt.writeWithResults(tx, datastore.CanonicalizeSubjectRelations(mutations))
 }
 
 // Caller must already hold the concurrent access lock!
 func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTupleUpdate) error {
+	_, err := rwt.writeWithResults(tx, mutations)
+	return err
+}
+
+// Caller must already hold the concurrent access lock!
+func (rwt *memdbReadWriteTx) writeWithResults(tx *memdb.Txn, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	results := make([]datastore.RelationshipUpdateResult, 0, len(mutations))
+
 	// Apply the mutations
 	for _, mutation := range mutations {
 		rel := &relationship{
@@ -44,6 +66,13 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 			mutation.Tuple.Subject.ObjectId,
 			mutation.Tuple.Subject.Relation,
 			rwt.toCaveatReference(mutation),
+			nil,
+			rwt.newRevision,
+		}
+
+		if mutation.Tuple.ExpiresAt != nil {
+			expiresAt := mutation.Tuple.ExpiresAt.AsTime()
+			rel.expiresAt = &expiresAt
 		}
 
 		found, err := tx.First(
@@ -57,7 +86,7 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 			rel.subjectRelation,
 		)
 		if err != nil {
-			return fmt.Errorf("error loading existing relationship: %w", err)
+			return nil, fmt.Errorf("error loading existing relationship: %w", err)
 		}
 
 		var existing *relationship
@@ -65,32 +94,40 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 			existing = found.(*relationship)
 		}
 
+		// An expired relationship is treated as deleted, and can therefore be created again.
+		live := existing != nil && !existing.expiredAt(rwt.revisionTime)
+		if existing != nil && mutation.Operation == core.RelationTupleUpdate_CREATE && !live {
+			existing = nil
+		}
+
 		switch mutation.Operation {
 		case core.RelationTupleUpdate_CREATE:
 			if existing != nil {
 				rt, err := existing.RelationTuple()
 				if err != nil {
-					return err
+					return nil, err
 				}
-				return common.NewCreateRelationshipExistsError(rt)
+				return nil, common.NewCreateRelationshipExistsError(rt)
 			}
 			fallthrough
 		case core.RelationTupleUpdate_TOUCH:
 			if err := tx.Insert(tableRelationship, rel); err != nil {
-				return fmt.Errorf("error inserting relationship: %w", err)
+				return nil, fmt.Errorf("error inserting relationship: %w", err)
 			}
 		case core.RelationTupleUpdate_DELETE:
 			if existing != nil {
 				if err := tx.Delete(tableRelationship, existing); err != nil {
-					return fmt.Errorf("error deleting relationship: %w", err)
+					return nil, fmt.Errorf("error deleting relationship: %w", err)
 				}
 			}
 		default:
-			return fmt.Errorf("unknown tuple mutation operation type: %s", mutation.Operation)
+			return nil, fmt.Errorf("unknown tuple mutation operation type: %s", mutation.Operation)
 		}
+
+		results = append(results, datastore.UpdateResultFor(mutation.Operation, live))
 	}
 
-	return nil
+	return results, nil
 }
 
 func (rwt *memdbReadWriteTx) toCaveatReference(mutation *core.RelationTupleUpdate) *contextualizedCaveat {
@@ -116,6 +153,14 @@ func (rwt *memdbReadWriteTx) DeleteRelationships(ctx context.Context, filter *v1
 	return rwt.deleteWithLock(tx, filter)
 }
 
+func (rwt *memdbReadWriteTx) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	return common.DeleteRelationshipsForSubject(ctx, rwt, subject)
+}
+
+func (rwt *memdbReadWriteTx) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	return common.RewriteRelationshipsSubject(ctx, rwt, oldSubject, newSubject)
+}
+
 // caller must already hold the concurrent access lock
 func (rwt *memdbReadWriteTx) deleteWithLock(tx *memdb.Txn, filter *v1.RelationshipFilter) error {
 	// Create an iterator to find the relevant tuples
@@ -164,7 +209,7 @@ func (rwt *memdbReadWriteTx) WriteNamespaces(ctx context.Context, newConfigs ...
 	return nil
 }
 
-func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
 	rwt.lockOrPanic()
 	defer rwt.Unlock()
 
@@ -183,6 +228,22 @@ func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, nsNames ...st
 			return fmt.Errorf("unable to find namespace to delete")
 		}
 
+		if delOption == datastore.DeleteNamespacesOnlyIfEmpty {
+			found, err := hasReferencingRelationships(tx, nsName, rwt.revisionTime)
+			if err != nil {
+				return err
+			}
+
+			// The relationships are only counted for the error.
+			if found {
+				count, err := countReferencingRelationships(tx, nsName, rwt.revisionTime)
+				if err != nil {
+					return err
+				}
+				return datastore.NewNamespaceHasRelationshipsErr(nsName, count)
+			}
+		}
+
 		if err := tx.Delete(tableNamespace, foundRaw); err != nil {
 			return err
 		}
@@ -193,11 +254,99 @@ func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, nsNames ...st
 		}); err != nil {
 			return fmt.Errorf("unable to delete relationships from deleted namespace: %w", err)
 		}
+
+		// Delete the relationships with subjects in the namespace
+		if err := rwt.deleteSubjectsWithLock(tx, nsName); err != nil {
+			return fmt.Errorf("unable to delete relationships referencing deleted namespace: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// caller must already hold the concurrent access lock
+func (rwt *memdbReadWriteTx) deleteSubjectsWithLock(tx *memdb.Txn, subjectType string) error {
+	iter, err := tx.Get(tableRelationship, indexSubjectNamespace, subjectType)
+	if err != nil {
+		return err
+	}
+
+	var mutations []*core.RelationTupleUpdate
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		rt, err := row.(*relationship).RelationTuple()
+		if err != nil {
+			return err
+		}
+		mutations = append(mutations, tuple.Delete(rt))
+	}
+
+	return rwt.write(tx, mutations...)
+}
+
+// expiredRelationships returns the relationships which have expired as of the given time.
+func expiredRelationships(tx *memdb.Txn, at time.Time) ([]*relationship, error) {
+	iter, err := tx.Get(tableRelationship, indexExpiring, true)
+	if err != nil {
+		return nil, fmt.Errorf("error loading expiring relationships: %w", err)
+	}
+
+	var expired []*relationship
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		if rel := row.(*relationship); rel.expiredAt(at) {
+			expired = append(expired, rel)
+		}
+	}
+	return expired, nil
+}
+
+// hasReferencingRelationships returns whether any relationship live at the given time has a
+// resource or subject of the namespace, returning at the first found. Caller must already hold the
+// concurrent access lock.
+func hasReferencingRelationships(tx *memdb.Txn, nsName string, at time.Time) (bool, error) {
+	for _, index := range []string{indexNamespace, indexSubjectNamespace} {
+		iter, err := tx.Get(tableRelationship, index, nsName)
+		if err != nil {
+			return false, err
+		}
+		for row := iter.Next(); row != nil; row = iter.Next() {
+			if !row.(*relationship).expiredAt(at) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// countReferencingRelationships counts the relationships live at the given time with a resource or
+// subject of the given namespace. Caller must already hold the concurrent access lock.
+func countReferencingRelationships(tx *memdb.Txn, nsName string, at time.Time) (uint64, error) {
+	var count uint64
+
+	iter, err := tx.Get(tableRelationship, indexNamespace, nsName)
+	if err != nil {
+		return 0, err
+	}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		if !row.(*relationship).expiredAt(at) {
+			count++
+		}
+	}
+
+	iter, err = tx.Get(tableRelationship, indexSubjectNamespace, nsName)
+	if err != nil {
+		return 0, err
+	}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		rel := row.(*relationship)
+		if rel.namespace != nsName && !rel.expiredAt(at) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 func relationshipFilterFilterFunc(filter *v1.RelationshipFilter) func(interface{}) bool {
 	return func(tupleRaw interface{}) bool {
 		tuple := tupleRaw.(*relationship)
@@ -229,4 +378,7 @@ func relationshipFilterFilterFunc(filter *v1.RelationshipFilter) func(interface{
 	}
 }
 
-var _ datastore.ReadWriteTransaction = &memdbReadWriteTx{}
+var (
+	_ datastore.ReadWriteTransaction       = &memdbReadWriteTx{}
+	_ datastore.RelationshipUpdateReporter = &memdbReadWriteTx{}
+)
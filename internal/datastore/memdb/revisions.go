@@ -2,6 +2,8 @@ package memdb
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -14,6 +16,11 @@ func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
 
+// revisionTime returns the time at which the revision was created.
+func revisionTime(rev revision.Decimal) time.Time {
+	return time.Unix(0, rev.IntPart()).UTC()
+}
+
 func (mdb *memdbDatastore) newRevisionID() revision.Decimal {
 	mdb.Lock()
 	defer mdb.Unlock()
@@ -69,6 +76,32 @@ func (mdb *memdbDatastore) CheckRevision(ctx context.Context, revisionRaw datast
 	return mdb.checkRevisionLocalCallerMustLock(dr)
 }
 
+func (mdb *memdbDatastore) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if len(mdb.revisions) == 0 {
+		return datastore.NoRevision, fmt.Errorf("memdb datastore is not ready")
+	}
+
+	now := revisionFromTimestamp(time.Now().UTC())
+	oldest := revision.NewFromDecimal(now.Add(mdb.negativeGCWindow))
+	if revisionFromTimestamp(at).LessThan(oldest) {
+		return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	// Find the first snapshot committed after the requested time; the one before it is the
+	// latest snapshot committed at or before that time.
+	revIndex := sort.Search(len(mdb.revisions), func(i int) bool {
+		return mdb.revisions[i].committedAt.After(at)
+	})
+	if revIndex == 0 {
+		return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	return revision.NewFromDecimal(mdb.revisions[revIndex-1].revision), nil
+}
+
 func (mdb *memdbDatastore) checkRevisionLocalCallerMustLock(revisionRaw revision.Decimal) error {
 	now := revisionFromTimestamp(time.Now().UTC())
 
@@ -2,6 +2,7 @@ package memdb
 
 import (
 	"fmt"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/hashicorp/go-memdb"
@@ -9,6 +10,7 @@ import (
 	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -23,9 +25,11 @@ const (
 	indexNamespaceAndRelation   = "namespaceAndRelation"
 	indexNamespaceAndSubjectID  = "namespaceAndSubjectID"
 	indexSubjectNamespace       = "subjectNamespace"
+	indexExpiring               = "expiring"
 
-	tableChangelog = "changelog"
-	indexRevision  = "id"
+	tableChangelog      = "changelog"
+	indexRevision       = "id"
+	indexIdempotencyKey = "idempotencyKey"
 )
 
 type namespace struct {
@@ -46,6 +50,15 @@ type relationship struct {
 	subjectObjectID  string
 	subjectRelation  string
 	caveat           *contextualizedCaveat
+	expiresAt        *time.Time
+
+	// createdRevision is the revision of the transaction which wrote the relationship.
+	createdRevision datastore.Revision
+}
+
+// expiredAt returns whether the relationship has expired as of the given time.
+func (r relationship) expiredAt(at time.Time) bool {
+	return r.expiresAt != nil && !r.expiresAt.After(at)
 }
 
 type contextualizedCaveat struct {
@@ -122,13 +135,15 @@ func (r relationship) RelationTuple() (*core.RelationTuple, error) {
 			ObjectId:  r.subjectObjectID,
 			Relation:  r.subjectRelation,
 		},
-		Caveat: cr,
+		Caveat:    cr,
+		ExpiresAt: common.ExpirationFrom(r.expiresAt),
 	}, nil
 }
 
 type changelog struct {
-	revisionNanos int64
-	changes       datastore.RevisionChanges
+	revisionNanos  int64
+	changes        datastore.RevisionChanges
+	idempotencyKey string
 }
 
 var schema = &memdb.DBSchema{
@@ -151,6 +166,12 @@ var schema = &memdb.DBSchema{
 					Unique:  true,
 					Indexer: &memdb.IntFieldIndex{Field: "revisionNanos"},
 				},
+				indexIdempotencyKey: {
+					Name:         indexIdempotencyKey,
+					Unique:       true,
+					AllowMissing: true,
+					Indexer:      &memdb.StringFieldIndex{Field: "idempotencyKey"},
+				},
 			},
 		},
 		tableRelationship: {
@@ -211,6 +232,15 @@ var schema = &memdb.DBSchema{
 					Unique:  false,
 					Indexer: &memdb.StringFieldIndex{Field: "subjectNamespace"},
 				},
+				indexExpiring: {
+					Name:   indexExpiring,
+					Unique: false,
+					Indexer: &memdb.ConditionalIndex{
+						Conditional: func(obj interface{}) (bool, error) {
+							return obj.(*relationship).expiresAt != nil, nil
+						},
+					},
+				},
 			},
 		},
 		tableCaveats: {
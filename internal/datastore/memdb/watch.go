@@ -4,14 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
+	"github.com/shopspring/decimal"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 )
 
-const errWatchError = "watch error: %w"
+const (
+	errWatchError = "watch error: %w"
+
+	// watchCheckpointInterval is the time after which a watch without changes emits a checkpoint.
+	watchCheckpointInterval = 100 * time.Millisecond
+)
 
 func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
 	ar := afterRevision.(revision.Decimal)
@@ -45,19 +53,26 @@ func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Re
 				}
 			}
 
-			// Wait for new changes
+			// Wait for new changes, emitting a checkpoint if there are none for a while.
 			ws := memdb.NewWatchSet()
 			ws.Add(watchChan)
 
-			err = ws.WatchCtx(ctx)
+			checkpointCtx, cancel := context.WithTimeout(ctx, watchCheckpointInterval)
+			err = ws.WatchCtx(checkpointCtx)
+			cancel()
 			if err != nil {
 				switch {
-				case errors.Is(err, context.Canceled):
+				case errors.Is(ctx.Err(), context.Canceled):
 					errs <- datastore.NewWatchCanceledErr()
+					return
+				case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+					if checkpoint, ok := mdb.checkpoint(currentTxn); ok {
+						common.SendCheckpoint(updates, checkpoint)
+					}
 				default:
 					errs <- fmt.Errorf(errWatchError, err)
+					return
 				}
-				return
 			}
 		}
 	}()
@@ -92,3 +107,42 @@ func (mdb *memdbDatastore) loadChanges(ctx context.Context, currentTxn int64) ([
 
 	return changes, lastRevision, watchChan, nil
 }
+
+// checkpoint returns a checkpoint through which the changes following currentTxn have all been
+// loaded, unless a write is in progress or has committed since. The checkpoint is at the current
+// time, or just before the next expiration of a relationship, which is reported as deleted by the
+// first write after it.
+func (mdb *memdbDatastore) checkpoint(currentTxn int64) (datastore.Revision, bool) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.writesInProgress > 0 || mdb.db == nil {
+		return nil, false
+	}
+
+	head, err := mdb.headRevisionNoLock()
+	if err != nil || head.IntPart() > currentTxn {
+		return nil, false
+	}
+
+	// The next write's revision is allocated from a later time, which may share the current
+	// time at the clock's precision, so the checkpoint is just before it.
+	checkpoint := time.Now().UTC()
+
+	iter, err := mdb.db.Txn(false).Get(tableRelationship, indexExpiring, true)
+	if err != nil {
+		return nil, false
+	}
+	for row := iter.Next(); row != nil; row = iter.Next() {
+		if expiresAt := row.(*relationship).expiresAt; expiresAt.Before(checkpoint) {
+			checkpoint = *expiresAt
+		}
+	}
+
+	checkpointRevision := revisionFromTimestamp(checkpoint).Sub(decimal.NewFromInt(1))
+	if checkpointRevision.IntPart() <= currentTxn {
+		return nil, false
+	}
+
+	return revision.NewFromDecimal(checkpointRevision), true
+}
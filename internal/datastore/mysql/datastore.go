@@ -21,6 +21,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
 	"github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -271,7 +272,12 @@ func noCleanup() error { return nil }
 func (mds *Datastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	if options.NewRWTOptionsWithOptions(opts...).IdempotencyKey != "" {
+		return datastore.NoRevision, datastore.NewIdempotencyKeysUnsupportedErr(Engine)
+	}
+
 	var err error
 	for i := uint8(0); i <= mds.maxRetries; i++ {
 		var newTxnID uint64
@@ -478,6 +484,21 @@ func (mds *Datastore) IsReady(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// HealthCheck reports whether the database is reachable and whether its connection pool
+// still has connections available.
+func (mds *Datastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	stats := mds.db.Stats()
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		return datastore.Degraded(fmt.Sprintf("all %d connections in the pool are in use", stats.MaxOpenConnections))
+	}
+
+	if err := mds.db.PingContext(ctx); err != nil {
+		return datastore.Unreachable(err)
+	}
+
+	return datastore.Healthy()
+}
+
 func (mds *Datastore) Features(ctx context.Context) (*datastore.Features, error) {
 	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
 }
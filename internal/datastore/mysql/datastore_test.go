@@ -99,6 +99,10 @@ func TestMySQLDatastore(t *testing.T) {
 	t.Run("QuantizedRevisions", func(t *testing.T) {
 		QuantizedRevisionTest(t, b)
 	})
+	t.Run("RelationshipRevisionsDeletedAt", func(t *testing.T) {
+		// Deleted rows are retained until garbage collected, along with the deleting transaction.
+		test.RelationshipRevisionsDeletedAtTest(t, test.DatastoreTesterFunc(dst.createDatastore), true)
+	})
 }
 
 func TestMySQLDatastoreWithTablePrefix(t *testing.T) {
@@ -17,13 +17,14 @@ type QueryBuilder struct {
 	DeleteNamespaceQuery       sq.UpdateBuilder
 	DeleteNamespaceTuplesQuery sq.UpdateBuilder
 
-	QueryTupleIdsQuery    sq.SelectBuilder
-	QueryTuplesQuery      sq.SelectBuilder
-	DeleteTupleQuery      sq.UpdateBuilder
-	QueryTupleExistsQuery sq.SelectBuilder
-	WriteTupleQuery       sq.InsertBuilder
-	QueryChangedQuery     sq.SelectBuilder
-	CountTupleQuery       sq.SelectBuilder
+	QueryTupleIdsQuery            sq.SelectBuilder
+	QueryTuplesQuery              sq.SelectBuilder
+	QueryTuplesWithRevisionsQuery sq.SelectBuilder
+	DeleteTupleQuery              sq.UpdateBuilder
+	QueryTupleExistsQuery         sq.SelectBuilder
+	WriteTupleQuery               sq.InsertBuilder
+	QueryChangedQuery             sq.SelectBuilder
+	CountTupleQuery               sq.SelectBuilder
 
 	WriteCaveatQuery  sq.InsertBuilder
 	ReadCaveatQuery   sq.SelectBuilder
@@ -49,6 +50,7 @@ func NewQueryBuilder(driver *migrations.MySQLDriver) *QueryBuilder {
 	builder.QueryTupleIdsQuery = queryTupleIds(driver.RelationTuple())
 	builder.DeleteNamespaceTuplesQuery = deleteNamespaceTuples(driver.RelationTuple())
 	builder.QueryTuplesQuery = queryTuples(driver.RelationTuple())
+	builder.QueryTuplesWithRevisionsQuery = queryTuplesWithRevisions(driver.RelationTuple())
 	builder.DeleteTupleQuery = deleteTuple(driver.RelationTuple())
 	builder.QueryTupleExistsQuery = queryTupleExists(driver.RelationTuple())
 	builder.WriteTupleQuery = writeTuple(driver.RelationTuple())
@@ -131,6 +133,21 @@ func queryTuples(tableTuple string) sq.SelectBuilder {
 	).From(tableTuple)
 }
 
+func queryTuplesWithRevisions(tableTuple string) sq.SelectBuilder {
+	return sb.Select(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+		colCaveatName,
+		colCaveatContext,
+		colCreatedTxn,
+		colDeletedTxn,
+	).From(tableTuple)
+}
+
 func countTuples(tableTuple string) sq.SelectBuilder {
 	return sb.Select(
 		"count(*)",
@@ -34,6 +34,7 @@ const (
 	errUnableToReadConfig     = "unable to read namespace config: %w"
 	errUnableToListNamespaces = "unable to list namespaces: %w"
 	errUnableToQueryTuples    = "unable to query tuples: %w"
+	errUnableToReadRevisions  = "unable to read relationship revisions: %w"
 )
 
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
@@ -57,6 +58,90 @@ func (mr *mysqlReader) QueryRelationships(
 	return mr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader. Rows are
+// versioned by the transactions which created and deleted them, so a row read at a past revision
+// may have since been deleted by a later transaction, which is returned as its DeletedAt.
+func (mr *mysqlReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	tx, txCleanup, err := mr.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	query, args, err := common.NewSchemaQueryFilterer(schema, mr.filterer(mr.QueryTuplesWithRevisionsQuery)).
+		FilterWithRelationshipsFilter(filter).
+		QueryBuilder(opts...).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var versions []datastore.RelationshipVersion
+	for rows.Next() {
+		tpl := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+		var caveatName string
+		var caveatContext caveatContextWrapper
+		var createdTxn, deletedTxn uint64
+		if err := rows.Scan(
+			&tpl.ResourceAndRelation.Namespace,
+			&tpl.ResourceAndRelation.ObjectId,
+			&tpl.ResourceAndRelation.Relation,
+			&tpl.Subject.Namespace,
+			&tpl.Subject.ObjectId,
+			&tpl.Subject.Relation,
+			&caveatName,
+			&caveatContext,
+			&createdTxn,
+			&deletedTxn,
+		); err != nil {
+			return nil, fmt.Errorf(errUnableToReadRevisions, err)
+		}
+
+		tpl.Caveat, err = common.ContextualizedCaveatFrom(caveatName, caveatContext)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToReadRevisions, err)
+		}
+
+		version := datastore.RelationshipVersion{
+			Relationship: tpl,
+			CreatedAt:    revisionFromTransaction(createdTxn),
+			DeletedAt:    datastore.NoRevision,
+		}
+		if deletedTxn != liveDeletedTxnID {
+			version.DeletedAt = revisionFromTransaction(deletedTxn)
+		}
+
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	return versions, nil
+}
+
+func (mr *mysqlReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(schema, mr.filterer(mr.QueryTuplesQuery)).FilterToResourceTypes(resourceTypes)
+	return mr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
+}
+
 func (mr *mysqlReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -128,7 +213,7 @@ func loadNamespace(ctx context.Context, namespace string, tx *sql.Tx, baseQuery
 	return loaded, revision.NewFromDecimal(version), nil
 }
 
-func (mr *mysqlReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (mr *mysqlReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	tx, txCleanup, err := mr.txSource(ctx)
 	if err != nil {
@@ -143,7 +228,7 @@ func (mr *mysqlReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDef
 		return nil, fmt.Errorf(errUnableToListNamespaces, err)
 	}
 
-	return nsDefs, err
+	return options.NewListNamespacesOptionsWithOptions(opts...).Apply(nsDefs), nil
 }
 
 func (mr *mysqlReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -209,4 +294,7 @@ func loadAllNamespaces(ctx context.Context, tx *sql.Tx, queryBuilder sq.SelectBu
 	return nsDefs, nil
 }
 
-var _ datastore.Reader = &mysqlReader{}
+var (
+	_ datastore.Reader                      = &mysqlReader{}
+	_ datastore.RelationshipRevisionsReader = &mysqlReader{}
+)
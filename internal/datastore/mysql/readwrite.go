@@ -57,6 +57,7 @@ func (cc *caveatContextWrapper) Value() (driver.Value, error) {
 func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	// there are some fundamental changes introduced to prevent a deadlock in MySQL
+	mutations = datastore.CanonicalizeSubjectRelations(mutations)
 
 	bulkWrite := rwt.WriteTupleQuery
 	bulkWriteHasValues := false
@@ -67,6 +68,10 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 
 	// Process the actual updates
 	for _, mut := range mutations {
+		if mut.Operation != core.RelationTupleUpdate_DELETE && mut.Tuple.ExpiresAt != nil {
+			return datastore.NewRelationshipExpirationUnsupportedErr(Engine)
+		}
+
 		tpl := mut.Tuple
 
 		// Implementation for TOUCH deviates from PostgreSQL datastore to prevent a deadlock in MySQL
@@ -192,6 +197,14 @@ func (rwt *mysqlReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v
 	return nil
 }
 
+func (rwt *mysqlReadWriteTXN) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	return common.DeleteRelationshipsForSubject(ctx, rwt, subject)
+}
+
+func (rwt *mysqlReadWriteTXN) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	return common.RewriteRelationshipsSubject(ctx, rwt, oldSubject, newSubject)
+}
+
 func (rwt *mysqlReadWriteTXN) WriteNamespaces(ctx context.Context, newNamespaces ...*core.NamespaceDefinition) error {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 
@@ -234,7 +247,7 @@ func (rwt *mysqlReadWriteTXN) WriteNamespaces(ctx context.Context, newNamespaces
 	return nil
 }
 
-func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 
 	// For each namespace, check they exist and collect predicates for the
@@ -256,7 +269,13 @@ func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...s
 		}
 
 		nsClauses = append(nsClauses, sq.Eq{colNamespace: nsName, colCreatedTxn: createdAt})
-		tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName})
+		tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName}, sq.Eq{colUsersetNamespace: nsName})
+	}
+
+	if delOption == datastore.DeleteNamespacesOnlyIfEmpty {
+		if err := common.EnsureNoRelationshipsForNamespaces(ctx, rwt, nsNames...); err != nil {
+			return err
+		}
 	}
 
 	delSQL, delArgs, err := rwt.DeleteNamespaceQuery.
@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/shopspring/decimal"
 
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -18,6 +19,8 @@ const (
 	errRevision      = "unable to find revision: %w"
 	errCheckRevision = "unable to check revision: %w"
 
+	errRevisionAtTime = "unable to find revision at time: %w"
+
 	// querySelectRevision will round the database's timestamp down to the nearest
 	// quantization period, and then find the first transaction after that. If there
 	// are no transactions newer than the quantization period, it just picks the latest
@@ -108,6 +111,34 @@ func (mds *Datastore) CheckRevision(ctx context.Context, revisionRaw datastore.R
 	return nil
 }
 
+func (mds *Datastore) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	now, err := mds.Now(ctx)
+	if err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	at = at.UTC()
+	if at.Before(now.Add(-mds.gcWindow)) {
+		return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	query, args, err := mds.GetLastRevision.Where(sq.LtOrEq{colTimestamp: at}).ToSql()
+	if err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	var value sql.NullInt64
+	if err := mds.db.QueryRowContext(ctx, query, args...).Scan(&value); err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	if !value.Valid {
+		return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	return revisionFromTransaction(uint64(value.Int64)), nil
+}
+
 func (mds *Datastore) loadRevision(ctx context.Context) (uint64, error) {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	// slightly changed to support no revisions at all, needed for runtime seeding of first transaction
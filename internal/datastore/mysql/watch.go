@@ -35,6 +35,8 @@ func (mds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revi
 		currentTxn := transactionFromRevision(afterRevision)
 
 		for {
+			previousTxn := currentTxn
+
 			var stagedUpdates []datastore.RevisionChanges
 			var err error
 			stagedUpdates, currentTxn, err = mds.loadChanges(ctx, currentTxn)
@@ -59,6 +61,13 @@ func (mds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revi
 				}
 			}
 
+			// Transactions without relationship changes are emitted as a checkpoint at the
+			// latest transaction loaded, through which all changes have been written.
+			checkpoint := revisionFromTransaction(currentTxn)
+			if currentTxn != previousTxn && (len(stagedUpdates) == 0 || !stagedUpdates[len(stagedUpdates)-1].Revision.Equal(checkpoint)) {
+				common.SendCheckpoint(updates, checkpoint)
+			}
+
 			// If there were no changes, sleep a bit
 			if len(stagedUpdates) == 0 {
 				sleep := time.NewTimer(watchSleep)
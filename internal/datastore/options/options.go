@@ -1,15 +1,19 @@
 package options
 
 import (
+	"sort"
+	"strings"
+
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
-//go:generate go run github.com/ecordell/optgen -output zz_generated.query_options.go . QueryOptions ReverseQueryOptions
+//go:generate go run github.com/ecordell/optgen -output zz_generated.query_options.go . QueryOptions ReverseQueryOptions RWTOptions ListNamespacesOptions ListCaveatsOptions
 
 // QueryOptions are the options that can affect the results of a normal forward query.
 type QueryOptions struct {
 	Limit    *uint64
 	Usersets []*core.ObjectAndRelation
+	Sort     SortOrder
 }
 
 // ReverseQueryOptions are the options that can affect the results of a reverse query.
@@ -18,6 +22,140 @@ type ReverseQueryOptions struct {
 	ResRelation  *ResourceRelation
 }
 
+// RWTOptions are the options that can affect the behavior of a read-write transaction.
+type RWTOptions struct {
+	// IdempotencyKey, if not empty, is recorded with the committed transaction. If a transaction
+	// with the same key was already committed within the garbage collection window, the revision
+	// of that transaction is returned instead of (re)applying the transaction.
+	IdempotencyKey string
+}
+
+// ListNamespacesOptions are the options that can affect the results of listing namespaces. The
+// namespaces are always returned ordered by name.
+type ListNamespacesOptions struct {
+	// NamespacePrefix, if not empty, limits the results to namespaces whose name starts with it.
+	NamespacePrefix string
+
+	// NamespaceLimit, if not nil, is the maximum number of namespaces returned.
+	NamespaceLimit *uint64
+
+	// AfterNamespace, if not empty, is the cursor for the page: only namespaces whose name is
+	// ordered after it are returned. It is typically the name of the last namespace of the
+	// previous page.
+	AfterNamespace string
+}
+
+// Matches returns whether a namespace with the given name is matched by the prefix and cursor of
+// the options.
+func (lno *ListNamespacesOptions) Matches(namespaceName string) bool {
+	return strings.HasPrefix(namespaceName, lno.NamespacePrefix) && namespaceName > lno.AfterNamespace
+}
+
+// Apply applies the options to the full list of namespace definitions, for datastores which do
+// not support applying them as part of their query. The given slice is sorted in place.
+func (lno *ListNamespacesOptions) Apply(nsDefs []*core.NamespaceDefinition) []*core.NamespaceDefinition {
+	sort.Slice(nsDefs, func(i, j int) bool {
+		return nsDefs[i].Name < nsDefs[j].Name
+	})
+
+	filtered := make([]*core.NamespaceDefinition, 0, len(nsDefs))
+	for _, nsDef := range nsDefs {
+		if lno.NamespaceLimit != nil && uint64(len(filtered)) >= *lno.NamespaceLimit {
+			break
+		}
+
+		if lno.Matches(nsDef.Name) {
+			filtered = append(filtered, nsDef)
+		}
+	}
+	return filtered
+}
+
+// ListCaveatsOptions are the options that can affect the results of listing caveats. The caveats
+// are always returned ordered by name.
+type ListCaveatsOptions struct {
+	// CaveatPrefix, if not empty, limits the results to caveats whose name starts with it.
+	CaveatPrefix string
+
+	// CaveatLimit, if not nil, is the maximum number of caveats returned.
+	CaveatLimit *uint64
+
+	// AfterCaveat, if not empty, is the cursor for the page: only caveats whose name is ordered
+	// after it are returned. It is typically the name of the last caveat of the previous page.
+	AfterCaveat string
+}
+
+// Matches returns whether a caveat with the given name is matched by the prefix and cursor of the
+// options.
+func (lco *ListCaveatsOptions) Matches(caveatName string) bool {
+	return strings.HasPrefix(caveatName, lco.CaveatPrefix) && caveatName > lco.AfterCaveat
+}
+
+// Apply applies the options to the full list of caveat definitions, for datastores which do not
+// support applying them as part of their query. The given slice is sorted in place.
+func (lco *ListCaveatsOptions) Apply(caveatDefs []*core.CaveatDefinition) []*core.CaveatDefinition {
+	sort.Slice(caveatDefs, func(i, j int) bool {
+		return caveatDefs[i].Name < caveatDefs[j].Name
+	})
+
+	filtered := make([]*core.CaveatDefinition, 0, len(caveatDefs))
+	for _, caveatDef := range caveatDefs {
+		if lco.CaveatLimit != nil && uint64(len(filtered)) >= *lco.CaveatLimit {
+			break
+		}
+
+		if lco.Matches(caveatDef.Name) {
+			filtered = append(filtered, caveatDef)
+		}
+	}
+	return filtered
+}
+
+// SortOrder is the order in which the relationships found by a query are returned. Each order
+// sorts on all relationship fields, making it total and therefore stable across pages.
+type SortOrder int8
+
+const (
+	// Unsorted indicates that the relationships may be returned in any order. This is the
+	// default and is the most efficient option for the datastore.
+	Unsorted SortOrder = iota
+
+	// ByResource sorts by resource (type, ID and relation), and then by subject.
+	ByResource
+
+	// ByRelation sorts by resource relation, then by resource type and ID, and then by subject.
+	ByRelation
+
+	// BySubject sorts by subject (type, ID and relation), and then by resource.
+	BySubject
+)
+
+// LessThan returns whether the lhs relationship is ordered before the rhs relationship
+// under the sort order.
+func (so SortOrder) LessThan(lhs, rhs *core.RelationTuple) bool {
+	lhsKey, rhsKey := so.sortKey(lhs), so.sortKey(rhs)
+	for index := range lhsKey {
+		if lhsKey[index] != rhsKey[index] {
+			return lhsKey[index] < rhsKey[index]
+		}
+	}
+	return false
+}
+
+func (so SortOrder) sortKey(tpl *core.RelationTuple) [6]string {
+	resource, subject := tpl.ResourceAndRelation, tpl.Subject
+	switch so {
+	case ByResource:
+		return [6]string{resource.Namespace, resource.ObjectId, resource.Relation, subject.Namespace, subject.ObjectId, subject.Relation}
+	case ByRelation:
+		return [6]string{resource.Relation, resource.Namespace, resource.ObjectId, subject.Namespace, subject.ObjectId, subject.Relation}
+	case BySubject:
+		return [6]string{subject.Namespace, subject.ObjectId, subject.Relation, resource.Namespace, resource.ObjectId, resource.Relation}
+	default:
+		return [6]string{}
+	}
+}
+
 // ResourceRelation combines a resource object type and relation.
 type ResourceRelation struct {
 	Namespace string
@@ -19,6 +19,7 @@ func (q *QueryOptions) ToOption() QueryOptionsOption {
 	return func(to *QueryOptions) {
 		to.Limit = q.Limit
 		to.Usersets = q.Usersets
+		to.Sort = q.Sort
 	}
 }
 
@@ -51,6 +52,13 @@ func SetUsersets(usersets []*v1.ObjectAndRelation) QueryOptionsOption {
 	}
 }
 
+// WithSort returns an option that can set Sort on a QueryOptions
+func WithSort(sort SortOrder) QueryOptionsOption {
+	return func(q *QueryOptions) {
+		q.Sort = sort
+	}
+}
+
 type ReverseQueryOptionsOption func(r *ReverseQueryOptions)
 
 // NewReverseQueryOptionsWithOptions creates a new ReverseQueryOptions with the passed in options set
@@ -91,3 +99,134 @@ func WithResRelation(resRelation *ResourceRelation) ReverseQueryOptionsOption {
 		r.ResRelation = resRelation
 	}
 }
+
+type RWTOptionsOption func(r *RWTOptions)
+
+// NewRWTOptionsWithOptions creates a new RWTOptions with the passed in options set
+func NewRWTOptionsWithOptions(opts ...RWTOptionsOption) *RWTOptions {
+	r := &RWTOptions{}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// ToOption returns a new RWTOptionsOption that sets the values from the passed in RWTOptions
+func (r *RWTOptions) ToOption() RWTOptionsOption {
+	return func(to *RWTOptions) {
+		to.IdempotencyKey = r.IdempotencyKey
+	}
+}
+
+// RWTOptionsWithOptions configures an existing RWTOptions with the passed in options set
+func RWTOptionsWithOptions(r *RWTOptions, opts ...RWTOptionsOption) *RWTOptions {
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// WithIdempotencyKey returns an option that can set IdempotencyKey on a RWTOptions
+func WithIdempotencyKey(idempotencyKey string) RWTOptionsOption {
+	return func(r *RWTOptions) {
+		r.IdempotencyKey = idempotencyKey
+	}
+}
+
+type ListNamespacesOptionsOption func(l *ListNamespacesOptions)
+
+// NewListNamespacesOptionsWithOptions creates a new ListNamespacesOptions with the passed in options set
+func NewListNamespacesOptionsWithOptions(opts ...ListNamespacesOptionsOption) *ListNamespacesOptions {
+	l := &ListNamespacesOptions{}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// ToOption returns a new ListNamespacesOptionsOption that sets the values from the passed in ListNamespacesOptions
+func (l *ListNamespacesOptions) ToOption() ListNamespacesOptionsOption {
+	return func(to *ListNamespacesOptions) {
+		to.NamespacePrefix = l.NamespacePrefix
+		to.NamespaceLimit = l.NamespaceLimit
+		to.AfterNamespace = l.AfterNamespace
+	}
+}
+
+// ListNamespacesOptionsWithOptions configures an existing ListNamespacesOptions with the passed in options set
+func ListNamespacesOptionsWithOptions(l *ListNamespacesOptions, opts ...ListNamespacesOptionsOption) *ListNamespacesOptions {
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// WithNamespacePrefix returns an option that can set NamespacePrefix on a ListNamespacesOptions
+func WithNamespacePrefix(namespacePrefix string) ListNamespacesOptionsOption {
+	return func(l *ListNamespacesOptions) {
+		l.NamespacePrefix = namespacePrefix
+	}
+}
+
+// WithNamespaceLimit returns an option that can set NamespaceLimit on a ListNamespacesOptions
+func WithNamespaceLimit(namespaceLimit *uint64) ListNamespacesOptionsOption {
+	return func(l *ListNamespacesOptions) {
+		l.NamespaceLimit = namespaceLimit
+	}
+}
+
+// WithAfterNamespace returns an option that can set AfterNamespace on a ListNamespacesOptions
+func WithAfterNamespace(afterNamespace string) ListNamespacesOptionsOption {
+	return func(l *ListNamespacesOptions) {
+		l.AfterNamespace = afterNamespace
+	}
+}
+
+type ListCaveatsOptionsOption func(l *ListCaveatsOptions)
+
+// NewListCaveatsOptionsWithOptions creates a new ListCaveatsOptions with the passed in options set
+func NewListCaveatsOptionsWithOptions(opts ...ListCaveatsOptionsOption) *ListCaveatsOptions {
+	l := &ListCaveatsOptions{}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// ToOption returns a new ListCaveatsOptionsOption that sets the values from the passed in ListCaveatsOptions
+func (l *ListCaveatsOptions) ToOption() ListCaveatsOptionsOption {
+	return func(to *ListCaveatsOptions) {
+		to.CaveatPrefix = l.CaveatPrefix
+		to.CaveatLimit = l.CaveatLimit
+		to.AfterCaveat = l.AfterCaveat
+	}
+}
+
+// ListCaveatsOptionsWithOptions configures an existing ListCaveatsOptions with the passed in options set
+func ListCaveatsOptionsWithOptions(l *ListCaveatsOptions, opts ...ListCaveatsOptionsOption) *ListCaveatsOptions {
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// WithCaveatPrefix returns an option that can set CaveatPrefix on a ListCaveatsOptions
+func WithCaveatPrefix(caveatPrefix string) ListCaveatsOptionsOption {
+	return func(l *ListCaveatsOptions) {
+		l.CaveatPrefix = caveatPrefix
+	}
+}
+
+// WithCaveatLimit returns an option that can set CaveatLimit on a ListCaveatsOptions
+func WithCaveatLimit(caveatLimit *uint64) ListCaveatsOptionsOption {
+	return func(l *ListCaveatsOptions) {
+		l.CaveatLimit = caveatLimit
+	}
+}
+
+// WithAfterCaveat returns an option that can set AfterCaveat on a ListCaveatsOptions
+func WithAfterCaveat(afterCaveat string) ListCaveatsOptionsOption {
+	return func(l *ListCaveatsOptions) {
+		l.AfterCaveat = afterCaveat
+	}
+}
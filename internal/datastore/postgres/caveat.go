@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 
@@ -64,8 +65,32 @@ func (r *pgReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*c
 		caveatsWithNames = caveatsWithNames.Where(sq.Eq{colCaveatName: caveatNames})
 	}
 
-	filteredListCaveat := r.filterer(caveatsWithNames)
-	sql, args, err := filteredListCaveat.ToSql()
+	return r.listCaveats(ctx, r.filterer(caveatsWithNames))
+}
+
+// ListCaveatsWithOptions lists the caveats matching the options, filtering and paginating them in
+// the query.
+func (r *pgReader) ListCaveatsWithOptions(ctx context.Context, opts ...options.ListCaveatsOptionsOption) ([]*core.CaveatDefinition, error) {
+	listOpts := options.NewListCaveatsOptionsWithOptions(opts...)
+
+	// Names are compared bytewise, so that the order and the cursor are independent of the
+	// collation of the database.
+	query := r.filterer(psql.Select(colCaveatDefinition).From(tableCaveat)).OrderBy(colCaveatName + ` COLLATE "C"`)
+	if listOpts.CaveatPrefix != "" {
+		query = query.Where(sq.Like{colCaveatName: escapeLikePattern(listOpts.CaveatPrefix) + "%"})
+	}
+	if listOpts.AfterCaveat != "" {
+		query = query.Where(sq.Expr(colCaveatName+` COLLATE "C" > ?`, listOpts.AfterCaveat))
+	}
+	if listOpts.CaveatLimit != nil {
+		query = query.Limit(*listOpts.CaveatLimit)
+	}
+
+	return r.listCaveats(ctx, query)
+}
+
+func (r *pgReader) listCaveats(ctx context.Context, query sq.SelectBuilder) ([]*core.CaveatDefinition, error) {
+	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf(errListCaveats, err)
 	}
@@ -155,3 +180,5 @@ func (rwt *pgReadWriteTXN) deleteCaveatsFromNames(ctx context.Context, names []s
 	}
 	return nil
 }
+
+var _ datastore.CaveatPageLister = &pgReader{}
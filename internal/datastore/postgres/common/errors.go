@@ -2,17 +2,29 @@ package common
 
 import (
 	"errors"
+	"net"
 	"regexp"
 	"strings"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 
 	dscommon "github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
 const (
 	pgUniqueConstraintViolation = "23505"
+	pgSerializationFailure      = "40001"
+	pgDeadlockDetected          = "40P01"
+	pgAdminShutdown             = "57P01"
+	pgCrashShutdown             = "57P02"
+	pgCannotConnectNow          = "57P03"
+	pgTooManyConnections        = "53300"
+
+	pgIntegrityConstraintViolationClass = "23"
+	pgConnectionExceptionClass          = "08"
 )
 
 var createConflictDetailsRegex = regexp.MustCompile(`^Key (.+)=\(([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+)\) already exists`)
@@ -43,3 +55,50 @@ func ConvertToWriteConstraintError(livingTupleConstraint string, err error) erro
 
 	return nil
 }
+
+// ClassifyError wraps the given error returned by pgx into the typed datastore error for its
+// cause, so that callers can branch on it: serialization failures and deadlocks are
+// datastore.ErrRetryable, constraint violations are datastore.ErrConflict, a missing row is
+// datastore.ErrNotFound, and lost or refused connections are datastore.ErrUnavailable. Errors
+// of any other cause, and errors which are already classified, are returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil || isClassified(err) {
+		return err
+	}
+
+	var pgerr *pgconn.PgError
+	if errors.As(err, &pgerr) {
+		switch {
+		case pgerr.Code == pgSerializationFailure || pgerr.Code == pgDeadlockDetected:
+			return datastore.NewRetryableErr(err)
+		case strings.HasPrefix(pgerr.Code, pgIntegrityConstraintViolationClass):
+			return datastore.NewConflictErr(err)
+		case strings.HasPrefix(pgerr.Code, pgConnectionExceptionClass),
+			pgerr.Code == pgAdminShutdown,
+			pgerr.Code == pgCrashShutdown,
+			pgerr.Code == pgCannotConnectNow,
+			pgerr.Code == pgTooManyConnections:
+			return datastore.NewUnavailableErr(err)
+		default:
+			return err
+		}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return datastore.NewNotFoundErr(err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || pgconn.SafeToRetry(err) {
+		return datastore.NewUnavailableErr(err)
+	}
+
+	return err
+}
+
+func isClassified(err error) bool {
+	return errors.As(err, &datastore.ErrRetryable{}) ||
+		errors.As(err, &datastore.ErrConflict{}) ||
+		errors.As(err, &datastore.ErrNotFound{}) ||
+		errors.As(err, &datastore.ErrUnavailable{})
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+func TestClassifyError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected any
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, datastore.ErrRetryable{}},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, datastore.ErrRetryable{}},
+		{"unique constraint violation", &pgconn.PgError{Code: "23505"}, datastore.ErrConflict{}},
+		{"foreign key violation", &pgconn.PgError{Code: "23503"}, datastore.ErrConflict{}},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, datastore.ErrUnavailable{}},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, datastore.ErrUnavailable{}},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, datastore.ErrUnavailable{}},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, datastore.ErrUnavailable{}},
+		{"no rows", pgx.ErrNoRows, datastore.ErrNotFound{}},
+		{"wrapped", fmt.Errorf("unable to write relationships: %w", &pgconn.PgError{Code: "40001"}), datastore.ErrRetryable{}},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, nil},
+		{"other error", errors.New("something went wrong"), nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			classified := ClassifyError(tc.err)
+			require.ErrorIs(t, classified, tc.err)
+			require.Equal(t, tc.err.Error(), classified.Error())
+
+			switch tc.expected.(type) {
+			case datastore.ErrRetryable:
+				require.ErrorAs(t, classified, &datastore.ErrRetryable{})
+			case datastore.ErrConflict:
+				require.ErrorAs(t, classified, &datastore.ErrConflict{})
+			case datastore.ErrNotFound:
+				require.ErrorAs(t, classified, &datastore.ErrNotFound{})
+			case datastore.ErrUnavailable:
+				require.ErrorAs(t, classified, &datastore.ErrUnavailable{})
+			default:
+				require.Equal(t, tc.err, classified)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorIsIdempotent(t *testing.T) {
+	classified := ClassifyError(&pgconn.PgError{Code: "40001"})
+	require.Equal(t, classified, ClassifyError(classified))
+	require.Nil(t, ClassifyError(nil))
+}
+
+func TestWrapQueryErrorClassifiesError(t *testing.T) {
+	err := wrapQueryError(context.Background(), trace.SpanFromContext(context.Background()), &pgconn.PgError{Code: "08006"})
+	require.ErrorAs(t, err, &datastore.ErrUnavailable{})
+	require.Contains(t, err.Error(), "unable to query tuples")
+
+	var pgerr *pgconn.PgError
+	require.ErrorAs(t, err, &pgerr)
+	require.Equal(t, "08006", pgerr.Code)
+}
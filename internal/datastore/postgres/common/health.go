@@ -0,0 +1,39 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const queryHealthCheck = "SELECT 1"
+
+// CheckPoolHealth checks the health of the database behind the given connection pool by
+// issuing a trivial query. A pool with every connection in use is reported as degraded, as
+// the query would otherwise block until a connection was released.
+func CheckPoolHealth(ctx context.Context, pool *pgxpool.Pool) datastore.HealthCheckResult {
+	stats := PoolStats(pool)
+	if stats.Saturated() {
+		return datastore.Degraded(fmt.Sprintf("all %d connections in the pool are in use", stats.MaxConns))
+	}
+
+	if _, err := pool.Exec(ctx, queryHealthCheck); err != nil {
+		return datastore.Unreachable(err)
+	}
+
+	return datastore.Healthy()
+}
+
+// PoolStats returns the current state of the given connection pool.
+func PoolStats(pool *pgxpool.Pool) datastore.PoolStats {
+	stat := pool.Stat()
+	return datastore.PoolStats{
+		AcquiredConns: stat.AcquiredConns(),
+		IdleConns:     stat.IdleConns(),
+		TotalConns:    stat.TotalConns(),
+		MaxConns:      stat.MaxConns(),
+	}
+}
@@ -5,32 +5,89 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/logging"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/log/zerologadapter"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	errUnableToQueryTuples = "unable to query tuples: %w"
+
+	statementTimeoutKey  = "statementTimeout"
+	statementTimedOutKey = "statementTimedOut"
 )
 
 // NewPGXExecutor creates an executor that uses the pgx library to make the specified queries.
-func NewPGXExecutor(txSource TxFactory) common.ExecuteQueryFunc {
+//
+// Each query is canceled once it has run for longer than the statement timeout, returning an
+// error with a DeadlineExceeded status. The timeout can be overridden for a request with
+// common.ContextWithQueryTimeout. A timeout of zero disables the statement timeout.
+//
+// Each query which runs for at least the slow query threshold is logged, along with its duration
+// and the number of relationships returned. Only the SQL of the query is logged and never its
+// arguments, which contain object IDs. A threshold of zero disables the logging of slow queries.
+func NewPGXExecutor(txSource TxFactory, statementTimeout time.Duration, slowQueryThreshold time.Duration) common.ExecuteQueryFunc {
+	executor := newStatementTimeoutExecutor(txSource, statementTimeout)
+	if slowQueryThreshold <= 0 {
+		return executor
+	}
+
+	return func(ctx context.Context, sql string, args []any) ([]*corev1.RelationTuple, error) {
+		start := time.Now()
+		tuples, err := executor(ctx, sql, args)
+
+		if duration := time.Since(start); duration >= slowQueryThreshold {
+			logging.Ctx(ctx).Warn().
+				Str("query", sql).
+				Dur("duration", duration).
+				Int("rows", len(tuples)).
+				Bool("failed", err != nil).
+				Msg("slow relationships query")
+		}
+
+		return tuples, err
+	}
+}
+
+func newStatementTimeoutExecutor(txSource TxFactory, statementTimeout time.Duration) common.ExecuteQueryFunc {
 	return func(ctx context.Context, sql string, args []any) ([]*corev1.RelationTuple, error) {
 		span := trace.SpanFromContext(ctx)
 
 		tx, txCleanup, err := txSource(ctx)
 		if err != nil {
-			return nil, fmt.Errorf(errUnableToQueryTuples, err)
+			return nil, wrapQueryError(ctx, span, err)
 		}
 		defer txCleanup(ctx)
-		return queryTuples(ctx, sql, args, span, tx)
+
+		timeout := statementTimeout
+		if override, ok := common.QueryTimeoutFromContext(ctx); ok {
+			timeout = override
+		}
+		if timeout <= 0 {
+			return queryTuples(ctx, sql, args, span, tx)
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		tuples, err := queryTuples(queryCtx, sql, args, span, tx)
+		if err != nil && ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+			span.SetAttributes(
+				attribute.Bool(statementTimedOutKey, true),
+				attribute.Stringer(statementTimeoutKey, timeout),
+			)
+		}
+		return tuples, err
 	}
 }
 
@@ -39,7 +96,7 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 	span.AddEvent("DB transaction established")
 	rows, err := tx.Query(ctx, sqlStatement, args...)
 	if err != nil {
-		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		return nil, wrapQueryError(ctx, span, err)
 	}
 	defer rows.Close()
 
@@ -53,7 +110,8 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 		}
 		var caveatName sql.NullString
 		var caveatCtx map[string]any
-		err := rows.Scan(
+		var expiresAt *time.Time
+		dest := []any{
 			&nextTuple.ResourceAndRelation.Namespace,
 			&nextTuple.ResourceAndRelation.ObjectId,
 			&nextTuple.ResourceAndRelation.Relation,
@@ -62,25 +120,70 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 			&nextTuple.Subject.Relation,
 			&caveatName,
 			&caveatCtx,
-		)
-		if err != nil {
-			return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		}
+
+		// Datastores which support expiring relationships select the expiration as an
+		// additional column.
+		if len(rows.FieldDescriptions()) > len(dest) {
+			dest = append(dest, &expiresAt)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, wrapQueryError(ctx, span, err)
 		}
 
 		nextTuple.Caveat, err = common.ContextualizedCaveatFrom(caveatName.String, caveatCtx)
 		if err != nil {
 			return nil, fmt.Errorf("unable to fetch caveat context: %w", err)
 		}
+		nextTuple.ExpiresAt = common.ExpirationFrom(expiresAt)
 		tuples = append(tuples, nextTuple)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		return nil, wrapQueryError(ctx, span, err)
 	}
 
 	span.AddEvent("Tuples loaded", trace.WithAttributes(attribute.Int("tupleCount", len(tuples))))
 	return tuples, nil
 }
 
+// wrapQueryError wraps an error encountered while querying tuples, classified by ClassifyError.
+// If the query failed because its context was canceled or its deadline exceeded, an error with a
+// Canceled or DeadlineExceeded status is returned instead, so that the cancellation is not
+// reported as a failure to query.
+func wrapQueryError(ctx context.Context, span trace.Span, err error) error {
+	ctxErr := ctx.Err()
+	if ctxErr == nil || !(errors.Is(err, ctxErr) || pgconn.Timeout(err)) {
+		return fmt.Errorf(errUnableToQueryTuples, ClassifyError(err))
+	}
+
+	span.RecordError(ctxErr)
+
+	code := codes.Canceled
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		code = codes.DeadlineExceeded
+	}
+
+	return queryCanceledError{fmt.Errorf("query canceled: %w", ctxErr), code}
+}
+
+// queryCanceledError is returned when a query is interrupted because its context was canceled
+// or its deadline exceeded. It unwraps to the context's error.
+type queryCanceledError struct {
+	error
+	code codes.Code
+}
+
+// Unwrap returns the context error which caused the query to be canceled.
+func (err queryCanceledError) Unwrap() error {
+	return err.error
+}
+
+// GRPCStatus returns the Canceled or DeadlineExceeded status for the error.
+func (err queryCanceledError) GRPCStatus() *status.Status {
+	return status.New(err.code, err.Error())
+}
+
 // ConfigurePGXLogger sets zerolog global logger into the connection pool configuration, and maps
 // info level events to debug, as they are rather verbose for SpiceDB's info level
 func ConfigurePGXLogger(connConfig *pgx.ConnConfig) {
@@ -0,0 +1,232 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+)
+
+type fakeTx struct {
+	pgx.Tx
+	rows pgx.Rows
+}
+
+func (tx fakeTx) Query(_ context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	return tx.rows, nil
+}
+
+type failingTx struct {
+	pgx.Tx
+}
+
+func (failingTx) Query(_ context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	return nil, errors.New("connection refused")
+}
+
+// interruptedRows simulates a query interrupted mid-stream: the first call to Next interrupts
+// the query and reports that no further rows are available.
+type interruptedRows struct {
+	pgx.Rows
+	ctx       context.Context
+	interrupt func()
+	closed    bool
+}
+
+func (r *interruptedRows) Next() bool {
+	r.interrupt()
+	return false
+}
+
+func (r *interruptedRows) Err() error {
+	return fmt.Errorf("unexpected EOF: %w", r.ctx.Err())
+}
+
+func (r *interruptedRows) Close() {
+	r.closed = true
+}
+
+func TestQueryTuplesInterrupted(t *testing.T) {
+	testCases := []struct {
+		name         string
+		newContext   func() (context.Context, func())
+		expectedCode codes.Code
+		expectedErr  error
+	}{
+		{
+			"canceled",
+			func() (context.Context, func()) {
+				return context.WithCancel(context.Background())
+			},
+			codes.Canceled,
+			context.Canceled,
+		},
+		{
+			"deadline exceeded",
+			func() (context.Context, func()) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				return ctx, func() {
+					<-ctx.Done()
+					cancel()
+				}
+			},
+			codes.DeadlineExceeded,
+			context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ctx, interrupt := tc.newContext()
+			defer interrupt()
+
+			rows := &interruptedRows{ctx: ctx, interrupt: interrupt}
+			_, err := queryTuples(ctx, "SELECT 1", nil, trace.SpanFromContext(ctx), fakeTx{rows: rows})
+			require.Error(err)
+			require.True(errors.Is(err, tc.expectedErr))
+			require.Equal(tc.expectedCode, status.Code(err))
+			require.NotContains(err.Error(), "unable to query tuples")
+			require.True(rows.closed)
+		})
+	}
+}
+
+func TestQueryTuplesFailedNotInterrupted(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	_, err := queryTuples(ctx, "SELECT 1", nil, trace.SpanFromContext(ctx), failingTx{})
+	require.ErrorContains(err, "unable to query tuples")
+	require.Equal(codes.Unknown, status.Code(err))
+}
+
+// blockingTx simulates a long running query, which only returns once its context is done.
+type blockingTx struct {
+	pgx.Tx
+}
+
+func (blockingTx) Query(ctx context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type recordingSpan struct {
+	trace.Span
+	attributes []attribute.KeyValue
+}
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.attributes = append(s.attributes, kv...)
+}
+
+func blockingTxSource(context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
+	return blockingTx{}, func(context.Context) {}, nil
+}
+
+func TestExecutorStatementTimeout(t *testing.T) {
+	require := require.New(t)
+
+	span := &recordingSpan{Span: trace.SpanFromContext(context.Background())}
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	executor := NewPGXExecutor(blockingTxSource, 10*time.Millisecond, 0)
+	_, err := executor(ctx, "SELECT 1", nil)
+	require.ErrorIs(err, context.DeadlineExceeded)
+	require.Equal(codes.DeadlineExceeded, status.Code(err))
+	require.Contains(span.attributes, attribute.Bool(statementTimedOutKey, true))
+}
+
+func TestExecutorStatementTimeoutOverride(t *testing.T) {
+	require := require.New(t)
+
+	executor := NewPGXExecutor(blockingTxSource, time.Hour, 0)
+
+	ctx := common.ContextWithQueryTimeout(context.Background(), 10*time.Millisecond)
+	_, err := executor(ctx, "SELECT 1", nil)
+	require.Equal(codes.DeadlineExceeded, status.Code(err))
+
+	// A canceled request is not reported as a statement timeout.
+	span := &recordingSpan{Span: trace.SpanFromContext(context.Background())}
+	ctx, cancel := context.WithCancel(trace.ContextWithSpan(context.Background(), span))
+	cancel()
+
+	_, err = executor(ctx, "SELECT 1", nil)
+	require.Equal(codes.Canceled, status.Code(err))
+	require.Empty(span.attributes)
+}
+
+// emptyRows is a result set with no rows.
+type emptyRows struct {
+	pgx.Rows
+}
+
+func (emptyRows) Next() bool { return false }
+
+func (emptyRows) Err() error { return nil }
+
+func (emptyRows) Close() {}
+
+// slowTx simulates a query which takes the given delay to return no rows.
+type slowTx struct {
+	pgx.Tx
+	delay time.Duration
+}
+
+func (tx slowTx) Query(_ context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	time.Sleep(tx.delay)
+	return emptyRows{}, nil
+}
+
+func TestExecutorSlowQueryLogging(t *testing.T) {
+	testCases := []struct {
+		name        string
+		threshold   time.Duration
+		delay       time.Duration
+		expectedLog bool
+	}{
+		{"disabled", 0, 10 * time.Millisecond, false},
+		{"fast query", time.Hour, 0, false},
+		{"slow query", 5 * time.Millisecond, 10 * time.Millisecond, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			ctx := logger.WithContext(context.Background())
+
+			executor := NewPGXExecutor(func(context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
+				return slowTx{delay: tc.delay}, func(context.Context) {}, nil
+			}, 0, tc.threshold)
+
+			_, err := executor(ctx, "SELECT * FROM relation_tuple WHERE object_id = $1", []any{"secret-id"})
+			require.NoError(err)
+
+			if !tc.expectedLog {
+				require.Empty(buf.String())
+				return
+			}
+
+			require.Contains(buf.String(), "slow relationships query")
+			require.Contains(buf.String(), "WHERE object_id = $1")
+			require.Contains(buf.String(), `"rows":0`)
+			require.NotContains(buf.String(), "secret-id")
+		})
+	}
+}
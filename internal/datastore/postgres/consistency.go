@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var (
+	relationshipIdentityCols = []string{
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+	}
+
+	notCurrentlyExpired = sq.Or{sq.Eq{colExpiresAt: nil}, sq.Expr(colExpiresAt + " > NOW()")}
+
+	liveRelationships = psql.Select(relationshipIdentityCols...).
+				From(tableTuple).
+				Where(sq.Eq{colDeletedXid: liveDeletedTxnID}).
+				Where(notCurrentlyExpired)
+
+	undefinedNamespaceFormat = "%s NOT IN (SELECT " + colNamespace + " FROM " + tableNamespace + " WHERE " + colDeletedXid + " = ?)"
+
+	consistencyChecks = []struct {
+		reason string
+		query  sq.SelectBuilder
+	}{
+		{
+			"relationship was deleted before it was created",
+			psql.Select(relationshipIdentityCols...).
+				From(tableTuple).
+				Where(sq.Expr(colCreatedXid + " > " + colDeletedXid)),
+		},
+		{
+			"relationship is live more than once",
+			psql.Select(relationshipIdentityCols...).
+				From(tableTuple).
+				Where(sq.Eq{colDeletedXid: liveDeletedTxnID}).
+				GroupBy(relationshipIdentityCols...).
+				Having("COUNT(*) > 1"),
+		},
+		{
+			"resource object definition is not defined",
+			liveRelationships.Where(sq.Expr(fmt.Sprintf(undefinedNamespaceFormat, colNamespace), liveDeletedTxnID)),
+		},
+		{
+			"subject object definition is not defined",
+			liveRelationships.Where(sq.Expr(fmt.Sprintf(undefinedNamespaceFormat, colUsersetNamespace), liveDeletedTxnID)),
+		},
+	}
+)
+
+// ValidateConsistency implements datastore.ConsistencyValidator. No relationship may have been
+// deleted by a transaction before the one which created it, no relationship may be live more than
+// once, and the namespaces of every live relationship must be defined.
+func (pgd *pgDatastore) ValidateConsistency(ctx context.Context) error {
+	var violations []datastore.ConsistencyViolation
+	if err := pgd.dbpool.BeginTxFunc(ctx, pgd.readTxOptions, func(tx pgx.Tx) error {
+		for _, check := range consistencyChecks {
+			sql, args, err := check.query.ToSql()
+			if err != nil {
+				return fmt.Errorf("unable to prepare consistency check sql: %w", err)
+			}
+
+			rows, err := tx.Query(ctx, sql, args...)
+			if err != nil {
+				return fmt.Errorf("unable to run consistency check: %w", err)
+			}
+
+			for rows.Next() {
+				tpl := &core.RelationTuple{
+					ResourceAndRelation: &core.ObjectAndRelation{},
+					Subject:             &core.ObjectAndRelation{},
+				}
+				if err := rows.Scan(
+					&tpl.ResourceAndRelation.Namespace,
+					&tpl.ResourceAndRelation.ObjectId,
+					&tpl.ResourceAndRelation.Relation,
+					&tpl.Subject.Namespace,
+					&tpl.Subject.ObjectId,
+					&tpl.Subject.Relation,
+				); err != nil {
+					rows.Close()
+					return fmt.Errorf("unable to scan consistency check result: %w", err)
+				}
+
+				violations = append(violations, datastore.ConsistencyViolation{
+					Relationship: tpl,
+					Reason:       check.reason,
+				})
+			}
+			rows.Close()
+
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("unable to read consistency check results: %w", err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return datastore.NewConsistencyViolationsErr(violations)
+	}
+
+	return nil
+}
+
+var _ datastore.ConsistencyValidator = &pgDatastore{}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const errDiffRevisions = "unable to diff revisions: %w"
+
+var queryDiff = psql.Select(
+	colNamespace,
+	colObjectID,
+	colRelation,
+	colUsersetNamespace,
+	colUsersetObjectID,
+	colUsersetRelation,
+	colCaveatContextName,
+	colCaveatContext,
+	colExpiresAt,
+).From(tableTuple)
+
+func (pgd *pgDatastore) DiffRevisions(ctx context.Context, startRevisionRaw, endRevisionRaw datastore.Revision) (*datastore.RevisionDiff, error) {
+	for _, revision := range []datastore.Revision{startRevisionRaw, endRevisionRaw} {
+		if err := pgd.CheckRevision(ctx, revision); err != nil {
+			return nil, err
+		}
+	}
+
+	startRevision := startRevisionRaw.(postgresRevision)
+	endRevision := endRevisionRaw.(postgresRevision)
+	if startRevision.GreaterThan(endRevision) {
+		return nil, fmt.Errorf(errDiffRevisions, fmt.Errorf("start revision %s is after end revision %s", startRevision, endRevision))
+	}
+
+	// A relationship which expired between the revisions is reported as deleted.
+	aliveAtStart := sq.And{livingObjectPredicate(startRevision), notExpiredPredicate(startRevision.tx)}
+	aliveAtEnd := sq.And{livingObjectPredicate(endRevision), notExpiredPredicate(endRevision.tx)}
+
+	created, err := pgd.queryDiffTuples(ctx, sq.And{aliveAtEnd, sq.Expr("NOT (?)", aliveAtStart)})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := pgd.queryDiffTuples(ctx, sq.And{aliveAtStart, sq.Expr("NOT (?)", aliveAtEnd)})
+	if err != nil {
+		return nil, err
+	}
+
+	return common.NewRevisionDiff(created, deleted), nil
+}
+
+func (pgd *pgDatastore) queryDiffTuples(ctx context.Context, predicate sq.Sqlizer) ([]*core.RelationTuple, error) {
+	query, args, err := queryDiff.Where(predicate).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errDiffRevisions, err)
+	}
+
+	rows, err := pgd.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errDiffRevisions, err)
+	}
+	defer rows.Close()
+
+	var tuples []*core.RelationTuple
+	for rows.Next() {
+		nextTuple := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+
+		var caveatName sql.NullString
+		var caveatContext map[string]any
+		var expiresAt *time.Time
+		if err := rows.Scan(
+			&nextTuple.ResourceAndRelation.Namespace,
+			&nextTuple.ResourceAndRelation.ObjectId,
+			&nextTuple.ResourceAndRelation.Relation,
+			&nextTuple.Subject.Namespace,
+			&nextTuple.Subject.ObjectId,
+			&nextTuple.Subject.Relation,
+			&caveatName,
+			&caveatContext,
+			&expiresAt,
+		); err != nil {
+			return nil, fmt.Errorf(errDiffRevisions, err)
+		}
+
+		nextTuple.Caveat, err = common.ContextualizedCaveatFrom(caveatName.String, caveatContext)
+		if err != nil {
+			return nil, fmt.Errorf(errDiffRevisions, err)
+		}
+
+		nextTuple.ExpiresAt = common.ExpirationFrom(expiresAt)
+		tuples = append(tuples, nextTuple)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(errDiffRevisions, err)
+	}
+
+	return tuples, nil
+}
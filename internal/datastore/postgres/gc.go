@@ -91,6 +91,20 @@ func (pgd *pgDatastore) DeleteBeforeTx(ctx context.Context, txID datastore.Revis
 		return
 	}
 
+	// Delete any relationship rows that had already expired as of this transaction. These must be
+	// deleted before the transaction rows, as the expiration is compared to this transaction's
+	// timestamp.
+	expired, err := pgd.batchDelete(
+		ctx,
+		tableTuple,
+		relationTuplePKCols,
+		expiredPredicate(revision.tx),
+	)
+	removed.Relationships += expired
+	if err != nil {
+		return
+	}
+
 	// Delete all transaction rows with ID < the transaction ID.
 	//
 	// We don't delete the transaction itself to ensure there is always at least
@@ -125,7 +139,7 @@ func (pgd *pgDatastore) batchDelete(
 	pkCols []string,
 	filter sqlFilter,
 ) (int64, error) {
-	sql, args, err := psql.Select(pkCols...).From(tableName).Where(filter).Limit(batchDeleteSize).ToSql()
+	sql, args, err := psql.Select(pkCols...).From(tableName).Where(filter).Limit(pgd.gcBatchSize).ToSql()
 	if err != nil {
 		return -1, err
 	}
@@ -146,9 +160,17 @@ func (pgd *pgDatastore) batchDelete(
 
 		rowsDeleted := cr.RowsAffected()
 		deletedCount += rowsDeleted
-		if rowsDeleted < batchDeleteSize {
+		if rowsDeleted < int64(pgd.gcBatchSize) {
 			break
 		}
+
+		if pgd.gcBatchDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return deletedCount, ctx.Err()
+			case <-time.After(pgd.gcBatchDelay):
+			}
+		}
 	}
 
 	return deletedCount, nil
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errUnableToReadHistory = "unable to read relationship history: %w"
+
+	// deletedWithinGCWindow matches the rows deleted by a transaction which is still within the
+	// garbage collection window, and so which may not yet have been collected.
+	//
+	//   %[1] Name of deleted xid column
+	//   %[2] Name of xid column
+	//   %[3] Relationship tuple transaction table
+	//   %[4] Name of timestamp column
+	//   %[5] GC window (in seconds)
+	deletedWithinGCWindow = "%[1]s >= (SELECT %[2]s FROM %[3]s WHERE %[4]s >= NOW() - INTERVAL '%[5]f seconds' ORDER BY %[4]s ASC LIMIT 1)"
+)
+
+// queryRelationshipVersions selects the relationship rows along with the transactions which
+// created and deleted them, as read by scanRelationshipVersions.
+var queryRelationshipVersions = psql.Select(
+	colNamespace,
+	colObjectID,
+	colRelation,
+	colUsersetNamespace,
+	colUsersetObjectID,
+	colUsersetRelation,
+	colCaveatContextName,
+	colCaveatContext,
+	colExpiresAt,
+	colCreatedXid,
+	colDeletedXid,
+).From(tableTuple)
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader. Rows are versioned by the
+// transactions which created and deleted them, so the history is read by querying the rows of
+// the relationship without the liveness predicate.
+func (pgd *pgDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	query, args, err := queryRelationshipVersions.Where(sq.Eq{
+		colNamespace:        tpl.ResourceAndRelation.Namespace,
+		colObjectID:         tpl.ResourceAndRelation.ObjectId,
+		colRelation:         tpl.ResourceAndRelation.Relation,
+		colUsersetNamespace: tpl.Subject.Namespace,
+		colUsersetObjectID:  tpl.Subject.ObjectId,
+		colUsersetRelation:  stringz.DefaultEmpty(tpl.Subject.Relation, datastore.Ellipsis),
+	}).Where(sq.Or{
+		sq.Eq{colDeletedXid: liveDeletedTxnID},
+		sq.Expr(fmt.Sprintf(deletedWithinGCWindow, colDeletedXid, colXID, tableTransaction, colTimestamp, pgd.gcWindow.Seconds())),
+	}).OrderBy(colCreatedXid).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+
+	rows, err := pgd.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+	defer rows.Close()
+
+	versions, err := scanRelationshipVersions(rows)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+	return versions, nil
+}
+
+// scanRelationshipVersions reads the rows selected by queryRelationshipVersions. A row whose
+// deleting transaction is still the live sentinel is returned with a DeletedAt of NoRevision.
+func scanRelationshipVersions(rows pgx.Rows) ([]datastore.RelationshipVersion, error) {
+	var versions []datastore.RelationshipVersion
+	for rows.Next() {
+		tpl := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+		var caveatName sql.NullString
+		var caveatContext map[string]any
+		var expiresAt *time.Time
+		var createdXid, deletedXid xid8
+		if err := rows.Scan(
+			&tpl.ResourceAndRelation.Namespace,
+			&tpl.ResourceAndRelation.ObjectId,
+			&tpl.ResourceAndRelation.Relation,
+			&tpl.Subject.Namespace,
+			&tpl.Subject.ObjectId,
+			&tpl.Subject.Relation,
+			&caveatName,
+			&caveatContext,
+			&expiresAt,
+			&createdXid,
+			&deletedXid,
+		); err != nil {
+			return nil, err
+		}
+
+		var err error
+		tpl.Caveat, err = common.ContextualizedCaveatFrom(caveatName.String, caveatContext)
+		if err != nil {
+			return nil, err
+		}
+		tpl.ExpiresAt = common.ExpirationFrom(expiresAt)
+
+		version := datastore.RelationshipVersion{
+			Relationship: tpl,
+			CreatedAt:    postgresRevision{createdXid, noXmin},
+			DeletedAt:    datastore.NoRevision,
+		}
+		if deletedXid.Status == pgtype.Present && deletedXid.Uint != liveDeletedTxnID {
+			version.DeletedAt = postgresRevision{deletedXid, noXmin}
+		}
+
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+var _ datastore.RelationshipHistoryReader = &pgDatastore{}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var addIdempotencyKeyStmts = []string{
+	`ALTER TABLE relation_tuple_transaction
+		ADD COLUMN idempotency_key VARCHAR;`,
+	`CREATE UNIQUE INDEX ix_relation_tuple_transaction_idempotency_key
+		ON relation_tuple_transaction (idempotency_key);`,
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-transaction-idempotency-key", "drop-bigserial-ids",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			for _, stmt := range addIdempotencyKeyStmts {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
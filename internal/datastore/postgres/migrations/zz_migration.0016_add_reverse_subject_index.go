@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// createReverseSubjectIndex indexes relationships by their subject, leading with the subject
+// type. The index created by add-reverse-index leads with the subject object ID, and so cannot
+// serve reverse queries which filter on the subject type without a subject ID.
+const createReverseSubjectIndex = `CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_relation_tuple_by_subject_ns_id_rel
+	ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, relation)`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-reverse-subject-index", "add-transaction-idempotency-key",
+		func(ctx context.Context, conn *pgx.Conn) error {
+			// CREATE INDEX CONCURRENTLY cannot run inside a transaction block (SQLSTATE 25001)
+			_, err := conn.Exec(ctx, createReverseSubjectIndex)
+			return err
+		}, noTxMigration,
+	); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
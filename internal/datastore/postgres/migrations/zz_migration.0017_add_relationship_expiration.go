@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addExpirationColumn = `ALTER TABLE relation_tuple
+	ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITHOUT TIME ZONE;`
+
+// createExpirationIndex indexes only the relationships which expire, so that garbage collection
+// can find expired relationships without scanning the relationships which never expire.
+const createExpirationIndex = `CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_relation_tuple_expires_at
+	ON relation_tuple (expires_at) WHERE expires_at IS NOT NULL`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-expiration", "add-reverse-subject-index",
+		func(ctx context.Context, conn *pgx.Conn) error {
+			if _, err := conn.Exec(ctx, addExpirationColumn); err != nil {
+				return err
+			}
+
+			// CREATE INDEX CONCURRENTLY cannot run inside a transaction block (SQLSTATE 25001)
+			_, err := conn.Exec(ctx, createExpirationIndex)
+			return err
+		}, noTxMigration,
+	); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -18,8 +18,13 @@ type postgresOptions struct {
 	gcWindow             time.Duration
 	gcInterval           time.Duration
 	gcMaxOperationTime   time.Duration
+	gcBatchSize          uint64
+	gcBatchDelay         time.Duration
 	splitAtUsersetCount  uint16
 	maxRetries           uint8
+	retryBackoff         time.Duration
+	statementTimeout     time.Duration
+	slowQueryThreshold   time.Duration
 
 	enablePrometheusStats   bool
 	analyzeBeforeStatistics bool
@@ -27,6 +32,8 @@ type postgresOptions struct {
 
 	migrationPhase string
 
+	readReplicaURL string
+
 	logger *tracingLogger
 }
 
@@ -46,17 +53,22 @@ var migrationPhases = map[string]migrationPhase{
 
 const (
 	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
+	errGCBatchSizeZero      = "GC batch size must be greater than zero"
 
 	defaultWatchBufferLength                 = 128
 	defaultGarbageCollectionWindow           = 24 * time.Hour
 	defaultGarbageCollectionInterval         = time.Minute * 3
 	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultGarbageCollectionBatchSize        = 1000
+	defaultGarbageCollectionBatchDelay       = 0
 	defaultUsersetBatchSize                  = 1024
 	defaultQuantization                      = 5 * time.Second
 	defaultMaxRevisionStalenessPercent       = 0.1
 	defaultEnablePrometheusStats             = false
 	defaultMaxRetries                        = 10
+	defaultRetryBackoff                      = 10 * time.Millisecond
 	defaultGCEnabled                         = true
+	defaultStatementTimeout                  = time.Minute
 )
 
 // Option provides the facility to configure how clients within the
@@ -68,13 +80,17 @@ func generateConfig(options []Option) (postgresOptions, error) {
 		gcWindow:                    defaultGarbageCollectionWindow,
 		gcInterval:                  defaultGarbageCollectionInterval,
 		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		gcBatchSize:                 defaultGarbageCollectionBatchSize,
+		gcBatchDelay:                defaultGarbageCollectionBatchDelay,
 		watchBufferLength:           defaultWatchBufferLength,
 		splitAtUsersetCount:         defaultUsersetBatchSize,
 		revisionQuantization:        defaultQuantization,
 		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
 		enablePrometheusStats:       defaultEnablePrometheusStats,
 		maxRetries:                  defaultMaxRetries,
+		retryBackoff:                defaultRetryBackoff,
 		gcEnabled:                   defaultGCEnabled,
+		statementTimeout:            defaultStatementTimeout,
 	}
 
 	for _, option := range options {
@@ -90,6 +106,10 @@ func generateConfig(options []Option) (postgresOptions, error) {
 		)
 	}
 
+	if computed.gcBatchSize == 0 {
+		return computed, fmt.Errorf(errGCBatchSizeZero)
+	}
+
 	if _, ok := migrationPhases[computed.migrationPhase]; !ok {
 		return computed, fmt.Errorf("unknown migration phase: %s", computed.migrationPhase)
 	}
@@ -215,6 +235,27 @@ func GCMaxOperationTime(time time.Duration) Option {
 	}
 }
 
+// GCBatchSize is the maximum number of rows deleted by a single statement
+// during a garbage collection pass. Smaller batches hold locks for less time,
+// at the cost of more round trips.
+//
+// This value defaults to 1000.
+func GCBatchSize(batchSize uint64) Option {
+	return func(po *postgresOptions) {
+		po.gcBatchSize = batchSize
+	}
+}
+
+// GCBatchDelay is the amount of time to wait between deletion batches during
+// a garbage collection pass, to reduce the load placed on a busy database.
+//
+// This value defaults to no delay.
+func GCBatchDelay(delay time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.gcBatchDelay = delay
+	}
+}
+
 // MaxRetries is the maximum number of times a retriable transaction will be
 // client-side retried.
 // Default: 10
@@ -224,6 +265,39 @@ func MaxRetries(maxRetries uint8) Option {
 	}
 }
 
+// RetryBackoff is the amount of time to wait before the first client-side retry
+// of a transaction which failed to serialize or deadlocked. The wait doubles on
+// each further retry, up to a maximum of one second.
+//
+// This value defaults to 10 milliseconds.
+func RetryBackoff(backoff time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.retryBackoff = backoff
+	}
+}
+
+// StatementTimeout is the maximum amount of time a query for relationships can run before it is
+// canceled. It can be overridden for a request with common.ContextWithQueryTimeout. A timeout of
+// zero disables the statement timeout.
+//
+// This value defaults to 1 minute.
+func StatementTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.statementTimeout = timeout
+	}
+}
+
+// SlowQueryThreshold is the amount of time a query for relationships must run for before it is
+// logged as a slow query, with its SQL, duration and row count. The arguments of the query are
+// never logged. A threshold of zero disables the logging of slow queries.
+//
+// This value defaults to 0 (disabled).
+func SlowQueryThreshold(threshold time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.slowQueryThreshold = threshold
+	}
+}
+
 // WithEnablePrometheusStats marks whether Prometheus metrics provided by the Postgres
 // clients being used by the datastore are enabled.
 //
@@ -264,6 +338,24 @@ func DebugAnalyzeBeforeStatistics() Option {
 	}
 }
 
+// ReadReplicaConnURI is the connection string of a read replica of the database, such as a hot
+// standby fed by streaming replication. When configured, a second connection pool is opened to the
+// replica, using the same pool settings as the primary, and reads at a revision are routed to the
+// replica once it has replayed the transaction of that revision. Reads at revisions the replica has
+// not yet replayed, writes, revision selection, watch and garbage collection always use the primary.
+//
+// Reads served by the replica are exactly as of their revision, so the staleness of a request is
+// bounded by the revision it selects: requests with minimize_latency consistency read at a quantized
+// revision, and are therefore served by a replica lagging by less than the revision quantization
+// interval, while fully consistent requests will usually fall back to the primary.
+//
+// No replica is used by default.
+func ReadReplicaConnURI(url string) Option {
+	return func(po *postgresOptions) {
+		po.readReplicaURL = url
+	}
+}
+
 // MigrationPhase configures the postgres driver to the proper state of a
 // multi-phase migration.
 //
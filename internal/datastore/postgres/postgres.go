@@ -5,11 +5,12 @@ import (
 	dbsql "database/sql"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/pgxpoolprometheus"
 	sq "github.com/Masterminds/squirrel"
-	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jackc/pgx/v4/stdlib"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
@@ -54,6 +56,8 @@ const (
 	colCaveatDefinition  = "definition"
 	colCaveatContextName = "caveat_name"
 	colCaveatContext     = "caveat_context"
+	colIdempotencyKey    = "idempotency_key"
+	colExpiresAt         = "expires_at"
 
 	errUnableToInstantiate = "unable to instantiate datastore: %w"
 
@@ -65,13 +69,19 @@ const (
 	// 5: a squirrel library placeholder string, i.e. `?`
 	snapshotAlive = "pg_visible_in_snapshot(%[1]s, (SELECT %[2]s FROM %[3]s WHERE %[4]s = %[5]s)) = %[5]s"
 
+	// The parameters to this format string are:
+	// 1: the expires_at column name
+	// 2: the transaction table's timestamp column name
+	// 3: the transaction table name
+	// 4: the transaction table's xid column name
+	// 5: a squirrel library placeholder string, i.e. `?`
+	expiredAtTransaction = "%[1]s <= (SELECT %[2]s FROM %[3]s WHERE %[4]s = %[5]s)"
+
 	// This is the largest positive integer possible in postgresql
 	liveDeletedTxnID = uint64(9223372036854775807)
 
 	tracingDriverName = "postgres-tracing"
 
-	batchDeleteSize = 1000
-
 	pgSerializationFailure      = "40001"
 	pgUniqueConstraintViolation = "23505"
 
@@ -98,8 +108,26 @@ var (
 		colSnapshot,
 	)
 
+	createTxnWithIdempotencyKey = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES ($1) RETURNING %s, pg_snapshot_xmin(%s)",
+		tableTransaction,
+		colIdempotencyKey,
+		colXID,
+		colSnapshot,
+	)
+
+	getRevisionForIdempotencyKey = psql.
+					Select(colXID, fmt.Sprintf("pg_snapshot_xmin(%s)", colSnapshot), colTimestamp).
+					From(tableTransaction)
+
+	clearIdempotencyKey = psql.Update(tableTransaction).Set(colIdempotencyKey, nil)
+
 	getNow = psql.Select("NOW()")
 
+	// queryCurrentSnapshotXmin returns the xmin of the current snapshot: every transaction with a
+	// lower ID has either committed or aborted. On a read replica, this is as of its replay position.
+	queryCurrentSnapshotXmin = "SELECT pg_snapshot_xmin(pg_current_snapshot());"
+
 	tracer = otel.Tracer("spicedb/internal/datastore/postgres")
 )
 
@@ -167,11 +195,34 @@ func newPostgresDatastore(
 		log.Warn().Msg("watch API disabled, postgres must be run with track_commit_timestamp=on")
 	}
 
+	var readReplicaPool *pgxpool.Pool
+	if config.readReplicaURL != "" {
+		replicaPgxConfig, err := pgxpool.ParseConfig(config.readReplicaURL)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+
+		configurePool(config, replicaPgxConfig)
+
+		readReplicaPool, err = pgxpool.ConnectConfig(initializationContext, replicaPgxConfig)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+
+		log.Info().Msg("postgres configured to read from a replica when it has caught up")
+	}
+
 	if config.enablePrometheusStats {
 		collector := pgxpoolprometheus.NewCollector(dbpool, map[string]string{"db_name": "spicedb"})
 		if err := prometheus.Register(collector); err != nil {
 			return nil, fmt.Errorf(errUnableToInstantiate, err)
 		}
+		if readReplicaPool != nil {
+			replicaCollector := pgxpoolprometheus.NewCollector(readReplicaPool, map[string]string{"db_name": "spicedb-replica"})
+			if err := prometheus.Register(replicaCollector); err != nil {
+				return nil, fmt.Errorf(errUnableToInstantiate, err)
+			}
+		}
 		if err := common.RegisterGCMetrics(); err != nil {
 			return nil, fmt.Errorf(errUnableToInstantiate, err)
 		}
@@ -209,12 +260,15 @@ func newPostgresDatastore(
 		),
 		dburl:                   url,
 		dbpool:                  dbpool,
+		readReplicaPool:         readReplicaPool,
 		watchBufferLength:       config.watchBufferLength,
 		optimizedRevisionQuery:  revisionQuery,
 		validTransactionQuery:   validTransactionQuery,
 		gcWindow:                config.gcWindow,
 		gcInterval:              config.gcInterval,
 		gcTimeout:               config.gcMaxOperationTime,
+		gcBatchSize:             config.gcBatchSize,
+		gcBatchDelay:            config.gcBatchDelay,
 		analyzeBeforeStatistics: config.analyzeBeforeStatistics,
 		usersetBatchSize:        config.splitAtUsersetCount,
 		watchEnabled:            watchEnabled,
@@ -222,6 +276,9 @@ func newPostgresDatastore(
 		cancelGc:                cancelGc,
 		readTxOptions:           pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly},
 		maxRetries:              config.maxRetries,
+		retryBackoff:            config.retryBackoff,
+		statementTimeout:        config.statementTimeout,
+		slowQueryThreshold:      config.slowQueryThreshold,
 	}
 
 	datastore.SetOptimizedRevisionFunc(datastore.optimizedRevisionFunc)
@@ -278,18 +335,27 @@ type pgDatastore struct {
 
 	dburl                   string
 	dbpool                  *pgxpool.Pool
+	readReplicaPool         *pgxpool.Pool
 	watchBufferLength       uint16
 	optimizedRevisionQuery  string
 	validTransactionQuery   string
 	gcWindow                time.Duration
 	gcInterval              time.Duration
 	gcTimeout               time.Duration
+	gcBatchSize             uint64
+	gcBatchDelay            time.Duration
 	usersetBatchSize        uint16
 	analyzeBeforeStatistics bool
 	readTxOptions           pgx.TxOptions
 	maxRetries              uint8
+	retryBackoff            time.Duration
+	statementTimeout        time.Duration
+	slowQueryThreshold      time.Duration
 	watchEnabled            bool
 
+	// replicaXmin is the latest snapshot xmin observed on the read replica, if any.
+	replicaXmin atomic.Uint64
+
 	gcGroup  *errgroup.Group
 	gcCtx    context.Context
 	cancelGc context.CancelFunc
@@ -298,8 +364,17 @@ type pgDatastore struct {
 func (pgd *pgDatastore) SnapshotReader(revRaw datastore.Revision) datastore.Reader {
 	rev := revRaw.(postgresRevision)
 
+	// The pool is selected once per reader, so that all of its queries are read from the same
+	// database.
+	var readPool *pgxpool.Pool
+	var selectPool sync.Once
+
 	createTxFunc := func(ctx context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
-		tx, err := pgd.dbpool.BeginTx(ctx, pgd.readTxOptions)
+		selectPool.Do(func() {
+			readPool = pgd.readPoolForRevision(ctx, rev)
+		})
+
+		tx, err := readPool.BeginTx(ctx, pgd.readTxOptions)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -314,7 +389,7 @@ func (pgd *pgDatastore) SnapshotReader(revRaw datastore.Revision) datastore.Read
 	}
 
 	querySplitter := common.TupleQuerySplitter{
-		Executor:         pgxcommon.NewPGXExecutor(createTxFunc),
+		Executor:         pgxcommon.NewPGXExecutor(createTxFunc, pgd.statementTimeout, pgd.slowQueryThreshold),
 		UsersetBatchSize: pgd.usersetBatchSize,
 	}
 
@@ -322,23 +397,38 @@ func (pgd *pgDatastore) SnapshotReader(revRaw datastore.Revision) datastore.Read
 		createTxFunc,
 		querySplitter,
 		buildLivingObjectFilterForRevision(rev),
+		notExpiredPredicate(rev.tx),
 	}
 }
 
 func noCleanup(context.Context) {}
 
 // ReadWriteTx tarts a read/write transaction, which will be committed if no error is
-// returned and rolled back if an error is returned.
+// returned and rolled back if an error is returned. A transaction which fails to serialize or
+// deadlocks is retried with backoff, running fn again from the start, so that any reads it
+// makes, such as the evaluation of preconditions, see the data against which it is retried.
 func (pgd *pgDatastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
-	var err error
-	for i := uint8(0); i <= pgd.maxRetries; i++ {
-		var newXID, newXmin xid8
-		err = pgd.dbpool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newXID, newXmin xid8
+	var existing datastore.Revision
+	err := executeWithRetries(ctx, pgd.maxRetries, pgd.retryBackoff, func(ctx context.Context) error {
+		existing = nil
+		return pgd.dbpool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			if config.IdempotencyKey != "" {
+				var err error
+				existing, err = pgd.revisionForIdempotencyKey(ctx, tx, config.IdempotencyKey)
+				if err != nil || existing != nil {
+					return err
+				}
+			}
+
 			var err error
-			newXID, newXmin, err = createNewTransaction(ctx, tx)
+			newXID, newXmin, err = createNewTransaction(ctx, tx, config.IdempotencyKey)
 			if err != nil {
 				return err
 			}
@@ -348,7 +438,7 @@ func (pgd *pgDatastore) ReadWriteTx(
 			}
 
 			querySplitter := common.TupleQuerySplitter{
-				Executor:         pgxcommon.NewPGXExecutor(longLivedTx),
+				Executor:         pgxcommon.NewPGXExecutor(longLivedTx, pgd.statementTimeout, pgd.slowQueryThreshold),
 				UsersetBatchSize: pgd.usersetBatchSize,
 			}
 
@@ -357,6 +447,7 @@ func (pgd *pgDatastore) ReadWriteTx(
 					longLivedTx,
 					querySplitter,
 					currentlyLivingObjects,
+					notExpiredPredicate(newXID),
 				},
 				tx,
 				newXID,
@@ -364,16 +455,54 @@ func (pgd *pgDatastore) ReadWriteTx(
 
 			return fn(rwt)
 		})
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	return postgresRevision{newXID, newXmin}, nil
+}
+
+// revisionForIdempotencyKey returns the revision of the transaction previously committed with the
+// given idempotency key, or nil if there is none within the GC window. A key found outside of the
+// GC window is released, so that it can be recorded with the new transaction.
+func (pgd *pgDatastore) revisionForIdempotencyKey(ctx context.Context, tx pgx.Tx, idempotencyKey string) (datastore.Revision, error) {
+	sql, args, err := getRevisionForIdempotencyKey.Where(sq.Eq{colIdempotencyKey: idempotencyKey}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var xid, xmin xid8
+	var timestamp time.Time
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&xid, &xmin, &timestamp); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	now, err := pgd.Now(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if timestamp.Before(now.Add(-pgd.gcWindow)) {
+		sql, args, err := clearIdempotencyKey.Where(sq.Eq{colIdempotencyKey: idempotencyKey}).ToSql()
 		if err != nil {
-			if errorRetryable(err) {
-				continue
-			}
-			return datastore.NoRevision, err
+			return nil, err
 		}
 
-		return postgresRevision{newXID, newXmin}, nil
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return nil, err
+		}
+		return nil, nil
 	}
-	return datastore.NoRevision, fmt.Errorf("max retries exceeded: %w", err)
+
+	return postgresRevision{xid, xmin}, nil
 }
 
 func (pgd *pgDatastore) Close() error {
@@ -385,20 +514,10 @@ func (pgd *pgDatastore) Close() error {
 	}
 
 	pgd.dbpool.Close()
-	return nil
-}
-
-func errorRetryable(err error) bool {
-	var pgerr *pgconn.PgError
-	if !errors.As(err, &pgerr) {
-		log.Debug().Err(err).Msg("couldn't determine a sqlstate error code")
-		return false
+	if pgd.readReplicaPool != nil {
+		pgd.readReplicaPool.Close()
 	}
-
-	// We need to check unique constraint here because some versions of postgres have an error where
-	// unique constraint violations are raised instead of serialization errors.
-	// (e.g. https://www.postgresql.org/message-id/flat/CAGPCyEZG76zjv7S31v_xPeLNRuzj-m%3DY2GOY7PEzu7vhB%3DyQog%40mail.gmail.com)
-	return pgerr.SQLState() == pgSerializationFailure || pgerr.SQLState() == pgUniqueConstraintViolation
+	return nil
 }
 
 func (pgd *pgDatastore) IsReady(ctx context.Context) (bool, error) {
@@ -421,11 +540,31 @@ func (pgd *pgDatastore) IsReady(ctx context.Context) (bool, error) {
 	return version == headMigration, nil
 }
 
+func (pgd *pgDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	return pgxcommon.CheckPoolHealth(ctx, pgd.dbpool)
+}
+
+// PoolStats implements datastore.PoolStatsReporter.
+func (pgd *pgDatastore) PoolStats() datastore.PoolStats {
+	return pgxcommon.PoolStats(pgd.dbpool)
+}
+
 func (pgd *pgDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: pgd.watchEnabled}}, nil
+	return &datastore.Features{
+		Watch:       datastore.Feature{Enabled: pgd.watchEnabled},
+		WatchSchema: datastore.Feature{Enabled: pgd.watchEnabled},
+	}, nil
 }
 
 func buildLivingObjectFilterForRevision(revision postgresRevision) queryFilterer {
+	aliveAtRevision := livingObjectPredicate(revision)
+	return func(original sq.SelectBuilder) sq.SelectBuilder {
+		return original.Where(aliveAtRevision)
+	}
+}
+
+// livingObjectPredicate returns a predicate matching the rows alive as of the revision.
+func livingObjectPredicate(revision postgresRevision) sq.Sqlizer {
 	createdBeforeTXN := sq.Expr(fmt.Sprintf(
 		snapshotAlive,
 		colCreatedXid,
@@ -453,8 +592,35 @@ func buildLivingObjectFilterForRevision(revision postgresRevision) queryFilterer
 		sq.Expr(colDeletedXid+" <> "+sq.Placeholders(1), revision.tx),
 	}
 
-	return func(original sq.SelectBuilder) sq.SelectBuilder {
-		return original.Where(alreadyAlive).Where(notYetDead)
+	return sq.And{alreadyAlive, notYetDead}
+}
+
+// expiredPredicate returns a predicate matching the relationship rows which have expired as of
+// the time of the given transaction.
+func expiredPredicate(txID xid8) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf(
+		expiredAtTransaction,
+		colExpiresAt,
+		colTimestamp,
+		tableTransaction,
+		colXID,
+		sq.Placeholders(1),
+	), txID)
+}
+
+// notExpiredPredicate returns a predicate matching the relationship rows which have not expired
+// as of the time of the given transaction.
+func notExpiredPredicate(txID xid8) sq.Sqlizer {
+	return sq.Or{
+		sq.Eq{colExpiresAt: nil},
+		sq.Expr(fmt.Sprintf(
+			"NOT ("+expiredAtTransaction+")",
+			colExpiresAt,
+			colTimestamp,
+			tableTransaction,
+			colXID,
+			sq.Placeholders(1),
+		), txID),
 	}
 }
 
@@ -462,4 +628,7 @@ func currentlyLivingObjects(original sq.SelectBuilder) sq.SelectBuilder {
 	return original.Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 }
 
-var _ datastore.Datastore = &pgDatastore{}
+var (
+	_ datastore.Datastore         = &pgDatastore{}
+	_ datastore.PoolStatsReporter = &pgDatastore{}
+)
@@ -7,11 +7,15 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
@@ -73,6 +77,54 @@ func TestPostgresDatastore(t *testing.T) {
 				}))
 			})
 
+			t.Run("WithReadReplica", func(t *testing.T) {
+				// Use the primary as its own replica, which has always replayed every revision, so
+				// that all reads are served by the replica pool.
+				test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+					ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+						ds, err := newPostgresDatastore(uri,
+							RevisionQuantization(revisionQuantization),
+							GCWindow(gcWindow),
+							WatchBufferLength(watchBufferLength),
+							DebugAnalyzeBeforeStatistics(),
+							ReadReplicaConnURI(uri),
+							MigrationPhase(config.migrationPhase),
+						)
+						require.NoError(t, err)
+						return ds
+					})
+
+					return ds, nil
+				}))
+			})
+
+			t.Run("RelationshipRevisionsDeletedAt", func(t *testing.T) {
+				// Deleted rows are retained until garbage collected, along with the deleting
+				// transaction.
+				test.RelationshipRevisionsDeletedAtTest(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+					ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+						ds, err := newPostgresDatastore(uri,
+							RevisionQuantization(revisionQuantization),
+							GCWindow(gcWindow),
+							WatchBufferLength(watchBufferLength),
+							MigrationPhase(config.migrationPhase),
+						)
+						require.NoError(t, err)
+						return ds
+					})
+					return ds, nil
+				}), true)
+			})
+
+			t.Run("ReadReplicaRouting", createDatastoreTest(
+				b,
+				ReadReplicaRoutingTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				WatchBufferLength(1),
+				MigrationPhase(config.migrationPhase),
+			))
+
 			t.Run("GarbageCollection", createDatastoreTest(
 				b,
 				GarbageCollectionTest,
@@ -109,6 +161,26 @@ func TestPostgresDatastore(t *testing.T) {
 				MigrationPhase(config.migrationPhase),
 			))
 
+			t.Run("ChunkedGarbageCollectionSmallBatches", createDatastoreTest(
+				b,
+				ChunkedGarbageCollectionTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				GCBatchSize(7),
+				GCBatchDelay(1*time.Millisecond),
+				WatchBufferLength(1),
+				MigrationPhase(config.migrationPhase),
+			))
+
+			t.Run("ReverseQueryUsesIndex", createDatastoreTest(
+				b,
+				ReverseQueryIndexTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				WatchBufferLength(1),
+				MigrationPhase(config.migrationPhase),
+			))
+
 			t.Run("QuantizedRevisions", func(t *testing.T) {
 				QuantizedRevisionTest(t, b)
 			})
@@ -126,6 +198,25 @@ func TestPostgresDatastore(t *testing.T) {
 					WatchBufferLength(1),
 					MigrationPhase(config.migrationPhase),
 				))
+
+				t.Run("SerializationFailureRetried", createDatastoreTest(
+					b,
+					SerializationFailureRetriedTest,
+					RevisionQuantization(0),
+					GCWindow(24*time.Hour),
+					WatchBufferLength(1),
+					MigrationPhase(config.migrationPhase),
+					RetryBackoff(time.Millisecond),
+				))
+
+				t.Run("ConsistencyViolations", createDatastoreTest(
+					b,
+					ConsistencyViolationsTest,
+					RevisionQuantization(0),
+					GCWindow(24*time.Hour),
+					WatchBufferLength(1),
+					MigrationPhase(config.migrationPhase),
+				))
 			}
 		})
 	}
@@ -313,7 +404,7 @@ func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
 	tx, err := pgd.dbpool.Begin(ctx)
 	require.NoError(err)
 
-	txXID, _, err := createNewTransaction(ctx, tx)
+	txXID, _, err := createNewTransaction(ctx, tx, "")
 	require.NoError(err)
 
 	err = tx.Commit(ctx)
@@ -680,6 +771,121 @@ func RevisionInversionTest(t *testing.T, ds datastore.Datastore) {
 	require.False(commitFirstRev.Equal(commitLastRev))
 }
 
+func ConsistencyViolationsTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, namespace.Namespace("user"), namespace.Namespace("document", namespace.Relation("viewer", nil)))
+	})
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH,
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:second#viewer@user:tom"),
+	)
+	require.NoError(err)
+
+	validator := ds.(datastore.ConsistencyValidator)
+	require.NoError(validator.ValidateConsistency(ctx))
+
+	// Corrupt the relationships, bypassing the checks made on writes.
+	pgd := ds.(*pgDatastore)
+	insertTuple := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s, %s, %s, %s) VALUES ($1, $2, 'viewer', 'user', 'tom', '...')",
+		tableTuple, colNamespace, colObjectID, colRelation, colUsersetNamespace, colUsersetObjectID, colUsersetRelation,
+	)
+	_, err = pgd.dbpool.Exec(ctx, insertTuple, "document", "first")
+	require.NoError(err)
+	_, err = pgd.dbpool.Exec(ctx, insertTuple, "folder", "somefolder")
+	require.NoError(err)
+	_, err = pgd.dbpool.Exec(ctx, fmt.Sprintf("UPDATE %s SET %s = '1' WHERE %s = 'second'", tableTuple, colDeletedXid, colObjectID))
+	require.NoError(err)
+
+	err = validator.ValidateConsistency(ctx)
+	var violationsErr datastore.ErrConsistencyViolations
+	require.ErrorAs(err, &violationsErr)
+
+	foundViolations := make(map[string]string, len(violationsErr.Violations()))
+	for _, violation := range violationsErr.Violations() {
+		foundViolations[tuple.MustString(violation.Relationship)] = violation.Reason
+	}
+
+	require.Equal(map[string]string{
+		"document:first#viewer@user:tom":    "relationship is live more than once",
+		"document:second#viewer@user:tom":   "relationship was deleted before it was created",
+		"folder:somefolder#viewer@user:tom": "resource object definition is not defined",
+	}, foundViolations)
+}
+
+func SerializationFailureRetriedTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, namespace.Namespace("user"), namespace.Namespace("document", namespace.Relation("viewer", nil)))
+	})
+	require.NoError(err)
+
+	// Fail the first attempt to serialize after having written, which must be rolled back.
+	toWrite := tuple.MustParse("document:first#viewer@user:tom")
+	attempts := 0
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		attempts++
+		if err := rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(toWrite)}); err != nil {
+			return err
+		}
+
+		if attempts == 1 {
+			return &pgconn.PgError{Code: pgSerializationFailure}
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(2, attempts)
+
+	exists, err := datastore.RelationshipExists(ctx, ds.SnapshotReader(revision), toWrite)
+	require.NoError(err)
+	require.True(exists)
+
+	// Errors which are not retryable are returned after a single attempt.
+	attempts = 0
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		attempts++
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(toWrite)})
+	})
+	require.Error(err)
+	require.Equal(1, attempts)
+}
+
+func ReadReplicaRoutingTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	pds := ds.(*pgDatastore)
+	_, revision := testfixtures.StandardDatastoreWithData(ds, require)
+	rev := revision.(postgresRevision)
+
+	// Without a replica, reads always use the primary.
+	require.Same(pds.dbpool, pds.readPoolForRevision(ctx, rev))
+
+	// Use the primary as its own replica: having replayed the revision, it serves the read.
+	replicaPool, err := pgxpool.Connect(ctx, pds.dburl)
+	require.NoError(err)
+	defer replicaPool.Close()
+
+	pds.readReplicaPool = replicaPool
+	defer func() {
+		pds.readReplicaPool = nil
+	}()
+	require.Same(replicaPool, pds.readPoolForRevision(ctx, rev))
+	require.Greater(pds.replicaXmin.Load(), rev.tx.Uint)
+
+	// A revision which the replica has not yet replayed is read from the primary.
+	future := postgresRevision{xid8{pds.replicaXmin.Load() + 1000, pgtype.Present}, noXmin}
+	require.Same(pds.dbpool, pds.readPoolForRevision(ctx, future))
+}
+
 func WatchNotEnabledTest(t *testing.T, b testdatastore.RunningEngineForTest) {
 	require := require.New(t)
 
@@ -704,6 +910,64 @@ func WatchNotEnabledTest(t *testing.T, b testdatastore.RunningEngineForTest) {
 	require.Contains(err.Error(), "track_commit_timestamp=on")
 }
 
+func ReverseQueryIndexTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, _ = testfixtures.StandardDatastoreWithData(ds, require)
+	pgd := ds.(*pgDatastore)
+
+	conn, err := pgd.dbpool.Acquire(ctx)
+	require.NoError(err)
+	defer conn.Release()
+
+	// The test tables are small enough that a sequential scan would otherwise always be
+	// cheapest, so they are disabled to check that the index can serve the queries.
+	_, err = conn.Exec(ctx, "SET enable_seqscan = off")
+	require.NoError(err)
+	defer func() {
+		_, err := conn.Exec(ctx, "RESET enable_seqscan")
+		require.NoError(err)
+	}()
+
+	// ix_relation_tuple_by_subject leads with the subject object ID, so it cannot serve queries
+	// which filter on the subject type alone; the reverse index leads with the subject type.
+	for _, tc := range []struct {
+		name  string
+		query sq.SelectBuilder
+	}{
+		{
+			"subject type",
+			queryTuples.Where(sq.Eq{colUsersetNamespace: "user"}),
+		},
+		{
+			"subject type and resource type",
+			queryTuples.
+				Where(sq.Eq{colUsersetNamespace: "user"}).
+				Where(sq.Eq{colNamespace: "document"}),
+		},
+	} {
+		query, args, err := tc.query.ToSql()
+		require.NoError(err)
+
+		rows, err := conn.Query(ctx, "EXPLAIN "+query, args...)
+		require.NoError(err)
+
+		var plan []string
+		for rows.Next() {
+			var line string
+			require.NoError(rows.Scan(&line))
+			plan = append(plan, line)
+		}
+		rows.Close()
+		require.NoError(rows.Err())
+
+		explained := strings.Join(plan, "\n")
+		require.Contains(explained, "ix_relation_tuple_by_subject_ns_id_rel", "expected %s query to use the reverse subject index:\n%s", tc.name, explained)
+		require.NotContains(explained, "Seq Scan", "expected %s query not to scan sequentially:\n%s", tc.name, explained)
+	}
+}
+
 func BenchmarkPostgresQuery(b *testing.B) {
 	req := require.New(b)
 
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v4"
+	"github.com/jzelinskie/stringz"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/options"
@@ -19,6 +21,9 @@ type pgReader struct {
 	txSource      pgxcommon.TxFactory
 	querySplitter common.TupleQuerySplitter
 	filterer      queryFilterer
+
+	// notExpired matches the relationships which have not expired as of the revision being read.
+	notExpired sq.Sqlizer
 }
 
 type queryFilterer func(original sq.SelectBuilder) sq.SelectBuilder
@@ -33,6 +38,7 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiresAt,
 	).From(tableTuple)
 
 	schema = common.SchemaInformation{
@@ -46,11 +52,16 @@ var (
 	}
 
 	readNamespace = psql.Select(colConfig, colCreatedXid).From(tableNamespace)
+
+	queryTupleExists = psql.Select("1").From(tableTuple)
 )
 
 const (
 	errUnableToReadConfig     = "unable to read namespace config: %w"
 	errUnableToListNamespaces = "unable to list namespaces: %w"
+	errUnableToCheckExistence = "unable to check relationship existence: %w"
+	errUnableToReadRevisions  = "unable to read relationship revisions: %w"
+	errUnableToCheckNamespace = "unable to check namespace relationships: %w"
 )
 
 func (r *pgReader) QueryRelationships(
@@ -58,7 +69,16 @@ func (r *pgReader) QueryRelationships(
 	filter datastore.RelationshipsFilter,
 	opts ...options.QueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, r.filterer(queryTuples)).FilterWithRelationshipsFilter(filter)
+	qBuilder := common.NewSchemaQueryFilterer(schema, r.queryLivingTuples()).FilterWithRelationshipsFilter(filter)
+	return r.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
+}
+
+func (r *pgReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(schema, r.queryLivingTuples()).FilterToResourceTypes(resourceTypes)
 	return r.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
@@ -67,7 +87,7 @@ func (r *pgReader) ReverseQueryRelationships(
 	subjectsFilter datastore.SubjectsFilter,
 	opts ...options.ReverseQueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, r.filterer(queryTuples)).
+	qBuilder := common.NewSchemaQueryFilterer(schema, r.queryLivingTuples()).
 		FilterWithSubjectsFilter(subjectsFilter)
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
@@ -84,6 +104,106 @@ func (r *pgReader) ReverseQueryRelationships(
 	)
 }
 
+// RelationshipExists checks for the given relationship with a single row lookup, without loading it.
+func (r *pgReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	tx, txCleanup, err := r.txSource(ctx)
+	if err != nil {
+		return false, fmt.Errorf(errUnableToCheckExistence, err)
+	}
+	defer txCleanup(ctx)
+
+	sql, args, err := r.filterer(queryTupleExists).Where(r.notExpired).Where(sq.Eq{
+		colNamespace:        tpl.ResourceAndRelation.Namespace,
+		colObjectID:         tpl.ResourceAndRelation.ObjectId,
+		colRelation:         tpl.ResourceAndRelation.Relation,
+		colUsersetNamespace: tpl.Subject.Namespace,
+		colUsersetObjectID:  tpl.Subject.ObjectId,
+		colUsersetRelation:  stringz.DefaultEmpty(tpl.Subject.Relation, datastore.Ellipsis),
+	}).Limit(1).ToSql()
+	if err != nil {
+		return false, fmt.Errorf(errUnableToCheckExistence, err)
+	}
+
+	var exists int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf(errUnableToCheckExistence, err)
+	}
+
+	return true, nil
+}
+
+// NamespaceHasRelationships checks for a live relationship with a resource or subject of the
+// namespace with a single row lookup.
+func (r *pgReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	tx, txCleanup, err := r.txSource(ctx)
+	if err != nil {
+		return false, fmt.Errorf(errUnableToCheckNamespace, err)
+	}
+	defer txCleanup(ctx)
+
+	sql, args, err := r.filterer(queryTupleExists).Where(r.notExpired).Where(sq.Or{
+		sq.Eq{colNamespace: nsName},
+		sq.Eq{colUsersetNamespace: nsName},
+	}).Limit(1).ToSql()
+	if err != nil {
+		return false, fmt.Errorf(errUnableToCheckNamespace, err)
+	}
+
+	var exists int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf(errUnableToCheckNamespace, err)
+	}
+
+	return true, nil
+}
+
+// QueryRelationshipsWithRevisions reads the transactions which created and deleted each row along
+// with the relationship. A row read at a past revision may have since been deleted by a later
+// transaction, which is returned as its DeletedAt.
+func (r *pgReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	tx, txCleanup, err := r.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+	defer txCleanup(ctx)
+
+	query, args, err := common.NewSchemaQueryFilterer(schema, r.filterer(queryRelationshipVersions).Where(r.notExpired)).
+		FilterWithRelationshipsFilter(filter).
+		QueryBuilder(opts...).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+	defer rows.Close()
+
+	versions, err := scanRelationshipVersions(rows)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+	return versions, nil
+}
+
+// queryLivingTuples returns the query for the relationships which are alive, and have not
+// expired, as of the revision being read.
+func (r *pgReader) queryLivingTuples() sq.SelectBuilder {
+	return r.filterer(queryTuples).Where(r.notExpired)
+}
+
 func (r *pgReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
 	tx, txCleanup, err := r.txSource(ctx)
 	if err != nil {
@@ -120,14 +240,29 @@ func (r *pgReader) loadNamespace(ctx context.Context, namespace string, tx pgx.T
 	return defs[0].nsDef, defs[0].revision, nil
 }
 
-func (r *pgReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (r *pgReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	tx, txCleanup, err := r.txSource(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer txCleanup(ctx)
 
-	nsDefsWithRevisions, err := loadAllNamespaces(ctx, tx, r.filterer)
+	listOpts := options.NewListNamespacesOptionsWithOptions(opts...)
+	nsDefsWithRevisions, err := loadAllNamespaces(ctx, tx, func(original sq.SelectBuilder) sq.SelectBuilder {
+		// Names are compared bytewise, so that the order and the cursor are independent of the
+		// collation of the database.
+		query := r.filterer(original).OrderBy(colNamespace + ` COLLATE "C"`)
+		if listOpts.NamespacePrefix != "" {
+			query = query.Where(sq.Like{colNamespace: escapeLikePattern(listOpts.NamespacePrefix) + "%"})
+		}
+		if listOpts.AfterNamespace != "" {
+			query = query.Where(sq.Expr(colNamespace+` COLLATE "C" > ?`, listOpts.AfterNamespace))
+		}
+		if listOpts.NamespaceLimit != nil {
+			query = query.Limit(*listOpts.NamespaceLimit)
+		}
+		return query
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errUnableToListNamespaces, err)
 	}
@@ -161,6 +296,14 @@ func (r *pgReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*c
 	return stripRevisions(nsDefsWithRevisions), err
 }
 
+// escapeLikePattern escapes the characters of the given string which are special within a LIKE
+// pattern, using the default escape character.
+func escapeLikePattern(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 func stripRevisions(defsWithRevisions []nsAndVersion) []*core.NamespaceDefinition {
 	nsDefs := make([]*core.NamespaceDefinition, 0, len(defsWithRevisions))
 	for _, defWithRevision := range defsWithRevisions {
@@ -215,4 +358,9 @@ func loadAllNamespaces(
 	return nsDefs, nil
 }
 
-var _ datastore.Reader = &pgReader{}
+var (
+	_ datastore.Reader                        = &pgReader{}
+	_ datastore.RelationshipExistenceChecker  = &pgReader{}
+	_ datastore.NamespaceRelationshipsChecker = &pgReader{}
+	_ datastore.RelationshipRevisionsReader   = &pgReader{}
+)
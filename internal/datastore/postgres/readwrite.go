@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
@@ -11,9 +12,12 @@ import (
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/util"
 )
 
 const (
@@ -21,6 +25,7 @@ const (
 	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
 	errUnableToWriteRelationships  = "unable to write relationships: %w"
 	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToRewriteSubject      = "unable to rewrite relationships subject: %w"
 )
 
 var (
@@ -42,6 +47,7 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiresAt,
 	)
 
 	deleteTuple = psql.Update(tableTuple).Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
@@ -54,9 +60,23 @@ type pgReadWriteTXN struct {
 }
 
 func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	_, err := rwt.writeRelationships(ctx, mutations, false)
+	return err
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter. The rows replaced
+// by TOUCH and DELETE mutations are returned by the statement which marks them deleted.
+func (rwt *pgReadWriteTXN) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	return rwt.writeRelationships(ctx, mutations, true)
+}
+
+func (rwt *pgReadWriteTXN) writeRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate, withResults bool) ([]datastore.RelationshipUpdateResult, error) {
+	mutations = datastore.CanonicalizeSubjectRelations(mutations)
+
 	bulkWrite := writeTuple
 	bulkWriteHasValues := false
 	deleteClauses := sq.Or{}
+	expiredClauses := sq.Or{}
 
 	// Process the actual updates
 	for _, mut := range mutations {
@@ -66,6 +86,11 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 			deleteClauses = append(deleteClauses, exactRelationshipClause(tpl))
 		}
 
+		// An expired relationship is treated as deleted, and can therefore be created again.
+		if mut.Operation == core.RelationTupleUpdate_CREATE {
+			expiredClauses = append(expiredClauses, exactRelationshipClause(tpl))
+		}
+
 		if mut.Operation == core.RelationTupleUpdate_TOUCH || mut.Operation == core.RelationTupleUpdate_CREATE {
 			var caveatName string
 			var caveatContext map[string]any
@@ -82,6 +107,7 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 				tpl.Subject.Relation,
 				caveatName,
 				caveatContext, // PGX driver serializes map[string]any to JSONB type columns
+				expiresAtValue(mut),
 			}
 
 			bulkWrite = bulkWrite.Values(valuesToWrite...)
@@ -89,38 +115,115 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 		}
 	}
 
+	liveReplaced := util.NewSet[string]()
 	if len(deleteClauses) > 0 {
-		sql, args, err := deleteTuple.
+		query := deleteTuple.
 			Where(deleteClauses).
+			Set(colDeletedXid, rwt.newXID)
+
+		if !withResults {
+			sql, args, err := query.ToSql()
+			if err != nil {
+				return nil, fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+
+			if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
+				return nil, fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+		} else {
+			expired := fmt.Sprintf(expiredAtTransaction, colExpiresAt, colTimestamp, tableTransaction, colXID, sq.Placeholders(1))
+			sql, args, err := query.Suffix(fmt.Sprintf(
+				"RETURNING %s, %s, %s, %s, %s, %s, %s",
+				colNamespace, colObjectID, colRelation, colUsersetNamespace, colUsersetObjectID, colUsersetRelation, expired,
+			), rwt.newXID).ToSql()
+			if err != nil {
+				return nil, fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+
+			if err := rwt.collectLiveReplaced(ctx, sql, args, liveReplaced); err != nil {
+				return nil, fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+		}
+	}
+
+	if len(expiredClauses) > 0 {
+		sql, args, err := deleteTuple.
+			Where(expiredClauses).
+			Where(expiredPredicate(rwt.newXID)).
 			Set(colDeletedXid, rwt.newXID).
 			ToSql()
 		if err != nil {
-			return fmt.Errorf(errUnableToWriteRelationships, err)
+			return nil, fmt.Errorf(errUnableToWriteRelationships, err)
 		}
 
 		if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
-			return fmt.Errorf(errUnableToWriteRelationships, err)
+			return nil, fmt.Errorf(errUnableToWriteRelationships, err)
 		}
 	}
 
 	if bulkWriteHasValues {
 		sql, args, err := bulkWrite.ToSql()
 		if err != nil {
-			return fmt.Errorf(errUnableToWriteRelationships, err)
+			return nil, fmt.Errorf(errUnableToWriteRelationships, err)
 		}
 
 		if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
 			// If a unique constraint violation is returned, then its likely that the cause
 			// was an existing relationship given as a CREATE.
 			if cerr := pgxcommon.ConvertToWriteConstraintError(livingTupleConstraint, err); cerr != nil {
-				return cerr
+				return nil, cerr
 			}
 
-			return fmt.Errorf(errUnableToWriteRelationships, err)
+			return nil, fmt.Errorf(errUnableToWriteRelationships, err)
 		}
 	}
 
-	return nil
+	if !withResults {
+		return nil, nil
+	}
+
+	results := make([]datastore.RelationshipUpdateResult, 0, len(mutations))
+	for _, mut := range mutations {
+		existed := liveReplaced.Has(tuple.StringWithoutCaveat(mut.Tuple))
+		results = append(results, datastore.UpdateResultFor(mut.Operation, existed))
+	}
+	return results, nil
+}
+
+// collectLiveReplaced runs the given statement, which returns the identity of each relationship
+// row it marks deleted followed by whether that row had expired, and adds the identities of
+// those which had not to the given set.
+func (rwt *pgReadWriteTXN) collectLiveReplaced(ctx context.Context, sql string, args []any, liveReplaced *util.Set[string]) error {
+	rows, err := rwt.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tpl := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+		var expired *bool
+		if err := rows.Scan(
+			&tpl.ResourceAndRelation.Namespace,
+			&tpl.ResourceAndRelation.ObjectId,
+			&tpl.ResourceAndRelation.Relation,
+			&tpl.Subject.Namespace,
+			&tpl.Subject.ObjectId,
+			&tpl.Subject.Relation,
+			&expired,
+		); err != nil {
+			return err
+		}
+
+		// A relationship without an expiration yields NULL.
+		if expired == nil || !*expired {
+			liveReplaced.Add(tuple.StringWithoutCaveat(tpl))
+		}
+	}
+	return rows.Err()
 }
 
 func (rwt *pgReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
@@ -156,6 +259,128 @@ func (rwt *pgReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.R
 	return nil
 }
 
+func (rwt *pgReadWriteTXN) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	// The subject columns are the prefix of the reverse subject index.
+	query := deleteTuple.Where(sq.Eq{
+		colUsersetNamespace: subject.Namespace,
+		colUsersetObjectID:  subject.ObjectId,
+		colUsersetRelation:  subject.Relation,
+	}).Set(colDeletedXid, rwt.newXID)
+
+	// Relationships which have expired but have not yet been garbage collected are deleted as
+	// well, so that no trace of the subject remains, but are not counted as they were not live.
+	sql, args, err := query.Where(expiredPredicate(rwt.newXID)).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	sql, args, err = query.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	result, err := rwt.tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	return uint64(result.RowsAffected()), nil
+}
+
+func (rwt *pgReadWriteTXN) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	if proto.Equal(oldSubject, newSubject) {
+		return 0, nil
+	}
+
+	// Delete the live relationships of the old subject, returning what is needed to recreate them.
+	sql, args, err := deleteTuple.Where(sq.Eq{
+		colUsersetNamespace: oldSubject.Namespace,
+		colUsersetObjectID:  oldSubject.ObjectId,
+		colUsersetRelation:  oldSubject.Relation,
+	}).
+		Where(notExpiredPredicate(rwt.newXID)).
+		Set(colDeletedXid, rwt.newXID).
+		Suffix(fmt.Sprintf("RETURNING %s, %s, %s, %s, %s, %s",
+			colNamespace, colObjectID, colRelation, colCaveatContextName, colCaveatContext, colExpiresAt)).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+
+	rows, err := rwt.tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+	defer rows.Close()
+
+	bulkWrite := writeTuple
+	var rewritten uint64
+	for rows.Next() {
+		var namespace, objectID, relation string
+		var caveatName *string
+		var caveatContext map[string]any
+		var expiresAt *time.Time
+		if err := rows.Scan(&namespace, &objectID, &relation, &caveatName, &caveatContext, &expiresAt); err != nil {
+			return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+		}
+
+		bulkWrite = bulkWrite.Values(
+			namespace,
+			objectID,
+			relation,
+			newSubject.Namespace,
+			newSubject.ObjectId,
+			newSubject.Relation,
+			caveatName,
+			caveatContext,
+			expiresAt,
+		)
+		rewritten++
+	}
+	if rows.Err() != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, rows.Err())
+	}
+	rows.Close()
+
+	if rewritten == 0 {
+		return 0, nil
+	}
+
+	// Expired relationships of the new subject which have not yet been garbage collected would
+	// otherwise conflict with those being rewritten.
+	sql, args, err = deleteTuple.Where(sq.Eq{
+		colUsersetNamespace: newSubject.Namespace,
+		colUsersetObjectID:  newSubject.ObjectId,
+		colUsersetRelation:  newSubject.Relation,
+	}).
+		Where(expiredPredicate(rwt.newXID)).
+		Set(colDeletedXid, rwt.newXID).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+
+	if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+
+	// Relationships which the new subject already has are kept as they are.
+	sql, args, err = bulkWrite.Suffix("ON CONFLICT DO NOTHING").ToSql()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+
+	if _, err := rwt.tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf(errUnableToRewriteSubject, err)
+	}
+
+	return rewritten, nil
+}
+
 func (rwt *pgReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
 	deletedNamespaceClause := sq.Or{}
 	writeQuery := writeNamespace
@@ -198,7 +423,7 @@ func (rwt *pgReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*c
 	return nil
 }
 
-func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
 	filterer := func(original sq.SelectBuilder) sq.SelectBuilder {
 		return original.Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 	}
@@ -217,7 +442,13 @@ func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...stri
 		}
 
 		nsClauses = append(nsClauses, sq.Eq{colNamespace: nsName, colCreatedXid: createdAt.tx})
-		tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName})
+		tplClauses = append(tplClauses, sq.Eq{colNamespace: nsName}, sq.Eq{colUsersetNamespace: nsName})
+	}
+
+	if delOption == datastore.DeleteNamespacesOnlyIfEmpty {
+		if err := common.EnsureNoRelationshipsForNamespaces(ctx, rwt, nsNames...); err != nil {
+			return err
+		}
 	}
 
 	delSQL, delArgs, err := deleteNamespace.
@@ -249,6 +480,18 @@ func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...stri
 	return nil
 }
 
+// expiresAtValue returns the value for the expires_at column of the relationship written by the
+// mutation. The transaction table's timestamps are not timezone aware, so the expiration is
+// written in UTC.
+func expiresAtValue(mut *core.RelationTupleUpdate) *time.Time {
+	if mut.Tuple.ExpiresAt == nil {
+		return nil
+	}
+
+	expiresAt := mut.Tuple.ExpiresAt.AsTime().UTC()
+	return &expiresAt
+}
+
 func exactRelationshipClause(r *core.RelationTuple) sq.Eq {
 	return sq.Eq{
 		colNamespace:        r.ResourceAndRelation.Namespace,
@@ -260,4 +503,7 @@ func exactRelationshipClause(r *core.RelationTuple) sq.Eq {
 	}
 }
 
-var _ datastore.ReadWriteTransaction = &pgReadWriteTXN{}
+var (
+	_ datastore.ReadWriteTransaction       = &pgReadWriteTXN{}
+	_ datastore.RelationshipUpdateReporter = &pgReadWriteTXN{}
+)
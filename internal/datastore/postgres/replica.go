@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+// readPoolForRevision returns the pool over which to read at the given revision: the read replica
+// if one is configured and it has replayed the transaction of the revision, and the primary
+// otherwise.
+//
+// The transactions visible at a revision all committed before the transaction of the revision
+// itself, and a replica replays transactions in commit order, so once the transaction of the
+// revision has been replayed, reading from the replica returns exactly what the primary would.
+func (pgd *pgDatastore) readPoolForRevision(ctx context.Context, rev postgresRevision) *pgxpool.Pool {
+	if pgd.readReplicaPool == nil {
+		return pgd.dbpool
+	}
+
+	if rev.tx.Uint < pgd.replicaXmin.Load() {
+		return pgd.readReplicaPool
+	}
+
+	var replicaXmin xid8
+	if err := pgd.readReplicaPool.QueryRow(ctx, queryCurrentSnapshotXmin).Scan(&replicaXmin); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("unable to determine the replay position of the read replica; reading from the primary")
+		return pgd.dbpool
+	}
+
+	// The replay position only ever moves forward, so the latest one observed is kept to avoid
+	// querying the replica for revisions it is already known to have replayed.
+	for current := pgd.replicaXmin.Load(); replicaXmin.Uint > current; current = pgd.replicaXmin.Load() {
+		if pgd.replicaXmin.CompareAndSwap(current, replicaXmin.Uint) {
+			break
+		}
+	}
+
+	if rev.tx.Uint < replicaXmin.Uint {
+		return pgd.readReplicaPool
+	}
+
+	return pgd.dbpool
+}
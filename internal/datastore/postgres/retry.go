@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// maxRetryBackoff is the maximum amount of time to wait between two attempts of a transaction.
+const maxRetryBackoff = time.Second
+
+// executeWithRetries runs fn, running it again for up to maxRetries retries while it fails with
+// a retryable error. The wait before the first retry is initialBackoff, doubling on each further
+// retry up to maxRetryBackoff. Errors are returned classified, as per pgxcommon.ClassifyError, and
+// a non-retryable error is returned immediately.
+func executeWithRetries(ctx context.Context, maxRetries uint8, initialBackoff time.Duration, fn func(context.Context) error) error {
+	backoff := initialBackoff
+	var err error
+	for attempt := uint8(0); attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return fmt.Errorf("retry canceled: %w", pgxcommon.ClassifyError(err))
+			}
+
+			backoff *= 2
+			if backoff > maxRetryBackoff {
+				backoff = maxRetryBackoff
+			}
+		}
+
+		err = fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if !errorRetryable(err) {
+			return pgxcommon.ClassifyError(err)
+		}
+
+		log.Ctx(ctx).Debug().Err(err).Uint8("attempt", attempt).Msg("retrying transaction")
+	}
+
+	return fmt.Errorf("max retries exceeded: %w", pgxcommon.ClassifyError(err))
+}
+
+func errorRetryable(err error) bool {
+	if errors.As(pgxcommon.ClassifyError(err), &datastore.ErrRetryable{}) {
+		return true
+	}
+
+	// We need to check unique constraint here because some versions of postgres have an error where
+	// unique constraint violations are raised instead of serialization errors.
+	// (e.g. https://www.postgresql.org/message-id/flat/CAGPCyEZG76zjv7S31v_xPeLNRuzj-m%3DY2GOY7PEzu7vhB%3DyQog%40mail.gmail.com)
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.SQLState() == pgUniqueConstraintViolation
+}
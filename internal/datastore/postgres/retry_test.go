@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+func TestExecuteWithRetries(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: pgSerializationFailure}
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	notRetryable := errors.New("not retryable")
+
+	tests := []struct {
+		name             string
+		maxRetries       uint8
+		errs             []error
+		expectedAttempts int
+		expectedErr      error
+	}{
+		{"succeeds first time", 3, []error{nil}, 1, nil},
+		{"serialization failure then success", 3, []error{serializationFailure, nil}, 2, nil},
+		{"deadlock then success", 3, []error{deadlock, nil}, 2, nil},
+		{"not retryable", 3, []error{notRetryable, nil}, 1, notRetryable},
+		{"retryable then not retryable", 3, []error{serializationFailure, notRetryable}, 2, notRetryable},
+		{
+			"out of retries",
+			2,
+			[]error{serializationFailure, serializationFailure, serializationFailure, nil},
+			3,
+			datastore.ErrRetryable{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			err := executeWithRetries(context.Background(), tc.maxRetries, time.Millisecond, func(context.Context) error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			})
+			require.Equal(t, tc.expectedAttempts, attempts)
+
+			switch {
+			case tc.expectedErr == nil:
+				require.NoError(t, err)
+			case errors.As(tc.expectedErr, &datastore.ErrRetryable{}):
+				require.ErrorAs(t, err, &datastore.ErrRetryable{})
+				require.ErrorContains(t, err, "max retries exceeded")
+			default:
+				require.ErrorIs(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestExecuteWithRetriesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := executeWithRetries(ctx, 3, time.Hour, func(context.Context) error {
+		attempts++
+		cancel()
+		return &pgconn.PgError{Code: pgSerializationFailure}
+	})
+	require.Equal(t, 1, attempts)
+	require.ErrorAs(t, err, &datastore.ErrRetryable{})
+}
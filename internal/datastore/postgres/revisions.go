@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/shopspring/decimal"
@@ -19,6 +20,7 @@ const (
 	errRevision       = "unable to find revision: %w"
 	errCheckRevision  = "unable to check revision: %w"
 	errRevisionFormat = "invalid revision format: %w"
+	errRevisionAtTime = "unable to find revision at time: %w"
 
 	// querySelectRevision will round the database's timestamp down to the nearest
 	// quantization period, and then find the first transaction (and its active xmin)
@@ -102,6 +104,35 @@ func (pgd *pgDatastore) CheckRevision(ctx context.Context, revisionRaw datastore
 	return nil
 }
 
+func (pgd *pgDatastore) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	now, err := pgd.Now(ctx)
+	if err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	// RelationTupleTransaction is not timezone aware -- explicitly use UTC
+	// before using as a query arg.
+	at = at.UTC()
+	if at.Before(now.Add(-pgd.gcWindow)) {
+		return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+	}
+
+	sql, args, err := getRevision.Where(sq.LtOrEq{colTimestamp: at}).ToSql()
+	if err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	var revision, xmin xid8
+	if err := pgd.dbpool.QueryRow(ctx, sql, args...).Scan(&revision, &xmin); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(at)
+		}
+		return datastore.NoRevision, fmt.Errorf(errRevisionAtTime, err)
+	}
+
+	return postgresRevision{revision, xmin}, nil
+}
+
 func (pgd *pgDatastore) RevisionFromString(revisionStr string) (datastore.Revision, error) {
 	return parseRevision(revisionStr)
 }
@@ -170,10 +201,15 @@ func (pgd *pgDatastore) loadRevision(ctx context.Context) (xid8, xid8, error) {
 	return revision, xmin, nil
 }
 
-func createNewTransaction(ctx context.Context, tx pgx.Tx) (newXID, newXmin xid8, err error) {
+func createNewTransaction(ctx context.Context, tx pgx.Tx, idempotencyKey string) (newXID, newXmin xid8, err error) {
 	ctx, span := tracer.Start(ctx, "createNewTransaction")
 	defer span.End()
 
+	if idempotencyKey != "" {
+		err = tx.QueryRow(ctx, createTxnWithIdempotencyKey, idempotencyKey).Scan(&newXID, &newXmin)
+		return
+	}
+
 	err = tx.QueryRow(ctx, createTxn).Scan(&newXID, &newXmin)
 	return
 }
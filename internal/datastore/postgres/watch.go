@@ -7,6 +7,7 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"google.golang.org/protobuf/types/known/structpb"
 
@@ -40,9 +41,26 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiresAt,
 		colCreatedXid,
 		colDeletedXid,
 	).From(tableTuple)
+
+	queryTransactionTimestamps = psql.Select(colXID, colTimestamp).From(tableTransaction)
+
+	queryChangedNamespaces = psql.Select(
+		colNamespace,
+		colConfig,
+		colCreatedXid,
+		colDeletedXid,
+	).From(tableNamespace)
+
+	queryChangedCaveats = psql.Select(
+		colCaveatName,
+		colCaveatDefinition,
+		colCreatedXid,
+		colDeletedXid,
+	).From(tableCaveat)
 )
 
 func (pgd *pgDatastore) Watch(
@@ -64,9 +82,10 @@ func (pgd *pgDatastore) Watch(
 		defer close(errs)
 
 		currentTxn := afterRevision
+		checkpointXmin := afterRevision.xmin
 
 		for {
-			newTxns, err := pgd.getNewRevisions(ctx, currentTxn)
+			newTxns, currentXmin, err := pgd.getNewRevisions(ctx, currentTxn)
 			if err != nil {
 				if errors.Is(ctx.Err(), context.Canceled) {
 					errs <- datastore.NewWatchCanceledErr()
@@ -77,7 +96,7 @@ func (pgd *pgDatastore) Watch(
 			}
 
 			if len(newTxns) > 0 {
-				changesToWrite, err := pgd.loadChanges(ctx, newTxns)
+				changesToWrite, err := pgd.loadChanges(ctx, currentTxn, newTxns)
 				if err != nil {
 					if errors.Is(ctx.Err(), context.Canceled) {
 						errs <- datastore.NewWatchCanceledErr()
@@ -101,6 +120,14 @@ func (pgd *pgDatastore) Watch(
 					currentTxn = changeToWrite.Revision.(postgresRevision)
 				}
 			} else {
+				// Every transaction below the current xmin has completed, and none which committed
+				// since the current transaction is visible, so the changes through the current
+				// transaction remain complete as of the xmin, which is emitted as a checkpoint.
+				if checkpointXmin.Status != pgtype.Present || currentXmin.Uint > checkpointXmin.Uint {
+					checkpointXmin = currentXmin
+					common.SendCheckpoint(updates, postgresRevision{currentTxn.tx, currentXmin})
+				}
+
 				sleep := time.NewTimer(watchSleep)
 
 				select {
@@ -117,12 +144,19 @@ func (pgd *pgDatastore) Watch(
 	return updates, errs
 }
 
+// getNewRevisions returns the transactions committed since the after transaction, along with the
+// xmin of the snapshot in which they were found.
 func (pgd *pgDatastore) getNewRevisions(
 	ctx context.Context,
 	afterTX postgresRevision,
-) ([]postgresRevision, error) {
+) ([]postgresRevision, xid8, error) {
 	var ids []postgresRevision
+	var currentXmin xid8
 	if err := pgd.dbpool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead}, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, queryCurrentSnapshotXmin).Scan(&currentXmin); err != nil {
+			return fmt.Errorf("unable to load current snapshot: %w", err)
+		}
+
 		rows, err := tx.Query(ctx, newRevisionsQuery, afterTX.tx)
 		if err != nil {
 			return fmt.Errorf("unable to load new revisions: %w", err)
@@ -142,13 +176,13 @@ func (pgd *pgDatastore) getNewRevisions(
 		}
 		return nil
 	}); err != nil {
-		return nil, fmt.Errorf("transaction error: %w", err)
+		return nil, xid8{}, fmt.Errorf("transaction error: %w", err)
 	}
 
-	return ids, nil
+	return ids, currentXmin, nil
 }
 
-func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRevision) ([]datastore.RevisionChanges, error) {
+func (pgd *pgDatastore) loadChanges(ctx context.Context, afterRevision postgresRevision, revisions []postgresRevision) ([]datastore.RevisionChanges, error) {
 	min := revisions[0].tx.Uint
 	max := revisions[0].tx.Uint
 	filter := make(map[uint64]int, len(revisions))
@@ -163,7 +197,7 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 		filter[rev.tx.Uint] = i
 	}
 
-	sql, args, err := queryChanged.Where(sq.Or{
+	changedInRange := sq.Or{
 		sq.And{
 			sq.LtOrEq{colCreatedXid: max},
 			sq.GtOrEq{colCreatedXid: min},
@@ -172,7 +206,9 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 			sq.LtOrEq{colDeletedXid: max},
 			sq.GtOrEq{colDeletedXid: min},
 		},
-	}).ToSql()
+	}
+
+	sql, args, err := queryChanged.Where(changedInRange).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("unable to prepare changes SQL: %w", err)
 	}
@@ -192,6 +228,7 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 		var createdXID, deletedXID xid8
 		var caveatName string
 		var caveatContext map[string]any
+		var expiresAt *time.Time
 		if err := changes.Scan(
 			&nextTuple.ResourceAndRelation.Namespace,
 			&nextTuple.ResourceAndRelation.ObjectId,
@@ -201,6 +238,7 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 			&nextTuple.Subject.Relation,
 			&caveatName,
 			&caveatContext,
+			&expiresAt,
 			&createdXID,
 			&deletedXID,
 		); err != nil {
@@ -217,6 +255,7 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 				Context:    contextStruct,
 			}
 		}
+		nextTuple.ExpiresAt = common.ExpirationFrom(expiresAt)
 
 		if _, found := filter[createdXID.Uint]; found {
 			tracked.AddChange(ctx, postgresRevision{createdXID, noXmin}, nextTuple, core.RelationTupleUpdate_TOUCH)
@@ -229,8 +268,197 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revisions []postgresRev
 		return nil, fmt.Errorf("unable to load changes for XID: %w", err)
 	}
 
+	if err := pgd.loadExpirations(ctx, tracked, afterRevision, revisions); err != nil {
+		return nil, err
+	}
+
+	if err := pgd.loadSchemaChanges(ctx, tracked, filter, changedInRange); err != nil {
+		return nil, err
+	}
+
 	reconciledChanges := tracked.AsRevisionChanges(func(lhs, rhs uint64) bool {
 		return filter[lhs] < filter[rhs]
 	})
 	return reconciledChanges, nil
 }
+
+// loadExpirations adds a deletion of each relationship which expired after the after revision, up
+// to the last of the revisions. A relationship is reported as deleted by the first of the
+// revisions at or after its expiration, which is the first revision at which it is not read.
+func (pgd *pgDatastore) loadExpirations(
+	ctx context.Context,
+	tracked common.Changes[postgresRevision, uint64],
+	afterRevision postgresRevision,
+	revisions []postgresRevision,
+) error {
+	xids := make([]xid8, 0, len(revisions)+1)
+	xids = append(xids, afterRevision.tx)
+	for _, rev := range revisions {
+		xids = append(xids, rev.tx)
+	}
+
+	query, args, err := queryTransactionTimestamps.Where(sq.Eq{colXID: xids}).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to prepare transaction timestamps SQL: %w", err)
+	}
+
+	rows, err := pgd.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("unable to load transaction timestamps: %w", err)
+	}
+	defer rows.Close()
+
+	timestamps := make(map[uint64]time.Time, len(xids))
+	for rows.Next() {
+		var xid xid8
+		var timestamp time.Time
+		if err := rows.Scan(&xid, &timestamp); err != nil {
+			return fmt.Errorf("unable to load transaction timestamps: %w", err)
+		}
+		timestamps[xid.Uint] = timestamp
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to load transaction timestamps: %w", err)
+	}
+	rows.Close()
+
+	expiredInRange := sq.And{
+		sq.Gt{colExpiresAt: timestamps[afterRevision.tx.Uint]},
+		sq.LtOrEq{colExpiresAt: timestamps[revisions[len(revisions)-1].tx.Uint]},
+	}
+
+	query, args, err = queryChanged.Where(expiredInRange).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to prepare expirations SQL: %w", err)
+	}
+
+	expired, err := pgd.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("unable to load expirations: %w", err)
+	}
+	defer expired.Close()
+
+	for expired.Next() {
+		nextTuple := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+
+		var createdXID, deletedXID xid8
+		var caveatName string
+		var caveatContext map[string]any
+		var expiresAt time.Time
+		if err := expired.Scan(
+			&nextTuple.ResourceAndRelation.Namespace,
+			&nextTuple.ResourceAndRelation.ObjectId,
+			&nextTuple.ResourceAndRelation.Relation,
+			&nextTuple.Subject.Namespace,
+			&nextTuple.Subject.ObjectId,
+			&nextTuple.Subject.Relation,
+			&caveatName,
+			&caveatContext,
+			&expiresAt,
+			&createdXID,
+			&deletedXID,
+		); err != nil {
+			return fmt.Errorf("unable to parse expired tuple: %w", err)
+		}
+
+		nextTuple.Caveat, err = common.ContextualizedCaveatFrom(caveatName, caveatContext)
+		if err != nil {
+			return fmt.Errorf("unable to parse expired tuple: %w", err)
+		}
+		nextTuple.ExpiresAt = common.ExpirationFrom(&expiresAt)
+
+		for _, rev := range revisions {
+			if timestamps[rev.tx.Uint].Before(expiresAt) {
+				continue
+			}
+
+			// The row is only reported if it was live up to the revision at which it expired.
+			if createdXID.Uint < rev.tx.Uint && deletedXID.Uint >= rev.tx.Uint {
+				tracked.AddChange(ctx, postgresRevision{rev.tx, noXmin}, nextTuple, core.RelationTupleUpdate_DELETE)
+			}
+			break
+		}
+	}
+	if err := expired.Err(); err != nil {
+		return fmt.Errorf("unable to load expirations: %w", err)
+	}
+
+	return nil
+}
+
+// loadSchemaChanges adds the changes made to namespace and caveat definitions in the revisions
+// of the filter to the tracked changes. Updating a definition deletes its existing row and
+// creates another in the same transaction, which is tracked as a change to the definition.
+func (pgd *pgDatastore) loadSchemaChanges(
+	ctx context.Context,
+	tracked common.Changes[postgresRevision, uint64],
+	filter map[uint64]int,
+	changedInRange sq.Sqlizer,
+) error {
+	sql, args, err := queryChangedNamespaces.Where(changedInRange).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to prepare namespace changes SQL: %w", err)
+	}
+
+	if err := pgd.loadDefinitionChanges(ctx, sql, args, filter, func(config []byte) (datastore.SchemaDefinition, error) {
+		def := &core.NamespaceDefinition{}
+		return def, def.UnmarshalVT(config)
+	}, tracked.AddChangedDefinition, tracked.AddDeletedNamespace); err != nil {
+		return fmt.Errorf("unable to load namespace changes for XID: %w", err)
+	}
+
+	sql, args, err = queryChangedCaveats.Where(changedInRange).ToSql()
+	if err != nil {
+		return fmt.Errorf("unable to prepare caveat changes SQL: %w", err)
+	}
+
+	if err := pgd.loadDefinitionChanges(ctx, sql, args, filter, func(definition []byte) (datastore.SchemaDefinition, error) {
+		def := &core.CaveatDefinition{}
+		return def, def.UnmarshalVT(definition)
+	}, tracked.AddChangedDefinition, tracked.AddDeletedCaveat); err != nil {
+		return fmt.Errorf("unable to load caveat changes for XID: %w", err)
+	}
+
+	return nil
+}
+
+func (pgd *pgDatastore) loadDefinitionChanges(
+	ctx context.Context,
+	sql string,
+	args []any,
+	filter map[uint64]int,
+	unmarshal func([]byte) (datastore.SchemaDefinition, error),
+	addChanged func(postgresRevision, datastore.SchemaDefinition),
+	addDeleted func(postgresRevision, string),
+) error {
+	rows, err := pgd.dbpool.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var serialized []byte
+		var createdXID, deletedXID xid8
+		if err := rows.Scan(&name, &serialized, &createdXID, &deletedXID); err != nil {
+			return err
+		}
+
+		if _, found := filter[deletedXID.Uint]; found {
+			addDeleted(postgresRevision{deletedXID, noXmin}, name)
+		}
+		if _, found := filter[createdXID.Uint]; found {
+			def, err := unmarshal(serialized)
+			if err != nil {
+				return err
+			}
+			addChanged(postgresRevision{createdXID, noXmin}, def)
+		}
+	}
+
+	return rows.Err()
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/cache"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -62,11 +63,30 @@ func (p *nsCachingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader
 func (p *nsCachingProxy) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	return p.Datastore.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
 		rwt := &nsCachingRWT{delegateRWT, &sync.Map{}}
 		return f(rwt)
-	})
+	}, opts...)
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (p *nsCachingProxy) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(p.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (p *nsCachingProxy) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, p.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (p *nsCachingProxy) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, p.Datastore)
 }
 
 type nsCachingReader struct {
@@ -113,6 +133,62 @@ func (r *nsCachingReader) ReadNamespace(
 	return loaded.namespaceDefinition, loaded.updated, loaded.notFound
 }
 
+// LookupNamespaces returns the cached namespace definitions, and looks up all of those not yet
+// cached from the delegate reader in a single call. As the lookup does not return the revision at
+// which each namespace was last written, the namespaces looked up are not cached.
+func (r *nsCachingReader) LookupNamespaces(
+	ctx context.Context,
+	nsNames []string,
+) ([]*core.NamespaceDefinition, error) {
+	found := make([]*core.NamespaceDefinition, 0, len(nsNames))
+	seen := make(map[string]struct{}, len(nsNames))
+	var uncached []string
+	for _, nsName := range nsNames {
+		if _, ok := seen[nsName]; ok {
+			continue
+		}
+		seen[nsName] = struct{}{}
+
+		loadedRaw, ok := r.p.c.Get(nsName + "@" + r.rev.String())
+		if !ok {
+			uncached = append(uncached, nsName)
+			continue
+		}
+
+		if loaded := loadedRaw.(*cacheEntry); loaded.notFound == nil {
+			found = append(found, loaded.namespaceDefinition)
+		}
+	}
+
+	if len(uncached) == 0 {
+		return found, nil
+	}
+
+	loaded, err := r.Reader.LookupNamespaces(SeparateContextWithTracing(ctx), uncached)
+	if err != nil {
+		return nil, err
+	}
+	return append(found, loaded...), nil
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate reader.
+func (r *nsCachingReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, r.Reader, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate reader.
+func (r *nsCachingReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, r.Reader, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by
+// forwarding to the delegate reader.
+func (r *nsCachingReader) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, r.Reader, filter, opts...)
+}
+
 type nsCachingRWT struct {
 	datastore.ReadWriteTransaction
 	namespaceCache *sync.Map
@@ -159,6 +235,30 @@ func (rwt *nsCachingRWT) WriteNamespaces(ctx context.Context, newConfigs ...*cor
 	return nil
 }
 
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate transaction.
+func (rwt *nsCachingRWT) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, rwt.ReadWriteTransaction, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate transaction.
+func (rwt *nsCachingRWT) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, rwt.ReadWriteTransaction, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by
+// forwarding to the delegate transaction.
+func (rwt *nsCachingRWT) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, rwt.ReadWriteTransaction, filter, opts...)
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by forwarding to
+// the delegate transaction.
+func (rwt *nsCachingRWT) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	return datastore.WriteRelationshipsWithResults(ctx, rwt.ReadWriteTransaction, mutations)
+}
+
 type cacheEntry struct {
 	namespaceDefinition *core.NamespaceDefinition
 	updated             datastore.Revision
@@ -170,8 +270,18 @@ func (c *cacheEntry) Size() int64 {
 }
 
 var (
-	_ datastore.Datastore = &nsCachingProxy{}
-	_ datastore.Reader    = &nsCachingReader{}
+	_ datastore.Datastore                     = &nsCachingProxy{}
+	_ datastore.PoolStatsReporter             = &nsCachingProxy{}
+	_ datastore.RelationshipHistoryReader     = &nsCachingProxy{}
+	_ datastore.ConsistencyValidator          = &nsCachingProxy{}
+	_ datastore.Reader                        = &nsCachingReader{}
+	_ datastore.RelationshipExistenceChecker  = &nsCachingReader{}
+	_ datastore.NamespaceRelationshipsChecker = &nsCachingReader{}
+	_ datastore.RelationshipExistenceChecker  = &nsCachingRWT{}
+	_ datastore.NamespaceRelationshipsChecker = &nsCachingRWT{}
+	_ datastore.RelationshipRevisionsReader   = &nsCachingReader{}
+	_ datastore.RelationshipRevisionsReader   = &nsCachingRWT{}
+	_ datastore.RelationshipUpdateReporter    = &nsCachingRWT{}
 )
 
 func estimatedNamespaceDefinitionSize(sizevt int) int64 {
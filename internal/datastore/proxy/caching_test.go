@@ -102,6 +102,36 @@ func TestSnapshotNamespaceCaching(t *testing.T) {
 	twoReader.AssertExpectations(t)
 }
 
+func TestSnapshotNamespaceLookupCaching(t *testing.T) {
+	dsMock := &proxy_test.MockDatastore{}
+
+	oneReader := &proxy_test.MockReader{}
+	dsMock.On("SnapshotReader", one).Return(oneReader)
+	oneReader.On("ReadNamespace", nsA).Return(&core.NamespaceDefinition{Name: nsA}, old, nil).Once()
+	oneReader.On("LookupNamespaces", []string{nsB, "c"}).Return([]*core.NamespaceDefinition{{Name: "c"}}, nil).Twice()
+
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := NewCachingDatastoreProxy(dsMock, DatastoreProxyTestCache(t))
+
+	_, _, err := ds.SnapshotReader(one).ReadNamespace(ctx, nsA)
+	require.NoError(err)
+
+	// Cached namespaces are returned from the cache, and only the others are looked up, once
+	// each. Namespaces which are not found are omitted.
+	for i := 0; i < 2; i++ {
+		found, err := ds.SnapshotReader(one).LookupNamespaces(ctx, []string{nsA, nsB, "c", nsA})
+		require.NoError(err)
+		require.Len(found, 2)
+		require.Equal(nsA, found[0].Name)
+		require.Equal("c", found[1].Name)
+	}
+
+	dsMock.AssertExpectations(t)
+	oneReader.AssertExpectations(t)
+}
+
 func TestRWTNamespaceCaching(t *testing.T) {
 	dsMock := &proxy_test.MockDatastore{}
 	rwtMock := &proxy_test.MockReadWriteTransaction{}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"time"
 
 	"go.opentelemetry.io/otel/trace"
 
@@ -38,8 +39,8 @@ func NewSeparatingContextDatastoreProxy(d datastore.Datastore) datastore.Datasto
 
 type ctxProxy struct{ delegate datastore.Datastore }
 
-func (p *ctxProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc) (datastore.Revision, error) {
-	return p.delegate.ReadWriteTx(ctx, f)
+func (p *ctxProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return p.delegate.ReadWriteTx(ctx, f, opts...)
 }
 
 func (p *ctxProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
@@ -50,6 +51,10 @@ func (p *ctxProxy) CheckRevision(ctx context.Context, revision datastore.Revisio
 	return p.delegate.CheckRevision(SeparateContextWithTracing(ctx), revision)
 }
 
+func (p *ctxProxy) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	return p.delegate.RevisionAtTime(SeparateContextWithTracing(ctx), at)
+}
+
 func (p *ctxProxy) HeadRevision(ctx context.Context) (datastore.Revision, error) {
 	return p.delegate.HeadRevision(SeparateContextWithTracing(ctx))
 }
@@ -62,6 +67,10 @@ func (p *ctxProxy) Watch(ctx context.Context, afterRevision datastore.Revision)
 	return p.delegate.Watch(ctx, afterRevision)
 }
 
+func (p *ctxProxy) DiffRevisions(ctx context.Context, startRevision, endRevision datastore.Revision) (*datastore.RevisionDiff, error) {
+	return p.delegate.DiffRevisions(SeparateContextWithTracing(ctx), startRevision, endRevision)
+}
+
 func (p *ctxProxy) Features(ctx context.Context) (*datastore.Features, error) {
 	return p.delegate.Features(SeparateContextWithTracing(ctx))
 }
@@ -74,8 +83,30 @@ func (p *ctxProxy) IsReady(ctx context.Context) (bool, error) {
 	return p.delegate.IsReady(SeparateContextWithTracing(ctx))
 }
 
+func (p *ctxProxy) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	return p.delegate.HealthCheck(SeparateContextWithTracing(ctx))
+}
+
 func (p *ctxProxy) Close() error { return p.delegate.Close() }
 
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (p *ctxProxy) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(p.delegate)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (p *ctxProxy) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(SeparateContextWithTracing(ctx), p.delegate, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (p *ctxProxy) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(SeparateContextWithTracing(ctx), p.delegate)
+}
+
 func (p *ctxProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
 	delegateReader := p.delegate.SnapshotReader(rev)
 	return &ctxReader{delegateReader}
@@ -91,8 +122,8 @@ func (r *ctxReader) ListCaveats(ctx context.Context, caveatNamesForFiltering ...
 	return r.delegate.ListCaveats(SeparateContextWithTracing(ctx), caveatNamesForFiltering...)
 }
 
-func (r *ctxReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
-	return r.delegate.ListNamespaces(SeparateContextWithTracing(ctx))
+func (r *ctxReader) ListNamespaces(ctx context.Context, options ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return r.delegate.ListNamespaces(SeparateContextWithTracing(ctx), options...)
 }
 
 func (r *ctxReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -107,11 +138,39 @@ func (r *ctxReader) QueryRelationships(ctx context.Context, filter datastore.Rel
 	return r.delegate.QueryRelationships(SeparateContextWithTracing(ctx), filter, options...)
 }
 
+func (r *ctxReader) QueryRelationshipsForResourceTypes(ctx context.Context, resourceTypes []string, options ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return r.delegate.QueryRelationshipsForResourceTypes(SeparateContextWithTracing(ctx), resourceTypes, options...)
+}
+
 func (r *ctxReader) ReverseQueryRelationships(ctx context.Context, subjectFilter datastore.SubjectsFilter, options ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
 	return r.delegate.ReverseQueryRelationships(SeparateContextWithTracing(ctx), subjectFilter, options...)
 }
 
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate reader.
+func (r *ctxReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(SeparateContextWithTracing(ctx), r.delegate, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate reader.
+func (r *ctxReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(SeparateContextWithTracing(ctx), r.delegate, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by
+// forwarding to the delegate reader.
+func (r *ctxReader) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, options ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(SeparateContextWithTracing(ctx), r.delegate, filter, options...)
+}
+
 var (
-	_ datastore.Datastore = (*ctxProxy)(nil)
-	_ datastore.Reader    = (*ctxReader)(nil)
+	_ datastore.Datastore                     = (*ctxProxy)(nil)
+	_ datastore.PoolStatsReporter             = (*ctxProxy)(nil)
+	_ datastore.RelationshipHistoryReader     = (*ctxProxy)(nil)
+	_ datastore.ConsistencyValidator          = (*ctxProxy)(nil)
+	_ datastore.Reader                        = (*ctxReader)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*ctxReader)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*ctxReader)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*ctxReader)(nil)
 )
@@ -0,0 +1,358 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// wrapInServerProxies wraps the datastore in the proxies the server wraps its datastore in, from
+// the datastore implementation through to the server.
+func wrapInServerProxies(t *testing.T, ds datastore.Datastore) datastore.Datastore {
+	ds = NewSeparatingContextDatastoreProxy(ds)
+	ds = NewHedgingProxy(ds, 10*time.Millisecond, 1000, 0.95)
+	ds = NewRelationshipLimitDatastore(ds, map[string]uint64{"document": 1000})
+	ds = NewRelationshipTypeCheckingDatastore(ds)
+	ds = NewCachingDatastoreProxy(ds, DatastoreProxyTestCache(t))
+	return NewObservableDatastoreProxy(ds)
+}
+
+type poolStatsDatastore struct {
+	datastore.Datastore
+	stats datastore.PoolStats
+}
+
+func (pd poolStatsDatastore) PoolStats() datastore.PoolStats { return pd.stats }
+
+func TestPoolStatsForwarded(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	stats := datastore.PoolStats{AcquiredConns: 4, TotalConns: 4, MaxConns: 4}
+	pooled := poolStatsDatastore{rawDS, stats}
+
+	for name, ds := range map[string]datastore.Datastore{
+		"server":             wrapInServerProxies(t, pooled),
+		"readonly":           NewReadonlyDatastore(pooled),
+		"namespace readonly": NewNamespaceReadonlyDatastore(pooled, "document"),
+		"mirroring":          NewMirroringDatastore(pooled, rawDS),
+		"recording":          NewRecordingDatastore(pooled, NewMemoryOperationSink()),
+	} {
+		found, ok := datastore.PoolStatsOf(ds)
+		require.True(t, ok, name)
+		require.Equal(t, stats, found, name)
+		require.True(t, found.Saturated(), name)
+	}
+
+	found, ok := datastore.PoolStatsOf(wrapInServerProxies(t, rawDS))
+	require.True(t, ok)
+	require.Equal(t, datastore.PoolStats{}, found)
+
+	_, ok = datastore.PoolStatsOf(rawDS)
+	require.False(t, ok)
+}
+
+// forwardedCalls counts the calls made to the optional capabilities of a capabilityDatastore.
+type forwardedCalls struct {
+	sync.Mutex
+	counts map[string]int
+}
+
+func (fc *forwardedCalls) record(method string) {
+	fc.Lock()
+	defer fc.Unlock()
+	fc.counts[method]++
+}
+
+func (fc *forwardedCalls) count(method string) int {
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.counts[method]
+}
+
+// capabilityDatastore wraps a datastore whose readers and transactions implement the optional
+// capabilities, recording each call made to them, so that tests can assert that the calls are
+// forwarded through the proxies rather than answered by a fallback.
+type capabilityDatastore struct {
+	datastore.Datastore
+	calls *forwardedCalls
+}
+
+func newCapabilityDatastore(t *testing.T) capabilityDatastore {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+	return capabilityDatastore{rawDS, &forwardedCalls{counts: map[string]int{}}}
+}
+
+func (cd capabilityDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader {
+	return &capabilityReader{cd.Datastore.SnapshotReader(rev), cd.calls}
+}
+
+func (cd capabilityDatastore) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return cd.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&capabilityRWT{rwt, &capabilityReader{rwt, cd.calls}})
+	}, opts...)
+}
+
+type capabilityReader struct {
+	datastore.Reader
+	calls *forwardedCalls
+}
+
+func (cr *capabilityReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	cr.calls.record("RelationshipExists")
+	return datastore.RelationshipExists(ctx, cr.Reader, tpl)
+}
+
+type capabilityRWT struct {
+	datastore.ReadWriteTransaction
+	*capabilityReader
+}
+
+func (crwt *capabilityRWT) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	crwt.calls.record("WriteRelationshipsWithResults")
+	return datastore.WriteRelationshipsWithResults(ctx, crwt.ReadWriteTransaction, mutations)
+}
+
+func TestRelationshipExistsForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	capable := newCapabilityDatastore(t)
+	ds := wrapInServerProxies(t, capable)
+
+	tpl := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		exists, err := datastore.RelationshipExists(ctx, rwt, tpl)
+		require.NoError(err)
+		require.False(exists)
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(1, capable.calls.count("RelationshipExists"))
+
+	headRevision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	exists, err := datastore.RelationshipExists(ctx, ds.SnapshotReader(headRevision), tpl)
+	require.NoError(err)
+	require.False(exists)
+	require.Equal(2, capable.calls.count("RelationshipExists"))
+}
+
+func TestWriteRelationshipsWithResultsForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	capable := newCapabilityDatastore(t)
+	testfixtures.StandardDatastoreWithSchema(capable, require)
+
+	writeWithResults := func(ds datastore.Datastore, mutations ...*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+		var results []datastore.RelationshipUpdateResult
+		_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			var err error
+			results, err = datastore.WriteRelationshipsWithResults(ctx, rwt, mutations)
+			return err
+		})
+		return results, err
+	}
+
+	ds := wrapInServerProxies(t, capable)
+	results, err := writeWithResults(ds, tuple.Touch(tuple.MustParse("document:firstdoc#viewer@user:tom")))
+	require.NoError(err)
+	require.Equal([]datastore.RelationshipUpdateResult{datastore.RelationshipCreated}, results)
+	require.Equal(1, capable.calls.count("WriteRelationshipsWithResults"))
+
+	// The checks made by the proxies apply to writes reporting their results, too.
+	_, err = writeWithResults(ds, tuple.Create(tuple.MustParse("document:firstdoc#viewer@document:seconddoc")))
+	require.Error(err)
+	require.Equal(1, capable.calls.count("WriteRelationshipsWithResults"))
+
+	limited := NewRelationshipLimitDatastore(capable, map[string]uint64{"document": 1})
+	_, err = writeWithResults(limited, tuple.Create(tuple.MustParse("document:seconddoc#viewer@user:tom")))
+	require.ErrorAs(err, &datastore.ErrRelationshipLimitExceeded{})
+	require.Equal(1, capable.calls.count("WriteRelationshipsWithResults"))
+
+	_, err = writeWithResults(NewNamespaceReadonlyDatastore(capable, "document"), tuple.Create(tuple.MustParse("document:seconddoc#viewer@user:tom")))
+	require.ErrorIs(err, errReadOnly)
+	require.Equal(1, capable.calls.count("WriteRelationshipsWithResults"))
+
+	// Mirrored writes are replayed against the secondary.
+	secondary := newMirroringTestDatastore(t)
+	tpl := tuple.MustParse("document:seconddoc#viewer@user:tom")
+	results, err = writeWithResults(NewMirroringDatastore(capable, secondary), tuple.Touch(tpl))
+	require.NoError(err)
+	require.Equal([]datastore.RelationshipUpdateResult{datastore.RelationshipCreated}, results)
+	require.Equal(2, capable.calls.count("WriteRelationshipsWithResults"))
+
+	secondaryRevision, err := secondary.HeadRevision(ctx)
+	require.NoError(err)
+	requireRelationshipExists(t, secondary, secondaryRevision, tpl)
+}
+
+// historylessDatastore hides the optional capabilities of the datastore it wraps.
+type historylessDatastore struct{ datastore.Datastore }
+
+func TestRelationshipHistoryForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	testfixtures.StandardDatastoreWithSchema(ds, require)
+
+	tpl := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	created, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	for name, proxied := range map[string]datastore.Datastore{
+		"server":             wrapInServerProxies(t, ds),
+		"readonly":           NewReadonlyDatastore(ds),
+		"namespace readonly": NewNamespaceReadonlyDatastore(ds, "document"),
+		"mirroring":          NewMirroringDatastore(ds, newMirroringTestDatastore(t)),
+		"recording":          NewRecordingDatastore(ds, NewMemoryOperationSink()),
+	} {
+		versions, err := datastore.RelationshipHistory(ctx, proxied, tpl)
+		require.NoError(err, name)
+		require.Len(versions, 1, name)
+		require.True(created.Equal(versions[0].CreatedAt), name)
+	}
+
+	_, err = datastore.RelationshipHistory(ctx, wrapInServerProxies(t, historylessDatastore{ds}), tpl)
+	require.ErrorAs(err, &datastore.ErrRelationshipHistoryUnsupported{})
+}
+
+func TestValidateConsistencyForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	testfixtures.StandardDatastoreWithData(ds, require)
+
+	for name, proxied := range map[string]datastore.Datastore{
+		"server":             wrapInServerProxies(t, ds),
+		"readonly":           NewReadonlyDatastore(ds),
+		"namespace readonly": NewNamespaceReadonlyDatastore(ds, "document"),
+		"mirroring":          NewMirroringDatastore(ds, newMirroringTestDatastore(t)),
+		"recording":          NewRecordingDatastore(ds, NewMemoryOperationSink()),
+	} {
+		_, ok := proxied.(datastore.ConsistencyValidator)
+		require.True(ok, name)
+		require.NoError(datastore.ValidateConsistency(ctx, proxied), name)
+	}
+
+	err = datastore.ValidateConsistency(ctx, wrapInServerProxies(t, historylessDatastore{ds}))
+	require.ErrorAs(err, &datastore.ErrConsistencyValidationUnsupported{})
+}
+
+func TestQueryRelationshipsWithRevisionsForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	testfixtures.StandardDatastoreWithSchema(ds, require)
+
+	tpl := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	created, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	filter := datastore.RelationshipsFilterFromTuple(tpl)
+	requireCreatedAt := func(name string, reader datastore.Reader) {
+		versions, err := datastore.QueryRelationshipsWithRevisions(ctx, reader, filter)
+		require.NoError(err, name)
+		require.Len(versions, 1, name)
+		require.True(created.Equal(versions[0].CreatedAt), name)
+	}
+
+	for name, proxied := range map[string]datastore.Datastore{
+		"server":             wrapInServerProxies(t, ds),
+		"readonly":           NewReadonlyDatastore(ds),
+		"namespace readonly": NewNamespaceReadonlyDatastore(ds, "document"),
+		"mirroring":          NewMirroringDatastore(ds, newMirroringTestDatastore(t)),
+		"recording":          NewRecordingDatastore(ds, NewMemoryOperationSink()),
+	} {
+		headRevision, err := proxied.HeadRevision(ctx)
+		require.NoError(err, name)
+		requireCreatedAt(name, proxied.SnapshotReader(headRevision))
+
+		if name == "readonly" {
+			continue
+		}
+		_, err = proxied.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			requireCreatedAt(name, rwt)
+			return nil
+		})
+		require.NoError(err, name)
+	}
+
+	headRevision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	_, err = datastore.QueryRelationshipsWithRevisions(ctx, wrapInServerProxies(t, revisionlessDatastore{ds}).SnapshotReader(headRevision), filter)
+	require.ErrorAs(err, &datastore.ErrRelationshipRevisionsUnsupported{})
+}
+
+// revisionlessDatastore hides the optional capabilities of the readers of the datastore it wraps.
+type revisionlessDatastore struct{ datastore.Datastore }
+
+func (rd revisionlessDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader {
+	return struct{ datastore.Reader }{rd.Datastore.SnapshotReader(rev)}
+}
+
+func TestNamespaceHasRelationshipsForwarded(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	testfixtures.StandardDatastoreWithSchema(ds, require)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:firstdoc#viewer@user:tom"))
+	require.NoError(err)
+
+	requireChecked := func(name string, reader datastore.Reader) {
+		_, ok := reader.(datastore.NamespaceRelationshipsChecker)
+		require.True(ok, name)
+
+		found, err := datastore.NamespaceHasRelationships(ctx, reader, "user")
+		require.NoError(err, name)
+		require.True(found, name)
+
+		found, err = datastore.NamespaceHasRelationships(ctx, reader, "folder")
+		require.NoError(err, name)
+		require.False(found, name)
+	}
+
+	for name, proxied := range map[string]datastore.Datastore{
+		"server":             wrapInServerProxies(t, ds),
+		"readonly":           NewReadonlyDatastore(ds),
+		"namespace readonly": NewNamespaceReadonlyDatastore(ds, "document"),
+		"mirroring":          NewMirroringDatastore(ds, newMirroringTestDatastore(t)),
+		"recording":          NewRecordingDatastore(ds, NewMemoryOperationSink()),
+	} {
+		headRevision, err := proxied.HeadRevision(ctx)
+		require.NoError(err, name)
+		requireChecked(name, proxied.SnapshotReader(headRevision))
+
+		if name == "readonly" {
+			continue
+		}
+		_, err = proxied.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			requireChecked(name, rwt)
+			return nil
+		})
+		require.NoError(err, name)
+	}
+}
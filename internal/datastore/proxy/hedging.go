@@ -203,6 +203,24 @@ func (hp hedgingProxy) HeadRevision(ctx context.Context) (rev datastore.Revision
 	return
 }
 
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (hp hedgingProxy) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(hp.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (hp hedgingProxy) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, hp.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (hp hedgingProxy) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, hp.Datastore)
+}
+
 func (hp hedgingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
 	delegate := hp.Datastore.SnapshotReader(rev)
 	return &hedgingReader{delegate, hp}
@@ -244,6 +262,16 @@ func (hp hedgingReader) QueryRelationships(
 	})
 }
 
+func (hp hedgingReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	options ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	return hp.executeQuery(ctx, func(c context.Context) (datastore.RelationshipIterator, error) {
+		return hp.Reader.QueryRelationshipsForResourceTypes(ctx, resourceTypes, options...)
+	})
+}
+
 func (hp hedgingReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectFilter datastore.SubjectsFilter,
@@ -280,3 +308,31 @@ func (hp hedgingReader) executeQuery(
 
 	return
 }
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate reader.
+func (hp hedgingReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, hp.Reader, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate reader.
+func (hp hedgingReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, hp.Reader, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by
+// forwarding to the delegate reader, without hedging.
+func (hp hedgingReader) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, hp.Reader, filter, opts...)
+}
+
+var (
+	_ datastore.Datastore                     = hedgingProxy{}
+	_ datastore.PoolStatsReporter             = hedgingProxy{}
+	_ datastore.RelationshipHistoryReader     = hedgingProxy{}
+	_ datastore.ConsistencyValidator          = hedgingProxy{}
+	_ datastore.RelationshipExistenceChecker  = hedgingReader{}
+	_ datastore.NamespaceRelationshipsChecker = hedgingReader{}
+	_ datastore.RelationshipRevisionsReader   = hedgingReader{}
+)
@@ -0,0 +1,248 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// ErrMirrorWriteFailed is returned when a write was committed to the primary datastore but
+// could not be applied to the secondary datastore.
+type ErrMirrorWriteFailed struct {
+	error
+}
+
+// Unwrap returns the error returned by the secondary datastore.
+func (err ErrMirrorWriteFailed) Unwrap() error {
+	return err.error
+}
+
+type mirroringDatastore struct {
+	datastore.Datastore
+	secondary datastore.Datastore
+}
+
+// NewMirroringDatastore creates a proxy which serves all reads and revisions from the primary
+// datastore, and mirrors every write committed to the primary onto the secondary datastore.
+//
+// Writes are applied to the secondary only after they have been committed to the primary. If
+// the secondary fails to apply them, the primary's revision is returned together with an
+// ErrMirrorWriteFailed; the primary's committed state is left untouched.
+func NewMirroringDatastore(primary, secondary datastore.Datastore) datastore.Datastore {
+	return mirroringDatastore{Datastore: primary, secondary: secondary}
+}
+
+func (md mirroringDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	var writes []mirroredWrite
+	revision, err := md.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		// The transaction function may be retried, so only the writes of the attempt that was
+		// committed are kept.
+		recorder := &recordingTransaction{ReadWriteTransaction: rwt}
+		if err := f(recorder); err != nil {
+			return err
+		}
+
+		writes = recorder.writes
+		return nil
+	}, opts...)
+	if err != nil || len(writes) == 0 {
+		return revision, err
+	}
+
+	_, err = md.secondary.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		for _, write := range writes {
+			if err := write(ctx, rwt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return revision, ErrMirrorWriteFailed{
+			fmt.Errorf("write committed to the primary datastore at revision %s, but could not be mirrored to the secondary: %w", revision, err),
+		}
+	}
+
+	return revision, nil
+}
+
+func (md mirroringDatastore) Close() error {
+	primaryErr := md.Datastore.Close()
+	if err := md.secondary.Close(); err != nil {
+		return err
+	}
+	return primaryErr
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the primary datastore.
+func (md mirroringDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(md.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (md mirroringDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, md.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (md mirroringDatastore) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, md.Datastore)
+}
+
+// mirroredWrite replays a single write made to the primary datastore against the secondary.
+type mirroredWrite func(context.Context, datastore.ReadWriteTransaction) error
+
+// recordingTransaction records the writes made within a primary transaction, so that they can
+// be replayed against the secondary datastore once the primary transaction has committed.
+type recordingTransaction struct {
+	datastore.ReadWriteTransaction
+	writes []mirroredWrite
+}
+
+func (rt *recordingTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := rt.ReadWriteTransaction.WriteRelationships(ctx, mutations); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, mutations)
+	})
+	return nil
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by forwarding to
+// the delegate transaction and recording the write, as WriteRelationships does.
+func (rt *recordingTransaction) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	results, err := datastore.WriteRelationshipsWithResults(ctx, rt.ReadWriteTransaction, mutations)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, mutations)
+	})
+	return results, nil
+}
+
+func (rt *recordingTransaction) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	if err := rt.ReadWriteTransaction.DeleteRelationships(ctx, filter); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteRelationships(ctx, filter)
+	})
+	return nil
+}
+
+func (rt *recordingTransaction) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	deleted, err := rt.ReadWriteTransaction.DeleteRelationshipsForSubject(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		_, err := rwt.DeleteRelationshipsForSubject(ctx, subject)
+		return err
+	})
+	return deleted, nil
+}
+
+func (rt *recordingTransaction) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	rewritten, err := rt.ReadWriteTransaction.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+	if err != nil {
+		return 0, err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		_, err := rwt.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+		return err
+	})
+	return rewritten, nil
+}
+
+func (rt *recordingTransaction) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	if err := rt.ReadWriteTransaction.WriteNamespaces(ctx, newConfigs...); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, newConfigs...)
+	})
+	return nil
+}
+
+func (rt *recordingTransaction) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	if err := rt.ReadWriteTransaction.DeleteNamespaces(ctx, delOption, nsNames...); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteNamespaces(ctx, delOption, nsNames...)
+	})
+	return nil
+}
+
+func (rt *recordingTransaction) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	if err := rt.ReadWriteTransaction.WriteCaveats(ctx, caveats); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteCaveats(ctx, caveats)
+	})
+	return nil
+}
+
+func (rt *recordingTransaction) DeleteCaveats(ctx context.Context, names []string) error {
+	if err := rt.ReadWriteTransaction.DeleteCaveats(ctx, names); err != nil {
+		return err
+	}
+
+	rt.writes = append(rt.writes, func(ctx context.Context, rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteCaveats(ctx, names)
+	})
+	return nil
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the primary transaction.
+func (rt *recordingTransaction) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, rt.ReadWriteTransaction, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the primary transaction.
+func (rt *recordingTransaction) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, rt.ReadWriteTransaction, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by forwarding
+// to the primary transaction.
+func (rt *recordingTransaction) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, rt.ReadWriteTransaction, filter, opts...)
+}
+
+var (
+	_ datastore.Datastore                     = (*mirroringDatastore)(nil)
+	_ datastore.PoolStatsReporter             = (*mirroringDatastore)(nil)
+	_ datastore.RelationshipHistoryReader     = (*mirroringDatastore)(nil)
+	_ datastore.ConsistencyValidator          = (*mirroringDatastore)(nil)
+	_ datastore.ReadWriteTransaction          = (*recordingTransaction)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*recordingTransaction)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*recordingTransaction)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*recordingTransaction)(nil)
+	_ datastore.RelationshipUpdateReporter    = (*recordingTransaction)(nil)
+)
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func newMirroringTestDatastore(t *testing.T) datastore.Datastore {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require.New(t))
+	return ds
+}
+
+func requireRelationshipExists(t *testing.T, ds datastore.Datastore, revision datastore.Revision, tpl *core.RelationTuple) {
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType:             tpl.ResourceAndRelation.Namespace,
+		OptionalResourceIds:      []string{tpl.ResourceAndRelation.ObjectId},
+		OptionalResourceRelation: tpl.ResourceAndRelation.Relation,
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	found := iter.Next()
+	require.NoError(t, iter.Err())
+	require.NotNil(t, found, "missing relationship %s", tuple.MustString(tpl))
+	require.Equal(t, tuple.MustString(tpl), tuple.MustString(found))
+}
+
+func TestMirroringWrites(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	primary := newMirroringTestDatastore(t)
+	secondary := newMirroringTestDatastore(t)
+	ds := NewMirroringDatastore(primary, secondary)
+
+	tpl := tuple.Parse("document:mirrored#viewer@user:tom")
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	requireRelationshipExists(t, primary, revision, tpl)
+
+	secondaryRevision, err := secondary.HeadRevision(ctx)
+	require.NoError(err)
+	requireRelationshipExists(t, secondary, secondaryRevision, tpl)
+
+	// Revisions are those of the primary.
+	headRevision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	require.True(headRevision.Equal(revision))
+}
+
+func TestMirroringOnlyCommittedWrites(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	primary := newMirroringTestDatastore(t)
+	secondary := newMirroringTestDatastore(t)
+	ds := NewMirroringDatastore(primary, secondary)
+
+	beforeRevision, err := secondary.HeadRevision(ctx)
+	require.NoError(err)
+
+	// A write which fails on the primary is never mirrored.
+	existing := tuple.Parse("document:existing#viewer@user:tom")
+	_, err = common.WriteTuples(ctx, primary, core.RelationTupleUpdate_CREATE, existing)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("document:other#viewer@user:tom"), existing)
+	require.ErrorAs(err, &common.CreateRelationshipExistsError{})
+
+	afterRevision, err := secondary.HeadRevision(ctx)
+	require.NoError(err)
+	require.True(afterRevision.Equal(beforeRevision))
+}
+
+func TestMirroringSecondaryFailure(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	primary := newMirroringTestDatastore(t)
+	ds := NewMirroringDatastore(primary, NewReadonlyDatastore(newMirroringTestDatastore(t)))
+
+	tpl := tuple.Parse("document:mirrored#viewer@user:tom")
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.ErrorAs(err, &ErrMirrorWriteFailed{})
+	require.ErrorAs(err, &datastore.ErrReadOnly{})
+	require.NotEqual(datastore.NoRevision, revision)
+
+	// The write remains committed to the primary.
+	requireRelationshipExists(t, primary, revision, tpl)
+	requireRelationshipExists(t, ds, revision, tpl)
+}
@@ -0,0 +1,204 @@
+package proxy
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+type namespaceReadonlyDatastore struct {
+	datastore.Datastore
+	protected *util.Set[string]
+}
+
+// NewNamespaceReadonlyDatastore creates a proxy which disables write operations to the given
+// protected namespaces of a downstream delegate datastore, while allowing writes to all others.
+// Writing or deleting the definition of a protected namespace, or writing or deleting any
+// relationship whose resource is of a protected namespace, fails the transaction with an
+// ErrReadOnly. A write of several relationships is rejected as a whole if any of them is
+// protected.
+func NewNamespaceReadonlyDatastore(delegate datastore.Datastore, protectedNamespaces ...string) datastore.Datastore {
+	return namespaceReadonlyDatastore{Datastore: delegate, protected: util.NewSet(protectedNamespaces...)}
+}
+
+func (nrd namespaceReadonlyDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	return nrd.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&namespaceReadonlyTransaction{ReadWriteTransaction: rwt, protected: nrd.protected})
+	}, opts...)
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (nrd namespaceReadonlyDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(nrd.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (nrd namespaceReadonlyDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, nrd.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (nrd namespaceReadonlyDatastore) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, nrd.Datastore)
+}
+
+type namespaceReadonlyTransaction struct {
+	datastore.ReadWriteTransaction
+	protected *util.Set[string]
+}
+
+func (nrt *namespaceReadonlyTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := nrt.ensureMutationsUnprotected(mutations); err != nil {
+		return err
+	}
+
+	return nrt.ReadWriteTransaction.WriteRelationships(ctx, mutations)
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by rejecting
+// writes to protected namespaces and forwarding the rest to the delegate transaction.
+func (nrt *namespaceReadonlyTransaction) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	if err := nrt.ensureMutationsUnprotected(mutations); err != nil {
+		return nil, err
+	}
+
+	return datastore.WriteRelationshipsWithResults(ctx, nrt.ReadWriteTransaction, mutations)
+}
+
+func (nrt *namespaceReadonlyTransaction) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	if nrt.protected.Has(filter.ResourceType) {
+		return errReadOnly
+	}
+
+	return nrt.ReadWriteTransaction.DeleteRelationships(ctx, filter)
+}
+
+func (nrt *namespaceReadonlyTransaction) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	if err := nrt.ensureSubjectUnprotected(ctx, subject); err != nil {
+		return 0, err
+	}
+
+	return nrt.ReadWriteTransaction.DeleteRelationshipsForSubject(ctx, subject)
+}
+
+func (nrt *namespaceReadonlyTransaction) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	if err := nrt.ensureSubjectUnprotected(ctx, oldSubject); err != nil {
+		return 0, err
+	}
+
+	return nrt.ReadWriteTransaction.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+}
+
+func (nrt *namespaceReadonlyTransaction) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	for _, newConfig := range newConfigs {
+		if nrt.protected.Has(newConfig.Name) {
+			return errReadOnly
+		}
+	}
+
+	return nrt.ReadWriteTransaction.WriteNamespaces(ctx, newConfigs...)
+}
+
+func (nrt *namespaceReadonlyTransaction) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	for _, nsName := range nsNames {
+		if nrt.protected.Has(nsName) {
+			return errReadOnly
+		}
+	}
+
+	// Deleting the relationships of the namespaces also deletes those of protected resources
+	// with subjects of the namespaces.
+	if delOption == datastore.DeleteNamespacesAndRelationships {
+		for _, nsName := range nsNames {
+			if err := nrt.ensureSubjectsUnprotected(ctx, datastore.SubjectsFilter{SubjectType: nsName}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nrt.ReadWriteTransaction.DeleteNamespaces(ctx, delOption, nsNames...)
+}
+
+// ensureSubjectUnprotected returns an ErrReadOnly if the subject has any relationship whose
+// resource is of a protected namespace.
+func (nrt *namespaceReadonlyTransaction) ensureMutationsUnprotected(mutations []*core.RelationTupleUpdate) error {
+	for _, mutation := range mutations {
+		if nrt.protected.Has(mutation.Tuple.ResourceAndRelation.Namespace) {
+			return errReadOnly
+		}
+	}
+	return nil
+}
+
+func (nrt *namespaceReadonlyTransaction) ensureSubjectUnprotected(ctx context.Context, subject *core.ObjectAndRelation) error {
+	relationFilter := datastore.SubjectRelationFilter{}
+	if subject.Relation == datastore.Ellipsis || subject.Relation == "" {
+		relationFilter = relationFilter.WithEllipsisRelation()
+	} else {
+		relationFilter = relationFilter.WithNonEllipsisRelation(subject.Relation)
+	}
+
+	return nrt.ensureSubjectsUnprotected(ctx, datastore.SubjectsFilter{
+		SubjectType:        subject.Namespace,
+		OptionalSubjectIds: []string{subject.ObjectId},
+		RelationFilter:     relationFilter,
+	})
+}
+
+func (nrt *namespaceReadonlyTransaction) ensureSubjectsUnprotected(ctx context.Context, subjectsFilter datastore.SubjectsFilter) error {
+	iter, err := nrt.ReverseQueryRelationships(ctx, subjectsFilter)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if nrt.protected.Has(tpl.ResourceAndRelation.Namespace) {
+			return errReadOnly
+		}
+	}
+
+	return iter.Err()
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate transaction.
+func (nrt *namespaceReadonlyTransaction) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, nrt.ReadWriteTransaction, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate transaction.
+func (nrt *namespaceReadonlyTransaction) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, nrt.ReadWriteTransaction, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by forwarding
+// to the delegate transaction.
+func (nrt *namespaceReadonlyTransaction) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, nrt.ReadWriteTransaction, filter, opts...)
+}
+
+var (
+	_ datastore.Datastore                     = (*namespaceReadonlyDatastore)(nil)
+	_ datastore.PoolStatsReporter             = (*namespaceReadonlyDatastore)(nil)
+	_ datastore.RelationshipHistoryReader     = (*namespaceReadonlyDatastore)(nil)
+	_ datastore.ConsistencyValidator          = (*namespaceReadonlyDatastore)(nil)
+	_ datastore.ReadWriteTransaction          = (*namespaceReadonlyTransaction)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*namespaceReadonlyTransaction)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*namespaceReadonlyTransaction)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*namespaceReadonlyTransaction)(nil)
+	_ datastore.RelationshipUpdateReporter    = (*namespaceReadonlyTransaction)(nil)
+)
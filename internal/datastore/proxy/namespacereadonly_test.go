@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func collectRelationships(iter datastore.RelationshipIterator, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.MustString(tpl))
+	}
+	return found, iter.Err()
+}
+
+func TestNamespaceReadonlyDatastore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	delegate, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ds := NewNamespaceReadonlyDatastore(delegate, "folder")
+
+	// Writes to unprotected namespaces are delegated.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("document:newdoc#viewer@user:tom"))
+	require.NoError(err)
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, &core.NamespaceDefinition{Name: "unprotected"})
+	})
+	require.NoError(err)
+
+	// A batch touching a protected namespace is rejected as a whole.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:otherdoc#viewer@user:tom"),
+		tuple.Parse("folder:newfolder#viewer@user:tom"),
+	)
+	require.ErrorAs(err, &datastore.ErrReadOnly{})
+
+	rev, err := delegate.HeadRevision(ctx)
+	require.NoError(err)
+	_, _, err = delegate.SnapshotReader(rev).ReadNamespace(ctx, "unprotected")
+	require.NoError(err)
+
+	found, err := collectRelationships(delegate.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:        "document",
+		OptionalResourceIds: []string{"otherdoc"},
+	}))
+	require.NoError(err)
+	require.Empty(found)
+
+	for name, f := range map[string]datastore.TxUserFunc{
+		"write namespace": func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, &core.NamespaceDefinition{Name: "folder"})
+		},
+		"delete namespace": func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, "folder")
+		},
+		"delete namespace with protected relationships": func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, "user")
+		},
+		"delete relationships": func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{ResourceType: "folder"})
+		},
+		"delete relationships for subject": func(rwt datastore.ReadWriteTransaction) error {
+			_, err := rwt.DeleteRelationshipsForSubject(ctx, tuple.ParseSubjectONR("user:legal"))
+			return err
+		},
+		"rewrite relationships subject": func(rwt datastore.ReadWriteTransaction) error {
+			_, err := rwt.RewriteRelationshipsSubject(ctx, tuple.ParseSubjectONR("user:legal"), tuple.ParseSubjectONR("user:tom"))
+			return err
+		},
+	} {
+		_, err := ds.ReadWriteTx(ctx, f)
+		require.ErrorAs(err, &datastore.ErrReadOnly{}, name)
+	}
+
+	// Subjects without protected relationships can be deleted.
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		deleted, err := rwt.DeleteRelationshipsForSubject(ctx, tuple.ParseSubjectONR("user:tom"))
+		require.Equal(uint64(1), deleted)
+		return err
+	})
+	require.NoError(err)
+}
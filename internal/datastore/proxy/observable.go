@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"go.opentelemetry.io/otel"
@@ -12,6 +13,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 var tracer = otel.Tracer("spicedb/datastore/proxy/observable")
@@ -50,10 +52,10 @@ func (p *observableProxy) SnapshotReader(rev datastore.Revision) datastore.Reade
 	return &observableReader{delegateReader}
 }
 
-func (p *observableProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc) (datastore.Revision, error) {
+func (p *observableProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
 	return p.delegate.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
 		return f(&observableRWT{&observableReader{delegateRWT}, delegateRWT})
-	})
+	}, opts...)
 }
 
 func (p *observableProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
@@ -74,6 +76,16 @@ func (p *observableProxy) CheckRevision(ctx context.Context, revision datastore.
 	return p.delegate.CheckRevision(ctx, revision)
 }
 
+func (p *observableProxy) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "RevisionAtTime", trace.WithAttributes(
+		attribute.String("at", at.UTC().Format(time.RFC3339Nano)),
+	))
+	defer span.End()
+
+	return p.delegate.RevisionAtTime(ctx, at)
+}
+
 func (p *observableProxy) HeadRevision(ctx context.Context) (datastore.Revision, error) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "HeadRevision")
@@ -90,6 +102,17 @@ func (p *observableProxy) Watch(ctx context.Context, afterRevision datastore.Rev
 	return p.delegate.Watch(ctx, afterRevision)
 }
 
+func (p *observableProxy) DiffRevisions(ctx context.Context, startRevision, endRevision datastore.Revision) (*datastore.RevisionDiff, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "DiffRevisions", trace.WithAttributes(
+		attribute.Stringer("startRevision", startRevision),
+		attribute.Stringer("endRevision", endRevision),
+	))
+	defer span.End()
+
+	return p.delegate.DiffRevisions(ctx, startRevision, endRevision)
+}
+
 func (p *observableProxy) Features(ctx context.Context) (*datastore.Features, error) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "Features")
@@ -114,8 +137,44 @@ func (p *observableProxy) IsReady(ctx context.Context) (bool, error) {
 	return p.delegate.IsReady(ctx)
 }
 
+func (p *observableProxy) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "HealthCheck")
+	defer span.End()
+
+	result := p.delegate.HealthCheck(ctx)
+	span.SetAttributes(attribute.String("status", result.Status.String()))
+	return result
+}
+
 func (p *observableProxy) Close() error { return p.delegate.Close() }
 
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (p *observableProxy) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(p.delegate)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (p *observableProxy) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "RelationshipHistory")
+	defer span.End()
+
+	return datastore.RelationshipHistory(ctx, p.delegate, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (p *observableProxy) ValidateConsistency(ctx context.Context) error {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "ValidateConsistency")
+	defer span.End()
+
+	return datastore.ValidateConsistency(ctx, p.delegate)
+}
+
 type observableReader struct{ delegate datastore.Reader }
 
 func (r *observableReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
@@ -138,12 +197,12 @@ func (r *observableReader) ListCaveats(ctx context.Context, caveatNamesForFilter
 	return r.delegate.ListCaveats(ctx, caveatNamesForFiltering...)
 }
 
-func (r *observableReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (r *observableReader) ListNamespaces(ctx context.Context, options ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "ListNamespaces")
 	defer span.End()
 
-	return r.delegate.ListNamespaces(ctx)
+	return r.delegate.ListNamespaces(ctx, options...)
 }
 
 func (r *observableReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -165,6 +224,32 @@ func (r *observableReader) ReadNamespace(ctx context.Context, nsName string) (*c
 	return r.delegate.ReadNamespace(ctx, nsName)
 }
 
+func (r *observableReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	ctx, span := tracer.Start(ctx, "RelationshipExists", trace.WithAttributes(
+		attribute.String("relationship", tuple.StringWithoutCaveat(tpl)),
+	))
+	defer span.End()
+
+	return datastore.RelationshipExists(ctx, r.delegate, tpl)
+}
+
+func (r *observableReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "NamespaceHasRelationships", trace.WithAttributes(
+		attribute.String("namespace", nsName),
+	))
+	defer span.End()
+
+	return datastore.NamespaceHasRelationships(ctx, r.delegate, nsName)
+}
+
+func (r *observableReader) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, options ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "QueryRelationshipsWithRevisions")
+	defer span.End()
+
+	return datastore.QueryRelationshipsWithRevisions(ctx, r.delegate, filter, options...)
+}
+
 func (r *observableReader) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, options ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "QueryRelationships")
@@ -176,6 +261,17 @@ func (r *observableReader) QueryRelationships(ctx context.Context, filter datast
 	return observableRelationshipIterator{span, iterator}, nil
 }
 
+func (r *observableReader) QueryRelationshipsForResourceTypes(ctx context.Context, resourceTypes []string, options ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "QueryRelationshipsForResourceTypes")
+
+	iterator, err := r.delegate.QueryRelationshipsForResourceTypes(ctx, resourceTypes, options...)
+	if err != nil {
+		return iterator, err
+	}
+	return observableRelationshipIterator{span, iterator}, nil
+}
+
 type observableRelationshipIterator struct {
 	span     trace.Span
 	delegate datastore.RelationshipIterator
@@ -236,6 +332,16 @@ func (rwt *observableRWT) WriteRelationships(ctx context.Context, mutations []*c
 	return rwt.delegate.WriteRelationships(ctx, mutations)
 }
 
+func (rwt *observableRWT) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "WriteRelationshipsWithResults", trace.WithAttributes(
+		attribute.Int("mutations", len(mutations)),
+	))
+	defer span.End()
+
+	return datastore.WriteRelationshipsWithResults(ctx, rwt.delegate, mutations)
+}
+
 func (rwt *observableRWT) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
 	nsNames := make([]string, 0, len(newConfigs))
 	for _, ns := range newConfigs {
@@ -251,14 +357,14 @@ func (rwt *observableRWT) WriteNamespaces(ctx context.Context, newConfigs ...*co
 	return rwt.delegate.WriteNamespaces(ctx, newConfigs...)
 }
 
-func (rwt *observableRWT) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt *observableRWT) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "DeleteNamespace", trace.WithAttributes(
 		attribute.StringSlice("names", nsNames),
 	))
 	defer span.End()
 
-	return rwt.delegate.DeleteNamespaces(ctx, nsNames...)
+	return rwt.delegate.DeleteNamespaces(ctx, delOption, nsNames...)
 }
 
 func (rwt *observableRWT) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
@@ -273,9 +379,43 @@ func (rwt *observableRWT) DeleteRelationships(ctx context.Context, filter *v1.Re
 	return rwt.delegate.DeleteRelationships(ctx, filter)
 }
 
+func (rwt *observableRWT) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(
+		ctx,
+		"DeleteRelationshipsForSubject",
+		trace.WithAttributes(attribute.String("subject", tuple.StringONR(subject))),
+	)
+	defer span.End()
+
+	return rwt.delegate.DeleteRelationshipsForSubject(ctx, subject)
+}
+
+func (rwt *observableRWT) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(
+		ctx,
+		"RewriteRelationshipsSubject",
+		trace.WithAttributes(
+			attribute.String("oldSubject", tuple.StringONR(oldSubject)),
+			attribute.String("newSubject", tuple.StringONR(newSubject)),
+		),
+	)
+	defer span.End()
+
+	return rwt.delegate.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+}
+
 var (
-	_ datastore.Datastore            = (*observableProxy)(nil)
-	_ datastore.Reader               = (*observableReader)(nil)
-	_ datastore.ReadWriteTransaction = (*observableRWT)(nil)
-	_ datastore.RelationshipIterator = (*observableRelationshipIterator)(nil)
+	_ datastore.Datastore                     = (*observableProxy)(nil)
+	_ datastore.PoolStatsReporter             = (*observableProxy)(nil)
+	_ datastore.RelationshipHistoryReader     = (*observableProxy)(nil)
+	_ datastore.ConsistencyValidator          = (*observableProxy)(nil)
+	_ datastore.Reader                        = (*observableReader)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*observableReader)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*observableReader)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*observableReader)(nil)
+	_ datastore.ReadWriteTransaction          = (*observableRWT)(nil)
+	_ datastore.RelationshipUpdateReporter    = (*observableRWT)(nil)
+	_ datastore.RelationshipIterator          = (*observableRelationshipIterator)(nil)
 )
@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+type overlayDatastore struct {
+	datastore.Datastore
+	overlay *relationshipOverlay
+}
+
+// NewOverlayDatastore creates a read-only proxy whose readers return the relationships of the
+// delegate datastore as though the given updates had been applied on top of the revision read,
+// without writing them. CREATE and TOUCH updates add their relationship, replacing any stored
+// relationship with the same resource, relation and subject, and DELETE updates remove it. Of
+// several updates of the same relationship, the last wins.
+//
+// As every relationship query is merged with the overlay, the relationships it finds are
+// materialized rather than streamed; the proxy is intended for analysis of hypothetical changes,
+// rather than for serving traffic.
+func NewOverlayDatastore(delegate datastore.Datastore, updates []*core.RelationTupleUpdate) datastore.Datastore {
+	return overlayDatastore{Datastore: delegate, overlay: newRelationshipOverlay(updates)}
+}
+
+func (od overlayDatastore) SnapshotReader(revision datastore.Revision) datastore.Reader {
+	return overlayReader{Reader: od.Datastore.SnapshotReader(revision), overlay: od.overlay}
+}
+
+func (od overlayDatastore) ReadWriteTx(context.Context, datastore.TxUserFunc, ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return datastore.NoRevision, errReadOnly
+}
+
+// relationshipOverlay is the outcome of a list of updates: the relationships they add, in the
+// order first updated, and the key of every relationship they add or remove, each of which
+// hides the stored relationship with the same key.
+type relationshipOverlay struct {
+	added   []*core.RelationTuple
+	updated *util.Set[string]
+}
+
+func newRelationshipOverlay(updates []*core.RelationTupleUpdate) *relationshipOverlay {
+	updates = datastore.CanonicalizeSubjectRelations(updates)
+
+	var keys []string
+	latest := make(map[string]*core.RelationTupleUpdate, len(updates))
+	for _, update := range updates {
+		key := tuple.StringWithoutCaveat(update.Tuple)
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = update
+	}
+
+	overlay := &relationshipOverlay{updated: util.NewSet[string]()}
+	for _, key := range keys {
+		overlay.updated.Add(key)
+		if update := latest[key]; update.Operation != core.RelationTupleUpdate_DELETE {
+			overlay.added = append(overlay.added, update.Tuple)
+		}
+	}
+	return overlay
+}
+
+// merge returns the relationships found by the delegate iterator which are not hidden by the
+// overlay, followed by those the overlay adds which are matched.
+func (ro *relationshipOverlay) merge(iter datastore.RelationshipIterator, matches func(*core.RelationTuple) bool) ([]*core.RelationTuple, error) {
+	defer iter.Close()
+
+	var tuples []*core.RelationTuple
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if !ro.updated.Has(tuple.StringWithoutCaveat(tpl)) {
+			tuples = append(tuples, tpl)
+		}
+	}
+	if iter.Err() != nil {
+		return nil, iter.Err()
+	}
+
+	for _, tpl := range ro.added {
+		if matches(tpl) {
+			tuples = append(tuples, tpl)
+		}
+	}
+	return tuples, nil
+}
+
+type overlayReader struct {
+	datastore.Reader
+	overlay *relationshipOverlay
+}
+
+func (r overlayReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	// The limit is applied once merged, as the overlay may hide relationships within it.
+	iter, err := r.Reader.QueryRelationships(ctx, filter, withoutLimit(queryOpts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := r.overlay.merge(iter, func(tpl *core.RelationTuple) bool {
+		return relationshipsFilterMatches(filter, tpl) && usersetsMatch(queryOpts.Usersets, tpl)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedAndLimited(tuples, queryOpts.Sort, queryOpts.Limit), nil
+}
+
+func (r overlayReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	iter, err := r.Reader.QueryRelationshipsForResourceTypes(ctx, resourceTypes, withoutLimit(queryOpts)...)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := r.overlay.merge(iter, func(tpl *core.RelationTuple) bool {
+		return stringz.SliceContains(resourceTypes, tpl.ResourceAndRelation.Namespace) && usersetsMatch(queryOpts.Usersets, tpl)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedAndLimited(tuples, queryOpts.Sort, queryOpts.Limit), nil
+}
+
+func (r overlayReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	var delegateOpts []options.ReverseQueryOptionsOption
+	if queryOpts.ResRelation != nil {
+		delegateOpts = append(delegateOpts, options.WithResRelation(queryOpts.ResRelation))
+	}
+
+	iter, err := r.Reader.ReverseQueryRelationships(ctx, subjectsFilter, delegateOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples, err := r.overlay.merge(iter, func(tpl *core.RelationTuple) bool {
+		if queryOpts.ResRelation != nil && (queryOpts.ResRelation.Namespace != tpl.ResourceAndRelation.Namespace ||
+			queryOpts.ResRelation.Relation != tpl.ResourceAndRelation.Relation) {
+			return false
+		}
+		return subjectsFilterMatches(subjectsFilter, tpl)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedAndLimited(tuples, options.Unsorted, queryOpts.ReverseLimit), nil
+}
+
+func withoutLimit(queryOpts *options.QueryOptions) []options.QueryOptionsOption {
+	opts := []options.QueryOptionsOption{options.WithSort(queryOpts.Sort)}
+	for _, userset := range queryOpts.Usersets {
+		opts = append(opts, options.WithUsersets(userset))
+	}
+	return opts
+}
+
+func sortedAndLimited(tuples []*core.RelationTuple, order options.SortOrder, limit *uint64) datastore.RelationshipIterator {
+	if order != options.Unsorted {
+		sort.SliceStable(tuples, func(i, j int) bool {
+			return order.LessThan(tuples[i], tuples[j])
+		})
+	}
+
+	if limit != nil && uint64(len(tuples)) > *limit {
+		tuples = tuples[:*limit]
+	}
+
+	return datastore.NewSliceRelationshipIterator(tuples)
+}
+
+func relationshipsFilterMatches(filter datastore.RelationshipsFilter, tpl *core.RelationTuple) bool {
+	resource := tpl.ResourceAndRelation
+	switch {
+	case filter.ResourceType != resource.Namespace:
+		return false
+	case len(filter.OptionalResourceIds) > 0 && !stringz.SliceContains(filter.OptionalResourceIds, resource.ObjectId):
+		return false
+	case !strings.HasPrefix(resource.ObjectId, filter.OptionalResourceIDPrefix):
+		return false
+	case filter.OptionalResourceRelation != "" && filter.OptionalResourceRelation != resource.Relation:
+		return false
+	case filter.OptionalCaveatName != "" && tpl.Caveat.GetCaveatName() != filter.OptionalCaveatName:
+		return false
+	case filter.OptionalSubjectsFilter != nil && !subjectsFilterMatches(*filter.OptionalSubjectsFilter, tpl):
+		return false
+	default:
+		return true
+	}
+}
+
+func subjectsFilterMatches(filter datastore.SubjectsFilter, tpl *core.RelationTuple) bool {
+	subject := tpl.Subject
+	if filter.SubjectType != subject.Namespace {
+		return false
+	}
+
+	if len(filter.OptionalSubjectIds) > 0 && !stringz.SliceContains(filter.OptionalSubjectIds, subject.ObjectId) &&
+		!(filter.IncludeWildcardSubjects && subject.ObjectId == tuple.PublicWildcard) {
+		return false
+	}
+
+	if filter.RelationFilter.IsEmpty() {
+		return true
+	}
+
+	return (filter.RelationFilter.IncludeEllipsisRelation && subject.Relation == datastore.Ellipsis) ||
+		(filter.RelationFilter.NonEllipsisRelation != "" && filter.RelationFilter.NonEllipsisRelation == subject.Relation)
+}
+
+func usersetsMatch(usersets []*core.ObjectAndRelation, tpl *core.RelationTuple) bool {
+	if len(usersets) == 0 {
+		return true
+	}
+
+	for _, userset := range usersets {
+		if userset.Namespace == tpl.Subject.Namespace &&
+			userset.ObjectId == tpl.Subject.ObjectId &&
+			stringz.DefaultEmpty(userset.Relation, datastore.Ellipsis) == tpl.Subject.Relation {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ datastore.Datastore = (*overlayDatastore)(nil)
+	_ datastore.Reader    = (*overlayReader)(nil)
+)
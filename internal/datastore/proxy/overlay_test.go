@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestOverlayDatastore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	delegate := newMirroringTestDatastore(t)
+	revision, err := common.WriteTuples(ctx, delegate, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:first#viewer@user:tom"),
+		tuple.Parse("document:first#viewer@user:sarah"),
+		tuple.Parse("document:second#viewer@user:tom"),
+	)
+	require.NoError(err)
+
+	ds := NewOverlayDatastore(delegate, []*core.RelationTupleUpdate{
+		tuple.Delete(tuple.Parse("document:first#viewer@user:tom")),
+		tuple.Create(tuple.Parse("document:first#viewer@user:fred")),
+		tuple.Create(tuple.Parse("document:first#editor@user:tom")),
+		tuple.Delete(tuple.Parse("document:first#editor@user:tom")),
+		tuple.Touch(&core.RelationTuple{
+			ResourceAndRelation: tuple.ParseONR("document:third#viewer"),
+			Subject:             tuple.ObjectAndRelation("user", "tom", ""),
+		}),
+	})
+	reader := ds.SnapshotReader(revision)
+
+	found, err := collectRelationships(reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:        "document",
+		OptionalResourceIds: []string{"first"},
+	}))
+	require.NoError(err)
+	require.ElementsMatch([]string{"document:first#viewer@user:sarah", "document:first#viewer@user:fred"}, found)
+
+	found, err = collectRelationships(reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: "document",
+	}, options.WithSort(options.ByResource), options.WithLimit(options.LimitOne)))
+	require.NoError(err)
+	require.Equal([]string{"document:first#viewer@user:fred"}, found)
+
+	found, err = collectRelationships(reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	}, options.WithResRelation(&options.ResourceRelation{Namespace: "document", Relation: "viewer"})))
+	require.NoError(err)
+	require.ElementsMatch([]string{"document:second#viewer@user:tom", "document:third#viewer@user:tom"}, found)
+
+	found, err = collectRelationships(reader.QueryRelationshipsForResourceTypes(ctx, []string{"document"}))
+	require.NoError(err)
+	require.Len(found, 4)
+
+	// The delegate is left untouched, and the overlay cannot be written.
+	found, err = collectRelationships(delegate.SnapshotReader(revision).QueryRelationshipsForResourceTypes(ctx, []string{"document"}))
+	require.NoError(err)
+	require.Len(found, 3)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("document:fourth#viewer@user:tom"))
+	require.ErrorAs(err, &datastore.ErrReadOnly{})
+}
@@ -2,6 +2,7 @@ package proxy_test
 
 import (
 	"context"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/stretchr/testify/mock"
@@ -23,6 +24,7 @@ func (dm *MockDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader
 func (dm *MockDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	args := dm.Called()
 	mockRWT := args.Get(0).(datastore.ReadWriteTransaction)
@@ -49,6 +51,11 @@ func (dm *MockDatastore) CheckRevision(ctx context.Context, revision datastore.R
 	return args.Error(0)
 }
 
+func (dm *MockDatastore) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	args := dm.Called(at)
+	return args.Get(0).(datastore.Revision), args.Error(1)
+}
+
 func (dm *MockDatastore) RevisionFromString(s string) (datastore.Revision, error) {
 	args := dm.Called(s)
 	return args.Get(0).(datastore.Revision), args.Error(1)
@@ -59,11 +66,21 @@ func (dm *MockDatastore) Watch(ctx context.Context, afterRevision datastore.Revi
 	return args.Get(0).(<-chan *datastore.RevisionChanges), args.Get(1).(<-chan error)
 }
 
+func (dm *MockDatastore) DiffRevisions(ctx context.Context, startRevision, endRevision datastore.Revision) (*datastore.RevisionDiff, error) {
+	args := dm.Called(startRevision, endRevision)
+	return args.Get(0).(*datastore.RevisionDiff), args.Error(1)
+}
+
 func (dm *MockDatastore) IsReady(ctx context.Context) (bool, error) {
 	args := dm.Called()
 	return args.Bool(0), args.Error(1)
 }
 
+func (dm *MockDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	args := dm.Called()
+	return args.Get(0).(datastore.HealthCheckResult)
+}
+
 func (dm *MockDatastore) Features(ctx context.Context) (*datastore.Features, error) {
 	args := dm.Called()
 	return args.Get(0).(*datastore.Features), args.Error(1)
@@ -117,6 +134,26 @@ func (dm *MockReader) QueryRelationships(
 	return results, args.Error(1)
 }
 
+func (dm *MockReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	options ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	callArgs := make([]interface{}, 0, len(options)+1)
+	callArgs = append(callArgs, resourceTypes)
+	for _, option := range options {
+		callArgs = append(callArgs, option)
+	}
+
+	args := dm.Called(callArgs...)
+	var results datastore.RelationshipIterator
+	if args.Get(0) != nil {
+		results = args.Get(0).(datastore.RelationshipIterator)
+	}
+
+	return results, args.Error(1)
+}
+
 func (dm *MockReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -137,13 +174,13 @@ func (dm *MockReader) ReverseQueryRelationships(
 	return results, args.Error(1)
 }
 
-func (dm *MockReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (dm *MockReader) ListNamespaces(ctx context.Context, options ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	args := dm.Called()
 	return args.Get(0).([]*core.NamespaceDefinition), args.Error(1)
 }
 
 func (dm *MockReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
-	args := dm.Called()
+	args := dm.Called(nsNames)
 	return args.Get(0).([]*core.NamespaceDefinition), args.Error(1)
 }
 
@@ -195,6 +232,26 @@ func (dm *MockReadWriteTransaction) QueryRelationships(
 	return results, args.Error(1)
 }
 
+func (dm *MockReadWriteTransaction) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	options ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	callArgs := make([]interface{}, 0, len(options)+1)
+	callArgs = append(callArgs, resourceTypes)
+	for _, option := range options {
+		callArgs = append(callArgs, option)
+	}
+
+	args := dm.Called(callArgs...)
+	var results datastore.RelationshipIterator
+	if args.Get(0) != nil {
+		results = args.Get(0).(datastore.RelationshipIterator)
+	}
+
+	return results, args.Error(1)
+}
+
 func (dm *MockReadWriteTransaction) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -215,7 +272,7 @@ func (dm *MockReadWriteTransaction) ReverseQueryRelationships(
 	return results, args.Error(1)
 }
 
-func (dm *MockReadWriteTransaction) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (dm *MockReadWriteTransaction) ListNamespaces(ctx context.Context, options ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	args := dm.Called()
 	return args.Get(0).([]*core.NamespaceDefinition), args.Error(1)
 }
@@ -235,13 +292,24 @@ func (dm *MockReadWriteTransaction) DeleteRelationships(ctx context.Context, fil
 	return args.Error(0)
 }
 
+func (dm *MockReadWriteTransaction) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	args := dm.Called(subject)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (dm *MockReadWriteTransaction) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	args := dm.Called(oldSubject, newSubject)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
 func (dm *MockReadWriteTransaction) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
 	args := dm.Called(newConfigs)
 	return args.Error(0)
 }
 
-func (dm *MockReadWriteTransaction) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
-	xs := make([]any, 0, len(nsNames))
+func (dm *MockReadWriteTransaction) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	xs := make([]any, 0, len(nsNames)+1)
+	xs = append(xs, delOption)
 	for _, nsName := range nsNames {
 		xs = append(xs, nsName)
 	}
@@ -3,7 +3,9 @@ package proxy
 import (
 	"context"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
 var errReadOnly = datastore.NewReadonlyErr()
@@ -18,6 +20,31 @@ func NewReadonlyDatastore(delegate datastore.Datastore) datastore.Datastore {
 	return roDatastore{Datastore: delegate}
 }
 
-func (rd roDatastore) ReadWriteTx(context.Context, datastore.TxUserFunc) (datastore.Revision, error) {
+func (rd roDatastore) ReadWriteTx(context.Context, datastore.TxUserFunc, ...options.RWTOptionsOption) (datastore.Revision, error) {
 	return datastore.NoRevision, errReadOnly
 }
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (rd roDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(rd.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (rd roDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, rd.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (rd roDatastore) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, rd.Datastore)
+}
+
+var (
+	_ datastore.Datastore                 = roDatastore{}
+	_ datastore.PoolStatsReporter         = roDatastore{}
+	_ datastore.RelationshipHistoryReader = roDatastore{}
+	_ datastore.ConsistencyValidator      = roDatastore{}
+)
@@ -0,0 +1,961 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// RecordedOperation is a single call made to a datastore through a recording proxy. Only the
+// arguments of the called method are set.
+type RecordedOperation struct {
+	// Method is the name of the datastore method which was called.
+	Method string
+
+	// Transaction, if not zero, identifies the read-write transaction in which the call was made.
+	// The calls of a transaction are recorded before the ReadWriteTx operation which committed it,
+	// and only for the attempt which was committed.
+	Transaction uint64 `json:",omitempty"`
+
+	// Revision is the revision of the snapshot read, or the revision returned by the call.
+	Revision string `json:",omitempty"`
+
+	Names                 []string                                      `json:",omitempty"`
+	Updates               []*core.RelationTupleUpdate                   `json:"-"`
+	Namespaces            []*core.NamespaceDefinition                   `json:"-"`
+	Caveats               []*core.CaveatDefinition                      `json:"-"`
+	Subjects              []*core.ObjectAndRelation                     `json:",omitempty"`
+	RelationshipsFilter   *datastore.RelationshipsFilter                `json:",omitempty"`
+	SubjectsFilter        *datastore.SubjectsFilter                     `json:",omitempty"`
+	DeleteFilter          *v1.RelationshipFilter                        `json:",omitempty"`
+	DeleteOption          datastore.DeleteNamespacesRelationshipsOption `json:",omitempty"`
+	QueryOptions          *options.QueryOptions                         `json:",omitempty"`
+	ReverseQueryOptions   *options.ReverseQueryOptions                  `json:",omitempty"`
+	ListNamespacesOptions *options.ListNamespacesOptions                `json:",omitempty"`
+	RWTOptions            *options.RWTOptions                           `json:",omitempty"`
+	At                    *time.Time                                    `json:",omitempty"`
+
+	// Error is the message of the error returned by the call, if any.
+	Error string `json:",omitempty"`
+}
+
+// MarshalJSON encodes the operation, encoding its definitions and updates as protobuf JSON.
+func (op RecordedOperation) MarshalJSON() ([]byte, error) {
+	type plain RecordedOperation
+	encoded := struct {
+		plain
+		Updates    []json.RawMessage `json:",omitempty"`
+		Namespaces []json.RawMessage `json:",omitempty"`
+		Caveats    []json.RawMessage `json:",omitempty"`
+	}{plain: plain(op)}
+
+	var err error
+	if encoded.Updates, err = marshalProtos(op.Updates); err != nil {
+		return nil, err
+	}
+	if encoded.Namespaces, err = marshalProtos(op.Namespaces); err != nil {
+		return nil, err
+	}
+	if encoded.Caveats, err = marshalProtos(op.Caveats); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes an operation encoded by MarshalJSON.
+func (op *RecordedOperation) UnmarshalJSON(data []byte) error {
+	type plain RecordedOperation
+	var decoded struct {
+		plain
+		Updates    []json.RawMessage
+		Namespaces []json.RawMessage
+		Caveats    []json.RawMessage
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*op = RecordedOperation(decoded.plain)
+
+	var err error
+	if op.Updates, err = unmarshalProtos[*core.RelationTupleUpdate](decoded.Updates); err != nil {
+		return err
+	}
+	if op.Namespaces, err = unmarshalProtos[*core.NamespaceDefinition](decoded.Namespaces); err != nil {
+		return err
+	}
+	op.Caveats, err = unmarshalProtos[*core.CaveatDefinition](decoded.Caveats)
+	return err
+}
+
+func marshalProtos[T proto.Message](messages []T) ([]json.RawMessage, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	encoded := make([]json.RawMessage, 0, len(messages))
+	for _, message := range messages {
+		data, err := protojson.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, data)
+	}
+	return encoded, nil
+}
+
+func unmarshalProtos[T interface {
+	proto.Message
+	*M
+}, M any](encoded []json.RawMessage) ([]T, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	messages := make([]T, 0, len(encoded))
+	for _, data := range encoded {
+		message := T(new(M))
+		if err := protojson.Unmarshal(data, message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// OperationSink receives the operations recorded by a recording proxy. Record may be called
+// concurrently.
+type OperationSink interface {
+	Record(op RecordedOperation) error
+}
+
+// MemoryOperationSink is an OperationSink which keeps the recorded operations in memory.
+type MemoryOperationSink struct {
+	sync.Mutex
+	operations []RecordedOperation
+}
+
+// NewMemoryOperationSink creates an empty in-memory sink.
+func NewMemoryOperationSink() *MemoryOperationSink {
+	return &MemoryOperationSink{}
+}
+
+func (s *MemoryOperationSink) Record(op RecordedOperation) error {
+	s.Lock()
+	defer s.Unlock()
+	s.operations = append(s.operations, op)
+	return nil
+}
+
+// Operations returns the operations recorded so far, in order.
+func (s *MemoryOperationSink) Operations() []RecordedOperation {
+	s.Lock()
+	defer s.Unlock()
+	return append([]RecordedOperation(nil), s.operations...)
+}
+
+// FileOperationSink is an OperationSink which appends the recorded operations to a file, one JSON
+// encoded operation per line. The recording can be read back with ReadRecordedOperations.
+type FileOperationSink struct {
+	sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewFileOperationSink creates a sink appending to the file at the given path, creating it if it
+// does not exist.
+func NewFileOperationSink(path string) (*FileOperationSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording file: %w", err)
+	}
+
+	return &FileOperationSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (s *FileOperationSink) Record(op RecordedOperation) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.encoder.Encode(op)
+}
+
+// Close closes the file of the sink.
+func (s *FileOperationSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.file.Close()
+}
+
+// ReadRecordedOperations reads the operations written by a FileOperationSink.
+func ReadRecordedOperations(r io.Reader) ([]RecordedOperation, error) {
+	var operations []RecordedOperation
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var op RecordedOperation
+		if err := decoder.Decode(&op); err != nil {
+			if err == io.EOF {
+				return operations, nil
+			}
+			return nil, fmt.Errorf("unable to read recorded operation %d: %w", len(operations), err)
+		}
+		operations = append(operations, op)
+	}
+}
+
+// RecordingOption is an option for a recording proxy.
+type RecordingOption func(rd *recordingDatastore)
+
+// WithHashedObjectIDs replaces the object IDs of relationships, filters and subjects in the
+// recorded operations with a hash, so they are not disclosed by the recording. The same ID is
+// always replaced by the same hash, so a recording can still be replayed consistently.
+// Wildcard subjects are kept as is.
+func WithHashedObjectIDs() RecordingOption {
+	return func(rd *recordingDatastore) {
+		rd.hashObjectIDs = true
+	}
+}
+
+type recordingDatastore struct {
+	delegate      datastore.Datastore
+	sink          OperationSink
+	hashObjectIDs bool
+
+	lastTransaction uint64
+}
+
+// NewRecordingDatastore creates a proxy which passes all calls through to the delegate, and
+// records each of them, along with its arguments and any error, to the sink. A recording can be
+// replayed against another datastore with ReplayOperations.
+//
+// Failures to record an operation are logged and do not fail the call.
+func NewRecordingDatastore(delegate datastore.Datastore, sink OperationSink, opts ...RecordingOption) datastore.Datastore {
+	rd := &recordingDatastore{delegate: delegate, sink: sink}
+	for _, opt := range opts {
+		opt(rd)
+	}
+	return rd
+}
+
+func (rd *recordingDatastore) record(ctx context.Context, op RecordedOperation, err error) {
+	if err != nil {
+		op.Error = err.Error()
+	}
+
+	if recordErr := rd.sink.Record(op); recordErr != nil {
+		log.Ctx(ctx).Warn().Err(recordErr).Str("method", op.Method).Msg("unable to record datastore operation")
+	}
+}
+
+func (rd *recordingDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader {
+	return &recordingReader{
+		delegate: rd.delegate.SnapshotReader(rev),
+		rd:       rd,
+		record: func(ctx context.Context, op RecordedOperation, err error) {
+			op.Revision = rev.String()
+			rd.record(ctx, op, err)
+		},
+	}
+}
+
+func (rd *recordingDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	transaction := atomic.AddUint64(&rd.lastTransaction, 1)
+
+	var recorded []RecordedOperation
+	revision, err := rd.delegate.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		// The transaction function may be retried, so only the operations of the attempt that
+		// was committed are kept.
+		var lock sync.Mutex
+		var attempt []RecordedOperation
+		recorder := &recordingRWT{
+			recordingReader: recordingReader{
+				delegate: rwt,
+				rd:       rd,
+				record: func(ctx context.Context, op RecordedOperation, err error) {
+					op.Transaction = transaction
+					if err != nil {
+						op.Error = err.Error()
+					}
+
+					lock.Lock()
+					defer lock.Unlock()
+					attempt = append(attempt, op)
+				},
+			},
+			delegate: rwt,
+		}
+
+		if err := f(recorder); err != nil {
+			return err
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		recorded = attempt
+		return nil
+	}, opts...)
+
+	for _, op := range recorded {
+		rd.record(ctx, op, nil)
+	}
+
+	op := RecordedOperation{
+		Method:      "ReadWriteTx",
+		Transaction: transaction,
+		RWTOptions:  options.NewRWTOptionsWithOptions(opts...),
+	}
+	if err == nil {
+		op.Revision = revision.String()
+	}
+	rd.record(ctx, op, err)
+
+	return revision, err
+}
+
+func (rd *recordingDatastore) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	revision, err := rd.delegate.OptimizedRevision(ctx)
+	rd.record(ctx, RecordedOperation{Method: "OptimizedRevision", Revision: revisionString(revision)}, err)
+	return revision, err
+}
+
+func (rd *recordingDatastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	revision, err := rd.delegate.HeadRevision(ctx)
+	rd.record(ctx, RecordedOperation{Method: "HeadRevision", Revision: revisionString(revision)}, err)
+	return revision, err
+}
+
+func (rd *recordingDatastore) CheckRevision(ctx context.Context, revision datastore.Revision) error {
+	err := rd.delegate.CheckRevision(ctx, revision)
+	rd.record(ctx, RecordedOperation{Method: "CheckRevision", Revision: revisionString(revision)}, err)
+	return err
+}
+
+func (rd *recordingDatastore) RevisionAtTime(ctx context.Context, at time.Time) (datastore.Revision, error) {
+	revision, err := rd.delegate.RevisionAtTime(ctx, at)
+	rd.record(ctx, RecordedOperation{Method: "RevisionAtTime", Revision: revisionString(revision), At: &at}, err)
+	return revision, err
+}
+
+func (rd *recordingDatastore) RevisionFromString(serialized string) (datastore.Revision, error) {
+	revision, err := rd.delegate.RevisionFromString(serialized)
+	rd.record(context.Background(), RecordedOperation{Method: "RevisionFromString", Revision: serialized}, err)
+	return revision, err
+}
+
+func (rd *recordingDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	rd.record(ctx, RecordedOperation{Method: "Watch", Revision: revisionString(afterRevision)}, nil)
+	return rd.delegate.Watch(ctx, afterRevision)
+}
+
+func (rd *recordingDatastore) DiffRevisions(ctx context.Context, startRevision, endRevision datastore.Revision) (*datastore.RevisionDiff, error) {
+	diff, err := rd.delegate.DiffRevisions(ctx, startRevision, endRevision)
+	rd.record(ctx, RecordedOperation{
+		Method:   "DiffRevisions",
+		Revision: revisionString(startRevision) + ".." + revisionString(endRevision),
+	}, err)
+	return diff, err
+}
+
+func (rd *recordingDatastore) IsReady(ctx context.Context) (bool, error) {
+	ready, err := rd.delegate.IsReady(ctx)
+	rd.record(ctx, RecordedOperation{Method: "IsReady"}, err)
+	return ready, err
+}
+
+func (rd *recordingDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	result := rd.delegate.HealthCheck(ctx)
+	rd.record(ctx, RecordedOperation{Method: "HealthCheck"}, nil)
+	return result
+}
+
+func (rd *recordingDatastore) Features(ctx context.Context) (*datastore.Features, error) {
+	features, err := rd.delegate.Features(ctx)
+	rd.record(ctx, RecordedOperation{Method: "Features"}, err)
+	return features, err
+}
+
+func (rd *recordingDatastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	stats, err := rd.delegate.Statistics(ctx)
+	rd.record(ctx, RecordedOperation{Method: "Statistics"}, err)
+	return stats, err
+}
+
+func (rd *recordingDatastore) Close() error {
+	err := rd.delegate.Close()
+	rd.record(context.Background(), RecordedOperation{Method: "Close"}, err)
+	return err
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (rd *recordingDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(rd.delegate)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore. The resource and subject of the relationship are recorded as its subjects.
+func (rd *recordingDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	versions, err := datastore.RelationshipHistory(ctx, rd.delegate, tpl)
+	rd.record(ctx, RecordedOperation{
+		Method:   "RelationshipHistory",
+		Subjects: rd.subjects(tpl.ResourceAndRelation, tpl.Subject),
+	}, err)
+	return versions, err
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (rd *recordingDatastore) ValidateConsistency(ctx context.Context) error {
+	err := datastore.ValidateConsistency(ctx, rd.delegate)
+	rd.record(ctx, RecordedOperation{Method: "ValidateConsistency"}, err)
+	return err
+}
+
+func revisionString(revision datastore.Revision) string {
+	if revision == nil {
+		return ""
+	}
+	return revision.String()
+}
+
+type recordingReader struct {
+	delegate datastore.Reader
+	rd       *recordingDatastore
+	record   func(ctx context.Context, op RecordedOperation, err error)
+}
+
+func (rr *recordingReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	caveat, lastWritten, err := rr.delegate.ReadCaveatByName(ctx, name)
+	rr.record(ctx, RecordedOperation{Method: "ReadCaveatByName", Names: []string{name}}, err)
+	return caveat, lastWritten, err
+}
+
+func (rr *recordingReader) ListCaveats(ctx context.Context, caveatNamesForFiltering ...string) ([]*core.CaveatDefinition, error) {
+	caveats, err := rr.delegate.ListCaveats(ctx, caveatNamesForFiltering...)
+	rr.record(ctx, RecordedOperation{Method: "ListCaveats", Names: caveatNamesForFiltering}, err)
+	return caveats, err
+}
+
+func (rr *recordingReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	it, err := rr.delegate.QueryRelationships(ctx, filter, opts...)
+	rr.record(ctx, RecordedOperation{
+		Method:              "QueryRelationships",
+		RelationshipsFilter: rr.rd.relationshipsFilter(filter),
+		QueryOptions:        rr.rd.queryOptions(options.NewQueryOptionsWithOptions(opts...)),
+	}, err)
+	return it, err
+}
+
+func (rr *recordingReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	it, err := rr.delegate.QueryRelationshipsForResourceTypes(ctx, resourceTypes, opts...)
+	rr.record(ctx, RecordedOperation{
+		Method:       "QueryRelationshipsForResourceTypes",
+		Names:        resourceTypes,
+		QueryOptions: rr.rd.queryOptions(options.NewQueryOptionsWithOptions(opts...)),
+	}, err)
+	return it, err
+}
+
+func (rr *recordingReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	it, err := rr.delegate.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+	rr.record(ctx, RecordedOperation{
+		Method:              "ReverseQueryRelationships",
+		SubjectsFilter:      rr.rd.subjectsFilter(&subjectsFilter),
+		ReverseQueryOptions: options.NewReverseQueryOptionsWithOptions(opts...),
+	}, err)
+	return it, err
+}
+
+func (rr *recordingReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	ns, lastWritten, err := rr.delegate.ReadNamespace(ctx, nsName)
+	rr.record(ctx, RecordedOperation{Method: "ReadNamespace", Names: []string{nsName}}, err)
+	return ns, lastWritten, err
+}
+
+func (rr *recordingReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
+	nsDefs, err := rr.delegate.ListNamespaces(ctx, opts...)
+	rr.record(ctx, RecordedOperation{
+		Method:                "ListNamespaces",
+		ListNamespacesOptions: options.NewListNamespacesOptionsWithOptions(opts...),
+	}, err)
+	return nsDefs, err
+}
+
+func (rr *recordingReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	nsDefs, err := rr.delegate.LookupNamespaces(ctx, nsNames)
+	rr.record(ctx, RecordedOperation{Method: "LookupNamespaces", Names: nsNames}, err)
+	return nsDefs, err
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to the
+// delegate reader. The resource and subject of the relationship are recorded as its subjects.
+func (rr *recordingReader) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	exists, err := datastore.RelationshipExists(ctx, rr.delegate, tpl)
+	rr.record(ctx, RecordedOperation{
+		Method:   "RelationshipExists",
+		Subjects: rr.rd.subjects(tpl.ResourceAndRelation, tpl.Subject),
+	}, err)
+	return exists, err
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate reader.
+func (rr *recordingReader) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	found, err := datastore.NamespaceHasRelationships(ctx, rr.delegate, nsName)
+	rr.record(ctx, RecordedOperation{Method: "NamespaceHasRelationships", Names: []string{nsName}}, err)
+	return found, err
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by forwarding
+// to the delegate reader.
+func (rr *recordingReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	versions, err := datastore.QueryRelationshipsWithRevisions(ctx, rr.delegate, filter, opts...)
+	rr.record(ctx, RecordedOperation{
+		Method:              "QueryRelationshipsWithRevisions",
+		RelationshipsFilter: rr.rd.relationshipsFilter(filter),
+		QueryOptions:        rr.rd.queryOptions(options.NewQueryOptionsWithOptions(opts...)),
+	}, err)
+	return versions, err
+}
+
+type recordingRWT struct {
+	recordingReader
+	delegate datastore.ReadWriteTransaction
+}
+
+func (rrwt *recordingRWT) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	err := rrwt.delegate.WriteRelationships(ctx, mutations)
+	rrwt.record(ctx, RecordedOperation{Method: "WriteRelationships", Updates: rrwt.rd.updates(mutations)}, err)
+	return err
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by forwarding
+// to the delegate transaction. The call is recorded as a WriteRelationships, as it is replayed.
+func (rrwt *recordingRWT) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	results, err := datastore.WriteRelationshipsWithResults(ctx, rrwt.delegate, mutations)
+	rrwt.record(ctx, RecordedOperation{Method: "WriteRelationships", Updates: rrwt.rd.updates(mutations)}, err)
+	return results, err
+}
+
+func (rrwt *recordingRWT) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	err := rrwt.delegate.DeleteRelationships(ctx, filter)
+	rrwt.record(ctx, RecordedOperation{Method: "DeleteRelationships", DeleteFilter: rrwt.rd.deleteFilter(filter)}, err)
+	return err
+}
+
+func (rrwt *recordingRWT) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	deleted, err := rrwt.delegate.DeleteRelationshipsForSubject(ctx, subject)
+	rrwt.record(ctx, RecordedOperation{
+		Method:   "DeleteRelationshipsForSubject",
+		Subjects: rrwt.rd.subjects(subject),
+	}, err)
+	return deleted, err
+}
+
+func (rrwt *recordingRWT) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	rewritten, err := rrwt.delegate.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+	rrwt.record(ctx, RecordedOperation{
+		Method:   "RewriteRelationshipsSubject",
+		Subjects: rrwt.rd.subjects(oldSubject, newSubject),
+	}, err)
+	return rewritten, err
+}
+
+func (rrwt *recordingRWT) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	err := rrwt.delegate.WriteNamespaces(ctx, newConfigs...)
+	rrwt.record(ctx, RecordedOperation{Method: "WriteNamespaces", Namespaces: newConfigs}, err)
+	return err
+}
+
+func (rrwt *recordingRWT) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	err := rrwt.delegate.DeleteNamespaces(ctx, delOption, nsNames...)
+	rrwt.record(ctx, RecordedOperation{Method: "DeleteNamespaces", Names: nsNames, DeleteOption: delOption}, err)
+	return err
+}
+
+func (rrwt *recordingRWT) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	err := rrwt.delegate.WriteCaveats(ctx, caveats)
+	rrwt.record(ctx, RecordedOperation{Method: "WriteCaveats", Caveats: caveats}, err)
+	return err
+}
+
+func (rrwt *recordingRWT) DeleteCaveats(ctx context.Context, names []string) error {
+	err := rrwt.delegate.DeleteCaveats(ctx, names)
+	rrwt.record(ctx, RecordedOperation{Method: "DeleteCaveats", Names: names}, err)
+	return err
+}
+
+// objectID returns the object ID as it is recorded.
+func (rd *recordingDatastore) objectID(objectID string) string {
+	if !rd.hashObjectIDs || objectID == tuple.PublicWildcard || objectID == "" {
+		return objectID
+	}
+
+	hashed := sha256.Sum256([]byte(objectID))
+	return hex.EncodeToString(hashed[:16])
+}
+
+func (rd *recordingDatastore) objectIDs(objectIDs []string) []string {
+	if !rd.hashObjectIDs || objectIDs == nil {
+		return objectIDs
+	}
+
+	hashed := make([]string, 0, len(objectIDs))
+	for _, objectID := range objectIDs {
+		hashed = append(hashed, rd.objectID(objectID))
+	}
+	return hashed
+}
+
+func (rd *recordingDatastore) onr(onr *core.ObjectAndRelation) *core.ObjectAndRelation {
+	if !rd.hashObjectIDs || onr == nil {
+		return onr
+	}
+
+	cloned := onr.CloneVT()
+	cloned.ObjectId = rd.objectID(onr.ObjectId)
+	return cloned
+}
+
+func (rd *recordingDatastore) subjects(subjects ...*core.ObjectAndRelation) []*core.ObjectAndRelation {
+	recorded := make([]*core.ObjectAndRelation, 0, len(subjects))
+	for _, subject := range subjects {
+		recorded = append(recorded, rd.onr(subject))
+	}
+	return recorded
+}
+
+func (rd *recordingDatastore) updates(updates []*core.RelationTupleUpdate) []*core.RelationTupleUpdate {
+	if !rd.hashObjectIDs {
+		return updates
+	}
+
+	recorded := make([]*core.RelationTupleUpdate, 0, len(updates))
+	for _, update := range updates {
+		cloned := update.CloneVT()
+		if cloned.Tuple != nil {
+			cloned.Tuple.ResourceAndRelation = rd.onr(cloned.Tuple.ResourceAndRelation)
+			cloned.Tuple.Subject = rd.onr(cloned.Tuple.Subject)
+		}
+		recorded = append(recorded, cloned)
+	}
+	return recorded
+}
+
+func (rd *recordingDatastore) relationshipsFilter(filter datastore.RelationshipsFilter) *datastore.RelationshipsFilter {
+	filter.OptionalResourceIds = rd.objectIDs(filter.OptionalResourceIds)
+	filter.OptionalResourceIDPrefix = rd.objectID(filter.OptionalResourceIDPrefix)
+	filter.OptionalSubjectsFilter = rd.subjectsFilter(filter.OptionalSubjectsFilter)
+	return &filter
+}
+
+func (rd *recordingDatastore) subjectsFilter(filter *datastore.SubjectsFilter) *datastore.SubjectsFilter {
+	if filter == nil {
+		return nil
+	}
+
+	recorded := *filter
+	recorded.OptionalSubjectIds = rd.objectIDs(filter.OptionalSubjectIds)
+	return &recorded
+}
+
+func (rd *recordingDatastore) deleteFilter(filter *v1.RelationshipFilter) *v1.RelationshipFilter {
+	if !rd.hashObjectIDs || filter == nil {
+		return filter
+	}
+
+	recorded := filter.CloneVT()
+	recorded.OptionalResourceId = rd.objectID(filter.OptionalResourceId)
+	if recorded.OptionalSubjectFilter != nil {
+		recorded.OptionalSubjectFilter.OptionalSubjectId = rd.objectID(filter.OptionalSubjectFilter.OptionalSubjectId)
+	}
+	return recorded
+}
+
+func (rd *recordingDatastore) queryOptions(queryOpts *options.QueryOptions) *options.QueryOptions {
+	queryOpts.Usersets = rd.subjects(queryOpts.Usersets...)
+	return queryOpts
+}
+
+var (
+	_ datastore.Datastore                     = &recordingDatastore{}
+	_ datastore.PoolStatsReporter             = &recordingDatastore{}
+	_ datastore.RelationshipHistoryReader     = &recordingDatastore{}
+	_ datastore.ConsistencyValidator          = &recordingDatastore{}
+	_ datastore.Reader                        = &recordingReader{}
+	_ datastore.RelationshipExistenceChecker  = &recordingReader{}
+	_ datastore.NamespaceRelationshipsChecker = &recordingReader{}
+	_ datastore.RelationshipRevisionsReader   = &recordingReader{}
+	_ datastore.ReadWriteTransaction          = &recordingRWT{}
+	_ datastore.RelationshipUpdateReporter    = &recordingRWT{}
+)
+
+// ReplayOperations re-issues recorded operations, in order, against the given datastore.
+//
+// Reads are made at the head revision of the datastore, and the relationships they return are
+// read in full. The operations of each recorded transaction are applied within a single
+// read-write transaction, when its ReadWriteTx operation is reached; transactions which did not
+// commit are not replayed. Operations which depend on the revisions of the recorded datastore,
+// such as Watch, CheckRevision and DiffRevisions, and Close, are skipped.
+//
+// An error is returned for the first operation which fails when it did not fail as recorded.
+func ReplayOperations(ctx context.Context, ds datastore.Datastore, operations []RecordedOperation) error {
+	pending := make(map[uint64][]RecordedOperation)
+	for index, op := range operations {
+		if op.Transaction != 0 && op.Method != "ReadWriteTx" {
+			pending[op.Transaction] = append(pending[op.Transaction], op)
+			continue
+		}
+
+		err := replayOperation(ctx, ds, op, pending)
+		if err != nil && op.Error == "" {
+			return fmt.Errorf("unable to replay operation %d (%s): %w", index, op.Method, err)
+		}
+	}
+	return nil
+}
+
+func replayOperation(ctx context.Context, ds datastore.Datastore, op RecordedOperation, pending map[uint64][]RecordedOperation) error {
+	switch op.Method {
+	case "ReadWriteTx":
+		txOps := pending[op.Transaction]
+		delete(pending, op.Transaction)
+		if op.Error != "" {
+			return nil
+		}
+
+		var opts []options.RWTOptionsOption
+		if op.RWTOptions != nil {
+			opts = append(opts, op.RWTOptions.ToOption())
+		}
+
+		_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			for _, txOp := range txOps {
+				if err := replayTransactionOperation(ctx, rwt, txOp); err != nil && txOp.Error == "" {
+					return fmt.Errorf("unable to replay %s: %w", txOp.Method, err)
+				}
+			}
+			return nil
+		}, opts...)
+		return err
+
+	case "OptimizedRevision":
+		_, err := ds.OptimizedRevision(ctx)
+		return err
+
+	case "HeadRevision":
+		_, err := ds.HeadRevision(ctx)
+		return err
+
+	case "RevisionAtTime":
+		if op.At == nil {
+			return nil
+		}
+		_, err := ds.RevisionAtTime(ctx, *op.At)
+		return err
+
+	case "IsReady":
+		_, err := ds.IsReady(ctx)
+		return err
+
+	case "HealthCheck":
+		ds.HealthCheck(ctx)
+		return nil
+
+	case "Features":
+		_, err := ds.Features(ctx)
+		return err
+
+	case "Statistics":
+		_, err := ds.Statistics(ctx)
+		return err
+
+	case "CheckRevision", "RevisionFromString", "Watch", "DiffRevisions", "Close":
+		return nil
+
+	default:
+		revision, err := ds.HeadRevision(ctx)
+		if err != nil {
+			return err
+		}
+		return replayReadOperation(ctx, ds.SnapshotReader(revision), op)
+	}
+}
+
+func replayTransactionOperation(ctx context.Context, rwt datastore.ReadWriteTransaction, op RecordedOperation) error {
+	switch op.Method {
+	case "WriteRelationships":
+		return rwt.WriteRelationships(ctx, op.Updates)
+
+	case "DeleteRelationships":
+		return rwt.DeleteRelationships(ctx, op.DeleteFilter)
+
+	case "DeleteRelationshipsForSubject":
+		if len(op.Subjects) != 1 {
+			return fmt.Errorf("expected a single subject, found %d", len(op.Subjects))
+		}
+		_, err := rwt.DeleteRelationshipsForSubject(ctx, op.Subjects[0])
+		return err
+
+	case "RewriteRelationshipsSubject":
+		if len(op.Subjects) != 2 {
+			return fmt.Errorf("expected an old and a new subject, found %d subjects", len(op.Subjects))
+		}
+		_, err := rwt.RewriteRelationshipsSubject(ctx, op.Subjects[0], op.Subjects[1])
+		return err
+
+	case "WriteNamespaces":
+		return rwt.WriteNamespaces(ctx, op.Namespaces...)
+
+	case "DeleteNamespaces":
+		return rwt.DeleteNamespaces(ctx, op.DeleteOption, op.Names...)
+
+	case "WriteCaveats":
+		return rwt.WriteCaveats(ctx, op.Caveats)
+
+	case "DeleteCaveats":
+		return rwt.DeleteCaveats(ctx, op.Names)
+
+	default:
+		return replayReadOperation(ctx, rwt, op)
+	}
+}
+
+func replayReadOperation(ctx context.Context, reader datastore.Reader, op RecordedOperation) error {
+	switch op.Method {
+	case "ReadCaveatByName":
+		if len(op.Names) != 1 {
+			return fmt.Errorf("expected a single caveat name, found %d", len(op.Names))
+		}
+		_, _, err := reader.ReadCaveatByName(ctx, op.Names[0])
+		return err
+
+	case "ListCaveats":
+		_, err := reader.ListCaveats(ctx, op.Names...)
+		return err
+
+	case "QueryRelationships":
+		if op.RelationshipsFilter == nil {
+			return fmt.Errorf("missing relationships filter")
+		}
+
+		var opts []options.QueryOptionsOption
+		if op.QueryOptions != nil {
+			opts = append(opts, op.QueryOptions.ToOption())
+		}
+		return drainIterator(reader.QueryRelationships(ctx, *op.RelationshipsFilter, opts...))
+
+	case "QueryRelationshipsWithRevisions":
+		if op.RelationshipsFilter == nil {
+			return fmt.Errorf("missing relationships filter")
+		}
+
+		var opts []options.QueryOptionsOption
+		if op.QueryOptions != nil {
+			opts = append(opts, op.QueryOptions.ToOption())
+		}
+		_, err := datastore.QueryRelationshipsWithRevisions(ctx, reader, *op.RelationshipsFilter, opts...)
+		return err
+
+	case "QueryRelationshipsForResourceTypes":
+		var opts []options.QueryOptionsOption
+		if op.QueryOptions != nil {
+			opts = append(opts, op.QueryOptions.ToOption())
+		}
+		return drainIterator(reader.QueryRelationshipsForResourceTypes(ctx, op.Names, opts...))
+
+	case "ReverseQueryRelationships":
+		if op.SubjectsFilter == nil {
+			return fmt.Errorf("missing subjects filter")
+		}
+
+		var opts []options.ReverseQueryOptionsOption
+		if op.ReverseQueryOptions != nil {
+			opts = append(opts, op.ReverseQueryOptions.ToOption())
+		}
+		return drainIterator(reader.ReverseQueryRelationships(ctx, *op.SubjectsFilter, opts...))
+
+	case "ReadNamespace":
+		if len(op.Names) != 1 {
+			return fmt.Errorf("expected a single namespace name, found %d", len(op.Names))
+		}
+		_, _, err := reader.ReadNamespace(ctx, op.Names[0])
+		return err
+
+	case "ListNamespaces":
+		var opts []options.ListNamespacesOptionsOption
+		if op.ListNamespacesOptions != nil {
+			opts = append(opts, op.ListNamespacesOptions.ToOption())
+		}
+		_, err := reader.ListNamespaces(ctx, opts...)
+		return err
+
+	case "LookupNamespaces":
+		_, err := reader.LookupNamespaces(ctx, op.Names)
+		return err
+
+	case "RelationshipExists":
+		if len(op.Subjects) != 2 {
+			return fmt.Errorf("expected a resource and a subject, found %d subjects", len(op.Subjects))
+		}
+		_, err := datastore.RelationshipExists(ctx, reader, &core.RelationTuple{
+			ResourceAndRelation: op.Subjects[0],
+			Subject:             op.Subjects[1],
+		})
+		return err
+
+	default:
+		return fmt.Errorf("unknown recorded operation %s", op.Method)
+	}
+}
+
+func drainIterator(it datastore.RelationshipIterator, err error) error {
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for {
+		if it.Next() == nil {
+			return it.Err()
+		}
+	}
+}
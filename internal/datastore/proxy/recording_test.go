@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func recordSession(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:recorded#viewer@user:tom"),
+		tuple.Parse("document:recorded#editor@user:sarah"),
+		tuple.Parse("document:public#viewer@user:*"),
+	)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tuple.Parse("document:recorded#editor@user:sarah"))
+	require.NoError(err)
+
+	revision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:        "document",
+		OptionalResourceIds: []string{"recorded"},
+	})
+	require.NoError(err)
+	iter.Close()
+
+	_, _, err = ds.SnapshotReader(revision).ReadNamespace(ctx, "unknown")
+	require.Error(err)
+}
+
+func readDocumentRelationships(t *testing.T, ds datastore.Datastore) []string {
+	ctx := context.Background()
+	revision, err := ds.HeadRevision(ctx)
+	require.NoError(t, err)
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.MustString(tpl))
+	}
+	require.NoError(t, iter.Err())
+	return found
+}
+
+func TestRecordingAndReplay(t *testing.T) {
+	require := require.New(t)
+
+	sink := NewMemoryOperationSink()
+	ds := NewRecordingDatastore(newMirroringTestDatastore(t), sink)
+	recordSession(t, ds)
+
+	operations := sink.Operations()
+	methods := make([]string, 0, len(operations))
+	for _, op := range operations {
+		methods = append(methods, op.Method)
+	}
+	require.Equal([]string{
+		"WriteRelationships", "ReadWriteTx",
+		"WriteRelationships", "ReadWriteTx",
+		"HeadRevision",
+		"QueryRelationships",
+		"ReadNamespace",
+	}, methods)
+
+	require.Equal(operations[0].Transaction, operations[1].Transaction)
+	require.NotEqual(operations[0].Transaction, operations[2].Transaction)
+	require.Len(operations[0].Updates, 3)
+	require.NotEmpty(operations[1].Revision)
+	require.Equal([]string{"recorded"}, operations[5].RelationshipsFilter.OptionalResourceIds)
+	require.Empty(operations[5].Error)
+	require.NotEmpty(operations[6].Error)
+
+	target := newMirroringTestDatastore(t)
+	require.NoError(ReplayOperations(context.Background(), target, operations))
+	require.ElementsMatch(readDocumentRelationships(t, ds), readDocumentRelationships(t, target))
+}
+
+func TestRecordingToFile(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	sink, err := NewFileOperationSink(path)
+	require.NoError(err)
+
+	ds := NewRecordingDatastore(newMirroringTestDatastore(t), sink)
+	recordSession(t, ds)
+
+	// Namespace definitions are encoded as well.
+	_, err = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		nsDef, _, err := rwt.ReadNamespace(context.Background(), "document")
+		if err != nil {
+			return err
+		}
+		return rwt.WriteNamespaces(context.Background(), nsDef)
+	})
+	require.NoError(err)
+	require.NoError(sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(err)
+	defer file.Close()
+
+	operations, err := ReadRecordedOperations(file)
+	require.NoError(err)
+	require.Len(operations, 10)
+	require.NotEmpty(operations[8].Namespaces[0].Relation)
+
+	target := newMirroringTestDatastore(t)
+	require.NoError(ReplayOperations(context.Background(), target, operations))
+	require.ElementsMatch(readDocumentRelationships(t, ds), readDocumentRelationships(t, target))
+}
+
+func TestRecordingWithHashedObjectIDs(t *testing.T) {
+	require := require.New(t)
+
+	sink := NewMemoryOperationSink()
+	ds := NewRecordingDatastore(newMirroringTestDatastore(t), sink, WithHashedObjectIDs())
+	recordSession(t, ds)
+
+	operations := sink.Operations()
+	for _, op := range operations {
+		encoded, err := op.MarshalJSON()
+		require.NoError(err)
+		for _, objectID := range []string{"recorded", "tom", "sarah"} {
+			require.False(strings.Contains(string(encoded), objectID), "found %s in %s", objectID, encoded)
+		}
+	}
+
+	// Wildcards are kept, and the same IDs are hashed the same way.
+	require.Equal(tuple.PublicWildcard, operations[0].Updates[2].Tuple.Subject.ObjectId)
+	require.Equal(operations[0].Updates[1].Tuple.Subject.ObjectId, operations[2].Updates[0].Tuple.Subject.ObjectId)
+	require.Equal(operations[0].Updates[0].Tuple.ResourceAndRelation.ObjectId, operations[5].RelationshipsFilter.OptionalResourceIds[0])
+
+	target := newMirroringTestDatastore(t)
+	require.NoError(ReplayOperations(context.Background(), target, operations))
+	require.Len(readDocumentRelationships(t, target), len(readDocumentRelationships(t, ds)))
+}
+
+func TestRecordingForwardsCapabilities(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	capable := newCapabilityDatastore(t)
+	sink := NewMemoryOperationSink()
+	ds := NewRecordingDatastore(capable, sink)
+
+	tpl := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		results, err := datastore.WriteRelationshipsWithResults(ctx, rwt, []*core.RelationTupleUpdate{tuple.Create(tpl)})
+		require.NoError(err)
+		require.Equal([]datastore.RelationshipUpdateResult{datastore.RelationshipCreated}, results)
+
+		exists, err := datastore.RelationshipExists(ctx, rwt, tpl)
+		require.NoError(err)
+		require.True(exists)
+		return nil
+	})
+	require.NoError(err)
+	require.Equal(1, capable.calls.count("WriteRelationshipsWithResults"))
+	require.Equal(1, capable.calls.count("RelationshipExists"))
+
+	operations := sink.Operations()
+	methods := make([]string, 0, len(operations))
+	for _, op := range operations {
+		methods = append(methods, op.Method)
+	}
+	require.Equal([]string{"WriteRelationships", "RelationshipExists", "ReadWriteTx"}, methods)
+
+	// The recorded calls are replayed as the calls they were recorded as.
+	replayed := newCapabilityDatastore(t)
+	require.NoError(ReplayOperations(ctx, replayed, operations))
+	require.Equal(1, replayed.calls.count("RelationshipExists"))
+	require.ElementsMatch(readDocumentRelationships(t, ds), readDocumentRelationships(t, replayed))
+}
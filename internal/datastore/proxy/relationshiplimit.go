@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"context"
+	"sort"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type relationshipLimitDatastore struct {
+	datastore.Datastore
+	limits map[string]uint64
+}
+
+// NewRelationshipLimitDatastore creates a proxy which rejects any write that would push the
+// number of live relationships of a namespace over the limit configured for it, returning an
+// ErrRelationshipLimitExceeded. Namespaces without a configured limit are unlimited.
+//
+// Relationships are counted by the namespace of their resource. Deleted relationships do not
+// count towards the limit, and a write is counted by the net number of relationships it creates.
+// Checking a write reads up to the limit's worth of the live relationships of the namespace, so
+// limits are intended as a safeguard for development and shared environments.
+func NewRelationshipLimitDatastore(delegate datastore.Datastore, limits map[string]uint64) datastore.Datastore {
+	return relationshipLimitDatastore{Datastore: delegate, limits: limits}
+}
+
+func (rld relationshipLimitDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	return rld.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&limitingTransaction{ReadWriteTransaction: rwt, limits: rld.limits})
+	}, opts...)
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (rld relationshipLimitDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(rld.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (rld relationshipLimitDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, rld.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (rld relationshipLimitDatastore) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, rld.Datastore)
+}
+
+type limitingTransaction struct {
+	datastore.ReadWriteTransaction
+	limits map[string]uint64
+}
+
+func (lt *limitingTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := lt.ensureWithinLimits(ctx, mutations); err != nil {
+		return err
+	}
+
+	return lt.ReadWriteTransaction.WriteRelationships(ctx, mutations)
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by applying the
+// limits and forwarding to the delegate transaction.
+func (lt *limitingTransaction) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	if err := lt.ensureWithinLimits(ctx, mutations); err != nil {
+		return nil, err
+	}
+
+	return datastore.WriteRelationshipsWithResults(ctx, lt.ReadWriteTransaction, mutations)
+}
+
+// ensureWithinLimits returns an error if writing the mutations would take any limited namespace
+// beyond its limit.
+func (lt *limitingTransaction) ensureWithinLimits(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	// Compute the number of relationships each limited namespace would gain from the write. The
+	// mutations of each relationship are applied in order, so that a relationship mutated more
+	// than once within the write, such as by duplicate TOUCHes, is counted by its net change.
+	type relationshipChange struct {
+		nsName  string
+		initial bool
+		final   bool
+	}
+
+	changes := make(map[string]*relationshipChange)
+	keys := make([]string, 0, len(mutations))
+	for _, mutation := range mutations {
+		nsName := mutation.Tuple.ResourceAndRelation.Namespace
+		if _, ok := lt.limits[nsName]; !ok {
+			continue
+		}
+
+		key := tuple.StringWithoutCaveat(mutation.Tuple)
+		change, ok := changes[key]
+		if !ok {
+			change = &relationshipChange{nsName: nsName}
+
+			// A CREATE of a relationship which already exists fails the write, so only the
+			// existence of relationships first mutated by a TOUCH or DELETE is checked.
+			if mutation.Operation != core.RelationTupleUpdate_CREATE {
+				exists, err := datastore.RelationshipExists(ctx, lt.ReadWriteTransaction, mutation.Tuple)
+				if err != nil {
+					return err
+				}
+				change.initial = exists
+			}
+
+			change.final = change.initial
+			changes[key] = change
+			keys = append(keys, key)
+		}
+
+		change.final = mutation.Operation != core.RelationTupleUpdate_DELETE
+	}
+
+	added := make(map[string]int64)
+	for _, key := range keys {
+		change := changes[key]
+		if change.final && !change.initial {
+			added[change.nsName]++
+		} else if !change.final && change.initial {
+			added[change.nsName]--
+		}
+	}
+
+	nsNames := make([]string, 0, len(added))
+	for nsName := range added {
+		nsNames = append(nsNames, nsName)
+	}
+	sort.Strings(nsNames)
+
+	for _, nsName := range nsNames {
+		if added[nsName] <= 0 {
+			continue
+		}
+
+		limit := lt.limits[nsName]
+		if uint64(added[nsName]) > limit {
+			return datastore.NewRelationshipLimitExceededErr(nsName, limit)
+		}
+
+		// Only whether the live relationships exceed the remaining room matters, so at most one
+		// more than that is read.
+		remaining := limit - uint64(added[nsName])
+		exceeded, err := lt.hasMoreRelationshipsThan(ctx, nsName, remaining)
+		if err != nil {
+			return err
+		}
+
+		if exceeded {
+			return datastore.NewRelationshipLimitExceededErr(nsName, limit)
+		}
+	}
+
+	return nil
+}
+
+// hasMoreRelationshipsThan returns whether the namespace has more than the given number of live
+// relationships, reading no more than one relationship beyond it.
+func (lt *limitingTransaction) hasMoreRelationshipsThan(ctx context.Context, nsName string, count uint64) (bool, error) {
+	limit := count + 1
+	iter, err := lt.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: nsName}, options.WithLimit(&limit))
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+
+	var found uint64
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found++
+	}
+	if iter.Err() != nil {
+		return false, iter.Err()
+	}
+
+	return found > count, nil
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate transaction.
+func (lt *limitingTransaction) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, lt.ReadWriteTransaction, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate transaction.
+func (lt *limitingTransaction) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, lt.ReadWriteTransaction, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by forwarding
+// to the delegate transaction.
+func (lt *limitingTransaction) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, lt.ReadWriteTransaction, filter, opts...)
+}
+
+var (
+	_ datastore.Datastore                     = (*relationshipLimitDatastore)(nil)
+	_ datastore.PoolStatsReporter             = (*relationshipLimitDatastore)(nil)
+	_ datastore.RelationshipHistoryReader     = (*relationshipLimitDatastore)(nil)
+	_ datastore.ConsistencyValidator          = (*relationshipLimitDatastore)(nil)
+	_ datastore.ReadWriteTransaction          = (*limitingTransaction)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*limitingTransaction)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*limitingTransaction)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*limitingTransaction)(nil)
+	_ datastore.RelationshipUpdateReporter    = (*limitingTransaction)(nil)
+)
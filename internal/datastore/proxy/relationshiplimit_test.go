@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// newLimitedTestDatastore returns the standard datastore with a limit placed on the `document`
+// namespace that allows the given number of additional relationships.
+func newLimitedTestDatastore(t *testing.T, additional uint64) datastore.Datastore {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require.New(t))
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType: "document",
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var existing uint64
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		existing++
+	}
+	require.NoError(t, iter.Err())
+
+	return NewRelationshipLimitDatastore(ds, map[string]uint64{"document": existing + additional})
+}
+
+func TestRelationshipLimitFillToCap(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := newLimitedTestDatastore(t, 2)
+
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:limited#viewer@user:first"),
+		tuple.Parse("document:limited#viewer@user:second"),
+	)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("document:limited#viewer@user:third"))
+	require.ErrorAs(err, &datastore.ErrRelationshipLimitExceeded{})
+
+	var limitErr datastore.ErrRelationshipLimitExceeded
+	require.ErrorAs(err, &limitErr)
+	require.Equal("document", limitErr.NamespaceName())
+
+	// Touching an existing relationship does not add to the count.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, tuple.Parse("document:limited#viewer@user:first"))
+	require.NoError(err)
+
+	// Other namespaces are unlimited.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("folder:limited#viewer@user:third"))
+	require.NoError(err)
+}
+
+func TestRelationshipLimitSingleWriteOverCap(t *testing.T) {
+	ds := newLimitedTestDatastore(t, 1)
+
+	_, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:limited#viewer@user:first"),
+		tuple.Parse("document:limited#viewer@user:second"),
+	)
+	require.ErrorAs(t, err, &datastore.ErrRelationshipLimitExceeded{})
+}
+
+func TestRelationshipLimitDeletedDoNotCount(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := newLimitedTestDatastore(t, 1)
+
+	first := tuple.Parse("document:limited#viewer@user:first")
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, first)
+	require.NoError(err)
+
+	second := tuple.Parse("document:limited#viewer@user:second")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, second)
+	require.ErrorAs(err, &datastore.ErrRelationshipLimitExceeded{})
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, first)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, second)
+	require.NoError(err)
+
+	// Replacing a relationship within a single write stays within the limit.
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Delete(second),
+			tuple.Create(first),
+		})
+	})
+	require.NoError(err)
+}
+
+func TestRelationshipLimitDuplicateUpdatesCountOnce(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// The standard datastore validates that updates are not duplicated, so the limit is placed
+	// directly upon an empty datastore.
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds := NewRelationshipLimitDatastore(rawDS, map[string]uint64{"document": 1})
+
+	// Duplicate TOUCHes of a single relationship within a write add it once.
+	first := tuple.Parse("document:limited#viewer@user:first")
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Touch(first),
+			tuple.Touch(first),
+		})
+	})
+	require.NoError(err)
+
+	// The limit has been reached, so another relationship cannot be added, even alongside
+	// duplicate TOUCHes of an existing one.
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Touch(first),
+			tuple.Touch(first),
+			tuple.Touch(tuple.Parse("document:limited#viewer@user:second")),
+		})
+	})
+	require.ErrorAs(err, &datastore.ErrRelationshipLimitExceeded{})
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/relationships"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+type relationshipTypeCheckingDatastore struct {
+	datastore.Datastore
+}
+
+// NewRelationshipTypeCheckingDatastore creates a proxy which rejects any write containing a
+// relationship whose subject is not allowed by the schema on its relation, returning an
+// ErrInvalidSubjectTypes which lists every violating relationship.
+//
+// The API already validates the relationships it writes; this proxy extends the check to every
+// write made through the datastore, including those which do not pass through the API.
+func NewRelationshipTypeCheckingDatastore(delegate datastore.Datastore) datastore.Datastore {
+	return relationshipTypeCheckingDatastore{Datastore: delegate}
+}
+
+func (rtd relationshipTypeCheckingDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	return rtd.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&typeCheckingTransaction{ReadWriteTransaction: rwt})
+	}, opts...)
+}
+
+// PoolStats implements datastore.PoolStatsReporter by forwarding to the delegate datastore.
+func (rtd relationshipTypeCheckingDatastore) PoolStats() datastore.PoolStats {
+	stats, _ := datastore.PoolStatsOf(rtd.Datastore)
+	return stats
+}
+
+// RelationshipHistory implements datastore.RelationshipHistoryReader by forwarding to the
+// delegate datastore.
+func (rtd relationshipTypeCheckingDatastore) RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]datastore.RelationshipVersion, error) {
+	return datastore.RelationshipHistory(ctx, rtd.Datastore, tpl)
+}
+
+// ValidateConsistency implements datastore.ConsistencyValidator by forwarding to the delegate
+// datastore.
+func (rtd relationshipTypeCheckingDatastore) ValidateConsistency(ctx context.Context) error {
+	return datastore.ValidateConsistency(ctx, rtd.Datastore)
+}
+
+type typeCheckingTransaction struct {
+	datastore.ReadWriteTransaction
+}
+
+func (tct *typeCheckingTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := relationships.ValidateRelationshipSubjectTypes(ctx, tct.ReadWriteTransaction, mutations); err != nil {
+		return err
+	}
+
+	return tct.ReadWriteTransaction.WriteRelationships(ctx, mutations)
+}
+
+// WriteRelationshipsWithResults implements datastore.RelationshipUpdateReporter by checking the
+// subject types and forwarding to the delegate transaction.
+func (tct *typeCheckingTransaction) WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, error) {
+	if err := relationships.ValidateRelationshipSubjectTypes(ctx, tct.ReadWriteTransaction, mutations); err != nil {
+		return nil, err
+	}
+
+	return datastore.WriteRelationshipsWithResults(ctx, tct.ReadWriteTransaction, mutations)
+}
+
+// RelationshipExists implements datastore.RelationshipExistenceChecker by forwarding to
+// the delegate transaction.
+func (tct *typeCheckingTransaction) RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error) {
+	return datastore.RelationshipExists(ctx, tct.ReadWriteTransaction, tpl)
+}
+
+// NamespaceHasRelationships implements datastore.NamespaceRelationshipsChecker by forwarding to
+// the delegate transaction.
+func (tct *typeCheckingTransaction) NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error) {
+	return datastore.NamespaceHasRelationships(ctx, tct.ReadWriteTransaction, nsName)
+}
+
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader by forwarding
+// to the delegate transaction.
+func (tct *typeCheckingTransaction) QueryRelationshipsWithRevisions(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) ([]datastore.RelationshipVersion, error) {
+	return datastore.QueryRelationshipsWithRevisions(ctx, tct.ReadWriteTransaction, filter, opts...)
+}
+
+var (
+	_ datastore.Datastore                     = (*relationshipTypeCheckingDatastore)(nil)
+	_ datastore.PoolStatsReporter             = (*relationshipTypeCheckingDatastore)(nil)
+	_ datastore.RelationshipHistoryReader     = (*relationshipTypeCheckingDatastore)(nil)
+	_ datastore.ConsistencyValidator          = (*relationshipTypeCheckingDatastore)(nil)
+	_ datastore.ReadWriteTransaction          = (*typeCheckingTransaction)(nil)
+	_ datastore.RelationshipExistenceChecker  = (*typeCheckingTransaction)(nil)
+	_ datastore.NamespaceRelationshipsChecker = (*typeCheckingTransaction)(nil)
+	_ datastore.RelationshipRevisionsReader   = (*typeCheckingTransaction)(nil)
+	_ datastore.RelationshipUpdateReporter    = (*typeCheckingTransaction)(nil)
+)
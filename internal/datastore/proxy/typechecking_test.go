@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/relationships"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const typeCheckingSchema = `
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition user {}
+
+	definition group {
+		relation member: user
+	}
+
+	definition document {
+		relation viewer: user | user:* | group#member
+		relation editor: user with somecaveat
+		permission view = viewer + editor
+	}
+`
+
+func newTypeCheckingTestDatastore(t *testing.T) datastore.Datastore {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, typeCheckingSchema, nil, require.New(t))
+	return NewRelationshipTypeCheckingDatastore(ds)
+}
+
+func TestRelationshipTypeCheckingAllowed(t *testing.T) {
+	ds := newTypeCheckingTestDatastore(t)
+
+	_, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:first#viewer@user:tom"),
+		tuple.Parse("document:first#viewer@user:*"),
+		tuple.Parse("document:first#viewer@group:eng#member"),
+		tuple.Parse("document:first#editor@user:sarah[somecaveat]"),
+	)
+	require.NoError(t, err)
+}
+
+func TestRelationshipTypeCheckingRejected(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := newTypeCheckingTestDatastore(t)
+
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		tuple.Parse("document:first#viewer@user:tom"),
+		tuple.Parse("document:first#viewer@group:*"),
+		tuple.Parse("document:first#editor@user:sarah"),
+		tuple.Parse("document:first#viewer@group:eng#..."),
+	)
+
+	var typesErr relationships.ErrInvalidSubjectTypes
+	require.ErrorAs(err, &typesErr)
+	require.Len(typesErr.Violations(), 3)
+	require.Equal(codes.InvalidArgument, status.Code(err))
+	require.ErrorContains(err, "subjects of type `group:*` are not allowed on relation `document#viewer`")
+	require.ErrorContains(err, "subjects of type `user` are not allowed on relation `document#editor`")
+	require.ErrorContains(err, "subjects of type `group` are not allowed on relation `document#viewer`")
+
+	// Nothing from the rejected write was stored.
+	headRevision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	iter, err := ds.SnapshotReader(headRevision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: "document",
+	})
+	require.NoError(err)
+	defer iter.Close()
+	require.Nil(iter.Next())
+	require.NoError(iter.Err())
+}
+
+func TestRelationshipTypeCheckingDeletesUnchecked(t *testing.T) {
+	ds := newTypeCheckingTestDatastore(t)
+
+	// Deletes are not checked, so that relationships written before the schema changed can be
+	// removed.
+	_, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_DELETE,
+		tuple.Parse("document:first#viewer@group:*"),
+	)
+	require.NoError(t, err)
+}
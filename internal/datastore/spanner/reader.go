@@ -41,6 +41,74 @@ func (sr spannerReader) QueryRelationships(
 	return sr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
+// QueryRelationshipsWithRevisions implements datastore.RelationshipRevisionsReader. Every write
+// of a row, including a touch, sets its timestamp to the commit timestamp of the writing
+// transaction, which is the revision of that transaction. Deleted rows are not retained, so
+// DeletedAt is always NoRevision.
+func (sr spannerReader) QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]datastore.RelationshipVersion, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(schema, queryTuplesWithRevisions).
+		FilterWithRelationshipsFilter(filter).
+		QueryBuilder(opts...).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	var versions []datastore.RelationshipVersion
+	if err := sr.txSource().Query(ctx, statementFromSQL(sql, args)).Do(func(row *spanner.Row) error {
+		tpl := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+		var caveatName spanner.NullString
+		var caveatCtx spanner.NullJSON
+		var timestamp time.Time
+		err := row.Columns(
+			&tpl.ResourceAndRelation.Namespace,
+			&tpl.ResourceAndRelation.ObjectId,
+			&tpl.ResourceAndRelation.Relation,
+			&tpl.Subject.Namespace,
+			&tpl.Subject.ObjectId,
+			&tpl.Subject.Relation,
+			&caveatName,
+			&caveatCtx,
+			&timestamp,
+		)
+		if err != nil {
+			return err
+		}
+
+		tpl.Caveat, err = ContextualizedCaveatFrom(caveatName, caveatCtx)
+		if err != nil {
+			return err
+		}
+
+		versions = append(versions, datastore.RelationshipVersion{
+			Relationship: tpl,
+			CreatedAt:    revisionFromTimestamp(timestamp),
+			DeletedAt:    datastore.NoRevision,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf(errUnableToReadRevisions, err)
+	}
+
+	return versions, nil
+}
+
+func (sr spannerReader) QueryRelationshipsForResourceTypes(
+	ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).FilterToResourceTypes(resourceTypes)
+	return sr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
+}
+
 func (sr spannerReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -142,7 +210,7 @@ func (sr spannerReader) ReadNamespace(ctx context.Context, nsName string) (*core
 	return ns, revisionFromTimestamp(updated), nil
 }
 
-func (sr spannerReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (sr spannerReader) ListNamespaces(ctx context.Context, opts ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error) {
 	iter := sr.txSource().Read(
 		ctx,
 		tableNamespace,
@@ -155,7 +223,7 @@ func (sr spannerReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDe
 		return nil, fmt.Errorf(errUnableToListNamespaces, err)
 	}
 
-	return allNamespaces, nil
+	return options.NewListNamespacesOptionsWithOptions(opts...).Apply(allNamespaces), nil
 }
 
 func (sr spannerReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -217,6 +285,18 @@ var queryTuples = sql.Select(
 	colCaveatContext,
 ).From(tableRelationship)
 
+var queryTuplesWithRevisions = sql.Select(
+	colNamespace,
+	colObjectID,
+	colRelation,
+	colUsersetNamespace,
+	colUsersetObjectID,
+	colUsersetRelation,
+	colCaveatName,
+	colCaveatContext,
+	colTimestamp,
+).From(tableRelationship)
+
 var schema = common.SchemaInformation{
 	ColNamespace:        colNamespace,
 	ColObjectID:         colObjectID,
@@ -227,4 +307,7 @@ var schema = common.SchemaInformation{
 	ColCaveatName:       colCaveatName,
 }
 
-var _ datastore.Reader = spannerReader{}
+var (
+	_ datastore.Reader                      = spannerReader{}
+	_ datastore.RelationshipRevisionsReader = spannerReader{}
+)
@@ -11,6 +11,7 @@ import (
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -22,12 +23,18 @@ type spannerReadWriteTXN struct {
 }
 
 func (rwt spannerReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	mutations = datastore.CanonicalizeSubjectRelations(mutations)
+
 	changeUUID := uuid.New().String()
 
 	var rowCountChange int64
 
 	for _, mutation := range mutations {
 		var txnMut *spanner.Mutation
+		if mutation.Operation != core.RelationTupleUpdate_DELETE && mutation.Tuple.ExpiresAt != nil {
+			return datastore.NewRelationshipExpirationUnsupportedErr(Engine)
+		}
+
 		var op int
 		switch mutation.Operation {
 		case core.RelationTupleUpdate_TOUCH:
@@ -71,6 +78,14 @@ func (rwt spannerReadWriteTXN) DeleteRelationships(ctx context.Context, filter *
 	return nil
 }
 
+func (rwt spannerReadWriteTXN) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	return common.DeleteRelationshipsForSubject(ctx, rwt, subject)
+}
+
+func (rwt spannerReadWriteTXN) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	return common.RewriteRelationshipsSubject(ctx, rwt, oldSubject, newSubject)
+}
+
 type selectAndDelete struct {
 	sel sq.SelectBuilder
 	del sq.DeleteBuilder
@@ -105,6 +120,10 @@ func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, fi
 		}
 	}
 
+	return deleteWithQueries(ctx, rwt, queries)
+}
+
+func deleteWithQueries(ctx context.Context, rwt *spanner.ReadWriteTransaction, queries selectAndDelete) error {
 	ssql, sargs, err := queries.sel.ToSql()
 	if err != nil {
 		return err
@@ -238,7 +257,13 @@ func (rwt spannerReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs .
 	return rwt.spannerRWT.BufferWrite(mutations)
 }
 
-func (rwt spannerReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+func (rwt spannerReadWriteTXN) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	if delOption == datastore.DeleteNamespacesOnlyIfEmpty {
+		if err := common.EnsureNoRelationshipsForNamespaces(ctx, rwt, nsNames...); err != nil {
+			return err
+		}
+	}
+
 	for _, nsName := range nsNames {
 		if err := deleteWithFilter(ctx, rwt.spannerRWT, &v1.RelationshipFilter{
 			ResourceType: nsName,
@@ -246,6 +271,12 @@ func (rwt spannerReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...
 			return fmt.Errorf(errUnableToDeleteConfig, err)
 		}
 
+		subjectQueries := selectAndDelete{queryTuples, sql.Delete(tableRelationship)}.
+			Where(sq.Eq{colUsersetNamespace: nsName})
+		if err := deleteWithQueries(ctx, rwt.spannerRWT, subjectQueries); err != nil {
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+
 		err := rwt.spannerRWT.BufferWrite([]*spanner.Mutation{
 			spanner.Delete(tableNamespace, spanner.KeySetFromKeys(spanner.Key{nsName})),
 		})
@@ -15,6 +15,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/datastore/spanner/migrations"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -35,6 +36,7 @@ const (
 
 	errUnableToWriteRelationships  = "unable to write relationships: %w"
 	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToReadRevisions       = "unable to read relationship revisions: %w"
 
 	errUnableToWriteConfig    = "unable to write namespace config: %w"
 	errUnableToReadConfig     = "unable to read namespace config: %w"
@@ -137,7 +139,12 @@ func (sd spannerDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 func (sd spannerDatastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	if options.NewRWTOptionsWithOptions(opts...).IdempotencyKey != "" {
+		return datastore.NoRevision, datastore.NewIdempotencyKeysUnsupportedErr(Engine)
+	}
+
 	ts, err := sd.client.ReadWriteTransaction(ctx, func(ctx context.Context, spannerRWT *spanner.ReadWriteTransaction) error {
 		txSource := func() readTX {
 			return spannerRWT
@@ -180,6 +187,17 @@ func (sd spannerDatastore) IsReady(ctx context.Context) (bool, error) {
 	return version == headMigration, nil
 }
 
+func (sd spannerDatastore) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	iter := sd.client.Single().Query(ctx, spanner.Statement{SQL: "SELECT 1"})
+	defer iter.Stop()
+
+	if _, err := iter.Next(); err != nil {
+		return datastore.Unreachable(err)
+	}
+
+	return datastore.Healthy()
+}
+
 func (sd spannerDatastore) Features(ctx context.Context) (*datastore.Features, error) {
 	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
 }
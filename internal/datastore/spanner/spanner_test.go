@@ -16,12 +16,19 @@ import (
 
 func TestSpannerDatastore(t *testing.T) {
 	b := testdatastore.RunSpannerForTesting(t, "")
-	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+	tester := test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
 		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
 			ds, err := NewSpannerDatastore(uri, RevisionQuantization(revisionQuantization), GCWindow(gcWindow), WatchBufferLength(watchBufferLength))
 			require.NoError(t, err)
 			return ds
 		})
 		return ds, nil
-	}))
+	})
+	test.All(t, tester)
+
+	// Rows are deleted outright, so a relationship read at a past revision has no deleting
+	// revision.
+	t.Run("RelationshipRevisionsDeletedAt", func(t *testing.T) {
+		test.RelationshipRevisionsDeletedAtTest(t, tester, false)
+	})
 }
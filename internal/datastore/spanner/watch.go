@@ -34,8 +34,9 @@ func (sd spannerDatastore) Watch(ctx context.Context, afterRevisionRaw datastore
 
 		for {
 			var stagedUpdates []datastore.RevisionChanges
+			var readTimestamp time.Time
 			var err error
-			stagedUpdates, currentTxn, err = sd.loadChanges(ctx, currentTxn)
+			stagedUpdates, currentTxn, readTimestamp, err = sd.loadChanges(ctx, currentTxn)
 			if err != nil {
 				if errors.Is(ctx.Err(), context.Canceled) {
 					errs <- datastore.NewWatchCanceledErr()
@@ -57,8 +58,13 @@ func (sd spannerDatastore) Watch(ctx context.Context, afterRevisionRaw datastore
 				}
 			}
 
-			// If there were no changes, sleep a bit
+			// If there were no changes, emit a checkpoint at the timestamp of the read, as any
+			// later change commits after it, and sleep a bit
 			if len(stagedUpdates) == 0 {
+				if readTimestamp.After(currentTxn) {
+					common.SendCheckpoint(updates, revisionFromTimestamp(readTimestamp))
+				}
+
 				sleep := time.NewTimer(watchSleep)
 
 				select {
@@ -75,16 +81,19 @@ func (sd spannerDatastore) Watch(ctx context.Context, afterRevisionRaw datastore
 	return updates, errs
 }
 
+// loadChanges returns the changes following the after timestamp, the timestamp of the latest of
+// them, and the timestamp at which they were read.
 func (sd spannerDatastore) loadChanges(
 	ctx context.Context,
 	afterTimestamp time.Time,
-) ([]datastore.RevisionChanges, time.Time, error) {
+) ([]datastore.RevisionChanges, time.Time, time.Time, error) {
 	sql, args, err := queryChanged.Where(sq.Gt{colChangeTS: afterTimestamp}).ToSql()
 	if err != nil {
-		return nil, afterTimestamp, err
+		return nil, afterTimestamp, time.Time{}, err
 	}
 
-	rows := sd.client.Single().Query(ctx, statementFromSQL(sql, args))
+	txn := sd.client.Single()
+	rows := txn.Query(ctx, statementFromSQL(sql, args))
 	stagedChanges := common.NewChanges(revision.DecimalKeyFunc)
 
 	newTimestamp := afterTimestamp
@@ -127,12 +136,17 @@ func (sd spannerDatastore) loadChanges(
 		return nil
 	})
 	if err != nil {
-		return nil, afterTimestamp, err
+		return nil, afterTimestamp, time.Time{}, err
+	}
+
+	readTimestamp, err := txn.Timestamp()
+	if err != nil {
+		return nil, afterTimestamp, time.Time{}, err
 	}
 
 	changes := stagedChanges.AsRevisionChanges(revision.DecimalKeyLessThanFunc)
 
-	return changes, newTimestamp, nil
+	return changes, newTimestamp, readTimestamp, nil
 }
 
 func maxTime(t1 time.Time, t2 time.Time) time.Time {
@@ -141,3 +155,8 @@ func maxTime(t1 time.Time, t2 time.Time) time.Time {
 	}
 	return t2
 }
+
+// DiffRevisions is not yet supported by the Spanner datastore.
+func (sd spannerDatastore) DiffRevisions(_ context.Context, _, _ datastore.Revision) (*datastore.RevisionDiff, error) {
+	return nil, datastore.NewRevisionDiffUnsupportedErr(Engine)
+}
@@ -0,0 +1,110 @@
+package developmentmembership
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// Serialize returns a deterministic byte representation of the set, including the caveat
+// expression, excluded subjects and relationships of each subject. Sets containing the same
+// subjects always serialize to the same bytes, which makes the serialized form suitable for
+// caching and for comparing the results of expansions.
+func (tss *TrackingSubjectSet) Serialize() ([]byte, error) {
+	keys := make([]string, 0, len(tss.setByType))
+	for key := range tss.setByType {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	serialized := &devinterface.SerializedSubjectSet{}
+	for _, key := range keys {
+		subjects := tss.setByType[key].AsSlice()
+		sort.Slice(subjects, func(i, j int) bool {
+			return subjects[i].subject.ObjectId < subjects[j].subject.ObjectId
+		})
+
+		for _, subject := range subjects {
+			serialized.Subjects = append(serialized.Subjects, serializeFoundSubject(subject))
+		}
+	}
+
+	return proto.MarshalOptions{Deterministic: true}.Marshal(serialized)
+}
+
+func serializeFoundSubject(fs FoundSubject) *devinterface.SerializedSubject {
+	serialized := &devinterface.SerializedSubject{
+		Subject:          fs.subject,
+		CaveatExpression: fs.caveatExpression,
+	}
+
+	excludedSubjects := make([]FoundSubject, len(fs.excludedSubjects))
+	copy(excludedSubjects, fs.excludedSubjects)
+	sort.Slice(excludedSubjects, func(i, j int) bool {
+		return excludedSubjects[i].subject.ObjectId < excludedSubjects[j].subject.ObjectId
+	})
+	for _, excludedSubject := range excludedSubjects {
+		serialized.ExcludedSubjects = append(serialized.ExcludedSubjects, serializeFoundSubject(excludedSubject))
+	}
+
+	if fs.relationships != nil {
+		relationships := fs.relationships.AsSlice()
+		sort.Slice(relationships, func(i, j int) bool {
+			return tuple.StringONR(relationships[i]) < tuple.StringONR(relationships[j])
+		})
+		serialized.Relationships = relationships
+	}
+
+	return serialized
+}
+
+// DeserializeTrackingSubjectSet reconstructs a set from the bytes returned by Serialize.
+func DeserializeTrackingSubjectSet(data []byte) (*TrackingSubjectSet, error) {
+	serialized := &devinterface.SerializedSubjectSet{}
+	if err := proto.Unmarshal(data, serialized); err != nil {
+		return nil, fmt.Errorf("could not deserialize subject set: %w", err)
+	}
+
+	tss := NewTrackingSubjectSet()
+	for _, subject := range serialized.Subjects {
+		fs, err := deserializeFoundSubject(subject)
+		if err != nil {
+			return nil, err
+		}
+
+		// The subjects are added exactly as they were found, as unioning them would rewrite the
+		// exclusions of any wildcard.
+		tss.getSet(fs).UnsafeAddExact(fs)
+	}
+
+	return tss, nil
+}
+
+func deserializeFoundSubject(serialized *devinterface.SerializedSubject) (FoundSubject, error) {
+	if serialized.Subject == nil {
+		return FoundSubject{}, fmt.Errorf("could not deserialize subject set: missing subject")
+	}
+
+	fs := FoundSubject{
+		subject:          serialized.Subject,
+		caveatExpression: serialized.CaveatExpression,
+		relationships:    tuple.NewONRSet(serialized.Relationships...),
+	}
+
+	if len(serialized.ExcludedSubjects) > 0 {
+		fs.excludedSubjects = make([]FoundSubject, 0, len(serialized.ExcludedSubjects))
+		for _, excludedSubject := range serialized.ExcludedSubjects {
+			excluded, err := deserializeFoundSubject(excludedSubject)
+			if err != nil {
+				return FoundSubject{}, err
+			}
+			fs.excludedSubjects = append(fs.excludedSubjects, excluded)
+		}
+	}
+
+	return fs, nil
+}
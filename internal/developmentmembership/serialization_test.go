@@ -0,0 +1,157 @@
+package developmentmembership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/testutil"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestSerializationRoundTrip(t *testing.T) {
+	caveatedExclusion := cfs("user", "*", "...", nil, "somecaveat")
+	caveatedExclusion.excludedSubjects = []FoundSubject{
+		cfs("user", "tom", "...", nil, "anothercaveat"),
+		cfs("user", "fred", "...", nil, ""),
+	}
+
+	testCases := []struct {
+		name string
+		set  *TrackingSubjectSet
+	}{
+		{
+			"empty set",
+			NewTrackingSubjectSet(),
+		},
+		{
+			"concrete subjects",
+			set(
+				DS("user", "user2", "..."),
+				DS("user", "user1", "..."),
+				DS("team", "admins", "member"),
+			),
+		},
+		{
+			"wildcard with exclusions",
+			NewTrackingSubjectSet(
+				fs("user", "*", "...", "user3", "user1"),
+				fs("user", "user2", "..."),
+			),
+		},
+		{
+			"caveated subjects",
+			set(
+				CaveatedDS("user", "user1", "...", "somecaveat"),
+				DS("user", "user2", "..."),
+			),
+		},
+		{
+			"caveated wildcard with caveated exclusions",
+			NewTrackingSubjectSet(caveatedExclusion),
+		},
+		{
+			"with relationships",
+			NewTrackingSubjectSet(
+				NewFoundSubject(
+					CaveatedDS("user", "user1", "...", "somecaveat"),
+					ONR("document", "second", "viewer"),
+					ONR("document", "first", "viewer"),
+				),
+			),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			serialized, err := tc.set.Serialize()
+			require.NoError(err)
+
+			deserialized, err := DeserializeTrackingSubjectSet(serialized)
+			require.NoError(err)
+
+			reserialized, err := deserialized.Serialize()
+			require.NoError(err)
+			require.Equal(serialized, reserialized)
+
+			expected := tc.set.ToSlice()
+			found := deserialized.ToSlice()
+			require.Equal(len(expected), len(found))
+
+			for _, expectedSubject := range expected {
+				foundSubject, ok := deserialized.Get(expectedSubject.Subject())
+				require.True(ok, "missing subject %s", tuple.StringONR(expectedSubject.Subject()))
+				requireFoundSubjectEqual(t, expectedSubject, foundSubject)
+			}
+		})
+	}
+}
+
+func requireFoundSubjectEqual(t *testing.T, expected FoundSubject, found FoundSubject) {
+	testutil.RequireProtoEqual(t, expected.subject, found.subject, "mismatch in subject")
+	testutil.RequireProtoEqual(t, expected.caveatExpression, found.caveatExpression, "mismatch in caveat expression")
+	require.ElementsMatch(t, relationshipStrings(expected), relationshipStrings(found))
+
+	require.Equal(t, len(expected.excludedSubjects), len(found.excludedSubjects))
+	for _, expectedExcluded := range expected.excludedSubjects {
+		matched := false
+		for _, foundExcluded := range found.excludedSubjects {
+			if expectedExcluded.subject.ObjectId == foundExcluded.subject.ObjectId {
+				requireFoundSubjectEqual(t, expectedExcluded, foundExcluded)
+				matched = true
+			}
+		}
+		require.True(t, matched, "missing excluded subject %s", tuple.StringONR(expectedExcluded.subject))
+	}
+}
+
+func relationshipStrings(fs FoundSubject) []string {
+	if fs.relationships == nil {
+		return []string{}
+	}
+
+	strs := make([]string, 0, fs.relationships.Length())
+	for _, onr := range fs.Relationships() {
+		strs = append(strs, tuple.StringONR(onr))
+	}
+	return strs
+}
+
+func TestSerializationDeterministic(t *testing.T) {
+	require := require.New(t)
+
+	first := NewTrackingSubjectSet(
+		NewFoundSubject(CaveatedDS("user", "user1", "...", "somecaveat"), ONR("document", "first", "viewer")),
+		fs("user", "*", "...", "user3", "user2"),
+		NewFoundSubject(DS("team", "admins", "member")),
+	)
+	second := NewTrackingSubjectSet(
+		NewFoundSubject(DS("team", "admins", "member")),
+		fs("user", "*", "...", "user2", "user3"),
+		NewFoundSubject(CaveatedDS("user", "user1", "...", "somecaveat"), ONR("document", "first", "viewer")),
+	)
+
+	firstSerialized, err := first.Serialize()
+	require.NoError(err)
+
+	secondSerialized, err := second.Serialize()
+	require.NoError(err)
+	require.Equal(firstSerialized, secondSerialized)
+
+	// A change to a caveat expression changes the serialized form.
+	changed := NewTrackingSubjectSet(
+		NewFoundSubject(CaveatedDS("user", "user1", "...", "anothercaveat"), ONR("document", "first", "viewer")),
+		fs("user", "*", "...", "user2", "user3"),
+		NewFoundSubject(DS("team", "admins", "member")),
+	)
+	changedSerialized, err := changed.Serialize()
+	require.NoError(err)
+	require.NotEqual(firstSerialized, changedSerialized)
+}
+
+func TestDeserializeInvalid(t *testing.T) {
+	_, err := DeserializeTrackingSubjectSet([]byte("not a subject set"))
+	require.Error(t, err)
+}
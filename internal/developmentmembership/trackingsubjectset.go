@@ -36,6 +36,17 @@ func (tss *TrackingSubjectSet) AddFrom(otherSet *TrackingSubjectSet) {
 	}
 }
 
+// AddFromDetectingConflicts adds the subjects found in the other set to this set, returning
+// those subjects whose combined caveat expression after the union can never be satisfied. Such
+// subjects are dead grants: they are found in the set but can never actually be accessible.
+func (tss *TrackingSubjectSet) AddFromDetectingConflicts(otherSet *TrackingSubjectSet) []FoundSubject {
+	var conflicting []FoundSubject
+	for key, oss := range otherSet.setByType {
+		conflicting = append(conflicting, tss.getSetForKey(key).UnionWithSetDetectingConflicts(oss)...)
+	}
+	return conflicting
+}
+
 // RemoveFrom removes any subjects found in the other set from this set.
 func (tss *TrackingSubjectSet) RemoveFrom(otherSet *TrackingSubjectSet) {
 	for key, oss := range otherSet.setByType {
@@ -106,6 +117,26 @@ func (tss *TrackingSubjectSet) Contains(subject *core.ObjectAndRelation) bool {
 	return ok
 }
 
+// AccessibleSubject returns the given subject as found in the set, either directly or via a
+// wildcard, with the caveat expression under which it is found and every relationship via which
+// it was found.
+func (tss *TrackingSubjectSet) AccessibleSubject(subject *core.ObjectAndRelation) (FoundSubject, bool) {
+	subjectSet := NewTrackingSubjectSet(NewFoundSubject(&core.DirectSubject{Subject: subject}))
+	found, ok := subjectSet.Intersect(tss).Get(subject)
+	if !ok {
+		return FoundSubject{}, false
+	}
+
+	// A subject found via a wildcard without a caveat is returned by the intersection as given,
+	// so the relationships of the wildcard are added here.
+	wildcard, ok := tss.Get(tuple.ObjectAndRelation(subject.Namespace, tuple.PublicWildcard, subject.Relation))
+	if ok && subjectSet.Intersect(NewTrackingSubjectSet(wildcard)).Contains(subject) {
+		found.relationships.UpdateFrom(wildcard.relationships)
+	}
+
+	return found, true
+}
+
 // Exclude returns a new set that contains the items in this set minus those in the other set.
 func (tss *TrackingSubjectSet) Exclude(otherSet *TrackingSubjectSet) *TrackingSubjectSet {
 	newSet := NewTrackingSubjectSet()
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/authzed/spicedb/internal/caveats"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
 	"github.com/authzed/spicedb/pkg/util"
@@ -379,3 +380,77 @@ func TestTrackingSubjectSetResourceTrackingWithWildcard(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, 1, len(found.Relationships()))
 }
+
+func TestTrackingSubjectSetAddFromDetectingConflicts(t *testing.T) {
+	contradiction := func(caveatName string) *core.CaveatExpression {
+		return caveats.Subtract(caveats.CaveatExprForTesting(caveatName), caveats.CaveatExprForTesting(caveatName))
+	}
+
+	tss := NewTrackingSubjectSet(
+		NewFoundSubject(&core.DirectSubject{Subject: ONR("user", "tom", "..."), CaveatExpression: contradiction("first")}),
+		NewFoundSubject(CaveatedDS("user", "sarah", "...", "first")),
+		NewFoundSubject(DS("user", "fred", "...")),
+	)
+
+	other := NewTrackingSubjectSet(
+		NewFoundSubject(&core.DirectSubject{Subject: ONR("user", "tom", "..."), CaveatExpression: contradiction("second")}),
+		NewFoundSubject(&core.DirectSubject{Subject: ONR("user", "sarah", "..."), CaveatExpression: caveats.Invert(caveats.CaveatExprForTesting("first"))}),
+		NewFoundSubject(&core.DirectSubject{Subject: ONR("user", "jill", "..."), CaveatExpression: contradiction("third")}),
+	)
+
+	conflicting := tss.AddFromDetectingConflicts(other)
+
+	conflictingSubjects := make([]string, 0, len(conflicting))
+	for _, fs := range conflicting {
+		conflictingSubjects = append(conflictingSubjects, tuple.StringONR(fs.Subject()))
+	}
+	require.ElementsMatch(t, []string{"user:jill", "user:tom"}, conflictingSubjects)
+
+	// The union itself must be identical to that of AddFrom.
+	for _, subject := range []string{"tom", "sarah", "fred", "jill"} {
+		require.True(t, tss.Contains(ONR("user", subject, "...")), "missing subject %s", subject)
+	}
+}
+
+func TestTrackingSubjectSetAccessibleSubject(t *testing.T) {
+	wildcard := fs("user", "*", "...", "fred")
+	wildcard.relationships.Add(ONR("resource", "foo", "public"))
+
+	tss := NewTrackingSubjectSet(
+		NewFoundSubject(DS("user", "tom", "..."), ONR("resource", "foo", "viewer")),
+		NewFoundSubject(CaveatedDS("user", "sarah", "...", "first"), ONR("resource", "foo", "editor")),
+		wildcard,
+	)
+
+	relationshipStrings := func(found FoundSubject) []string {
+		strs := make([]string, 0, len(found.Relationships()))
+		for _, onr := range found.Relationships() {
+			strs = append(strs, tuple.StringONR(onr))
+		}
+		return strs
+	}
+
+	// Found directly, and via the wildcard.
+	found, ok := tss.AccessibleSubject(ONR("user", "tom", "..."))
+	require.True(t, ok)
+	require.Nil(t, found.GetCaveatExpression())
+	require.ElementsMatch(t, []string{"resource:foo#viewer", "resource:foo#public"}, relationshipStrings(found))
+
+	// Found conditionally, and unconditionally via the wildcard.
+	found, ok = tss.AccessibleSubject(ONR("user", "sarah", "..."))
+	require.True(t, ok)
+	require.ElementsMatch(t, []string{"resource:foo#editor", "resource:foo#public"}, relationshipStrings(found))
+
+	// Found only via the wildcard.
+	found, ok = tss.AccessibleSubject(ONR("user", "jill", "..."))
+	require.True(t, ok)
+	require.Equal(t, []string{"resource:foo#public"}, relationshipStrings(found))
+
+	// Excluded from the wildcard.
+	_, ok = tss.AccessibleSubject(ONR("user", "fred", "..."))
+	require.False(t, ok)
+
+	// Of another type.
+	_, ok = tss.AccessibleSubject(ONR("group", "tom", "member"))
+	require.False(t, ok)
+}
@@ -0,0 +1,109 @@
+// Package admission implements a dispatcher that bounds the number of requests evaluated
+// concurrently, queueing the remainder.
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// Dispatcher is a dispatcher which bounds the number of requests dispatched through it that are
+// evaluated concurrently. Requests beyond the bound are queued until a slot is released, or until
+// their context is done, in which case they fail with an error wrapping the context's error.
+//
+// Only requests made through the Dispatcher itself are counted, so it must only be placed in front
+// of the dispatcher used by the API. If it were also in front of the dispatcher to which
+// subproblems are redispatched, or of the dispatcher serving requests from other nodes, requests
+// holding every slot could wait forever on their subproblems queued behind them.
+type Dispatcher struct {
+	dispatch.Dispatcher
+
+	slots chan struct{}
+}
+
+// NewAdmittingDispatcher creates a new dispatch.Dispatcher which evaluates at most the given
+// number of requests concurrently, dispatching them to the delegate. If the number is zero, the
+// delegate is returned, and every request is dispatched at once.
+func NewAdmittingDispatcher(delegate dispatch.Dispatcher, maxConcurrentRequests uint16) dispatch.Dispatcher {
+	if maxConcurrentRequests == 0 {
+		return delegate
+	}
+
+	return &Dispatcher{
+		Dispatcher: delegate,
+		slots:      make(chan struct{}, maxConcurrentRequests),
+	}
+}
+
+// admit waits until a request can be evaluated, and returns a function which must be called once
+// its evaluation has completed. If the context is done before the request is admitted, an error
+// wrapping the context's error is returned.
+func (d *Dispatcher) admit(ctx context.Context) (func(), error) {
+	select {
+	case d.slots <- struct{}{}:
+		return func() { <-d.slots }, nil
+
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request was not admitted for evaluation: %w", ctx.Err())
+	}
+}
+
+// DispatchCheck implements dispatch.Check interface
+func (d *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	release, err := d.admit(ctx)
+	if err != nil {
+		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, err
+	}
+	defer release()
+
+	return d.Dispatcher.DispatchCheck(ctx, req)
+}
+
+// DispatchExpand implements dispatch.Expand interface
+func (d *Dispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
+	release, err := d.admit(ctx)
+	if err != nil {
+		return &v1.DispatchExpandResponse{Metadata: &v1.ResponseMeta{}}, err
+	}
+	defer release()
+
+	return d.Dispatcher.DispatchExpand(ctx, req)
+}
+
+// DispatchLookup implements dispatch.Lookup interface
+func (d *Dispatcher) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+	release, err := d.admit(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return d.Dispatcher.DispatchLookup(req, stream)
+}
+
+// DispatchReachableResources implements dispatch.ReachableResources interface
+func (d *Dispatcher) DispatchReachableResources(req *v1.DispatchReachableResourcesRequest, stream dispatch.ReachableResourcesStream) error {
+	release, err := d.admit(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return d.Dispatcher.DispatchReachableResources(req, stream)
+}
+
+// DispatchLookupSubjects implements dispatch.LookupSubjects interface
+func (d *Dispatcher) DispatchLookupSubjects(req *v1.DispatchLookupSubjectsRequest, stream dispatch.LookupSubjectsStream) error {
+	release, err := d.admit(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return d.Dispatcher.DispatchLookupSubjects(req, stream)
+}
+
+var _ dispatch.Dispatcher = &Dispatcher{}
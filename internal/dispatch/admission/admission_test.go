@@ -0,0 +1,142 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func newGraphDispatcher(t testing.TB) (context.Context, dispatch.Dispatcher, datastore.Revision) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require.New(t))
+
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	return ctx, graph.NewLocalOnlyDispatcher(10), revision
+}
+
+func checkRequest(subjectID string, revision datastore.Revision) *v1.DispatchCheckRequest {
+	return &v1.DispatchCheckRequest{
+		ResourceRelation: &core.RelationReference{Namespace: "document", Relation: "view"},
+		ResourceIds:      []string{"masterplan"},
+		ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+		Subject:          &core.ObjectAndRelation{Namespace: "user", ObjectId: subjectID, Relation: datastore.Ellipsis},
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+	}
+}
+
+func TestAdmittingDispatcher(t *testing.T) {
+	require := require.New(t)
+
+	ctx, delegate, revision := newGraphDispatcher(t)
+	dispatcher := NewAdmittingDispatcher(delegate, 1)
+
+	// Requests beyond the limit are queued, rather than failed. With a single slot, a check is
+	// only completed if its subproblems, redispatched by the graph dispatcher, are not admitted.
+	g, gCtx := errgroup.WithContext(ctx)
+	for i := 0; i < 20; i++ {
+		g.Go(func() error {
+			resp, err := dispatcher.DispatchCheck(gCtx, checkRequest("auditor", revision))
+			if err != nil {
+				return err
+			}
+			if resp.ResultsByResourceId["masterplan"].GetMembership() != v1.ResourceCheckResult_MEMBER {
+				return fmt.Errorf("expected membership")
+			}
+			return nil
+		})
+	}
+	require.NoError(g.Wait())
+
+	// A request queued past its deadline fails with the deadline exceeded.
+	release, err := dispatcher.(*Dispatcher).admit(ctx)
+	require.NoError(err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = dispatcher.DispatchCheck(timeoutCtx, checkRequest("auditor", revision))
+	require.ErrorIs(err, context.DeadlineExceeded)
+
+	// And is admitted once the slot is released.
+	release()
+	resp, err := dispatcher.DispatchCheck(ctx, checkRequest("auditor", revision))
+	require.NoError(err)
+	require.Equal(v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId["masterplan"].GetMembership())
+}
+
+func TestAdmittingDispatcherUnbounded(t *testing.T) {
+	_, delegate, _ := newGraphDispatcher(t)
+	require.Equal(t, delegate, NewAdmittingDispatcher(delegate, 0))
+}
+
+// BenchmarkCheckFlood runs a flood of concurrent checks and reports the largest number of
+// goroutines seen evaluating them, beyond those of the callers, with and without a bound on
+// concurrent requests.
+func BenchmarkCheckFlood(b *testing.B) {
+	const concurrentChecks = 500
+
+	for _, maxConcurrentRequests := range []uint16{0, 16} {
+		b.Run(fmt.Sprintf("max-concurrent-requests-%d", maxConcurrentRequests), func(b *testing.B) {
+			ctx, delegate, revision := newGraphDispatcher(b)
+			dispatcher := NewAdmittingDispatcher(delegate, maxConcurrentRequests)
+
+			baseline := int64(runtime.NumGoroutine())
+			var maxGoroutines int64
+			stopSampling := make(chan struct{})
+			sampled := make(chan struct{})
+			go func() {
+				defer close(sampled)
+				for {
+					select {
+					case <-stopSampling:
+						return
+					default:
+						if count := int64(runtime.NumGoroutine()); count > maxGoroutines {
+							maxGoroutines = count
+						}
+						runtime.Gosched()
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				g, gCtx := errgroup.WithContext(ctx)
+				for i := 0; i < concurrentChecks; i++ {
+					i := i
+					g.Go(func() error {
+						_, err := dispatcher.DispatchCheck(gCtx, checkRequest(fmt.Sprintf("user-%d", i), revision))
+						return err
+					})
+				}
+				require.NoError(b, g.Wait())
+			}
+			b.StopTimer()
+
+			close(stopSampling)
+			<-sampled
+			b.ReportMetric(float64(maxGoroutines-baseline-concurrentChecks), "max-evaluation-goroutines")
+		})
+	}
+}
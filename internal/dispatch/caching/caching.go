@@ -254,8 +254,8 @@ func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRe
 	}
 	computed, err := cd.d.DispatchCheck(ctx, req)
 
-	// We only want to cache the result if there was no error
-	if err == nil {
+	// We only want to cache the result if there was no error, and if it is complete.
+	if err == nil && !computed.Metadata.GetIncomplete() {
 		adjustedComputed := computed.CloneVT()
 		adjustedComputed.Metadata.CachedDispatchCount = adjustedComputed.Metadata.DispatchCount
 		adjustedComputed.Metadata.DispatchCount = 0
@@ -280,49 +280,81 @@ func (cd *Dispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpand
 	return resp, err
 }
 
-// DispatchLookup implements dispatch.Lookup interface and does not do any caching yet.
-func (cd *Dispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+// DispatchLookup implements dispatch.Lookup interface.
+func (cd *Dispatcher) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
 	cd.lookupTotalCounter.Inc()
 
-	requestKey, err := cd.keyHandler.LookupResourcesCacheKey(ctx, req)
+	requestKey, err := cd.keyHandler.LookupResourcesCacheKey(stream.Context(), req)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
+		return err
 	}
 
 	if cachedResultRaw, found := cd.c.Get(requestKey); found {
-		var response v1.DispatchLookupResponse
-		if err := response.UnmarshalVT(cachedResultRaw.([]byte)); err != nil {
-			return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
+		cachedResults := cachedResultRaw.([][]byte)
+		responses := make([]*v1.DispatchLookupResponse, 0, len(cachedResults))
+		var depthRequired uint32
+		for _, slice := range cachedResults {
+			var response v1.DispatchLookupResponse
+			if err := response.UnmarshalVT(slice); err != nil {
+				return fmt.Errorf("could not publish cached lookup result: %w", err)
+			}
+			if response.Metadata.DepthRequired > depthRequired {
+				depthRequired = response.Metadata.DepthRequired
+			}
+			responses = append(responses, &response)
 		}
 
-		if req.Metadata.DepthRemaining >= response.Metadata.DepthRequired {
-			log.Trace().Object("cachedLookup", req).Int("resultCount", len(response.ResolvedResources)).Send()
+		if req.Metadata.DepthRemaining >= depthRequired {
+			log.Trace().Object("cachedLookup", req).Int("responseCount", len(responses)).Send()
 			cd.lookupFromCacheCounter.Inc()
-			return &response, nil
+			for _, response := range responses {
+				if err := stream.Publish(response); err != nil {
+					return fmt.Errorf("could not publish cached lookup result: %w", err)
+				}
+			}
+			return nil
 		}
 	}
-	computed, err := cd.d.DispatchLookup(ctx, req)
 
-	// We only want to cache the result if there was no error.
-	if err == nil {
-		log.Trace().Object("cachingLookup", req).Int("resultCount", len(computed.ResolvedResources)).Send()
+	var (
+		mu             sync.Mutex
+		toCacheResults [][]byte
+	)
+	wrapped := &dispatch.WrappedDispatchStream[*v1.DispatchLookupResponse]{
+		Stream: stream,
+		Ctx:    stream.Context(),
+		Processor: func(result *v1.DispatchLookupResponse) (*v1.DispatchLookupResponse, bool, error) {
+			adjustedResult := result.CloneVT()
+			adjustedResult.Metadata.CachedDispatchCount = adjustedResult.Metadata.DispatchCount
+			adjustedResult.Metadata.DispatchCount = 0
+			adjustedResult.Metadata.DebugInfo = nil
 
-		adjustedComputed := computed.CloneVT()
-		adjustedComputed.Metadata.CachedDispatchCount = adjustedComputed.Metadata.DispatchCount
-		adjustedComputed.Metadata.DispatchCount = 0
-		adjustedComputed.Metadata.DebugInfo = nil
+			adjustedBytes, err := adjustedResult.MarshalVT()
+			if err != nil {
+				return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, false, err
+			}
 
-		adjustedBytes, err := adjustedComputed.MarshalVT()
-		if err != nil {
-			return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
-		}
+			mu.Lock()
+			toCacheResults = append(toCacheResults, adjustedBytes)
+			mu.Unlock()
 
-		cd.c.Set(requestKey, adjustedBytes, sliceSize(adjustedBytes))
+			return result, true, nil
+		},
 	}
 
-	// Return both the computed and err in ALL cases: computed contains resolved
-	// metadata even if there was an error.
-	return computed, err
+	if err := cd.d.DispatchLookup(req, wrapped); err != nil {
+		return err
+	}
+
+	log.Trace().Object("cachingLookup", req).Int("responseCount", len(toCacheResults)).Send()
+
+	var size int64
+	for _, slice := range toCacheResults {
+		size += sliceSize(slice)
+	}
+
+	cd.c.Set(requestKey, toCacheResults, size)
+	return nil
 }
 
 // DispatchReachableResources implements dispatch.ReachableResources interface.
@@ -145,6 +145,43 @@ func TestMaxDepthCaching(t *testing.T) {
 	}
 }
 
+func TestIncompleteResultsNotCached(t *testing.T) {
+	require := require.New(t)
+
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR("document", "read"),
+		ResourceIds:      []string{"doc1"},
+		Subject:          tuple.ParseSubjectONR("user:user1"),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+
+	delegate := delegateDispatchMock{&mock.Mock{}}
+	delegate.On("DispatchCheck", req).Return(&v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			"doc1": {Membership: v1.ResourceCheckResult_MEMBER},
+		},
+		Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1, Incomplete: true},
+	}, nil).Times(2)
+
+	cachingDispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+	require.NoError(err)
+	cachingDispatch.SetDelegate(delegate)
+	defer cachingDispatch.Close()
+
+	// Both checks are dispatched, as the result of the first was marked incomplete.
+	for i := 0; i < 2; i++ {
+		_, err := cachingDispatch.DispatchCheck(context.Background(), req)
+		require.NoError(err)
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	delegate.AssertExpectations(t)
+}
+
 type delegateDispatchMock struct {
 	*mock.Mock
 }
@@ -158,8 +195,8 @@ func (ddm delegateDispatchMock) DispatchExpand(ctx context.Context, req *v1.Disp
 	return &v1.DispatchExpandResponse{}, nil
 }
 
-func (ddm delegateDispatchMock) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
-	return &v1.DispatchLookupResponse{}, nil
+func (ddm delegateDispatchMock) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+	return nil
 }
 
 func (ddm delegateDispatchMock) DispatchReachableResources(req *v1.DispatchReachableResourcesRequest, stream dispatch.ReachableResourcesStream) error {
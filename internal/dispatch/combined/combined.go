@@ -4,6 +4,7 @@ package combined
 
 import (
 	"os"
+	"time"
 
 	"github.com/authzed/grpcutil"
 	"google.golang.org/grpc"
@@ -13,6 +14,7 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch/caching"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
+	"github.com/authzed/spicedb/internal/dispatch/materializing"
 	"github.com/authzed/spicedb/internal/dispatch/remote"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/cache"
@@ -30,6 +32,9 @@ type optionState struct {
 	grpcDialOpts        []grpc.DialOption
 	cache               cache.Cache
 	concurrencyLimits   graph.ConcurrencyLimits
+
+	materializedRelations    []string
+	materializedRelationsTTL time.Duration
 }
 
 // PrometheusSubsystem sets the subsystem name for the prometheus metrics
@@ -84,6 +89,16 @@ func ConcurrencyLimits(limits graph.ConcurrencyLimits) Option {
 	}
 }
 
+// MaterializedRelations sets the relations, each of the form `namespace#relation`, whose
+// resources are materialized into the cache to answer checks, and the TTL of each materialized
+// resource, which should not exceed the GC window of the datastore.
+func MaterializedRelations(relations []string, ttl time.Duration) Option {
+	return func(state *optionState) {
+		state.materializedRelations = relations
+		state.materializedRelationsTTL = ttl
+	}
+}
+
 // NewDispatcher initializes a Dispatcher that caches and redispatches
 // optionally to the provided upstream.
 func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
@@ -102,7 +117,20 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 		return nil, err
 	}
 
-	redispatch := graph.NewDispatcher(cachingRedispatch, opts.concurrencyLimits)
+	var topDispatch dispatch.Dispatcher = cachingRedispatch
+
+	// Materialized relations are checked before the cache, so that redispatched checks of them
+	// are answered from the materialized resources too.
+	if len(opts.materializedRelations) > 0 {
+		materializingRedispatch, err := materializing.NewMaterializingDispatcher(
+			cachingRedispatch, opts.cache, opts.materializedRelations, opts.materializedRelationsTTL)
+		if err != nil {
+			return nil, err
+		}
+		topDispatch = materializingRedispatch
+	}
+
+	redispatch := graph.NewDispatcher(topDispatch, opts.concurrencyLimits)
 
 	// If an upstream is specified, create a cluster dispatcher.
 	if opts.upstreamAddr != "" {
@@ -129,5 +157,5 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 
 	cachingRedispatch.SetDelegate(redispatch)
 
-	return cachingRedispatch, nil
+	return topDispatch, nil
 }
@@ -300,15 +300,17 @@ func TestCheckDebugging(t *testing.T) {
 	}
 
 	testCases := []struct {
-		namespace      string
-		objectID       string
-		permission     string
-		subject        *core.ObjectAndRelation
-		expectedFrames []expectedFrame
+		namespace         string
+		objectID          string
+		permission        string
+		subject           *core.ObjectAndRelation
+		expectedOperation v1.CheckDebugTrace_RewriteOperation
+		expectedFrames    []expectedFrame
 	}{
 		{
 			"document", "masterplan", "view",
 			ONR("user", "product_manager", graph.Ellipsis),
+			v1.CheckDebugTrace_UNION,
 			[]expectedFrame{
 				{
 					RR("document", "view"),
@@ -327,6 +329,7 @@ func TestCheckDebugging(t *testing.T) {
 		{
 			"document", "masterplan", "view_and_edit",
 			ONR("user", "product_manager", graph.Ellipsis),
+			v1.CheckDebugTrace_INTERSECTION,
 			[]expectedFrame{
 				{
 					RR("document", "view_and_edit"),
@@ -337,6 +340,7 @@ func TestCheckDebugging(t *testing.T) {
 		{
 			"document", "specialplan", "view_and_edit",
 			ONR("user", "multiroleguy", graph.Ellipsis),
+			v1.CheckDebugTrace_INTERSECTION,
 			[]expectedFrame{
 				{
 					RR("document", "view_and_edit"),
@@ -389,6 +393,7 @@ func TestCheckDebugging(t *testing.T) {
 			require.NoError(err)
 			require.NotNil(checkResult.Metadata.DebugInfo)
 			require.NotNil(checkResult.Metadata.DebugInfo.Check)
+			require.Equal(tc.expectedOperation, checkResult.Metadata.DebugInfo.Check.RewriteOperation)
 
 			expectedFrames := util.NewSet[string]()
 			for _, expectedFrame := range tc.expectedFrames {
@@ -403,6 +408,130 @@ func TestCheckDebugging(t *testing.T) {
 	}
 }
 
+func TestCheckDebuggingTimings(t *testing.T) {
+	require := require.New(t)
+
+	ctx, dispatch, revision := newLocalDispatcher(t)
+
+	checkResult, err := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+		ResourceRelation: RR("document", "view"),
+		ResourceIds:      []string{"masterplan"},
+		ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+		Subject:          ONR("user", "product_manager", graph.Ellipsis),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+		Debug: v1.DispatchCheckRequest_ENABLE_BASIC_DEBUGGING,
+	})
+	require.NoError(err)
+	require.NotNil(checkResult.Metadata.DebugInfo)
+
+	var verifyTrace func(trace *v1.CheckDebugTrace) uint64
+	verifyTrace = func(trace *v1.CheckDebugTrace) uint64 {
+		require.NotNil(trace.Duration, "missing duration for %s", trace.Request.ResourceRelation)
+
+		scanned := trace.RelationshipsScanned
+		for _, subTrace := range trace.SubProblems {
+			require.LessOrEqual(subTrace.Duration.AsDuration(), trace.Duration.AsDuration())
+			scanned += verifyTrace(subTrace)
+		}
+		return scanned
+	}
+
+	// The owner of the masterplan is found by reading its relationships.
+	require.Greater(verifyTrace(checkResult.Metadata.DebugInfo.Check), uint64(0))
+}
+
+func TestCheckProof(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	relationships := []*core.RelationTuple{
+		tuple.MustParse("document:direct#viewer@user:tom"),
+		tuple.MustParse("document:viaparent#parent@folder:somefolder"),
+		tuple.MustParse("folder:somefolder#viewer@user:tom"),
+		tuple.MustParse("document:viagroup#viewer@group:eng#member"),
+		tuple.MustParse("group:eng#member@group:backend#member"),
+		tuple.MustParse("group:backend#member@user:tom"),
+		tuple.MustParse("document:both#viewer@user:tom"),
+		tuple.MustParse("document:both#editor@user:tom"),
+		tuple.MustParse("document:notbanned#viewer@user:tom"),
+		tuple.MustParse("document:notbanned#banned@user:sarah"),
+	}
+
+	ctx, dispatcher, revision := newLocalDispatcherWithSchemaAndRels(t, `
+		definition user {}
+
+		definition group {
+			relation member: user | group#member
+		}
+
+		definition folder {
+			relation viewer: user | group#member
+		}
+
+		definition document {
+			relation parent: folder
+			relation viewer: user | group#member
+			relation editor: user
+			relation banned: user
+
+			permission view = viewer + parent->viewer
+			permission view_and_edit = viewer & editor
+			permission view_not_banned = viewer - banned
+		}
+	`, relationships)
+
+	testCases := []struct {
+		resourceID    string
+		permission    string
+		expectedProof []string
+	}{
+		{"direct", "view", []string{"document:direct#viewer@user:tom"}},
+		{"viaparent", "view", []string{"document:viaparent#parent@folder:somefolder", "folder:somefolder#viewer@user:tom"}},
+		{"viagroup", "view", []string{"document:viagroup#viewer@group:eng#member", "group:eng#member@group:backend#member", "group:backend#member@user:tom"}},
+		{"both", "view_and_edit", []string{"document:both#viewer@user:tom", "document:both#editor@user:tom"}},
+		{"notbanned", "view_not_banned", []string{"document:notbanned#viewer@user:tom"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.resourceID+"#"+tc.permission, func(t *testing.T) {
+			require := require.New(t)
+
+			for _, includeProof := range []bool{false, true} {
+				checkResult, err := dispatcher.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+					ResourceRelation: RR("document", tc.permission),
+					ResourceIds:      []string{tc.resourceID},
+					ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+					Subject:          ONR("user", "tom", graph.Ellipsis),
+					Metadata: &v1.ResolverMeta{
+						AtRevision:     revision.String(),
+						DepthRemaining: 50,
+					},
+					IncludeProof: includeProof,
+				})
+				require.NoError(err)
+
+				result := checkResult.ResultsByResourceId[tc.resourceID]
+				require.Equal(v1.ResourceCheckResult_MEMBER, result.GetMembership())
+
+				// Proofs are only returned, and cached, for requests asking for them.
+				if !includeProof {
+					require.Empty(result.Proof)
+					continue
+				}
+
+				foundProof := make([]string, 0, len(result.Proof))
+				for _, relationship := range result.Proof {
+					foundProof = append(foundProof, tuple.MustString(relationship))
+				}
+				require.ElementsMatch(tc.expectedProof, foundProof)
+			}
+		})
+	}
+}
+
 func newLocalDispatcher(t testing.TB) (context.Context, dispatch.Dispatcher, datastore.Revision) {
 	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
 	require.NoError(t, err)
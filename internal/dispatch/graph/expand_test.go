@@ -7,6 +7,7 @@ import (
 	"go/printer"
 	"go/token"
 	"os"
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/developmentmembership"
 	expand "github.com/authzed/spicedb/internal/graph"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/testfixtures"
@@ -294,7 +296,7 @@ func TestMaxDepthExpand(t *testing.T) {
 
 	dispatch := NewLocalOnlyDispatcher(10)
 
-	_, err = dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+	resp, err := dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
 		ResourceAndRelation: ONR("folder", "oops", "view"),
 		Metadata: &v1.ResolverMeta{
 			AtRevision:     revision.String(),
@@ -303,7 +305,158 @@ func TestMaxDepthExpand(t *testing.T) {
 		ExpansionMode: v1.DispatchExpandRequest_SHALLOW,
 	})
 
-	require.Error(err)
+	require.NoError(err)
+	require.True(resp.DepthLimited)
+	require.NotEmpty(depthLimitedNodes(resp.TreeNode))
+}
+
+func TestDepthLimitedExpand(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	rels := []*core.RelationTuple{
+		tuple.MustParse("folder:f0#viewer@user:top"),
+		tuple.MustParse("folder:f9#viewer@user:deep"),
+	}
+	for i := 0; i < 9; i++ {
+		rels = append(rels, tuple.MustParse(fmt.Sprintf("folder:f%d#parent@folder:f%d", i, i+1)))
+	}
+
+	ctx, dispatch, revision := newLocalDispatcherWithSchemaAndRels(t, `
+		definition user {}
+
+		definition folder {
+			relation parent: folder
+			relation viewer: user
+			permission view = viewer + parent->view
+		}
+	`, rels)
+
+	testCases := []struct {
+		name                 string
+		depthRemaining       uint32
+		expectedDepthLimited bool
+		expectedSubjects     []string
+	}{
+		{"chain deeper than the limit", 5, true, []string{"user:top"}},
+		{"chain within the limit", 50, false, []string{"user:deep", "user:top"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			resp, err := dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+				ResourceAndRelation: ONR("folder", "f0", "view"),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: tc.depthRemaining,
+				},
+				ExpansionMode: v1.DispatchExpandRequest_SHALLOW,
+			})
+			require.NoError(err)
+			require.NotNil(resp.TreeNode)
+			require.Equal(tc.expectedDepthLimited, resp.DepthLimited)
+
+			limited := depthLimitedNodes(resp.TreeNode)
+			if !tc.expectedDepthLimited {
+				require.Empty(limited)
+			} else {
+				require.NotEmpty(limited)
+				for _, node := range limited {
+					require.Empty(node.GetLeafNode().GetSubjects())
+					require.Equal("folder", node.Expanded.Namespace)
+				}
+			}
+
+			require.Equal(tc.expectedSubjects, leafSubjects(resp.TreeNode))
+		})
+	}
+}
+
+func TestMaxLeafSubjectsExpand(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	var rels []*core.RelationTuple
+	for i := 0; i < 10; i++ {
+		rels = append(rels, tuple.MustParse(fmt.Sprintf("folder:f%d#viewer@user:u%d", i, i)))
+		rels = append(rels, tuple.MustParse(fmt.Sprintf("folder:f%d#parent@folder:f%d", i, i+1)))
+	}
+
+	ctx, dispatch, revision := newLocalDispatcherWithSchemaAndRels(t, `
+		definition user {}
+
+		definition folder {
+			relation parent: folder
+			relation viewer: user
+			permission view = viewer + parent->view
+		}
+	`, rels)
+
+	testCases := []struct {
+		name            string
+		maxLeafSubjects uint32
+		expectExceeded  bool
+	}{
+		{"no maximum", 0, false},
+		{"maximum at leaf subject count", 10, false},
+		{"maximum below leaf subject count", 3, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			resp, err := dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+				ResourceAndRelation: ONR("folder", "f0", "view"),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+				ExpansionMode:   v1.DispatchExpandRequest_SHALLOW,
+				MaxLeafSubjects: tc.maxLeafSubjects,
+			})
+			if !tc.expectExceeded {
+				require.NoError(err)
+				require.Equal(uint64(10), expand.CountLeafSubjects(resp.TreeNode))
+				return
+			}
+
+			// The expansion stops at the first subtree found with more leaf subjects than the
+			// maximum, rather than expanding the full tree.
+			var limitErr expand.ErrLeafSubjectLimitExceeded
+			require.ErrorAs(err, &limitErr)
+			require.Greater(limitErr.LeafSubjectCount(), uint64(tc.maxLeafSubjects))
+			require.Less(limitErr.LeafSubjectCount(), uint64(10))
+		})
+	}
+}
+
+// depthLimitedNodes returns the nodes of the tree which are marked as depth limited.
+func depthLimitedNodes(node *core.RelationTupleTreeNode) []*core.RelationTupleTreeNode {
+	if node.DepthLimited {
+		return []*core.RelationTupleTreeNode{node}
+	}
+
+	var found []*core.RelationTupleTreeNode
+	for _, child := range node.GetIntermediateNode().GetChildNodes() {
+		found = append(found, depthLimitedNodes(child)...)
+	}
+	return found
+}
+
+// leafSubjects returns the sorted string forms of the subjects found in the leaves of the tree.
+func leafSubjects(node *core.RelationTupleTreeNode) []string {
+	var subjects []string
+	for _, subject := range node.GetLeafNode().GetSubjects() {
+		subjects = append(subjects, tuple.StringONR(subject.Subject))
+	}
+	for _, child := range node.GetIntermediateNode().GetChildNodes() {
+		subjects = append(subjects, leafSubjects(child)...)
+	}
+	sort.Strings(subjects)
+	return subjects
 }
 
 func TestCaveatedExpand(t *testing.T) {
@@ -659,3 +812,63 @@ func TestCaveatedExpand(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandNestedArrows(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	schema := `
+		definition user {}
+
+		definition folder {
+			relation parent: folder
+			relation viewer: user
+			permission view = viewer + parent->view
+		}
+
+		definition document {
+			relation parent: folder
+			relation viewer: user
+			permission view = viewer + parent->view
+		}
+	`
+
+	relationships := []*core.RelationTuple{
+		tuple.MustParse("document:plan#viewer@user:tom"),
+		tuple.MustParse("document:plan#parent@folder:team"),
+		tuple.MustParse("folder:team#viewer@user:fred"),
+		tuple.MustParse("folder:team#parent@folder:department"),
+		tuple.MustParse("folder:department#parent@folder:company"),
+		tuple.MustParse("folder:company#viewer@user:sarah"),
+		tuple.MustParse("folder:unrelated#viewer@user:villain"),
+	}
+
+	for _, expansionMode := range []v1.DispatchExpandRequest_ExpansionMode{
+		v1.DispatchExpandRequest_SHALLOW,
+		v1.DispatchExpandRequest_RECURSIVE,
+	} {
+		expansionMode := expansionMode
+		t.Run(expansionMode.String(), func(t *testing.T) {
+			require := require.New(t)
+
+			ctx, dispatch, revision := newLocalDispatcherWithSchemaAndRels(t, schema, relationships)
+
+			expandResult, err := dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+				ResourceAndRelation: ONR("document", "plan", "view"),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+				ExpansionMode: expansionMode,
+			})
+			require.NoError(err)
+
+			subjects, err := developmentmembership.AccessibleExpansionSubjects(expandResult.TreeNode)
+			require.NoError(err)
+
+			require.True(subjects.Contains(ONR("user", "tom", expand.Ellipsis)))
+			require.True(subjects.Contains(ONR("user", "fred", expand.Ellipsis)))
+			require.True(subjects.Contains(ONR("user", "sarah", expand.Ellipsis)), "missing subject granted via the grandparent folder")
+			require.False(subjects.Contains(ONR("user", "villain", expand.Ellipsis)))
+		})
+	}
+}
@@ -23,12 +23,34 @@ const errDispatch = "error dispatching request: %w"
 
 var tracer = otel.Tracer("spicedb/internal/dispatch/local")
 
+// Attributes attached to the span of each dispatched subproblem, allowing the expensive
+// branches of a deep request to be found by namespace and relation. Object IDs are kept
+// out of these (and the span names) to keep cardinality low.
+var (
+	namespaceKey      = attribute.Key("authzed.com/spicedb/dispatch/namespace")
+	relationKey       = attribute.Key("authzed.com/spicedb/dispatch/relation")
+	depthRemainingKey = attribute.Key("authzed.com/spicedb/dispatch/depthRemaining")
+)
+
 // ConcurrencyLimits defines per-dispatch-type concurrency limits.
 type ConcurrencyLimits struct {
 	Check              uint16
 	LookupResources    uint16
 	ReachableResources uint16
 	LookupSubjects     uint16
+
+	// MaxConcurrentRequests is the maximum number of requests, of any type, dispatched by the API
+	// and evaluated concurrently. Further requests are queued until a slot is released or their
+	// deadline passes. It is not applied by the dispatchers of this package: the server applies it
+	// with an admission dispatcher in front of the dispatcher used by the API, so that subproblems
+	// are never counted. If zero, the number is unbounded; it is not set by WithOverallDefaultLimit.
+	MaxConcurrentRequests uint16
+
+	// MaxReachableResourcesIntermediateResults is the maximum number of relationships loaded by a
+	// single step of a reachable resources walk, such as the members of a very wide relation. A
+	// step loading more fails the request. It is distinct from any limit on the final results. If
+	// zero, the number is unbounded; it is not set by WithOverallDefaultLimit.
+	MaxReachableResourcesIntermediateResults uint32
 }
 
 const defaultConcurrencyLimit = 50
@@ -44,6 +66,8 @@ func (cl ConcurrencyLimits) MarshalZerologObject(e *zerolog.Event) {
 	e.Uint16("lookup-resources", cl.LookupResources)
 	e.Uint16("lookup-subjects", cl.LookupSubjects)
 	e.Uint16("reachable-resources", cl.ReachableResources)
+	e.Uint16("max-concurrent-requests", cl.MaxConcurrentRequests)
+	e.Uint32("max-reachable-resources-intermediate-results", cl.MaxReachableResourcesIntermediateResults)
 }
 
 func limitsOrDefaults(limits ConcurrencyLimits, overallDefaultLimit uint16) ConcurrencyLimits {
@@ -87,7 +111,7 @@ func NewLocalOnlyDispatcherWithLimits(concurrencyLimits ConcurrencyLimits) dispa
 	d.checker = graph.NewConcurrentChecker(d, concurrencyLimits.Check)
 	d.expander = graph.NewConcurrentExpander(d)
 	d.lookupHandler = graph.NewConcurrentLookup(d, d, concurrencyLimits.LookupResources)
-	d.reachableResourcesHandler = graph.NewConcurrentReachableResources(d, concurrencyLimits.ReachableResources)
+	d.reachableResourcesHandler = graph.NewConcurrentReachableResources(d, concurrencyLimits.ReachableResources, concurrencyLimits.MaxReachableResourcesIntermediateResults)
 	d.lookupSubjectsHandler = graph.NewConcurrentLookupSubjects(d, concurrencyLimits.LookupSubjects)
 
 	return d
@@ -101,7 +125,7 @@ func NewDispatcher(redispatcher dispatch.Dispatcher, concurrencyLimits Concurren
 	checker := graph.NewConcurrentChecker(redispatcher, concurrencyLimits.Check)
 	expander := graph.NewConcurrentExpander(redispatcher)
 	lookupHandler := graph.NewConcurrentLookup(redispatcher, redispatcher, concurrencyLimits.LookupResources)
-	reachableResourcesHandler := graph.NewConcurrentReachableResources(redispatcher, concurrencyLimits.ReachableResources)
+	reachableResourcesHandler := graph.NewConcurrentReachableResources(redispatcher, concurrencyLimits.ReachableResources, concurrencyLimits.MaxReachableResourcesIntermediateResults)
 	lookupSubjectsHandler := graph.NewConcurrentLookupSubjects(redispatcher, concurrencyLimits.LookupSubjects)
 
 	return &localDispatcher{
@@ -176,6 +200,9 @@ func (ld *localDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCh
 		attribute.Stringer("resource-type", stringableRelRef{req.ResourceRelation}),
 		attribute.StringSlice("resource-ids", req.ResourceIds),
 		attribute.Stringer("subject", stringableOnr{req.Subject}),
+		namespaceKey.String(req.ResourceRelation.Namespace),
+		relationKey.String(req.ResourceRelation.Relation),
+		depthRemainingKey.Int64(int64(req.Metadata.GetDepthRemaining())),
 	))
 	defer span.End()
 
@@ -233,10 +260,11 @@ func (ld *localDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCh
 					Namespace: req.ResourceRelation.Namespace,
 					Relation:  relation.Name,
 				},
-				ResourceIds: req.ResourceIds,
-				Subject:     req.Subject,
-				Metadata:    req.Metadata,
-				Debug:       req.Debug,
+				ResourceIds:  req.ResourceIds,
+				Subject:      req.Subject,
+				Metadata:     req.Metadata,
+				Debug:        req.Debug,
+				IncludeProof: req.IncludeProof,
 			},
 			Revision: revision,
 		}
@@ -254,6 +282,9 @@ func (ld *localDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCh
 func (ld *localDispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
 	ctx, span := tracer.Start(ctx, "DispatchExpand", trace.WithAttributes(
 		attribute.Stringer("start", stringableOnr{req.ResourceAndRelation}),
+		namespaceKey.String(req.ResourceAndRelation.Namespace),
+		relationKey.String(req.ResourceAndRelation.Relation),
+		depthRemainingKey.Int64(int64(req.Metadata.GetDepthRemaining())),
 	))
 	defer span.End()
 
@@ -283,10 +314,13 @@ func (ld *localDispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchE
 }
 
 // DispatchLookup implements dispatch.Lookup interface
-func (ld *localDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+func (ld *localDispatcher) DispatchLookup(
+	req *v1.DispatchLookupRequest,
+	stream dispatch.LookupStream,
+) error {
 	// TODO(jschorr): Since lookup is now calling reachable resources exclusively, we should
 	// probably move it out of the dispatcher and into computed
-	ctx, span := tracer.Start(ctx, "DispatchLookup", trace.WithAttributes(
+	ctx, span := tracer.Start(stream.Context(), "DispatchLookup", trace.WithAttributes(
 		attribute.Stringer("start", stringableRelRef{req.ObjectRelation}),
 		attribute.Stringer("subject", stringableOnr{req.Subject}),
 		attribute.Int64("limit", int64(req.Limit)),
@@ -294,22 +328,25 @@ func (ld *localDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchL
 	defer span.End()
 
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
 	if req.Limit <= 0 {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata, ResolvedResources: []*v1.ResolvedResource{}}, nil
+		return stream.Publish(&v1.DispatchLookupResponse{Metadata: emptyMetadata, ResolvedResources: []*v1.ResolvedResource{}})
 	}
 
-	return ld.lookupHandler.LookupViaReachability(ctx, graph.ValidatedLookupRequest{
-		DispatchLookupRequest: req,
-		Revision:              revision,
-	})
+	return ld.lookupHandler.LookupViaReachability(
+		graph.ValidatedLookupRequest{
+			DispatchLookupRequest: req,
+			Revision:              revision,
+		},
+		dispatch.StreamWithContext(ctx, stream),
+	)
 }
 
 // DispatchReachableResources implements dispatch.ReachableResources interface
@@ -4,6 +4,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/authzed/spicedb/internal/graph"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 )
 
 func TestConcurrencyLimitsWithOverallDefaultLimit(t *testing.T) {
@@ -45,3 +51,79 @@ func TestSharedConcurrencyLimits(t *testing.T) {
 	require.Equal(t, uint16(42), withDefaults.LookupSubjects)
 	require.Equal(t, uint16(42), withDefaults.ReachableResources)
 }
+
+// recordSpans replaces the tracer of the dispatcher for the duration of the test, returning the
+// recorder of the spans it ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	original := tracer
+	tracer = provider.Tracer("spicedb/internal/dispatch/local")
+	t.Cleanup(func() {
+		tracer = original
+	})
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes()))
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestDispatchSpanAttributes(t *testing.T) {
+	require := require.New(t)
+	recorder := recordSpans(t)
+
+	ctx, dispatch, revision := newLocalDispatcher(t)
+
+	_, err := dispatch.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+		ResourceRelation: RR("document", "view"),
+		ResourceIds:      []string{"masterplan"},
+		ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+		Subject:          ONR("user", "villain", graph.Ellipsis),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+	})
+	require.NoError(err)
+
+	_, err = dispatch.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+		ResourceAndRelation: ONR("document", "masterplan", "view"),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+	})
+	require.NoError(err)
+
+	var foundCheck, foundExpand, foundNested bool
+	for _, span := range recorder.Ended() {
+		if span.Name() != "DispatchCheck" && span.Name() != "DispatchExpand" {
+			continue
+		}
+
+		attrs := spanAttributes(span)
+		if attrs[depthRemainingKey].AsInt64() < 50 {
+			// The dispatched subproblems are annotated with their own relation and the depth
+			// remaining to them.
+			foundNested = true
+			require.Contains([]string{"document", "folder"}, attrs[namespaceKey].AsString())
+			require.NotEmpty(attrs[relationKey].AsString())
+			continue
+		}
+
+		foundCheck = foundCheck || span.Name() == "DispatchCheck"
+		foundExpand = foundExpand || span.Name() == "DispatchExpand"
+		require.Equal("document", attrs[namespaceKey].AsString())
+		require.Equal("view", attrs[relationKey].AsString())
+	}
+
+	require.True(foundCheck)
+	require.True(foundExpand)
+	require.True(foundNested)
+}
@@ -11,6 +11,7 @@ import (
 	"go.uber.org/goleak"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -32,6 +33,23 @@ func resolvedRes(resourceID string) *v1.ResolvedResource {
 	}
 }
 
+// collectLookup performs the lookup, and combines the responses published to its stream.
+func collectLookup(ctx context.Context, d dispatch.Dispatcher, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](ctx)
+	err := d.DispatchLookup(req, stream)
+
+	collected := &v1.DispatchLookupResponse{
+		Metadata:          &v1.ResponseMeta{},
+		ResolvedResources: []*v1.ResolvedResource{},
+	}
+	for _, result := range stream.Results() {
+		collected.ResolvedResources = append(collected.ResolvedResources, result.ResolvedResources...)
+		collected.Truncated = collected.Truncated || result.Truncated
+		dispatch.AddResponseMetadata(collected.Metadata, result.Metadata)
+	}
+	return collected, err
+}
+
 func TestSimpleLookup(t *testing.T) {
 	defer goleak.VerifyNone(t, goleakIgnores...)
 
@@ -110,7 +128,7 @@ func TestSimpleLookup(t *testing.T) {
 			require := require.New(t)
 			ctx, dispatch, revision := newLocalDispatcher(t)
 
-			lookupResult, err := dispatch.DispatchLookup(ctx, &v1.DispatchLookupRequest{
+			lookupResult, err := collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
 				ObjectRelation: tc.start,
 				Subject:        tc.target,
 				Metadata: &v1.ResolverMeta{
@@ -132,7 +150,7 @@ func TestSimpleLookup(t *testing.T) {
 			time.Sleep(10 * time.Millisecond)
 
 			// Run again with the cache available.
-			lookupResult, err = dispatch.DispatchLookup(context.Background(), &v1.DispatchLookupRequest{
+			lookupResult, err = collectLookup(context.Background(), dispatch, &v1.DispatchLookupRequest{
 				ObjectRelation: tc.start,
 				Subject:        tc.target,
 				Metadata: &v1.ResolverMeta{
@@ -164,7 +182,7 @@ func TestMaxDepthLookup(t *testing.T) {
 	ctx := datastoremw.ContextWithHandle(context.Background())
 	require.NoError(datastoremw.SetInContext(ctx, ds))
 
-	_, err = dispatch.DispatchLookup(ctx, &v1.DispatchLookupRequest{
+	_, err = collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
 		ObjectRelation: RR("document", "view"),
 		Subject:        ONR("user", "legal", "..."),
 		Metadata: &v1.ResolverMeta{
@@ -186,3 +204,172 @@ func (a OrderedResolved) Less(i, j int) bool {
 }
 
 func (a OrderedResolved) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+func TestLookupThroughComputedUsersets(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	schema := `
+	definition user {}
+
+	definition group {
+		relation member: user
+	}
+
+	definition document {
+		relation owner: user
+		relation editor: user | group#member
+		relation banned: user
+
+		permission edit = owner + editor
+		permission viewer = edit - banned
+		permission view = viewer
+	}
+	`
+
+	rels := []*core.RelationTuple{
+		tuple.MustParse("document:owned#owner@user:tom"),
+		tuple.MustParse("document:edited#editor@user:tom"),
+		tuple.MustParse("document:bygroup#editor@group:editors#member"),
+		tuple.MustParse("group:editors#member@user:tom"),
+		tuple.MustParse("group:editors#member@user:sarah"),
+		tuple.MustParse("document:banned#owner@user:tom"),
+		tuple.MustParse("document:banned#banned@user:tom"),
+		tuple.MustParse("document:banned#editor@user:sarah"),
+	}
+
+	testCases := []struct {
+		start             *core.RelationReference
+		target            *core.ObjectAndRelation
+		expectedResources []string
+	}{
+		{
+			RR("document", "edit"),
+			ONR("user", "tom", "..."),
+			[]string{"owned", "edited", "bygroup", "banned"},
+		},
+		{
+			RR("document", "viewer"),
+			ONR("user", "tom", "..."),
+			[]string{"owned", "edited", "bygroup"},
+		},
+		{
+			RR("document", "view"),
+			ONR("user", "tom", "..."),
+			[]string{"owned", "edited", "bygroup"},
+		},
+		{
+			RR("document", "view"),
+			ONR("user", "sarah", "..."),
+			[]string{"bygroup", "banned"},
+		},
+		{
+			RR("document", "view"),
+			ONR("user", "unknown", "..."),
+			[]string{},
+		},
+		{
+			RR("document", "banned"),
+			ONR("user", "tom", "..."),
+			[]string{"banned"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		name := fmt.Sprintf(
+			"%s#%s->%s",
+			tc.start.Namespace,
+			tc.start.Relation,
+			tuple.StringONR(tc.target),
+		)
+
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			ctx, dispatch, revision := newLocalDispatcherWithSchemaAndRels(t, schema, rels)
+
+			lookupResult, err := collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
+				ObjectRelation: tc.start,
+				Subject:        tc.target,
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+				Limit: 10,
+			})
+			require.NoError(err)
+
+			expected := make([]*v1.ResolvedResource, 0, len(tc.expectedResources))
+			for _, resourceID := range tc.expectedResources {
+				expected = append(expected, resolvedRes(resourceID))
+			}
+			require.ElementsMatch(expected, lookupResult.ResolvedResources)
+		})
+	}
+}
+
+func TestLookupDeduplicationAndLimit(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	schema := `
+	definition user {}
+
+	definition group {
+		relation member: user
+	}
+
+	definition document {
+		relation viewer: user | group#member
+		permission view = viewer
+	}
+	`
+
+	// Each document is reachable for tom via both a direct grant and a group membership.
+	rels := []*core.RelationTuple{
+		tuple.MustParse("group:editors#member@user:tom"),
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:first#viewer@group:editors#member"),
+		tuple.MustParse("document:second#viewer@user:tom"),
+		tuple.MustParse("document:second#viewer@group:editors#member"),
+		tuple.MustParse("document:third#viewer@user:tom"),
+		tuple.MustParse("document:third#viewer@group:editors#member"),
+	}
+
+	testCases := []struct {
+		limit             uint32
+		expectedCount     int
+		expectedTruncated bool
+	}{
+		{1, 1, true},
+		{2, 2, true},
+		{3, 3, false},
+		{10, 3, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("limit %d", tc.limit), func(t *testing.T) {
+			require := require.New(t)
+			ctx, dispatch, revision := newLocalDispatcherWithSchemaAndRels(t, schema, rels)
+
+			lookupResult, err := collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
+				ObjectRelation: RR("document", "view"),
+				Subject:        ONR("user", "tom", "..."),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+				Limit: tc.limit,
+			})
+			require.NoError(err)
+			require.Equal(tc.expectedTruncated, lookupResult.Truncated)
+			require.Len(lookupResult.ResolvedResources, tc.expectedCount)
+
+			foundIDs := make(map[string]struct{}, len(lookupResult.ResolvedResources))
+			for _, found := range lookupResult.ResolvedResources {
+				require.Contains([]string{"first", "second", "third"}, found.ResourceId)
+				require.NotContains(foundIDs, found.ResourceId, "resource %s was returned more than once", found.ResourceId)
+				foundIDs[found.ResourceId] = struct{}{}
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -200,6 +201,132 @@ func TestMaxDepthreachableResources(t *testing.T) {
 	require.Error(err)
 }
 
+func TestReachableResourcesAcrossChunks(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	// More relationships than fit in the first few dispatch chunks, so that each chunk must
+	// only hold its own relationships for every resource to be found exactly once.
+	var relationships []*core.RelationTuple
+	for index := 0; index < 42; index++ {
+		relationships = append(relationships, tuple.MustParse(fmt.Sprintf("document:doc%d#viewer@user:tom", index)))
+	}
+
+	ctx, dispatcher, revision := newLocalDispatcherWithSchemaAndRels(t, `
+		definition user {}
+
+		definition document {
+			relation viewer: user
+			permission view = viewer
+		}
+	`, relationships)
+
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchReachableResourcesResponse](ctx)
+	err := dispatcher.DispatchReachableResources(&v1.DispatchReachableResourcesRequest{
+		ResourceRelation: RR("document", "view"),
+		SubjectRelation:  RR("user", "..."),
+		SubjectIds:       []string{"tom"},
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+	}, stream)
+	require.NoError(t, err)
+
+	foundCounts := map[string]int{}
+	for _, result := range stream.Results() {
+		for _, resource := range result.Resources {
+			foundCounts[resource.ResourceId]++
+		}
+	}
+
+	require.Len(t, foundCounts, len(relationships))
+	for resourceID, count := range foundCounts {
+		require.Equal(t, 1, count, "resource %s was found more than once", resourceID)
+	}
+}
+
+func TestReachableResourcesIntermediateResultLimit(t *testing.T) {
+	// tom is a member of a pathologically wide number of groups, each of which can view a document.
+	relationships := []*core.RelationTuple{
+		tuple.MustParse("group:narrow#member@user:sarah"),
+		tuple.MustParse("document:narrowdoc#viewer@group:narrow#member"),
+	}
+	for index := 0; index < 500; index++ {
+		relationships = append(relationships,
+			tuple.MustParse(fmt.Sprintf("group:g%d#member@user:tom", index)),
+			tuple.MustParse(fmt.Sprintf("document:doc%d#viewer@group:g%d#member", index, index)),
+		)
+	}
+
+	tcs := []struct {
+		name                   string
+		maxIntermediateResults uint32
+		subjectID              string
+		expectedResourceCount  int
+		expectedErr            bool
+	}{
+		{"unbounded", 0, "tom", 500, false},
+		{"within limit", 500, "tom", 500, false},
+		{"exceeding limit", 100, "tom", 0, true},
+		{"narrow walk within limit", 100, "sarah", 1, false},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			dispatcher := NewLocalOnlyDispatcherWithLimits(ConcurrencyLimits{
+				MaxReachableResourcesIntermediateResults: tc.maxIntermediateResults,
+			})
+
+			ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(ds, `
+				definition user {}
+
+				definition group {
+					relation member: user
+				}
+
+				definition document {
+					relation viewer: group#member
+					permission view = viewer
+				}
+			`, relationships, require)
+
+			ctx := datastoremw.ContextWithHandle(context.Background())
+			require.NoError(datastoremw.SetInContext(ctx, ds))
+
+			stream := dispatch.NewCollectingDispatchStream[*v1.DispatchReachableResourcesResponse](ctx)
+			err = dispatcher.DispatchReachableResources(&v1.DispatchReachableResourcesRequest{
+				ResourceRelation: RR("document", "view"),
+				SubjectRelation:  RR("user", "..."),
+				SubjectIds:       []string{tc.subjectID},
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+				},
+			}, stream)
+			if tc.expectedErr {
+				require.Error(err)
+				require.ErrorAs(err, &graph.ErrIntermediateResultLimitExceeded{})
+				return
+			}
+
+			require.NoError(err)
+
+			foundResourceIDs := make(map[string]struct{})
+			for _, result := range stream.Results() {
+				for _, resource := range result.Resources {
+					foundResourceIDs[resource.ResourceId] = struct{}{}
+				}
+			}
+			require.Len(foundResourceIDs, tc.expectedResourceCount)
+		})
+	}
+}
+
 type byONRAndPermission []reachableResource
 
 func (a byONRAndPermission) Len() int { return len(a) }
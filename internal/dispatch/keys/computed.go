@@ -33,10 +33,12 @@ var cachePrefixes = []cachePrefix{
 // checkRequestToKey converts a check request into a cache key based on the relation
 func checkRequestToKey(req *v1.DispatchCheckRequest, option dispatchCacheKeyHashComputeOption) DispatchCacheKey {
 	return dispatchCacheKeyHash(checkViaRelationPrefix, req.Metadata.AtRevision, option,
-		hashableRelationReference{req.ResourceRelation},
-		hashableIds(req.ResourceIds),
-		hashableOnr{req.Subject},
-		hashableResultSetting(req.ResultsSetting),
+		withProofSetting(req,
+			hashableRelationReference{req.ResourceRelation},
+			hashableIds(req.ResourceIds),
+			hashableOnr{req.Subject},
+			hashableResultSetting(req.ResultsSetting),
+		)...,
 	)
 }
 
@@ -49,20 +51,33 @@ func checkRequestToKeyWithCanonical(req *v1.DispatchCheckRequest, canonicalKey s
 
 	// NOTE: canonical cache keys are only unique *within* a version of a namespace.
 	return dispatchCacheKeyHash(checkViaCanonicalPrefix, req.Metadata.AtRevision, computeBothHashes,
-		hashableString(req.ResourceRelation.Namespace),
-		hashableString(canonicalKey),
-		hashableIds(req.ResourceIds),
-		hashableOnr{req.Subject},
-		hashableResultSetting(req.ResultsSetting),
+		withProofSetting(req,
+			hashableString(req.ResourceRelation.Namespace),
+			hashableString(canonicalKey),
+			hashableIds(req.ResourceIds),
+			hashableOnr{req.Subject},
+			hashableResultSetting(req.ResultsSetting),
+		)...,
 	)
 }
 
+// withProofSetting returns the given values, along with a marker if the check request asks for
+// proofs, as their results cannot be shared with those of requests which do not. The keys of
+// requests without proofs are left unchanged.
+func withProofSetting(req *v1.DispatchCheckRequest, values ...hashableValue) []hashableValue {
+	if req.IncludeProof {
+		return append(values, hashableString("proof"))
+	}
+	return values
+}
+
 // lookupRequestToKey converts a lookup request into a cache key
 func lookupRequestToKey(req *v1.DispatchLookupRequest, option dispatchCacheKeyHashComputeOption) DispatchCacheKey {
 	return dispatchCacheKeyHash(lookupPrefix, req.Metadata.AtRevision, option,
 		hashableRelationReference{req.ObjectRelation},
 		hashableOnr{req.Subject},
 		hashableContext{req.Context}, // NOTE: context is included here because lookup does a single dispatch
+		hashableLimit(req.Limit),     // NOTE: the results of a lookup stop at its limit
 	)
 }
 
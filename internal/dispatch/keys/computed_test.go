@@ -117,7 +117,21 @@ func TestStableCacheKeys(t *testing.T) {
 					},
 				}, computeBothHashes)
 			},
-			"87b8e4dcf893f4abd701",
+			"b9d98ab5ba80e495f701",
+		},
+		{
+			"lookup resources with a different limit",
+			func() DispatchCacheKey {
+				return lookupRequestToKey(&v1.DispatchLookupRequest{
+					ObjectRelation: RR("document", "view"),
+					Subject:        ONR("user", "mariah", "..."),
+					Limit:          20,
+					Metadata: &v1.ResolverMeta{
+						AtRevision: "1234",
+					},
+				}, computeBothHashes)
+			},
+			"a0f6d9f7f5eebfee44",
 		},
 		{
 			"lookup resources with nil context",
@@ -132,7 +146,7 @@ func TestStableCacheKeys(t *testing.T) {
 					Context: nil,
 				}, computeBothHashes)
 			},
-			"87b8e4dcf893f4abd701",
+			"b9d98ab5ba80e495f701",
 		},
 		{
 			"lookup resources with empty context",
@@ -150,7 +164,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"87b8e4dcf893f4abd701",
+			"b9d98ab5ba80e495f701",
 		},
 		{
 			"lookup resources with context",
@@ -171,7 +185,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"8a9bd5bba3ba9cde9301",
+			"8ac2a88cf5e1e59acc01",
 		},
 		{
 			"lookup resources with different context",
@@ -192,7 +206,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"f6db868dc194c19ade01",
+			"88d49b80f38696bf33",
 		},
 		{
 			"lookup resources with escaped string",
@@ -212,7 +226,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"f98bb6f7fce8eb9ecc01",
+			"beace98edfa0a4c805",
 		},
 		{
 			"lookup resources with nested context",
@@ -236,7 +250,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"e0d8d0e099d68b96fa01",
+			"a5e9a6b09f9abafc0c",
 		},
 		{
 			"reachable resources",
@@ -521,5 +535,5 @@ func TestComputeContextHash(t *testing.T) {
 		}(),
 	}, computeBothHashes)
 
-	require.Equal(t, "82b4a3a3c5e3ecf1df01", hex.EncodeToString(result.StableSumAsBytes()))
+	require.Equal(t, "a48affa7b9e2cb9b36", hex.EncodeToString(result.StableSumAsBytes()))
 }
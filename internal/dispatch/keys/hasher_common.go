@@ -38,6 +38,12 @@ func (hrs hashableResultSetting) AppendToHash(hasher hasherInterface) {
 	hasher.WriteString(string(hrs))
 }
 
+type hashableLimit uint32
+
+func (hl hashableLimit) AppendToHash(hasher hasherInterface) {
+	hasher.WriteString(strconv.FormatUint(uint64(hl), 10))
+}
+
 type hashableIds []string
 
 func (hid hashableIds) AppendToHash(hasher hasherInterface) {
@@ -0,0 +1,232 @@
+// Package materializing implements a dispatcher that answers checks of configured relations from
+// the materialized set of subjects of each resource.
+package materializing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/authzed/spicedb/internal/datasets"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph"
+	"github.com/authzed/spicedb/pkg/cache"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const errMaterializingInitialization = "error initializing materializing dispatcher: %w"
+
+// Dispatcher is a dispatcher which materializes the subjects of the resources of configured
+// relations, answering subsequent checks of those resources with lookups in the materialized sets.
+//
+// The subjects of a type of a resource are materialized by a single LookupSubjects dispatch, at
+// the revision of the check; the set of subjects found is cached for that revision, so it never
+// becomes stale, and expires after the TTL so that sets of revisions no longer read are released.
+// Checks for a subject which is not a terminal subject (such as a userset), or which request
+// debugging or proofs, are dispatched to the delegate. Caveated memberships are returned with their
+// caveat expressions, as by the delegate.
+//
+// Materialization trades a single expensive lookup for cheap checks, and so is intended for
+// relations checked for many subjects of the same resource at the same revision, with a bounded
+// number of subjects.
+type Dispatcher struct {
+	dispatch.Dispatcher
+
+	c         cache.Cache
+	relations map[string]struct{}
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewMaterializingDispatcher creates a new dispatch.Dispatcher which materializes the given
+// relations, each of the form `namespace#relation`, into the given cache, dispatching everything
+// else to the delegate. The TTL should not exceed the GC window of the datastore, past which the
+// revisions of the materialized sets can no longer be read.
+func NewMaterializingDispatcher(delegate dispatch.Dispatcher, cacheInst cache.Cache, relations []string, ttl time.Duration) (*Dispatcher, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf(errMaterializingInitialization, fmt.Errorf("TTL must be positive, got %s", ttl))
+	}
+
+	if cacheInst == nil {
+		cacheInst = cache.NoopCache()
+	}
+
+	relationSet := make(map[string]struct{}, len(relations))
+	for _, relation := range relations {
+		namespace, relationName, ok := strings.Cut(relation, "#")
+		if !ok || namespace == "" || relationName == "" {
+			return nil, fmt.Errorf(errMaterializingInitialization, fmt.Errorf("relation `%s` must be of the form `namespace#relation`", relation))
+		}
+		relationSet[relation] = struct{}{}
+	}
+
+	return &Dispatcher{
+		Dispatcher: delegate,
+		c:          cacheInst,
+		relations:  relationSet,
+		ttl:        ttl,
+		now:        time.Now,
+	}, nil
+}
+
+type materializedSet struct {
+	subjects      datasets.SubjectSet
+	depthRequired uint32
+	createdAt     time.Time
+}
+
+// DispatchCheck implements dispatch.Check interface
+func (md *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	if !md.materializes(req) {
+		return md.Dispatcher.DispatchCheck(ctx, req)
+	}
+
+	metadata := &v1.ResponseMeta{}
+	members := graph.NewMembershipSet()
+	var remaining []string
+	for _, resourceID := range req.ResourceIds {
+		set, err := md.materialized(ctx, req, resourceID, metadata)
+		if err != nil {
+			return &v1.DispatchCheckResponse{Metadata: metadata}, err
+		}
+
+		if set == nil {
+			remaining = append(remaining, resourceID)
+			continue
+		}
+
+		// Non-members are omitted from results, as by the delegate.
+		if found, ok := accessibleSubject(set.subjects, req.Subject.ObjectId); ok {
+			members.AddMember(resourceID, found.GetCaveatExpression())
+		}
+	}
+
+	results := members.AsCheckResultsMap()
+	if len(remaining) > 0 {
+		delegateReq := req.CloneVT()
+		delegateReq.ResourceIds = remaining
+
+		computed, err := md.Dispatcher.DispatchCheck(ctx, delegateReq)
+		if computed == nil || computed.Metadata == nil {
+			return computed, err
+		}
+
+		dispatch.AddResponseMetadata(computed.Metadata, metadata)
+		if err != nil {
+			return computed, err
+		}
+
+		maps.Copy(results, computed.ResultsByResourceId)
+		computed.ResultsByResourceId = results
+		return computed, nil
+	}
+
+	return &v1.DispatchCheckResponse{Metadata: metadata, ResultsByResourceId: results}, nil
+}
+
+// accessibleSubject returns the subject with the given ID found in the set, with the caveat
+// expression under which it is accessible, either directly or via a wildcard from which it is not
+// excluded.
+func accessibleSubject(subjects datasets.SubjectSet, subjectID string) (*v1.FoundSubject, bool) {
+	accessible := datasets.NewSubjectSet()
+	accessible.Add(&v1.FoundSubject{SubjectId: subjectID})
+	accessible.IntersectionDifference(subjects)
+	return accessible.Get(subjectID)
+}
+
+// materializes returns whether the check can be answered from materialized sets.
+func (md *Dispatcher) materializes(req *v1.DispatchCheckRequest) bool {
+	if req.Debug != v1.DispatchCheckRequest_NO_DEBUG || req.IncludeProof {
+		return false
+	}
+
+	if req.Subject.Relation != tuple.Ellipsis {
+		return false
+	}
+
+	_, ok := md.relations[req.ResourceRelation.Namespace+"#"+req.ResourceRelation.Relation]
+	return ok
+}
+
+// materialized returns the materialized set of subjects of the type of the subject of the request
+// for the resource, looking it up if not cached, or nil if the cached set cannot be used for the
+// request. The cost of the lookup is added to the metadata.
+func (md *Dispatcher) materialized(ctx context.Context, req *v1.DispatchCheckRequest, resourceID string, metadata *v1.ResponseMeta) (*materializedSet, error) {
+	// The key is prefixed to be distinct from the keys of the dispatch cache, which may be shared.
+	key := fmt.Sprintf("materialized:%s:%s#%s@%s@%s", req.ResourceRelation.Namespace, resourceID,
+		req.ResourceRelation.Relation, req.Subject.Namespace, req.Metadata.AtRevision)
+
+	if cached, found := md.c.Get(key); found {
+		set := cached.(*materializedSet)
+		if md.now().Sub(set.createdAt) <= md.ttl {
+			if req.Metadata.DepthRemaining < set.depthRequired {
+				return nil, nil
+			}
+
+			metadata.CachedDispatchCount++
+			metadata.DepthRequired = maxUint32(metadata.DepthRequired, set.depthRequired)
+			return set, nil
+		}
+	}
+
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupSubjectsResponse](ctx)
+	err := md.Dispatcher.DispatchLookupSubjects(&v1.DispatchLookupSubjectsRequest{
+		Metadata:         req.Metadata,
+		ResourceRelation: req.ResourceRelation,
+		ResourceIds:      []string{resourceID},
+		SubjectRelation: &core.RelationReference{
+			Namespace: req.Subject.Namespace,
+			Relation:  tuple.Ellipsis,
+		},
+	}, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	lookupMetadata := &v1.ResponseMeta{}
+	subjects := datasets.NewSubjectSet()
+	for _, result := range stream.Results() {
+		dispatch.AddResponseMetadata(lookupMetadata, result.Metadata)
+		subjects.UnionWith(result.FoundSubjectsByResourceId[resourceID].GetFoundSubjects())
+	}
+
+	metadata.DispatchCount += lookupMetadata.DispatchCount
+	metadata.CachedDispatchCount += lookupMetadata.CachedDispatchCount
+
+	set := &materializedSet{
+		subjects:      subjects,
+		depthRequired: lookupMetadata.DepthRequired,
+		createdAt:     md.now(),
+	}
+	md.c.Set(key, set, materializedCost(subjects))
+
+	metadata.DepthRequired = maxUint32(metadata.DepthRequired, set.depthRequired)
+	return set, nil
+}
+
+// materializedCost estimates the size, in bytes, of the materialized set of subjects.
+func materializedCost(subjects datasets.SubjectSet) int64 {
+	// The overhead of each subject in the set, beyond its ID and its caveat expression and
+	// exclusions, which are estimated by their encoded sizes.
+	const subjectOverhead = 64
+
+	var cost int64
+	for _, found := range subjects.AsSlice() {
+		cost += int64(subjectOverhead + found.SizeVT())
+	}
+	return cost
+}
+
+func maxUint32(x, y uint32) uint32 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+var _ dispatch.Dispatcher = &Dispatcher{}
@@ -0,0 +1,171 @@
+package materializing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type countingDispatcher struct {
+	dispatch.Dispatcher
+	checks  int
+	lookups int
+	err     error
+}
+
+func (cd *countingDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	cd.checks++
+	return cd.Dispatcher.DispatchCheck(ctx, req)
+}
+
+func (cd *countingDispatcher) DispatchLookupSubjects(req *v1.DispatchLookupSubjectsRequest, stream dispatch.LookupSubjectsStream) error {
+	cd.lookups++
+	if cd.err != nil {
+		return cd.err
+	}
+	return cd.Dispatcher.DispatchLookupSubjects(req, stream)
+}
+
+func TestMaterializingDispatcher(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, `
+		definition user {}
+
+		caveat somecaveat(somecondition int) {
+			somecondition == 42
+		}
+
+		definition group {
+			relation member: user
+		}
+
+		definition document {
+			relation viewer: user | user:* | user with somecaveat | group#member
+			relation banned: user
+			permission view = viewer - banned
+		}
+	`, []*core.RelationTuple{
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:first#viewer@group:eng#member"),
+		tuple.MustParse("group:eng#member@user:sarah"),
+		tuple.WithCaveat(tuple.MustParse("document:first#viewer@user:fred"), "somecaveat"),
+		tuple.MustParse("document:public#viewer@user:*"),
+		tuple.MustParse("document:public#banned@user:tom"),
+	}, require)
+
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(datastoremw.SetInContext(ctx, ds))
+
+	delegate := &countingDispatcher{Dispatcher: graph.NewLocalOnlyDispatcher(10)}
+	md, err := NewMaterializingDispatcher(delegate, caching.DispatchTestCache(t), []string{"document#view"}, time.Minute)
+	require.NoError(err)
+
+	checkRequest := func(resourceIDs []string, subject string) *v1.DispatchCheckRequest {
+		return &v1.DispatchCheckRequest{
+			ResourceRelation: &core.RelationReference{Namespace: "document", Relation: "view"},
+			ResourceIds:      resourceIDs,
+			Subject:          tuple.ParseSubjectONR(subject),
+			Metadata: &v1.ResolverMeta{
+				AtRevision:     revision.String(),
+				DepthRemaining: 50,
+			},
+		}
+	}
+
+	check := func(resourceIDs []string, subject string) map[string]v1.ResourceCheckResult_Membership {
+		resp, err := md.DispatchCheck(ctx, checkRequest(resourceIDs, subject))
+		require.NoError(err)
+
+		memberships := make(map[string]v1.ResourceCheckResult_Membership, len(resp.ResultsByResourceId))
+		for resourceID, result := range resp.ResultsByResourceId {
+			memberships[resourceID] = result.Membership
+		}
+		return memberships
+	}
+
+	// The first check materializes the resources, and later checks are answered from the
+	// materialized sets.
+	require.Equal(map[string]v1.ResourceCheckResult_Membership{
+		"first":  v1.ResourceCheckResult_MEMBER,
+		"public": v1.ResourceCheckResult_MEMBER,
+	}, check([]string{"first", "public"}, "user:sarah"))
+	md.c.Wait()
+	require.Equal(2, delegate.lookups)
+	require.Equal(0, delegate.checks)
+
+	require.Equal(map[string]v1.ResourceCheckResult_Membership{
+		"first": v1.ResourceCheckResult_MEMBER,
+	}, check([]string{"first", "public"}, "user:tom"))
+	require.Empty(check([]string{"first"}, "user:unknown"))
+	require.Equal(2, delegate.lookups)
+	require.Equal(0, delegate.checks)
+
+	// Caveated memberships are returned with their caveat expressions.
+	require.Equal(map[string]v1.ResourceCheckResult_Membership{
+		"first":  v1.ResourceCheckResult_CAVEATED_MEMBER,
+		"public": v1.ResourceCheckResult_MEMBER,
+	}, check([]string{"first", "public"}, "user:fred"))
+	require.Equal(0, delegate.checks)
+
+	result, _, err := computed.ComputeCheck(ctx, md, computed.CheckParameters{
+		ResourceType:  &core.RelationReference{Namespace: "document", Relation: "view"},
+		Subject:       tuple.ParseSubjectONR("user:fred"),
+		CaveatContext: map[string]any{"somecondition": 42},
+		AtRevision:    revision,
+		MaximumDepth:  50,
+	}, "first")
+	require.NoError(err)
+	require.Equal(v1.ResourceCheckResult_MEMBER, result.Membership)
+	require.Equal(0, delegate.checks)
+
+	// Non-terminal subjects are checked by the delegate.
+	require.Equal(map[string]v1.ResourceCheckResult_Membership{
+		"first": v1.ResourceCheckResult_MEMBER,
+	}, check([]string{"first"}, "group:eng#member"))
+	require.Equal(1, delegate.checks)
+
+	// Expired sets are materialized again.
+	md.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	require.Equal(map[string]v1.ResourceCheckResult_Membership{
+		"first": v1.ResourceCheckResult_MEMBER,
+	}, check([]string{"first"}, "user:tom"))
+	require.Equal(3, delegate.lookups)
+
+	// Errors materializing a set are returned, rather than hidden by a check of the delegate.
+	delegate.err = errors.New("lookup failed")
+	_, err = md.DispatchCheck(ctx, checkRequest([]string{"other"}, "user:tom"))
+	require.ErrorIs(err, delegate.err)
+	require.Equal(1, delegate.checks)
+}
+
+func TestMaterializingDispatcherConfig(t *testing.T) {
+	delegate := graph.NewLocalOnlyDispatcher(10)
+
+	_, err := NewMaterializingDispatcher(delegate, nil, []string{"document#view"}, 0)
+	require.Error(t, err)
+
+	_, err = NewMaterializingDispatcher(delegate, nil, []string{"document"}, time.Minute)
+	require.Error(t, err)
+
+	_, err = NewMaterializingDispatcher(delegate, nil, []string{"document#view", "folder#view"}, time.Minute)
+	require.NoError(t, err)
+}
@@ -6,7 +6,9 @@ import (
 	"io"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
@@ -19,6 +21,7 @@ type clusterClient interface {
 	DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest, opts ...grpc.CallOption) (*v1.DispatchCheckResponse, error)
 	DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest, opts ...grpc.CallOption) (*v1.DispatchExpandResponse, error)
 	DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest, opts ...grpc.CallOption) (*v1.DispatchLookupResponse, error)
+	DispatchStreamingLookup(ctx context.Context, req *v1.DispatchLookupRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchStreamingLookupClient, error)
 	DispatchReachableResources(ctx context.Context, in *v1.DispatchReachableResourcesRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchReachableResourcesClient, error)
 	DispatchLookupSubjects(ctx context.Context, in *v1.DispatchLookupSubjectsRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchLookupSubjectsClient, error)
 }
@@ -77,23 +80,56 @@ func (cr *clusterDispatcher) DispatchExpand(ctx context.Context, req *v1.Dispatc
 	return resp, nil
 }
 
-func (cr *clusterDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+func (cr *clusterDispatcher) DispatchLookup(
+	req *v1.DispatchLookupRequest,
+	stream dispatch.LookupStream,
+) error {
+	requestKey, err := cr.keyHandler.LookupResourcesDispatchKey(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(stream.Context(), balancer.CtxKey, requestKey)
+	stream = dispatch.StreamWithContext(ctx, stream)
+
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
-	requestKey, err := cr.keyHandler.LookupResourcesDispatchKey(ctx, req)
+	client, err := cr.clusterClient.DispatchStreamingLookup(ctx, req)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
-	ctx = context.WithValue(ctx, balancer.CtxKey, requestKey)
-	resp, err := cr.clusterClient.DispatchLookup(ctx, req)
-	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: requestFailureMetadata}, err
+	published := false
+	for {
+		result, err := client.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// Nodes which predate the streaming lookup only serve the unary lookup, such as during a
+		// rolling upgrade.
+		if !published && status.Code(err) == codes.Unimplemented {
+			resp, err := cr.clusterClient.DispatchLookup(ctx, req)
+			if err != nil {
+				return err
+			}
+			return stream.Publish(resp)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		serr := stream.Publish(result)
+		if serr != nil {
+			return serr
+		}
+		published = true
 	}
 
-	return resp, nil
+	return nil
 }
 
 func (cr *clusterDispatcher) DispatchReachableResources(
@@ -0,0 +1,55 @@
+package remote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// preStreamingClient is a client of a node which predates DispatchStreamingLookup.
+type preStreamingClient struct {
+	v1.DispatchServiceClient
+	resp *v1.DispatchLookupResponse
+}
+
+func (pc preStreamingClient) DispatchLookup(_ context.Context, _ *v1.DispatchLookupRequest, _ ...grpc.CallOption) (*v1.DispatchLookupResponse, error) {
+	return pc.resp, nil
+}
+
+func (pc preStreamingClient) DispatchStreamingLookup(_ context.Context, _ *v1.DispatchLookupRequest, _ ...grpc.CallOption) (v1.DispatchService_DispatchStreamingLookupClient, error) {
+	return unimplementedStream{}, nil
+}
+
+type unimplementedStream struct {
+	grpc.ClientStream
+}
+
+func (unimplementedStream) Recv() (*v1.DispatchLookupResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "unknown method DispatchStreamingLookup")
+}
+
+func TestDispatchLookupFallsBackToUnary(t *testing.T) {
+	resp := &v1.DispatchLookupResponse{
+		Metadata:          &v1.ResponseMeta{DispatchCount: 1},
+		ResolvedResources: []*v1.ResolvedResource{{ResourceId: "masterplan"}},
+	}
+	dispatcher := NewClusterDispatcher(preStreamingClient{resp: resp}, nil, nil)
+
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	err := dispatcher.DispatchLookup(&v1.DispatchLookupRequest{
+		Metadata:       &v1.ResolverMeta{AtRevision: "1", DepthRemaining: 50},
+		ObjectRelation: &core.RelationReference{Namespace: "document", Relation: "view"},
+		Subject:        &core.ObjectAndRelation{Namespace: "user", ObjectId: "tom", Relation: "..."},
+		Limit:          10,
+	}, stream)
+	require.NoError(t, err)
+	require.Equal(t, []*v1.DispatchLookupResponse{resp}, stream.Results())
+}
@@ -0,0 +1,25 @@
+package dispatch
+
+import (
+	"time"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// SoftDeadlineReached returns a channel which receives once the soft deadline of the request
+// metadata is reached, along with a function releasing its timer, which must be called once the
+// channel is no longer needed. If the request has no soft deadline, the channel is nil, and so
+// blocks forever.
+//
+// Once the soft deadline is reached, set operations stop waiting for their remaining branches and
+// return the best-effort result of the branches resolved so far, marking the response metadata
+// as incomplete. The soft deadline is carried by the request metadata, so it applies to branches
+// dispatched to other nodes, too.
+func SoftDeadlineReached(meta *v1.ResolverMeta) (<-chan time.Time, func()) {
+	if meta.GetSoftDeadline() == nil {
+		return nil, func() {}
+	}
+
+	timer := time.NewTimer(time.Until(meta.SoftDeadline.AsTime()))
+	return timer.C, func() { timer.Stop() }
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -81,16 +84,32 @@ type currentRequestContext struct {
 
 	// maxDispatchCount is the maximum number of resource IDs that can be specified in each dispatch.
 	maxDispatchCount uint64
+
+	// relationshipsScanned, if non-nil, counts the relationships read from the datastore for
+	// the current request. It is only set when debugging is enabled.
+	relationshipsScanned *atomic.Uint64
+}
+
+// recordRelationshipScanned counts a relationship read from the datastore, if debugging.
+func (crc currentRequestContext) recordRelationshipScanned() {
+	if crc.relationshipsScanned != nil {
+		crc.relationshipsScanned.Add(1)
+	}
 }
 
 // Check performs a check request with the provided request and context
 func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckRequest, relation *core.Relation) (*v1.DispatchCheckResponse, error) {
-	resolved := cc.checkInternal(ctx, req, relation)
-	resolved.Resp.Metadata = addCallToResponseMetadata(resolved.Resp.Metadata)
 	if req.Debug == v1.DispatchCheckRequest_NO_DEBUG {
+		resolved := cc.checkInternal(ctx, req, relation, nil)
+		resolved.Resp.Metadata = addCallToResponseMetadata(resolved.Resp.Metadata)
 		return resolved.Resp, resolved.Err
 	}
 
+	startTime := time.Now()
+	var relationshipsScanned atomic.Uint64
+	resolved := cc.checkInternal(ctx, req, relation, &relationshipsScanned)
+	resolved.Resp.Metadata = addCallToResponseMetadata(resolved.Resp.Metadata)
+
 	// Add debug information if requested.
 	debugInfo := resolved.Resp.Metadata.DebugInfo
 	if debugInfo == nil {
@@ -107,6 +126,10 @@ func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckReques
 		debugInfo.Check.ResourceRelationType = v1.CheckDebugTrace_RELATION
 	}
 
+	debugInfo.Check.RewriteOperation = rewriteOperationForTrace(relation.UsersetRewrite)
+	debugInfo.Check.Duration = durationpb.New(time.Since(startTime))
+	debugInfo.Check.RelationshipsScanned = relationshipsScanned.Load()
+
 	// Build the results for the debug trace.
 	results := make(map[string]*v1.ResourceCheckResult, len(req.DispatchCheckRequest.ResourceIds))
 	for _, resourceID := range req.DispatchCheckRequest.ResourceIds {
@@ -120,7 +143,22 @@ func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckReques
 	return resolved.Resp, resolved.Err
 }
 
-func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedCheckRequest, relation *core.Relation) CheckResult {
+// rewriteOperationForTrace returns the operation at the root of the rewrite, for recording in
+// the debug trace.
+func rewriteOperationForTrace(rewrite *core.UsersetRewrite) v1.CheckDebugTrace_RewriteOperation {
+	switch rewrite.GetRewriteOperation().(type) {
+	case *core.UsersetRewrite_Union:
+		return v1.CheckDebugTrace_UNION
+	case *core.UsersetRewrite_Intersection:
+		return v1.CheckDebugTrace_INTERSECTION
+	case *core.UsersetRewrite_Exclusion:
+		return v1.CheckDebugTrace_EXCLUSION
+	default:
+		return v1.CheckDebugTrace_NO_REWRITE
+	}
+}
+
+func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedCheckRequest, relation *core.Relation, relationshipsScanned *atomic.Uint64) CheckResult {
 	// Ensure that we have proper type information for running the check. This is now required as of the deprecation and removal
 	// of the v0 API.
 	if relation.GetTypeInformation() == nil && relation.GetUsersetRewrite() == nil {
@@ -149,7 +187,7 @@ func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedChe
 	//
 	// If the filtering results in no further resource IDs to check, or a result is found and a single
 	// result is allowed, we terminate early.
-	membershipSet, filteredResourcesIds := filterForFoundMemberResource(req.ResourceRelation, req.ResourceIds, req.Subject)
+	membershipSet, filteredResourcesIds := filterForFoundMemberResource(req.ResourceRelation, req.ResourceIds, req.Subject, req.IncludeProof)
 	if membershipSet.HasDeterminedMember() && req.DispatchCheckRequest.ResultsSetting == v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT {
 		return checkResultsForMembership(membershipSet, emptyMetadata)
 	}
@@ -166,10 +204,11 @@ func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedChe
 	}
 
 	crc := currentRequestContext{
-		parentReq:           req,
-		filteredResourceIDs: filteredResourcesIds,
-		resultsSetting:      resultsSetting,
-		maxDispatchCount:    maxDispatchChunkSize,
+		parentReq:            req,
+		filteredResourceIDs:  filteredResourcesIds,
+		resultsSetting:       resultsSetting,
+		maxDispatchCount:     maxDispatchChunkSize,
+		relationshipsScanned: relationshipsScanned,
 	}
 
 	if req.Debug == v1.DispatchCheckRequest_ENABLE_TRACE_DEBUGGING {
@@ -213,7 +252,7 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 	defer it.Close()
 
 	// Find the subjects over which to dispatch.
-	foundResources := NewMembershipSet()
+	foundResources := newMembershipSetForRequest(crc.parentReq.IncludeProof)
 	subjectsToDispatch := tuple.NewONRByTypeSet()
 	relationshipsBySubjectONR := util.NewMultiMap[string, *core.RelationTuple]()
 
@@ -232,11 +271,12 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 		if it.Err() != nil {
 			return checkResultError(NewCheckFailureErr(it.Err()), emptyMetadata)
 		}
+		crc.recordRelationshipScanned()
 
 		// If the subject of the relationship matches the target subject, then we've found
 		// a result.
 		if onrEqualOrWildcard(tpl.Subject, crc.parentReq.Subject) {
-			foundResources.AddDirectMember(tpl.ResourceAndRelation.ObjectId, tpl.Caveat)
+			foundResources.AddDirectMemberViaRelationship(tpl.ResourceAndRelation.ObjectId, tpl)
 			hadDirectResult = true
 			if crc.resultsSetting == v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT && foundResources.HasDeterminedMember() {
 				return checkResultsForMembership(foundResources, emptyMetadata)
@@ -277,8 +317,9 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 				Subject:          crc.parentReq.Subject,
 				ResultsSetting:   crc.resultsSetting,
 
-				Metadata: decrementDepth(crc.parentReq.Metadata),
-				Debug:    crc.parentReq.Debug,
+				Metadata:     decrementDepth(crc.parentReq.Metadata),
+				Debug:        crc.parentReq.Debug,
+				IncludeProof: crc.parentReq.IncludeProof,
 			},
 			crc.parentReq.Revision,
 		})
@@ -286,15 +327,15 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 			return childResult
 		}
 
-		return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR)
+		return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR, crc.parentReq.IncludeProof)
 	}, cc.concurrencyLimit)
 
 	return combineResultWithFoundResources(result, foundResources)
 }
 
-func mapFoundResources(result CheckResult, resourceType *core.RelationReference, relationshipsBySubjectONR *util.MultiMap[string, *core.RelationTuple]) CheckResult {
+func mapFoundResources(result CheckResult, resourceType *core.RelationReference, relationshipsBySubjectONR *util.MultiMap[string, *core.RelationTuple], includeProof bool) CheckResult {
 	// Map any resources found to the parent resource IDs.
-	membershipSet := NewMembershipSet()
+	membershipSet := newMembershipSetForRequest(includeProof)
 	for foundResourceID, result := range result.Resp.ResultsByResourceId {
 		subjectKey := tuple.StringONR(&core.ObjectAndRelation{
 			Namespace: resourceType.Namespace,
@@ -304,7 +345,7 @@ func mapFoundResources(result CheckResult, resourceType *core.RelationReference,
 
 		tuples, _ := relationshipsBySubjectONR.Get(subjectKey)
 		for _, relationTuple := range tuples {
-			membershipSet.AddMemberViaRelationship(relationTuple.ResourceAndRelation.ObjectId, result.Expression, relationTuple)
+			membershipSet.addMemberViaRelationship(relationTuple.ResourceAndRelation.ObjectId, result.Expression, result.Proof, relationTuple)
 		}
 	}
 
@@ -376,7 +417,7 @@ func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc curre
 	}
 
 	// If we will be dispatching to the goal's ONR, then we know that the ONR is a member.
-	membershipSet, updatedTargetResourceIds := filterForFoundMemberResource(targetRR, targetResourceIds, crc.parentReq.Subject)
+	membershipSet, updatedTargetResourceIds := filterForFoundMemberResource(targetRR, targetResourceIds, crc.parentReq.Subject, crc.parentReq.IncludeProof)
 	if (membershipSet.HasDeterminedMember() && crc.resultsSetting == v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT) || len(updatedTargetResourceIds) == 0 {
 		return checkResultsForMembership(membershipSet, emptyMetadata)
 	}
@@ -400,20 +441,21 @@ func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc curre
 			ResultsSetting:   crc.resultsSetting,
 			Metadata:         decrementDepth(crc.parentReq.Metadata),
 			Debug:            crc.parentReq.Debug,
+			IncludeProof:     crc.parentReq.IncludeProof,
 		},
 		crc.parentReq.Revision,
 	})
 	return combineResultWithFoundResources(result, membershipSet)
 }
 
-func filterForFoundMemberResource(resourceRelation *core.RelationReference, resourceIds []string, subject *core.ObjectAndRelation) (*MembershipSet, []string) {
+func filterForFoundMemberResource(resourceRelation *core.RelationReference, resourceIds []string, subject *core.ObjectAndRelation, includeProof bool) (*MembershipSet, []string) {
 	if resourceRelation.Namespace != subject.Namespace || resourceRelation.Relation != subject.Relation {
 		return nil, resourceIds
 	}
 
 	for index, resourceID := range resourceIds {
 		if subject.ObjectId == resourceID {
-			membershipSet := NewMembershipSet()
+			membershipSet := newMembershipSetForRequest(includeProof)
 			membershipSet.AddDirectMember(resourceID, nil)
 			return membershipSet, removeIndexFromSlice(resourceIds, index)
 		}
@@ -447,6 +489,7 @@ func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc curren
 		if it.Err() != nil {
 			return checkResultError(NewCheckFailureErr(it.Err()), emptyMetadata)
 		}
+		crc.recordRelationshipScanned()
 
 		subjectsToDispatch.Add(tpl.Subject)
 		relationshipsBySubjectONR.Add(tuple.StringONR(tpl.Subject), tpl)
@@ -477,7 +520,7 @@ func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc curren
 				return childResult
 			}
 
-			return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR)
+			return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR, crc.parentReq.IncludeProof)
 		},
 		cc.concurrencyLimit,
 	)
@@ -499,15 +542,17 @@ func union[T any](
 	childCtx, cancelFn := context.WithCancel(ctx)
 
 	dispatcherCleanup := dispatchAllAsync(childCtx, crc, children, handler, resultChan, concurrencyLimit)
+	softDeadline, stopSoftDeadline := dispatch.SoftDeadlineReached(crc.parentReq.Metadata)
 
 	defer func() {
 		cancelFn()
 		dispatcherCleanup()
 		close(resultChan)
+		stopSoftDeadline()
 	}()
 
 	responseMetadata := emptyMetadata
-	membershipSet := NewMembershipSet()
+	membershipSet := newMembershipSetForRequest(crc.parentReq.IncludeProof)
 
 	for i := 0; i < len(children); i++ {
 		select {
@@ -523,6 +568,10 @@ func union[T any](
 				return checkResultsForMembership(membershipSet, responseMetadata)
 			}
 
+		case <-softDeadline:
+			// The members found so far are members regardless of the remaining branches.
+			return checkResultsForMembership(membershipSet, incompleteMetadata(responseMetadata))
+
 		case <-ctx.Done():
 			log.Ctx(ctx).Trace().Msg("anyCanceled")
 			return checkResultError(NewRequestCanceledErr(), responseMetadata)
@@ -549,16 +598,19 @@ func all[T any](
 	childCtx, cancelFn := context.WithCancel(ctx)
 
 	cleanupFunc := dispatchAllAsync(childCtx, currentRequestContext{
-		parentReq:           crc.parentReq,
-		filteredResourceIDs: crc.filteredResourceIDs,
-		resultsSetting:      v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
-		maxDispatchCount:    crc.maxDispatchCount,
+		parentReq:            crc.parentReq,
+		filteredResourceIDs:  crc.filteredResourceIDs,
+		resultsSetting:       v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
+		maxDispatchCount:     crc.maxDispatchCount,
+		relationshipsScanned: crc.relationshipsScanned,
 	}, children, handler, resultChan, concurrencyLimit)
+	softDeadline, stopSoftDeadline := dispatch.SoftDeadlineReached(crc.parentReq.Metadata)
 
 	defer func() {
 		cancelFn()
 		cleanupFunc()
 		close(resultChan)
+		stopSoftDeadline()
 	}()
 
 	var membershipSet *MembershipSet
@@ -571,7 +623,7 @@ func all[T any](
 			}
 
 			if membershipSet == nil {
-				membershipSet = NewMembershipSet()
+				membershipSet = newMembershipSetForRequest(crc.parentReq.IncludeProof)
 				membershipSet.UnionWith(result.Resp.ResultsByResourceId)
 			} else {
 				membershipSet.IntersectWith(result.Resp.ResultsByResourceId)
@@ -580,6 +632,11 @@ func all[T any](
 			if membershipSet.IsEmpty() {
 				return noMembersWithMetadata(responseMetadata)
 			}
+
+		case <-softDeadline:
+			// No resource can be found a member without resolving every branch.
+			return noMembersWithMetadata(incompleteMetadata(responseMetadata))
+
 		case <-ctx.Done():
 			return checkResultError(NewRequestCanceledErr(), responseMetadata)
 		}
@@ -618,11 +675,13 @@ func difference[T any](
 	}()
 
 	cleanupFunc := dispatchAllAsync(childCtx, currentRequestContext{
-		parentReq:           crc.parentReq,
-		filteredResourceIDs: crc.filteredResourceIDs,
-		resultsSetting:      v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
-		maxDispatchCount:    crc.maxDispatchCount,
+		parentReq:            crc.parentReq,
+		filteredResourceIDs:  crc.filteredResourceIDs,
+		resultsSetting:       v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
+		maxDispatchCount:     crc.maxDispatchCount,
+		relationshipsScanned: crc.relationshipsScanned,
 	}, children[1:], handler, othersChan, concurrencyLimit-1)
+	softDeadline, stopSoftDeadline := dispatch.SoftDeadlineReached(crc.parentReq.Metadata)
 
 	defer func() {
 		cancelFn()
@@ -630,10 +689,11 @@ func difference[T any](
 		close(othersChan)
 		wg.Wait()
 		close(baseChan)
+		stopSoftDeadline()
 	}()
 
 	responseMetadata := emptyMetadata
-	membershipSet := NewMembershipSet()
+	membershipSet := newMembershipSetForRequest(crc.parentReq.IncludeProof)
 
 	// Wait for the base set to return.
 	select {
@@ -649,6 +709,9 @@ func difference[T any](
 			return noMembersWithMetadata(responseMetadata)
 		}
 
+	case <-softDeadline:
+		return noMembersWithMetadata(incompleteMetadata(responseMetadata))
+
 	case <-ctx.Done():
 		return checkResultError(NewRequestCanceledErr(), responseMetadata)
 	}
@@ -668,6 +731,10 @@ func difference[T any](
 				return noMembers()
 			}
 
+		case <-softDeadline:
+			// The members of the base set not yet excluded are returned as probable members.
+			return checkResultsForMembership(membershipSet, incompleteMetadata(responseMetadata))
+
 		case <-ctx.Done():
 			return checkResultError(NewRequestCanceledErr(), responseMetadata)
 		}
@@ -772,11 +839,20 @@ func combineResultWithFoundResources(result CheckResult, foundResources *Members
 	}
 }
 
+// incompleteMetadata returns a copy of the response metadata marking the response as incomplete,
+// having been returned before all of its branches were resolved.
+func incompleteMetadata(metadata *v1.ResponseMeta) *v1.ResponseMeta {
+	marked := metadata.CloneVT()
+	marked.Incomplete = true
+	return marked
+}
+
 func combineResponseMetadata(existing *v1.ResponseMeta, responseMetadata *v1.ResponseMeta) *v1.ResponseMeta {
 	combined := &v1.ResponseMeta{
 		DispatchCount:       existing.DispatchCount + responseMetadata.DispatchCount,
 		DepthRequired:       max(existing.DepthRequired, responseMetadata.DepthRequired),
 		CachedDispatchCount: existing.CachedDispatchCount + responseMetadata.CachedDispatchCount,
+		Incomplete:          existing.Incomplete || responseMetadata.Incomplete,
 	}
 
 	if responseMetadata.DebugInfo == nil {
@@ -2,13 +2,18 @@ package computed
 
 import (
 	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	cexpr "github.com/authzed/spicedb/internal/caveats"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // DebugOption defines the various debug level options for Checks.
@@ -44,6 +49,23 @@ type CheckParameters struct {
 	AtRevision    datastore.Revision
 	MaximumDepth  uint32
 	DebugOption   DebugOption
+
+	// IgnoreCaveats, if true, treats every caveated relationship as unconditionally present,
+	// matching the behavior of Check before caveats were introduced.
+	IgnoreCaveats bool
+
+	// CaveatContextOverrides, if given, are caveat context which applies only to caveats found on
+	// relationships of specific namespaces or relations, taking precedence over CaveatContext.
+	CaveatContextOverrides cexpr.ContextOverrides
+
+	// IncludeProof, if true, places into the Proof of each member found the relationships which
+	// prove its membership.
+	IncludeProof bool
+
+	// SoftDeadline, if not zero, is the time at which the check returns the best-effort result
+	// computed so far, rather than running until the deadline of the context. The response
+	// metadata is then marked as incomplete. See ComputeBestEffortCheck.
+	SoftDeadline time.Time
 }
 
 // ComputeCheck computes a check result for the given resource and subject, computing any
@@ -72,6 +94,116 @@ func ComputeBulkCheck(
 	return computeCheck(ctx, d, params, resourceIDs)
 }
 
+// ComputeOverlayCheck computes a check result for the given resource and subject as though the
+// overlay updates had been applied on top of the revision checked, without writing them: CREATE
+// and TOUCH updates add their relationship, and DELETE updates remove it. It is intended for
+// "what-if" analysis of proposed changes.
+//
+// The dispatcher must compute the check within this process and must not cache its results, as
+// they reflect a hypothetical state which is not identified by the revision, e.g. a local-only
+// dispatcher.
+func ComputeOverlayCheck(
+	ctx context.Context,
+	d dispatch.Check,
+	params CheckParameters,
+	resourceID string,
+	overlay []*core.RelationTupleUpdate,
+) (*v1.ResourceCheckResult, *v1.ResponseMeta, error) {
+	ds := proxy.NewOverlayDatastore(datastoremw.MustFromContext(ctx), overlay)
+	return ComputeCheck(datastoremw.ContextWithDatastore(ctx, ds), d, params, resourceID)
+}
+
+// ComputeBestEffortCheck computes a check result for the given resource and subject, returning
+// the best-effort result computed so far if the check has not completed by the soft deadline,
+// rather than running until the hard deadline of the context and failing. Whether the result is
+// complete is returned alongside it; an incomplete result may find a member whose exclusions
+// were not all verified, or may not find a member whose branches were not all resolved, and so
+// the caller decides whether to trust it.
+//
+// The soft deadline is carried by the dispatched requests, and so applies to the set operations
+// computed on other nodes, too; see dispatch.SoftDeadlineReached.
+func ComputeBestEffortCheck(
+	ctx context.Context,
+	d dispatch.Check,
+	params CheckParameters,
+	resourceID string,
+	softDeadline time.Time,
+) (*v1.ResourceCheckResult, *v1.ResponseMeta, bool, error) {
+	params.SoftDeadline = softDeadline
+	result, meta, err := ComputeCheck(ctx, d, params, resourceID)
+	return result, meta, !meta.GetIncomplete(), err
+}
+
+// HeadRevisionCheckParameters are the parameters for the ComputeSubjectsCheckAtHead call. All
+// are required, except MinimumRevision.
+type HeadRevisionCheckParameters struct {
+	ResourceType  *core.RelationReference
+	CaveatContext map[string]any
+	MaximumDepth  uint32
+	DebugOption   DebugOption
+
+	// MinimumRevision, if not datastore.NoRevision, is the revision at or after which the checks
+	// must be performed, such as the revision of the caller's own last write.
+	MinimumRevision datastore.Revision
+}
+
+// ComputeSubjectsCheckAtHead computes a check result for each of the given subjects against a
+// single resource, at the head revision of the datastore. The head revision is computed once and
+// shared amongst the checks of all of the subjects, rather than being computed per check.
+//
+// If a minimum revision is given, it must be within the datastore's GC window, and the checks are
+// performed at the later of it and the head revision, so that the caller reads its own writes.
+//
+// The returned map is keyed by the string form of each subject, as per tuple.StringONR, and
+// the revision at which all of the checks were performed is returned alongside it.
+func ComputeSubjectsCheckAtHead(
+	ctx context.Context,
+	d dispatch.Check,
+	params HeadRevisionCheckParameters,
+	resourceID string,
+	subjects []*core.ObjectAndRelation,
+) (map[string]*v1.ResourceCheckResult, datastore.Revision, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+
+	ds := datastoremw.MustFromContext(ctx)
+	headRevision, err := ds.HeadRevision(ctx)
+	if err != nil {
+		return nil, datastore.NoRevision, respMetadata, err
+	}
+
+	if params.MinimumRevision != nil && params.MinimumRevision != datastore.NoRevision {
+		if err := ds.CheckRevision(ctx, params.MinimumRevision); err != nil {
+			return nil, datastore.NoRevision, respMetadata, err
+		}
+
+		if params.MinimumRevision.GreaterThan(headRevision) {
+			headRevision = params.MinimumRevision
+		}
+	}
+
+	results := make(map[string]*v1.ResourceCheckResult, len(subjects))
+	for _, subject := range subjects {
+		result, meta, err := ComputeCheck(ctx, d, CheckParameters{
+			ResourceType:  params.ResourceType,
+			Subject:       subject,
+			CaveatContext: params.CaveatContext,
+			AtRevision:    headRevision,
+			MaximumDepth:  params.MaximumDepth,
+			DebugOption:   params.DebugOption,
+		}, resourceID)
+		if meta != nil {
+			dispatch.AddResponseMetadata(respMetadata, meta)
+		}
+		if err != nil {
+			return nil, headRevision, respMetadata, err
+		}
+
+		results[tuple.StringONR(subject)] = result
+	}
+
+	return results, headRevision, respMetadata, nil
+}
+
 func computeCheck(ctx context.Context,
 	d dispatch.Check,
 	params CheckParameters,
@@ -89,16 +221,22 @@ func computeCheck(ctx context.Context,
 		setting = v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT
 	}
 
+	metadata := &v1.ResolverMeta{
+		AtRevision:     params.AtRevision.String(),
+		DepthRemaining: params.MaximumDepth,
+	}
+	if !params.SoftDeadline.IsZero() {
+		metadata.SoftDeadline = timestamppb.New(params.SoftDeadline)
+	}
+
 	checkResult, err := d.DispatchCheck(ctx, &v1.DispatchCheckRequest{
 		ResourceRelation: params.ResourceType,
 		ResourceIds:      resourceIDs,
 		ResultsSetting:   setting,
 		Subject:          params.Subject,
-		Metadata: &v1.ResolverMeta{
-			AtRevision:     params.AtRevision.String(),
-			DepthRemaining: params.MaximumDepth,
-		},
-		Debug: debugging,
+		Metadata:         metadata,
+		Debug:            debugging,
+		IncludeProof:     params.IncludeProof,
 	})
 	if err != nil {
 		return nil, checkResult.Metadata, err
@@ -127,10 +265,37 @@ func computeCaveatedCheckResult(ctx context.Context, params CheckParameters, res
 		return result, nil
 	}
 
+	if params.IgnoreCaveats {
+		if holdsIgnoringCaveats(result.Expression) {
+			return &v1.ResourceCheckResult{
+				Membership: v1.ResourceCheckResult_MEMBER,
+				Proof:      result.Proof,
+			}, nil
+		}
+
+		return &v1.ResourceCheckResult{
+			Membership: v1.ResourceCheckResult_NOT_MEMBER,
+		}, nil
+	}
+
 	ds := datastoremw.MustFromContext(ctx)
 	reader := ds.SnapshotReader(params.AtRevision)
 
-	caveatResult, err := cexpr.RunCaveatExpression(ctx, result.Expression, params.CaveatContext, reader, cexpr.RunCaveatExpressionNoDebugging)
+	computed, err := computeCaveatedMembership(ctx, result.Expression, params.CaveatContext, params.CaveatContextOverrides, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if computed.Membership != v1.ResourceCheckResult_NOT_MEMBER {
+		computed.Proof = result.Proof
+	}
+	return computed, nil
+}
+
+// computeCaveatedMembership computes the membership represented by a caveat expression under
+// the given caveat context and overrides.
+func computeCaveatedMembership(ctx context.Context, expr *core.CaveatExpression, caveatContext map[string]any, overrides cexpr.ContextOverrides, reader datastore.Reader) (*v1.ResourceCheckResult, error) {
+	caveatResult, err := cexpr.RunCaveatExpressionWithOverrides(ctx, expr, caveatContext, overrides, reader, cexpr.RunCaveatExpressionNoDebugging)
 	if err != nil {
 		return nil, err
 	}
@@ -153,3 +318,36 @@ func computeCaveatedCheckResult(ctx context.Context, params CheckParameters, res
 		Membership: v1.ResourceCheckResult_NOT_MEMBER,
 	}, nil
 }
+
+// holdsIgnoringCaveats returns whether the caveat expression holds when every caveat found
+// within it is treated as satisfied.
+func holdsIgnoringCaveats(expr *core.CaveatExpression) bool {
+	if expr.GetCaveat() != nil {
+		return true
+	}
+
+	operation := expr.GetOperation()
+	switch operation.GetOp() {
+	case core.CaveatOperation_AND:
+		for _, child := range operation.GetChildren() {
+			if !holdsIgnoringCaveats(child) {
+				return false
+			}
+		}
+		return true
+
+	case core.CaveatOperation_OR:
+		for _, child := range operation.GetChildren() {
+			if holdsIgnoringCaveats(child) {
+				return true
+			}
+		}
+		return false
+
+	case core.CaveatOperation_NOT:
+		return len(operation.GetChildren()) == 1 && !holdsIgnoringCaveats(operation.GetChildren()[0])
+
+	default:
+		return false
+	}
+}
@@ -2,11 +2,14 @@ package computed_test
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/graph/computed"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -19,6 +22,7 @@ import (
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/tuple"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 )
 
@@ -704,6 +708,78 @@ func TestComputeCheckWithCaveats(t *testing.T) {
 				},
 			},
 		},
+		{
+			"list context test",
+			`definition user {}
+
+			definition document {
+				relation viewer: user | user with testcaveat
+
+				permission view = viewer
+			}
+
+			caveat testcaveat(allowed list<int>, index int) {
+				allowed[index] == 42
+			}
+			`,
+			[]caveatedUpdate{
+				{core.RelationTupleUpdate_CREATE, "document:foo#viewer@user:sarah", "testcaveat", map[string]any{"index": 1}},
+			},
+			[]check{
+				{
+					"document:foo#view@user:sarah",
+					map[string]any{
+						"allowed": []any{1, 42},
+					},
+					v1.ResourceCheckResult_MEMBER,
+					nil,
+					"",
+				},
+				{
+					"document:foo#view@user:sarah",
+					map[string]any{
+						"allowed": []int64{42, 1},
+					},
+					v1.ResourceCheckResult_NOT_MEMBER,
+					nil,
+					"",
+				},
+				{
+					"document:foo#view@user:sarah",
+					nil,
+					v1.ResourceCheckResult_CAVEATED_MEMBER,
+					[]string{"allowed"},
+					"",
+				},
+				{
+					"document:foo#view@user:sarah",
+					map[string]any{
+						"allowed": []any{42},
+					},
+					v1.ResourceCheckResult_NOT_MEMBER,
+					nil,
+					"could not evaluate caveat `testcaveat`: index out of bounds: 1",
+				},
+				{
+					"document:foo#view@user:sarah",
+					map[string]any{
+						"allowed": []any{"42", "a"},
+					},
+					v1.ResourceCheckResult_NOT_MEMBER,
+					nil,
+					"type error for parameters for caveat `testcaveat`: could not convert context parameter `allowed`: for list<int>: found an invalid value for item at index 1: for int: a int64 value is required, but found invalid string value `a`",
+				},
+				{
+					"document:foo#view@user:sarah",
+					map[string]any{
+						"allowed": map[string]any{"1": 42},
+					},
+					v1.ResourceCheckResult_NOT_MEMBER,
+					nil,
+					"type error for parameters for caveat `testcaveat`: could not convert context parameter `allowed`: for list<int>: list requires a list, found: map[string]interface {}",
+				},
+			},
+		},
 		{
 			"context type error test",
 			`definition user {}
@@ -930,6 +1006,371 @@ func TestComputeBulkCheck(t *testing.T) {
 	require.Equal(t, resp["third"].Membership, v1.ResourceCheckResult_NOT_MEMBER)
 }
 
+func TestComputeCheckIgnoringCaveats(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation viewer: user | user with somecaveat
+		relation banned: user | user with somecaveat
+		permission view = viewer - banned
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:direct#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:unsatisfied#viewer@user:tom", "somecaveat", map[string]any{
+			"somecondition": 32,
+		}},
+		{core.RelationTupleUpdate_CREATE, "document:missingcontext#viewer@user:tom", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:banned#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:banned#banned@user:tom", "somecaveat", map[string]any{
+			"somecondition": 32,
+		}},
+	})
+	require.NoError(t, err)
+
+	resourceIDs := []string{"direct", "unsatisfied", "missingcontext", "banned", "unknown"}
+	check := func(ignoreCaveats bool) map[string]*v1.ResourceCheckResult {
+		resp, _, err := computed.ComputeBulkCheck(ctx, dispatch,
+			computed.CheckParameters{
+				ResourceType: &core.RelationReference{
+					Namespace: "document",
+					Relation:  "view",
+				},
+				Subject: &core.ObjectAndRelation{
+					Namespace: "user",
+					ObjectId:  "tom",
+					Relation:  "...",
+				},
+				CaveatContext: nil,
+				AtRevision:    revision,
+				MaximumDepth:  50,
+				DebugOption:   computed.BasicDebuggingEnabled,
+				IgnoreCaveats: ignoreCaveats,
+			},
+			resourceIDs,
+		)
+		require.NoError(t, err)
+		return resp
+	}
+
+	evaluated := check(false)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, evaluated["direct"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, evaluated["unsatisfied"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_CAVEATED_MEMBER, evaluated["missingcontext"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, evaluated["banned"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, evaluated["unknown"].Membership)
+
+	ignored := check(true)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, ignored["direct"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, ignored["unsatisfied"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, ignored["missingcontext"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, ignored["banned"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, ignored["unknown"].Membership)
+}
+
+type countingHeadRevisionDatastore struct {
+	datastore.Datastore
+	headRevisionCalls int
+}
+
+func (cd *countingHeadRevisionDatastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	cd.headRevisionCalls++
+	return cd.Datastore.HeadRevision(ctx)
+}
+
+func TestComputeSubjectsCheckAtHead(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds := &countingHeadRevisionDatastore{Datastore: rawDS}
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	writtenRevision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation viewer: user | user with somecaveat
+		permission view = viewer
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:sarah", "somecaveat", map[string]any{}},
+	})
+	require.NoError(t, err)
+
+	subjects := []*core.ObjectAndRelation{
+		tuple.ParseSubjectONR("user:tom"),
+		tuple.ParseSubjectONR("user:sarah"),
+		tuple.ParseSubjectONR("user:fred"),
+	}
+
+	results, checkedAt, _, err := computed.ComputeSubjectsCheckAtHead(ctx, dispatch,
+		computed.HeadRevisionCheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: "document",
+				Relation:  "view",
+			},
+			CaveatContext: nil,
+			MaximumDepth:  50,
+			DebugOption:   computed.NoDebugging,
+		},
+		"somedoc",
+		subjects,
+	)
+	require.NoError(t, err)
+	require.True(t, checkedAt.Equal(writtenRevision))
+
+	// The head revision is computed once for the whole batch.
+	require.Equal(t, 1, ds.headRevisionCalls)
+
+	require.Len(t, results, 3)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["user:tom"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_CAVEATED_MEMBER, results["user:sarah"].Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, results["user:fred"].Membership)
+}
+
+// laggingHeadRevisionDatastore reports a fixed head revision, as would a lagging replica.
+type laggingHeadRevisionDatastore struct {
+	datastore.Datastore
+	headRevision datastore.Revision
+}
+
+func (ld *laggingHeadRevisionDatastore) HeadRevision(_ context.Context) (datastore.Revision, error) {
+	return ld.headRevision, nil
+}
+
+func TestComputeSubjectsCheckAtHeadWithMinimumRevision(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, 24*time.Hour)
+	require.NoError(t, err)
+
+	ds := &laggingHeadRevisionDatastore{Datastore: rawDS}
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	schema := `
+	definition user {}
+
+	definition document {
+		relation viewer: user
+		permission view = viewer
+	}
+	`
+	laggingRevision, err := writeCaveatedTuples(ctx, t, rawDS, schema, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:fred", "", nil},
+	})
+	require.NoError(t, err)
+	ds.headRevision = laggingRevision
+
+	writtenRevision, err := writeCaveatedTuples(ctx, t, rawDS, schema, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:tom", "", nil},
+	})
+	require.NoError(t, err)
+
+	check := func(minimumRevision datastore.Revision) (map[string]*v1.ResourceCheckResult, datastore.Revision, error) {
+		results, checkedAt, _, err := computed.ComputeSubjectsCheckAtHead(ctx, dispatch,
+			computed.HeadRevisionCheckParameters{
+				ResourceType: &core.RelationReference{
+					Namespace: "document",
+					Relation:  "view",
+				},
+				MaximumDepth:    50,
+				DebugOption:     computed.NoDebugging,
+				MinimumRevision: minimumRevision,
+			},
+			"somedoc",
+			[]*core.ObjectAndRelation{tuple.ParseSubjectONR("user:tom")},
+		)
+		return results, checkedAt, err
+	}
+
+	// Without a minimum revision, the check is performed at the lagging head.
+	results, checkedAt, err := check(datastore.NoRevision)
+	require.NoError(t, err)
+	require.True(t, checkedAt.Equal(laggingRevision))
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, results["user:tom"].Membership)
+
+	// With the revision of the write as the minimum, the write is read.
+	results, checkedAt, err = check(writtenRevision)
+	require.NoError(t, err)
+	require.True(t, checkedAt.Equal(writtenRevision))
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["user:tom"].Membership)
+
+	// A minimum revision earlier than the head does not move the check backwards.
+	ds.headRevision = writtenRevision
+	_, checkedAt, err = check(laggingRevision)
+	require.NoError(t, err)
+	require.True(t, checkedAt.Equal(writtenRevision))
+
+	// A minimum revision outside of the GC window is rejected.
+	_, _, err = check(revision.NewFromDecimal(decimal.NewFromInt(1)))
+	require.ErrorAs(t, err, &datastore.ErrInvalidRevision{})
+}
+
+// slowExclusionDispatcher delays checks of the `banned` relation until the context is done, and
+// records whether the dispatched checks of the relation carried a soft deadline.
+type slowExclusionDispatcher struct {
+	dispatch.Dispatcher
+
+	bannedWithSoftDeadline atomic.Bool
+}
+
+func (sd *slowExclusionDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	if req.ResourceRelation.Relation == "banned" {
+		sd.bannedWithSoftDeadline.Store(req.Metadata.GetSoftDeadline() != nil)
+		<-ctx.Done()
+		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, ctx.Err()
+	}
+	return sd.Dispatcher.DispatchCheck(ctx, req)
+}
+
+func TestComputeBestEffortCheck(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	slow := &slowExclusionDispatcher{}
+	slow.Dispatcher = graph.NewDispatcher(slow, graph.SharedConcurrencyLimits(10))
+
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	definition document {
+		relation viewer: user
+		relation banned: user
+		permission view = viewer - banned
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#banned@user:tom", "", nil},
+	})
+	require.NoError(t, err)
+
+	params := func(subject string) computed.CheckParameters {
+		return computed.CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: "document",
+				Relation:  "view",
+			},
+			Subject:      tuple.ParseSubjectONR(subject),
+			AtRevision:   revision,
+			MaximumDepth: 50,
+			DebugOption:  computed.NoDebugging,
+		}
+	}
+
+	// The exclusion is not resolved by the soft deadline, so the subject is a probable member.
+	result, _, complete, err := computed.ComputeBestEffortCheck(ctx, slow, params("user:tom"), "somedoc", time.Now().Add(50*time.Millisecond))
+	require.NoError(t, err)
+	require.False(t, complete)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, result.Membership)
+
+	// The soft deadline is carried by the dispatched requests, so that it survives dispatch to
+	// other nodes.
+	require.True(t, slow.bannedWithSoftDeadline.Load())
+
+	// A subject not found in the base set needs no exclusion to be resolved.
+	result, _, complete, err = computed.ComputeBestEffortCheck(ctx, slow, params("user:sarah"), "somedoc", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, result.Membership)
+
+	// Without a soft deadline, the check fails at the hard deadline.
+	hardCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = computed.ComputeCheck(hardCtx, slow, params("user:tom"), "somedoc")
+	require.Error(t, err)
+}
+
+func TestComputeOverlayCheck(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition group {
+		relation member: user
+	}
+
+	definition document {
+		relation viewer: user | user with somecaveat | group#member
+		permission view = viewer
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:somedoc#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "group:eng#member@user:sarah", "", nil},
+	})
+	require.NoError(t, err)
+
+	overlay := []*core.RelationTupleUpdate{
+		tuple.Delete(tuple.MustParse("document:somedoc#viewer@user:tom")),
+		tuple.Create(tuple.MustParse("document:somedoc#viewer@group:eng#member")),
+		tuple.Touch(caveatedRelationTuple("document:somedoc#viewer@user:fred", "somecaveat", map[string]any{})),
+	}
+
+	params := func(subject string) computed.CheckParameters {
+		return computed.CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: "document",
+				Relation:  "view",
+			},
+			Subject:       tuple.ParseSubjectONR(subject),
+			CaveatContext: nil,
+			AtRevision:    revision,
+			MaximumDepth:  50,
+			DebugOption:   computed.NoDebugging,
+		}
+	}
+
+	expected := map[string]v1.ResourceCheckResult_Membership{
+		"user:tom":   v1.ResourceCheckResult_NOT_MEMBER,
+		"user:sarah": v1.ResourceCheckResult_MEMBER,
+		"user:fred":  v1.ResourceCheckResult_CAVEATED_MEMBER,
+	}
+	for subject, membership := range expected {
+		result, _, err := computed.ComputeOverlayCheck(ctx, dispatch, params(subject), "somedoc", overlay)
+		require.NoError(t, err)
+		require.Equal(t, membership, result.Membership, subject)
+	}
+
+	// The overlay is not written.
+	result, _, err := computed.ComputeCheck(ctx, dispatch, params("user:tom"), "somedoc")
+	require.NoError(t, err)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, result.Membership)
+
+	result, _, err = computed.ComputeCheck(ctx, dispatch, params("user:sarah"), "somedoc")
+	require.NoError(t, err)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, result.Membership)
+}
+
 func writeCaveatedTuples(ctx context.Context, t *testing.T, ds datastore.Datastore, schema string, updates []caveatedUpdate) (datastore.Revision, error) {
 	empty := ""
 	compiled, err := compiler.Compile(compiler.InputSchema{
@@ -0,0 +1,96 @@
+package computed
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// PermissionsCheckParameters are the parameters for the ComputePermissionsCheck call. *All*
+// are required.
+type PermissionsCheckParameters struct {
+	ResourceType  string
+	ResourceID    string
+	Subject       *core.ObjectAndRelation
+	CaveatContext map[string]any
+	AtRevision    datastore.Revision
+	MaximumDepth  uint32
+}
+
+// PermissionCheckResult is the outcome of checking a single permission in a call to
+// ComputePermissionsCheck. Exactly one of its fields is set.
+type PermissionCheckResult struct {
+	// Result is the check result for the permission.
+	Result *v1.ResourceCheckResult
+
+	// Err is the reason the permission could not be checked. It is a namespace.ErrRelationNotFound
+	// if the permission is not defined on the resource type, which the API surfaces as a
+	// FailedPrecondition for that permission alone.
+	Err error
+}
+
+// ComputePermissionsCheck computes a check result for each of the given permissions of a single
+// resource for a single subject, computing any caveat expressions found. Unlike the other bulk
+// checks, it is the permission which varies, rather than the resource or the subject.
+//
+// The permissions are checked one after another against the same revision, rather than
+// concurrently, so that subproblems shared between their rewrites, such as an owner relation
+// found in both edit and delete, are computed by the first check and then served from the
+// dispatch cache for the checks which follow.
+//
+// The returned map is keyed by permission; duplicate permissions are checked once. An unknown
+// resource type fails the whole call, whereas an unknown permission only fails its own result.
+func ComputePermissionsCheck(
+	ctx context.Context,
+	d dispatch.Check,
+	params PermissionsCheckParameters,
+	permissions []string,
+) (map[string]PermissionCheckResult, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+
+	reader := datastoremw.MustFromContext(ctx).SnapshotReader(params.AtRevision)
+	_, ts, err := namespace.ReadNamespaceAndTypes(ctx, params.ResourceType, reader)
+	if err != nil {
+		return nil, respMetadata, err
+	}
+
+	results := make(map[string]PermissionCheckResult, len(permissions))
+	for _, permission := range permissions {
+		if _, ok := results[permission]; ok {
+			continue
+		}
+
+		if !ts.HasRelation(permission) {
+			results[permission] = PermissionCheckResult{
+				Err: namespace.NewRelationNotFoundErr(params.ResourceType, permission),
+			}
+			continue
+		}
+
+		result, meta, err := ComputeCheck(ctx, d, CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: params.ResourceType,
+				Relation:  permission,
+			},
+			Subject:       params.Subject,
+			CaveatContext: params.CaveatContext,
+			AtRevision:    params.AtRevision,
+			MaximumDepth:  params.MaximumDepth,
+		}, params.ResourceID)
+		if meta != nil {
+			dispatch.AddResponseMetadata(respMetadata, meta)
+		}
+		if err != nil {
+			return nil, respMetadata, err
+		}
+
+		results[permission] = PermissionCheckResult{Result: result}
+	}
+
+	return results, respMetadata, nil
+}
@@ -0,0 +1,148 @@
+package computed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/dispatch/keys"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputePermissionsCheck(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation owner: user
+		relation editor: user
+		relation viewer: user | user with somecaveat
+		permission view = viewer + edit
+		permission edit = editor + owner
+		permission delete = owner
+		permission share = owner & editor
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:first#editor@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:first#viewer@user:sarah", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:first#owner@user:sarah", "", nil},
+	})
+	require.NoError(t, err)
+
+	check := func(subject string, permissions ...string) map[string]computed.PermissionCheckResult {
+		results, _, err := computed.ComputePermissionsCheck(ctx, dispatch,
+			computed.PermissionsCheckParameters{
+				ResourceType:  "document",
+				ResourceID:    "first",
+				Subject:       tuple.ParseSubjectONR(subject),
+				CaveatContext: nil,
+				AtRevision:    revision,
+				MaximumDepth:  50,
+			},
+			permissions,
+		)
+		require.NoError(t, err)
+		return results
+	}
+
+	results := check("user:tom", "view", "edit", "delete", "share", "view")
+	require.Len(t, results, 4)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["view"].Result.Membership)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["edit"].Result.Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, results["delete"].Result.Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, results["share"].Result.Membership)
+
+	// The caveated viewer relationship does not matter, as the owner can view unconditionally.
+	results = check("user:sarah", "view", "delete", "share")
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["view"].Result.Membership)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["delete"].Result.Membership)
+	require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, results["share"].Result.Membership)
+
+	// An unknown permission only fails its own result.
+	results = check("user:tom", "edit", "unknown")
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, results["edit"].Result.Membership)
+	require.Nil(t, results["unknown"].Result)
+	require.ErrorAs(t, results["unknown"].Err, &namespace.ErrRelationNotFound{})
+
+	// An unknown resource type fails the whole call.
+	_, _, err = computed.ComputePermissionsCheck(ctx, dispatch,
+		computed.PermissionsCheckParameters{
+			ResourceType: "unknown",
+			ResourceID:   "first",
+			Subject:      tuple.ParseSubjectONR("user:tom"),
+			AtRevision:   revision,
+			MaximumDepth: 50,
+		},
+		[]string{"view"},
+	)
+	require.Error(t, err)
+}
+
+func TestComputePermissionsCheckSharesDispatches(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	cachingDispatcher, err := caching.NewCachingDispatcher(caching.DispatchTestCache(t), "", &keys.CanonicalKeyHandler{})
+	require.NoError(t, err)
+	cachingDispatcher.SetDelegate(graph.NewDispatcher(cachingDispatcher, graph.SharedConcurrencyLimits(10)))
+
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	definition document {
+		relation owner: user
+		relation editor: user
+		permission edit = editor + owner
+		permission delete = owner
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:first#owner@user:sarah", "", nil},
+	})
+	require.NoError(t, err)
+
+	check := func(permissions ...string) *v1.ResponseMeta {
+		results, meta, err := computed.ComputePermissionsCheck(ctx, cachingDispatcher,
+			computed.PermissionsCheckParameters{
+				ResourceType: "document",
+				ResourceID:   "first",
+				Subject:      tuple.ParseSubjectONR("user:sarah"),
+				AtRevision:   revision,
+				MaximumDepth: 50,
+			},
+			permissions,
+		)
+		require.NoError(t, err)
+		for _, permission := range permissions {
+			require.Equal(t, v1.ResourceCheckResult_MEMBER, results[permission].Result.Membership)
+		}
+		return meta
+	}
+
+	// Checking delete after edit is served by the owner subproblem computed for edit.
+	meta := check("edit", "delete")
+	require.Equal(t, uint32(1), meta.CachedDispatchCount)
+}
@@ -0,0 +1,72 @@
+package computed
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// SubjectRelationsParameters are the parameters for the ComputeSubjectRelations call. *All* are
+// required.
+type SubjectRelationsParameters struct {
+	ResourceType  string
+	Subject       *core.ObjectAndRelation
+	CaveatContext map[string]any
+	AtRevision    datastore.Revision
+	MaximumDepth  uint32
+}
+
+// ComputeSubjectRelations computes every relation and permission of the resource's definition
+// under which the subject has access to the resource, computing any caveat expressions found.
+// This answers "what can the subject do on this resource", rather than a single check.
+//
+// Each relation and permission of the definition is checked once, including those computed from
+// other relations, such as permissions and aliases; subproblems shared between them are resolved
+// by the dispatcher, and so benefit from its caching.
+//
+// The returned map is keyed by relation or permission name, and only holds those under which the
+// subject is a member, whether conditionally or otherwise.
+func ComputeSubjectRelations(
+	ctx context.Context,
+	d dispatch.Check,
+	params SubjectRelationsParameters,
+	resourceID string,
+) (map[string]*v1.ResourceCheckResult, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(params.AtRevision)
+	nsDef, _, err := ds.ReadNamespace(ctx, params.ResourceType)
+	if err != nil {
+		return nil, respMetadata, err
+	}
+
+	results := make(map[string]*v1.ResourceCheckResult, len(nsDef.Relation))
+	for _, relation := range nsDef.Relation {
+		result, meta, err := ComputeCheck(ctx, d, CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: params.ResourceType,
+				Relation:  relation.Name,
+			},
+			Subject:       params.Subject,
+			CaveatContext: params.CaveatContext,
+			AtRevision:    params.AtRevision,
+			MaximumDepth:  params.MaximumDepth,
+		}, resourceID)
+		if meta != nil {
+			dispatch.AddResponseMetadata(respMetadata, meta)
+		}
+		if err != nil {
+			return nil, respMetadata, err
+		}
+
+		if result.Membership != v1.ResourceCheckResult_NOT_MEMBER {
+			results[relation.Name] = result
+		}
+	}
+
+	return results, respMetadata, nil
+}
@@ -0,0 +1,131 @@
+package computed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputeSubjectRelations(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition folder {
+		relation viewer: user
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user | user with somecaveat
+		relation editor: user
+		relation banned: user
+		permission edit = editor - banned
+		permission view = viewer + edit + parent->viewer
+		permission read = view
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:plans#parent@folder:shared", "", nil},
+		{core.RelationTupleUpdate_CREATE, "folder:shared#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:plans#editor@user:sarah", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:plans#editor@user:fred", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:plans#banned@user:fred", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:plans#viewer@user:amy", "somecaveat", map[string]any{}},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		subject  string
+		expected map[string]v1.ResourceCheckResult_Membership
+	}{
+		{
+			"user:tom",
+			map[string]v1.ResourceCheckResult_Membership{
+				"view": v1.ResourceCheckResult_MEMBER,
+				"read": v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		{
+			"user:sarah",
+			map[string]v1.ResourceCheckResult_Membership{
+				"editor": v1.ResourceCheckResult_MEMBER,
+				"edit":   v1.ResourceCheckResult_MEMBER,
+				"view":   v1.ResourceCheckResult_MEMBER,
+				"read":   v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		{
+			"user:fred",
+			map[string]v1.ResourceCheckResult_Membership{
+				"editor": v1.ResourceCheckResult_MEMBER,
+				"banned": v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		{
+			"user:amy",
+			map[string]v1.ResourceCheckResult_Membership{
+				"viewer": v1.ResourceCheckResult_CAVEATED_MEMBER,
+				"view":   v1.ResourceCheckResult_CAVEATED_MEMBER,
+				"read":   v1.ResourceCheckResult_CAVEATED_MEMBER,
+			},
+		},
+		{
+			"user:unknown",
+			map[string]v1.ResourceCheckResult_Membership{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.subject, func(t *testing.T) {
+			results, _, err := computed.ComputeSubjectRelations(ctx, dispatch,
+				computed.SubjectRelationsParameters{
+					ResourceType:  "document",
+					Subject:       tuple.ParseSubjectONR(tc.subject),
+					CaveatContext: nil,
+					AtRevision:    revision,
+					MaximumDepth:  50,
+				},
+				"plans",
+			)
+			require.NoError(t, err)
+
+			found := make(map[string]v1.ResourceCheckResult_Membership, len(results))
+			for relationName, result := range results {
+				found[relationName] = result.Membership
+			}
+			require.Equal(t, tc.expected, found)
+		})
+	}
+
+	_, _, err = computed.ComputeSubjectRelations(ctx, dispatch,
+		computed.SubjectRelationsParameters{
+			ResourceType: "unknown",
+			Subject:      tuple.ParseSubjectONR("user:tom"),
+			AtRevision:   revision,
+			MaximumDepth: 50,
+		},
+		"plans",
+	)
+	require.Error(t, err)
+}
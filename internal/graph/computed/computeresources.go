@@ -0,0 +1,75 @@
+package computed
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// BulkResourcesCheckParameters are the parameters for the ComputeBulkResourcesCheck call. *All*
+// are required.
+type BulkResourcesCheckParameters struct {
+	ResourceType  *core.RelationReference
+	Subject       *core.ObjectAndRelation
+	CaveatContext map[string]any
+	AtRevision    datastore.Revision
+	MaximumDepth  uint32
+}
+
+// ComputeBulkResourcesCheck computes a check result for the subject against each of the given
+// resources of a single type and permission, computing any caveat expressions found. It is the
+// mirror of ComputeBulkSubjectsCheck: the resources are supplied by the caller, rather than
+// enumerated as in a lookup.
+//
+// Rather than dispatching a check per resource, the resources are dispatched together in
+// batches, so that subproblems common to the resources of a batch, such as the membership of a
+// shared parent folder, are computed once. A wildcard granting the permission to all subjects of
+// the subject's type makes every resource it is found on accessible.
+//
+// The returned map is keyed by resource ID; duplicate resource IDs are checked once.
+func ComputeBulkResourcesCheck(
+	ctx context.Context,
+	d dispatch.Check,
+	params BulkResourcesCheckParameters,
+	resourceIDs []string,
+) (map[string]*v1.ResourceCheckResult, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+	results := make(map[string]*v1.ResourceCheckResult, len(resourceIDs))
+
+	uniqueResourceIDs := util.NewSet[string](resourceIDs...).AsSlice()
+
+	var checkErr error
+	util.ForEachChunk(uniqueResourceIDs, datastore.FilterMaximumIDCount, func(resourceIDsChunk []string) {
+		if checkErr != nil {
+			return
+		}
+
+		chunkResults, meta, err := computeCheck(ctx, d, CheckParameters{
+			ResourceType:  params.ResourceType,
+			Subject:       params.Subject,
+			CaveatContext: params.CaveatContext,
+			AtRevision:    params.AtRevision,
+			MaximumDepth:  params.MaximumDepth,
+		}, resourceIDsChunk)
+		if meta != nil {
+			dispatch.AddResponseMetadata(respMetadata, meta)
+		}
+		if err != nil {
+			checkErr = err
+			return
+		}
+
+		for resourceID, result := range chunkResults {
+			results[resourceID] = result
+		}
+	})
+	if checkErr != nil {
+		return nil, respMetadata, checkErr
+	}
+
+	return results, respMetadata, nil
+}
@@ -0,0 +1,116 @@
+package computed_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputeBulkResourcesCheck(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	updates := []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "folder:shared#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:direct#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#viewer@user:*", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:caveated#viewer@user:tom", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:banned#parent@folder:shared", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:banned#banned@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:other#viewer@user:sarah", "", nil},
+	}
+
+	// Place more documents within the shared folder than fit within a single dispatch.
+	expected := map[string]v1.ResourceCheckResult_Membership{
+		"direct":   v1.ResourceCheckResult_MEMBER,
+		"public":   v1.ResourceCheckResult_MEMBER,
+		"caveated": v1.ResourceCheckResult_CAVEATED_MEMBER,
+		"banned":   v1.ResourceCheckResult_NOT_MEMBER,
+		"other":    v1.ResourceCheckResult_NOT_MEMBER,
+		"unknown":  v1.ResourceCheckResult_NOT_MEMBER,
+	}
+	for i := 0; i < 250; i++ {
+		resourceID := fmt.Sprintf("infolder%d", i)
+		updates = append(updates, caveatedUpdate{core.RelationTupleUpdate_CREATE, "document:" + resourceID + "#parent@folder:shared", "", nil})
+		expected[resourceID] = v1.ResourceCheckResult_MEMBER
+	}
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition folder {
+		relation viewer: user
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user | user:* | user with somecaveat
+		relation banned: user
+		permission view = (viewer + parent->viewer) - banned
+	}
+	`, updates)
+	require.NoError(t, err)
+
+	resourceIDs := make([]string, 0, len(expected)+1)
+	for resourceID := range expected {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+
+	// Duplicates are checked once.
+	resourceIDs = append(resourceIDs, "direct")
+
+	resourceType := &core.RelationReference{Namespace: "document", Relation: "view"}
+	subject := tuple.ParseSubjectONR("user:tom")
+
+	results, _, err := computed.ComputeBulkResourcesCheck(ctx, dispatch,
+		computed.BulkResourcesCheckParameters{
+			ResourceType:  resourceType,
+			Subject:       subject,
+			CaveatContext: nil,
+			AtRevision:    revision,
+			MaximumDepth:  50,
+		},
+		resourceIDs,
+	)
+	require.NoError(t, err)
+	require.Len(t, results, len(expected))
+
+	for resourceID, membership := range expected {
+		result, ok := results[resourceID]
+		require.True(t, ok, "missing result for %s", resourceID)
+		require.Equal(t, membership, result.Membership, "unexpected membership for %s", resourceID)
+
+		// Ensure the bulk result matches that of a standard check.
+		checkResult, _, err := computed.ComputeCheck(ctx, dispatch,
+			computed.CheckParameters{
+				ResourceType:  resourceType,
+				Subject:       subject,
+				CaveatContext: nil,
+				AtRevision:    revision,
+				MaximumDepth:  50,
+			},
+			resourceID,
+		)
+		require.NoError(t, err)
+		require.Equal(t, checkResult.Membership, result.Membership, "mismatch with check for %s", resourceID)
+	}
+}
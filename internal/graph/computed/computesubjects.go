@@ -0,0 +1,211 @@
+package computed
+
+import (
+	"context"
+	"fmt"
+
+	cexpr "github.com/authzed/spicedb/internal/caveats"
+	"github.com/authzed/spicedb/internal/dispatch"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// BulkSubjectsCheckParameters are the parameters for the ComputeBulkSubjectsCheck call. *All*
+// are required.
+type BulkSubjectsCheckParameters struct {
+	Resource      *core.ObjectAndRelation
+	CaveatContext map[string]any
+	AtRevision    datastore.Revision
+	MaximumDepth  uint32
+}
+
+// ComputeBulkSubjectsCheck computes a check result for each of the given subjects against a
+// single resource and permission, computing any caveat expressions found. Rather than
+// dispatching a check per subject, the subjects of the resource are looked up once per
+// subject type and shared amongst all of the requested subjects of that type.
+//
+// The returned map is keyed by the string form of each subject, as per tuple.StringONR.
+func ComputeBulkSubjectsCheck(
+	ctx context.Context,
+	d dispatch.LookupSubjects,
+	params BulkSubjectsCheckParameters,
+	subjects []*core.ObjectAndRelation,
+) (map[string]*v1.ResourceCheckResult, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+
+	// Group the subjects by their type and relation, so that a single lookup can be
+	// performed for each.
+	subjectsByType := make(map[string][]*core.ObjectAndRelation)
+	subjectTypes := make([]*core.RelationReference, 0)
+	for _, subject := range subjects {
+		key := tuple.StringRR(&core.RelationReference{Namespace: subject.Namespace, Relation: subject.Relation})
+		if _, ok := subjectsByType[key]; !ok {
+			subjectTypes = append(subjectTypes, &core.RelationReference{
+				Namespace: subject.Namespace,
+				Relation:  subject.Relation,
+			})
+		}
+		subjectsByType[key] = append(subjectsByType[key], subject)
+	}
+
+	reader := datastoremw.MustFromContext(ctx).SnapshotReader(params.AtRevision)
+
+	results := make(map[string]*v1.ResourceCheckResult, len(subjects))
+	for _, subjectType := range subjectTypes {
+		foundSubjects, err := lookupFoundSubjects(ctx, d, params, subjectType, respMetadata)
+		if err != nil {
+			return nil, respMetadata, err
+		}
+
+		for _, subject := range subjectsByType[tuple.StringRR(subjectType)] {
+			computed, err := computeSubjectMembership(ctx, params, reader, foundSubjects, subject.ObjectId)
+			if err != nil {
+				return nil, respMetadata, err
+			}
+			results[tuple.StringONR(subject)] = computed
+		}
+	}
+
+	return results, respMetadata, nil
+}
+
+// lookupFoundSubjects dispatches a single lookup of the subjects of the given type for the
+// resource, returning the found subjects indexed by subject ID. A subject ID may be found more
+// than once, in which case it is a member if any of the found entries apply.
+func lookupFoundSubjects(
+	ctx context.Context,
+	d dispatch.LookupSubjects,
+	params BulkSubjectsCheckParameters,
+	subjectType *core.RelationReference,
+	respMetadata *v1.ResponseMeta,
+) (map[string][]*v1.FoundSubject, error) {
+	foundSubjects := make(map[string][]*v1.FoundSubject)
+	stream := dispatch.NewHandlingDispatchStream(ctx, func(result *v1.DispatchLookupSubjectsResponse) error {
+		found, ok := result.FoundSubjectsByResourceId[params.Resource.ObjectId]
+		if !ok {
+			return fmt.Errorf("missing resource ID in returned LS")
+		}
+
+		for _, foundSubject := range found.FoundSubjects {
+			foundSubjects[foundSubject.SubjectId] = append(foundSubjects[foundSubject.SubjectId], foundSubject)
+		}
+
+		dispatch.AddResponseMetadata(respMetadata, result.Metadata)
+		return nil
+	})
+
+	err := d.DispatchLookupSubjects(&v1.DispatchLookupSubjectsRequest{
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     params.AtRevision.String(),
+			DepthRemaining: params.MaximumDepth,
+		},
+		ResourceRelation: &core.RelationReference{
+			Namespace: params.Resource.Namespace,
+			Relation:  params.Resource.Relation,
+		},
+		ResourceIds:     []string{params.Resource.ObjectId},
+		SubjectRelation: subjectType,
+	}, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	return foundSubjects, nil
+}
+
+// computeSubjectMembership computes the membership of a single subject ID from the set of
+// subjects found for the resource, taking into account wildcards and their exclusions.
+func computeSubjectMembership(
+	ctx context.Context,
+	params BulkSubjectsCheckParameters,
+	reader datastore.Reader,
+	foundSubjects map[string][]*v1.FoundSubject,
+	subjectID string,
+) (*v1.ResourceCheckResult, error) {
+	var expr *core.CaveatExpression
+	isMember := false
+	addPath := func(pathExpr *core.CaveatExpression) {
+		if !isMember {
+			expr = pathExpr
+			isMember = true
+			return
+		}
+		expr = cexpr.ShortcircuitedOr(expr, pathExpr)
+	}
+
+	for _, found := range foundSubjects[subjectID] {
+		addPath(found.CaveatExpression)
+	}
+
+	if subjectID != tuple.PublicWildcard {
+		for _, wildcard := range foundSubjects[tuple.PublicWildcard] {
+			if wildcardExpr, isExcluded := wildcardExpressionForSubject(wildcard, subjectID); !isExcluded {
+				addPath(wildcardExpr)
+			}
+		}
+	}
+
+	if !isMember {
+		return &v1.ResourceCheckResult{
+			Membership: v1.ResourceCheckResult_NOT_MEMBER,
+		}, nil
+	}
+
+	if expr == nil {
+		return &v1.ResourceCheckResult{
+			Membership: v1.ResourceCheckResult_MEMBER,
+		}, nil
+	}
+
+	return computeCaveatedMembership(ctx, expr, params.CaveatContext, nil, reader)
+}
+
+// wildcardExpressionForSubject returns the caveat expression under which the wildcard applies
+// to the given subject ID, or true if the subject is unconditionally excluded from it.
+func wildcardExpressionForSubject(wildcard *v1.FoundSubject, subjectID string) (*core.CaveatExpression, bool) {
+	expr := wildcard.CaveatExpression
+	for _, excluded := range wildcard.ExcludedSubjects {
+		if excluded.SubjectId != subjectID {
+			continue
+		}
+
+		if excluded.CaveatExpression == nil {
+			return nil, true
+		}
+
+		expr = cexpr.Subtract(expr, excluded.CaveatExpression)
+	}
+	return expr, false
+}
+
+// ComputeMemberSubjects returns the subset of the given candidate subjects that are members of
+// the resource and permission, in the order in which they were given. As with
+// ComputeBulkSubjectsCheck, the subjects of the resource are looked up once per subject type and
+// intersected with the candidates, so a wildcard found for the resource makes every candidate of
+// its type a member, excluding any subjects excluded from the wildcard.
+//
+// Candidates whose membership depends upon caveat context that was not provided are not
+// considered members and are not returned.
+func ComputeMemberSubjects(
+	ctx context.Context,
+	d dispatch.LookupSubjects,
+	params BulkSubjectsCheckParameters,
+	candidates []*core.ObjectAndRelation,
+) ([]*core.ObjectAndRelation, *v1.ResponseMeta, error) {
+	results, respMetadata, err := ComputeBulkSubjectsCheck(ctx, d, params, candidates)
+	if err != nil {
+		return nil, respMetadata, err
+	}
+
+	members := make([]*core.ObjectAndRelation, 0, len(candidates))
+	for _, candidate := range candidates {
+		if results[tuple.StringONR(candidate)].Membership == v1.ResourceCheckResult_MEMBER {
+			members = append(members, candidate)
+		}
+	}
+
+	return members, respMetadata, nil
+}
@@ -0,0 +1,223 @@
+package computed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputeBulkSubjectsCheck(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition group {
+		relation member: user
+	}
+
+	definition document {
+		relation viewer: user | user:* | user with somecaveat | group#member
+		relation banned: user | user with somecaveat
+		permission view = viewer - banned
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:public#viewer@user:*", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#banned@user:villain", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#banned@user:maybevillain", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:jill", "somecaveat", map[string]any{
+			"somecondition": 42,
+		}},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:sarah", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:fred", "somecaveat", map[string]any{
+			"somecondition": 32,
+		}},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@group:admins#member", "", nil},
+		{core.RelationTupleUpdate_CREATE, "group:admins#member@user:alice", "", nil},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		resource string
+		expected map[string]v1.ResourceCheckResult_Membership
+	}{
+		{
+			"document:public#view",
+			map[string]v1.ResourceCheckResult_Membership{
+				"user:anyone":       v1.ResourceCheckResult_MEMBER,
+				"user:villain":      v1.ResourceCheckResult_NOT_MEMBER,
+				"user:maybevillain": v1.ResourceCheckResult_CAVEATED_MEMBER,
+				"group:admins":      v1.ResourceCheckResult_NOT_MEMBER,
+			},
+		},
+		{
+			"document:private#view",
+			map[string]v1.ResourceCheckResult_Membership{
+				"user:tom":            v1.ResourceCheckResult_MEMBER,
+				"user:jill":           v1.ResourceCheckResult_MEMBER,
+				"user:sarah":          v1.ResourceCheckResult_CAVEATED_MEMBER,
+				"user:fred":           v1.ResourceCheckResult_NOT_MEMBER,
+				"user:alice":          v1.ResourceCheckResult_MEMBER,
+				"user:unknown":        v1.ResourceCheckResult_NOT_MEMBER,
+				"group:admins#member": v1.ResourceCheckResult_MEMBER,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.resource, func(t *testing.T) {
+			resource := tuple.ParseONR(tc.resource)
+			require.NotNil(t, resource)
+
+			subjects := make([]*core.ObjectAndRelation, 0, len(tc.expected))
+			for subjectStr := range tc.expected {
+				subject := tuple.ParseSubjectONR(subjectStr)
+				require.NotNil(t, subject)
+				subjects = append(subjects, subject)
+			}
+
+			results, _, err := computed.ComputeBulkSubjectsCheck(ctx, dispatch,
+				computed.BulkSubjectsCheckParameters{
+					Resource:      resource,
+					CaveatContext: nil,
+					AtRevision:    revision,
+					MaximumDepth:  50,
+				},
+				subjects,
+			)
+			require.NoError(t, err)
+			require.Len(t, results, len(subjects))
+
+			for _, subject := range subjects {
+				subjectStr := tuple.StringONR(subject)
+				result, ok := results[subjectStr]
+				require.True(t, ok, "missing result for %s", subjectStr)
+				require.Equal(t, tc.expected[subjectStr], result.Membership, "unexpected membership for %s", subjectStr)
+
+				// Ensure the bulk result matches that of a standard check.
+				checkResult, _, err := computed.ComputeCheck(ctx, dispatch,
+					computed.CheckParameters{
+						ResourceType: &core.RelationReference{
+							Namespace: resource.Namespace,
+							Relation:  resource.Relation,
+						},
+						Subject:       subject,
+						CaveatContext: nil,
+						AtRevision:    revision,
+						MaximumDepth:  50,
+					},
+					resource.ObjectId,
+				)
+				require.NoError(t, err)
+				require.Equal(t, checkResult.Membership, result.Membership, "mismatch with check for %s", subjectStr)
+			}
+		})
+	}
+}
+
+func TestComputeMemberSubjects(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation viewer: user | user:* | user with somecaveat
+		relation banned: user
+		permission view = viewer - banned
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:public#viewer@user:*", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#banned@user:villain", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:sarah", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:private#viewer@user:fred", "somecaveat", map[string]any{
+			"somecondition": 42,
+		}},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		resource   string
+		candidates []string
+		expected   []string
+	}{
+		{
+			"document:public#view",
+			[]string{"user:tom", "user:villain", "user:sarah", "user:anyone"},
+			[]string{"user:tom", "user:sarah", "user:anyone"},
+		},
+		{
+			"document:private#view",
+			[]string{"user:unknown", "user:fred", "user:sarah", "user:tom"},
+			[]string{"user:fred", "user:tom"},
+		},
+		{
+			"document:private#view",
+			[]string{"user:unknown"},
+			[]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.resource, func(t *testing.T) {
+			resource := tuple.ParseONR(tc.resource)
+			require.NotNil(t, resource)
+
+			candidates := make([]*core.ObjectAndRelation, 0, len(tc.candidates))
+			for _, candidateStr := range tc.candidates {
+				candidate := tuple.ParseSubjectONR(candidateStr)
+				require.NotNil(t, candidate)
+				candidates = append(candidates, candidate)
+			}
+
+			members, _, err := computed.ComputeMemberSubjects(ctx, dispatch,
+				computed.BulkSubjectsCheckParameters{
+					Resource:      resource,
+					CaveatContext: nil,
+					AtRevision:    revision,
+					MaximumDepth:  50,
+				},
+				candidates,
+			)
+			require.NoError(t, err)
+
+			memberStrs := make([]string, 0, len(members))
+			for _, member := range members {
+				memberStrs = append(memberStrs, tuple.StringONR(member))
+			}
+			require.Equal(t, tc.expected, memberStrs)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package computed
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// EffectiveRelationshipsParameters are the parameters for the ComputeEffectiveRelationships
+// call. *All* are required.
+type EffectiveRelationshipsParameters struct {
+	Resource      *core.ObjectAndRelation
+	CaveatContext map[string]any
+	AtRevision    datastore.Revision
+	MaximumDepth  uint32
+}
+
+// ComputeEffectiveRelationships returns the relationships on the resource which grant it the
+// permission, after the permission's rewrite has been applied. Unlike a raw read of the
+// resource's relationships, relationships whose grant is nullified by the rewrite, such as by an
+// exclusion or an intersection, are not returned, so the returned relationships agree with
+// Check.
+//
+// A relationship is found by walking the branches of the rewrite that can grant the permission,
+// which excludes the subtracted branches of exclusions, and is then returned if a check of the
+// permission succeeds for the subject granted by the relationship:
+//   - for a relation referenced directly, the subject of the relationship
+//   - for a tupleset relation of an arrow, the arrowed relation of the subject of the relationship
+//
+// As checks cannot be performed for wildcards, relationships to a wildcard are returned if found
+// on a branch that can grant the permission. Similarly, a relationship is returned if its grant
+// is only nullified for some of the subjects reached through it, such as a member of a group
+// who is also excluded.
+//
+// Relationships whose grant depends upon caveat context that was not provided are not returned.
+func ComputeEffectiveRelationships(
+	ctx context.Context,
+	d dispatch.Check,
+	params EffectiveRelationshipsParameters,
+) ([]*core.RelationTuple, *v1.ResponseMeta, error) {
+	respMetadata := &v1.ResponseMeta{}
+	reader := datastoremw.MustFromContext(ctx).SnapshotReader(params.AtRevision)
+
+	nsDef, relation, err := namespace.ReadNamespaceAndRelation(ctx, params.Resource.Namespace, params.Resource.Relation, reader)
+	if err != nil {
+		return nil, respMetadata, err
+	}
+
+	gc := &grantCollector{
+		nsDef:    nsDef,
+		grants:   map[string]*relationGrant{},
+		visiting: map[string]struct{}{},
+	}
+	gc.collectRelation(relation)
+
+	var effective []*core.RelationTuple
+	for _, relationName := range gc.order {
+		grant := gc.grants[relationName]
+
+		it, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+			ResourceType:             params.Resource.Namespace,
+			OptionalResourceIds:      []string{params.Resource.ObjectId},
+			OptionalResourceRelation: relationName,
+		})
+		if err != nil {
+			return nil, respMetadata, err
+		}
+
+		var found []*core.RelationTuple
+		for tpl := it.Next(); tpl != nil; tpl = it.Next() {
+			found = append(found, tpl)
+		}
+		it.Close()
+		if it.Err() != nil {
+			return nil, respMetadata, it.Err()
+		}
+
+		for _, tpl := range found {
+			isEffective, err := isEffectiveRelationship(ctx, d, params, grant, tpl, respMetadata)
+			if err != nil {
+				return nil, respMetadata, err
+			}
+
+			if isEffective {
+				effective = append(effective, tpl)
+			}
+		}
+	}
+
+	return effective, respMetadata, nil
+}
+
+// isEffectiveRelationship returns whether the permission is granted for any of the subjects that
+// the relationship grants under the given relation grant.
+func isEffectiveRelationship(
+	ctx context.Context,
+	d dispatch.Check,
+	params EffectiveRelationshipsParameters,
+	grant *relationGrant,
+	tpl *core.RelationTuple,
+	respMetadata *v1.ResponseMeta,
+) (bool, error) {
+	subjects := make([]*core.ObjectAndRelation, 0, len(grant.arrowedRelations)+1)
+	if grant.direct {
+		if tpl.Subject.ObjectId == tuple.PublicWildcard {
+			return true, nil
+		}
+		subjects = append(subjects, tpl.Subject)
+	}
+
+	for _, arrowedRelation := range grant.arrowedRelations {
+		subjects = append(subjects, &core.ObjectAndRelation{
+			Namespace: tpl.Subject.Namespace,
+			ObjectId:  tpl.Subject.ObjectId,
+			Relation:  arrowedRelation,
+		})
+	}
+
+	for _, subject := range subjects {
+		result, meta, err := ComputeCheck(ctx, d, CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: params.Resource.Namespace,
+				Relation:  params.Resource.Relation,
+			},
+			Subject:       subject,
+			CaveatContext: params.CaveatContext,
+			AtRevision:    params.AtRevision,
+			MaximumDepth:  params.MaximumDepth,
+		}, params.Resource.ObjectId)
+		if meta != nil {
+			dispatch.AddResponseMetadata(respMetadata, meta)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if result.Membership == v1.ResourceCheckResult_MEMBER {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// relationGrant describes how the relationships of a relation can grant a permission.
+type relationGrant struct {
+	// direct is true if the subjects of the relationships are granted the permission.
+	direct bool
+
+	// arrowedRelations are the relations, on the subjects of the relationships, which are
+	// granted the permission via an arrow.
+	arrowedRelations []string
+}
+
+// grantCollector collects the relations of a definition whose relationships can grant a
+// permission, in the order in which they are found.
+type grantCollector struct {
+	nsDef    *core.NamespaceDefinition
+	order    []string
+	grants   map[string]*relationGrant
+	visiting map[string]struct{}
+}
+
+func (gc *grantCollector) grantFor(relationName string) *relationGrant {
+	grant, ok := gc.grants[relationName]
+	if !ok {
+		grant = &relationGrant{}
+		gc.grants[relationName] = grant
+		gc.order = append(gc.order, relationName)
+	}
+	return grant
+}
+
+func (gc *grantCollector) collectRelation(relation *core.Relation) {
+	if _, ok := gc.visiting[relation.Name]; ok {
+		return
+	}
+	gc.visiting[relation.Name] = struct{}{}
+
+	if relation.UsersetRewrite == nil {
+		gc.grantFor(relation.Name).direct = true
+		return
+	}
+
+	gc.collectRewrite(relation, relation.UsersetRewrite)
+}
+
+func (gc *grantCollector) collectRewrite(relation *core.Relation, rewrite *core.UsersetRewrite) {
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		gc.collectChildren(relation, rw.Union.Child)
+
+	case *core.UsersetRewrite_Intersection:
+		gc.collectChildren(relation, rw.Intersection.Child)
+
+	case *core.UsersetRewrite_Exclusion:
+		// Only the base of an exclusion can grant the permission.
+		if len(rw.Exclusion.Child) > 0 {
+			gc.collectChildren(relation, rw.Exclusion.Child[:1])
+		}
+	}
+}
+
+func (gc *grantCollector) collectChildren(relation *core.Relation, children []*core.SetOperation_Child) {
+	for _, setOpChild := range children {
+		switch child := setOpChild.ChildType.(type) {
+		case *core.SetOperation_Child_XThis:
+			gc.grantFor(relation.Name).direct = true
+
+		case *core.SetOperation_Child_ComputedUserset:
+			for _, rel := range gc.nsDef.Relation {
+				if rel.Name == child.ComputedUserset.Relation {
+					gc.collectRelation(rel)
+					break
+				}
+			}
+
+		case *core.SetOperation_Child_UsersetRewrite:
+			gc.collectRewrite(relation, child.UsersetRewrite)
+
+		case *core.SetOperation_Child_TupleToUserset:
+			grant := gc.grantFor(child.TupleToUserset.Tupleset.Relation)
+			grant.arrowedRelations = append(grant.arrowedRelations, child.TupleToUserset.ComputedUserset.Relation)
+		}
+	}
+}
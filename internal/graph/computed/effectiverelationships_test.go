@@ -0,0 +1,143 @@
+package computed_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputeEffectiveRelationships(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	dispatch := graph.NewLocalOnlyDispatcher(10)
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition group {
+		relation member: user
+	}
+
+	definition folder {
+		relation viewer: user
+		relation banned: user
+		permission view = viewer - banned
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user | user:* | user with somecaveat | group#member
+		relation editor: user
+		relation banned: user | group#member
+		relation org: user
+		permission edit = editor & org
+		permission view = (viewer + edit + parent->view) - banned
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@user:tom", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@user:villain", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#viewer@user:*", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:public#banned@user:villain", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@user:sarah", "somecaveat", map[string]any{}},
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@user:fred", "somecaveat", map[string]any{
+			"somecondition": 42,
+		}},
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@group:admins#member", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#viewer@group:bannedgroup#member", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#banned@user:villain", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#banned@group:bannedgroup#member", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#editor@user:alice", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#editor@user:bob", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#org@user:alice", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:doc#parent@folder:somefolder", "", nil},
+		{core.RelationTupleUpdate_CREATE, "folder:somefolder#viewer@user:jill", "", nil},
+		{core.RelationTupleUpdate_CREATE, "group:admins#member@user:jill", "", nil},
+		{core.RelationTupleUpdate_CREATE, "document:otherdoc#viewer@user:tom", "", nil},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		resource string
+		expected []string
+	}{
+		{
+			"document:doc#view",
+			[]string{
+				"document:doc#viewer@user:tom",
+				"document:doc#viewer@user:fred[somecaveat:{\"somecondition\":42}]",
+				"document:doc#viewer@group:admins#member",
+				"document:doc#editor@user:alice",
+				"document:doc#org@user:alice",
+				"document:doc#parent@folder:somefolder",
+			},
+		},
+		{
+			"document:doc#edit",
+			[]string{
+				"document:doc#editor@user:alice",
+				"document:doc#org@user:alice",
+			},
+		},
+		{
+			"document:doc#banned",
+			[]string{
+				"document:doc#banned@user:villain",
+				"document:doc#banned@group:bannedgroup#member",
+			},
+		},
+		{
+			"document:public#view",
+			[]string{"document:public#viewer@user:*"},
+		},
+		{
+			"document:unknown#view",
+			[]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.resource, func(t *testing.T) {
+			resource := tuple.ParseONR(tc.resource)
+			require.NotNil(t, resource)
+
+			effective, _, err := computed.ComputeEffectiveRelationships(ctx, dispatch,
+				computed.EffectiveRelationshipsParameters{
+					Resource:      resource,
+					CaveatContext: nil,
+					AtRevision:    revision,
+					MaximumDepth:  50,
+				},
+			)
+			require.NoError(t, err)
+
+			found := make([]string, 0, len(effective))
+			for _, tpl := range effective {
+				found = append(found, tuple.MustString(tpl))
+			}
+
+			expected := make([]string, len(tc.expected))
+			copy(expected, tc.expected)
+			sort.Strings(expected)
+			sort.Strings(found)
+			require.Equal(t, expected, found)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/rs/zerolog"
 
@@ -139,6 +140,65 @@ func NewRelationMissingTypeInfoErr(nsName string, relationName string) error {
 	}
 }
 
+// ErrIntermediateResultLimitExceeded occurs when a single step of a reachable resources walk
+// loads more relationships than the configured maximum.
+type ErrIntermediateResultLimitExceeded struct {
+	error
+	namespaceName string
+	relationName  string
+	limit         uint32
+}
+
+func (err ErrIntermediateResultLimitExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("relation", err.relationName).Uint32("limit", err.limit)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrIntermediateResultLimitExceeded) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"relation_name":   err.relationName,
+		"limit":           strconv.FormatUint(uint64(err.limit), 10),
+	}
+}
+
+// NewIntermediateResultLimitExceededErr constructs a new intermediate result limit exceeded error.
+func NewIntermediateResultLimitExceededErr(nsName string, relationName string, limit uint32) error {
+	return ErrIntermediateResultLimitExceeded{
+		error:         fmt.Errorf("found more than %d relationships for relation `%s` under definition `%s` in a single step of the lookup", limit, relationName, nsName),
+		namespaceName: nsName,
+		relationName:  relationName,
+		limit:         limit,
+	}
+}
+
+// ErrLeafSubjectLimitExceeded occurs when an expansion finds more subjects in the leaves of its
+// tree than the maximum requested.
+type ErrLeafSubjectLimitExceeded struct {
+	error
+	leafSubjectCount uint64
+	limit            uint32
+}
+
+func (err ErrLeafSubjectLimitExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Uint64("leafSubjectCount", err.leafSubjectCount).Uint32("limit", err.limit)
+}
+
+// LeafSubjectCount returns the number of leaf subjects found when the expansion was stopped,
+// which is a lower bound on the number of leaf subjects of the full expansion.
+func (err ErrLeafSubjectLimitExceeded) LeafSubjectCount() uint64 {
+	return err.leafSubjectCount
+}
+
+// NewLeafSubjectLimitExceededErr constructs a new leaf subject limit exceeded error.
+func NewLeafSubjectLimitExceededErr(leafSubjectCount uint64, limit uint32) error {
+	return ErrLeafSubjectLimitExceeded{
+		error:            fmt.Errorf("found %d leaf subjects in the expansion, which is more than the maximum of %d", leafSubjectCount, limit),
+		leafSubjectCount: leafSubjectCount,
+		limit:            limit,
+	}
+}
+
 // ErrInvalidArgument occurs when a request sent has an invalid argument.
 type ErrInvalidArgument struct {
 	error
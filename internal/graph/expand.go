@@ -86,8 +86,18 @@ func (ce *ConcurrentExpander) expandDirect(
 				foundNonTerminalUsersets = append(foundNonTerminalUsersets, ds)
 			}
 		}
+		if it.Err() != nil {
+			resultChan <- expandResultError(NewExpansionFailureErr(it.Err()), emptyMetadata)
+			return
+		}
 		it.Close()
 
+		foundCount := uint64(len(foundTerminalUsersets) + len(foundNonTerminalUsersets))
+		if req.MaxLeafSubjects > 0 && foundCount > uint64(req.MaxLeafSubjects) {
+			resultChan <- expandResultError(NewLeafSubjectLimitExceededErr(foundCount, req.MaxLeafSubjects), emptyMetadata)
+			return
+		}
+
 		// If only shallow expansion was required, or there are no non-terminal subjects found,
 		// nothing more to do.
 		if req.ExpansionMode == v1.DispatchExpandRequest_SHALLOW || len(foundNonTerminalUsersets) == 0 {
@@ -114,6 +124,7 @@ func (ce *ConcurrentExpander) expandDirect(
 					ResourceAndRelation: nonTerminalUser.Subject,
 					Metadata:            decrementDepth(req.Metadata),
 					ExpansionMode:       req.ExpansionMode,
+					MaxLeafSubjects:     req.MaxLeafSubjects,
 				},
 				req.Revision,
 			})
@@ -121,7 +132,7 @@ func (ce *ConcurrentExpander) expandDirect(
 			requestsToDispatch = append(requestsToDispatch, decorateWithCaveatIfNecessary(toDispatch, nonTerminalUser.CaveatExpression))
 		}
 
-		result := expandAny(ctx, req.ResourceAndRelation, requestsToDispatch)
+		result := expandAny(ctx, req.ResourceAndRelation, requestsToDispatch, req.MaxLeafSubjects)
 		if result.Err != nil {
 			resultChan <- result
 			return
@@ -198,12 +209,19 @@ func (ce *ConcurrentExpander) expandSetOperation(ctx context.Context, req Valida
 		}
 	}
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
-		resultChan <- reducer(ctx, req.ResourceAndRelation, requests)
+		resultChan <- reducer(ctx, req.ResourceAndRelation, requests, req.MaxLeafSubjects)
 	}
 }
 
 func (ce *ConcurrentExpander) dispatch(req ValidatedExpandRequest) ReduceableExpandFunc {
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
+		// If the maximum depth has been reached, return the subproblem unexpanded, rather than
+		// failing the entire expansion.
+		if req.Metadata.DepthRemaining == 0 {
+			resultChan <- depthLimitedResult(req.ResourceAndRelation)
+			return
+		}
+
 		log.Ctx(ctx).Trace().Object("dispatchExpand", req).Send()
 		result, err := ce.d.DispatchExpand(ctx, req.DispatchExpandRequest)
 		resultChan <- ExpandResult{result, err}
@@ -246,8 +264,9 @@ func (ce *ConcurrentExpander) expandComputedUserset(ctx context.Context, req Val
 				ObjectId:  start.ObjectId,
 				Relation:  cu.Relation,
 			},
-			Metadata:      decrementDepth(req.Metadata),
-			ExpansionMode: req.ExpansionMode,
+			Metadata:        decrementDepth(req.Metadata),
+			ExpansionMode:   req.ExpansionMode,
+			MaxLeafSubjects: req.MaxLeafSubjects,
 		},
 		req.Revision,
 	})
@@ -277,9 +296,13 @@ func (ce *ConcurrentExpander) expandTupleToUserset(ctx context.Context, req Vali
 			toDispatch := ce.expandComputedUserset(ctx, req, ttu.ComputedUserset, tpl)
 			requestsToDispatch = append(requestsToDispatch, decorateWithCaveatIfNecessary(toDispatch, caveats.CaveatAsExpr(tpl.Caveat)))
 		}
+		if it.Err() != nil {
+			resultChan <- expandResultError(NewExpansionFailureErr(it.Err()), emptyMetadata)
+			return
+		}
 		it.Close()
 
-		resultChan <- expandAny(ctx, req.ResourceAndRelation, requestsToDispatch)
+		resultChan <- expandAny(ctx, req.ResourceAndRelation, requestsToDispatch, req.MaxLeafSubjects)
 	}
 }
 
@@ -308,6 +331,7 @@ func expandSetOperation(
 	start *core.ObjectAndRelation,
 	requests []ReduceableExpandFunc,
 	op core.SetOperationUserset_Operation,
+	maxLeafSubjects uint32,
 ) ExpandResult {
 	children := make([]*core.RelationTupleTreeNode, 0, len(requests))
 
@@ -326,6 +350,8 @@ func expandSetOperation(
 	}
 
 	responseMetadata := emptyMetadata
+	depthLimited := false
+	var leafSubjectCount uint64
 	for _, resultChan := range resultChans {
 		select {
 		case result := <-resultChan:
@@ -334,12 +360,39 @@ func expandSetOperation(
 				return expandResultError(result.Err, responseMetadata)
 			}
 			children = append(children, result.Resp.TreeNode)
+			depthLimited = depthLimited || result.Resp.DepthLimited
+
+			// Once the maximum has been exceeded, the remaining children are canceled.
+			leafSubjectCount += CountLeafSubjects(result.Resp.TreeNode)
+			if maxLeafSubjects > 0 && leafSubjectCount > uint64(maxLeafSubjects) {
+				return expandResultError(NewLeafSubjectLimitExceededErr(leafSubjectCount, maxLeafSubjects), responseMetadata)
+			}
 		case <-ctx.Done():
 			return expandResultError(NewRequestCanceledErr(), responseMetadata)
 		}
 	}
 
-	return setResult(op, start, children, responseMetadata)
+	result := setResult(op, start, children, responseMetadata)
+	result.Resp.DepthLimited = depthLimited
+	return result
+}
+
+// CountLeafSubjects returns the number of subjects found in the leaves of the expansion tree.
+func CountLeafSubjects(node *core.RelationTupleTreeNode) uint64 {
+	switch t := node.GetNodeType().(type) {
+	case *core.RelationTupleTreeNode_IntermediateNode:
+		var count uint64
+		for _, child := range t.IntermediateNode.ChildNodes {
+			count += CountLeafSubjects(child)
+		}
+		return count
+
+	case *core.RelationTupleTreeNode_LeafNode:
+		return uint64(len(t.LeafNode.Subjects))
+
+	default:
+		return 0
+	}
 }
 
 // emptyExpansion returns an empty expansion.
@@ -354,6 +407,19 @@ func emptyExpansion(start *core.ObjectAndRelation) ReduceableExpandFunc {
 	}
 }
 
+// depthLimitedResult returns an unexpanded leaf for the start, marked as being depth limited.
+func depthLimitedResult(start *core.ObjectAndRelation) ExpandResult {
+	result := expandResult(&core.RelationTupleTreeNode{
+		NodeType: &core.RelationTupleTreeNode_LeafNode{
+			LeafNode: &core.DirectSubjects{},
+		},
+		Expanded:     start,
+		DepthLimited: true,
+	}, emptyMetadata)
+	result.Resp.DepthLimited = true
+	return result
+}
+
 // expandError returns the error.
 func expandError(err error) ReduceableExpandFunc {
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
@@ -362,18 +428,18 @@ func expandError(err error) ReduceableExpandFunc {
 }
 
 // expandAll returns a tree with all of the children and an intersection node type.
-func expandAll(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc) ExpandResult {
-	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_INTERSECTION)
+func expandAll(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc, maxLeafSubjects uint32) ExpandResult {
+	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_INTERSECTION, maxLeafSubjects)
 }
 
 // expandAny returns a tree with all of the children and a union node type.
-func expandAny(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc) ExpandResult {
-	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_UNION)
+func expandAny(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc, maxLeafSubjects uint32) ExpandResult {
+	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_UNION, maxLeafSubjects)
 }
 
 // expandDifference returns a tree with all of the children and an exclusion node type.
-func expandDifference(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc) ExpandResult {
-	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_EXCLUSION)
+func expandDifference(ctx context.Context, start *core.ObjectAndRelation, requests []ReduceableExpandFunc, maxLeafSubjects uint32) ExpandResult {
+	return expandSetOperation(ctx, start, requests, core.SetOperationUserset_EXCLUSION, maxLeafSubjects)
 }
 
 // expandOne waits for exactly one response
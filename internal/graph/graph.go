@@ -32,12 +32,6 @@ type ExpandResult struct {
 	Err  error
 }
 
-// LookupResult is the data that is returned by a single lookup or sub-lookup.
-type LookupResult struct {
-	Resp *v1.DispatchLookupResponse
-	Err  error
-}
-
 // ReduceableExpandFunc is a function that can be bound to a execution context.
 type ReduceableExpandFunc func(ctx context.Context, resultChan chan<- ExpandResult)
 
@@ -51,12 +45,14 @@ type ExpandReducer func(
 	ctx context.Context,
 	start *core.ObjectAndRelation,
 	requests []ReduceableExpandFunc,
+	maxLeafSubjects uint32,
 ) ExpandResult
 
 func decrementDepth(md *v1.ResolverMeta) *v1.ResolverMeta {
 	return &v1.ResolverMeta{
 		AtRevision:     md.AtRevision,
 		DepthRemaining: md.DepthRemaining - 1,
+		SoftDeadline:   md.SoftDeadline,
 	}
 }
 
@@ -79,6 +75,7 @@ func ensureMetadata(subProblemMetadata *v1.ResponseMeta) *v1.ResponseMeta {
 		DepthRequired:       subProblemMetadata.DepthRequired,
 		CachedDispatchCount: subProblemMetadata.CachedDispatchCount,
 		DebugInfo:           subProblemMetadata.DebugInfo,
+		Incomplete:          subProblemMetadata.Incomplete,
 	}
 }
 
@@ -89,5 +86,6 @@ func addCallToResponseMetadata(metadata *v1.ResponseMeta) *v1.ResponseMeta {
 		DepthRequired:       metadata.DepthRequired + 1,
 		CachedDispatchCount: metadata.CachedDispatchCount,
 		DebugInfo:           metadata.DebugInfo,
+		Incomplete:          metadata.Incomplete,
 	}
 }
@@ -3,14 +3,18 @@ package graph
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 
+	"golang.org/x/exp/maps"
+
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/util"
 )
 
 // NewConcurrentLookup creates and instance of ConcurrentLookup.
@@ -35,7 +39,7 @@ type ValidatedLookupRequest struct {
 
 type collectingStream struct {
 	checker *parallelChecker
-	req     ValidatedLookupRequest
+	results *lookupResultsStream
 	context context.Context
 
 	dispatchCount       uint32
@@ -65,10 +69,13 @@ func (ls *collectingStream) Publish(result *v1.DispatchReachableResourcesRespons
 
 	for _, found := range result.Resources {
 		if found.ResultStatus == v1.ReachableResource_HAS_PERMISSION {
-			ls.checker.AddResolvedResource(&v1.ResolvedResource{
+			err := ls.results.Add(&v1.ResolvedResource{
 				ResourceId:     found.ResourceId,
 				Permissionship: v1.ResolvedResource_HAS_PERMISSION,
 			})
+			if err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -77,17 +84,20 @@ func (ls *collectingStream) Publish(result *v1.DispatchReachableResourcesRespons
 	return nil
 }
 
-func (cl *ConcurrentLookup) LookupViaReachability(ctx context.Context, req ValidatedLookupRequest) (*v1.DispatchLookupResponse, error) {
+// LookupViaReachability performs a lookup by walking the reachable resources for the subject,
+// publishing the resources found to have permission to the stream as they are found. Once the
+// walk has completed, a final response is published with the metadata of the lookup.
+func (cl *ConcurrentLookup) LookupViaReachability(req ValidatedLookupRequest, stream dispatch.LookupStream) error {
 	if req.Subject.ObjectId == tuple.PublicWildcard {
-		resp := lookupResultError(NewErrInvalidArgument(errors.New("cannot perform lookup on wildcard")), emptyMetadata)
-		return resp.Resp, resp.Err
+		return NewErrInvalidArgument(errors.New("cannot perform lookup on wildcard"))
 	}
 
-	cancelCtx, cancel := context.WithCancel(ctx)
+	cancelCtx, cancel := context.WithCancel(stream.Context())
 	defer cancel()
 
-	checker := newParallelChecker(cancelCtx, cancel, cl.c, req, cl.concurrencyLimit)
-	stream := &collectingStream{checker, req, cancelCtx, 0, 0, 0, sync.Mutex{}}
+	results := newLookupResultsStream(stream, req.Limit, cancel)
+	checker := newParallelChecker(cancelCtx, cl.c, req, results, cl.concurrencyLimit)
+	reachableStream := &collectingStream{checker, results, cancelCtx, 0, 0, 0, sync.Mutex{}}
 
 	// Start the checker.
 	checker.Start()
@@ -104,52 +114,124 @@ func (cl *ConcurrentLookup) LookupViaReachability(ctx context.Context, req Valid
 		},
 		SubjectIds: []string{req.Subject.ObjectId},
 		Metadata:   req.Metadata,
-	}, stream)
-	if err != nil {
-		resp := lookupResultError(err, emptyMetadata)
-		return resp.Resp, resp.Err
+	}, reachableStream)
+	if err != nil && !results.IsTruncated() {
+		cancel()
+		_ = checker.Wait()
+		return err
 	}
 
-	// Wait for the checker to finish.
-	allowed, err := checker.Wait()
-	if err != nil {
-		resp := lookupResultError(err, emptyMetadata)
-		return resp.Resp, resp.Err
+	// Wait for the checker to finish. If the lookup was truncated, the remaining work was
+	// canceled, and any error from doing so is expected.
+	if err := checker.Wait(); err != nil && !results.IsTruncated() {
+		return err
 	}
 
-	res := lookupResult(allowed, req, &v1.ResponseMeta{
-		DispatchCount:       stream.dispatchCount + checker.DispatchCount() + 1, // +1 for the lookup
-		CachedDispatchCount: stream.cachedDispatchCount + checker.CachedDispatchCount(),
-		DepthRequired:       max(stream.depthRequired, checker.DepthRequired()) + 1, // +1 for the lookup
+	return results.Finish(&v1.ResponseMeta{
+		DispatchCount:       reachableStream.dispatchCount + checker.DispatchCount() + 1, // +1 for the lookup
+		CachedDispatchCount: reachableStream.cachedDispatchCount + checker.CachedDispatchCount(),
+		DepthRequired:       max(reachableStream.depthRequired, checker.DepthRequired()) + 1, // +1 for the lookup
 	})
-	return res.Resp, res.Err
 }
 
-func lookupResult(foundResources []*v1.ResolvedResource, req ValidatedLookupRequest, subProblemMetadata *v1.ResponseMeta) LookupResult {
-	limitedResources := limitedSlice(foundResources, req.Limit)
+// lookupResultsStream publishes the resources found by a lookup to its stream, publishing each
+// resource at most once and no more resources than the limit of the lookup.
+//
+// A resource can be found more than once, such as when it is reachable via both a direct grant
+// and a group membership. Resources found to have permission are published as soon as they are
+// found, while those which conditionally have permission are held until the lookup completes, as
+// the resource may yet be found to have permission unconditionally.
+type lookupResultsStream struct {
+	stream dispatch.LookupStream
+	limit  uint32
+	cancel func()
+
+	found       *util.Set[string]
+	conditional map[string]*v1.ResolvedResource
+	truncated   bool
 
-	return LookupResult{
-		&v1.DispatchLookupResponse{
-			Metadata:          ensureMetadata(subProblemMetadata),
-			ResolvedResources: limitedResources,
-		},
-		nil,
+	mu sync.Mutex
+}
+
+func newLookupResultsStream(stream dispatch.LookupStream, limit uint32, cancel func()) *lookupResultsStream {
+	return &lookupResultsStream{
+		stream: stream,
+		limit:  limit,
+		cancel: cancel,
+
+		found:       util.NewSet[string](),
+		conditional: map[string]*v1.ResolvedResource{},
 	}
 }
 
-func limitedSlice[T any](slice []T, limit uint32) []T {
-	if len(slice) > int(limit) {
-		return slice[0:limit]
+// Add adds a resource found by the lookup. If the resource is beyond the limit of the lookup, the
+// lookup is marked as truncated and its remaining work is canceled.
+func (lrs *lookupResultsStream) Add(resolvedResource *v1.ResolvedResource) error {
+	lrs.mu.Lock()
+	defer lrs.mu.Unlock()
+
+	if lrs.truncated {
+		return nil
+	}
+
+	resourceID := resolvedResource.ResourceId
+	if lrs.found.Has(resourceID) {
+		// If the resource was already published, or is conditional again, there is nothing to do.
+		if _, ok := lrs.conditional[resourceID]; !ok || resolvedResource.Permissionship == v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
+			return nil
+		}
+	} else {
+		if lrs.found.Len() >= int(lrs.limit) {
+			lrs.truncated = true
+			lrs.cancel()
+			return nil
+		}
+		lrs.found.Add(resourceID)
 	}
 
-	return slice
+	if resolvedResource.Permissionship == v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
+		lrs.conditional[resourceID] = resolvedResource
+		return nil
+	}
+
+	delete(lrs.conditional, resourceID)
+	return lrs.stream.Publish(&v1.DispatchLookupResponse{
+		Metadata:          emptyMetadata,
+		ResolvedResources: []*v1.ResolvedResource{resolvedResource},
+	})
 }
 
-func lookupResultError(err error, subProblemMetadata *v1.ResponseMeta) LookupResult {
-	return LookupResult{
-		&v1.DispatchLookupResponse{
-			Metadata: ensureMetadata(subProblemMetadata),
-		},
-		err,
+// WasFound returns whether the resource was already found by the lookup.
+func (lrs *lookupResultsStream) WasFound(resourceID string) bool {
+	lrs.mu.Lock()
+	defer lrs.mu.Unlock()
+	return lrs.found.Has(resourceID)
+}
+
+// IsTruncated returns whether the lookup stopped at its limit while further resources remained.
+func (lrs *lookupResultsStream) IsTruncated() bool {
+	lrs.mu.Lock()
+	defer lrs.mu.Unlock()
+	return lrs.truncated
+}
+
+// Finish publishes the final response of the lookup, containing the resources which
+// conditionally have permission and the given metadata.
+func (lrs *lookupResultsStream) Finish(metadata *v1.ResponseMeta) error {
+	lrs.mu.Lock()
+	defer lrs.mu.Unlock()
+
+	resourceIDs := maps.Keys(lrs.conditional)
+	sort.Strings(resourceIDs)
+
+	conditional := make([]*v1.ResolvedResource, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		conditional = append(conditional, lrs.conditional[resourceID])
 	}
+
+	return lrs.stream.Publish(&v1.DispatchLookupResponse{
+		Metadata:          ensureMetadata(metadata),
+		ResolvedResources: conditional,
+		Truncated:         lrs.truncated,
+	})
 }
@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func TestLookupResultsStreamDirectOverload(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	results := newLookupResultsStream(stream, 50, func() {})
+
+	// Add a conditional item and ensure it is held.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
+	}))
+	require.True(t, results.WasFound("foo"))
+	require.Empty(t, stream.Results())
+
+	// Add a concrete item and ensure it overloads, and is published.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.Len(t, stream.Results(), 1)
+	require.Equal(t, "foo", stream.Results()[0].ResolvedResources[0].ResourceId)
+	require.Equal(t, v1.ResolvedResource_HAS_PERMISSION, stream.Results()[0].ResolvedResources[0].Permissionship)
+
+	// Add the item again, both as conditional and concrete, and ensure it is ignored.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
+	}))
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.Len(t, stream.Results(), 1)
+
+	// Add another conditional item and ensure it is published on finish.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "bar",
+		Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
+	}))
+	require.NoError(t, results.Finish(&v1.ResponseMeta{DispatchCount: 1}))
+	require.Len(t, stream.Results(), 2)
+
+	final := stream.Results()[1]
+	require.Len(t, final.ResolvedResources, 1)
+	require.Equal(t, "bar", final.ResolvedResources[0].ResourceId)
+	require.Equal(t, v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION, final.ResolvedResources[0].Permissionship)
+	require.Equal(t, uint32(1), final.Metadata.DispatchCount)
+	require.False(t, final.Truncated)
+}
+
+func TestLookupResultsStreamLimit(t *testing.T) {
+	canceled := false
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	results := newLookupResultsStream(stream, 1, func() { canceled = true })
+
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.False(t, results.IsTruncated())
+
+	// Add the same item again, which is not beyond the limit.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.False(t, results.IsTruncated())
+	require.False(t, canceled)
+
+	// Add a second item and ensure the lookup is truncated.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "bar",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.True(t, results.IsTruncated())
+	require.True(t, canceled)
+	require.False(t, results.WasFound("bar"))
+
+	require.NoError(t, results.Finish(&v1.ResponseMeta{}))
+	require.Len(t, stream.Results(), 2)
+	require.Empty(t, stream.Results()[1].ResolvedResources)
+	require.True(t, stream.Results()[1].Truncated)
+}
+
+func TestQueueToCheckAfterTruncation(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	results := newLookupResultsStream(stream, 1, func() {})
+	pc := newParallelChecker(context.Background(), nil, ValidatedLookupRequest{
+		DispatchLookupRequest: &v1.DispatchLookupRequest{
+			Limit: 1,
+		},
+	}, results, 10)
+
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "foo",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+
+	// Queue the found item and ensure it is ignored.
+	require.False(t, pc.QueueToCheck("foo"))
+
+	// Queue a second item and ensure it is queued.
+	require.True(t, pc.QueueToCheck("bar"))
+
+	// Truncate the lookup, and ensure further items are ignored.
+	require.NoError(t, results.Add(&v1.ResolvedResource{
+		ResourceId:     "baz",
+		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
+	}))
+	require.False(t, pc.QueueToCheck("qux"))
+}
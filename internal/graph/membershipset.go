@@ -7,10 +7,11 @@ import (
 )
 
 var (
-	caveatOr   = caveats.Or
-	caveatAnd  = caveats.And
-	caveatSub  = caveats.Subtract
-	wrapCaveat = caveats.CaveatAsExpr
+	caveatOr               = caveats.Or
+	caveatAnd              = caveats.And
+	caveatSub              = caveats.Subtract
+	wrapCaveat             = caveats.CaveatAsExpr
+	wrapRelationshipCaveat = caveats.RelationshipCaveatAsExpr
 )
 
 // CheckResultsMap defines a type that is a map from resource ID to ResourceCheckResult.
@@ -27,10 +28,26 @@ func NewMembershipSet() *MembershipSet {
 	}
 }
 
+// NewMembershipSetWithProofs constructs a new helper set for tracking the membership found for a
+// dispatched check request, which also tracks the relationships proving each member.
+func NewMembershipSetWithProofs() *MembershipSet {
+	ms := NewMembershipSet()
+	ms.proofsByID = map[string][]*core.RelationTuple{}
+	return ms
+}
+
+// newMembershipSetForRequest constructs a new membership set, tracking proofs if requested.
+func newMembershipSetForRequest(includeProof bool) *MembershipSet {
+	if includeProof {
+		return NewMembershipSetWithProofs()
+	}
+	return NewMembershipSet()
+}
+
 func membershipSetFromMap(mp map[string]*core.CaveatExpression) *MembershipSet {
 	ms := NewMembershipSet()
 	for resourceID, result := range mp {
-		ms.addMember(resourceID, result)
+		ms.addMember(resourceID, result, nil)
 	}
 	return ms
 }
@@ -40,12 +57,33 @@ func membershipSetFromMap(mp map[string]*core.CaveatExpression) *MembershipSet {
 type MembershipSet struct {
 	membersByID         map[string]*core.CaveatExpression
 	hasDeterminedMember bool
+
+	// proofsByID holds the relationships proving the membership of each member, and is nil
+	// unless proofs are being tracked.
+	proofsByID map[string][]*core.RelationTuple
 }
 
 // AddDirectMember adds a resource ID that was *directly* found for the dispatched check, with
 // optional caveat found on the relationship.
 func (ms *MembershipSet) AddDirectMember(resourceID string, caveat *core.ContextualizedCaveat) {
-	ms.addMember(resourceID, wrapCaveat(caveat))
+	ms.addMember(resourceID, wrapCaveat(caveat), nil)
+}
+
+// AddDirectMemberViaRelationship adds a resource ID that was *directly* found for the dispatched
+// check via the given relationship, with the caveat found on the relationship, if any, recorded
+// as having been found on the relationship's relation.
+func (ms *MembershipSet) AddDirectMemberViaRelationship(resourceID string, relationship *core.RelationTuple) {
+	var proof []*core.RelationTuple
+	if ms.proofsByID != nil {
+		proof = []*core.RelationTuple{relationship}
+	}
+	ms.addMember(resourceID, wrapRelationshipCaveat(relationship), proof)
+}
+
+// AddMember adds a resource ID that was found with the given caveat expression, if any, such as
+// the expression under which the subject was found among the subjects of the resource.
+func (ms *MembershipSet) AddMember(resourceID string, caveatExpression *core.CaveatExpression) {
+	ms.addMember(resourceID, caveatExpression, nil)
 }
 
 // AddMemberViaRelationship adds a resource ID that was found via another relationship, such
@@ -58,15 +96,34 @@ func (ms *MembershipSet) AddMemberViaRelationship(
 	resourceCaveatExpression *core.CaveatExpression,
 	parentRelationship *core.RelationTuple,
 ) {
-	intersection := caveatAnd(wrapCaveat(parentRelationship.Caveat), resourceCaveatExpression)
-	ms.addMember(resourceID, intersection)
+	ms.addMemberViaRelationship(resourceID, resourceCaveatExpression, nil, parentRelationship)
+}
+
+// addMemberViaRelationship adds a resource ID that was found via another relationship, as per
+// AddMemberViaRelationship, whose membership is proven by the given proof along with the parent
+// relationship.
+func (ms *MembershipSet) addMemberViaRelationship(
+	resourceID string,
+	resourceCaveatExpression *core.CaveatExpression,
+	resourceProof []*core.RelationTuple,
+	parentRelationship *core.RelationTuple,
+) {
+	intersection := caveatAnd(wrapRelationshipCaveat(parentRelationship), resourceCaveatExpression)
+
+	// NOTE: proofs are only combined when tracked, to keep allocations off the fast path.
+	var proof []*core.RelationTuple
+	if ms.proofsByID != nil {
+		proof = combineProofs([]*core.RelationTuple{parentRelationship}, resourceProof)
+	}
+	ms.addMember(resourceID, intersection, proof)
 }
 
-func (ms *MembershipSet) addMember(resourceID string, caveatExpr *core.CaveatExpression) {
+func (ms *MembershipSet) addMember(resourceID string, caveatExpr *core.CaveatExpression, proof []*core.RelationTuple) {
 	existing, ok := ms.membersByID[resourceID]
 	if !ok {
 		ms.hasDeterminedMember = ms.hasDeterminedMember || caveatExpr == nil
 		ms.membersByID[resourceID] = caveatExpr
+		ms.setProof(resourceID, proof)
 		return
 	}
 
@@ -80,18 +137,35 @@ func (ms *MembershipSet) addMember(resourceID string, caveatExpr *core.CaveatExp
 	if caveatExpr == nil {
 		ms.hasDeterminedMember = true
 		ms.membersByID[resourceID] = nil
+		ms.setProof(resourceID, proof)
 		return
 	}
 
-	// Otherwise, the caveats get unioned together.
+	// Otherwise, the caveats get unioned together, as do their proofs, as either may hold.
 	ms.membersByID[resourceID] = caveatOr(existing, caveatExpr)
+	if ms.proofsByID != nil {
+		ms.setProof(resourceID, combineProofs(ms.proofsByID[resourceID], proof))
+	}
+}
+
+func (ms *MembershipSet) setProof(resourceID string, proof []*core.RelationTuple) {
+	if ms.proofsByID != nil {
+		ms.proofsByID[resourceID] = proof
+	}
+}
+
+// combineProofs returns the relationships of both proofs, without modifying either.
+func combineProofs(first []*core.RelationTuple, second []*core.RelationTuple) []*core.RelationTuple {
+	combined := make([]*core.RelationTuple, 0, len(first)+len(second))
+	combined = append(combined, first...)
+	return append(combined, second...)
 }
 
 // UnionWith combines the results found in the given map with the members of this set.
 // The changes are made in-place.
 func (ms *MembershipSet) UnionWith(resultsMap CheckResultsMap) {
 	for resourceID, details := range resultsMap {
-		ms.addMember(resourceID, details.Expression)
+		ms.addMember(resourceID, details.Expression, details.Proof)
 	}
 }
 
@@ -101,6 +175,7 @@ func (ms *MembershipSet) IntersectWith(resultsMap CheckResultsMap) {
 	for resourceID := range ms.membersByID {
 		if _, ok := resultsMap[resourceID]; !ok {
 			delete(ms.membersByID, resourceID)
+			delete(ms.proofsByID, resourceID)
 		}
 	}
 
@@ -110,6 +185,12 @@ func (ms *MembershipSet) IntersectWith(resultsMap CheckResultsMap) {
 		if !ok {
 			continue
 		}
+
+		// Membership in an intersection is proven only by the proofs of all of its branches.
+		if ms.proofsByID != nil {
+			ms.setProof(resourceID, combineProofs(ms.proofsByID[resourceID], details.Proof))
+		}
+
 		if existing == nil && details.Expression == nil {
 			ms.hasDeterminedMember = true
 			continue
@@ -128,6 +209,7 @@ func (ms *MembershipSet) Subtract(resultsMap CheckResultsMap) {
 			// If the incoming member has no caveat, then this removal is absolute.
 			if details.Expression == nil {
 				delete(ms.membersByID, resourceID)
+				delete(ms.proofsByID, resourceID)
 				continue
 			}
 
@@ -173,6 +255,7 @@ func (ms *MembershipSet) AsCheckResultsMap() CheckResultsMap {
 		resultsMap[resourceID] = &v1.ResourceCheckResult{
 			Membership: membership,
 			Expression: caveat,
+			Proof:      ms.proofsByID[resourceID],
 		}
 	}
 
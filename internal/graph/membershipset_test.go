@@ -22,6 +22,12 @@ func caveat(name string, context map[string]any) *core.CaveatExpression {
 		})
 }
 
+func caveatOnRelation(name string, context map[string]any, namespace, relation string) *core.CaveatExpression {
+	expr := caveat(name, context)
+	expr.SourceRelation = &core.RelationReference{Namespace: namespace, Relation: relation}
+	return expr
+}
+
 func TestMembershipSetAddDirectMember(t *testing.T) {
 	tcs := []struct {
 		name                string
@@ -174,7 +180,7 @@ func TestMembershipSetAddMemberViaRelationship(t *testing.T) {
 			nil,
 			withCaveat(tuple.MustParse("document:foo#viewer@user:tom"), caveat("somecaveat", nil)),
 			map[string]*core.CaveatExpression{
-				"somedoc": caveat("somecaveat", nil),
+				"somedoc": caveatOnRelation("somecaveat", nil, "document", "viewer"),
 			},
 			false,
 		},
@@ -186,7 +192,7 @@ func TestMembershipSetAddMemberViaRelationship(t *testing.T) {
 			withCaveat(tuple.MustParse("document:foo#viewer@user:tom"), caveat("c2", nil)),
 			map[string]*core.CaveatExpression{
 				"somedoc": caveatAnd(
-					caveat("c2", nil),
+					caveatOnRelation("c2", nil, "document", "viewer"),
 					caveat("c1", nil),
 				),
 			},
@@ -217,7 +223,7 @@ func TestMembershipSetAddMemberViaRelationship(t *testing.T) {
 				"somedoc": caveatOr(
 					caveat("c0", nil),
 					caveatAnd(
-						caveat("c2", nil),
+						caveatOnRelation("c2", nil, "document", "viewer"),
 						caveat("c1", nil),
 					),
 				),
@@ -715,6 +721,56 @@ func TestMembershipSetSubtract(t *testing.T) {
 	}
 }
 
+func TestMembershipSetProofs(t *testing.T) {
+	viewer := tuple.MustParse("document:somedoc#viewer@user:tom")
+	editor := tuple.MustParse("document:somedoc#editor@user:tom")
+	parent := tuple.MustParse("document:somedoc#parent@folder:somefolder")
+	folderViewer := tuple.MustParse("folder:somefolder#viewer@user:tom")
+	banned := tuple.MustParse("document:otherdoc#banned@user:tom")
+
+	// Without proofs being tracked, none are returned.
+	untracked := NewMembershipSet()
+	untracked.AddDirectMemberViaRelationship("somedoc", viewer)
+	require.Nil(t, untracked.AsCheckResultsMap()["somedoc"].Proof)
+
+	// Nor are they allocated.
+	require.Zero(t, testing.AllocsPerRun(10, func() {
+		untracked.AddDirectMemberViaRelationship("somedoc", viewer)
+		untracked.AddMemberViaRelationship("somedoc", nil, parent)
+	}))
+
+	// A determined member found via a single relationship keeps the first proof found.
+	union := NewMembershipSetWithProofs()
+	union.AddDirectMemberViaRelationship("somedoc", viewer)
+	union.addMemberViaRelationship("somedoc", nil, []*core.RelationTuple{folderViewer}, parent)
+	require.Equal(t, []*core.RelationTuple{viewer}, union.AsCheckResultsMap()["somedoc"].Proof)
+
+	// A member found via a parent relationship is proven by both relationships.
+	arrow := NewMembershipSetWithProofs()
+	arrow.addMemberViaRelationship("somedoc", nil, []*core.RelationTuple{folderViewer}, parent)
+	require.Equal(t, []*core.RelationTuple{parent, folderViewer}, arrow.AsCheckResultsMap()["somedoc"].Proof)
+
+	// An intersection is proven by the proofs of both branches.
+	intersection := NewMembershipSetWithProofs()
+	intersection.AddDirectMemberViaRelationship("somedoc", viewer)
+	other := NewMembershipSetWithProofs()
+	other.AddDirectMemberViaRelationship("somedoc", editor)
+	intersection.IntersectWith(other.AsCheckResultsMap())
+	require.Equal(t, []*core.RelationTuple{viewer, editor}, intersection.AsCheckResultsMap()["somedoc"].Proof)
+
+	// An exclusion is proven by the proof of its base, and removed members lose their proofs.
+	exclusion := NewMembershipSetWithProofs()
+	exclusion.AddDirectMemberViaRelationship("somedoc", viewer)
+	exclusion.AddDirectMemberViaRelationship("otherdoc", tuple.MustParse("document:otherdoc#viewer@user:tom"))
+	excluded := NewMembershipSetWithProofs()
+	excluded.AddDirectMemberViaRelationship("otherdoc", banned)
+	exclusion.Subtract(excluded.AsCheckResultsMap())
+	results := exclusion.AsCheckResultsMap()
+	require.Equal(t, []*core.RelationTuple{viewer}, results["somedoc"].Proof)
+	require.NotContains(t, results, "otherdoc")
+	require.NotContains(t, exclusion.proofsByID, "otherdoc")
+}
+
 func unwrapCaveat(ce *core.CaveatExpression) *core.ContextualizedCaveat {
 	if ce == nil {
 		return nil
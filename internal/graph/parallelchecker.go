@@ -4,7 +4,6 @@ import (
 	"context"
 	"sync"
 
-	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 
@@ -15,12 +14,11 @@ import (
 )
 
 // parallelChecker is a helper for initiating checks over a large set of resources of a specific
-// type, for a specific subject, and putting the results concurrently into a set.
+// type, for a specific subject, and adding the results concurrently to the results of a lookup.
 type parallelChecker struct {
 	c        dispatch.Check
 	g        *errgroup.Group
 	checkCtx context.Context
-	cancel   func()
 
 	toCheck         chan string
 	enqueuedToCheck *util.Set[string]
@@ -28,7 +26,7 @@ type parallelChecker struct {
 	lookupRequest ValidatedLookupRequest
 	maxConcurrent uint16
 
-	foundResourceIDs map[string]*v1.ResolvedResource
+	results *lookupResultsStream
 
 	dispatchCount       uint32
 	cachedDispatchCount uint32
@@ -38,12 +36,11 @@ type parallelChecker struct {
 }
 
 // newParallelChecker creates a new parallel checker, for a given subject.
-func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, req ValidatedLookupRequest, maxConcurrent uint16) *parallelChecker {
+func newParallelChecker(ctx context.Context, c dispatch.Check, req ValidatedLookupRequest, results *lookupResultsStream, maxConcurrent uint16) *parallelChecker {
 	g, checkCtx := errgroup.WithContext(ctx)
 	toCheck := make(chan string, maxConcurrent)
 	return &parallelChecker{
 		checkCtx: checkCtx,
-		cancel:   cancel,
 
 		c: c,
 		g: g,
@@ -54,7 +51,7 @@ func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, re
 		lookupRequest: req,
 		maxConcurrent: maxConcurrent,
 
-		foundResourceIDs:    map[string]*v1.ResolvedResource{},
+		results:             results,
 		dispatchCount:       0,
 		cachedDispatchCount: 0,
 		depthRequired:       0,
@@ -63,13 +60,6 @@ func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, re
 	}
 }
 
-// AddResolvedResource adds a resource that has been already checked to the set.
-func (pc *parallelChecker) AddResolvedResource(resolvedResource *v1.ResolvedResource) {
-	pc.mu.Lock()
-	defer pc.mu.Unlock()
-	pc.addResultsUnsafe(resolvedResource)
-}
-
 // DispatchCount returns the number of dispatches used for checks.
 func (pc *parallelChecker) DispatchCount() uint32 {
 	return pc.dispatchCount
@@ -85,47 +75,35 @@ func (pc *parallelChecker) DepthRequired() uint32 {
 	return pc.depthRequired
 }
 
-func (pc *parallelChecker) addResultsUnsafe(resolvedResource *v1.ResolvedResource) {
-	// If the result being added is conditional and we've already found a valid permission, skip.
-	if resolvedResource.Permissionship == v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
-		existing, ok := pc.foundResourceIDs[resolvedResource.ResourceId]
-		if ok && existing.Permissionship == v1.ResolvedResource_HAS_PERMISSION {
-			return
-		}
-	}
-
-	pc.foundResourceIDs[resolvedResource.ResourceId] = resolvedResource
-	if len(pc.foundResourceIDs) >= int(pc.lookupRequest.Limit) {
-		// Cancel any further work
-		pc.cancel()
-		return
-	}
-}
-
 func (pc *parallelChecker) updateStatsUnsafe(metadata *v1.ResponseMeta) {
 	pc.dispatchCount += metadata.DispatchCount
 	pc.cachedDispatchCount += metadata.CachedDispatchCount
 	pc.depthRequired = max(pc.depthRequired, metadata.DepthRequired)
 }
 
-// QueueToCheck queues a resource ID to be checked.
+// QueueToCheck queues a resource ID to be checked, returning whether it was queued. Resources
+// already found by the lookup, or already queued, are not checked again.
 func (pc *parallelChecker) QueueToCheck(resourceID string) bool {
+	if pc.results.IsTruncated() || pc.results.WasFound(resourceID) {
+		return false
+	}
+
 	queue := func() bool {
 		pc.mu.Lock()
 		defer pc.mu.Unlock()
-		if len(pc.foundResourceIDs) >= int(pc.lookupRequest.Limit) {
-			close(pc.toCheck)
-			return false
-		}
-
 		return pc.enqueuedToCheck.Add(resourceID)
 	}()
 	if !queue {
 		return false
 	}
 
-	pc.toCheck <- resourceID
-	return true
+	select {
+	case pc.toCheck <- resourceID:
+		return true
+
+	case <-pc.checkCtx.Done():
+		return false
+	}
 }
 
 // Start starts the parallel checks over those items added via QueueToCheck.
@@ -181,22 +159,26 @@ func (pc *parallelChecker) Start() {
 
 				pc.mu.Lock()
 				pc.updateStatsUnsafe(resultsMeta)
+				pc.mu.Unlock()
 
 				for resourceID, result := range results {
+					var err error
 					if result.Membership == v1.ResourceCheckResult_MEMBER {
-						pc.addResultsUnsafe(&v1.ResolvedResource{
+						err = pc.results.Add(&v1.ResolvedResource{
 							ResourceId:     resourceID,
 							Permissionship: v1.ResolvedResource_HAS_PERMISSION,
 						})
 					} else if result.Membership == v1.ResourceCheckResult_CAVEATED_MEMBER {
-						pc.addResultsUnsafe(&v1.ResolvedResource{
+						err = pc.results.Add(&v1.ResolvedResource{
 							ResourceId:             resourceID,
 							Permissionship:         v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
 							MissingRequiredContext: result.MissingExprFields,
 						})
 					}
+					if err != nil {
+						return err
+					}
 				}
-				pc.mu.Unlock()
 				return nil
 			})
 		}
@@ -208,13 +190,9 @@ func (pc *parallelChecker) Start() {
 }
 
 // Wait waits for the parallel checker to finish performing all of its
-// checks and returns the set of resources that checked, along with whether an
-// error occurred. Once called, no new items can be added via QueueToCheck.
-func (pc *parallelChecker) Wait() ([]*v1.ResolvedResource, error) {
+// checks, returning whether an error occurred. Once called, no new items can be
+// added via QueueToCheck.
+func (pc *parallelChecker) Wait() error {
 	close(pc.toCheck)
-	if err := pc.g.Wait(); err != nil {
-		return nil, err
-	}
-
-	return maps.Values(pc.foundResourceIDs), nil
+	return pc.g.Wait()
 }
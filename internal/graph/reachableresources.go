@@ -18,16 +18,19 @@ import (
 	"github.com/authzed/spicedb/pkg/util"
 )
 
-// NewConcurrentReachableResources creates an instance of ConcurrentReachableResources.
-func NewConcurrentReachableResources(d dispatch.ReachableResources, concurrencyLimit uint16) *ConcurrentReachableResources {
-	return &ConcurrentReachableResources{d, concurrencyLimit}
+// NewConcurrentReachableResources creates an instance of ConcurrentReachableResources. If
+// maxIntermediateResults is not zero, it is the maximum number of relationships loaded by any single
+// step of the walk; a step loading more fails with an ErrIntermediateResultLimitExceeded.
+func NewConcurrentReachableResources(d dispatch.ReachableResources, concurrencyLimit uint16, maxIntermediateResults uint32) *ConcurrentReachableResources {
+	return &ConcurrentReachableResources{d, concurrencyLimit, maxIntermediateResults}
 }
 
 // ConcurrentReachableResources exposes a method to perform ReachableResources requests, and
 // delegates subproblems to the provided dispatch.ReachableResources instance.
 type ConcurrentReachableResources struct {
-	d                dispatch.ReachableResources
-	concurrencyLimit uint16
+	d                      dispatch.ReachableResources
+	concurrencyLimit       uint16
+	maxIntermediateResults uint32
 }
 
 // ValidatedReachableResourcesRequest represents a request after it has been validated and parsed for internal
@@ -238,16 +241,28 @@ func (crr *ConcurrentReachableResources) chunkedRedispatch(
 	toBeHandled := make([]resourcesSubjectMap, 0)
 	rsm := newResourcesSubjectMap(resourceType)
 	chunkIndex := 0
+	var loadedCount uint32
 	for tpl := it.Next(); tpl != nil; tpl = it.Next() {
 		chunkSize := progressiveDispatchChunkSizes[min(chunkIndex, len(progressiveDispatchChunkSizes)-1)]
 		if it.Err() != nil {
 			return it.Err()
 		}
 
+		// All relationships of the step are loaded before any is handled, so the step is
+		// stopped as soon as it is found to be too wide.
+		loadedCount++
+		if crr.maxIntermediateResults > 0 && loadedCount > crr.maxIntermediateResults {
+			return NewIntermediateResultLimitExceededErr(resourceType.Namespace, resourceType.Relation, crr.maxIntermediateResults)
+		}
+
 		rsm.addRelationship(tpl)
 		if rsm.len() == chunkSize {
 			chunkIndex++
 			toBeHandled = append(toBeHandled, rsm)
+
+			// NOTE: each chunk must only hold its own relationships, or the resources of the
+			// earlier chunks would be dispatched again with each later one.
+			rsm = newResourcesSubjectMap(resourceType)
 		}
 	}
 	it.Close()
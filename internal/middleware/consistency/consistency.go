@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/authzed/spicedb/internal/services/shared"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	log "github.com/authzed/spicedb/internal/logging"
@@ -23,12 +25,24 @@ type hasConsistency interface {
 	GetConsistency() *v1.Consistency
 }
 
+// AtTimeMetadataKey is the request metadata key under which a caller can supply an RFC 3339
+// timestamp at which the request should be evaluated, as an alternative to an exact zedtoken.
+const AtTimeMetadataKey = "io.spicedb.consistency.at-time"
+
+// WrittenAtMetadataKey is the request metadata key under which a caller can supply the zedtokens
+// returned by its own writes, alongside a fully consistent or an exact snapshot requirement. The key
+// may be repeated to supply the tokens of multiple writes.
+//
+// With a fully consistent requirement, the request is guaranteed to be evaluated at a revision which
+// includes all of the writes; the zedtoken returned for such a request therefore captures a snapshot
+// spanning those writes. With an exact snapshot requirement, the snapshot is used exactly as given,
+// and the request fails if the snapshot does not include all of the writes.
+const WrittenAtMetadataKey = "io.spicedb.consistency.written-at"
+
 type ctxKeyType struct{}
 
 var revisionKey ctxKeyType = struct{}{}
 
-var errInvalidZedToken = errors.New("invalid revision requested")
-
 type revisionHandle struct {
 	revision datastore.Revision
 }
@@ -97,7 +111,30 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 	var revision datastore.Revision
 	consistency := req.GetConsistency()
 
+	atTime, hasAtTime, err := atTimeFromMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	writtenAt := writtenAtFromMetadata(ctx)
+	hasWrittenAt := len(writtenAt) > 0
+	if hasWrittenAt && !consistency.GetFullyConsistent() && consistency.GetAtExactSnapshot() == nil {
+		return status.Errorf(codes.InvalidArgument, "%s can only be combined with a fully consistent or exact snapshot requirement", WrittenAtMetadataKey)
+	}
+
 	switch {
+	case hasAtTime:
+		// At time: Use the latest revision committed at or before the requested time.
+		if consistency != nil && !consistency.GetMinimizeLatency() {
+			return status.Errorf(codes.InvalidArgument, "%s cannot be combined with a consistency requirement", AtTimeMetadataKey)
+		}
+
+		atTimeRev, err := ds.RevisionAtTime(ctx, atTime)
+		if err != nil {
+			return rewriteDatastoreError(ctx, err)
+		}
+		revision = atTimeRev
+
 	case consistency == nil || consistency.GetMinimizeLatency():
 		// Minimize Latency: Use the datastore's current revision, whatever it may be.
 		databaseRev, err := ds.OptimizedRevision(ctx)
@@ -114,6 +151,19 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 		}
 		revision = databaseRev
 
+		// If the caller supplied the revisions of its own writes, ensure that the writes are
+		// visible, regardless of what the datastore reports as its head.
+		if hasWrittenAt {
+			writtenRev, err := latestWrittenRevision(ctx, writtenAt, ds)
+			if err != nil {
+				return err
+			}
+
+			if writtenRev.GreaterThan(revision) {
+				revision = writtenRev
+			}
+		}
+
 	case consistency.GetAtLeastAsFresh() != nil:
 		// At least as fresh as: Pick one of the datastore's revision and that specified, which
 		// ever is later.
@@ -125,9 +175,9 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 
 	case consistency.GetAtExactSnapshot() != nil:
 		// Exact snapshot: Use the revision as encoded in the zed token.
-		requestedRev, err := zedtoken.DecodeRevision(consistency.GetAtExactSnapshot(), ds)
+		requestedRev, err := decodeZedToken(consistency.GetAtExactSnapshot(), ds)
 		if err != nil {
-			return errInvalidZedToken
+			return err
 		}
 
 		err = ds.CheckRevision(ctx, requestedRev)
@@ -135,6 +185,19 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 			return rewriteDatastoreError(ctx, err)
 		}
 
+		// If the caller supplied the revisions of its own writes, the snapshot must include them
+		// all. The snapshot is never moved forward to do so, as it must be read exactly.
+		if hasWrittenAt {
+			writtenRev, err := latestWrittenRevision(ctx, writtenAt, ds)
+			if err != nil {
+				return err
+			}
+
+			if writtenRev.GreaterThan(requestedRev) {
+				return status.Errorf(codes.FailedPrecondition, "requested snapshot does not include all of the writes supplied via %s", WrittenAtMetadataKey)
+			}
+		}
+
 		revision = requestedRev
 
 	default:
@@ -145,6 +208,62 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 	return nil
 }
 
+// atTimeFromMetadata returns the point in time requested via AtTimeMetadataKey, if any.
+func atTimeFromMetadata(ctx context.Context) (time.Time, bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	values := md.Get(AtTimeMetadataKey)
+	if len(values) == 0 {
+		return time.Time{}, false, nil
+	}
+
+	atTime, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		return time.Time{}, false, status.Errorf(codes.InvalidArgument, "invalid %s: %s", AtTimeMetadataKey, err)
+	}
+
+	return atTime, true, nil
+}
+
+// writtenAtFromMetadata returns the zedtokens supplied via WrittenAtMetadataKey, if any.
+func writtenAtFromMetadata(ctx context.Context) []*v1.ZedToken {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	values := md.Get(WrittenAtMetadataKey)
+	tokens := make([]*v1.ZedToken, 0, len(values))
+	for _, value := range values {
+		tokens = append(tokens, &v1.ZedToken{Token: value})
+	}
+	return tokens
+}
+
+// latestWrittenRevision decodes the given write zedtokens, ensures that each is still within the
+// datastore's GC window, and returns the latest of their revisions.
+func latestWrittenRevision(ctx context.Context, writtenAt []*v1.ZedToken, ds datastore.Datastore) (datastore.Revision, error) {
+	latest := datastore.NoRevision
+	for _, token := range writtenAt {
+		writtenRev, err := decodeZedToken(token, ds)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ds.CheckRevision(ctx, writtenRev); err != nil {
+			return nil, rewriteDatastoreError(ctx, err)
+		}
+
+		if latest == datastore.NoRevision || writtenRev.GreaterThan(latest) {
+			latest = writtenRev
+		}
+	}
+	return latest, nil
+}
+
 var bypassServiceWhitelist = map[string]struct{}{
 	"/grpc.reflection.v1alpha.ServerReflection/": {},
 	"/grpc.health.v1.Health/":                    {},
@@ -213,12 +332,12 @@ func pickBestRevision(ctx context.Context, requested *v1.ZedToken, ds datastore.
 	}
 
 	if requested != nil {
-		requestedRev, err := zedtoken.DecodeRevision(requested, ds)
+		requestedRev, err := decodeZedToken(requested, ds)
 		if err != nil {
-			return datastore.NoRevision, errInvalidZedToken
+			return datastore.NoRevision, err
 		}
 
-		if databaseRev.GreaterThan(requestedRev) {
+		if datastore.CompareRevisions(databaseRev, requestedRev) == datastore.RevisionAfter {
 			return databaseRev, nil
 		}
 		return requestedRev, nil
@@ -227,6 +346,17 @@ func pickBestRevision(ctx context.Context, requested *v1.ZedToken, ds datastore.
 	return databaseRev, nil
 }
 
+// decodeZedToken decodes the revision of a zedtoken supplied by the caller. A token which cannot be
+// decoded is malformed, and is rejected as an invalid argument; this is distinct from a well-formed
+// token whose revision is no longer available, which is rejected by the datastore as out of range.
+func decodeZedToken(token *v1.ZedToken, ds datastore.Datastore) (datastore.Revision, error) {
+	decoded, err := zedtoken.DecodeRevision(token, ds)
+	if err != nil {
+		return datastore.NoRevision, status.Errorf(codes.InvalidArgument, "malformed zedtoken: %s", err)
+	}
+	return decoded, nil
+}
+
 func rewriteDatastoreError(ctx context.Context, err error) error {
 	// Check if the error can be directly used.
 	if _, ok := status.FromError(err); ok {
@@ -237,6 +367,9 @@ func rewriteDatastoreError(ctx context.Context, err error) error {
 	case errors.As(err, &datastore.ErrInvalidRevision{}):
 		return status.Errorf(codes.OutOfRange, "invalid revision: %s", err)
 
+	case errors.As(err, &datastore.ErrTimestampBeforeGCWindow{}):
+		return status.Errorf(codes.OutOfRange, "%s", err)
+
 	case errors.As(err, &datastore.ErrReadOnly{}):
 		return shared.ErrServiceReadOnly
 
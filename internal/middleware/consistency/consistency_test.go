@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"testing"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
@@ -13,9 +14,13 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
@@ -78,6 +83,61 @@ func TestAddRevisionToContextFullyConsistent(t *testing.T) {
 	ds.AssertExpectations(t)
 }
 
+func TestAddRevisionToContextFullyConsistentWrittenAt(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		written  datastore.Revision
+		expected datastore.Revision
+	}{
+		{"written before head", exact, head},
+		{"written after head", revision.NewFromDecimal(decimal.NewFromInt(200)), revision.NewFromDecimal(decimal.NewFromInt(200))},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds := &proxy_test.MockDatastore{}
+			ds.On("HeadRevision").Return(head, nil).Once()
+			ds.On("RevisionFromString", tc.written.String()).Return(tc.written, nil).Once()
+			ds.On("CheckRevision", tc.written).Return(nil).Once()
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(WrittenAtMetadataKey, zedtoken.NewFromRevision(tc.written).Token))
+			updated := ContextWithHandle(ctx)
+			err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_FullyConsistent{
+						FullyConsistent: true,
+					},
+				},
+			}, ds)
+			require.NoError(err)
+			require.True(tc.expected.Equal(RevisionFromContext(updated)))
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddRevisionToContextWrittenAtInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		consistency *v1.Consistency
+	}{
+		{"without consistency", nil},
+		{"with minimize latency", &v1.Consistency{
+			Requirement: &v1.Consistency_MinimizeLatency{MinimizeLatency: true},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := &proxy_test.MockDatastore{}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(WrittenAtMetadataKey, zedtoken.NewFromRevision(exact).Token))
+			updated := ContextWithHandle(ctx)
+			err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{Consistency: tc.consistency}, ds)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAddRevisionToContextAtLeastAsFresh(t *testing.T) {
 	require := require.New(t)
 
@@ -137,6 +197,211 @@ func TestAddRevisionToContextAtInvalidExactSnapshot(t *testing.T) {
 	ds.AssertExpectations(t)
 }
 
+func TestAddRevisionToContextMalformedZedToken(t *testing.T) {
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "!!not a zedtoken!!"},
+		{"garbage bytes", "/////w=="},
+		{"truncated", "CAIaCAoGMTIz"},
+		{"incompatible version", "KgIIAQ=="},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, consistency := range map[string]*v1.Consistency{
+				"at exact snapshot": {Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: &v1.ZedToken{Token: tc.token}}},
+				"at least as fresh": {Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: &v1.ZedToken{Token: tc.token}}},
+			} {
+				ds := &proxy_test.MockDatastore{}
+				ds.On("OptimizedRevision").Return(optimized, nil).Maybe()
+
+				updated := ContextWithHandle(context.Background())
+				err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{Consistency: consistency}, ds)
+				require.Equal(t, codes.InvalidArgument, status.Code(err), name)
+				require.ErrorContains(t, err, "malformed zedtoken", name)
+				ds.AssertExpectations(t)
+			}
+
+			ds := &proxy_test.MockDatastore{}
+			ds.On("HeadRevision").Return(head, nil).Once()
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(WrittenAtMetadataKey, tc.token))
+			updated := ContextWithHandle(ctx)
+			err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true},
+				},
+			}, ds)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddRevisionToContextExpiredZedToken(t *testing.T) {
+	ds := &proxy_test.MockDatastore{}
+	ds.On("RevisionFromString", zero.String()).Return(zero, nil).Once()
+	ds.On("CheckRevision", zero).Return(datastore.NewInvalidRevisionErr(zero, datastore.RevisionStale)).Once()
+
+	updated := ContextWithHandle(context.Background())
+	err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtExactSnapshot{
+				AtExactSnapshot: zedtoken.NewFromRevision(zero),
+			},
+		},
+	}, ds)
+	require.Equal(t, codes.OutOfRange, status.Code(err))
+	ds.AssertExpectations(t)
+}
+
+func TestAddRevisionToContextFullyConsistentMultipleWrittenAt(t *testing.T) {
+	require := require.New(t)
+
+	later := revision.NewFromDecimal(decimal.NewFromInt(200))
+
+	ds := &proxy_test.MockDatastore{}
+	ds.On("HeadRevision").Return(head, nil).Once()
+	ds.On("RevisionFromString", later.String()).Return(later, nil).Once()
+	ds.On("RevisionFromString", exact.String()).Return(exact, nil).Once()
+	ds.On("CheckRevision", later).Return(nil).Once()
+	ds.On("CheckRevision", exact).Return(nil).Once()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		WrittenAtMetadataKey, zedtoken.NewFromRevision(later).Token,
+		WrittenAtMetadataKey, zedtoken.NewFromRevision(exact).Token,
+	))
+	updated := ContextWithHandle(ctx)
+	err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_FullyConsistent{
+				FullyConsistent: true,
+			},
+		},
+	}, ds)
+	require.NoError(err)
+	require.True(later.Equal(RevisionFromContext(updated)))
+	ds.AssertExpectations(t)
+}
+
+func TestAddRevisionToContextAtExactSnapshotWrittenAt(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		written      []datastore.Revision
+		expectedCode codes.Code
+	}{
+		{"includes all writes", []datastore.Revision{zero, exact}, codes.OK},
+		{"missing a write", []datastore.Revision{exact, head}, codes.FailedPrecondition},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ds := &proxy_test.MockDatastore{}
+			ds.On("RevisionFromString", exact.String()).Return(exact, nil)
+			ds.On("CheckRevision", exact).Return(nil)
+
+			pairs := make([]string, 0, len(tc.written)*2)
+			for _, written := range tc.written {
+				ds.On("RevisionFromString", written.String()).Return(written, nil)
+				ds.On("CheckRevision", written).Return(nil)
+				pairs = append(pairs, WrittenAtMetadataKey, zedtoken.NewFromRevision(written).Token)
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+			updated := ContextWithHandle(ctx)
+			err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtExactSnapshot{
+						AtExactSnapshot: zedtoken.NewFromRevision(exact),
+					},
+				},
+			}, ds)
+			require.Equal(tc.expectedCode, status.Code(err))
+			if tc.expectedCode == codes.OK {
+				require.True(exact.Equal(RevisionFromContext(updated)))
+			}
+		})
+	}
+}
+
+func TestAddRevisionToContextAtExactSnapshotWrittenAtBeforeGCWindow(t *testing.T) {
+	require := require.New(t)
+
+	ds := &proxy_test.MockDatastore{}
+	ds.On("RevisionFromString", exact.String()).Return(exact, nil).Once()
+	ds.On("CheckRevision", exact).Return(nil).Once()
+	ds.On("RevisionFromString", zero.String()).Return(zero, nil).Once()
+	ds.On("CheckRevision", zero).Return(datastore.NewInvalidRevisionErr(zero, datastore.RevisionStale)).Once()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(WrittenAtMetadataKey, zedtoken.NewFromRevision(zero).Token))
+	updated := ContextWithHandle(ctx)
+	err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtExactSnapshot{
+				AtExactSnapshot: zedtoken.NewFromRevision(exact),
+			},
+		},
+	}, ds)
+	require.Error(err)
+	ds.AssertExpectations(t)
+}
+
+func TestAddRevisionToContextAtTime(t *testing.T) {
+	require := require.New(t)
+
+	atTime := time.Date(2022, 10, 1, 15, 0, 0, 0, time.UTC)
+
+	ds := &proxy_test.MockDatastore{}
+	ds.On("RevisionAtTime", atTime).Return(exact, nil).Once()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AtTimeMetadataKey, atTime.Format(time.RFC3339Nano)))
+	updated := ContextWithHandle(ctx)
+	err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{}, ds)
+	require.NoError(err)
+	require.True(exact.Equal(RevisionFromContext(updated)))
+	ds.AssertExpectations(t)
+}
+
+func TestAddRevisionToContextAtTimeBeforeGCWindow(t *testing.T) {
+	require := require.New(t)
+
+	atTime := time.Date(2022, 10, 1, 15, 0, 0, 0, time.UTC)
+
+	ds := &proxy_test.MockDatastore{}
+	ds.On("RevisionAtTime", atTime).Return(datastore.NoRevision, datastore.NewTimestampBeforeGCWindowErr(atTime)).Once()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AtTimeMetadataKey, atTime.Format(time.RFC3339Nano)))
+	updated := ContextWithHandle(ctx)
+	err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{}, ds)
+	require.Equal(codes.OutOfRange, status.Code(err))
+	ds.AssertExpectations(t)
+}
+
+func TestAddRevisionToContextAtTimeInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		atTime      string
+		consistency *v1.Consistency
+	}{
+		{"unparsable", "yesterday at 3pm", nil},
+		{"with consistency", "2022-10-01T15:00:00Z", &v1.Consistency{
+			Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := &proxy_test.MockDatastore{}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(AtTimeMetadataKey, tc.atTime))
+			updated := ContextWithHandle(ctx)
+			err := AddRevisionToContext(updated, &v1.ReadRelationshipsRequest{Consistency: tc.consistency}, ds)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
 func TestAddRevisionToContextAPIAlwaysFullyConsistent(t *testing.T) {
 	require := require.New(t)
 
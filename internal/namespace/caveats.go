@@ -53,3 +53,40 @@ func ValidateCaveatDefinition(caveat *core.CaveatDefinition) error {
 
 	return nil
 }
+
+// SimplifyCaveatDefinition returns a copy of the given caveat definition with its expression
+// simplified, as per caveats.SimplifyCaveat. The definition must have been validated.
+func SimplifyCaveatDefinition(caveat *core.CaveatDefinition) (*core.CaveatDefinition, error) {
+	parameterTypes := make(map[string]caveattypes.VariableType, len(caveat.ParameterTypes))
+	for paramName, paramType := range caveat.ParameterTypes {
+		decoded, err := caveattypes.DecodeParameterType(paramType)
+		if err != nil {
+			return nil, err
+		}
+		parameterTypes[paramName] = *decoded
+	}
+
+	env, err := caveats.EnvForVariables(parameterTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	deserialized, err := caveats.DeserializeCaveat(caveat.SerializedExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	simplified, err := caveats.SimplifyCaveat(env, deserialized)
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := simplified.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	cloned := caveat.CloneVT()
+	cloned.SerializedExpression = serialized
+	return cloned, nil
+}
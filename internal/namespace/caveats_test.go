@@ -58,3 +58,29 @@ func TestValidateCaveatDefinition(t *testing.T) {
 		})
 	}
 }
+
+func TestSimplifyCaveatDefinition(t *testing.T) {
+	require := require.New(t)
+
+	caveat := ns.MustCaveatDefinition(caveats.MustEnvForVariables(
+		map[string]caveattypes.VariableType{
+			"someCondition": caveattypes.IntType,
+			"allowed":       caveattypes.BooleanType,
+		},
+	), "test", "(someCondition == 42 && true) || (someCondition == 42 && allowed) || false")
+
+	simplified, err := SimplifyCaveatDefinition(caveat)
+	require.NoError(err)
+	require.Equal(caveat.Name, simplified.Name)
+	require.Equal(caveat.ParameterTypes, simplified.ParameterTypes)
+	require.NoError(ValidateCaveatDefinition(simplified))
+
+	deserialized, err := caveats.DeserializeCaveat(simplified.SerializedExpression)
+	require.NoError(err)
+
+	exprString, err := deserialized.ExprString()
+	require.NoError(err)
+
+	// The absorbed operand is kept, as it is the only one referencing `allowed`.
+	require.Equal("someCondition == 42 || someCondition == 42 && allowed", exprString)
+}
@@ -0,0 +1,180 @@
+package namespace
+
+import (
+	"sort"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// RelationDependencies is the dependency graph of the relations and permissions of a set of
+// definitions, as statically computed from their rewrites and type information.
+//
+// A relation or permission depends upon:
+//   - each relation or permission it references via a computed userset
+//   - the tupleset relation of each arrow, and the arrowed relation or permission on each type
+//     allowed on the tupleset relation
+//   - each subject relation allowed on it, such as `group#member` for `relation viewer: group#member`
+type RelationDependencies struct {
+	direct     map[string][]*core.RelationReference
+	dependents map[string][]*core.RelationReference
+}
+
+// ComputeRelationDependencies statically computes the dependency graph of the relations and
+// permissions of the given definitions. Relations and permissions of definitions not found in
+// the given set are treated as not existing.
+func ComputeRelationDependencies(definitions []*core.NamespaceDefinition) *RelationDependencies {
+	relations := make(map[string]map[string]*core.Relation, len(definitions))
+	for _, def := range definitions {
+		byName := make(map[string]*core.Relation, len(def.Relation))
+		for _, rel := range def.Relation {
+			byName[rel.Name] = rel
+		}
+		relations[def.Name] = byName
+	}
+
+	dc := &dependencyCollector{relations: relations}
+
+	rd := &RelationDependencies{
+		direct:     map[string][]*core.RelationReference{},
+		dependents: map[string][]*core.RelationReference{},
+	}
+	for _, def := range definitions {
+		for _, rel := range def.Relation {
+			dc.found = nil
+			dc.seen = map[string]struct{}{}
+
+			for _, allowed := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+				if allowed.GetPublicWildcard() != nil || allowed.GetRelation() == tuple.Ellipsis {
+					continue
+				}
+				dc.add(allowed.Namespace, allowed.GetRelation())
+			}
+
+			if rel.UsersetRewrite != nil {
+				dc.collectRewrite(def.Name, rel.UsersetRewrite)
+			}
+
+			rd.direct[relationKey(def.Name, rel.Name)] = sortedReferences(dc.found)
+			for _, dependency := range dc.found {
+				key := relationKey(dependency.Namespace, dependency.Relation)
+				rd.dependents[key] = append(rd.dependents[key], tuple.RelationReference(def.Name, rel.Name))
+			}
+		}
+	}
+
+	return rd
+}
+
+// DirectDependencies returns the relations and permissions which the given relation or
+// permission directly depends upon.
+func (rd *RelationDependencies) DirectDependencies(namespaceName, relationName string) []*core.RelationReference {
+	return rd.direct[relationKey(namespaceName, relationName)]
+}
+
+// TransitiveDependencies returns the transitive closure of the relations and permissions which
+// the given relation or permission depends upon. The given relation or permission is only
+// included if it depends upon itself, such as via a recursive arrow.
+func (rd *RelationDependencies) TransitiveDependencies(namespaceName, relationName string) []*core.RelationReference {
+	return rd.closure(namespaceName, relationName, func(key string) []*core.RelationReference {
+		return rd.direct[key]
+	})
+}
+
+// AffectedBy returns the relations and permissions which transitively depend upon the given
+// relation or permission, and are therefore affected by a change to it.
+func (rd *RelationDependencies) AffectedBy(namespaceName, relationName string) []*core.RelationReference {
+	return rd.closure(namespaceName, relationName, func(key string) []*core.RelationReference {
+		return rd.dependents[key]
+	})
+}
+
+func (rd *RelationDependencies) closure(namespaceName, relationName string, next func(key string) []*core.RelationReference) []*core.RelationReference {
+	visited := map[string]struct{}{}
+	var found []*core.RelationReference
+
+	queue := []string{relationKey(namespaceName, relationName)}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+
+		for _, ref := range next(key) {
+			refKey := relationKey(ref.Namespace, ref.Relation)
+			if _, ok := visited[refKey]; ok {
+				continue
+			}
+
+			visited[refKey] = struct{}{}
+			found = append(found, ref)
+			queue = append(queue, refKey)
+		}
+	}
+
+	return sortedReferences(found)
+}
+
+type dependencyCollector struct {
+	relations map[string]map[string]*core.Relation
+	found     []*core.RelationReference
+	seen      map[string]struct{}
+}
+
+func (dc *dependencyCollector) add(namespaceName, relationName string) {
+	key := relationKey(namespaceName, relationName)
+	if _, ok := dc.seen[key]; ok {
+		return
+	}
+
+	dc.seen[key] = struct{}{}
+	dc.found = append(dc.found, tuple.RelationReference(namespaceName, relationName))
+}
+
+func (dc *dependencyCollector) collectRewrite(namespaceName string, rewrite *core.UsersetRewrite) {
+	var setOp *core.SetOperation
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		setOp = rw.Union
+	case *core.UsersetRewrite_Intersection:
+		setOp = rw.Intersection
+	case *core.UsersetRewrite_Exclusion:
+		setOp = rw.Exclusion
+	default:
+		return
+	}
+
+	for _, setOpChild := range setOp.Child {
+		switch child := setOpChild.ChildType.(type) {
+		case *core.SetOperation_Child_ComputedUserset:
+			dc.add(namespaceName, child.ComputedUserset.Relation)
+
+		case *core.SetOperation_Child_UsersetRewrite:
+			dc.collectRewrite(namespaceName, child.UsersetRewrite)
+
+		case *core.SetOperation_Child_TupleToUserset:
+			tuplesetName := child.TupleToUserset.Tupleset.Relation
+			computedName := child.TupleToUserset.ComputedUserset.Relation
+			dc.add(namespaceName, tuplesetName)
+
+			tupleset, ok := dc.relations[namespaceName][tuplesetName]
+			if !ok {
+				continue
+			}
+
+			for _, allowed := range tupleset.GetTypeInformation().GetAllowedDirectRelations() {
+				if _, ok := dc.relations[allowed.Namespace][computedName]; ok {
+					dc.add(allowed.Namespace, computedName)
+				}
+			}
+		}
+	}
+}
+
+func sortedReferences(refs []*core.RelationReference) []*core.RelationReference {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Namespace != refs[j].Namespace {
+			return refs[i].Namespace < refs[j].Namespace
+		}
+		return refs[i].Relation < refs[j].Relation
+	})
+	return refs
+}
@@ -0,0 +1,136 @@
+package namespace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestComputeRelationDependencies(t *testing.T) {
+	schema := `definition user {}
+
+	definition group {
+		relation member: user | group#member
+	}
+
+	definition organization {
+		relation admin: user
+	}
+
+	definition folder {
+		relation parent: folder | organization
+		relation viewer: user | user:* | group#member
+		relation banned: user
+		permission admin = parent->admin
+		permission view = (viewer - banned) + parent->view
+	}
+
+	definition document {
+		relation parent: folder
+		relation viewer: user
+		relation editor: user
+		permission edit = editor & parent->admin
+		permission view = viewer + edit + parent->view
+	}`
+
+	testCases := []struct {
+		name               string
+		relation           string
+		expectedDirect     []string
+		expectedTransitive []string
+		expectedAffectedBy []string
+	}{
+		{
+			"relation with subject relation",
+			"folder#viewer",
+			[]string{"group#member"},
+			[]string{"group#member"},
+			[]string{"document#view", "folder#view"},
+		},
+		{
+			"recursive subject relation",
+			"group#member",
+			[]string{"group#member"},
+			[]string{"group#member"},
+			[]string{"document#view", "folder#view", "folder#viewer", "group#member"},
+		},
+		{
+			"recursive arrow",
+			"folder#view",
+			[]string{"folder#banned", "folder#parent", "folder#view", "folder#viewer"},
+			[]string{"folder#banned", "folder#parent", "folder#view", "folder#viewer", "group#member"},
+			[]string{"document#view", "folder#view"},
+		},
+		{
+			"arrow over multiple types",
+			"folder#admin",
+			[]string{"folder#admin", "folder#parent", "organization#admin"},
+			[]string{"folder#admin", "folder#parent", "organization#admin"},
+			[]string{"document#edit", "document#view", "folder#admin"},
+		},
+		{
+			"permission across definitions",
+			"document#view",
+			[]string{"document#edit", "document#parent", "document#viewer", "folder#view"},
+			[]string{
+				"document#edit", "document#editor", "document#parent", "document#viewer",
+				"folder#admin", "folder#banned", "folder#parent", "folder#view", "folder#viewer",
+				"group#member", "organization#admin",
+			},
+			nil,
+		},
+		{
+			"leaf relation",
+			"organization#admin",
+			nil,
+			nil,
+			[]string{"document#edit", "document#view", "folder#admin"},
+		},
+		{
+			"unknown relation",
+			"document#unknown",
+			nil,
+			nil,
+			nil,
+		},
+	}
+
+	empty := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, &empty)
+	require.NoError(t, err)
+
+	deps := ComputeRelationDependencies(compiled.ObjectDefinitions)
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			namespaceName, relationName, _ := strings.Cut(tc.relation, "#")
+			require.Equal(tc.expectedDirect, relationStrings(deps.DirectDependencies(namespaceName, relationName)))
+			require.Equal(tc.expectedTransitive, relationStrings(deps.TransitiveDependencies(namespaceName, relationName)))
+			require.Equal(tc.expectedAffectedBy, relationStrings(deps.AffectedBy(namespaceName, relationName)))
+		})
+	}
+}
+
+func relationStrings(refs []*core.RelationReference) []string {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	strs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		strs = append(strs, tuple.StringRR(ref))
+	}
+	return strs
+}
@@ -169,6 +169,27 @@ func (err ErrMissingAllowedRelations) DetailsMetadata() map[string]string {
 	}
 }
 
+// ErrAllowedRelationsOnPermission occurs when a permission, which has no direct relationships,
+// declares allowed relations.
+type ErrAllowedRelationsOnPermission struct {
+	error
+	namespaceName  string
+	permissionName string
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrAllowedRelationsOnPermission) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("permission", err.permissionName)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrAllowedRelationsOnPermission) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"permission_name": err.permissionName,
+	}
+}
+
 // ErrTransitiveWildcard occurs when a wildcard relation in turn references another wildcard
 // relation.
 type ErrTransitiveWildcard struct {
@@ -325,6 +346,15 @@ func NewMissingAllowedRelationsErr(nsName string, relationName string) error {
 	}
 }
 
+// NewAllowedRelationsOnPermissionErr constructs an error indicating that a permission declares allowed relations.
+func NewAllowedRelationsOnPermissionErr(nsName string, permissionName string) error {
+	return ErrAllowedRelationsOnPermission{
+		error:          fmt.Errorf("direct relations are not allowed under permission `%s` under definition `%s`", permissionName, nsName),
+		namespaceName:  nsName,
+		permissionName: permissionName,
+	}
+}
+
 // NewTransitiveWildcardErr constructs an error indicating that a transitive wildcard exists.
 func NewTransitiveWildcardErr(nsName string, relationName string, foundRelationNamespace string, foundRelationName string, wildcardTypeName string, wildcardRelationReference string) error {
 	return ErrTransitiveWildcard{
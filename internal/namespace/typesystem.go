@@ -416,10 +416,12 @@ func (nts *TypeSystem) Validate(ctx context.Context) (*ValidatedNamespaceTypeSys
 				)
 			}
 		} else {
+			// A permission has no direct relationships, so any allowed relations would be ignored
+			// and the definition would be rendered ambiguously as schema. This cannot occur with
+			// compiled schema, only with malformed definitions.
 			if len(allowedDirectRelations) != 0 {
-				// NOTE: This is a legacy error and should never really occur with schema.
 				return nil, newTypeErrorWithSource(
-					fmt.Errorf("direct relations are not allowed under relation `%s`", relation.Name),
+					NewAllowedRelationsOnPermissionErr(nts.nsDef.Name, relation.Name),
 					relation, relation.Name)
 			}
 		}
@@ -92,7 +92,7 @@ func TestTypeSystem(t *testing.T) {
 			),
 			[]*core.NamespaceDefinition{},
 			nil,
-			"direct relations are not allowed under relation `editor`",
+			"direct relations are not allowed under permission `editor` under definition `document`",
 		},
 		{
 			"relation in relation types has invalid namespace",
@@ -0,0 +1,224 @@
+package namespace
+
+import (
+	"fmt"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// UnreachablePermission is a warning for a permission which can never resolve to any subject.
+type UnreachablePermission struct {
+	// Namespace is the name of the definition containing the permission.
+	Namespace string
+
+	// Permission is the name of the permission.
+	Permission string
+
+	// Reason describes why the permission can never resolve.
+	Reason string
+}
+
+func (up UnreachablePermission) String() string {
+	return fmt.Sprintf("permission `%s` under definition `%s` can never resolve to any subject: %s", up.Permission, up.Namespace, up.Reason)
+}
+
+// FindUnreachablePermissions statically analyzes the definitions and returns a warning for each
+// permission that can never resolve to any subject, such as a permission whose every branch
+// references a relation that does not exist, or an intersection with an empty branch.
+//
+// Relations and permissions of definitions not found in the given set are treated as not
+// existing.
+func FindUnreachablePermissions(definitions []*core.NamespaceDefinition) []UnreachablePermission {
+	ra := &reachabilityAnalyzer{
+		relations: make(map[string]map[string]*core.Relation, len(definitions)),
+		reachable: map[string]struct{}{},
+	}
+	for _, def := range definitions {
+		byName := make(map[string]*core.Relation, len(def.Relation))
+		for _, rel := range def.Relation {
+			byName[rel.Name] = rel
+		}
+		ra.relations[def.Name] = byName
+	}
+
+	// Compute the least fixed point of reachability, so that relations which only reference
+	// themselves, directly or through a cycle, are found to be unreachable.
+	for changed := true; changed; {
+		changed = false
+		for _, def := range definitions {
+			for _, rel := range def.Relation {
+				key := relationKey(def.Name, rel.Name)
+				if _, ok := ra.reachable[key]; ok {
+					continue
+				}
+
+				if ra.relationReachable(def.Name, rel) {
+					ra.reachable[key] = struct{}{}
+					changed = true
+				}
+			}
+		}
+	}
+
+	var unreachable []UnreachablePermission
+	for _, def := range definitions {
+		for _, rel := range def.Relation {
+			if rel.UsersetRewrite == nil {
+				continue
+			}
+
+			if _, ok := ra.reachable[relationKey(def.Name, rel.Name)]; ok {
+				continue
+			}
+
+			var missing []string
+			ra.collectMissing(def.Name, rel.UsersetRewrite, &missing)
+
+			reason := "every referenced relation or permission can never resolve to any subject"
+			if len(missing) > 0 {
+				reason = strings.Join(missing, "; ")
+			}
+
+			unreachable = append(unreachable, UnreachablePermission{
+				Namespace:  def.Name,
+				Permission: rel.Name,
+				Reason:     reason,
+			})
+		}
+	}
+
+	return unreachable
+}
+
+type reachabilityAnalyzer struct {
+	relations map[string]map[string]*core.Relation
+	reachable map[string]struct{}
+}
+
+func (ra *reachabilityAnalyzer) lookupRelation(namespaceName, relationName string) *core.Relation {
+	return ra.relations[namespaceName][relationName]
+}
+
+func (ra *reachabilityAnalyzer) isReachable(namespaceName, relationName string) bool {
+	_, ok := ra.reachable[relationKey(namespaceName, relationName)]
+	return ok
+}
+
+func (ra *reachabilityAnalyzer) relationReachable(namespaceName string, relation *core.Relation) bool {
+	if relation.UsersetRewrite == nil {
+		return len(relation.GetTypeInformation().GetAllowedDirectRelations()) > 0
+	}
+
+	return ra.rewriteReachable(namespaceName, relation, relation.UsersetRewrite)
+}
+
+func (ra *reachabilityAnalyzer) rewriteReachable(namespaceName string, relation *core.Relation, rewrite *core.UsersetRewrite) bool {
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		for _, child := range rw.Union.Child {
+			if ra.childReachable(namespaceName, relation, child) {
+				return true
+			}
+		}
+		return false
+
+	case *core.UsersetRewrite_Intersection:
+		for _, child := range rw.Intersection.Child {
+			if !ra.childReachable(namespaceName, relation, child) {
+				return false
+			}
+		}
+		return len(rw.Intersection.Child) > 0
+
+	case *core.UsersetRewrite_Exclusion:
+		// Only the base of an exclusion determines whether it can resolve to any subject.
+		return len(rw.Exclusion.Child) > 0 && ra.childReachable(namespaceName, relation, rw.Exclusion.Child[0])
+
+	default:
+		return false
+	}
+}
+
+func (ra *reachabilityAnalyzer) childReachable(namespaceName string, relation *core.Relation, setOpChild *core.SetOperation_Child) bool {
+	switch child := setOpChild.ChildType.(type) {
+	case *core.SetOperation_Child_XThis:
+		return len(relation.GetTypeInformation().GetAllowedDirectRelations()) > 0
+
+	case *core.SetOperation_Child_XNil:
+		return false
+
+	case *core.SetOperation_Child_ComputedUserset:
+		return ra.isReachable(namespaceName, child.ComputedUserset.Relation)
+
+	case *core.SetOperation_Child_UsersetRewrite:
+		return ra.rewriteReachable(namespaceName, relation, child.UsersetRewrite)
+
+	case *core.SetOperation_Child_TupleToUserset:
+		tuplesetName := child.TupleToUserset.Tupleset.Relation
+		tupleset := ra.lookupRelation(namespaceName, tuplesetName)
+		if tupleset == nil || !ra.isReachable(namespaceName, tuplesetName) {
+			return false
+		}
+
+		for _, allowed := range tupleset.GetTypeInformation().GetAllowedDirectRelations() {
+			if ra.isReachable(allowed.Namespace, child.TupleToUserset.ComputedUserset.Relation) {
+				return true
+			}
+		}
+		return false
+
+	default:
+		return false
+	}
+}
+
+// collectMissing collects a description of each relation or permission referenced by the
+// rewrite that does not exist.
+func (ra *reachabilityAnalyzer) collectMissing(namespaceName string, rewrite *core.UsersetRewrite, missing *[]string) {
+	var setOp *core.SetOperation
+	switch rw := rewrite.RewriteOperation.(type) {
+	case *core.UsersetRewrite_Union:
+		setOp = rw.Union
+	case *core.UsersetRewrite_Intersection:
+		setOp = rw.Intersection
+	case *core.UsersetRewrite_Exclusion:
+		setOp = rw.Exclusion
+	default:
+		return
+	}
+
+	for _, setOpChild := range setOp.Child {
+		switch child := setOpChild.ChildType.(type) {
+		case *core.SetOperation_Child_ComputedUserset:
+			if ra.lookupRelation(namespaceName, child.ComputedUserset.Relation) == nil {
+				*missing = append(*missing, fmt.Sprintf("relation/permission `%s` does not exist", child.ComputedUserset.Relation))
+			}
+
+		case *core.SetOperation_Child_UsersetRewrite:
+			ra.collectMissing(namespaceName, child.UsersetRewrite, missing)
+
+		case *core.SetOperation_Child_TupleToUserset:
+			tuplesetName := child.TupleToUserset.Tupleset.Relation
+			computedName := child.TupleToUserset.ComputedUserset.Relation
+
+			tupleset := ra.lookupRelation(namespaceName, tuplesetName)
+			if tupleset == nil {
+				*missing = append(*missing, fmt.Sprintf("relation `%s` does not exist", tuplesetName))
+				continue
+			}
+
+			found := false
+			for _, allowed := range tupleset.GetTypeInformation().GetAllowedDirectRelations() {
+				if ra.lookupRelation(allowed.Namespace, computedName) != nil {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				*missing = append(*missing, fmt.Sprintf("relation/permission `%s` does not exist on any type of relation `%s`", computedName, tuplesetName))
+			}
+		}
+	}
+}
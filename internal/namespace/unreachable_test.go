@@ -0,0 +1,127 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+func TestFindUnreachablePermissions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   string
+		expected []UnreachablePermission
+	}{
+		{
+			"all reachable",
+			`definition user {}
+
+			definition folder {
+				relation viewer: user
+				permission view = viewer
+			}
+
+			definition document {
+				relation parent: folder
+				relation viewer: user
+				relation editor: user
+				permission edit = editor
+				permission view = viewer + edit + parent->view
+				permission view_and_edit = viewer & edit
+				permission view_not_edit = viewer - edit
+			}`,
+			nil,
+		},
+		{
+			"missing computed userset",
+			`definition user {}
+
+			definition document {
+				relation viewer: user
+				permission view = viewer + editor
+				permission only_missing = editor
+			}`,
+			[]UnreachablePermission{
+				{"document", "only_missing", "relation/permission `editor` does not exist"},
+			},
+		},
+		{
+			"missing arrow target",
+			`definition user {}
+
+			definition folder {
+				relation viewer: user
+			}
+
+			definition document {
+				relation parent: folder
+				permission view = parent->view
+				permission other = missing->view
+			}`,
+			[]UnreachablePermission{
+				{"document", "view", "relation/permission `view` does not exist on any type of relation `parent`"},
+				{"document", "other", "relation `missing` does not exist"},
+			},
+		},
+		{
+			"empty intersection",
+			`definition user {}
+
+			definition document {
+				relation viewer: user
+				permission empty = nil
+				permission view = viewer & empty
+				permission exclusion = empty - viewer
+			}`,
+			[]UnreachablePermission{
+				{"document", "empty", "every referenced relation or permission can never resolve to any subject"},
+				{"document", "view", "every referenced relation or permission can never resolve to any subject"},
+				{"document", "exclusion", "every referenced relation or permission can never resolve to any subject"},
+			},
+		},
+		{
+			"self-referential cycle",
+			`definition user {}
+
+			definition document {
+				relation viewer: user
+				permission first = second
+				permission second = first
+				permission view = viewer + first
+			}`,
+			[]UnreachablePermission{
+				{"document", "first", "every referenced relation or permission can never resolve to any subject"},
+				{"document", "second", "every referenced relation or permission can never resolve to any subject"},
+			},
+		},
+		{
+			"recursive arrow",
+			`definition user {}
+
+			definition folder {
+				relation parent: folder
+				relation viewer: user
+				permission view = viewer + parent->view
+			}`,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			empty := ""
+			compiled, err := compiler.Compile(compiler.InputSchema{
+				Source:       input.Source("schema"),
+				SchemaString: tc.schema,
+			}, &empty)
+			require.NoError(err)
+
+			require.Equal(tc.expected, FindUnreachablePermissions(compiled.ObjectDefinitions))
+		})
+	}
+}
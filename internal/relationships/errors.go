@@ -2,6 +2,8 @@ package relationships
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/authzed/spicedb/internal/namespace"
 
@@ -53,6 +55,50 @@ func (err ErrInvalidSubjectType) GRPCStatus() *status.Status {
 	)
 }
 
+// ErrInvalidSubjectTypes indicates that a write was attempted with one or more relationships whose
+// subject types are not allowed on their relations.
+type ErrInvalidSubjectTypes struct {
+	error
+	violations []ErrInvalidSubjectType
+}
+
+// NewInvalidSubjectTypesError constructs a new error listing each relationship in a write whose
+// subject type is not allowed.
+func NewInvalidSubjectTypesError(violations []ErrInvalidSubjectType) ErrInvalidSubjectTypes {
+	descriptions := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		descriptions = append(descriptions, fmt.Sprintf("`%s`: %s", tuple.MustString(violation.update.Tuple), violation.Error()))
+	}
+
+	return ErrInvalidSubjectTypes{
+		error: fmt.Errorf(
+			"%d relationship(s) have subject types not allowed by the schema: %s",
+			len(violations),
+			strings.Join(descriptions, "; "),
+		),
+		violations: violations,
+	}
+}
+
+// Violations returns the error for each relationship whose subject type is not allowed.
+func (err ErrInvalidSubjectTypes) Violations() []ErrInvalidSubjectType {
+	return err.violations
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrInvalidSubjectTypes) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_INVALID_SUBJECT_TYPE,
+			map[string]string{
+				"violation_count": strconv.Itoa(len(err.violations)),
+			},
+		),
+	)
+}
+
 // ErrCannotWriteToPermission indicates that a write was attempted on a permission.
 type ErrCannotWriteToPermission struct {
 	error
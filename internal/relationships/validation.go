@@ -3,6 +3,9 @@ package relationships
 import (
 	"context"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/pkg/caveats"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -18,6 +21,36 @@ func ValidateRelationshipUpdates(
 	ctx context.Context,
 	rwt datastore.ReadWriteTransaction,
 	updates []*core.RelationTupleUpdate,
+) error {
+	return validateRelationshipUpdates(ctx, rwt, updates, true)
+}
+
+// ValidateRelationshipUpdatesWithRelaxedObjectIDs performs the validation of
+// ValidateRelationshipUpdates, except that object IDs are not validated against the pattern of
+// the API. Callers are expected to have validated the object IDs by rules of their own. A
+// wildcard resource ID is still rejected.
+func ValidateRelationshipUpdatesWithRelaxedObjectIDs(
+	ctx context.Context,
+	rwt datastore.ReadWriteTransaction,
+	updates []*core.RelationTupleUpdate,
+) error {
+	return validateRelationshipUpdates(ctx, rwt, updates, false)
+}
+
+// validateResourceIDNotWildcard ensures that the resource ID of the update is not a wildcard.
+func validateResourceIDNotWildcard(update *core.RelationTupleUpdate) error {
+	if update.Tuple.ResourceAndRelation.ObjectId == tuple.PublicWildcard {
+		return status.Errorf(codes.InvalidArgument, "%s", tuple.ValidateResourceID(update.Tuple.ResourceAndRelation.ObjectId))
+	}
+
+	return nil
+}
+
+func validateRelationshipUpdates(
+	ctx context.Context,
+	rwt datastore.ReadWriteTransaction,
+	updates []*core.RelationTupleUpdate,
+	strictObjectIDs bool,
 ) error {
 	// Load caveats, if any.
 	var referencedCaveatMap map[string]*core.CaveatDefinition
@@ -45,11 +78,15 @@ func ValidateRelationshipUpdates(
 	// Check each update.
 	for _, update := range updates {
 		// Validate the IDs of the resource and subject.
-		if err := tuple.ValidateResourceID(update.Tuple.ResourceAndRelation.ObjectId); err != nil {
-			return err
-		}
+		if strictObjectIDs {
+			if err := tuple.ValidateResourceID(update.Tuple.ResourceAndRelation.ObjectId); err != nil {
+				return err
+			}
 
-		if err := tuple.ValidateSubjectID(update.Tuple.Subject.ObjectId); err != nil {
+			if err := tuple.ValidateSubjectID(update.Tuple.Subject.ObjectId); err != nil {
+				return err
+			}
+		} else if err := validateResourceIDNotWildcard(update); err != nil {
 			return err
 		}
 
@@ -149,3 +186,61 @@ func hasNonEmptyCaveatContext(update *core.RelationTupleUpdate) bool {
 		update.Tuple.Caveat.Context != nil &&
 		len(update.Tuple.Caveat.Context.GetFields()) > 0
 }
+
+// ValidateRelationshipSubjectTypes checks the subject of each relationship being created or touched
+// against the types allowed on its relation, including the wildcard and caveated forms. Unlike
+// ValidateRelationshipUpdates, it does not stop at the first violation: all violations are
+// collected and returned in a single ErrInvalidSubjectTypes.
+func ValidateRelationshipSubjectTypes(
+	ctx context.Context,
+	reader datastore.Reader,
+	updates []*core.RelationTupleUpdate,
+) error {
+	typeSystems := make(map[string]*namespace.TypeSystem)
+	var violations []ErrInvalidSubjectType
+	for _, update := range updates {
+		if update.Operation == core.RelationTupleUpdate_DELETE {
+			continue
+		}
+
+		nsName := update.Tuple.ResourceAndRelation.Namespace
+		ts, ok := typeSystems[nsName]
+		if !ok {
+			_, loaded, err := namespace.ReadNamespaceAndTypes(ctx, nsName, reader)
+			if err != nil {
+				return err
+			}
+			typeSystems[nsName] = loaded
+			ts = loaded
+		}
+
+		var caveat *core.AllowedCaveat
+		if update.Tuple.Caveat != nil {
+			caveat = ns.AllowedCaveat(update.Tuple.Caveat.CaveatName)
+		}
+
+		var relationToCheck *core.AllowedRelation
+		if update.Tuple.Subject.ObjectId == tuple.PublicWildcard {
+			relationToCheck = ns.AllowedPublicNamespaceWithCaveat(update.Tuple.Subject.Namespace, caveat)
+		} else {
+			relationToCheck = ns.AllowedRelationWithCaveat(
+				update.Tuple.Subject.Namespace,
+				update.Tuple.Subject.Relation,
+				caveat)
+		}
+
+		isAllowed, err := ts.HasAllowedRelation(update.Tuple.ResourceAndRelation.Relation, relationToCheck)
+		if err != nil {
+			return err
+		}
+
+		if isAllowed != namespace.AllowedRelationValid {
+			violations = append(violations, NewInvalidSubjectTypeError(update, relationToCheck))
+		}
+	}
+
+	if len(violations) > 0 {
+		return NewInvalidSubjectTypesError(violations)
+	}
+	return nil
+}
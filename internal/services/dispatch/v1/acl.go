@@ -43,9 +43,30 @@ func (ds *dispatchServer) DispatchExpand(ctx context.Context, req *dispatchv1.Di
 	return resp, rewriteGraphError(ctx, err)
 }
 
+// DispatchLookup serves lookups dispatched by nodes which predate DispatchStreamingLookup, by
+// collecting the results of the lookup into a single response.
 func (ds *dispatchServer) DispatchLookup(ctx context.Context, req *dispatchv1.DispatchLookupRequest) (*dispatchv1.DispatchLookupResponse, error) {
-	resp, err := ds.localDispatch.DispatchLookup(ctx, req)
-	return resp, rewriteGraphError(ctx, err)
+	stream := dispatch.NewCollectingDispatchStream[*dispatchv1.DispatchLookupResponse](ctx)
+	if err := ds.localDispatch.DispatchLookup(req, stream); err != nil {
+		return nil, rewriteGraphError(ctx, err)
+	}
+
+	collected := &dispatchv1.DispatchLookupResponse{Metadata: &dispatchv1.ResponseMeta{}}
+	for _, result := range stream.Results() {
+		dispatch.AddResponseMetadata(collected.Metadata, result.Metadata)
+		collected.ResolvedResources = append(collected.ResolvedResources, result.ResolvedResources...)
+		collected.Truncated = collected.Truncated || result.Truncated
+	}
+	return collected, nil
+}
+
+func (ds *dispatchServer) DispatchStreamingLookup(
+	req *dispatchv1.DispatchLookupRequest,
+	resp dispatchv1.DispatchService_DispatchStreamingLookupServer,
+) error {
+	err := ds.localDispatch.DispatchLookup(req,
+		dispatch.WrapGRPCStream[*dispatchv1.DispatchLookupResponse](resp))
+	return rewriteGraphError(resp.Context(), err)
 }
 
 func (ds *dispatchServer) DispatchReachableResources(
@@ -79,6 +100,11 @@ func rewriteGraphError(ctx context.Context, err error) error {
 	case err == nil:
 		return nil
 
+	case errors.As(err, &graph.ErrIntermediateResultLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	case errors.As(err, &graph.ErrLeafSubjectLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+
 	case errors.As(err, &graph.ErrAlwaysFail{}):
 		fallthrough
 	default:
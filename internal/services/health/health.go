@@ -10,16 +10,23 @@ import (
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
 )
 
-const datastoreReadyTimeout = time.Millisecond * 500
+const (
+	datastoreReadyTimeout       = time.Millisecond * 500
+	datastoreHealthCheckTimeout = time.Second * 2
+	datastoreHealthCheckPeriod  = time.Second * 10
+)
 
 // NewHealthManager creates and returns a new health manager that checks the IsReady
 // status of the given dispatcher and datastore checker and sets the health check to
-// return healthy once both have gone to true.
+// return healthy once both have gone to true. Once healthy, the health check of the
+// datastore is run periodically, and the services are reported as not serving for as
+// long as the datastore is unreachable.
 func NewHealthManager(dispatcher dispatch.Dispatcher, dsc DatastoreChecker) Manager {
 	healthSvc := grpcutil.NewAuthlessHealthServer()
-	return &healthManager{healthSvc, dispatcher, dsc, map[string]struct{}{}}
+	return &healthManager{healthSvc, dispatcher, dsc, map[string]struct{}{}, datastoreHealthCheckPeriod}
 }
 
 // DatastoreChecker is an interface for determining if the datastore is ready for
@@ -27,6 +34,9 @@ func NewHealthManager(dispatcher dispatch.Dispatcher, dsc DatastoreChecker) Mana
 type DatastoreChecker interface {
 	// IsReady returns whether the datastore is ready to be used.
 	IsReady(ctx context.Context) (bool, error)
+
+	// HealthCheck checks whether the backing store of the datastore can be reached.
+	HealthCheck(ctx context.Context) datastore.HealthCheckResult
 }
 
 // Manager is a system which manages the health service statuses.
@@ -47,6 +57,8 @@ type healthManager struct {
 	dispatcher   dispatch.Dispatcher
 	dsc          DatastoreChecker
 	serviceNames map[string]struct{}
+
+	healthCheckPeriod time.Duration
 }
 
 func (hm *healthManager) HealthSvc() *grpcutil.AuthlessHealthServer {
@@ -79,9 +91,8 @@ func (hm *healthManager) Checker(ctx context.Context) func() error {
 
 			isReady := hm.checkIsReady(ctx)
 			if isReady {
-				for serviceName := range hm.serviceNames {
-					hm.healthSvc.Server.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
-				}
+				hm.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+				hm.monitorHealth(ctx)
 				return nil
 			}
 
@@ -108,5 +119,60 @@ func (hm *healthManager) checkIsReady(ctx context.Context) bool {
 
 	dispatchReady := hm.dispatcher.IsReady()
 	log.Debug().Bool("datastoreReady", dsReady).Bool("dispatchReady", dispatchReady).Msg("completed dispatcher and datastore readiness checks")
-	return dsReady && dispatchReady
+	return dsReady && dispatchReady && hm.checkIsHealthy(ctx)
+}
+
+// monitorHealth runs the health check of the datastore every health check period until the
+// context is canceled, reporting the services as not serving while the datastore is
+// unreachable.
+func (hm *healthManager) monitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(hm.healthCheckPeriod)
+	defer ticker.Stop()
+
+	wasHealthy := true
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		isHealthy := hm.checkIsHealthy(ctx)
+		if isHealthy == wasHealthy {
+			continue
+		}
+
+		if isHealthy {
+			log.Info().Msg("datastore is reachable again, reporting services as serving")
+			hm.setServingStatus(healthpb.HealthCheckResponse_SERVING)
+		} else {
+			hm.setServingStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+		wasHealthy = isHealthy
+	}
+}
+
+// checkIsHealthy runs the health check of the datastore, returning false only if the datastore
+// is unreachable. A degraded datastore can still serve requests, so is only logged.
+func (hm *healthManager) checkIsHealthy(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, datastoreHealthCheckTimeout)
+	defer cancel()
+
+	result := hm.dsc.HealthCheck(ctx)
+	switch result.Status {
+	case datastore.HealthStatusHealthy:
+		return true
+	case datastore.HealthStatusDegraded:
+		log.Warn().Str("reason", result.Reason).Msg("datastore health check reported the datastore as degraded")
+		return true
+	default:
+		log.Warn().Str("status", result.Status.String()).Str("reason", result.Reason).Msg("datastore health check failed")
+		return false
+	}
+}
+
+func (hm *healthManager) setServingStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	for serviceName := range hm.serviceNames {
+		hm.healthSvc.Server.SetServingStatus(serviceName, status)
+	}
 }
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+type readyDispatcher struct {
+	dispatch.Dispatcher
+}
+
+func (readyDispatcher) IsReady() bool {
+	return true
+}
+
+// fakeDatastoreChecker is always ready, and reports the health most recently set.
+type fakeDatastoreChecker struct {
+	sync.Mutex
+	result datastore.HealthCheckResult
+}
+
+func (fdc *fakeDatastoreChecker) IsReady(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (fdc *fakeDatastoreChecker) HealthCheck(_ context.Context) datastore.HealthCheckResult {
+	fdc.Lock()
+	defer fdc.Unlock()
+	return fdc.result
+}
+
+func (fdc *fakeDatastoreChecker) setResult(result datastore.HealthCheckResult) {
+	fdc.Lock()
+	defer fdc.Unlock()
+	fdc.result = result
+}
+
+func TestHealthManagerReportsDatastoreHealth(t *testing.T) {
+	const serviceName = "some.Service"
+
+	dsc := &fakeDatastoreChecker{result: datastore.Unreachable(errors.New("connection refused"))}
+	hm := &healthManager{
+		healthSvc:         grpcutil.NewAuthlessHealthServer(),
+		dispatcher:        readyDispatcher{},
+		dsc:               dsc,
+		serviceNames:      map[string]struct{}{},
+		healthCheckPeriod: 10 * time.Millisecond,
+	}
+	hm.RegisterReportedService(serviceName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	checkerDone := make(chan error, 1)
+	go func() {
+		checkerDone <- hm.Checker(ctx)()
+	}()
+
+	requireStatus := func(expected healthpb.HealthCheckResponse_ServingStatus) {
+		require.Eventually(t, func() bool {
+			resp, err := hm.healthSvc.Server.Check(ctx, &healthpb.HealthCheckRequest{Service: serviceName})
+			require.NoError(t, err)
+			return resp.Status == expected
+		}, 5*time.Second, 5*time.Millisecond)
+	}
+
+	// An unreachable datastore is never reported as serving, even when ready.
+	time.Sleep(50 * time.Millisecond)
+	requireStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	dsc.setResult(datastore.Healthy())
+	requireStatus(healthpb.HealthCheckResponse_SERVING)
+
+	// A degraded datastore can still serve.
+	dsc.setResult(datastore.Degraded("pool exhausted"))
+	time.Sleep(50 * time.Millisecond)
+	requireStatus(healthpb.HealthCheckResponse_SERVING)
+
+	dsc.setResult(datastore.Unreachable(errors.New("connection refused")))
+	requireStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	dsc.setResult(datastore.Healthy())
+	requireStatus(healthpb.HealthCheckResponse_SERVING)
+
+	cancel()
+	require.NoError(t, <-checkerDone)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/services/health"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
 )
 
 // SchemaServiceOption defines the options for enabling or disabling the V1 Schema service.
@@ -43,6 +44,10 @@ const (
 
 	// CaveatsEnabled indicates that caveats are enabled.
 	CaveatsEnabled CaveatsOption = 1
+
+	// CaveatsEnabledWithoutSimplification indicates that caveats are enabled, and that their
+	// expressions are written as given, rather than simplified. Intended for debugging.
+	CaveatsEnabledWithoutSimplification CaveatsOption = 2
 )
 
 const (
@@ -62,16 +67,48 @@ func RegisterGrpcServices(
 ) {
 	healthManager.RegisterReportedService(OverallServerHealthCheckKey)
 
-	v1.RegisterPermissionsServiceServer(srv, v1svc.NewPermissionsServer(dispatch, permSysConfig, caveatsOption == CaveatsEnabled))
+	caveatsEnabled := caveatsOption == CaveatsEnabled || caveatsOption == CaveatsEnabledWithoutSimplification
+
+	v1.RegisterPermissionsServiceServer(srv, v1svc.NewPermissionsServer(dispatch, permSysConfig, caveatsEnabled))
 	healthManager.RegisterReportedService(v1.PermissionsService_ServiceDesc.ServiceName)
 
+	spicedbv1.RegisterCheckPermissionsServiceServer(srv, v1svc.NewCheckPermissionsServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.CheckPermissionsService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterBulkResourcesCheckServiceServer(srv, v1svc.NewBulkResourcesCheckServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.BulkResourcesCheckService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterBulkSubjectsCheckServiceServer(srv, v1svc.NewBulkSubjectsCheckServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.BulkSubjectsCheckService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterEffectiveRelationshipsServiceServer(srv, v1svc.NewEffectiveRelationshipsServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.EffectiveRelationshipsService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterHeadCheckServiceServer(srv, v1svc.NewHeadCheckServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.HeadCheckService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterMemberSubjectsServiceServer(srv, v1svc.NewMemberSubjectsServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.MemberSubjectsService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterOverlayCheckServiceServer(srv, v1svc.NewOverlayCheckServer(permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.OverlayCheckService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterSubjectRelationsServiceServer(srv, v1svc.NewSubjectRelationsServer(dispatch, permSysConfig))
+	healthManager.RegisterReportedService(spicedbv1.SubjectRelationsService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterZedTokenServiceServer(srv, v1svc.NewZedTokenServer())
+	healthManager.RegisterReportedService(spicedbv1.ZedTokenService_ServiceDesc.ServiceName)
+
+	spicedbv1.RegisterSchemaHashServiceServer(srv, v1svc.NewSchemaHashServer())
+	healthManager.RegisterReportedService(spicedbv1.SchemaHashService_ServiceDesc.ServiceName)
+
 	if watchServiceOption == WatchServiceEnabled {
 		v1.RegisterWatchServiceServer(srv, v1svc.NewWatchServer())
 		healthManager.RegisterReportedService(v1.WatchService_ServiceDesc.ServiceName)
 	}
 
 	if schemaServiceOption == V1SchemaServiceEnabled || schemaServiceOption == V1SchemaServiceAdditiveOnly {
-		v1.RegisterSchemaServiceServer(srv, v1svc.NewSchemaServer(schemaServiceOption == V1SchemaServiceAdditiveOnly, caveatsOption == CaveatsEnabled))
+		v1.RegisterSchemaServiceServer(srv, v1svc.NewSchemaServer(schemaServiceOption == V1SchemaServiceAdditiveOnly, caveatsEnabled, caveatsOption == CaveatsEnabled))
 		healthManager.RegisterReportedService(v1.SchemaService_ServiceDesc.ServiceName)
 	}
 
@@ -70,6 +70,22 @@ func ValidateSchemaChanges(ctx context.Context, compiled *compiler.CompiledSchem
 	}, nil
 }
 
+// SimplifyCaveatDefinitions simplifies the expressions of the caveats defined in the compiled
+// schema in place, so that the expressions written for them are canonical. The caveats must have
+// been validated.
+func SimplifyCaveatDefinitions(compiled *compiler.CompiledSchema) error {
+	for _, caveatDef := range compiled.CaveatDefinitions {
+		simplified, err := namespace.SimplifyCaveatDefinition(caveatDef)
+		if err != nil {
+			return err
+		}
+
+		caveatDef.SerializedExpression = simplified.SerializedExpression
+	}
+
+	return nil
+}
+
 // AppliedSchemaChanges holds information about the applied schema changes.
 type AppliedSchemaChanges struct {
 	// TotalOperationCount holds the total number of "dispatch" operations performed by the schema
@@ -184,7 +200,7 @@ func ApplySchemaChangesOverExisting(
 	if !validated.additiveOnly {
 		// Delete the removed namespaces.
 		if removedObjectDefNames.Len() > 0 {
-			if err := rwt.DeleteNamespaces(ctx, removedObjectDefNames.AsSlice()...); err != nil {
+			if err := rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesOnlyIfEmpty, removedObjectDefNames.AsSlice()...); err != nil {
 				return nil, err
 			}
 		}
@@ -7,8 +7,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
 )
@@ -53,3 +56,24 @@ func TestApplySchemaChanges(t *testing.T) {
 	})
 	require.NoError(err)
 }
+
+func TestValidateSchemaChangesRejectsAllowedRelationsOnPermission(t *testing.T) {
+	// A permission declaring allowed relations cannot be written as schema, so construct the
+	// malformed definition directly.
+	compiled := &compiler.CompiledSchema{
+		ObjectDefinitions: []*core.NamespaceDefinition{
+			ns.Namespace("user"),
+			ns.Namespace(
+				"document",
+				ns.Relation("viewer", nil, ns.AllowedRelation("user", "...")),
+				ns.Relation("view", ns.Union(
+					ns.ComputedUserset("viewer"),
+				), ns.AllowedRelation("user", "...")),
+			),
+		},
+	}
+
+	_, err := ValidateSchemaChanges(context.Background(), compiled, false)
+	require.ErrorAs(t, err, &namespace.ErrAllowedRelationsOnPermission{})
+	require.ErrorAs(t, err, &namespace.TypeError{})
+}
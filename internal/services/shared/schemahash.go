@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/generator"
+)
+
+// schemaHashLength is the number of bytes of the SHA-256 digest kept in a schema hash.
+const schemaHashLength = 16
+
+// ComputeSchemaHash returns a short hex digest of the schema read by the given reader, computed
+// over the caveat and object definitions generated as source, each sorted by name. As the hash
+// only depends on the generated source, which is what ReadSchema returns, it is stable across
+// processes and datastores, and insensitive to the encoding of the stored definitions.
+func ComputeSchemaHash(ctx context.Context, reader datastore.Reader) (string, error) {
+	nsDefs, err := reader.ListNamespaces(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	caveatDefs, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(caveatDefs, func(i, j int) bool {
+		return caveatDefs[i].Name < caveatDefs[j].Name
+	})
+	sort.Slice(nsDefs, func(i, j int) bool {
+		return nsDefs[i].Name < nsDefs[j].Name
+	})
+
+	schemaDefinitions := make([]compiler.SchemaDefinition, 0, len(nsDefs)+len(caveatDefs))
+	for _, caveatDef := range caveatDefs {
+		schemaDefinitions = append(schemaDefinitions, caveatDef)
+	}
+
+	for _, nsDef := range nsDefs {
+		schemaDefinitions = append(schemaDefinitions, nsDef)
+	}
+
+	schemaText, _ := generator.GenerateSchema(schemaDefinitions)
+	digest := sha256.Sum256([]byte(schemaText))
+	return hex.EncodeToString(digest[:schemaHashLength]), nil
+}
@@ -0,0 +1,80 @@
+package shared
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+)
+
+func schemaHashOf(t *testing.T, schema string) string {
+	require := require.New(t)
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, schema, nil, require)
+	hash, err := ComputeSchemaHash(context.Background(), ds.SnapshotReader(revision))
+	require.NoError(err)
+	return hash
+}
+
+func TestComputeSchemaHash(t *testing.T) {
+	schema := `
+		caveat is_weekday(day string) {
+			day != "saturday" && day != "sunday"
+		}
+
+		definition user {}
+
+		definition document {
+			relation viewer: user with is_weekday
+			permission view = viewer
+		}
+	`
+
+	hash := schemaHashOf(t, schema)
+	require.Len(t, hash, 32)
+
+	// The hash is independent of the order and formatting of the definitions.
+	require.Equal(t, hash, schemaHashOf(t, `
+		definition document {
+			relation viewer: user with is_weekday
+			permission view = viewer
+		}
+
+		definition user {}
+
+		caveat is_weekday(day string) { day != "saturday" && day != "sunday" }
+	`))
+
+	// Any change to a definition changes the hash.
+	require.NotEqual(t, hash, schemaHashOf(t, `
+		caveat is_weekday(day string) {
+			day != "saturday" && day != "sunday"
+		}
+
+		definition user {}
+
+		definition document {
+			relation viewer: user with is_weekday
+			relation editor: user
+			permission view = viewer
+		}
+	`))
+
+	require.NotEqual(t, hash, schemaHashOf(t, `
+		caveat is_weekday(day string) {
+			day != "saturday"
+		}
+
+		definition user {}
+
+		definition document {
+			relation viewer: user with is_weekday
+			permission view = viewer
+		}
+	`))
+}
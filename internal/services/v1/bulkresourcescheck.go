@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type bulkResourcesCheckServer struct {
+	spicedbv1.UnimplementedBulkResourcesCheckServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewBulkResourcesCheckServer creates an instance of the BulkResourcesCheck server, which shares
+// the configuration of the permissions server.
+func NewBulkResourcesCheckServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.BulkResourcesCheckServiceServer {
+	return &bulkResourcesCheckServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (bs *bulkResourcesCheckServer) CheckBulkResources(ctx context.Context, req *spicedbv1.CheckBulkResourcesRequest) (*spicedbv1.CheckBulkResourcesResponse, error) {
+	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	errG, checksCtx := errgroup.WithContext(ctx)
+	errG.Go(func() error {
+		return namespace.CheckNamespaceAndRelation(
+			checksCtx,
+			req.ResourceObjectType,
+			req.Permission,
+			false,
+			ds,
+		)
+	})
+	errG.Go(func() error {
+		return namespace.CheckNamespaceAndRelation(
+			checksCtx,
+			req.Subject.Object.ObjectType,
+			normalizeSubjectRelation(req.Subject),
+			true,
+			ds,
+		)
+	})
+	if err := errG.Wait(); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	results, metadata, err := computed.ComputeBulkResourcesCheck(ctx, bs.dispatch,
+		computed.BulkResourcesCheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: req.ResourceObjectType,
+				Relation:  req.Permission,
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  bs.config.MaximumAPIDepth,
+		},
+		req.ResourceObjectIds,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	converted := make([]*spicedbv1.CheckBulkResourcesResult, 0, len(req.ResourceObjectIds))
+	for _, resourceID := range req.ResourceObjectIds {
+		result := &spicedbv1.CheckBulkResourcesResult{
+			ResourceObjectId: resourceID,
+			Permissionship:   v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		}
+
+		checked := results[resourceID]
+		if checked.Membership == dispatchv1.ResourceCheckResult_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
+		} else if checked.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+			result.PartialCaveatInfo = &v1.PartialCaveatInfo{
+				MissingRequiredContext: checked.MissingExprFields,
+			}
+		}
+
+		converted = append(converted, result)
+	}
+
+	return &spicedbv1.CheckBulkResourcesResponse{
+		CheckedAt: checkedAt,
+		Results:   converted,
+	}, nil
+}
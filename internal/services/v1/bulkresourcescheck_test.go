@@ -0,0 +1,73 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestCheckBulkResources(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewBulkResourcesCheckServiceClient(conn)
+	ctx := context.Background()
+
+	check := func(subjectType, permission string, resourceIDs ...string) (*spicedbv1.CheckBulkResourcesResponse, error) {
+		return client.CheckBulkResources(ctx, &spicedbv1.CheckBulkResourcesRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			ResourceObjectType: "document",
+			ResourceObjectIds:  resourceIDs,
+			Permission:         permission,
+			Subject:            &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: subjectType, ObjectId: "chief_financial_officer"}},
+		})
+	}
+
+	// The plans folder is the shared parent through which both plans are viewed.
+	resp, err := check("user", "view", "masterplan", "healthplan", "specialplan", "masterplan")
+	req.NoError(err)
+	req.NotNil(resp.CheckedAt)
+
+	expected := []struct {
+		resourceID     string
+		permissionship v1.CheckPermissionResponse_Permissionship
+	}{
+		{"masterplan", v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION},
+		{"healthplan", v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION},
+		{"specialplan", v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION},
+		{"masterplan", v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION},
+	}
+	req.Len(resp.Results, len(expected))
+	for i, result := range resp.Results {
+		req.Equal(expected[i].resourceID, result.ResourceObjectId)
+		req.Equal(expected[i].permissionship, result.Permissionship, result.ResourceObjectId)
+	}
+
+	resp, err = check("user", "edit", "masterplan", "healthplan")
+	req.NoError(err)
+	for _, result := range resp.Results {
+		req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, result.Permissionship, result.ResourceObjectId)
+	}
+
+	_, err = check("user", "unknown", "masterplan")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	_, err = check("unknown", "view", "masterplan")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	_, err = check("user", "view")
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
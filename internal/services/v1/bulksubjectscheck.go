@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type bulkSubjectsCheckServer struct {
+	spicedbv1.UnimplementedBulkSubjectsCheckServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewBulkSubjectsCheckServer creates an instance of the BulkSubjectsCheck server, which shares
+// the configuration of the permissions server.
+func NewBulkSubjectsCheckServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.BulkSubjectsCheckServiceServer {
+	return &bulkSubjectsCheckServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (bs *bulkSubjectsCheckServer) CheckBulkSubjects(ctx context.Context, req *spicedbv1.CheckBulkSubjectsRequest) (*spicedbv1.CheckBulkSubjectsResponse, error) {
+	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Resource.ObjectType,
+		req.Permission,
+		false,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	subjects, err := subjectsToONRs(ctx, req.Subjects, ds)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	results, metadata, err := computed.ComputeBulkSubjectsCheck(ctx, bs.dispatch,
+		computed.BulkSubjectsCheckParameters{
+			Resource: &core.ObjectAndRelation{
+				Namespace: req.Resource.ObjectType,
+				ObjectId:  req.Resource.ObjectId,
+				Relation:  req.Permission,
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  bs.config.MaximumAPIDepth,
+		},
+		subjects,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	converted := make([]*spicedbv1.CheckBulkSubjectsResult, 0, len(subjects))
+	for i, subject := range subjects {
+		result := &spicedbv1.CheckBulkSubjectsResult{
+			Subject:        req.Subjects[i],
+			Permissionship: v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		}
+
+		checked := results[tuple.StringONR(subject)]
+		if checked.Membership == dispatchv1.ResourceCheckResult_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
+		} else if checked.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+			result.PartialCaveatInfo = &v1.PartialCaveatInfo{
+				MissingRequiredContext: checked.MissingExprFields,
+			}
+		}
+
+		converted = append(converted, result)
+	}
+
+	return &spicedbv1.CheckBulkSubjectsResponse{
+		CheckedAt: checkedAt,
+		Results:   converted,
+	}, nil
+}
+
+// subjectsToONRs converts the subjects to ObjectAndRelations, in the same order, after checking
+// that each distinct subject type and relation is defined.
+func subjectsToONRs(ctx context.Context, subjects []*v1.SubjectReference, ds datastore.Reader) ([]*core.ObjectAndRelation, error) {
+	checked := make(map[string]struct{}, len(subjects))
+	onrs := make([]*core.ObjectAndRelation, 0, len(subjects))
+	for _, subject := range subjects {
+		onr := &core.ObjectAndRelation{
+			Namespace: subject.Object.ObjectType,
+			ObjectId:  subject.Object.ObjectId,
+			Relation:  normalizeSubjectRelation(subject),
+		}
+
+		key := tuple.StringRR(&core.RelationReference{Namespace: onr.Namespace, Relation: onr.Relation})
+		if _, ok := checked[key]; !ok {
+			if err := namespace.CheckNamespaceAndRelation(ctx, onr.Namespace, onr.Relation, true, ds); err != nil {
+				return nil, err
+			}
+			checked[key] = struct{}{}
+		}
+
+		onrs = append(onrs, onr)
+	}
+	return onrs, nil
+}
@@ -0,0 +1,66 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestCheckBulkSubjects(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewBulkSubjectsCheckServiceClient(conn)
+	ctx := context.Background()
+
+	check := func(permission string, subjects ...*v1.SubjectReference) (*spicedbv1.CheckBulkSubjectsResponse, error) {
+		return client.CheckBulkSubjects(ctx, &spicedbv1.CheckBulkSubjectsRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			Resource:   &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+			Permission: permission,
+			Subjects:   subjects,
+		})
+	}
+
+	user := func(userID string) *v1.SubjectReference {
+		return &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: userID}}
+	}
+
+	resp, err := check("view", user("eng_lead"), user("villain"), user("auditor"), user("product_manager"))
+	req.NoError(err)
+	req.NotNil(resp.CheckedAt)
+	req.Len(resp.Results, 4)
+
+	expected := map[string]v1.CheckPermissionResponse_Permissionship{
+		"eng_lead":        v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+		"villain":         v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		"auditor":         v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+		"product_manager": v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+	}
+	for i, subjectID := range []string{"eng_lead", "villain", "auditor", "product_manager"} {
+		req.Equal(subjectID, resp.Results[i].Subject.Object.ObjectId)
+		req.Equal(expected[subjectID], resp.Results[i].Permissionship, subjectID)
+	}
+
+	_, err = check("unknown", user("eng_lead"))
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	_, err = check("view", user("eng_lead"), &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "unknown", ObjectId: "villain"}})
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	_, err = check("view")
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type checkPermissionsServer struct {
+	spicedbv1.UnimplementedCheckPermissionsServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewCheckPermissionsServer creates an instance of the CheckPermissions server, which shares
+// the configuration of the permissions server.
+func NewCheckPermissionsServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.CheckPermissionsServiceServer {
+	return &checkPermissionsServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth:        defaultIfZero(config.MaximumAPIDepth, 50),
+			WellKnownCaveatContext: config.WellKnownCaveatContext,
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (cs *checkPermissionsServer) CheckPermissions(ctx context.Context, req *spicedbv1.CheckPermissionsRequest) (*spicedbv1.CheckPermissionsResponse, error) {
+	start := time.Now()
+
+	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if cs.config.WellKnownCaveatContext {
+		caveatContext = withWellKnownCaveatContext(caveatContext, start)
+	}
+
+	// An unknown subject type or relation fails the whole call, as does an unknown resource
+	// type; only unknown permissions fail their own results.
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Subject.Object.ObjectType,
+		normalizeSubjectRelation(req.Subject),
+		true,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	results, metadata, err := computed.ComputePermissionsCheck(ctx, cs.dispatch,
+		computed.PermissionsCheckParameters{
+			ResourceType: req.Resource.ObjectType,
+			ResourceID:   req.Resource.ObjectId,
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  cs.config.MaximumAPIDepth,
+		},
+		req.Permissions,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	converted := make([]*spicedbv1.CheckPermissionsResult, 0, len(req.Permissions))
+	for _, permission := range req.Permissions {
+		result := results[permission]
+		if result.Err != nil {
+			converted = append(converted, &spicedbv1.CheckPermissionsResult{
+				Permission: permission,
+				Response: &spicedbv1.CheckPermissionsResult_Error{
+					Error: status.Convert(rewriteError(ctx, result.Err)).Proto(),
+				},
+			})
+			continue
+		}
+
+		item := &spicedbv1.CheckPermissionsResultItem{
+			Permissionship: v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		}
+		if result.Result.Membership == dispatchv1.ResourceCheckResult_MEMBER {
+			item.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
+		} else if result.Result.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+			item.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+			item.PartialCaveatInfo = &v1.PartialCaveatInfo{
+				MissingRequiredContext: result.Result.MissingExprFields,
+			}
+		}
+
+		converted = append(converted, &spicedbv1.CheckPermissionsResult{
+			Permission: permission,
+			Response:   &spicedbv1.CheckPermissionsResult_Item{Item: item},
+		})
+	}
+
+	return &spicedbv1.CheckPermissionsResponse{
+		CheckedAt: checkedAt,
+		Results:   converted,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestCheckPermissionsService(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewCheckPermissionsServiceClient(conn)
+	ctx := context.Background()
+
+	check := func(subjectType, subjectID string, permissions ...string) (*spicedbv1.CheckPermissionsResponse, error) {
+		return client.CheckPermissions(ctx, &spicedbv1.CheckPermissionsRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			Resource:    &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+			Permissions: permissions,
+			Subject:     &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: subjectType, ObjectId: subjectID}},
+		})
+	}
+
+	resp, err := check("user", "eng_lead", "view", "edit", "unknown", "view")
+	req.NoError(err)
+	req.NotNil(resp.CheckedAt)
+	req.Len(resp.Results, 4)
+
+	// Results are in the order requested, with an entry for each duplicate.
+	req.Equal("view", resp.Results[0].Permission)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Results[0].GetItem().Permissionship)
+	req.Equal("edit", resp.Results[1].Permission)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, resp.Results[1].GetItem().Permissionship)
+	req.Equal("view", resp.Results[3].Permission)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Results[3].GetItem().Permissionship)
+
+	// An unknown permission only fails its own result.
+	req.Equal("unknown", resp.Results[2].Permission)
+	req.Nil(resp.Results[2].GetItem())
+	req.Equal(int32(codes.FailedPrecondition), resp.Results[2].GetError().Code)
+
+	resp, err = check("user", "product_manager", "view", "edit")
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Results[0].GetItem().Permissionship)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Results[1].GetItem().Permissionship)
+
+	// An unknown subject type fails the whole call.
+	_, err = check("unknown", "product_manager", "view")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	// As does a request without permissions.
+	_, err = check("user", "product_manager")
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
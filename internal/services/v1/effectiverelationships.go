@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type effectiveRelationshipsServer struct {
+	spicedbv1.UnimplementedEffectiveRelationshipsServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewEffectiveRelationshipsServer creates an instance of the EffectiveRelationships server, which
+// shares the configuration of the permissions server.
+func NewEffectiveRelationshipsServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.EffectiveRelationshipsServiceServer {
+	return &effectiveRelationshipsServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (es *effectiveRelationshipsServer) ReadEffectiveRelationships(ctx context.Context, req *spicedbv1.ReadEffectiveRelationshipsRequest) (*spicedbv1.ReadEffectiveRelationshipsResponse, error) {
+	atRevision, readAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Resource.ObjectType,
+		req.Permission,
+		false,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	effective, metadata, err := computed.ComputeEffectiveRelationships(ctx, es.dispatch,
+		computed.EffectiveRelationshipsParameters{
+			Resource: &core.ObjectAndRelation{
+				Namespace: req.Resource.ObjectType,
+				ObjectId:  req.Resource.ObjectId,
+				Relation:  req.Permission,
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  es.config.MaximumAPIDepth,
+		},
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	relationships := make([]*v1.Relationship, 0, len(effective))
+	for _, tpl := range effective {
+		relationships = append(relationships, tuple.ToRelationship(tpl))
+	}
+
+	return &spicedbv1.ReadEffectiveRelationshipsResponse{
+		ReadAt:        readAt,
+		Relationships: relationships,
+	}, nil
+}
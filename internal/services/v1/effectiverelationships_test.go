@@ -0,0 +1,67 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestReadEffectiveRelationships(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewEffectiveRelationshipsServiceClient(conn)
+	ctx := context.Background()
+
+	read := func(resourceID, permission string) ([]string, error) {
+		resp, err := client.ReadEffectiveRelationships(ctx, &spicedbv1.ReadEffectiveRelationshipsRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			Resource:   &v1.ObjectReference{ObjectType: "document", ObjectId: resourceID},
+			Permission: permission,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req.NotNil(resp.ReadAt)
+		relationships := make([]string, 0, len(resp.Relationships))
+		for _, rel := range resp.Relationships {
+			relationships = append(relationships, tuple.MustRelString(rel))
+		}
+		return relationships, nil
+	}
+
+	relationships, err := read("masterplan", "view")
+	req.NoError(err)
+	req.ElementsMatch([]string{
+		"document:masterplan#viewer@user:eng_lead",
+		"document:masterplan#owner@user:product_manager",
+		"document:masterplan#parent@folder:strategy",
+		"document:masterplan#parent@folder:plans",
+	}, relationships)
+
+	relationships, err = read("masterplan", "edit")
+	req.NoError(err)
+	req.Equal([]string{"document:masterplan#owner@user:product_manager"}, relationships)
+
+	relationships, err = read("unknown", "view")
+	req.NoError(err)
+	req.Empty(relationships)
+
+	_, err = read("masterplan", "unknown")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+}
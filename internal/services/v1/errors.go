@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -17,7 +18,9 @@ import (
 	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/internal/services/shared"
 	"github.com/authzed/spicedb/internal/sharederrors"
+	"github.com/authzed/spicedb/pkg/caveats"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/spiceerrors"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -26,13 +29,13 @@ import (
 // ErrExceedsMaximumUpdates occurs when too many updates are given to a call.
 type ErrExceedsMaximumUpdates struct {
 	error
-	updateCount     uint16
+	updateCount     uint64
 	maxCountAllowed uint16
 }
 
 // MarshalZerologObject implements zerolog object marshalling.
 func (err ErrExceedsMaximumUpdates) MarshalZerologObject(e *zerolog.Event) {
-	e.Err(err.error).Uint16("updateCount", err.updateCount).Uint16("maxCountAllowed", err.maxCountAllowed)
+	e.Err(err.error).Uint64("updateCount", err.updateCount).Uint16("maxCountAllowed", err.maxCountAllowed)
 }
 
 // GRPCStatus implements retrieving the gRPC status for the error.
@@ -43,7 +46,7 @@ func (err ErrExceedsMaximumUpdates) GRPCStatus() *status.Status {
 		spiceerrors.ForReason(
 			v1.ErrorReason_ERROR_REASON_TOO_MANY_UPDATES_IN_REQUEST,
 			map[string]string{
-				"update_count":            strconv.Itoa(int(err.updateCount)),
+				"update_count":            strconv.FormatUint(err.updateCount, 10),
 				"maximum_updates_allowed": strconv.Itoa(int(err.maxCountAllowed)),
 			},
 		),
@@ -51,7 +54,7 @@ func (err ErrExceedsMaximumUpdates) GRPCStatus() *status.Status {
 }
 
 // NewExceedsMaximumUpdatesErr creates a new error representing that too many updates were given to a WriteRelationships call.
-func NewExceedsMaximumUpdatesErr(updateCount uint16, maxCountAllowed uint16) ErrExceedsMaximumUpdates {
+func NewExceedsMaximumUpdatesErr(updateCount uint64, maxCountAllowed uint16) ErrExceedsMaximumUpdates {
 	return ErrExceedsMaximumUpdates{
 		error:           fmt.Errorf("update count of %d is greater than maximum allowed of %d", updateCount, maxCountAllowed),
 		updateCount:     updateCount,
@@ -62,13 +65,13 @@ func NewExceedsMaximumUpdatesErr(updateCount uint16, maxCountAllowed uint16) Err
 // ErrExceedsMaximumPreconditions occurs when too many preconditions are given to a call.
 type ErrExceedsMaximumPreconditions struct {
 	error
-	preconditionCount uint16
+	preconditionCount uint64
 	maxCountAllowed   uint16
 }
 
 // MarshalZerologObject implements zerolog object marshalling.
 func (err ErrExceedsMaximumPreconditions) MarshalZerologObject(e *zerolog.Event) {
-	e.Err(err.error).Uint16("preconditionCount", err.preconditionCount).Uint16("maxCountAllowed", err.maxCountAllowed)
+	e.Err(err.error).Uint64("preconditionCount", err.preconditionCount).Uint16("maxCountAllowed", err.maxCountAllowed)
 }
 
 // GRPCStatus implements retrieving the gRPC status for the error.
@@ -79,7 +82,7 @@ func (err ErrExceedsMaximumPreconditions) GRPCStatus() *status.Status {
 		spiceerrors.ForReason(
 			v1.ErrorReason_ERROR_REASON_TOO_MANY_PRECONDITIONS_IN_REQUEST,
 			map[string]string{
-				"precondition_count":      strconv.Itoa(int(err.preconditionCount)),
+				"precondition_count":      strconv.FormatUint(err.preconditionCount, 10),
 				"maximum_updates_allowed": strconv.Itoa(int(err.maxCountAllowed)),
 			},
 		),
@@ -87,7 +90,7 @@ func (err ErrExceedsMaximumPreconditions) GRPCStatus() *status.Status {
 }
 
 // NewExceedsMaximumPreconditionsErr creates a new error representing that too many preconditions were given to a call.
-func NewExceedsMaximumPreconditionsErr(preconditionCount uint16, maxCountAllowed uint16) ErrExceedsMaximumPreconditions {
+func NewExceedsMaximumPreconditionsErr(preconditionCount uint64, maxCountAllowed uint16) ErrExceedsMaximumPreconditions {
 	return ErrExceedsMaximumPreconditions{
 		error: fmt.Errorf(
 			"precondition count of %d is greater than maximum allowed of %d",
@@ -98,22 +101,78 @@ func NewExceedsMaximumPreconditionsErr(preconditionCount uint16, maxCountAllowed
 	}
 }
 
+// ErrExceedsMaximumExpandLeafSubjects occurs when an expansion has too many leaf subjects.
+type ErrExceedsMaximumExpandLeafSubjects struct {
+	error
+	leafSubjectCount uint64
+	maxCountAllowed  uint32
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrExceedsMaximumExpandLeafSubjects) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Uint64("leafSubjectCount", err.leafSubjectCount).Uint32("maxCountAllowed", err.maxCountAllowed)
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrExceedsMaximumExpandLeafSubjects) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.ResourceExhausted,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_UNSPECIFIED,
+			map[string]string{
+				"leaf_subject_count":            strconv.FormatUint(err.leafSubjectCount, 10),
+				"maximum_leaf_subjects_allowed": strconv.FormatUint(uint64(err.maxCountAllowed), 10),
+			},
+		),
+	)
+}
+
+// NewExceedsMaximumExpandLeafSubjectsErr creates a new error representing that an expansion has more leaf subjects than allowed.
+func NewExceedsMaximumExpandLeafSubjectsErr(leafSubjectCount uint64, maxCountAllowed uint32) ErrExceedsMaximumExpandLeafSubjects {
+	return ErrExceedsMaximumExpandLeafSubjects{
+		error: fmt.Errorf(
+			"expansion has at least %d leaf subjects, which is greater than the maximum allowed of %d",
+			leafSubjectCount,
+			maxCountAllowed),
+		leafSubjectCount: leafSubjectCount,
+		maxCountAllowed:  maxCountAllowed,
+	}
+}
+
 // ErrPreconditionFailed occurs when the precondition to a write tuple call does not match.
 type ErrPreconditionFailed struct {
 	error
 	precondition *v1.Precondition
+	matched      *core.RelationTuple
+	matchedCount uint64
 }
 
 // MarshalZerologObject implements zerolog object marshalling.
 func (err ErrPreconditionFailed) MarshalZerologObject(e *zerolog.Event) {
-	e.Err(err.error).Interface("precondition", err.precondition)
+	e.Err(err.error).Interface("precondition", err.precondition).Interface("matched", err.matched).Uint64("matchedCount", err.matchedCount)
 }
 
-// NewPreconditionFailedErr constructs a new precondition failed error.
-func NewPreconditionFailedErr(precondition *v1.Precondition) error {
+// NewPreconditionFailedErr constructs a new precondition failed error. For a MUST_NOT_MATCH
+// precondition, matched is the first relationship that was found and matchedCount is the number
+// of relationships matching the precondition, which may be bounded; for MUST_MATCH, they are nil
+// and zero.
+func NewPreconditionFailedErr(precondition *v1.Precondition, matched *core.RelationTuple, matchedCount uint64) error {
+	reason := "no matching relationship was found"
+	if matched != nil {
+		total := fmt.Sprintf("%d matching relationship(s) in total", matchedCount)
+		if matchedCount >= maxCountedPreconditionMatches {
+			// NOTE: the matching relationships are only counted up to the maximum.
+			total = fmt.Sprintf("at least %d matching relationship(s)", matchedCount)
+		}
+		reason = fmt.Sprintf("found matching relationship `%s` (%s)", tuple.StringWithoutCaveat(matched), total)
+	}
+
 	return ErrPreconditionFailed{
-		error:        fmt.Errorf("unable to satisfy write precondition `%s`", precondition),
+		error:        fmt.Errorf("unable to satisfy write precondition `%s`: %s", precondition, reason),
 		precondition: precondition,
+		matched:      matched,
+		matchedCount: matchedCount,
 	}
 }
 
@@ -122,6 +181,7 @@ func (err ErrPreconditionFailed) GRPCStatus() *status.Status {
 	metadata := map[string]string{
 		"precondition_resource_type": err.precondition.Filter.ResourceType,
 		"precondition_operation":     v1.Precondition_Operation_name[int32(err.precondition.Operation)],
+		"precondition_matched_count": strconv.FormatUint(err.matchedCount, 10),
 	}
 
 	if err.precondition.Filter.OptionalResourceId != "" {
@@ -144,6 +204,10 @@ func (err ErrPreconditionFailed) GRPCStatus() *status.Status {
 		}
 	}
 
+	if err.matched != nil {
+		metadata["precondition_matched_relationship"] = tuple.StringWithoutCaveat(err.matched)
+	}
+
 	return spiceerrors.WithCodeAndDetails(
 		err,
 		codes.FailedPrecondition,
@@ -154,17 +218,19 @@ func (err ErrPreconditionFailed) GRPCStatus() *status.Status {
 	)
 }
 
-// ErrDuplicateRelationshipError indicates that an update was attempted on the same relationship.
+// ErrDuplicateRelationshipError indicates that a relationship was both written and deleted by the
+// updates of a request.
 type ErrDuplicateRelationshipError struct {
 	error
 	update *v1.RelationshipUpdate
 }
 
-// NewDuplicateRelationshipErr constructs a new invalid subject error.
+// NewDuplicateRelationshipErr constructs a new error for an update contradicting an earlier update
+// of the same relationship.
 func NewDuplicateRelationshipErr(update *v1.RelationshipUpdate) ErrDuplicateRelationshipError {
 	return ErrDuplicateRelationshipError{
 		error: fmt.Errorf(
-			"found more than one update with relationship `%s` in this request; a relationship can only be specified in an update once per overall WriteRelationships request",
+			"found contradictory updates with relationship `%s` in this request; a relationship cannot be both written and deleted in the same WriteRelationships request",
 			tuple.StringRelationshipWithoutCaveat(update.Relationship),
 		),
 		update: update,
@@ -186,6 +252,53 @@ func (err ErrDuplicateRelationshipError) GRPCStatus() *status.Status {
 	)
 }
 
+// ErrRelationshipPolicyViolation occurs when a relationship written is rejected by the
+// RelationshipValidationPolicy of the server.
+type ErrRelationshipPolicyViolation struct {
+	error
+	relationship *v1.Relationship
+	rule         string
+}
+
+// Rule returns the name of the rule which rejected the relationship.
+func (err ErrRelationshipPolicyViolation) Rule() string {
+	return err.rule
+}
+
+// RelationshipPolicyViolationReason is the reason of the error details of an
+// ErrRelationshipPolicyViolation. The V1 API defines no reason for it, so it is given under the
+// same domain as the reasons of the V1 API.
+const RelationshipPolicyViolationReason = "ERROR_REASON_RELATIONSHIP_POLICY_VIOLATION"
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrRelationshipPolicyViolation) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.InvalidArgument,
+		&errdetails.ErrorInfo{
+			Reason: RelationshipPolicyViolationReason,
+			Domain: spiceerrors.Domain,
+			Metadata: map[string]string{
+				"relationship": tuple.StringRelationshipWithoutCaveat(err.relationship),
+				"rule":         err.rule,
+			},
+		},
+	)
+}
+
+// NewRelationshipPolicyViolationErr constructs a new error for a relationship rejected by the
+// named rule of a RelationshipValidationPolicy, with the given description of the violation.
+func NewRelationshipPolicyViolationErr(rel *v1.Relationship, rule string, description string) ErrRelationshipPolicyViolation {
+	return ErrRelationshipPolicyViolation{
+		error: fmt.Errorf(
+			"relationship `%s` violates rule `%s` of the validation policy: %s",
+			tuple.StringRelationshipWithoutCaveat(rel), rule, description,
+		),
+		relationship: rel,
+		rule:         rule,
+	}
+}
+
 func rewriteError(ctx context.Context, err error) error {
 	// Check if the error can be directly used.
 	if _, ok := status.FromError(err); ok {
@@ -217,13 +330,39 @@ func rewriteError(ctx context.Context, err error) error {
 		return shared.ErrServiceReadOnly
 	case errors.As(err, &datastore.ErrInvalidRevision{}):
 		return status.Errorf(codes.OutOfRange, "invalid zedtoken: %s", err)
+	case errors.As(err, &datastore.ErrTimestampBeforeGCWindow{}):
+		return status.Errorf(codes.OutOfRange, "%s", err)
 	case errors.As(err, &datastore.ErrReadOnly{}):
 		return shared.ErrServiceReadOnly
 	case errors.As(err, &datastore.ErrCaveatNameNotFound{}):
 		return spiceerrors.WithCodeAndReason(err, codes.FailedPrecondition, v1.ErrorReason_ERROR_REASON_UNKNOWN_CAVEAT)
+	case errors.As(err, &caveats.ParameterConversionErr{}):
+		return spiceerrors.WithCodeAndReason(err, codes.InvalidArgument, v1.ErrorReason_ERROR_REASON_CAVEAT_PARAMETER_TYPE_ERROR)
+	case errors.As(err, &caveats.EvaluationErr{}):
+		return status.Errorf(codes.InvalidArgument, "%s", err)
 	case errors.As(err, &datastore.ErrWatchDisabled{}):
 		return status.Errorf(codes.FailedPrecondition, "%s", err)
-
+	case errors.As(err, &datastore.ErrNamespaceHasRelationships{}):
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	case errors.As(err, &datastore.ErrRelationshipLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	case errors.As(err, &datastore.ErrIdempotencyKeysUnsupported{}):
+		return status.Errorf(codes.Unimplemented, "%s", err)
+	case errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}):
+		return status.Errorf(codes.Unimplemented, "%s", err)
+	case errors.As(err, &datastore.ErrRevisionDiffUnsupported{}):
+		return status.Errorf(codes.Unimplemented, "%s", err)
+	case errors.As(err, &datastore.ErrRetryable{}):
+		return status.Errorf(codes.Aborted, "%s", err)
+	case errors.As(err, &datastore.ErrConflict{}):
+		return status.Errorf(codes.AlreadyExists, "%s", err)
+	case errors.As(err, &datastore.ErrNotFound{}):
+		return status.Errorf(codes.NotFound, "%s", err)
+	case errors.As(err, &datastore.ErrUnavailable{}):
+		return status.Errorf(codes.Unavailable, "%s", err)
+
+	case errors.As(err, &graph.ErrIntermediateResultLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
 	case errors.As(err, &graph.ErrInvalidArgument{}):
 		return status.Errorf(codes.InvalidArgument, "%s", err)
 	case errors.As(err, &graph.ErrRequestCanceled{}):
@@ -2,11 +2,20 @@ package v1
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/authzed/grpcutil"
 	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/graph"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 )
 
 func TestRewriteCanceledError(t *testing.T) {
@@ -22,3 +31,47 @@ func TestRewriteDeadlineExceededError(t *testing.T) {
 	errorRewritten := rewriteError(ctx, ctx.Err())
 	grpcutil.RequireStatus(t, codes.DeadlineExceeded, errorRewritten)
 }
+
+func TestRewriteRelationshipLimitExceededError(t *testing.T) {
+	errorRewritten := rewriteError(context.Background(), datastore.NewRelationshipLimitExceededErr("document", 10))
+	grpcutil.RequireStatus(t, codes.ResourceExhausted, errorRewritten)
+}
+
+func TestRewriteIntermediateResultLimitExceededError(t *testing.T) {
+	errorRewritten := rewriteError(context.Background(), graph.NewIntermediateResultLimitExceededErr("group", "member", 100))
+	grpcutil.RequireStatus(t, codes.ResourceExhausted, errorRewritten)
+}
+
+func TestRewriteClassifiedDatastoreErrors(t *testing.T) {
+	underlying := errors.New("unable to write relationships")
+	for _, tc := range []struct {
+		err          error
+		expectedCode codes.Code
+	}{
+		{datastore.NewRetryableErr(underlying), codes.Aborted},
+		{datastore.NewConflictErr(underlying), codes.AlreadyExists},
+		{datastore.NewNotFoundErr(underlying), codes.NotFound},
+		{datastore.NewUnavailableErr(underlying), codes.Unavailable},
+		{fmt.Errorf("max retries exceeded: %w", datastore.NewRetryableErr(underlying)), codes.Aborted},
+	} {
+		errorRewritten := rewriteError(context.Background(), tc.err)
+		grpcutil.RequireStatus(t, tc.expectedCode, errorRewritten)
+	}
+}
+
+func TestRewriteAllowedRelationsOnPermissionError(t *testing.T) {
+	_, err := shared.ValidateSchemaChanges(context.Background(), &compiler.CompiledSchema{
+		ObjectDefinitions: []*core.NamespaceDefinition{
+			ns.Namespace("user"),
+			ns.Namespace(
+				"document",
+				ns.Relation("viewer", nil, ns.AllowedRelation("user", "...")),
+				ns.Relation("view", ns.Union(
+					ns.ComputedUserset("viewer"),
+				), ns.AllowedRelation("user", "...")),
+			),
+		},
+	}, false)
+	errorRewritten := rewriteError(context.Background(), err)
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, errorRewritten)
+}
@@ -0,0 +1,132 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+type headCheckServer struct {
+	spicedbv1.UnimplementedHeadCheckServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewHeadCheckServer creates an instance of the HeadCheck server, which shares the configuration
+// of the permissions server.
+func NewHeadCheckServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.HeadCheckServiceServer {
+	return &headCheckServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (hs *headCheckServer) CheckSubjectsAtHead(ctx context.Context, req *spicedbv1.CheckSubjectsAtHeadRequest) (*spicedbv1.CheckSubjectsAtHeadResponse, error) {
+	// The request has no consistency, so the schema is checked at the head revision found for it.
+	atRevision, _ := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Resource.ObjectType,
+		req.Permission,
+		false,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	subjects, err := subjectsToONRs(ctx, req.Subjects, ds)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	minimumRevision := datastore.NoRevision
+	if req.OptionalMinimumZedtoken != nil {
+		minimumRevision, err = zedtoken.DecodeRevision(req.OptionalMinimumZedtoken, datastoremw.MustFromContext(ctx))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "malformed zedtoken: %s", err)
+		}
+	}
+
+	results, checkedAt, metadata, err := computed.ComputeSubjectsCheckAtHead(ctx, hs.dispatch,
+		computed.HeadRevisionCheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: req.Resource.ObjectType,
+				Relation:  req.Permission,
+			},
+			CaveatContext:   caveatContext,
+			MaximumDepth:    hs.config.MaximumAPIDepth,
+			DebugOption:     computed.NoDebugging,
+			MinimumRevision: minimumRevision,
+		},
+		req.Resource.ObjectId,
+		subjects,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	converted := make([]*spicedbv1.CheckSubjectsAtHeadResult, 0, len(subjects))
+	for i, subject := range subjects {
+		result := &spicedbv1.CheckSubjectsAtHeadResult{
+			Subject:        req.Subjects[i],
+			Permissionship: v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		}
+
+		checked := results[tuple.StringONR(subject)]
+		if checked.Membership == dispatchv1.ResourceCheckResult_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
+		} else if checked.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+			result.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+			result.PartialCaveatInfo = &v1.PartialCaveatInfo{
+				MissingRequiredContext: checked.MissingExprFields,
+			}
+		}
+
+		converted = append(converted, result)
+	}
+
+	return &spicedbv1.CheckSubjectsAtHeadResponse{
+		CheckedAt: zedtoken.NewFromRevision(checkedAt),
+		Results:   converted,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestCheckSubjectsAtHead(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, _ := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewHeadCheckServiceClient(conn)
+	ctx := context.Background()
+
+	check := func(permission string, subjects ...*v1.SubjectReference) (*spicedbv1.CheckSubjectsAtHeadResponse, error) {
+		return client.CheckSubjectsAtHead(ctx, &spicedbv1.CheckSubjectsAtHeadRequest{
+			Resource:   &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+			Permission: permission,
+			Subjects:   subjects,
+		})
+	}
+
+	user := func(userID string) *v1.SubjectReference {
+		return &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: userID}}
+	}
+
+	resp, err := check("view", user("eng_lead"), user("villain"), user("auditor"))
+	req.NoError(err)
+	req.NotNil(resp.CheckedAt)
+	req.Len(resp.Results, 3)
+
+	expected := map[string]v1.CheckPermissionResponse_Permissionship{
+		"eng_lead": v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+		"villain":  v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+		"auditor":  v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+	}
+	for i, subjectID := range []string{"eng_lead", "villain", "auditor"} {
+		req.Equal(subjectID, resp.Results[i].Subject.Object.ObjectId)
+		req.Equal(expected[subjectID], resp.Results[i].Permissionship, subjectID)
+	}
+
+	// The zedtoken of a write is honored as the minimum revision of the check.
+	written, err := v1.NewPermissionsServiceClient(conn).WriteRelationships(ctx, &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{
+			tuple.UpdateToRelationshipUpdate(tuple.Create(tuple.MustParse("document:masterplan#viewer@user:villain"))),
+		},
+	})
+	req.NoError(err)
+
+	resp, err = client.CheckSubjectsAtHead(ctx, &spicedbv1.CheckSubjectsAtHeadRequest{
+		Resource:                &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+		Permission:              "view",
+		Subjects:                []*v1.SubjectReference{user("villain")},
+		OptionalMinimumZedtoken: written.WrittenAt,
+	})
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Results[0].Permissionship)
+
+	_, err = client.CheckSubjectsAtHead(ctx, &spicedbv1.CheckSubjectsAtHeadRequest{
+		Resource:                &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+		Permission:              "view",
+		Subjects:                []*v1.SubjectReference{user("villain")},
+		OptionalMinimumZedtoken: &v1.ZedToken{Token: "invalid"},
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+
+	_, err = check("unknown", user("eng_lead"))
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	_, err = check("view")
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type memberSubjectsServer struct {
+	spicedbv1.UnimplementedMemberSubjectsServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewMemberSubjectsServer creates an instance of the MemberSubjects server, which shares the
+// configuration of the permissions server.
+func NewMemberSubjectsServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.MemberSubjectsServiceServer {
+	return &memberSubjectsServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (ms *memberSubjectsServer) FilterMemberSubjects(ctx context.Context, req *spicedbv1.FilterMemberSubjectsRequest) (*spicedbv1.FilterMemberSubjectsResponse, error) {
+	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Resource.ObjectType,
+		req.Permission,
+		false,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	candidates, err := subjectsToONRs(ctx, req.CandidateSubjects, ds)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	members, metadata, err := computed.ComputeMemberSubjects(ctx, ms.dispatch,
+		computed.BulkSubjectsCheckParameters{
+			Resource: &core.ObjectAndRelation{
+				Namespace: req.Resource.ObjectType,
+				ObjectId:  req.Resource.ObjectId,
+				Relation:  req.Permission,
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  ms.config.MaximumAPIDepth,
+		},
+		candidates,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	// The members are the candidates themselves, so they are returned as they were requested.
+	requested := make(map[*core.ObjectAndRelation]*v1.SubjectReference, len(candidates))
+	for i, candidate := range candidates {
+		requested[candidate] = req.CandidateSubjects[i]
+	}
+
+	converted := make([]*v1.SubjectReference, 0, len(members))
+	for _, member := range members {
+		converted = append(converted, requested[member])
+	}
+
+	return &spicedbv1.FilterMemberSubjectsResponse{
+		CheckedAt: checkedAt,
+		Members:   converted,
+	}, nil
+}
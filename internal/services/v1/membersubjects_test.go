@@ -0,0 +1,68 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestFilterMemberSubjects(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewMemberSubjectsServiceClient(conn)
+	ctx := context.Background()
+
+	filter := func(permission string, candidateIDs ...string) ([]string, error) {
+		candidates := make([]*v1.SubjectReference, 0, len(candidateIDs))
+		for _, candidateID := range candidateIDs {
+			candidates = append(candidates, &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: candidateID}})
+		}
+
+		resp, err := client.FilterMemberSubjects(ctx, &spicedbv1.FilterMemberSubjectsRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			Resource:          &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+			Permission:        permission,
+			CandidateSubjects: candidates,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req.NotNil(resp.CheckedAt)
+		members := make([]string, 0, len(resp.Members))
+		for _, member := range resp.Members {
+			req.Equal("user", member.Object.ObjectType)
+			members = append(members, member.Object.ObjectId)
+		}
+		return members, nil
+	}
+
+	members, err := filter("view", "villain", "eng_lead", "vp_product", "auditor", "product_manager")
+	req.NoError(err)
+	req.Equal([]string{"eng_lead", "vp_product", "auditor", "product_manager"}, members)
+
+	members, err = filter("edit", "villain", "eng_lead", "vp_product", "auditor", "product_manager")
+	req.NoError(err)
+	req.Equal([]string{"product_manager"}, members)
+
+	members, err = filter("view", "villain")
+	req.NoError(err)
+	req.Empty(members)
+
+	_, err = filter("unknown", "villain")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+}
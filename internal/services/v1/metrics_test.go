@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestRelationMetricsObserve(t *testing.T) {
+	require := require.New(t)
+
+	registry := prometheus.NewRegistry()
+	metrics := newRelationMetrics(registry)
+
+	metrics.observe("CheckPermission", time.Now(), relationLabels{"document", "view"})
+	metrics.observe("CheckPermission", time.Now(), relationLabels{"document", "view"})
+	metrics.observe("CheckPermission", time.Now())
+
+	require.Equal(2.0, testutil.ToFloat64(metrics.requests.WithLabelValues("CheckPermission", "document", "view")))
+	require.Equal(1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("CheckPermission", unknownLabelValue, unknownLabelValue)))
+	require.Equal(2, testutil.CollectAndCount(metrics.latency))
+}
+
+func TestRelationMetricsReuseRegistered(t *testing.T) {
+	require := require.New(t)
+
+	registry := prometheus.NewRegistry()
+	first := newRelationMetrics(registry)
+	second := newRelationMetrics(registry)
+
+	second.observe("ExpandPermissionTree", time.Now(), relationLabels{"document", "view"})
+	require.Equal(1.0, testutil.ToFloat64(first.requests.WithLabelValues("ExpandPermissionTree", "document", "view")))
+}
+
+func TestRelationMetricsObserveExpandSize(t *testing.T) {
+	require := require.New(t)
+
+	registry := prometheus.NewRegistry()
+	metrics := newRelationMetrics(registry)
+
+	metrics.observeExpandSize(relationLabels{"document", "view"}, 3)
+	metrics.observeExpandSize(relationLabels{"document", "view"}, 12)
+	metrics.observeExpandSize(relationLabels{"folder", "view"}, 0)
+
+	require.Equal(2, testutil.CollectAndCount(metrics.expandSize))
+}
+
+func TestLabelsForUpdates(t *testing.T) {
+	updates := []*core.RelationTupleUpdate{
+		tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+		tuple.Touch(tuple.MustParse("document:second#viewer@user:sarah")),
+		tuple.Delete(tuple.MustParse("folder:plans#editor@user:tom")),
+	}
+
+	require.Equal(t, []relationLabels{
+		{"document", "viewer"},
+		{"folder", "editor"},
+	}, labelsForUpdates(updates))
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// namespaceRelation is a namespace and relation referenced by an item of a request.
+type namespaceRelation struct {
+	namespace string
+	relation  string
+
+	// allowEllipsis is whether the ellipsis relation is valid for the namespace, as it is for
+	// subjects.
+	allowEllipsis bool
+}
+
+// namespaceRelationChecks holds the result of validating each namespaceRelation of a batch: nil
+// if the namespace and relation exist, and the error to return for the item otherwise.
+type namespaceRelationChecks map[namespaceRelation]error
+
+// validateNamespaceRelations validates all of the namespace and relation pairs referenced by the
+// items of a batched request with a single read of their distinct namespaces, so that each item
+// can be checked without reading the namespaces again. Errors reading the namespaces, other than
+// a namespace not being found, are returned directly.
+func validateNamespaceRelations(ctx context.Context, ds datastore.Reader, refs []namespaceRelation) (namespaceRelationChecks, error) {
+	nsNames := util.NewSet[string]()
+	for _, ref := range refs {
+		nsNames.Add(ref.namespace)
+	}
+
+	found, err := ds.LookupNamespaces(ctx, nsNames.AsSlice())
+	if err != nil {
+		return nil, err
+	}
+
+	// Namespaces which were not found are missing from the map.
+	relationsByNamespace := make(map[string]*util.Set[string], len(found))
+	for _, nsDef := range found {
+		relations := util.NewSet[string]()
+		for _, rel := range nsDef.Relation {
+			relations.Add(rel.Name)
+		}
+		relationsByNamespace[nsDef.Name] = relations
+	}
+
+	checks := make(namespaceRelationChecks, len(refs))
+	for _, ref := range refs {
+		relations, ok := relationsByNamespace[ref.namespace]
+		switch {
+		case !ok:
+			checks[ref] = datastore.NewNamespaceNotFoundErr(ref.namespace)
+		case ref.allowEllipsis && ref.relation == datastore.Ellipsis:
+			checks[ref] = nil
+		case relations.Has(ref.relation):
+			checks[ref] = nil
+		default:
+			checks[ref] = namespace.NewRelationNotFoundErr(ref.namespace, ref.relation)
+		}
+	}
+	return checks, nil
+}
+
+// check returns the error of the first of the given references which is invalid, or nil if all
+// are valid. Every reference must have been validated in the batch.
+func (checks namespaceRelationChecks) check(refs ...namespaceRelation) error {
+	for _, ref := range refs {
+		err, ok := checks[ref]
+		if !ok {
+			return fmt.Errorf("namespace `%s` and relation `%s` were not validated", ref.namespace, ref.relation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterReferences returns the namespace and relation pairs referenced by the filter.
+func filterReferences(filter *v1.RelationshipFilter) []namespaceRelation {
+	refs := []namespaceRelation{filterComponentReference(filter.ResourceType, filter.OptionalRelation)}
+
+	if subjectFilter := filter.OptionalSubjectFilter; subjectFilter != nil {
+		subjectRelation := ""
+		if subjectFilter.OptionalRelation != nil {
+			subjectRelation = subjectFilter.OptionalRelation.Relation
+		}
+		refs = append(refs, filterComponentReference(subjectFilter.SubjectType, subjectRelation))
+	}
+
+	return refs
+}
+
+func filterComponentReference(objectType, optionalRelation string) namespaceRelation {
+	return namespaceRelation{
+		namespace:     objectType,
+		relation:      stringz.DefaultEmpty(optionalRelation, datastore.Ellipsis),
+		allowEllipsis: optionalRelation == "",
+	}
+}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+type countingNamespaceReader struct {
+	datastore.Reader
+	lookups     int
+	singleReads int
+}
+
+func (r *countingNamespaceReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	r.singleReads++
+	return r.Reader.ReadNamespace(ctx, nsName)
+}
+
+func (r *countingNamespaceReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	r.lookups++
+	return r.Reader.LookupNamespaces(ctx, nsNames)
+}
+
+func TestValidateNamespaceRelations(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+	ctx := context.Background()
+
+	valid := namespaceRelation{namespace: "document", relation: "viewer"}
+	validEllipsis := namespaceRelation{namespace: "user", relation: datastore.Ellipsis, allowEllipsis: true}
+	unknownNamespace := namespaceRelation{namespace: "unknown", relation: "viewer"}
+	unknownRelation := namespaceRelation{namespace: "document", relation: "unknown"}
+	disallowedEllipsis := namespaceRelation{namespace: "document", relation: datastore.Ellipsis}
+
+	reader := &countingNamespaceReader{Reader: ds.SnapshotReader(revision)}
+	checks, err := validateNamespaceRelations(ctx, reader, []namespaceRelation{
+		valid, validEllipsis, unknownNamespace, unknownRelation, disallowedEllipsis, valid,
+	})
+	require.NoError(err)
+	require.Equal(1, reader.lookups)
+	require.Equal(0, reader.singleReads)
+
+	require.NoError(checks.check(valid, validEllipsis))
+	require.ErrorAs(checks.check(valid, unknownNamespace), &datastore.ErrNamespaceNotFound{})
+	require.ErrorAs(checks.check(unknownRelation), &namespace.ErrRelationNotFound{})
+	require.ErrorAs(checks.check(disallowedEllipsis), &namespace.ErrRelationNotFound{})
+
+	// References which were not validated in the batch are reported, rather than assumed valid.
+	require.Error(checks.check(namespaceRelation{namespace: "folder", relation: "viewer"}))
+}
+
+func TestFilterReferences(t *testing.T) {
+	require.Equal(t, []namespaceRelation{
+		{namespace: "document", relation: datastore.Ellipsis, allowEllipsis: true},
+	}, filterReferences(&v1.RelationshipFilter{ResourceType: "document"}))
+
+	require.Equal(t, []namespaceRelation{
+		{namespace: "document", relation: "parent"},
+		{namespace: "folder", relation: "viewer"},
+	}, filterReferences(&v1.RelationshipFilter{
+		ResourceType:     "document",
+		OptionalRelation: "parent",
+		OptionalSubjectFilter: &v1.SubjectFilter{
+			SubjectType:       "folder",
+			OptionalRelation:  &v1.SubjectFilter_RelationFilter{Relation: "viewer"},
+			OptionalSubjectId: "company",
+		},
+	}))
+}
@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/graph"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type overlayCheckServer struct {
+	spicedbv1.UnimplementedOverlayCheckServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewOverlayCheckServer creates an instance of the OverlayCheck server, which shares the
+// configuration of the permissions server.
+//
+// Checks against an overlay describe a state which no revision identifies, so they cannot be
+// dispatched to other nodes or cached; the server computes them with a local-only dispatcher
+// of its own, with the default concurrency limits.
+func NewOverlayCheckServer(config PermissionsServerConfig) spicedbv1.OverlayCheckServiceServer {
+	return &overlayCheckServer{
+		dispatch: graph.NewLocalOnlyDispatcherWithLimits(graph.ConcurrencyLimits{}),
+		config: PermissionsServerConfig{
+			MaximumAPIDepth:    defaultIfZero(config.MaximumAPIDepth, 50),
+			MaxUpdatesPerWrite: defaultIfZero(config.MaxUpdatesPerWrite, 1000),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (ocs *overlayCheckServer) CheckPermissionWithOverlay(ctx context.Context, req *spicedbv1.CheckPermissionWithOverlayRequest) (*spicedbv1.CheckPermissionWithOverlayResponse, error) {
+	// The overlay is bounded like the updates of a write, as it is held in memory and merged
+	// into every relationship query of the check.
+	if len(req.OverlayUpdates) > int(ocs.config.MaxUpdatesPerWrite) {
+		return nil, rewriteError(
+			ctx,
+			NewExceedsMaximumUpdatesErr(uint64(len(req.OverlayUpdates)), ocs.config.MaxUpdatesPerWrite),
+		)
+	}
+
+	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	errG, checksCtx := errgroup.WithContext(ctx)
+	errG.Go(func() error {
+		return namespace.CheckNamespaceAndRelation(
+			checksCtx,
+			req.Resource.ObjectType,
+			req.Permission,
+			false,
+			ds,
+		)
+	})
+	errG.Go(func() error {
+		return namespace.CheckNamespaceAndRelation(
+			checksCtx,
+			req.Subject.Object.ObjectType,
+			normalizeSubjectRelation(req.Subject),
+			true,
+			ds,
+		)
+	})
+	if err := errG.Wait(); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	cr, metadata, err := computed.ComputeOverlayCheck(ctx, ocs.dispatch,
+		computed.CheckParameters{
+			ResourceType: &core.RelationReference{
+				Namespace: req.Resource.ObjectType,
+				Relation:  req.Permission,
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  ocs.config.MaximumAPIDepth,
+			DebugOption:   computed.NoDebugging,
+		},
+		req.Resource.ObjectId,
+		tuple.UpdateFromRelationshipUpdates(req.OverlayUpdates),
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	var partialCaveat *v1.PartialCaveatInfo
+	permissionship := v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION
+	if cr.Membership == dispatchv1.ResourceCheckResult_MEMBER {
+		permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION
+	} else if cr.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+		permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+		partialCaveat = &v1.PartialCaveatInfo{
+			MissingRequiredContext: cr.MissingExprFields,
+		}
+	}
+
+	return &spicedbv1.CheckPermissionWithOverlayResponse{
+		CheckedAt:         checkedAt,
+		Permissionship:    permissionship,
+		PartialCaveatInfo: partialCaveat,
+	}, nil
+}
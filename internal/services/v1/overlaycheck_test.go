@@ -0,0 +1,81 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestCheckPermissionWithOverlay(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	overlayClient := spicedbv1.NewOverlayCheckServiceClient(conn)
+	permissionsClient := v1.NewPermissionsServiceClient(conn)
+	ctx := context.Background()
+	consistency := &v1.Consistency{
+		Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+	}
+
+	check := func(subjectID string, overlay ...*v1.RelationshipUpdate) (v1.CheckPermissionResponse_Permissionship, error) {
+		resp, err := overlayClient.CheckPermissionWithOverlay(ctx, &spicedbv1.CheckPermissionWithOverlayRequest{
+			Consistency:    consistency,
+			Resource:       &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+			Permission:     "view",
+			Subject:        &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: subjectID}},
+			OverlayUpdates: overlay,
+		})
+		if err != nil {
+			return v1.CheckPermissionResponse_PERMISSIONSHIP_UNSPECIFIED, err
+		}
+
+		req.NotNil(resp.CheckedAt)
+		return resp.Permissionship, nil
+	}
+
+	removeEngLead := tuple.UpdateToRelationshipUpdate(tuple.Delete(tuple.MustParse("document:masterplan#viewer@user:eng_lead")))
+	addVillain := tuple.UpdateToRelationshipUpdate(tuple.Create(tuple.MustParse("document:masterplan#viewer@user:villain")))
+
+	permissionship, err := check("eng_lead", removeEngLead, addVillain)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, permissionship)
+
+	permissionship, err = check("villain", removeEngLead, addVillain)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, permissionship)
+
+	// Without an overlay, the check is that of the stored relationships.
+	permissionship, err = check("eng_lead")
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, permissionship)
+
+	// Nothing is written.
+	resp, err := permissionsClient.CheckPermission(ctx, &v1.CheckPermissionRequest{
+		Consistency: &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}},
+		Resource:    &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+		Permission:  "view",
+		Subject:     &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "villain"}},
+	})
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, resp.Permissionship)
+
+	_, err = overlayClient.CheckPermissionWithOverlay(ctx, &spicedbv1.CheckPermissionWithOverlayRequest{
+		Consistency: consistency,
+		Resource:    &v1.ObjectReference{ObjectType: "document", ObjectId: "masterplan"},
+		Permission:  "unknown",
+		Subject:     &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: "user", ObjectId: "villain"}},
+	})
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+}
@@ -2,7 +2,12 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 
@@ -33,7 +38,72 @@ import (
 
 const maxCaveatContextBytes = 4096
 
+// IgnoreCaveatsMetadataKey is the request metadata key which, when set to "true" on a
+// CheckPermission call, treats every caveated relationship as unconditionally present. This
+// matches the behavior of CheckPermission before caveats were introduced, and allows comparing
+// both evaluations during a caveat rollout.
+const IgnoreCaveatsMetadataKey = "io.spicedb.ignore-caveats"
+
+// LookupResourcesTruncatedTrailerKey is the response trailer metadata key which is set to "true"
+// when a LookupResources call stopped at the maximum number of results configured for the
+// server, while further resources remained to be returned.
+const LookupResourcesTruncatedTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.lookupresourcestruncated"
+
+// IncludeProofMetadataKey is the request metadata key which, when set to "true" on a
+// CheckPermission call, requests the relationships which prove that the subject has the
+// permission. They are returned in the CheckProofTrailerKey response trailer.
+const IncludeProofMetadataKey = "io.spicedb.include-proof"
+
+// CheckProofTrailerKey is the response trailer metadata key holding, when requested via
+// IncludeProofMetadataKey, a JSON array of the relationships which prove that the subject has the
+// permission, conditionally or otherwise. It is not set if the subject does not have the
+// permission.
+const CheckProofTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.checkproof"
+
+// CheckSoftDeadlineMetadataKey is the request metadata key which, on a CheckPermission call, sets
+// a soft deadline for the check, as a positive duration from the receipt of the request, such as
+// `250ms`. Once it is reached, the check returns the best-effort result computed so far, rather
+// than failing at the deadline of the call, and sets the CheckIncompleteTrailerKey response
+// trailer.
+const CheckSoftDeadlineMetadataKey = "io.spicedb.check-soft-deadline"
+
+// CheckIncompleteTrailerKey is the response trailer metadata key which is set to "true" when a
+// CheckPermission call reached the soft deadline requested via CheckSoftDeadlineMetadataKey, and
+// so returned a best-effort result: the branches not yet resolved were treated as having found no
+// exclusion and no intersection failure.
+const CheckIncompleteTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.checkincomplete"
+
+// MaxExpandLeafSubjectsMetadataKey is the request metadata key which, on an ExpandPermissionTree
+// call, sets the maximum number of leaf subjects the expansion may have before the call fails with
+// ResourceExhausted. The value must be a positive integer; it cannot raise the maximum configured
+// for the server.
+const MaxExpandLeafSubjectsMetadataKey = "io.spicedb.max-expand-leaf-subjects"
+
+// ExpandDepthLimitedTrailerKey is the response trailer metadata key which is set to "true" when
+// an ExpandPermissionTree call reached the maximum dispatch depth, and so returned a partial tree:
+// each subproblem which was not expanded is returned as a leaf without subjects, for its expanded
+// object and relation.
+const ExpandDepthLimitedTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.expanddepthlimited"
+
+// CaveatContextOverridesMetadataKey is the request metadata key for caveat context which, on a
+// CheckPermission call, applies only to caveats found on relationships of specific namespaces
+// or relations. The value is a JSON object whose keys are either a namespace, such as
+// `document`, or a namespace and relation, such as `document#viewer`, and whose values are the
+// caveat context objects to apply. For conflicting keys, the context for a relation takes
+// precedence over that for its namespace, which takes precedence over the request's context;
+// context written on a relationship always takes precedence over all of them.
+const CaveatContextOverridesMetadataKey = "io.spicedb.caveat-context-overrides"
+
+// WellKnownCaveatContextNow is the name of the well-known caveat context value holding the time
+// at which a CheckPermission request was received, as an RFC 3339 timestamp, for use by caveats
+// with a `timestamp` parameter of the same name.
+const WellKnownCaveatContextNow = "now"
+
 func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPermissionRequest) (*v1.CheckPermissionResponse, error) {
+	start := time.Now()
+	var labels []relationLabels
+	defer func() { ps.metrics.observe("CheckPermission", start, labels...) }()
+
 	atRevision, checkedAt := consistency.MustRevisionFromContext(ctx)
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
 
@@ -42,6 +112,10 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 		return nil, rewriteError(ctx, err)
 	}
 
+	if ps.config.WellKnownCaveatContext {
+		caveatContext = withWellKnownCaveatContext(caveatContext, start)
+	}
+
 	// Perform our preflight checks in parallel
 	errG, checksCtx := errgroup.WithContext(ctx)
 	errG.Go(func() error {
@@ -65,13 +139,42 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 	if err := errG.Wait(); err != nil {
 		return nil, rewriteError(ctx, err)
 	}
+	labels = []relationLabels{{req.Resource.ObjectType, req.Permission}}
 
 	debugOption := computed.NoDebugging
+	ignoreCaveats := false
+	includeProof := false
+	var softDeadline time.Time
+	var contextOverrides cexpr.ContextOverrides
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		_, isDebuggingEnabled := md[string(requestmeta.RequestDebugInformation)]
 		if isDebuggingEnabled {
 			debugOption = computed.BasicDebuggingEnabled
 		}
+
+		values := md.Get(IgnoreCaveatsMetadataKey)
+		ignoreCaveats = len(values) > 0 && values[0] == "true"
+
+		values = md.Get(IncludeProofMetadataKey)
+		includeProof = len(values) > 0 && values[0] == "true"
+
+		values = md.Get(CheckSoftDeadlineMetadataKey)
+		if len(values) > 0 && values[0] != "" {
+			timeout, err := time.ParseDuration(values[0])
+			if err != nil || timeout <= 0 {
+				return nil, rewriteError(
+					ctx,
+					status.Errorf(codes.InvalidArgument, "invalid check soft deadline `%s`: must be a positive duration", values[0]),
+				)
+			}
+			softDeadline = start.Add(timeout)
+		}
+
+		overrides, err := getCaveatContextOverrides(ctx, md.Get(CaveatContextOverridesMetadataKey))
+		if err != nil {
+			return nil, err
+		}
+		contextOverrides = overrides
 	}
 
 	cr, metadata, err := computed.ComputeCheck(ctx, ps.dispatch,
@@ -89,6 +192,11 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 			AtRevision:    atRevision,
 			MaximumDepth:  ps.config.MaximumAPIDepth,
 			DebugOption:   debugOption,
+			IgnoreCaveats: ignoreCaveats,
+			IncludeProof:  includeProof,
+			SoftDeadline:  softDeadline,
+
+			CaveatContextOverrides: contextOverrides,
 		},
 		req.Resource.ObjectId,
 	)
@@ -119,6 +227,21 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 		return nil, rewriteError(ctx, err)
 	}
 
+	if metadata.GetIncomplete() {
+		serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			CheckIncompleteTrailerKey: "true",
+		})
+		if serr != nil {
+			return nil, rewriteError(ctx, serr)
+		}
+	}
+
+	if includeProof && cr.Membership != dispatch.ResourceCheckResult_NOT_MEMBER {
+		if err := setCheckProofTrailer(ctx, cr.Proof); err != nil {
+			return nil, rewriteError(ctx, err)
+		}
+	}
+
 	var partialCaveat *v1.PartialCaveatInfo
 	permissionship := v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION
 	if cr.Membership == dispatch.ResourceCheckResult_MEMBER {
@@ -137,7 +260,33 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 	}, nil
 }
 
+// setCheckProofTrailer places the string forms of the relationships of the proof into the
+// response trailer.
+func setCheckProofTrailer(ctx context.Context, proof []*core.RelationTuple) error {
+	relationships := make([]string, 0, len(proof))
+	for _, tpl := range proof {
+		relationship, err := tuple.String(tpl)
+		if err != nil {
+			return err
+		}
+		relationships = append(relationships, relationship)
+	}
+
+	marshaled, err := json.Marshal(relationships)
+	if err != nil {
+		return err
+	}
+
+	return responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		CheckProofTrailerKey: string(marshaled),
+	})
+}
+
 func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.ExpandPermissionTreeRequest) (*v1.ExpandPermissionTreeResponse, error) {
+	start := time.Now()
+	var labels []relationLabels
+	defer func() { ps.metrics.observe("ExpandPermissionTree", start, labels...) }()
+
 	atRevision, expandedAt := consistency.MustRevisionFromContext(ctx)
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
 
@@ -145,6 +294,12 @@ func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.Ex
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
+	labels = []relationLabels{{req.Resource.ObjectType, req.Permission}}
+
+	maxLeafSubjects, err := ps.maxExpandLeafSubjects(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := ps.dispatch.DispatchExpand(ctx, &dispatch.DispatchExpandRequest{
 		Metadata: &dispatch.ResolverMeta{
@@ -156,13 +311,38 @@ func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.Ex
 			ObjectId:  req.Resource.ObjectId,
 			Relation:  req.Permission,
 		},
-		ExpansionMode: dispatch.DispatchExpandRequest_SHALLOW,
+		ExpansionMode:   dispatch.DispatchExpandRequest_SHALLOW,
+		MaxLeafSubjects: maxLeafSubjects,
 	})
 	usagemetrics.SetInContext(ctx, resp.Metadata)
+
+	// The expansion stops once it has found more leaf subjects than the maximum.
+	var limitErr graph.ErrLeafSubjectLimitExceeded
+	if errors.As(err, &limitErr) {
+		ps.metrics.observeExpandSize(labels[0], limitErr.LeafSubjectCount())
+		return nil, rewriteError(ctx, NewExceedsMaximumExpandLeafSubjectsErr(limitErr.LeafSubjectCount(), maxLeafSubjects))
+	}
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
+	leafSubjectCount := graph.CountLeafSubjects(resp.TreeNode)
+	ps.metrics.observeExpandSize(labels[0], leafSubjectCount)
+	if maxLeafSubjects > 0 && leafSubjectCount > uint64(maxLeafSubjects) {
+		return nil, rewriteError(ctx, NewExceedsMaximumExpandLeafSubjectsErr(leafSubjectCount, maxLeafSubjects))
+	}
+
+	// A depth limited tree is returned as is, and flagged so that the client can decide whether
+	// to expand the subproblems which were not expanded.
+	if resp.DepthLimited {
+		serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			ExpandDepthLimitedTrailerKey: "true",
+		})
+		if serr != nil {
+			return nil, rewriteError(ctx, serr)
+		}
+	}
+
 	// TODO(jschorr): Change to either using shared interfaces for nodes, or switch the internal
 	// dispatched expand to return V1 node types.
 	return &v1.ExpandPermissionTreeResponse{
@@ -171,6 +351,36 @@ func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.Ex
 	}, nil
 }
 
+// maxExpandLeafSubjects returns the maximum number of leaf subjects for an expansion, which is the
+// maximum configured for the server, lowered by MaxExpandLeafSubjectsMetadataKey if given. Zero
+// means no maximum.
+func (ps *permissionServer) maxExpandLeafSubjects(ctx context.Context) (uint32, error) {
+	maxLeafSubjects := ps.config.MaxExpandLeafSubjects
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return maxLeafSubjects, nil
+	}
+
+	values := md.Get(MaxExpandLeafSubjectsMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return maxLeafSubjects, nil
+	}
+
+	requested, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil || requested == 0 {
+		return 0, rewriteError(
+			ctx,
+			status.Errorf(codes.InvalidArgument, "invalid maximum number of expand leaf subjects `%s`: must be a positive integer", values[0]),
+		)
+	}
+
+	if maxLeafSubjects == 0 || uint32(requested) < maxLeafSubjects {
+		return uint32(requested), nil
+	}
+	return maxLeafSubjects, nil
+}
+
 // TranslateRelationshipTree translates a V1 PermissionRelationshipTree into a RelationTupleTreeNode.
 func TranslateRelationshipTree(tree *v1.PermissionRelationshipTree) *core.RelationTupleTreeNode {
 	var expanded *core.ObjectAndRelation
@@ -345,49 +555,76 @@ func (ps *permissionServer) LookupResources(req *v1.LookupResourcesRequest, resp
 		return rewriteError(ctx, err)
 	}
 
-	// TODO(jschorr): Change the internal dispatched lookup to also be streamed.
-	lookupResp, err := ps.dispatch.DispatchLookup(ctx, &dispatch.DispatchLookupRequest{
-		Metadata: &dispatch.ResolverMeta{
-			AtRevision:     atRevision.String(),
-			DepthRemaining: ps.config.MaximumAPIDepth,
-		},
-		ObjectRelation: &core.RelationReference{
-			Namespace: req.ResourceObjectType,
-			Relation:  req.Permission,
-		},
-		Subject: &core.ObjectAndRelation{
-			Namespace: req.Subject.Object.ObjectType,
-			ObjectId:  req.Subject.Object.ObjectId,
-			Relation:  normalizeSubjectRelation(req.Subject),
-		},
-		Context: req.Context,
-		Limit:   ^uint32(0), // Set no limit for now
-	})
-	usagemetrics.SetInContext(ctx, lookupResp.Metadata)
-	if err != nil {
-		return rewriteError(ctx, err)
+	respMetadata := &dispatch.ResponseMeta{
+		DispatchCount:       0,
+		CachedDispatchCount: 0,
+		DepthRequired:       0,
+		DebugInfo:           nil,
 	}
+	usagemetrics.SetInContext(ctx, respMetadata)
+
+	truncated := false
+	stream := dispatchpkg.NewHandlingDispatchStream(ctx, func(result *dispatch.DispatchLookupResponse) error {
+		for _, found := range result.ResolvedResources {
+			var partial *v1.PartialCaveatInfo
+			permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
+			if found.Permissionship == dispatch.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
+				permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+				partial = &v1.PartialCaveatInfo{
+					MissingRequiredContext: found.MissingRequiredContext,
+				}
+			}
 
-	for _, found := range lookupResp.ResolvedResources {
-		var partial *v1.PartialCaveatInfo
-		permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
-		if found.Permissionship == dispatch.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
-			permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION
-			partial = &v1.PartialCaveatInfo{
-				MissingRequiredContext: found.MissingRequiredContext,
+			err := resp.Send(&v1.LookupResourcesResponse{
+				LookedUpAt:        revisionReadAt,
+				ResourceObjectId:  found.ResourceId,
+				Permissionship:    permissionship,
+				PartialCaveatInfo: partial,
+			})
+			if err != nil {
+				return err
 			}
 		}
 
-		err := resp.Send(&v1.LookupResourcesResponse{
-			LookedUpAt:        revisionReadAt,
-			ResourceObjectId:  found.ResourceId,
-			Permissionship:    permissionship,
-			PartialCaveatInfo: partial,
+		truncated = truncated || result.Truncated
+		dispatchpkg.AddResponseMetadata(respMetadata, result.Metadata)
+		return nil
+	})
+
+	limit := ps.config.MaxLookupResourcesResults
+	if limit == 0 {
+		limit = ^uint32(0)
+	}
+
+	err := ps.dispatch.DispatchLookup(
+		&dispatch.DispatchLookupRequest{
+			Metadata: &dispatch.ResolverMeta{
+				AtRevision:     atRevision.String(),
+				DepthRemaining: ps.config.MaximumAPIDepth,
+			},
+			ObjectRelation: &core.RelationReference{
+				Namespace: req.ResourceObjectType,
+				Relation:  req.Permission,
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			Context: req.Context,
+			Limit:   limit,
+		},
+		stream)
+	if err != nil {
+		return rewriteError(ctx, err)
+	}
+
+	if truncated {
+		return responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+			LookupResourcesTruncatedTrailerKey: "true",
 		})
-		if err != nil {
-			return err
-		}
 	}
+
 	return nil
 }
 
@@ -573,3 +810,61 @@ func getCaveatContext(ctx context.Context, caveatCtx *structpb.Struct) (map[stri
 	}
 	return caveatContext, nil
 }
+
+// withWellKnownCaveatContext returns the caveat context of a request merged with the well-known
+// context values for a request received at the given time. The request's own values take
+// precedence over the well-known values, and so, in turn, do the context overrides and the
+// context written on relationships.
+func withWellKnownCaveatContext(caveatContext map[string]any, receivedAt time.Time) map[string]any {
+	merged := map[string]any{
+		WellKnownCaveatContextNow: receivedAt.UTC().Format(time.RFC3339Nano),
+	}
+	for key, value := range caveatContext {
+		merged[key] = value
+	}
+	return merged
+}
+
+func getCaveatContextOverrides(ctx context.Context, values []string) (cexpr.ContextOverrides, error) {
+	if len(values) == 0 || values[0] == "" {
+		return nil, nil
+	}
+
+	if size := len(values[0]); size > maxCaveatContextBytes {
+		return nil, rewriteError(
+			ctx,
+			status.Errorf(
+				codes.InvalidArgument,
+				"caveat context overrides should have less than %d bytes but had %d",
+				maxCaveatContextBytes,
+				size,
+			),
+		)
+	}
+
+	var parsed map[string]map[string]any
+	if err := json.Unmarshal([]byte(values[0]), &parsed); err != nil {
+		return nil, rewriteError(
+			ctx,
+			status.Errorf(codes.InvalidArgument, "invalid caveat context overrides: %s", err),
+		)
+	}
+
+	overrides := make(cexpr.ContextOverrides, 0, len(parsed))
+	for key, overrideContext := range parsed {
+		namespaceName, relationName, _ := strings.Cut(key, "#")
+		if namespaceName == "" {
+			return nil, rewriteError(
+				ctx,
+				status.Errorf(codes.InvalidArgument, "invalid caveat context override key `%s`", key),
+			)
+		}
+
+		overrides = append(overrides, cexpr.ContextOverride{
+			Namespace: namespaceName,
+			Relation:  relationName,
+			Context:   overrideContext,
+		})
+	}
+	return overrides, nil
+}
@@ -2,6 +2,7 @@ package v1_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -33,6 +34,7 @@ import (
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/spiceerrors"
 	"github.com/authzed/spicedb/pkg/tuple"
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
@@ -865,6 +867,381 @@ func TestCheckWithCaveats(t *testing.T) {
 	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
 }
 
+func TestCheckIgnoringCaveats(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true, tf.StandardDatastoreWithCaveatedData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	request := &v1.CheckPermissionRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtLeastAsFresh{
+				AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+			},
+		},
+		Resource:   obj("document", "companyplan"),
+		Permission: "view",
+		Subject:    sub("user", "owner", ""),
+	}
+
+	var err error
+	request.Context, err = structpb.NewStruct(map[string]any{"secret": "incorrect_value"})
+	req.NoError(err)
+
+	// The caveat is ignored, so the caveated relationship is treated as a plain grant.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.IgnoreCaveatsMetadataKey, "true")
+	checkResp, err := client.CheckPermission(ctx, request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, checkResp.Permissionship)
+
+	// The same holds when the caveat context is missing entirely.
+	request.Context = nil
+	checkResp, err = client.CheckPermission(ctx, request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, checkResp.Permissionship)
+	req.Nil(checkResp.PartialCaveatInfo)
+
+	// Without the metadata key, the caveat is evaluated.
+	checkResp, err = client.CheckPermission(context.Background(), request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION, checkResp.Permissionship)
+}
+
+func TestCheckWithProof(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+				caveat is_tuesday(day string) {
+					day == 'tuesday'
+				}
+
+				definition user {}
+
+				definition group {
+					relation member: user
+				}
+
+				definition document {
+					relation viewer: user | group#member | user with is_tuesday
+					permission view = viewer
+				}
+			`, []*core.RelationTuple{
+				tuple.MustParse("document:doc#viewer@group:eng#member"),
+				tuple.MustParse("group:eng#member@user:tom"),
+				tuple.MustParse("document:doc#viewer@user:sarah[is_tuesday]"),
+			}, require)
+		})
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	proofCtx := metadata.AppendToOutgoingContext(context.Background(), v1svc.IncludeProofMetadataKey, "true")
+
+	for _, tc := range []struct {
+		name                   string
+		ctx                    context.Context
+		subject                string
+		expectedPermissionship v1.CheckPermissionResponse_Permissionship
+		expectedProof          []string
+	}{
+		{
+			"proof via group",
+			proofCtx,
+			"tom",
+			v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+			[]string{"document:doc#viewer@group:eng#member", "group:eng#member@user:tom"},
+		},
+		{
+			"proof via caveated relationship",
+			proofCtx,
+			"sarah",
+			v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION,
+			[]string{"document:doc#viewer@user:sarah[is_tuesday]"},
+		},
+		{
+			"no proof without permission",
+			proofCtx,
+			"fred",
+			v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+			nil,
+		},
+		{
+			"no proof unless requested",
+			context.Background(),
+			"tom",
+			v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+			nil,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+
+			var trailer metadata.MD
+			checkResp, err := client.CheckPermission(tc.ctx, &v1.CheckPermissionRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtLeastAsFresh{
+						AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+					},
+				},
+				Resource:   obj("document", "doc"),
+				Permission: "view",
+				Subject:    sub("user", tc.subject, ""),
+			}, grpc.Trailer(&trailer))
+			req.NoError(err)
+			req.Equal(tc.expectedPermissionship, checkResp.Permissionship)
+
+			encodedProof, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.CheckProofTrailerKey)
+			req.NoError(err)
+			if tc.expectedProof == nil {
+				req.Nil(encodedProof)
+				return
+			}
+
+			req.NotNil(encodedProof)
+			var proof []string
+			req.NoError(json.Unmarshal([]byte(*encodedProof), &proof))
+			req.ElementsMatch(tc.expectedProof, proof)
+		})
+	}
+}
+
+func TestCheckWithSoftDeadline(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+				definition user {}
+
+				definition document {
+					relation viewer: user
+					relation banned: user
+					permission view = viewer - banned
+				}
+			`, []*core.RelationTuple{
+				tuple.MustParse("document:doc#viewer@user:tom"),
+				tuple.MustParse("document:doc#viewer@user:fred"),
+				tuple.MustParse("document:doc#banned@user:fred"),
+			}, require)
+		})
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	check := func(softDeadline string, subject string) (*v1.CheckPermissionResponse, metadata.MD, error) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.CheckSoftDeadlineMetadataKey, softDeadline)
+
+		var trailer metadata.MD
+		resp, err := client.CheckPermission(ctx, &v1.CheckPermissionRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{
+					AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+				},
+			},
+			Resource:   obj("document", "doc"),
+			Permission: "view",
+			Subject:    sub("user", subject, ""),
+		}, grpc.Trailer(&trailer))
+		return resp, trailer, err
+	}
+
+	// A check completed before its soft deadline is not marked incomplete.
+	for subject, expected := range map[string]v1.CheckPermissionResponse_Permissionship{
+		"tom":  v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+		"fred": v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION,
+	} {
+		resp, trailer, err := check("1m", subject)
+		req.NoError(err)
+		req.Equal(expected, resp.Permissionship, subject)
+
+		incomplete, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.CheckIncompleteTrailerKey)
+		req.NoError(err)
+		req.Nil(incomplete, subject)
+	}
+
+	for _, invalid := range []string{"soon", "0s", "-1s"} {
+		_, _, err := check(invalid, "tom")
+		grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	}
+}
+
+func TestCheckWithCaveatContextOverrides(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true, tf.StandardDatastoreWithCaveatedData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	request := &v1.CheckPermissionRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtLeastAsFresh{
+				AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+			},
+		},
+		Resource:   obj("document", "companyplan"),
+		Permission: "view",
+		Subject:    sub("user", "owner", ""),
+	}
+
+	// The secret is only provided for the caveat on the folder, so the caveat on the document's
+	// parent relationship cannot be computed.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.CaveatContextOverridesMetadataKey, `{"folder": {"secret": "1234"}}`)
+	checkResp, err := client.CheckPermission(ctx, request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION, checkResp.Permissionship)
+
+	// With the request's context, both caveats are satisfied.
+	request.Context, err = structpb.NewStruct(map[string]any{"secret": "1234"})
+	req.NoError(err)
+
+	checkResp, err = client.CheckPermission(ctx, request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, checkResp.Permissionship)
+
+	// An override for the relation takes precedence over both the namespace override and the
+	// request's context.
+	ctx = metadata.AppendToOutgoingContext(context.Background(), v1svc.CaveatContextOverridesMetadataKey, `{"folder": {"secret": "1234"}, "folder#owner": {"secret": "incorrect_value"}}`)
+	checkResp, err = client.CheckPermission(ctx, request)
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, checkResp.Permissionship)
+
+	// Invalid overrides are rejected.
+	ctx = metadata.AppendToOutgoingContext(context.Background(), v1svc.CaveatContextOverridesMetadataKey, `{"folder": "1234"}`)
+	_, err = client.CheckPermission(ctx, request)
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
+func TestCheckWithWellKnownCaveatContext(t *testing.T) {
+	now := time.Now().UTC()
+	future := now.Add(time.Hour).Format(time.RFC3339)
+	past := now.Add(-time.Hour).Format(time.RFC3339)
+
+	for _, enabled := range []bool{true, false} {
+		enabled := enabled
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			req := require.New(t)
+			conn, cleanup, _, revision := testserver.NewTestServerWithConfig(req, testTimedeltas[0], memdb.DisableGC, true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:            1000,
+					MaxPreconditionsCount:         1000,
+					WellKnownCaveatContextEnabled: enabled,
+				},
+				func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+					return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+						definition user {}
+
+						caveat unexpired(now timestamp, expires_at timestamp) {
+							now < expires_at
+						}
+
+						definition document {
+							relation viewer: user with unexpired
+							permission view = viewer
+						}
+					`, []*core.RelationTuple{
+						tuple.WithCaveat(tuple.MustParse("document:current#viewer@user:tom"), "unexpired", map[string]any{"expires_at": future}),
+						tuple.WithCaveat(tuple.MustParse("document:expired#viewer@user:tom"), "unexpired", map[string]any{"expires_at": past}),
+					}, require)
+				})
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			check := func(resourceID string, caveatContext map[string]any) v1.CheckPermissionResponse_Permissionship {
+				request := &v1.CheckPermissionRequest{
+					Consistency: &v1.Consistency{
+						Requirement: &v1.Consistency_AtLeastAsFresh{
+							AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+						},
+					},
+					Resource:   obj("document", resourceID),
+					Permission: "view",
+					Subject:    sub("user", "tom", ""),
+				}
+
+				if caveatContext != nil {
+					var err error
+					request.Context, err = structpb.NewStruct(caveatContext)
+					req.NoError(err)
+				}
+
+				checkResp, err := client.CheckPermission(context.Background(), request)
+				req.NoError(err)
+				return checkResp.Permissionship
+			}
+
+			if enabled {
+				// The request time is provided as `now`, bounding each relationship in time.
+				req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, check("current", nil))
+				req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, check("expired", nil))
+			} else {
+				req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION, check("current", nil))
+				req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION, check("expired", nil))
+			}
+
+			// The request's own value takes precedence over the well-known value.
+			earlier := map[string]any{"now": now.Add(-2 * time.Hour).Format(time.RFC3339)}
+			req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, check("expired", earlier))
+		})
+	}
+}
+
+func TestCheckWithListCaveatContext(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+				definition user {}
+
+				caveat testcaveat(allowed list<string>, index int) {
+					allowed[index] == 'tom'
+				}
+
+				definition document {
+					relation viewer: user with testcaveat
+					permission view = viewer
+				}
+			`, []*core.RelationTuple{
+				tuple.WithCaveat(tuple.MustParse("document:first#viewer@user:tom"), "testcaveat", map[string]any{"index": 1}),
+			}, require)
+		})
+
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	check := func(caveatContext map[string]any) (*v1.CheckPermissionResponse, error) {
+		encodedContext, err := structpb.NewStruct(caveatContext)
+		req.NoError(err)
+
+		return client.CheckPermission(context.Background(), &v1.CheckPermissionRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{
+					AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+				},
+			},
+			Resource:   obj("document", "first"),
+			Permission: "view",
+			Subject:    sub("user", "tom", ""),
+			Context:    encodedContext,
+		})
+	}
+
+	resp, err := check(map[string]any{"allowed": []any{"sarah", "tom"}})
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, resp.Permissionship)
+
+	resp, err = check(map[string]any{"allowed": []any{"tom", "sarah"}})
+	req.NoError(err)
+	req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_NO_PERMISSION, resp.Permissionship)
+
+	// A value of the wrong type for the parameter is rejected.
+	_, err = check(map[string]any{"allowed": "tom"})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	spiceerrors.RequireReason(t, v1.ErrorReason_ERROR_REASON_CAVEAT_PARAMETER_TYPE_ERROR, err, "parameter_name")
+
+	// As is a list which cannot be indexed by the written index.
+	_, err = check(map[string]any{"allowed": []any{"tom"}})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	req.Contains(err.Error(), "index out of bounds")
+}
+
 func TestLookupResourcesWithCaveats(t *testing.T) {
 	req := require.New(t)
 	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
@@ -975,6 +1352,212 @@ func TestLookupResourcesWithCaveats(t *testing.T) {
 	require.Equal(t, v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION, responses[1].Permissionship)
 }
 
+func TestLookupResourcesTruncation(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		maxResults        uint32
+		expectedCount     int
+		expectedTruncated bool
+	}{
+		{"no maximum", 0, 3, false},
+		{"maximum above result count", 5, 3, false},
+		{"maximum at result count", 3, 3, false},
+		{"maximum below result count", 2, 2, true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+			conn, cleanup, _, revision := testserver.NewTestServerWithConfig(req, testTimedeltas[0], memdb.DisableGC, true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:        1000,
+					MaxPreconditionsCount:     1000,
+					MaxLookupResourcesResults: tc.maxResults,
+				},
+				func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+					return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+						definition user {}
+
+						definition group {
+							relation member: user
+						}
+
+						definition document {
+							relation viewer: user | group#member
+							permission view = viewer
+						}
+					`, []*core.RelationTuple{
+						tuple.MustParse("group:editors#member@user:tom"),
+						tuple.MustParse("document:first#viewer@user:tom"),
+						tuple.MustParse("document:first#viewer@group:editors#member"),
+						tuple.MustParse("document:second#viewer@group:editors#member"),
+						tuple.MustParse("document:third#viewer@user:tom"),
+					}, require)
+				})
+
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			var trailer metadata.MD
+			cli, err := client.LookupResources(context.Background(), &v1.LookupResourcesRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtLeastAsFresh{
+						AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+					},
+				},
+				ResourceObjectType: "document",
+				Permission:         "view",
+				Subject:            sub("user", "tom", ""),
+			}, grpc.Trailer(&trailer))
+			req.NoError(err)
+
+			foundIDs := map[string]struct{}{}
+			for {
+				res, err := cli.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				req.NoError(err)
+				req.NotContains(foundIDs, res.ResourceObjectId, "resource %s was returned more than once", res.ResourceObjectId)
+				foundIDs[res.ResourceObjectId] = struct{}{}
+			}
+
+			req.Len(foundIDs, tc.expectedCount)
+
+			truncated, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.LookupResourcesTruncatedTrailerKey)
+			req.NoError(err)
+			if tc.expectedTruncated {
+				req.NotNil(truncated)
+				req.Equal("true", *truncated)
+			} else {
+				req.Nil(truncated)
+			}
+		})
+	}
+}
+
+func TestExpandLeafSubjectsLimit(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		maxLeafSubjects   uint32
+		requestedMaximum  string
+		expectedErrorCode codes.Code
+	}{
+		{"no maximum", 0, "", codes.OK},
+		{"maximum at leaf subject count", 3, "", codes.OK},
+		{"maximum below leaf subject count", 2, "", codes.ResourceExhausted},
+		{"requested maximum below leaf subject count", 0, "2", codes.ResourceExhausted},
+		{"requested maximum below server maximum", 5, "3", codes.OK},
+		{"requested maximum above server maximum", 2, "5", codes.ResourceExhausted},
+		{"zero requested maximum", 0, "0", codes.InvalidArgument},
+		{"invalid requested maximum", 0, "many", codes.InvalidArgument},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := require.New(t)
+			conn, cleanup, _, revision := testserver.NewTestServerWithConfig(req, testTimedeltas[0], memdb.DisableGC, true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:    1000,
+					MaxPreconditionsCount: 1000,
+					MaxExpandLeafSubjects: tc.maxLeafSubjects,
+				},
+				func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+					return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+						definition user {}
+
+						definition document {
+							relation viewer: user
+							relation editor: user
+							permission view = viewer + editor
+						}
+					`, []*core.RelationTuple{
+						tuple.MustParse("document:first#viewer@user:tom"),
+						tuple.MustParse("document:first#viewer@user:sarah"),
+						tuple.MustParse("document:first#editor@user:fred"),
+					}, require)
+				})
+
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			ctx := context.Background()
+			if tc.requestedMaximum != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, v1svc.MaxExpandLeafSubjectsMetadataKey, tc.requestedMaximum)
+			}
+
+			_, err := client.ExpandPermissionTree(ctx, &v1.ExpandPermissionTreeRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtLeastAsFresh{
+						AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+					},
+				},
+				Resource:   obj("document", "first"),
+				Permission: "view",
+			})
+			if tc.expectedErrorCode == codes.OK {
+				req.NoError(err)
+			} else {
+				grpcutil.RequireStatus(t, tc.expectedErrorCode, err)
+			}
+		})
+	}
+}
+
+func TestExpandDepthLimited(t *testing.T) {
+	req := require.New(t)
+
+	// A chain of folders deeper than the maximum dispatch depth of the test server.
+	relationships := []*core.RelationTuple{tuple.MustParse("folder:0#viewer@user:tom")}
+	for i := 1; i <= 60; i++ {
+		relationships = append(relationships, tuple.MustParse(fmt.Sprintf("folder:%d#parent@folder:%d", i, i-1)))
+	}
+
+	conn, cleanup, _, revision := testserver.NewTestServer(req, testTimedeltas[0], memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			return tf.DatastoreFromSchemaAndTestRelationships(ds, `
+				definition user {}
+
+				definition folder {
+					relation parent: folder
+					relation viewer: user
+					permission view = viewer + parent->view
+				}
+			`, relationships, require)
+		})
+	t.Cleanup(cleanup)
+
+	client := v1.NewPermissionsServiceClient(conn)
+	expand := func(folderID string) (*v1.ExpandPermissionTreeResponse, *string) {
+		var trailer metadata.MD
+		resp, err := client.ExpandPermissionTree(context.Background(), &v1.ExpandPermissionTreeRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{
+					AtLeastAsFresh: zedtoken.NewFromRevision(revision),
+				},
+			},
+			Resource:   obj("folder", folderID),
+			Permission: "view",
+		}, grpc.Trailer(&trailer))
+		req.NoError(err)
+
+		depthLimited, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.ExpandDepthLimitedTrailerKey)
+		req.NoError(err)
+		return resp, depthLimited
+	}
+
+	// The partial tree is returned, and flagged in the trailer.
+	resp, depthLimited := expand("60")
+	req.NotNil(resp.TreeRoot)
+	req.NotNil(resp.ExpandedAt)
+	req.NotNil(depthLimited)
+	req.Equal("true", *depthLimited)
+
+	// A chain within the maximum depth is expanded fully, without the trailer.
+	resp, depthLimited = expand("5")
+	req.NotNil(resp.TreeRoot)
+	req.Nil(depthLimited)
+}
+
 type byIDAndPermission []*v1.LookupResourcesResponse
 
 func (a byIDAndPermission) Len() int { return len(a) }
@@ -8,10 +8,15 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
 var limitOne uint64 = 1
 
+// maxCountedPreconditionMatches is the maximum number of relationships counted as matching a
+// failed MUST_NOT_MATCH precondition, bounding the work done to report the failure.
+var maxCountedPreconditionMatches uint64 = 1000
+
 // checkPreconditions checks whether the preconditions are met in the context of a datastore
 // read-write transaction, and returns an error if they are not met.
 func checkPreconditions(
@@ -20,6 +25,23 @@ func checkPreconditions(
 	preconditions []*v1.Precondition,
 ) error {
 	for _, precond := range preconditions {
+		// A precondition on a single, fully specified relationship only needs to know whether that
+		// relationship exists. If a MUST_NOT_MATCH precondition fails, the relationship is loaded
+		// below, so that the relationship reported is the one stored, including its caveat.
+		if tpl, ok := exactRelationshipForFilter(precond.Filter); ok {
+			exists, err := datastore.RelationshipExists(ctx, rwt, tpl)
+			if err != nil {
+				return fmt.Errorf("error checking relationship existence: %w", err)
+			}
+
+			if !exists || precond.Operation != v1.Precondition_OPERATION_MUST_NOT_MATCH {
+				if err := checkPrecondition(precond, exists, nil, nil); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
 		iter, err := rwt.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(precond.Filter), options.WithLimit(&limitOne))
 		if err != nil {
 			return fmt.Errorf("error reading relationships: %w", err)
@@ -28,23 +50,87 @@ func checkPreconditions(
 
 		first := iter.Next()
 		if first == nil && iter.Err() != nil {
-			return fmt.Errorf("error reading relationships from iterator: %w", err)
+			return fmt.Errorf("error reading relationships from iterator: %w", iter.Err())
 		}
 		iter.Close()
 
-		switch precond.Operation {
-		case v1.Precondition_OPERATION_MUST_NOT_MATCH:
-			if first != nil {
-				return NewPreconditionFailedErr(precond)
-			}
-		case v1.Precondition_OPERATION_MUST_MATCH:
-			if first == nil {
-				return NewPreconditionFailedErr(precond)
+		filterMatchCount := func() (uint64, error) { return countMatchingRelationships(ctx, rwt, precond.Filter) }
+		if err := checkPrecondition(precond, first != nil, first, filterMatchCount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPrecondition returns an error if the precondition is not met, given whether a matching
+// relationship was found. The matching relationships are only counted if a MUST_NOT_MATCH
+// precondition fails, to be reported in the error.
+func checkPrecondition(precond *v1.Precondition, found bool, matched *core.RelationTuple, countMatches func() (uint64, error)) error {
+	switch precond.Operation {
+	case v1.Precondition_OPERATION_MUST_NOT_MATCH:
+		if found {
+			matchedCount, err := countMatches()
+			if err != nil {
+				return err
 			}
-		default:
-			return fmt.Errorf("unspecified precondition operation: %s", precond.Operation)
+			return NewPreconditionFailedErr(precond, matched, matchedCount)
+		}
+	case v1.Precondition_OPERATION_MUST_MATCH:
+		if !found {
+			return NewPreconditionFailedErr(precond, nil, 0)
 		}
+	default:
+		return fmt.Errorf("unspecified precondition operation: %s", precond.Operation)
 	}
 
 	return nil
 }
+
+// countMatchingRelationships counts the relationships matching the filter, up to
+// maxCountedPreconditionMatches.
+func countMatchingRelationships(ctx context.Context, rwt datastore.ReadWriteTransaction, filter *v1.RelationshipFilter) (uint64, error) {
+	iter, err := rwt.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(filter), options.WithLimit(&maxCountedPreconditionMatches))
+	if err != nil {
+		return 0, fmt.Errorf("error reading relationships: %w", err)
+	}
+	defer iter.Close()
+
+	var count uint64
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		count++
+	}
+	if iter.Err() != nil {
+		return 0, fmt.Errorf("error reading relationships from iterator: %w", iter.Err())
+	}
+
+	return count, nil
+}
+
+// exactRelationshipForFilter returns the relationship matched by the filter, if the filter
+// specifies every part of a single relationship.
+func exactRelationshipForFilter(filter *v1.RelationshipFilter) (*core.RelationTuple, bool) {
+	subjectFilter := filter.GetOptionalSubjectFilter()
+	if filter.OptionalResourceId == "" || filter.OptionalRelation == "" ||
+		subjectFilter.GetOptionalSubjectId() == "" || subjectFilter.GetOptionalRelation() == nil {
+		return nil, false
+	}
+
+	subjectRelation := subjectFilter.OptionalRelation.Relation
+	if subjectRelation == "" {
+		subjectRelation = datastore.Ellipsis
+	}
+
+	return &core.RelationTuple{
+		ResourceAndRelation: &core.ObjectAndRelation{
+			Namespace: filter.ResourceType,
+			ObjectId:  filter.OptionalResourceId,
+			Relation:  filter.OptionalRelation,
+		},
+		Subject: &core.ObjectAndRelation{
+			Namespace: subjectFilter.SubjectType,
+			ObjectId:  subjectFilter.OptionalSubjectId,
+			Relation:  subjectRelation,
+		},
+	}, true
+}
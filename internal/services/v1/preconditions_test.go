@@ -6,10 +6,18 @@ import (
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/memdb"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/spiceerrors"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 var companyPlanFolder = &v1.RelationshipFilter{
@@ -48,3 +56,194 @@ func TestPreconditions(t *testing.T) {
 	})
 	require.NoError(err)
 }
+
+func TestPreconditionFailureDetails(t *testing.T) {
+	require := require.New(t)
+	uninitialized, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(uninitialized, require)
+
+	missingFolder := &v1.RelationshipFilter{
+		ResourceType:       "document",
+		OptionalResourceId: "companyplan",
+		OptionalRelation:   "parent",
+		OptionalSubjectFilter: &v1.SubjectFilter{
+			SubjectType:       "folder",
+			OptionalSubjectId: "nonexistent",
+		},
+	}
+
+	ctx := context.Background()
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		// The first failing precondition is reported, even if later ones would also fail.
+		err := checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    missingFolder,
+			},
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    companyPlanFolder,
+			},
+			{
+				Operation: v1.Precondition_OPERATION_MUST_MATCH,
+				Filter:    missingFolder,
+			},
+		})
+		require.ErrorContains(err, "found matching relationship `document:companyplan#parent@folder:company`")
+		spiceerrors.RequireReason(t, v1.ErrorReason_ERROR_REASON_WRITE_OR_DELETE_PRECONDITION_FAILURE, err,
+			"precondition_operation",
+			"precondition_matched_relationship",
+			"precondition_matched_count",
+		)
+
+		err = checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_MATCH,
+				Filter:    missingFolder,
+			},
+		})
+		require.ErrorContains(err, "no matching relationship was found")
+		return nil
+	})
+	require.NoError(err)
+}
+
+func TestPreconditionsOnExactRelationship(t *testing.T) {
+	require := require.New(t)
+	uninitialized, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(uninitialized, require)
+
+	exactFilter := func(subjectRelation string) *v1.RelationshipFilter {
+		filter := proto.Clone(companyPlanFolder).(*v1.RelationshipFilter)
+		filter.OptionalSubjectFilter.OptionalRelation = &v1.SubjectFilter_RelationFilter{Relation: subjectRelation}
+		return filter
+	}
+
+	ctx := context.Background()
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		_, ok := exactRelationshipForFilter(exactFilter(""))
+		require.True(ok)
+
+		require.NoError(checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_MATCH,
+				Filter:    exactFilter(""),
+			},
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    exactFilter("viewer"),
+			},
+		}))
+
+		err := checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    exactFilter(""),
+			},
+		})
+		require.ErrorContains(err, "found matching relationship `document:companyplan#parent@folder:company`")
+		return nil
+	})
+	require.NoError(err)
+}
+
+func TestPreconditionOnExactRelationshipReportsStoredRelationship(t *testing.T) {
+	require := require.New(t)
+	uninitialized, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(uninitialized, require)
+
+	ctx := context.Background()
+	caveated := tuple.WithCaveat(tuple.MustParse("document:companyplan#caveated_viewer@user:tom"), "test")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, caveated)
+	require.NoError(err)
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		err := checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter: &v1.RelationshipFilter{
+					ResourceType:       "document",
+					OptionalResourceId: "companyplan",
+					OptionalRelation:   "caveated_viewer",
+					OptionalSubjectFilter: &v1.SubjectFilter{
+						SubjectType:       "user",
+						OptionalSubjectId: "tom",
+						OptionalRelation:  &v1.SubjectFilter_RelationFilter{},
+					},
+				},
+			},
+		})
+
+		var failed ErrPreconditionFailed
+		require.ErrorAs(err, &failed)
+		require.Equal("test", failed.matched.GetCaveat().GetCaveatName())
+		require.Equal(uint64(1), failed.matchedCount)
+		return nil
+	})
+	require.NoError(err)
+}
+
+func TestPreconditionMatchedCount(t *testing.T) {
+	require := require.New(t)
+	uninitialized, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(uninitialized, require)
+
+	companyViewers := &v1.RelationshipFilter{
+		ResourceType:       "folder",
+		OptionalResourceId: "company",
+		OptionalRelation:   "viewer",
+	}
+
+	ctx := context.Background()
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		// A filter which must not match any relationship reports how many it matched.
+		err := checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    companyViewers,
+			},
+		})
+		require.ErrorContains(err, "(2 matching relationship(s) in total)")
+
+		st, ok := status.FromError(err)
+		require.True(ok)
+		require.Equal(codes.FailedPrecondition, st.Code())
+		info := st.Details()[0].(*errdetails.ErrorInfo)
+		require.Equal("2", info.Metadata["precondition_matched_count"])
+
+		// A count stopped at the maximum is reported as a lower bound.
+		maxCountedPreconditionMatches = 2
+		defer func() { maxCountedPreconditionMatches = 1000 }()
+		err = checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_NOT_MATCH,
+				Filter:    companyViewers,
+			},
+		})
+		require.ErrorContains(err, "(at least 2 matching relationship(s))")
+
+		// A filter which must match some relationship reports that it matched none.
+		companyViewers.OptionalResourceId = "nonexistent"
+		err = checkPreconditions(ctx, rwt, []*v1.Precondition{
+			{
+				Operation: v1.Precondition_OPERATION_MUST_MATCH,
+				Filter:    companyViewers,
+			},
+		})
+		st, ok = status.FromError(err)
+		require.True(ok)
+		require.Equal(codes.FailedPrecondition, st.Code())
+		info = st.Details()[0].(*errdetails.ErrorInfo)
+		require.Equal("0", info.Metadata["precondition_matched_count"])
+		return nil
+	})
+	require.NoError(err)
+}
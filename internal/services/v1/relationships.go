@@ -2,29 +2,55 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
-	"github.com/jzelinskie/stringz"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/middleware"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/middleware/handwrittenvalidation"
 	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
-	"github.com/authzed/spicedb/internal/namespace"
 	"github.com/authzed/spicedb/internal/relationships"
 	"github.com/authzed/spicedb/internal/services/shared"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
-	"github.com/authzed/spicedb/pkg/util"
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
 
+// IdempotencyKeyMetadataKey is the request metadata key under which a caller can supply a key
+// identifying a WriteRelationships call. Retrying a write with the same key within the GC window
+// returns the revision of the original write instead of applying the updates again.
+const IdempotencyKeyMetadataKey = "io.spicedb.idempotency-key"
+
+// ReturnUpdateResultsMetadataKey is the request metadata key which, when set to "true" on a
+// WriteRelationships call, requests whether each update created, deleted or left unchanged a
+// relationship. The results are returned in the UpdateResultsTrailerKey response trailer.
+const ReturnUpdateResultsMetadataKey = "io.spicedb.return-update-results"
+
+// UpdateResultsTrailerKey is the response trailer metadata key holding, when requested via
+// ReturnUpdateResultsMetadataKey, a JSON array with the result of each update of the request, in
+// order: one of "created", "deleted" or "unchanged". A touch of a relationship which already
+// existed is unchanged, even if its caveat or expiration was replaced. Updates collapsed onto the same
+// relationship share a result.
+const UpdateResultsTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.updateresults"
+
+// ResourceIDPrefixMatchMetadataKey is the request metadata key which, when set to "true" on a
+// ReadRelationships call, matches the optional resource ID of the relationship filter as a
+// prefix of the resource IDs to read, rather than exactly.
+const ResourceIDPrefixMatchMetadataKey = "io.spicedb.resource-id-prefix-match"
+
 // PermissionsServerConfig is configuration for the permissions server.
 type PermissionsServerConfig struct {
 	// MaxUpdatesPerWrite holds the maximum number of updates allowed per
@@ -38,6 +64,31 @@ type PermissionsServerConfig struct {
 	// MaximumAPIDepth is the default/starting depth remaining for API calls made
 	// to the permissions server.
 	MaximumAPIDepth uint32
+
+	// MaxLookupResourcesResults holds the maximum number of resources returned by a
+	// LookupResources call, after which the call ends with a truncation marker in its trailer.
+	// If zero, the number of resources is not limited.
+	MaxLookupResourcesResults uint32
+
+	// MaxExpandLeafSubjects holds the maximum number of leaf subjects an ExpandPermissionTree call
+	// may return, after which the call fails with ResourceExhausted. Requests may lower, but not
+	// raise, the maximum via MaxExpandLeafSubjectsMetadataKey. If zero, the number of leaf subjects
+	// is not limited.
+	MaxExpandLeafSubjects uint32
+
+	// RelationshipValidationPolicy, if not nil, validates the relationships written by
+	// WriteRelationships in place of the rules of the API for object IDs, rejecting those it does
+	// not allow with InvalidArgument.
+	RelationshipValidationPolicy RelationshipValidationPolicy
+
+	// WellKnownCaveatContext provides the well-known caveat context values, such as
+	// WellKnownCaveatContextNow, to the caveats evaluated by CheckPermission calls. See
+	// withWellKnownCaveatContext for their precedence.
+	WellKnownCaveatContext bool
+
+	// MetricsRegisterer is the registerer with which the per-namespace and per-relation
+	// metrics are registered. Defaults to prometheus.DefaultRegisterer.
+	MetricsRegisterer prometheus.Registerer
 }
 
 // NewPermissionsServer creates a PermissionsServiceServer instance.
@@ -47,18 +98,33 @@ func NewPermissionsServer(
 	caveatsEnabled bool,
 ) v1.PermissionsServiceServer {
 	configWithDefaults := PermissionsServerConfig{
-		MaxPreconditionsCount: defaultIfZero(config.MaxPreconditionsCount, 1000),
-		MaxUpdatesPerWrite:    defaultIfZero(config.MaxUpdatesPerWrite, 1000),
-		MaximumAPIDepth:       defaultIfZero(config.MaximumAPIDepth, 50),
+		MaxPreconditionsCount:        defaultIfZero(config.MaxPreconditionsCount, 1000),
+		MaxUpdatesPerWrite:           defaultIfZero(config.MaxUpdatesPerWrite, 1000),
+		MaximumAPIDepth:              defaultIfZero(config.MaximumAPIDepth, 50),
+		MaxLookupResourcesResults:    config.MaxLookupResourcesResults,
+		MaxExpandLeafSubjects:        config.MaxExpandLeafSubjects,
+		RelationshipValidationPolicy: config.RelationshipValidationPolicy,
+		WellKnownCaveatContext:       config.WellKnownCaveatContext,
+		MetricsRegisterer:            config.MetricsRegisterer,
+	}
+
+	if configWithDefaults.MetricsRegisterer == nil {
+		configWithDefaults.MetricsRegisterer = prometheus.DefaultRegisterer
+	}
+
+	validate := grpcvalidate.UnaryServerInterceptor(true)
+	if configWithDefaults.RelationshipValidationPolicy != nil {
+		validate = deferObjectIDsToPolicy(validate)
 	}
 
 	return &permissionServer{
 		dispatch:       dispatch,
 		config:         configWithDefaults,
 		caveatsEnabled: caveatsEnabled,
+		metrics:        newRelationMetrics(configWithDefaults.MetricsRegisterer),
 		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
 			Unary: middleware.ChainUnaryServer(
-				grpcvalidate.UnaryServerInterceptor(true),
+				validate,
 				handwrittenvalidation.UnaryServerInterceptor,
 				usagemetrics.UnaryServerInterceptor(),
 			),
@@ -78,33 +144,24 @@ type permissionServer struct {
 	dispatch       dispatch.Dispatcher
 	config         PermissionsServerConfig
 	caveatsEnabled bool
-}
-
-func (ps *permissionServer) checkFilterComponent(ctx context.Context, objectType, optionalRelation string, ds datastore.Reader) error {
-	relationToTest := stringz.DefaultEmpty(optionalRelation, datastore.Ellipsis)
-	allowEllipsis := optionalRelation == ""
-	return namespace.CheckNamespaceAndRelation(ctx, objectType, relationToTest, allowEllipsis, ds)
+	metrics        *relationMetrics
 }
 
 func (ps *permissionServer) checkFilterNamespaces(ctx context.Context, filter *v1.RelationshipFilter, ds datastore.Reader) error {
-	if err := ps.checkFilterComponent(ctx, filter.ResourceType, filter.OptionalRelation, ds); err != nil {
+	refs := filterReferences(filter)
+	checks, err := validateNamespaceRelations(ctx, ds, refs)
+	if err != nil {
 		return err
 	}
 
-	if subjectFilter := filter.OptionalSubjectFilter; subjectFilter != nil {
-		subjectRelation := ""
-		if subjectFilter.OptionalRelation != nil {
-			subjectRelation = subjectFilter.OptionalRelation.Relation
-		}
-		if err := ps.checkFilterComponent(ctx, subjectFilter.SubjectType, subjectRelation, ds); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return checks.check(refs...)
 }
 
 func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest, resp v1.PermissionsService_ReadRelationshipsServer) error {
+	start := time.Now()
+	var labels []relationLabels
+	defer func() { ps.metrics.observe("ReadRelationships", start, labels...) }()
+
 	ctx := resp.Context()
 	atRevision, revisionReadAt := consistency.MustRevisionFromContext(ctx)
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
@@ -112,12 +169,22 @@ func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest,
 	if err := ps.checkFilterNamespaces(ctx, req.RelationshipFilter, ds); err != nil {
 		return rewriteError(ctx, err)
 	}
+	labels = []relationLabels{{req.RelationshipFilter.ResourceType, req.RelationshipFilter.OptionalRelation}}
 
 	usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
 		DispatchCount: 1,
 	})
 
-	tupleIterator, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(req.RelationshipFilter))
+	filter := datastore.RelationshipsFilterFromPublicFilter(req.RelationshipFilter)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		values := md.Get(ResourceIDPrefixMatchMetadataKey)
+		if len(values) > 0 && values[0] == "true" {
+			filter.OptionalResourceIds = nil
+			filter.OptionalResourceIDPrefix = req.RelationshipFilter.OptionalResourceId
+		}
+	}
+
+	tupleIterator, err := ds.QueryRelationships(ctx, filter)
 	if err != nil {
 		return rewriteError(ctx, err)
 	}
@@ -141,56 +208,92 @@ func (ps *permissionServer) ReadRelationships(req *v1.ReadRelationshipsRequest,
 }
 
 func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.WriteRelationshipsRequest) (*v1.WriteRelationshipsResponse, error) {
+	start := time.Now()
+	var labels []relationLabels
+	defer func() { ps.metrics.observe("WriteRelationships", start, labels...) }()
+
 	ds := datastoremw.MustFromContext(ctx)
 
 	// Ensure that the updates and preconditions are not over the configured limits.
 	if len(req.Updates) > int(ps.config.MaxUpdatesPerWrite) {
 		return nil, rewriteError(
 			ctx,
-			NewExceedsMaximumUpdatesErr(uint16(len(req.Updates)), ps.config.MaxUpdatesPerWrite),
+			NewExceedsMaximumUpdatesErr(uint64(len(req.Updates)), ps.config.MaxUpdatesPerWrite),
 		)
 	}
 
 	if len(req.OptionalPreconditions) > int(ps.config.MaxPreconditionsCount) {
 		return nil, rewriteError(
 			ctx,
-			NewExceedsMaximumPreconditionsErr(uint16(len(req.OptionalPreconditions)), ps.config.MaxPreconditionsCount),
+			NewExceedsMaximumPreconditionsErr(uint64(len(req.OptionalPreconditions)), ps.config.MaxPreconditionsCount),
 		)
 	}
 
-	// Check for duplicate updates and create the set of caveat names to load.
-	updateRelationshipSet := util.NewSet[string]()
-	for _, update := range req.Updates {
-		tupleStr := tuple.StringRelationshipWithoutCaveat(update.Relationship)
-		if !updateRelationshipSet.Add(tupleStr) {
-			return nil, rewriteError(
-				ctx,
-				NewDuplicateRelationshipErr(update),
-			)
-		}
+	// Collapse the updates on the same relationship, rejecting those which contradict each other.
+	updates, positions, err := deduplicateUpdates(req.Updates)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
 
+	for _, update := range updates {
 		if !ps.caveatsEnabled {
 			if update.Relationship.OptionalCaveat != nil && update.Relationship.OptionalCaveat.CaveatName != "" {
 				return nil, status.Errorf(codes.InvalidArgument, "caveats are currently not supported")
 			}
 		}
+
+		if ps.config.RelationshipValidationPolicy != nil {
+			if err := validateUpdateWithPolicy(ps.config.RelationshipValidationPolicy, update); err != nil {
+				return nil, rewriteError(ctx, err)
+			}
+		}
+	}
+
+	returnResults := false
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		values := md.Get(ReturnUpdateResultsMetadataKey)
+		returnResults = len(values) > 0 && values[0] == "true"
+	}
+
+	var rwtOpts []options.RWTOptionsOption
+	if key := idempotencyKeyFromMetadata(ctx); key != "" {
+		rwtOpts = append(rwtOpts, options.WithIdempotencyKey(key))
 	}
 
 	// Execute the write operation(s).
+	var results []datastore.RelationshipUpdateResult
 	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
-		// Validate the preconditions.
+		// Validate the preconditions, reading the namespaces they reference once for all of them.
+		var refs []namespaceRelation
 		for _, precond := range req.OptionalPreconditions {
-			if err := ps.checkFilterNamespaces(ctx, precond.Filter, rwt); err != nil {
+			refs = append(refs, filterReferences(precond.Filter)...)
+		}
+
+		if len(refs) > 0 {
+			checks, err := validateNamespaceRelations(ctx, rwt, refs)
+			if err != nil {
 				return err
 			}
+
+			for _, precond := range req.OptionalPreconditions {
+				if err := checks.check(filterReferences(precond.Filter)...); err != nil {
+					return err
+				}
+			}
 		}
 
 		// Validate the updates.
-		tupleUpdates := tuple.UpdateFromRelationshipUpdates(req.Updates)
-		err := relationships.ValidateRelationshipUpdates(ctx, rwt, tupleUpdates)
+		tupleUpdates := tuple.UpdateFromRelationshipUpdates(updates)
+		validateUpdates := relationships.ValidateRelationshipUpdates
+		if ps.config.RelationshipValidationPolicy != nil {
+			validateUpdates = relationships.ValidateRelationshipUpdatesWithRelaxedObjectIDs
+		}
+
+		err := validateUpdates(ctx, rwt, tupleUpdates)
 		if err != nil {
 			return rewriteError(ctx, err)
 		}
+		labels = labelsForUpdates(tupleUpdates)
 
 		usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
 			// One request per precondition and one request for the actual writes.
@@ -201,22 +304,122 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 			return err
 		}
 
+		if returnResults {
+			results, err = datastore.WriteRelationshipsWithResults(ctx, rwt, tupleUpdates)
+			return err
+		}
+
 		return rwt.WriteRelationships(ctx, tupleUpdates)
-	})
+	}, rwtOpts...)
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
+	if returnResults {
+		if err := setUpdateResultsTrailer(ctx, results, positions); err != nil {
+			return nil, rewriteError(ctx, err)
+		}
+	}
+
 	return &v1.WriteRelationshipsResponse{
 		WrittenAt: zedtoken.NewFromRevision(revision),
 	}, nil
 }
 
+// setUpdateResultsTrailer places the result of each update of the request into the response
+// trailer, given the results of the deduplicated updates and the position of each update of the
+// request among them.
+func setUpdateResultsTrailer(ctx context.Context, results []datastore.RelationshipUpdateResult, positions []int) error {
+	updateResults := make([]string, 0, len(positions))
+	for _, position := range positions {
+		updateResults = append(updateResults, results[position].String())
+	}
+
+	marshaled, err := json.Marshal(updateResults)
+	if err != nil {
+		return err
+	}
+
+	return responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
+		UpdateResultsTrailerKey: string(marshaled),
+	})
+}
+
+// deduplicateUpdates collapses the updates on the same relationship into a single update, in the
+// position of the first, and returns the position of each given update among those returned. Of
+// several writes of the same relationship, whether created or touched, the last wins, and repeated
+// deletions are collapsed. As a create fails if the relationship already exists, the writes are
+// collapsed into a create if any of them is one. A relationship which is both written and deleted
+// cannot be collapsed, and is rejected with an ErrDuplicateRelationshipError.
+func deduplicateUpdates(updates []*v1.RelationshipUpdate) ([]*v1.RelationshipUpdate, []int, error) {
+	deduplicated := make([]*v1.RelationshipUpdate, 0, len(updates))
+	positions := make([]int, 0, len(updates))
+	indexByRelationship := make(map[string]int, len(updates))
+	for _, update := range updates {
+		tupleStr := tuple.StringRelationshipWithoutCaveat(update.Relationship)
+		index, ok := indexByRelationship[tupleStr]
+		if !ok {
+			indexByRelationship[tupleStr] = len(deduplicated)
+			positions = append(positions, len(deduplicated))
+			deduplicated = append(deduplicated, update)
+			continue
+		}
+
+		isDelete := update.Operation == v1.RelationshipUpdate_OPERATION_DELETE
+		if isDelete != (deduplicated[index].Operation == v1.RelationshipUpdate_OPERATION_DELETE) {
+			return nil, nil, NewDuplicateRelationshipErr(update)
+		}
+
+		if !isDelete {
+			if deduplicated[index].Operation == v1.RelationshipUpdate_OPERATION_CREATE {
+				update = &v1.RelationshipUpdate{
+					Operation:    v1.RelationshipUpdate_OPERATION_CREATE,
+					Relationship: update.Relationship,
+				}
+			}
+			deduplicated[index] = update
+		}
+		positions = append(positions, index)
+	}
+	return deduplicated, positions, nil
+}
+
+// labelsForUpdates returns the distinct resource namespace and relation labels of the updates.
+func labelsForUpdates(updates []*core.RelationTupleUpdate) []relationLabels {
+	seen := make(map[relationLabels]struct{}, len(updates))
+	labels := make([]relationLabels, 0, len(updates))
+	for _, update := range updates {
+		l := relationLabels{update.Tuple.ResourceAndRelation.Namespace, update.Tuple.ResourceAndRelation.Relation}
+		if _, ok := seen[l]; ok {
+			continue
+		}
+		seen[l] = struct{}{}
+		labels = append(labels, l)
+	}
+	return labels
+}
+
+// idempotencyKeyFromMetadata returns the idempotency key supplied via IdempotencyKeyMetadataKey,
+// if any.
+func idempotencyKeyFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(IdempotencyKeyMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
 func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.DeleteRelationshipsRequest) (*v1.DeleteRelationshipsResponse, error) {
 	if len(req.OptionalPreconditions) > int(ps.config.MaxPreconditionsCount) {
 		return nil, rewriteError(
 			ctx,
-			NewExceedsMaximumPreconditionsErr(uint16(len(req.OptionalPreconditions)), ps.config.MaxPreconditionsCount),
+			NewExceedsMaximumPreconditionsErr(uint64(len(req.OptionalPreconditions)), ps.config.MaxPreconditionsCount),
 		)
 	}
 
@@ -2,23 +2,31 @@ package v1_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/authzed/grpcutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/middleware/consistency"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
+	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/spiceerrors"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -254,6 +262,44 @@ func TestReadRelationships(t *testing.T) {
 	}
 }
 
+func TestReadRelationshipsWithResourceIDPrefix(t *testing.T) {
+	require := require.New(t)
+
+	conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	readIDPrefix := func(ctx context.Context, prefix string) map[string]struct{} {
+		stream, err := client.ReadRelationships(ctx, &v1.ReadRelationshipsRequest{
+			RelationshipFilter: &v1.RelationshipFilter{
+				ResourceType:       tf.DocumentNS.Name,
+				OptionalResourceId: prefix,
+				OptionalRelation:   "parent",
+			},
+		})
+		require.NoError(err)
+
+		found := map[string]struct{}{}
+		for {
+			rel, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return found
+			}
+			require.NoError(err)
+			found[tuple.MustRelString(rel.Relationship)] = struct{}{}
+		}
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.ResourceIDPrefixMatchMetadataKey, "true")
+	require.Equal(map[string]struct{}{
+		"document:masterplan#parent@folder:strategy": {},
+		"document:masterplan#parent@folder:plans":    {},
+	}, readIDPrefix(ctx, "master"))
+
+	// Without the metadata key, the resource ID is matched exactly.
+	require.Empty(readIDPrefix(context.Background(), "master"))
+}
+
 func TestWriteRelationships(t *testing.T) {
 	require := require.New(t)
 
@@ -343,6 +389,312 @@ func TestWriteRelationships(t *testing.T) {
 	require.ErrorIs(err, io.EOF)
 }
 
+func TestWriteRelationshipsWithIdempotencyKey(t *testing.T) {
+	require := require.New(t)
+
+	conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	req := &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{{
+			Operation:    v1.RelationshipUpdate_OPERATION_CREATE,
+			Relationship: tuple.MustToRelationship(tuple.MustParse("document:totallynew#parent@folder:plans")),
+		}},
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.IdempotencyKeyMetadataKey, "some-key")
+	first, err := client.WriteRelationships(ctx, req)
+	require.NoError(err)
+
+	// Retrying with the same key returns the original revision instead of failing the CREATE.
+	retried, err := client.WriteRelationships(ctx, req)
+	require.NoError(err)
+	require.Equal(first.WrittenAt.Token, retried.WrittenAt.Token)
+
+	// Without the key, the CREATE is applied again and fails.
+	_, err = client.WriteRelationships(context.Background(), req)
+	require.Error(err)
+	require.Contains(err.Error(), "could not CREATE")
+}
+
+func TestWriteRelationshipsWithUpdateResults(t *testing.T) {
+	require := require.New(t)
+
+	conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	update := func(op v1.RelationshipUpdate_Operation, rel string) *v1.RelationshipUpdate {
+		return &v1.RelationshipUpdate{
+			Operation:    op,
+			Relationship: tuple.MustToRelationship(tuple.MustParse(rel)),
+		}
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), v1svc.ReturnUpdateResultsMetadataKey, "true")
+	var trailer metadata.MD
+	_, err := client.WriteRelationships(ctx, &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{
+			update(v1.RelationshipUpdate_OPERATION_TOUCH, "document:companyplan#parent@folder:company"),
+			update(v1.RelationshipUpdate_OPERATION_TOUCH, "document:totallynew#parent@folder:plans"),
+			update(v1.RelationshipUpdate_OPERATION_DELETE, "document:masterplan#parent@folder:strategy"),
+			update(v1.RelationshipUpdate_OPERATION_DELETE, "document:totallymissing#parent@folder:plans"),
+			update(v1.RelationshipUpdate_OPERATION_TOUCH, "document:totallynew#parent@folder:plans"),
+		},
+	}, grpc.Trailer(&trailer))
+	require.NoError(err)
+
+	encodedResults, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.UpdateResultsTrailerKey)
+	require.NoError(err)
+	require.NotNil(encodedResults)
+
+	var results []string
+	require.NoError(json.Unmarshal([]byte(*encodedResults), &results))
+	require.Equal([]string{"unchanged", "created", "deleted", "unchanged", "created"}, results)
+
+	// Without the metadata key, no results are returned.
+	trailer = nil
+	_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{
+			update(v1.RelationshipUpdate_OPERATION_TOUCH, "document:totallynew#parent@folder:plans"),
+		},
+	}, grpc.Trailer(&trailer))
+	require.NoError(err)
+
+	encodedResults, err = responsemeta.GetResponseTrailerMetadataOrNil(trailer, v1svc.UpdateResultsTrailerKey)
+	require.NoError(err)
+	require.Nil(encodedResults)
+}
+
+// laggingHeadDatastore reports a fixed head revision, as would a datastore whose reads lag behind
+// its writes.
+type laggingHeadDatastore struct {
+	datastore.Datastore
+	head datastore.Revision
+}
+
+func (ds laggingHeadDatastore) HeadRevision(_ context.Context) (datastore.Revision, error) {
+	return ds.head, nil
+}
+
+func TestWriteRelationshipsReadYourWrites(t *testing.T) {
+	req := require.New(t)
+
+	// A large quantization window ensures that optimized revisions lag behind the write, and the
+	// head revision is fixed before the write.
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 1*time.Hour, memdb.DisableGC, true,
+		func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+			ds, revision := tf.StandardDatastoreWithData(ds, require)
+			return laggingHeadDatastore{ds, revision}, revision
+		})
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	toWrite := tuple.MustParse("document:totallynew#parent@folder:plans")
+	resp, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{{
+			Operation:    v1.RelationshipUpdate_OPERATION_CREATE,
+			Relationship: tuple.MustToRelationship(toWrite),
+		}},
+	})
+	req.NoError(err)
+
+	readWritten := func(ctx context.Context, consistency *v1.Consistency) ([]string, error) {
+		stream, err := client.ReadRelationships(ctx, &v1.ReadRelationshipsRequest{
+			Consistency:        consistency,
+			RelationshipFilter: tuple.MustToFilter(toWrite),
+		})
+		req.NoError(err)
+
+		var found []string
+		for {
+			rel, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return found, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, tuple.MustStringRelationship(rel.Relationship))
+		}
+	}
+
+	fullyConsistent := &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}}
+
+	// Without the written-at token, a fully consistent read is evaluated at the lagging head.
+	found, err := readWritten(context.Background(), fullyConsistent)
+	req.NoError(err)
+	req.Empty(found)
+
+	// With the written-at token, the read is moved forward to include the write.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), consistency.WrittenAtMetadataKey, resp.WrittenAt.Token)
+	found, err = readWritten(ctx, fullyConsistent)
+	req.NoError(err)
+	req.Equal([]string{tuple.MustString(toWrite)}, found)
+
+	// The written zedtoken is honored by an at least as fresh read, despite the quantization.
+	found, err = readWritten(context.Background(), &v1.Consistency{
+		Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: resp.WrittenAt},
+	})
+	req.NoError(err)
+	req.Equal([]string{tuple.MustString(toWrite)}, found)
+
+	// An exact snapshot from before the write cannot be combined with the written-at token.
+	_, err = readWritten(ctx, &v1.Consistency{
+		Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: zedtoken.NewFromRevision(revision)},
+	})
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+
+	// The written-at token cannot be combined with a requirement which may not include the write.
+	_, err = readWritten(ctx, &v1.Consistency{
+		Requirement: &v1.Consistency_MinimizeLatency{MinimizeLatency: true},
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
+func TestWriteRelationshipsDeduplication(t *testing.T) {
+	toWrite := tuple.MustParse("document:totallynew#parent@folder:plans")
+	other := tuple.MustParse("document:totallynew#parent@folder:auditors")
+	existing := tuple.MustParse("document:masterplan#parent@folder:plans")
+
+	update := func(operation v1.RelationshipUpdate_Operation, tpl *core.RelationTuple) *v1.RelationshipUpdate {
+		return &v1.RelationshipUpdate{
+			Operation:    operation,
+			Relationship: tuple.MustToRelationship(tpl),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		updates       []*v1.RelationshipUpdate
+		expectedCode  codes.Code
+		expectedLive  []*core.RelationTuple
+		errorContains string
+	}{
+		{
+			"duplicate creates",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_CREATE, toWrite),
+				update(v1.RelationshipUpdate_OPERATION_CREATE, other),
+				update(v1.RelationshipUpdate_OPERATION_CREATE, toWrite),
+			},
+			codes.OK,
+			[]*core.RelationTuple{toWrite, other},
+			"",
+		},
+		{
+			"create followed by touch",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_CREATE, toWrite),
+				update(v1.RelationshipUpdate_OPERATION_TOUCH, toWrite),
+			},
+			codes.OK,
+			[]*core.RelationTuple{toWrite},
+			"",
+		},
+		{
+			"create followed by touch of an existing relationship",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_CREATE, existing),
+				update(v1.RelationshipUpdate_OPERATION_TOUCH, existing),
+			},
+			codes.Unknown,
+			nil,
+			"could not CREATE relationship `document:masterplan#parent@folder:plans`",
+		},
+		{
+			"touch followed by create of an existing relationship",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_TOUCH, existing),
+				update(v1.RelationshipUpdate_OPERATION_CREATE, existing),
+			},
+			codes.Unknown,
+			nil,
+			"could not CREATE relationship `document:masterplan#parent@folder:plans`",
+		},
+		{
+			"duplicate deletes",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_DELETE, toWrite),
+				update(v1.RelationshipUpdate_OPERATION_DELETE, toWrite),
+			},
+			codes.OK,
+			nil,
+			"",
+		},
+		{
+			"create followed by delete",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_CREATE, toWrite),
+				update(v1.RelationshipUpdate_OPERATION_DELETE, toWrite),
+			},
+			codes.InvalidArgument,
+			nil,
+			"found contradictory updates with relationship `document:totallynew#parent@folder:plans`",
+		},
+		{
+			"delete followed by touch",
+			[]*v1.RelationshipUpdate{
+				update(v1.RelationshipUpdate_OPERATION_DELETE, toWrite),
+				update(v1.RelationshipUpdate_OPERATION_TOUCH, other),
+				update(v1.RelationshipUpdate_OPERATION_TOUCH, toWrite),
+			},
+			codes.InvalidArgument,
+			nil,
+			"found contradictory updates",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			resp, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: tc.updates,
+			})
+			if tc.expectedCode != codes.OK {
+				grpcutil.RequireStatus(t, tc.expectedCode, err)
+				require.Contains(err.Error(), tc.errorContains)
+				return
+			}
+			require.NoError(err)
+
+			stream, err := client.ReadRelationships(context.Background(), &v1.ReadRelationshipsRequest{
+				Consistency: &v1.Consistency{
+					Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: resp.WrittenAt},
+				},
+				RelationshipFilter: &v1.RelationshipFilter{
+					ResourceType:       "document",
+					OptionalResourceId: "totallynew",
+				},
+			})
+			require.NoError(err)
+
+			var found []string
+			for {
+				res, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(err)
+				found = append(found, tuple.MustStringRelationship(res.Relationship))
+			}
+
+			expected := make([]string, 0, len(tc.expectedLive))
+			for _, tpl := range tc.expectedLive {
+				expected = append(expected, tuple.MustString(tpl))
+			}
+			require.ElementsMatch(expected, found)
+		})
+	}
+}
+
 func TestWriteCaveatedRelationships(t *testing.T) {
 	req := require.New(t)
 
@@ -549,16 +901,6 @@ func TestInvalidWriteRelationship(t *testing.T) {
 			codes.InvalidArgument,
 			"user:*",
 		},
-		{
-			"duplicate relationship",
-			nil,
-			[]*v1.Relationship{
-				rel("document", "somedoc", "parent", "user", "tom", ""),
-				rel("document", "somedoc", "parent", "user", "tom", ""),
-			},
-			codes.InvalidArgument,
-			"found more than one update",
-		},
 		{
 			"disallowed caveat",
 			nil,
@@ -581,14 +923,14 @@ func TestInvalidWriteRelationship(t *testing.T) {
 			"folder#owner with somecaveat",
 		},
 		{
-			"caveated and uncaveated versions of the same relationship",
+			"caveated version of a relationship after its uncaveated version",
 			nil,
 			[]*v1.Relationship{
 				rel("document", "somedoc", "viewer", "user", "tom", ""),
 				relWithCaveat("document", "somedoc", "viewer", "user", "tom", "", "somecaveat"),
 			},
 			codes.InvalidArgument,
-			"found more than one update with relationship",
+			"user with somecaveat",
 		},
 	}
 
@@ -1082,6 +1424,78 @@ func TestWriteRelationshipsUpdatesOverLimit(t *testing.T) {
 	require.Contains(err.Error(), "update count of 2 is greater than maximum allowed of 1")
 }
 
+func TestWriteRelationshipsUpdatesAtLimit(t *testing.T) {
+	require := require.New(t)
+	conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+		require,
+		testTimedeltas[0],
+		memdb.DisableGC,
+		true,
+		testserver.ServerConfig{
+			MaxPreconditionsCount: 1,
+			MaxUpdatesPerWrite:    2,
+		},
+		tf.StandardDatastoreWithData,
+	)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	updates := func(count int) []*v1.RelationshipUpdate {
+		updates := make([]*v1.RelationshipUpdate, 0, count)
+		for i := 0; i < count; i++ {
+			updates = append(updates, &v1.RelationshipUpdate{
+				Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+				Relationship: rel("document", "newdoc", "parent", "folder", "afolder", ""),
+			})
+		}
+		return updates
+	}
+
+	// Exactly the maximum number of updates is allowed.
+	_, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: updates(2),
+	})
+	require.NoError(err)
+
+	_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: updates(3),
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.Contains(err.Error(), "update count of 3 is greater than maximum allowed of 2")
+
+	// Counts which do not fit within the type of the maximum are reported as given.
+	_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: updates(math.MaxUint16 + 2),
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+	require.Contains(err.Error(), "update count of 65537 is greater than maximum allowed of 2")
+}
+
+func TestWriteRelationshipsEmptyAndNilEntries(t *testing.T) {
+	require := require.New(t)
+	conn, cleanup, _, _ := testserver.NewTestServer(require, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	client := v1.NewPermissionsServiceClient(conn)
+	t.Cleanup(cleanup)
+
+	// A write without any updates is a degenerate batch, which succeeds with a revision.
+	resp, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{},
+	})
+	require.NoError(err)
+	require.NotEmpty(resp.WrittenAt.GetToken())
+
+	// Nil entries are sent as empty messages, which are rejected.
+	_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{nil},
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+
+	_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		OptionalPreconditions: []*v1.Precondition{nil},
+	})
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
+
 func readAll(require *require.Assertions, client v1.PermissionsServiceClient, token *v1.ZedToken) map[string]struct{} {
 	got := make(map[string]struct{})
 	namespaces := []string{"document", "folder"}
@@ -21,15 +21,17 @@ import (
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
 )
 
-// NewSchemaServer creates a SchemaServiceServer instance.
-func NewSchemaServer(additiveOnly, caveatsEnabled bool) v1.SchemaServiceServer {
+// NewSchemaServer creates a SchemaServiceServer instance. If simplifyCaveats is true, the
+// expressions of caveats are simplified before being written.
+func NewSchemaServer(additiveOnly, caveatsEnabled, simplifyCaveats bool) v1.SchemaServiceServer {
 	return &schemaServer{
 		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
 			Unary:  grpcvalidate.UnaryServerInterceptor(true),
 			Stream: grpcvalidate.StreamServerInterceptor(true),
 		},
-		additiveOnly:   additiveOnly,
-		caveatsEnabled: caveatsEnabled,
+		additiveOnly:    additiveOnly,
+		caveatsEnabled:  caveatsEnabled,
+		simplifyCaveats: simplifyCaveats,
 	}
 }
 
@@ -37,8 +39,9 @@ type schemaServer struct {
 	v1.UnimplementedSchemaServiceServer
 	shared.WithServiceSpecificInterceptors
 
-	additiveOnly   bool
-	caveatsEnabled bool
+	additiveOnly    bool
+	caveatsEnabled  bool
+	simplifyCaveats bool
 }
 
 func (ss *schemaServer) ReadSchema(ctx context.Context, in *v1.ReadSchemaRequest) (*v1.ReadSchemaResponse, error) {
@@ -105,6 +108,12 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 		return nil, rewriteError(ctx, err)
 	}
 
+	if ss.simplifyCaveats {
+		if err := shared.SimplifyCaveatDefinitions(compiled); err != nil {
+			return nil, rewriteError(ctx, err)
+		}
+	}
+
 	// Update the schema.
 	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		applied, err := shared.ApplySchemaChanges(ctx, rwt, validated)
@@ -79,6 +79,29 @@ func TestSchemaWriteAndReadBack(t *testing.T) {
 	require.Equal(t, userSchema, readback.SchemaText)
 }
 
+func TestSchemaWriteSimplifiesCaveats(t *testing.T) {
+	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
+	t.Cleanup(cleanup)
+	client := v1.NewSchemaServiceClient(conn)
+
+	_, err := client.WriteSchema(context.Background(), &v1.WriteSchemaRequest{
+		Schema: `caveat someCaveat(first int, second int) {
+			(first == 42 || first == 42) && true && !!(second > 1 || (second > 1 && first == 42))
+		}
+
+		definition example/document {
+			relation viewer: example/user with someCaveat
+		}
+
+		definition example/user {}`,
+	})
+	require.NoError(t, err)
+
+	readback, err := client.ReadSchema(context.Background(), &v1.ReadSchemaRequest{})
+	require.NoError(t, err)
+	require.Contains(t, readback.SchemaText, "caveat someCaveat(first int, second int) {\n\tfirst == 42 && second > 1\n}")
+}
+
 func TestSchemaDeleteRelation(t *testing.T) {
 	conn, cleanup, _, _ := testserver.NewTestServer(require.New(t), 0, memdb.DisableGC, true, tf.EmptyDatastore)
 	t.Cleanup(cleanup)
@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/middleware/consistency"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/services/shared"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type schemaHashServer struct {
+	spicedbv1.UnimplementedSchemaHashServiceServer
+}
+
+// NewSchemaHashServer creates an instance of the SchemaHash server.
+func NewSchemaHashServer() spicedbv1.SchemaHashServiceServer {
+	return &schemaHashServer{}
+}
+
+func (shs *schemaHashServer) ReadSchemaHash(ctx context.Context, _ *spicedbv1.ReadSchemaHashRequest) (*spicedbv1.ReadSchemaHashResponse, error) {
+	headRevision, _ := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(headRevision)
+
+	hash, err := shared.ComputeSchemaHash(ctx, ds)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	return &spicedbv1.ReadSchemaHashResponse{SchemaHash: hash}, nil
+}
@@ -0,0 +1,64 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestReadSchemaHash(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, _ := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewSchemaHashServiceClient(conn)
+	schemaClient := v1.NewSchemaServiceClient(conn)
+	permsClient := v1.NewPermissionsServiceClient(conn)
+
+	ctx := context.Background()
+	hash, err := client.ReadSchemaHash(ctx, &spicedbv1.ReadSchemaHashRequest{})
+	req.NoError(err)
+	req.Len(hash.SchemaHash, 32)
+
+	// Writing relationships does not change the hash.
+	_, err = permsClient.WriteRelationships(ctx, &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{{
+			Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+			Relationship: tuple.MustToRelationship(tuple.MustParse("document:newdoc#viewer@user:tom")),
+		}},
+	})
+	req.NoError(err)
+
+	unchanged, err := client.ReadSchemaHash(ctx, &spicedbv1.ReadSchemaHashRequest{})
+	req.NoError(err)
+	req.Equal(hash.SchemaHash, unchanged.SchemaHash)
+
+	// Writing back the same schema does not change the hash either.
+	schema, err := schemaClient.ReadSchema(ctx, &v1.ReadSchemaRequest{})
+	req.NoError(err)
+
+	_, err = schemaClient.WriteSchema(ctx, &v1.WriteSchemaRequest{Schema: schema.SchemaText})
+	req.NoError(err)
+
+	rewritten, err := client.ReadSchemaHash(ctx, &spicedbv1.ReadSchemaHashRequest{})
+	req.NoError(err)
+	req.Equal(hash.SchemaHash, rewritten.SchemaHash)
+
+	// Changing the schema changes the hash.
+	_, err = schemaClient.WriteSchema(ctx, &v1.WriteSchemaRequest{
+		Schema: schema.SchemaText + "\n\ndefinition anothertype {}",
+	})
+	req.NoError(err)
+
+	changed, err := client.ReadSchemaHash(ctx, &spicedbv1.ReadSchemaHashRequest{})
+	req.NoError(err)
+	req.NotEqual(hash.SchemaHash, changed.SchemaHash)
+}
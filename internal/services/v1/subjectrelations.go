@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"context"
+	"sort"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/middleware"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/middleware/usagemetrics"
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/middleware/consistency"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type subjectRelationsServer struct {
+	spicedbv1.UnimplementedSubjectRelationsServiceServer
+	shared.WithServiceSpecificInterceptors
+
+	dispatch dispatch.Dispatcher
+	config   PermissionsServerConfig
+}
+
+// NewSubjectRelationsServer creates an instance of the SubjectRelations server, which shares
+// the configuration of the permissions server.
+func NewSubjectRelationsServer(dispatch dispatch.Dispatcher, config PermissionsServerConfig) spicedbv1.SubjectRelationsServiceServer {
+	return &subjectRelationsServer{
+		dispatch: dispatch,
+		config: PermissionsServerConfig{
+			MaximumAPIDepth: defaultIfZero(config.MaximumAPIDepth, 50),
+		},
+		WithServiceSpecificInterceptors: shared.WithServiceSpecificInterceptors{
+			Unary: middleware.ChainUnaryServer(
+				grpcvalidate.UnaryServerInterceptor(true),
+				usagemetrics.UnaryServerInterceptor(),
+			),
+			Stream: middleware.ChainStreamServer(
+				grpcvalidate.StreamServerInterceptor(true),
+				usagemetrics.StreamServerInterceptor(),
+			),
+		},
+	}
+}
+
+func (ss *subjectRelationsServer) ReadSubjectRelations(ctx context.Context, req *spicedbv1.ReadSubjectRelationsRequest) (*spicedbv1.ReadSubjectRelationsResponse, error) {
+	atRevision, readAt := consistency.MustRevisionFromContext(ctx)
+	ds := datastoremw.MustFromContext(ctx).SnapshotReader(atRevision)
+
+	caveatContext, err := getCaveatContext(ctx, req.Context)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	if err := namespace.CheckNamespaceAndRelation(
+		ctx,
+		req.Subject.Object.ObjectType,
+		normalizeSubjectRelation(req.Subject),
+		true,
+		ds,
+	); err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	results, metadata, err := computed.ComputeSubjectRelations(ctx, ss.dispatch,
+		computed.SubjectRelationsParameters{
+			ResourceType: req.Resource.ObjectType,
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			CaveatContext: caveatContext,
+			AtRevision:    atRevision,
+			MaximumDepth:  ss.config.MaximumAPIDepth,
+		},
+		req.Resource.ObjectId,
+	)
+	usagemetrics.SetInContext(ctx, metadata)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
+	relations := make([]*spicedbv1.SubjectRelation, 0, len(results))
+	for relation, result := range results {
+		converted := &spicedbv1.SubjectRelation{
+			Relation:       relation,
+			Permissionship: v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION,
+		}
+		if result.Membership == dispatchv1.ResourceCheckResult_CAVEATED_MEMBER {
+			converted.Permissionship = v1.CheckPermissionResponse_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+			converted.PartialCaveatInfo = &v1.PartialCaveatInfo{
+				MissingRequiredContext: result.MissingExprFields,
+			}
+		}
+		relations = append(relations, converted)
+	}
+
+	sort.Slice(relations, func(i, j int) bool {
+		return relations[i].Relation < relations[j].Relation
+	})
+
+	return &spicedbv1.ReadSubjectRelationsResponse{
+		ReadAt:    readAt,
+		Relations: relations,
+	}, nil
+}
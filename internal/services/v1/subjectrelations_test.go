@@ -0,0 +1,66 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestReadSubjectRelations(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, _, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewSubjectRelationsServiceClient(conn)
+	ctx := context.Background()
+
+	read := func(resourceID, subjectType, subjectID string) ([]string, error) {
+		resp, err := client.ReadSubjectRelations(ctx, &spicedbv1.ReadSubjectRelationsRequest{
+			Consistency: &v1.Consistency{
+				Requirement: &v1.Consistency_AtLeastAsFresh{AtLeastAsFresh: zedtoken.NewFromRevision(revision)},
+			},
+			Resource: &v1.ObjectReference{ObjectType: "document", ObjectId: resourceID},
+			Subject:  &v1.SubjectReference{Object: &v1.ObjectReference{ObjectType: subjectType, ObjectId: subjectID}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req.NotNil(resp.ReadAt)
+		relations := make([]string, 0, len(resp.Relations))
+		for _, relation := range resp.Relations {
+			req.Equal(v1.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION, relation.Permissionship)
+			relations = append(relations, relation.Relation)
+		}
+		return relations, nil
+	}
+
+	relations, err := read("masterplan", "user", "product_manager")
+	req.NoError(err)
+	req.Equal([]string{"edit", "owner", "view"}, relations)
+
+	relations, err = read("masterplan", "user", "eng_lead")
+	req.NoError(err)
+	req.Equal([]string{"view", "viewer"}, relations)
+
+	relations, err = read("specialplan", "user", "multiroleguy")
+	req.NoError(err)
+	req.Equal([]string{"edit", "editor", "view", "view_and_edit", "viewer_and_editor"}, relations)
+
+	relations, err = read("masterplan", "user", "villain")
+	req.NoError(err)
+	req.Empty(relations)
+
+	_, err = read("masterplan", "unknown", "villain")
+	grpcutil.RequireStatus(t, codes.FailedPrecondition, err)
+}
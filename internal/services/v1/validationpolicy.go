@@ -0,0 +1,192 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/jzelinskie/stringz"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// DefaultObjectIDPattern is the pattern the API requires of object IDs, other than the wildcard
+// subject ID. It applies to the relationships written unless a RelationshipValidationPolicy with
+// a pattern of its own is configured.
+var DefaultObjectIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9/_|-]{0,127}$`)
+
+// MaximumObjectIDLength is the maximum length, in bytes, of the object IDs a
+// RelationshipValidationPolicy may allow, as that is the most every datastore can store.
+const MaximumObjectIDLength = 128
+
+// relaxedObjectIDPattern bounds the object IDs a RelationshipValidationPolicy may allow, other than
+// the wildcard subject ID.
+var relaxedObjectIDPattern = regexp.MustCompile(fmt.Sprintf(`^[a-zA-Z0-9/_|\-=+]{1,%d}$`, MaximumObjectIDLength))
+
+// DefaultRelationshipValidationPolicy is the policy applying the rules of the API to the object
+// IDs of the relationships written, and no other rules.
+var DefaultRelationshipValidationPolicy RelationshipValidationPolicy = ObjectValidationPolicy{}
+
+// RelationshipValidationPolicy validates the relationships created or touched by
+// WriteRelationships, allowing operators to enforce rules of their own, such as a pattern for
+// object IDs or an allowlist of object types.
+//
+// The policy replaces the rules of the API for the object IDs of the relationships written, and so
+// can relax them as well as make them stricter; the other rules of the API, such as the patterns
+// of object types and relations, still apply. Relaxed object IDs are bounded by what every
+// datastore can store: at most MaximumObjectIDLength bytes of letters, digits and `/_|-=+`. IDs
+// outside of that are rejected whatever the policy. Only object IDs matching
+// DefaultObjectIDPattern can be named by the other calls of the API. Policies should therefore
+// build on ObjectValidationPolicy, whose zero value applies the rules of the API.
+//
+// Deleted relationships are only validated if their object IDs do not match the rules of the
+// API, so that relationships written before a stricter policy was introduced can be removed.
+type RelationshipValidationPolicy interface {
+	// ValidateRelationship returns an ErrRelationshipPolicyViolation naming the rule violated by
+	// the relationship, or nil if the relationship is allowed. Errors without a gRPC status are
+	// reported as a violation of a rule named "custom".
+	ValidateRelationship(rel *v1.Relationship) error
+}
+
+// ObjectValidationPolicy is a RelationshipValidationPolicy applying the same rules to both the
+// resource and the subject of each relationship. The zero value allows every relationship allowed
+// by the API.
+type ObjectValidationPolicy struct {
+	// AllowedObjectTypes, if not empty, are the only object types which may be written.
+	AllowedObjectTypes []string
+
+	// ObjectIDPattern must match the ID of every object written, defaulting to
+	// DefaultObjectIDPattern if nil. The wildcard subject ID is exempt.
+	ObjectIDPattern *regexp.Regexp
+
+	// MinimumObjectIDLength is the minimum length, in bytes, of the ID of every object written.
+	// The wildcard subject ID is exempt.
+	MinimumObjectIDLength int
+}
+
+// ValidateRelationship implements RelationshipValidationPolicy.
+func (p ObjectValidationPolicy) ValidateRelationship(rel *v1.Relationship) error {
+	if err := p.validateObject(rel, rel.Resource); err != nil {
+		return err
+	}
+	return p.validateObject(rel, rel.Subject.Object)
+}
+
+func (p ObjectValidationPolicy) validateObject(rel *v1.Relationship, object *v1.ObjectReference) error {
+	if len(p.AllowedObjectTypes) > 0 && !stringz.SliceContains(p.AllowedObjectTypes, object.ObjectType) {
+		return NewRelationshipPolicyViolationErr(rel, "allowed-object-types",
+			fmt.Sprintf("object type `%s` is not allowed", object.ObjectType))
+	}
+
+	if object.ObjectId == tuple.PublicWildcard {
+		return nil
+	}
+
+	if len(object.ObjectId) < p.MinimumObjectIDLength {
+		return NewRelationshipPolicyViolationErr(rel, "minimum-object-id-length",
+			fmt.Sprintf("object ID `%s` is shorter than %d", object.ObjectId, p.MinimumObjectIDLength))
+	}
+
+	pattern := p.ObjectIDPattern
+	if pattern == nil {
+		pattern = DefaultObjectIDPattern
+	}
+
+	if !pattern.MatchString(object.ObjectId) {
+		return NewRelationshipPolicyViolationErr(rel, "object-id-pattern",
+			fmt.Sprintf("object ID `%s` does not match pattern `%s`", object.ObjectId, pattern))
+	}
+
+	return nil
+}
+
+// validateWithPolicy validates the relationship with the policy, ensuring that a rejection is
+// reported as an ErrRelationshipPolicyViolation unless the policy gave it a gRPC status, and that
+// the object IDs it allows can be stored.
+func validateWithPolicy(policy RelationshipValidationPolicy, rel *v1.Relationship) error {
+	err := policy.ValidateRelationship(rel)
+	if err == nil {
+		if err := validateRelaxedObjectID(rel, rel.Resource); err != nil {
+			return err
+		}
+		return validateRelaxedObjectID(rel, rel.Subject.Object)
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return NewRelationshipPolicyViolationErr(rel, "custom", err.Error())
+}
+
+func validateRelaxedObjectID(rel *v1.Relationship, object *v1.ObjectReference) error {
+	if object.ObjectId == tuple.PublicWildcard || relaxedObjectIDPattern.MatchString(object.ObjectId) {
+		return nil
+	}
+
+	return NewRelationshipPolicyViolationErr(rel, "storable-object-id",
+		fmt.Sprintf("object ID `%s` does not match pattern `%s`", object.ObjectId, relaxedObjectIDPattern))
+}
+
+// validateUpdateWithPolicy validates the relationship of the update with the policy. Deletions are
+// allowed if either the policy or the rules of the API allow them.
+func validateUpdateWithPolicy(policy RelationshipValidationPolicy, update *v1.RelationshipUpdate) error {
+	if update.Operation == v1.RelationshipUpdate_OPERATION_DELETE &&
+		DefaultRelationshipValidationPolicy.ValidateRelationship(update.Relationship) == nil {
+		return nil
+	}
+
+	return validateWithPolicy(policy, update.Relationship)
+}
+
+// deferObjectIDsToPolicy wraps the interceptor validating requests, such that violations of the
+// rules of the API by the object IDs of the relationships written by WriteRelationships are
+// ignored, and left to the RelationshipValidationPolicy applied by WriteRelationships itself.
+func deferObjectIDsToPolicy(validate grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		writeReq, ok := req.(*v1.WriteRelationshipsRequest)
+		if !ok {
+			return validate(ctx, req, info, handler)
+		}
+
+		if err := withoutObjectIDViolations(writeReq.ValidateAll()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// withoutObjectIDViolations returns the first of the validation errors which is not a violation by
+// the ID of an object, or nil if there is none. Errors of embedded messages are only returned if
+// they have such a violation themselves.
+func withoutObjectIDViolations(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if multi, ok := err.(interface{ AllErrors() []error }); ok {
+		for _, err := range multi.AllErrors() {
+			if remaining := withoutObjectIDViolations(err); remaining != nil {
+				return remaining
+			}
+		}
+		return nil
+	}
+
+	if objErr, ok := err.(v1.ObjectReferenceValidationError); ok && objErr.Field() == "ObjectId" {
+		return nil
+	}
+
+	if embedded, ok := err.(interface{ Cause() error }); ok && embedded.Cause() != nil {
+		if withoutObjectIDViolations(embedded.Cause()) == nil {
+			return nil
+		}
+	}
+
+	return err
+}
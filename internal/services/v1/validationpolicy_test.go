@@ -0,0 +1,228 @@
+package v1_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+func TestObjectValidationPolicy(t *testing.T) {
+	policy := v1svc.ObjectValidationPolicy{
+		AllowedObjectTypes:    []string{"document", "user"},
+		ObjectIDPattern:       regexp.MustCompile(`^[a-z]+$`),
+		MinimumObjectIDLength: 3,
+	}
+
+	testCases := []struct {
+		name         string
+		relationship *v1.Relationship
+		expectedRule string
+	}{
+		{"allowed", rel("document", "somedoc", "viewer", "user", "tom", ""), ""},
+		{"wildcard subject", rel("document", "somedoc", "viewer", "user", "*", ""), ""},
+		{"disallowed resource type", rel("folder", "somefolder", "viewer", "user", "tom", ""), "allowed-object-types"},
+		{"disallowed subject type", rel("document", "somedoc", "parent", "folder", "somefolder", ""), "allowed-object-types"},
+		{"short resource ID", rel("document", "ab", "viewer", "user", "tom", ""), "minimum-object-id-length"},
+		{"short subject ID", rel("document", "somedoc", "viewer", "user", "al", ""), "minimum-object-id-length"},
+		{"mismatched ID", rel("document", "some-doc", "viewer", "user", "tom", ""), "object-id-pattern"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.ValidateRelationship(tc.relationship)
+			if tc.expectedRule == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var violation v1svc.ErrRelationshipPolicyViolation
+			require.ErrorAs(t, err, &violation)
+			require.Equal(t, tc.expectedRule, violation.Rule())
+		})
+	}
+
+	// The zero policy applies the rules of the API.
+	require.NoError(t, v1svc.ObjectValidationPolicy{}.ValidateRelationship(rel("folder", "a", "viewer", "user", "b", "")))
+
+	var violation v1svc.ErrRelationshipPolicyViolation
+	require.ErrorAs(t, v1svc.ObjectValidationPolicy{}.ValidateRelationship(rel("folder", "a.b", "viewer", "user", "b", "")), &violation)
+	require.Equal(t, "object-id-pattern", violation.Rule())
+}
+
+func TestWriteRelationshipsWithRelaxedValidationPolicy(t *testing.T) {
+	relaxed := rel("document", "some=doc", "viewer", "user", "+tom", "")
+
+	// Relaxed object IDs are outside of the format enforced by the validating datastore of the
+	// fixtures, so the server is given the datastore underneath it.
+	withoutValidation := func(ds datastore.Datastore, require *require.Assertions) (datastore.Datastore, datastore.Revision) {
+		_, revision := tf.StandardDatastoreWithData(ds, require)
+		return ds, revision
+	}
+
+	for _, tc := range []struct {
+		name    string
+		policy  v1svc.RelationshipValidationPolicy
+		allowed bool
+	}{
+		{"no policy", nil, false},
+		{"default policy", v1svc.DefaultRelationshipValidationPolicy, false},
+		{"relaxed policy", v1svc.ObjectValidationPolicy{ObjectIDPattern: regexp.MustCompile(`^[a-zA-Z0-9_.=+-]+$`)}, true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+				require,
+				0,
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:           1000,
+					MaxPreconditionsCount:        1000,
+					RelationshipValidationPolicy: tc.policy,
+				},
+				withoutValidation,
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			write := func(operation v1.RelationshipUpdate_Operation, relationship *v1.Relationship) error {
+				_, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+					Updates: []*v1.RelationshipUpdate{{Operation: operation, Relationship: relationship}},
+				})
+				return err
+			}
+
+			// The rules of the API other than those for object IDs still apply, as does the bound
+			// on the object IDs which can be stored.
+			grpcutil.RequireStatus(t, codes.InvalidArgument, write(v1.RelationshipUpdate_OPERATION_TOUCH, rel("document", "some=doc", "Viewer", "user", "tom", "")))
+			grpcutil.RequireStatus(t, codes.InvalidArgument, write(v1.RelationshipUpdate_OPERATION_TOUCH, rel("document", "*", "viewer", "user", "tom", "")))
+			grpcutil.RequireStatus(t, codes.InvalidArgument, write(v1.RelationshipUpdate_OPERATION_TOUCH, rel("document", "some.doc", "viewer", "user", "tom", "")))
+			grpcutil.RequireStatus(t, codes.InvalidArgument, write(v1.RelationshipUpdate_OPERATION_TOUCH, rel("document", strings.Repeat("a", v1svc.MaximumObjectIDLength+1), "viewer", "user", "tom", "")))
+
+			if !tc.allowed {
+				grpcutil.RequireStatus(t, codes.InvalidArgument, write(v1.RelationshipUpdate_OPERATION_TOUCH, relaxed))
+				return
+			}
+
+			require.NoError(write(v1.RelationshipUpdate_OPERATION_TOUCH, relaxed))
+
+			stream, err := client.ReadRelationships(context.Background(), &v1.ReadRelationshipsRequest{
+				Consistency:        &v1.Consistency{Requirement: &v1.Consistency_FullyConsistent{FullyConsistent: true}},
+				RelationshipFilter: &v1.RelationshipFilter{ResourceType: "document", OptionalRelation: "viewer"},
+			})
+			require.NoError(err)
+
+			found := false
+			for {
+				resp, err := stream.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(err)
+				found = found || proto.Equal(relaxed, resp.Relationship)
+			}
+			require.True(found)
+
+			require.NoError(write(v1.RelationshipUpdate_OPERATION_DELETE, relaxed))
+		})
+	}
+}
+
+type rejectingPolicy struct{}
+
+func (rejectingPolicy) ValidateRelationship(rel *v1.Relationship) error {
+	if rel.Subject.Object.ObjectId == "rejected" {
+		return errors.New("subject is rejected")
+	}
+	return v1svc.DefaultRelationshipValidationPolicy.ValidateRelationship(rel)
+}
+
+func TestWriteRelationshipsWithValidationPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy v1svc.RelationshipValidationPolicy
+		bad    *v1.Relationship
+		rule   string
+	}{
+		{
+			"object policy",
+			v1svc.ObjectValidationPolicy{MinimumObjectIDLength: 4},
+			rel("document", "doc", "viewer", "user", "tom2", ""),
+			"minimum-object-id-length",
+		},
+		{
+			"custom policy",
+			rejectingPolicy{},
+			rel("document", "newdoc", "viewer", "user", "rejected", ""),
+			"custom",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+			conn, cleanup, _, _ := testserver.NewTestServerWithConfig(
+				require,
+				0,
+				memdb.DisableGC,
+				true,
+				testserver.ServerConfig{
+					MaxUpdatesPerWrite:           1000,
+					MaxPreconditionsCount:        1000,
+					RelationshipValidationPolicy: tc.policy,
+				},
+				tf.StandardDatastoreWithData,
+			)
+			client := v1.NewPermissionsServiceClient(conn)
+			t.Cleanup(cleanup)
+
+			_, err := client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{{
+					Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+					Relationship: rel("document", "newdoc", "viewer", "user", "fred", ""),
+				}},
+			})
+			require.NoError(err)
+
+			_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{{
+					Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+					Relationship: tc.bad,
+				}},
+			})
+			grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+			require.Contains(err.Error(), "violates rule `"+tc.rule+"`")
+
+			info := status.Convert(err).Details()[0].(*errdetails.ErrorInfo)
+			require.Equal(v1svc.RelationshipPolicyViolationReason, info.Reason)
+			require.Equal(tc.rule, info.Metadata["rule"])
+
+			// Deletions allowed by the rules of the API are not validated.
+			_, err = client.WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+				Updates: []*v1.RelationshipUpdate{{
+					Operation:    v1.RelationshipUpdate_OPERATION_DELETE,
+					Relationship: tc.bad,
+				}},
+			})
+			require.NoError(err)
+		})
+	}
+}
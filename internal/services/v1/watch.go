@@ -1,11 +1,14 @@
 package v1
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
@@ -18,6 +21,18 @@ import (
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
 
+// WatchHeartbeatIntervalMetadataKey is the request metadata key which, on a Watch call, requests
+// a heartbeat every given interval, such as `30s`, even when no changes occurred. A heartbeat is a
+// WatchResponse without updates, whose ChangesThrough is a revision through which all changes
+// have been sent, so that consumers can checkpoint it and detect that the stream is live.
+//
+// The revision of a heartbeat is the latest revision reported by the datastore's watch, including
+// revisions whose changes were all filtered out and the checkpoints which the datastore's watch
+// reports during quiet periods, or the start revision if none was reported yet. It is never read
+// from the datastore independently of the watch, which may not yet have reported the changes up
+// to the datastore's head revision.
+const WatchHeartbeatIntervalMetadataKey = "io.spicedb.watch-heartbeat-interval"
+
 type watchServer struct {
 	v1.UnimplementedWatchServiceServer
 	shared.WithStreamServiceSpecificInterceptor
@@ -62,11 +77,38 @@ func (ws *watchServer) Watch(req *v1.WatchRequest, stream v1.WatchService_WatchS
 		DispatchCount: 1,
 	})
 
+	heartbeatInterval, err := watchHeartbeatInterval(ctx)
+	if err != nil {
+		return err
+	}
+
+	var heartbeats <-chan time.Time
+	if heartbeatInterval > 0 {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
+
+	// lastRevision is the revision through which all changes have been received from the
+	// datastore's watch.
+	lastRevision := afterRevision
+
 	updates, errchan := ds.Watch(ctx, afterRevision)
 	for {
 		select {
+		case <-heartbeats:
+			if err := stream.Send(&v1.WatchResponse{
+				ChangesThrough: zedtoken.NewFromRevision(lastRevision),
+			}); err != nil {
+				return status.Errorf(codes.Canceled, "watch canceled by user: %s", err)
+			}
 		case update, ok := <-updates:
 			if ok {
+				lastRevision = update.Revision
+				if update.IsCheckpoint {
+					continue
+				}
+
 				filtered := filterUpdates(objectTypesMap, update.Changes)
 				if len(filtered) > 0 {
 					if err := stream.Send(&v1.WatchResponse{
@@ -90,6 +132,26 @@ func (ws *watchServer) Watch(req *v1.WatchRequest, stream v1.WatchService_WatchS
 	}
 }
 
+// watchHeartbeatInterval returns the heartbeat interval requested by
+// WatchHeartbeatIntervalMetadataKey, or zero if no heartbeat was requested.
+func watchHeartbeatInterval(ctx context.Context) (time.Duration, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+
+	values := md.Get(WatchHeartbeatIntervalMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(values[0])
+	if err != nil || interval <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid watch heartbeat interval `%s`: must be a positive duration", values[0])
+	}
+	return interval, nil
+}
+
 func filterUpdates(objectTypes map[string]struct{}, candidates []*core.RelationTupleUpdate) []*v1.RelationshipUpdate {
 	updates := tuple.UpdatesToRelationshipUpdates(candidates)
 
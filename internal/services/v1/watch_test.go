@@ -14,11 +14,14 @@ import (
 	"github.com/authzed/grpcutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
+	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/tuple"
 	"github.com/authzed/spicedb/pkg/zedtoken"
 )
@@ -192,3 +195,62 @@ func sortUpdates(in []*v1.RelationshipUpdate) []*v1.RelationshipUpdate {
 
 	return out
 }
+
+func TestWatchHeartbeat(t *testing.T) {
+	require := require.New(t)
+
+	conn, cleanup, ds, revision := testserver.NewTestServer(require, 0, memdb.DisableGC, true, testfixtures.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+	client := v1.NewWatchServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(metadata.AppendToOutgoingContext(ctx, v1svc.WatchHeartbeatIntervalMetadataKey, "20ms"), &v1.WatchRequest{
+		OptionalObjectTypes: []string{"folder"},
+		OptionalStartCursor: zedtoken.NewFromRevision(revision),
+	})
+	require.NoError(err)
+
+	// Heartbeats are sent without updates, and never go back.
+	lastRevision := revision
+	nextHeartbeat := func() datastore.Revision {
+		resp, err := stream.Recv()
+		require.NoError(err)
+		require.Empty(resp.Updates)
+
+		heartbeatRevision, err := zedtoken.DecodeRevision(resp.ChangesThrough, ds)
+		require.NoError(err)
+		require.False(heartbeatRevision.LessThan(lastRevision))
+		lastRevision = heartbeatRevision
+		return heartbeatRevision
+	}
+
+	// Heartbeats advance during quiet periods, with the checkpoints of the datastore's watch.
+	for {
+		if nextHeartbeat().GreaterThan(revision) {
+			break
+		}
+	}
+
+	// Heartbeats advance past changes which were filtered out.
+	written, err := v1.NewPermissionsServiceClient(conn).WriteRelationships(context.Background(), &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{
+			update(v1.RelationshipUpdate_OPERATION_CREATE, "document", "document1", "viewer", "user", "user1"),
+		},
+	})
+	require.NoError(err)
+
+	writtenRevision, err := zedtoken.DecodeRevision(written.WrittenAt, ds)
+	require.NoError(err)
+	for {
+		if !nextHeartbeat().LessThan(writtenRevision) {
+			break
+		}
+	}
+
+	stream, err = client.Watch(metadata.AppendToOutgoingContext(ctx, v1svc.WatchHeartbeatIntervalMetadataKey, "never"), &v1.WatchRequest{})
+	require.NoError(err)
+	_, err = stream.Recv()
+	grpcutil.RequireStatus(t, codes.InvalidArgument, err)
+}
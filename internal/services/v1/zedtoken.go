@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/middleware/consistency"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+)
+
+type zedTokenServer struct {
+	spicedbv1.UnimplementedZedTokenServiceServer
+}
+
+// NewZedTokenServer creates an instance of the ZedToken server.
+func NewZedTokenServer() spicedbv1.ZedTokenServiceServer {
+	return &zedTokenServer{}
+}
+
+func (zs *zedTokenServer) MintZedToken(ctx context.Context, _ *spicedbv1.MintZedTokenRequest) (*spicedbv1.MintZedTokenResponse, error) {
+	// The consistency middleware selects the head revision for requests without a consistency
+	// block, which is exactly the revision at which a write would have been performed.
+	_, token := consistency.MustRevisionFromContext(ctx)
+	return &spicedbv1.MintZedTokenResponse{MintedAt: token}, nil
+}
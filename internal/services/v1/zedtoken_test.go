@@ -0,0 +1,115 @@
+package v1_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	tf "github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/internal/testserver"
+	spicedbv1 "github.com/authzed/spicedb/pkg/proto/spicedb/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+	"github.com/authzed/spicedb/pkg/zedtoken"
+)
+
+func TestMintZedToken(t *testing.T) {
+	req := require.New(t)
+	conn, cleanup, ds, revision := testserver.NewTestServer(req, 0, memdb.DisableGC, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewZedTokenServiceClient(conn)
+	permsClient := v1.NewPermissionsServiceClient(conn)
+
+	ctx := context.Background()
+	mintResp, err := client.MintZedToken(ctx, &spicedbv1.MintZedTokenRequest{})
+	req.NoError(err)
+	minted := mintResp.MintedAt
+
+	mintedRevision, err := zedtoken.DecodeRevision(minted, ds)
+	req.NoError(err)
+	req.False(mintedRevision.LessThan(revision))
+
+	// Write a relationship and ensure it is not visible at the previously minted token.
+	rel := tuple.MustToRelationship(tuple.MustParse("document:newdoc#viewer@user:tom"))
+	resp, err := permsClient.WriteRelationships(ctx, &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{{
+			Operation:    v1.RelationshipUpdate_OPERATION_CREATE,
+			Relationship: rel,
+		}},
+	})
+	req.NoError(err)
+
+	stream, err := permsClient.ReadRelationships(ctx, &v1.ReadRelationshipsRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: minted},
+		},
+		RelationshipFilter: tuple.RelToFilter(rel),
+	})
+	req.NoError(err)
+	_, err = stream.Recv()
+	req.ErrorContains(err, "EOF")
+
+	// A token minted after the write must be at or after the write.
+	mintAfterResp, err := client.MintZedToken(ctx, &spicedbv1.MintZedTokenRequest{})
+	req.NoError(err)
+	mintedAfter := mintAfterResp.MintedAt
+
+	writtenRevision, err := zedtoken.DecodeRevision(resp.WrittenAt, ds)
+	req.NoError(err)
+
+	mintedAfterRevision, err := zedtoken.DecodeRevision(mintedAfter, ds)
+	req.NoError(err)
+	req.False(mintedAfterRevision.LessThan(writtenRevision))
+
+	req.Equal(rel.String(), readFirst(req, permsClient, mintedAfter, rel).String())
+}
+
+func TestMintedZedTokenExpiresWithGCWindow(t *testing.T) {
+	req := require.New(t)
+	gcWindow := 100 * time.Millisecond
+	conn, cleanup, _, _ := testserver.NewTestServer(req, 0, gcWindow, true, tf.StandardDatastoreWithData)
+	t.Cleanup(cleanup)
+
+	client := spicedbv1.NewZedTokenServiceClient(conn)
+	permsClient := v1.NewPermissionsServiceClient(conn)
+
+	ctx := context.Background()
+	mintResp, err := client.MintZedToken(ctx, &spicedbv1.MintZedTokenRequest{})
+	req.NoError(err)
+	minted := mintResp.MintedAt
+
+	time.Sleep(2 * gcWindow)
+
+	// Ensure the GC window has moved past the minted token by writing.
+	_, err = permsClient.WriteRelationships(ctx, &v1.WriteRelationshipsRequest{
+		Updates: []*v1.RelationshipUpdate{{
+			Operation:    v1.RelationshipUpdate_OPERATION_TOUCH,
+			Relationship: tuple.MustToRelationship(tuple.MustParse("document:newdoc#viewer@user:tom")),
+		}},
+	})
+	req.NoError(err)
+
+	_, err = permsClient.CheckPermission(ctx, &v1.CheckPermissionRequest{
+		Consistency: &v1.Consistency{
+			Requirement: &v1.Consistency_AtExactSnapshot{AtExactSnapshot: minted},
+		},
+		Resource: &v1.ObjectReference{
+			ObjectType: "document",
+			ObjectId:   "newdoc",
+		},
+		Permission: "view",
+		Subject: &v1.SubjectReference{
+			Object: &v1.ObjectReference{
+				ObjectType: "user",
+				ObjectId:   "tom",
+			},
+		},
+	})
+	grpcutil.RequireStatus(t, codes.OutOfRange, err)
+}
@@ -31,6 +31,7 @@ func (vd validatingDatastore) SnapshotReader(revision datastore.Revision) datast
 func (vd validatingDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	if f == nil {
 		return datastore.NoRevision, fmt.Errorf("nil delegate function")
@@ -39,7 +40,7 @@ func (vd validatingDatastore) ReadWriteTx(
 	return vd.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		txDelegate := validatingReadWriteTransaction{validatingSnapshotReader{rwt}, rwt}
 		return f(txDelegate)
-	})
+	}, opts...)
 }
 
 type validatingSnapshotReader struct {
@@ -48,8 +49,9 @@ type validatingSnapshotReader struct {
 
 func (vsr validatingSnapshotReader) ListNamespaces(
 	ctx context.Context,
+	opts ...options.ListNamespacesOptionsOption,
 ) ([]*core.NamespaceDefinition, error) {
-	read, err := vsr.delegate.ListNamespaces(ctx)
+	read, err := vsr.delegate.ListNamespaces(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +99,24 @@ func (vsr validatingSnapshotReader) QueryRelationships(ctx context.Context,
 	return vsr.delegate.QueryRelationships(ctx, filter, opts...)
 }
 
+func (vsr validatingSnapshotReader) QueryRelationshipsForResourceTypes(ctx context.Context,
+	resourceTypes []string,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	if len(resourceTypes) == 0 {
+		return nil, errors.New("query for resource types missing resource types")
+	}
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+	for _, sub := range queryOpts.Usersets {
+		if err := sub.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return vsr.delegate.QueryRelationshipsForResourceTypes(ctx, resourceTypes, opts...)
+}
+
 func (vsr validatingSnapshotReader) ReadNamespace(
 	ctx context.Context,
 	nsName string,
@@ -167,8 +187,8 @@ func (vrwt validatingReadWriteTransaction) WriteNamespaces(ctx context.Context,
 	return vrwt.delegate.WriteNamespaces(ctx, newConfigs...)
 }
 
-func (vrwt validatingReadWriteTransaction) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
-	return vrwt.delegate.DeleteNamespaces(ctx, nsNames...)
+func (vrwt validatingReadWriteTransaction) DeleteNamespaces(ctx context.Context, delOption datastore.DeleteNamespacesRelationshipsOption, nsNames ...string) error {
+	return vrwt.delegate.DeleteNamespaces(ctx, delOption, nsNames...)
 }
 
 func (vrwt validatingReadWriteTransaction) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
@@ -199,6 +219,26 @@ func (vrwt validatingReadWriteTransaction) DeleteRelationships(ctx context.Conte
 	return vrwt.delegate.DeleteRelationships(ctx, filter)
 }
 
+func (vrwt validatingReadWriteTransaction) DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error) {
+	if err := subject.Validate(); err != nil {
+		return 0, err
+	}
+
+	return vrwt.delegate.DeleteRelationshipsForSubject(ctx, subject)
+}
+
+func (vrwt validatingReadWriteTransaction) RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error) {
+	if err := oldSubject.Validate(); err != nil {
+		return 0, err
+	}
+
+	if err := newSubject.Validate(); err != nil {
+		return 0, err
+	}
+
+	return vrwt.delegate.RewriteRelationshipsSubject(ctx, oldSubject, newSubject)
+}
+
 func (vrwt validatingReadWriteTransaction) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
 	return vrwt.delegate.WriteCaveats(ctx, caveats)
 }
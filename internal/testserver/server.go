@@ -12,6 +12,7 @@ import (
 	"github.com/authzed/spicedb/internal/middleware/consistency"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/middleware/servicespecific"
+	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/cmd/util"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -20,8 +21,14 @@ import (
 
 // ServerConfig is configuration for the test server.
 type ServerConfig struct {
-	MaxUpdatesPerWrite    uint16
-	MaxPreconditionsCount uint16
+	MaxUpdatesPerWrite        uint16
+	MaxPreconditionsCount     uint16
+	MaxLookupResourcesResults uint32
+	MaxExpandLeafSubjects     uint32
+
+	RelationshipValidationPolicy v1svc.RelationshipValidationPolicy
+
+	WellKnownCaveatContextEnabled bool
 }
 
 // NewTestServer creates a new test server, using defaults for the config.
@@ -57,6 +64,10 @@ func NewTestServerWithConfig(require *require.Assertions,
 		server.WithDispatchMaxDepth(50),
 		server.WithMaximumPreconditionCount(config.MaxPreconditionsCount),
 		server.WithMaximumUpdatesPerWrite(config.MaxUpdatesPerWrite),
+		server.WithMaximumLookupResourcesResults(config.MaxLookupResourcesResults),
+		server.WithMaximumExpandLeafSubjects(config.MaxExpandLeafSubjects),
+		server.WithRelationshipValidationPolicy(config.RelationshipValidationPolicy),
+		server.WithWellKnownCaveatContextEnabled(config.WellKnownCaveatContextEnabled),
 		server.WithGRPCServer(util.GRPCServerConfig{
 			Network: util.BufferedNetwork,
 			Enabled: true,
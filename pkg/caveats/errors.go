@@ -25,6 +25,30 @@ func (err ParameterConversionErr) DetailsMetadata() map[string]string {
 	}
 }
 
+// EvaluationErr is an error in the evaluation of a caveat with the supplied parameters, such as
+// indexing a list parameter out of its bounds or reading a missing key of a map parameter.
+type EvaluationErr struct {
+	error
+	caveatName string
+}
+
+// MarshalZerologObject implements zerolog.LogObjectMarshaler
+func (err EvaluationErr) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("caveatName", err.caveatName)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err EvaluationErr) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"caveat_name": err.caveatName,
+	}
+}
+
+// Unwrap returns the error returned by the evaluation.
+func (err EvaluationErr) Unwrap() error {
+	return err.error
+}
+
 // CompilationErrors is a wrapping error for containing compilation errors for a Caveat.
 type CompilationErrors struct {
 	error
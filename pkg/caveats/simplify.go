@@ -0,0 +1,313 @@
+package caveats
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// SimplifyCaveat returns a simplified, but semantically equivalent, form of the given caveat,
+// compiled under the given environment. The boolean structure of the expression is simplified:
+//
+//   - constants are folded (`a && true` is `a`, `a || true` is `true` and `!false` is `true`)
+//   - repeated operands are removed (`a || a` is `a`)
+//   - absorbed operands are removed (`a || (a && b)` is `a`)
+//   - double negations are removed (`!!a` is `a`)
+//
+// Each rule holds under CEL's commutative handling of errors and unknown values, so the simplified
+// caveat evaluates to the same result as the original for any context, including partial ones.
+//
+// Operands which would be removed by folding or absorption are kept if they reference a parameter
+// which is otherwise no longer referenced, as every parameter of a caveat must be referenced by its
+// expression.
+//
+// Caveats whose expression cannot be printed, such as those using macros, are returned unchanged.
+func SimplifyCaveat(env *Environment, caveat *CompiledCaveat) (*CompiledCaveat, error) {
+	expr := caveat.ast.Expr()
+
+	parameters := util.NewSet[string]()
+	for name := range env.variables {
+		parameters.Add(name)
+	}
+
+	originalParams := util.NewSet[string]()
+	referencedParameters(parameters, expr, originalParams)
+
+	s := &simplifier{nextID: maxExprID(expr) + 1}
+	simplified := s.simplify(expr)
+
+	simplifiedParams := util.NewSet[string]()
+	referencedParameters(parameters, simplified, simplifiedParams)
+	if !originalParams.Subtract(simplifiedParams).IsEmpty() {
+		s.keepOperands = true
+		simplified = s.simplify(expr)
+	}
+
+	// The simplified expression is printed and compiled again, to produce a fully checked form.
+	exprString, err := cel.AstToString(cel.ParsedExprToAst(&exprpb.ParsedExpr{
+		Expr:       simplified,
+		SourceInfo: caveat.ast.SourceInfo(),
+	}))
+	if err != nil {
+		return caveat, nil
+	}
+
+	return CompileCaveatWithName(env, exprString, caveat.name)
+}
+
+type simplifier struct {
+	// nextID is the ID given to the next expression created by the simplifier.
+	nextID int64
+
+	// keepOperands, if true, disables the rules which remove operands that are not repeated.
+	keepOperands bool
+}
+
+func (s *simplifier) simplify(expr *exprpb.Expr) *exprpb.Expr {
+	call := expr.GetCallExpr()
+	if call == nil {
+		// Comprehensions are left as is, as they are printed from the macro which produced them.
+		return expr
+	}
+
+	switch call.Function {
+	case operators.LogicalAnd:
+		return s.simplifyLogical(expr, operators.LogicalAnd, operators.LogicalOr, true)
+
+	case operators.LogicalOr:
+		return s.simplifyLogical(expr, operators.LogicalOr, operators.LogicalAnd, false)
+
+	case operators.LogicalNot:
+		arg := s.simplify(call.Args[0])
+		if value, ok := boolConstant(arg); ok {
+			return s.boolExpr(!value)
+		}
+
+		if arg.GetCallExpr().GetFunction() == operators.LogicalNot {
+			return arg.GetCallExpr().Args[0]
+		}
+
+		return s.callExpr(expr.Id, operators.LogicalNot, arg)
+
+	default:
+		args := make([]*exprpb.Expr, 0, len(call.Args))
+		for _, arg := range call.Args {
+			args = append(args, s.simplify(arg))
+		}
+
+		target := call.Target
+		if target != nil {
+			target = s.simplify(target)
+		}
+
+		return &exprpb.Expr{
+			Id: expr.Id,
+			ExprKind: &exprpb.Expr_CallExpr{
+				CallExpr: &exprpb.Expr_Call{
+					Target:   target,
+					Function: call.Function,
+					Args:     args,
+				},
+			},
+		}
+	}
+}
+
+// simplifyLogical simplifies a conjunction or disjunction, whose identity is the given boolean.
+// The dual operator is the other of the two operators.
+func (s *simplifier) simplifyLogical(expr *exprpb.Expr, operator, dualOperator string, identity bool) *exprpb.Expr {
+	operands := make([]*exprpb.Expr, 0, 2)
+	foundKeys := util.NewSet[string]()
+	sawAnnihilator := false
+
+	var collect func(expr *exprpb.Expr)
+	collect = func(expr *exprpb.Expr) {
+		if expr.GetCallExpr().GetFunction() == operator {
+			for _, arg := range expr.GetCallExpr().Args {
+				collect(arg)
+			}
+			return
+		}
+
+		simplified := s.simplify(expr)
+		if simplified.GetCallExpr().GetFunction() == operator {
+			collect(simplified)
+			return
+		}
+
+		if value, ok := boolConstant(simplified); ok {
+			sawAnnihilator = sawAnnihilator || value != identity
+			return
+		}
+
+		if foundKeys.Add(exprKey(simplified)) {
+			operands = append(operands, simplified)
+		}
+	}
+	collect(expr)
+
+	if sawAnnihilator && (len(operands) == 0 || !s.keepOperands) {
+		return s.boolExpr(!identity)
+	}
+
+	if !s.keepOperands {
+		operands = absorb(operands, foundKeys, dualOperator)
+	}
+
+	if len(operands) == 0 {
+		return s.boolExpr(identity)
+	}
+
+	simplified := operands[0]
+	for _, operand := range operands[1:] {
+		simplified = s.callExpr(s.newID(), operator, simplified, operand)
+	}
+
+	// An annihilator which cannot be folded must be kept, to keep the value of the expression.
+	if sawAnnihilator {
+		simplified = s.callExpr(s.newID(), operator, simplified, s.boolExpr(!identity))
+	}
+
+	return simplified
+}
+
+// absorb removes the operands which are a dual operation over one of the other operands, as they
+// cannot change the result: `a || (a && b)` is `a`, and `a && (a || b)` is `a`.
+func absorb(operands []*exprpb.Expr, operandKeys *util.Set[string], dualOperator string) []*exprpb.Expr {
+	absorbed := make([]*exprpb.Expr, 0, len(operands))
+	for _, operand := range operands {
+		if operand.GetCallExpr().GetFunction() != dualOperator || !hasDualOperand(operand, operandKeys, dualOperator) {
+			absorbed = append(absorbed, operand)
+		}
+	}
+	return absorbed
+}
+
+func hasDualOperand(expr *exprpb.Expr, operandKeys *util.Set[string], dualOperator string) bool {
+	if expr.GetCallExpr().GetFunction() != dualOperator {
+		return operandKeys.Has(exprKey(expr))
+	}
+
+	for _, arg := range expr.GetCallExpr().Args {
+		if hasDualOperand(arg, operandKeys, dualOperator) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *simplifier) newID() int64 {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+func (s *simplifier) callExpr(id int64, function string, args ...*exprpb.Expr) *exprpb.Expr {
+	return &exprpb.Expr{
+		Id: id,
+		ExprKind: &exprpb.Expr_CallExpr{
+			CallExpr: &exprpb.Expr_Call{
+				Function: function,
+				Args:     args,
+			},
+		},
+	}
+}
+
+func (s *simplifier) boolExpr(value bool) *exprpb.Expr {
+	return &exprpb.Expr{
+		Id: s.newID(),
+		ExprKind: &exprpb.Expr_ConstExpr{
+			ConstExpr: &exprpb.Constant{
+				ConstantKind: &exprpb.Constant_BoolValue{BoolValue: value},
+			},
+		},
+	}
+}
+
+func boolConstant(expr *exprpb.Expr) (bool, bool) {
+	constant, ok := expr.GetConstExpr().GetConstantKind().(*exprpb.Constant_BoolValue)
+	if !ok {
+		return false, false
+	}
+	return constant.BoolValue, true
+}
+
+// exprKey returns a key for the expression, equal for expressions which are structurally equal.
+func exprKey(expr *exprpb.Expr) string {
+	cloned := proto.Clone(expr).(*exprpb.Expr)
+	clearExprIDs(cloned)
+
+	serialized, err := proto.MarshalOptions{Deterministic: true}.Marshal(cloned)
+	if err != nil {
+		panic(fmt.Sprintf("could not serialize expression: %v", err))
+	}
+	return string(serialized)
+}
+
+func clearExprIDs(expr *exprpb.Expr) {
+	visitExprs(expr, func(expr *exprpb.Expr) {
+		expr.Id = 0
+	})
+}
+
+func maxExprID(expr *exprpb.Expr) int64 {
+	var maxID int64
+	visitExprs(expr, func(expr *exprpb.Expr) {
+		if expr.Id > maxID {
+			maxID = expr.Id
+		}
+	})
+	return maxID
+}
+
+// visitExprs invokes the visitor on the expression and all of its subexpressions.
+func visitExprs(expr *exprpb.Expr, visitor func(expr *exprpb.Expr)) {
+	if expr == nil {
+		return
+	}
+
+	visitor(expr)
+
+	switch t := expr.ExprKind.(type) {
+	case *exprpb.Expr_ConstExpr, *exprpb.Expr_IdentExpr:
+		// nothing to do
+
+	case *exprpb.Expr_SelectExpr:
+		visitExprs(t.SelectExpr.Operand, visitor)
+
+	case *exprpb.Expr_CallExpr:
+		visitExprs(t.CallExpr.Target, visitor)
+		for _, arg := range t.CallExpr.Args {
+			visitExprs(arg, visitor)
+		}
+
+	case *exprpb.Expr_ListExpr:
+		for _, elem := range t.ListExpr.Elements {
+			visitExprs(elem, visitor)
+		}
+
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range t.StructExpr.Entries {
+			if mapKey := entry.GetMapKey(); mapKey != nil {
+				visitExprs(mapKey, visitor)
+			}
+			visitExprs(entry.Value, visitor)
+		}
+
+	case *exprpb.Expr_ComprehensionExpr:
+		visitExprs(t.ComprehensionExpr.IterRange, visitor)
+		visitExprs(t.ComprehensionExpr.AccuInit, visitor)
+		visitExprs(t.ComprehensionExpr.LoopCondition, visitor)
+		visitExprs(t.ComprehensionExpr.LoopStep, visitor)
+		visitExprs(t.ComprehensionExpr.Result, visitor)
+
+	default:
+		panic(fmt.Sprintf("unknown CEL expression kind: %T", t))
+	}
+}
@@ -0,0 +1,178 @@
+package caveats
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/caveats/types"
+)
+
+func TestSimplifyCaveat(t *testing.T) {
+	env := MustEnvForVariables(map[string]types.VariableType{
+		"a": types.BooleanType,
+		"b": types.BooleanType,
+		"c": types.BooleanType,
+		"x": types.IntType,
+	})
+
+	tcs := []struct {
+		exprString string
+		expected   string
+	}{
+		{"a", "a"},
+		{"a && b", "a && b"},
+		{"a || a", "a"},
+		{"a && a && a", "a"},
+		{"a || b || a", "a || b"},
+		{"(a || b) && (b || a)", "(a || b) && (b || a)"},
+		{"(a || b) && (a || b)", "a || b"},
+		{"a && true", "a"},
+		{"true && a", "a"},
+		{"a || false", "a"},
+		{"a && (b || false) && true", "a && b"},
+		{"!!a", "a"},
+		{"!!!a", "!a"},
+		{"!(a && true)", "!a"},
+		{"a && !!(b || b)", "a && b"},
+		{"(a || (a && b)) && b", "a && b"},
+		{"b && ((a && b) || a)", "b && a"},
+		{"a && (a || b) && b", "a && b"},
+		{"(a || (b && c && a)) && b && c", "a && b && c"},
+		{"a || (a && b)", "a || a && b"},
+		{"a && (a || b)", "a && (a || b)"},
+		{"a || (b && c)", "a || b && c"},
+		{"x > 1 && x > 1", "x > 1"},
+		{"(x > 1 || false) == (a && true)", "x > 1 == a"},
+		{"[a, b][x] && (true || c)", "[a, b][x] && (c || true)"},
+		{"(a ? b : c) && (a ? b : c)", "a ? b : c"},
+		{"(a ? b && true : c) || false", "a ? b : c"},
+		{"x > 1 && (a || true)", "x > 1 && (a || true)"},
+		{"b && a && false", "b && a && false"},
+		{"a || b || true", "a || b || true"},
+		{"(a || b) && !false", "a || b"},
+		{"(x > 1) || (x > 1 && b)", "x > 1 || x > 1 && b"},
+		{"(x > 1 && a) || (x > 1 && a)", "x > 1 && a"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.exprString, func(t *testing.T) {
+			require := require.New(t)
+
+			compiled, err := CompileCaveatWithName(env, tc.exprString, "test")
+			require.NoError(err)
+
+			simplified, err := SimplifyCaveat(env, compiled)
+			require.NoError(err)
+			require.Equal("test", simplified.Name())
+
+			simplifiedString, err := simplified.ExprString()
+			require.NoError(err)
+			require.Equal(tc.expected, simplifiedString)
+
+			// Simplifying must be idempotent.
+			resimplified, err := SimplifyCaveat(env, simplified)
+			require.NoError(err)
+			resimplifiedString, err := resimplified.ExprString()
+			require.NoError(err)
+			require.Equal(simplifiedString, resimplifiedString)
+
+			// The simplified caveat must reference the same parameters.
+			params := []string{"a", "b", "c", "x"}
+			require.ElementsMatch(compiled.ReferencedParameters(params).AsSlice(), simplified.ReferencedParameters(params).AsSlice())
+
+			requireEquivalent(t, compiled, simplified)
+		})
+	}
+}
+
+func TestSimplifyCaveatWithMacro(t *testing.T) {
+	env := MustEnvForVariables(map[string]types.VariableType{
+		"a": types.BooleanType,
+		"x": types.IntType,
+	})
+
+	compiled, err := CompileCaveatWithName(env, "[1, 2].all(i, i > x) && [1, 2].all(i, i > x) && a", "test")
+	require.NoError(t, err)
+
+	// Macros cannot be printed, so the caveat is left as is.
+	simplified, err := SimplifyCaveat(env, compiled)
+	require.NoError(t, err)
+	require.Same(t, compiled, simplified)
+}
+
+func TestSimplifyCaveatErrorsAndUnknowns(t *testing.T) {
+	env := MustEnvForVariables(map[string]types.VariableType{
+		"a": types.BooleanType,
+		"x": types.IntType,
+	})
+
+	// Each of these reads `x`, which can either be missing or make the left side of the
+	// expression an error, and must evaluate as their simplified form does.
+	for _, exprString := range []string{
+		"10 / x > 1 || true",
+		"10 / x > 1 && false",
+		"10 / x > 1 && true",
+		"10 / x > 1 || 10 / x > 1",
+		"10 / x > 1 || (10 / x > 1 && a)",
+		"a || (10 / x > 1 && a)",
+		"!!(10 / x > 1)",
+	} {
+		exprString := exprString
+		t.Run(exprString, func(t *testing.T) {
+			compiled, err := CompileCaveatWithName(env, exprString, "test")
+			require.NoError(t, err)
+
+			simplified, err := SimplifyCaveat(env, compiled)
+			require.NoError(t, err)
+
+			requireEquivalent(t, compiled, simplified)
+		})
+	}
+}
+
+// requireEquivalent evaluates both caveats for every combination of missing and present values for
+// their parameters, and requires the same results.
+func requireEquivalent(t *testing.T, original *CompiledCaveat, simplified *CompiledCaveat) {
+	values := map[string][]any{
+		"a": {nil, true, false},
+		"b": {nil, true, false},
+		"c": {nil, true, false},
+		"x": {nil, int64(0), int64(1), int64(5)},
+	}
+
+	contexts := []map[string]any{{}}
+	for name, candidates := range values {
+		expanded := make([]map[string]any, 0, len(contexts)*len(candidates))
+		for _, context := range contexts {
+			for _, candidate := range candidates {
+				updated := make(map[string]any, len(context)+1)
+				for k, v := range context {
+					updated[k] = v
+				}
+				if candidate != nil {
+					updated[name] = candidate
+				}
+				expanded = append(expanded, updated)
+			}
+		}
+		contexts = expanded
+	}
+
+	for _, context := range contexts {
+		originalResult, originalErr := EvaluateCaveat(original, context)
+		simplifiedResult, simplifiedErr := EvaluateCaveat(simplified, context)
+
+		description := fmt.Sprintf("for context %v", context)
+		if originalErr != nil {
+			require.Error(t, simplifiedErr, description)
+			continue
+		}
+
+		require.NoError(t, simplifiedErr, description)
+		require.Equal(t, originalResult.IsPartial(), simplifiedResult.IsPartial(), description)
+		require.Equal(t, originalResult.Value(), simplifiedResult.Value(), description)
+	}
+}
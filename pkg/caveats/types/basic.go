@@ -3,7 +3,9 @@ package types
 import (
 	"encoding/base64"
 	"fmt"
+	"math"
 	"math/big"
+	"reflect"
 	"time"
 
 	"github.com/google/cel-go/cel"
@@ -17,15 +19,66 @@ func requireType[T any](value any) (any, error) {
 	return vle, nil
 }
 
+// asAnySlice returns the items of the given value, if it is a slice or an array of any item type.
+func asAnySlice(value any) ([]any, bool) {
+	if vle, ok := value.([]any); ok {
+		return vle, true
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	items := make([]any, 0, reflected.Len())
+	for index := 0; index < reflected.Len(); index++ {
+		items = append(items, reflected.Index(index).Interface())
+	}
+	return items, true
+}
+
+// asAnyMap returns the entries of the given value, if it is a map with string keys of any value
+// type.
+func asAnyMap(value any) (map[string]any, bool) {
+	if vle, ok := value.(map[string]any); ok {
+		return vle, true
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Map || reflected.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	entries := make(map[string]any, reflected.Len())
+	iter := reflected.MapRange()
+	for iter.Next() {
+		entries[iter.Key().String()] = iter.Value().Interface()
+	}
+	return entries, true
+}
+
 func convertNumericType[T int64 | uint64 | float64](value any) (any, error) {
 	directValue, ok := value.(T)
 	if ok {
 		return directValue, nil
 	}
 
-	floatValue, ok := value.(float64)
-	bigFloat := big.NewFloat(floatValue)
-	if !ok {
+	var bigFloat *big.Float
+	reflected := reflect.ValueOf(value)
+	switch {
+	case reflected.CanInt():
+		bigFloat = new(big.Float).SetInt64(reflected.Int())
+
+	case reflected.CanUint():
+		bigFloat = new(big.Float).SetUint64(reflected.Uint())
+
+	case reflected.CanFloat():
+		if math.IsNaN(reflected.Float()) {
+			return nil, fmt.Errorf("a %T value is required, but found NaN", *new(T))
+		}
+		bigFloat = big.NewFloat(reflected.Float())
+
+	default:
 		stringValue, ok := value.(string)
 		if !ok {
 			return nil, fmt.Errorf("a %T value is required, but found %T `%v`", *new(T), value, value)
@@ -127,7 +180,7 @@ var (
 				celType:    cel.ListType(childTypes[0].celType),
 				childTypes: childTypes,
 				converter: func(value any) (any, error) {
-					vle, ok := value.([]any)
+					vle, ok := asAnySlice(value)
 					if !ok {
 						return nil, fmt.Errorf("list requires a list, found: %T", value)
 					}
@@ -153,7 +206,7 @@ var (
 				celType:    cel.MapType(cel.StringType, childTypes[0].celType),
 				childTypes: childTypes,
 				converter: func(value any) (any, error) {
-					vle, ok := value.(map[string]any)
+					vle, ok := asAnyMap(value)
 					if !ok {
 						return nil, fmt.Errorf("map requires a map, found: %T", value)
 					}
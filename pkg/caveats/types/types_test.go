@@ -1,6 +1,7 @@
 package types
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -185,6 +186,69 @@ func TestConversion(t *testing.T) {
 			expectedValue: map[string]any{"foo": map[string]any{"bar": "hiya"}},
 			expectedErr:   "",
 		},
+		{
+			name:          "int to int",
+			vtype:         IntType,
+			inputValue:    42,
+			expectedValue: int64(42),
+			expectedErr:   "",
+		},
+		{
+			name:          "negative int to uint",
+			vtype:         UIntType,
+			inputValue:    -42,
+			expectedValue: nil,
+			expectedErr:   "for uint: a uint value is required, but found int64 value `-42`",
+		},
+		{
+			name:          "NaN to int",
+			vtype:         IntType,
+			inputValue:    math.NaN(),
+			expectedValue: nil,
+			expectedErr:   "for int: a int64 value is required, but found NaN",
+		},
+		{
+			name:          "typed slice to list<int>",
+			vtype:         ListType(IntType),
+			inputValue:    []int64{1, 42},
+			expectedValue: []any{int64(1), int64(42)},
+			expectedErr:   "",
+		},
+		{
+			name:          "typed slice with invalid item to list<int>",
+			vtype:         ListType(IntType),
+			inputValue:    []string{"1", "a"},
+			expectedValue: nil,
+			expectedErr:   "for list<int>: found an invalid value for item at index 1: for int: a int64 value is required, but found invalid string value `a`",
+		},
+		{
+			name:          "non-list to list<int>",
+			vtype:         ListType(IntType),
+			inputValue:    map[string]any{"foo": 1.0},
+			expectedValue: nil,
+			expectedErr:   "for list<int>: list requires a list, found: map[string]interface {}",
+		},
+		{
+			name:          "typed map to map<string>",
+			vtype:         MapType(StringType),
+			inputValue:    map[string]string{"foo": "bar"},
+			expectedValue: map[string]any{"foo": "bar"},
+			expectedErr:   "",
+		},
+		{
+			name:          "map with non-string keys to map<string>",
+			vtype:         MapType(StringType),
+			inputValue:    map[int]string{1: "bar"},
+			expectedValue: nil,
+			expectedErr:   "for map<string>: map requires a map, found: map[int]string",
+		},
+		{
+			name:          "list to map<string>",
+			vtype:         MapType(StringType),
+			inputValue:    []any{"bar"},
+			expectedValue: nil,
+			expectedErr:   "for map<string>: map requires a map, found: []interface {}",
+		},
 		{
 			name:  "valid bytes",
 			vtype: BytesType,
@@ -54,6 +54,12 @@ type Config struct {
 	ReadOnly               bool
 	EnableDatastoreMetrics bool
 	DisableStats           bool
+	RelationshipLimits     map[string]int64
+
+	// ValidateRelationshipTypes rejects writes of relationships whose subject types are not
+	// allowed by the schema. It is off by default so that existing data which does not conform to
+	// the schema is not blocked; the intent is to enable it by default in a future release.
+	ValidateRelationshipTypes bool
 
 	// Bootstrap
 	BootstrapFiles     []string
@@ -76,6 +82,13 @@ type Config struct {
 	HealthCheckPeriod  time.Duration
 	GCInterval         time.Duration
 	GCMaxOperationTime time.Duration
+	GCBatchSize        uint64
+	GCBatchDelay       time.Duration
+	TxRetryBackoff     time.Duration
+	StatementTimeout   time.Duration
+	LogSlowQueries     bool
+	SlowQueryThreshold time.Duration
+	ReadReplicaURI     string
 
 	// Spanner
 	SpannerCredentialsFile string
@@ -103,8 +116,16 @@ func RegisterDatastoreFlags(cmd *cobra.Command, opts *Config) {
 	cmd.Flags().DurationVar(&opts.GCWindow, "datastore-gc-window", 24*time.Hour, "amount of time before revisions are garbage collected")
 	cmd.Flags().DurationVar(&opts.GCInterval, "datastore-gc-interval", 3*time.Minute, "amount of time between passes of garbage collection (postgres driver only)")
 	cmd.Flags().DurationVar(&opts.GCMaxOperationTime, "datastore-gc-max-operation-time", 1*time.Minute, "maximum amount of time a garbage collection pass can operate before timing out (postgres driver only)")
+	cmd.Flags().Uint64Var(&opts.GCBatchSize, "datastore-gc-batch-size", 1000, "maximum number of rows deleted per statement during a garbage collection pass (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.GCBatchDelay, "datastore-gc-batch-delay", 0, "amount of time to wait between deletion batches during a garbage collection pass (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.StatementTimeout, "datastore-statement-timeout", 1*time.Minute, "maximum amount of time a query for relationships can run before it is canceled, or 0 for no limit (postgres driver only)")
+	cmd.Flags().BoolVar(&opts.LogSlowQueries, "datastore-log-slow-queries", false, "log queries for relationships which run for longer than the slow query threshold (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.SlowQueryThreshold, "datastore-slow-query-threshold", 1*time.Second, "amount of time a query for relationships must run for to be logged as slow, when slow query logging is enabled (postgres driver only)")
+	cmd.Flags().StringVar(&opts.ReadReplicaURI, "datastore-read-replica-conn-uri", "", "connection string of a read replica, which serves reads at revisions it has already replayed; reads made with minimize_latency consistency can be served by a replica lagging by less than the revision quantization interval (postgres driver only)")
 	cmd.Flags().DurationVar(&opts.RevisionQuantization, "datastore-revision-quantization-interval", 5*time.Second, "boundary interval to which to round the quantized revision")
 	cmd.Flags().BoolVar(&opts.ReadOnly, "datastore-readonly", false, "set the service to read-only mode")
+	cmd.Flags().StringToInt64Var(&opts.RelationshipLimits, "datastore-relationship-limits", map[string]int64{}, `maximum number of live relationships allowed per object definition (e.g. "document=100000"); definitions not listed are unlimited`)
+	cmd.Flags().BoolVar(&opts.ValidateRelationshipTypes, "datastore-validate-relationship-types", false, "reject relationship writes whose subject types are not allowed by the schema")
 	cmd.Flags().StringSliceVar(&opts.BootstrapFiles, "datastore-bootstrap-files", []string{}, "bootstrap data yaml files to load")
 	cmd.Flags().BoolVar(&opts.BootstrapOverwrite, "datastore-bootstrap-overwrite", false, "overwrite any existing data with bootstrap data")
 	cmd.Flags().DurationVar(&opts.BootstrapTimeout, "datastore-bootstrap-timeout", 10*time.Second, "maximum duration before timeout for the bootstrap data to be written")
@@ -117,6 +138,7 @@ func RegisterDatastoreFlags(cmd *cobra.Command, opts *Config) {
 	cmd.Flags().DurationVar(&opts.FollowerReadDelay, "datastore-follower-read-delay-duration", 4_800*time.Millisecond, "amount of time to subtract from non-sync revision timestamps to ensure they are sufficiently in the past to enable follower reads (cockroach driver only)")
 	cmd.Flags().Uint16Var(&opts.SplitQueryCount, "datastore-query-userset-batch-size", 1024, "number of usersets after which a relationship query will be split into multiple queries")
 	cmd.Flags().IntVar(&opts.MaxRetries, "datastore-max-tx-retries", 10, "number of times a retriable transaction should be retried")
+	cmd.Flags().DurationVar(&opts.TxRetryBackoff, "datastore-tx-retry-backoff", 10*time.Millisecond, "amount of time to wait before the first retry of a transaction which failed to serialize, doubled on each further retry (postgres driver only)")
 	cmd.Flags().StringVar(&opts.OverlapStrategy, "datastore-tx-overlap-strategy", "static", `strategy to generate transaction overlap keys ("prefix", "static", "insecure") (cockroach driver only)`)
 	cmd.Flags().StringVar(&opts.OverlapKey, "datastore-tx-overlap-key", "key", "static key to touch when writing to ensure transactions overlap (only used if --datastore-tx-overlap-strategy=static is set; cockroach driver only)")
 	cmd.Flags().StringVar(&opts.SpannerCredentialsFile, "datastore-spanner-credentials", "", "path to service account key credentials file with access to the cloud spanner instance (omit to use application default credentials)")
@@ -151,6 +173,10 @@ func DefaultDatastoreConfig() *Config {
 		HealthCheckPeriod:      30 * time.Second,
 		GCInterval:             3 * time.Minute,
 		GCMaxOperationTime:     1 * time.Minute,
+		GCBatchSize:            1000,
+		TxRetryBackoff:         10 * time.Millisecond,
+		StatementTimeout:       1 * time.Minute,
+		SlowQueryThreshold:     1 * time.Second,
 		WatchBufferLength:      128,
 		EnableDatastoreMetrics: true,
 		DisableStats:           false,
@@ -220,6 +246,24 @@ func NewDatastore(ctx context.Context, options ...ConfigOption) (datastore.Datas
 		)
 	}
 
+	if len(opts.RelationshipLimits) > 0 {
+		limits := make(map[string]uint64, len(opts.RelationshipLimits))
+		for nsName, limit := range opts.RelationshipLimits {
+			if limit < 0 {
+				return nil, fmt.Errorf("invalid relationship limit for object definition `%s`: %d", nsName, limit)
+			}
+			limits[nsName] = uint64(limit)
+		}
+
+		log.Info().Interface("limits", limits).Msg("enforcing relationship limits")
+		ds = proxy.NewRelationshipLimitDatastore(ds, limits)
+	}
+
+	if opts.ValidateRelationshipTypes {
+		log.Info().Msg("validating the subject types of written relationships")
+		ds = proxy.NewRelationshipTypeCheckingDatastore(ds)
+	}
+
 	if opts.ReadOnly {
 		log.Warn().Msg("setting the datastore to read-only")
 		ds = proxy.NewReadonlyDatastore(ds)
@@ -261,12 +305,22 @@ func newPostgresDatastore(opts Config) (datastore.Datastore, error) {
 		postgres.HealthCheckPeriod(opts.HealthCheckPeriod),
 		postgres.GCInterval(opts.GCInterval),
 		postgres.GCMaxOperationTime(opts.GCMaxOperationTime),
+		postgres.GCBatchSize(opts.GCBatchSize),
+		postgres.GCBatchDelay(opts.GCBatchDelay),
+		postgres.StatementTimeout(opts.StatementTimeout),
 		postgres.EnableTracing(),
 		postgres.WatchBufferLength(opts.WatchBufferLength),
 		postgres.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
 		postgres.MaxRetries(uint8(opts.MaxRetries)),
+		postgres.RetryBackoff(opts.TxRetryBackoff),
 		postgres.MigrationPhase(opts.MigrationPhase),
 	}
+	if opts.LogSlowQueries {
+		pgOpts = append(pgOpts, postgres.SlowQueryThreshold(opts.SlowQueryThreshold))
+	}
+	if opts.ReadReplicaURI != "" {
+		pgOpts = append(pgOpts, postgres.ReadReplicaConnURI(opts.ReadReplicaURI))
+	}
 	return postgres.NewPostgresDatastore(opts.URI, pgOpts...)
 }
 
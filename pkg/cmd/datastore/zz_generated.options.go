@@ -30,6 +30,8 @@ func (c *Config) ToOption() ConfigOption {
 		to.ReadOnly = c.ReadOnly
 		to.EnableDatastoreMetrics = c.EnableDatastoreMetrics
 		to.DisableStats = c.DisableStats
+		to.RelationshipLimits = c.RelationshipLimits
+		to.ValidateRelationshipTypes = c.ValidateRelationshipTypes
 		to.BootstrapFiles = c.BootstrapFiles
 		to.BootstrapOverwrite = c.BootstrapOverwrite
 		to.BootstrapTimeout = c.BootstrapTimeout
@@ -44,6 +46,13 @@ func (c *Config) ToOption() ConfigOption {
 		to.HealthCheckPeriod = c.HealthCheckPeriod
 		to.GCInterval = c.GCInterval
 		to.GCMaxOperationTime = c.GCMaxOperationTime
+		to.GCBatchSize = c.GCBatchSize
+		to.GCBatchDelay = c.GCBatchDelay
+		to.TxRetryBackoff = c.TxRetryBackoff
+		to.StatementTimeout = c.StatementTimeout
+		to.LogSlowQueries = c.LogSlowQueries
+		to.SlowQueryThreshold = c.SlowQueryThreshold
+		to.ReadReplicaURI = c.ReadReplicaURI
 		to.SpannerCredentialsFile = c.SpannerCredentialsFile
 		to.SpannerEmulatorHost = c.SpannerEmulatorHost
 		to.TablePrefix = c.TablePrefix
@@ -151,6 +160,27 @@ func WithDisableStats(disableStats bool) ConfigOption {
 	}
 }
 
+// WithRelationshipLimits returns an option that can append RelationshipLimitss to Config.RelationshipLimits
+func WithRelationshipLimits(key string, value int64) ConfigOption {
+	return func(c *Config) {
+		c.RelationshipLimits[key] = value
+	}
+}
+
+// SetRelationshipLimits returns an option that can set RelationshipLimits on a Config
+func SetRelationshipLimits(relationshipLimits map[string]int64) ConfigOption {
+	return func(c *Config) {
+		c.RelationshipLimits = relationshipLimits
+	}
+}
+
+// WithValidateRelationshipTypes returns an option that can set ValidateRelationshipTypes on a Config
+func WithValidateRelationshipTypes(validateRelationshipTypes bool) ConfigOption {
+	return func(c *Config) {
+		c.ValidateRelationshipTypes = validateRelationshipTypes
+	}
+}
+
 // WithBootstrapFiles returns an option that can append BootstrapFiless to Config.BootstrapFiles
 func WithBootstrapFiles(bootstrapFiles string) ConfigOption {
 	return func(c *Config) {
@@ -256,6 +286,55 @@ func WithGCMaxOperationTime(gCMaxOperationTime time.Duration) ConfigOption {
 	}
 }
 
+// WithGCBatchSize returns an option that can set GCBatchSize on a Config
+func WithGCBatchSize(gCBatchSize uint64) ConfigOption {
+	return func(c *Config) {
+		c.GCBatchSize = gCBatchSize
+	}
+}
+
+// WithGCBatchDelay returns an option that can set GCBatchDelay on a Config
+func WithGCBatchDelay(gCBatchDelay time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.GCBatchDelay = gCBatchDelay
+	}
+}
+
+// WithTxRetryBackoff returns an option that can set TxRetryBackoff on a Config
+func WithTxRetryBackoff(txRetryBackoff time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.TxRetryBackoff = txRetryBackoff
+	}
+}
+
+// WithStatementTimeout returns an option that can set StatementTimeout on a Config
+func WithStatementTimeout(statementTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.StatementTimeout = statementTimeout
+	}
+}
+
+// WithLogSlowQueries returns an option that can set LogSlowQueries on a Config
+func WithLogSlowQueries(logSlowQueries bool) ConfigOption {
+	return func(c *Config) {
+		c.LogSlowQueries = logSlowQueries
+	}
+}
+
+// WithSlowQueryThreshold returns an option that can set SlowQueryThreshold on a Config
+func WithSlowQueryThreshold(slowQueryThreshold time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.SlowQueryThreshold = slowQueryThreshold
+	}
+}
+
+// WithReadReplicaURI returns an option that can set ReadReplicaURI on a Config
+func WithReadReplicaURI(readReplicaURI string) ConfigOption {
+	return func(c *Config) {
+		c.ReadReplicaURI = readReplicaURI
+	}
+}
+
 // WithSpannerCredentialsFile returns an option that can set SpannerCredentialsFile on a Config
 func WithSpannerCredentialsFile(spannerCredentialsFile string) ConfigOption {
 	return func(c *Config) {
@@ -88,18 +88,28 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 	cmd.Flags().StringVar(&config.DispatchUpstreamAddr, "dispatch-upstream-addr", "", "upstream grpc address to dispatch to")
 	cmd.Flags().StringVar(&config.DispatchUpstreamCAPath, "dispatch-upstream-ca-path", "", "local path to the TLS CA used when connecting to the dispatch cluster")
 
+	cmd.Flags().StringSliceVar(&config.DispatchMaterializedRelations, "dispatch-materialized-relations", nil, "relations, of the form namespace#relation, whose resources are materialized into the dispatch cache to answer checks")
+	cmd.Flags().DurationVar(&config.DispatchMaterializedRelationsTTL, "dispatch-materialized-relations-ttl", 0, "time for which each materialized resource is cached, at most and defaulting to the datastore GC window")
+
 	cmd.Flags().Uint16Var(&config.GlobalDispatchConcurrencyLimit, "dispatch-concurrency-limit", 50, "maximum number of parallel goroutines to create for each request or subrequest")
 
 	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimits.Check, "dispatch-check-permission-concurrency-limit", 0, "maximum number of parallel goroutines to create for each check request or subrequest. defaults to --dispatch-concurrency-limit")
 	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimits.LookupResources, "dispatch-lookup-resources-concurrency-limit", 0, "maximum number of parallel goroutines to create for each lookup resources request or subrequest. defaults to --dispatch-concurrency-limit")
 	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimits.LookupSubjects, "dispatch-lookup-subjects-concurrency-limit", 0, "maximum number of parallel goroutines to create for each lookup subjects request or subrequest. defaults to --dispatch-concurrency-limit")
 	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimits.ReachableResources, "dispatch-reachable-resources-concurrency-limit", 0, "maximum number of parallel goroutines to create for each reachable resources request or subrequest. defaults to --dispatch-concurrency-limit")
+	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimits.MaxConcurrentRequests, "dispatch-max-concurrent-requests", 0, "maximum number of API requests evaluated concurrently by the dispatcher, with further requests queued until their deadline; subproblems are not counted. 0 means unbounded")
+	cmd.Flags().Uint32Var(&config.DispatchConcurrencyLimits.MaxReachableResourcesIntermediateResults, "dispatch-reachable-resources-max-intermediate-results", 0, "maximum number of relationships loaded by a single step of a reachable resources (lookup resources) request, failing the request if exceeded. 0 means unbounded")
 
 	// Flags for configuring API behavior
 	cmd.Flags().BoolVar(&config.DisableV1SchemaAPI, "disable-v1-schema-api", false, "disables the V1 schema API")
 	cmd.Flags().BoolVar(&config.DisableVersionResponse, "disable-version-response", false, "disables version response support in the API")
 	cmd.Flags().Uint16Var(&config.MaximumUpdatesPerWrite, "write-relationships-max-updates-per-call", 1000, "maximum number of updates allowed for WriteRelationships calls")
 	cmd.Flags().Uint16Var(&config.MaximumPreconditionCount, "update-relationships-max-preconditions-per-call", 1000, "maximum number of preconditions allowed for WriteRelationships and DeleteRelationships calls")
+	cmd.Flags().Uint32Var(&config.MaximumLookupResourcesResults, "lookup-resources-max-results", 0, "maximum number of resources returned by LookupResources calls, after which the results are marked as truncated (0 for no maximum)")
+	cmd.Flags().Uint32Var(&config.MaximumExpandLeafSubjects, "expand-max-leaf-subjects", 0, "maximum number of leaf subjects returned by ExpandPermissionTree calls, after which the call fails as exhausted (0 for no maximum)")
+	cmd.Flags().StringSliceVar(&config.WriteRelationshipsAllowedObjectTypes, "write-relationships-allowed-object-types", nil, "object types of the relationships which may be written by WriteRelationships calls (empty for all object types)")
+	cmd.Flags().StringVar(&config.WriteRelationshipsObjectIDPattern, "write-relationships-object-id-pattern", "", "regular expression which the object IDs of the relationships written by WriteRelationships calls must match, in place of the pattern of the API (empty for the pattern of the API)")
+	cmd.Flags().IntVar(&config.WriteRelationshipsMinimumObjectIDLength, "write-relationships-min-object-id-length", 0, "minimum length of the object IDs of the relationships written by WriteRelationships calls")
 
 	cmd.Flags().BoolVar(&config.V1SchemaAdditiveOnly, "testing-only-schema-additive-writes", false, "append new definitions to the existing schema, rather than overwriting it")
 	if err := cmd.Flags().MarkHidden("testing-only-schema-additive-writes"); err != nil {
@@ -119,6 +129,13 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 	if err := cmd.Flags().MarkDeprecated("experiment-enable-caveats", "this is an experiment"); err != nil {
 		panic("failed to mark flag deprecated: " + err.Error())
 	}
+
+	cmd.Flags().BoolVar(&config.WellKnownCaveatContextEnabled, "caveats-well-known-context", false, "if true, caveats evaluated by CheckPermission are provided the well-known context values, such as the request time as \"now\", unless given by the request")
+
+	cmd.Flags().BoolVar(&config.DisableCaveatSimplification, "debug-disable-caveat-simplification", false, "write caveat expressions exactly as given in schemas, rather than simplified")
+	if err := cmd.Flags().MarkHidden("debug-disable-caveat-simplification"); err != nil {
+		panic("failed to mark flag hidden: " + err.Error())
+	}
 }
 
 func NewServeCommand(programName string, config *server.Config) *cobra.Command {
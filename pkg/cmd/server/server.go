@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"regexp"
 	"sync"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/authzed/spicedb/internal/dashboard"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/admission"
 	clusterdispatch "github.com/authzed/spicedb/internal/dispatch/cluster"
 	combineddispatch "github.com/authzed/spicedb/internal/dispatch/combined"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
@@ -78,6 +80,14 @@ type Config struct {
 	DispatchCacheConfig        CacheConfig
 	ClusterDispatchCacheConfig CacheConfig
 
+	// DispatchMaterializedRelations are the relations, each of the form `namespace#relation`,
+	// whose resources are materialized into the dispatch cache to answer checks.
+	DispatchMaterializedRelations []string
+
+	// DispatchMaterializedRelationsTTL is the TTL of each materialized resource, defaulting to
+	// the GC window of the datastore, which it may not exceed.
+	DispatchMaterializedRelationsTTL time.Duration
+
 	// API Behavior
 	DisableV1SchemaAPI         bool
 	V1SchemaAdditiveOnly       bool
@@ -85,6 +95,39 @@ type Config struct {
 	MaximumPreconditionCount   uint16
 	ExperimentalCaveatsEnabled bool
 
+	// MaximumLookupResourcesResults is the maximum number of resources returned by a
+	// LookupResources call, or zero for no maximum.
+	MaximumLookupResourcesResults uint32
+
+	// MaximumExpandLeafSubjects is the maximum number of leaf subjects returned by an
+	// ExpandPermissionTree call, or zero for no maximum.
+	MaximumExpandLeafSubjects uint32
+
+	// RelationshipValidationPolicy, if not nil, validates the relationships written via
+	// WriteRelationships, in place of the rules of the API for object IDs. If nil, a policy is
+	// built from the WriteRelationships fields below, if any is set.
+	RelationshipValidationPolicy v1svc.RelationshipValidationPolicy
+
+	// WriteRelationshipsAllowedObjectTypes, if not empty, are the only object types of the
+	// relationships which may be written.
+	WriteRelationshipsAllowedObjectTypes []string
+
+	// WriteRelationshipsObjectIDPattern, if not empty, is the pattern the object IDs of the
+	// relationships written must match, in place of the pattern of the API.
+	WriteRelationshipsObjectIDPattern string
+
+	// WriteRelationshipsMinimumObjectIDLength is the minimum length of the object IDs of the
+	// relationships written.
+	WriteRelationshipsMinimumObjectIDLength int
+
+	// DisableCaveatSimplification writes caveat expressions as given in schemas, rather than
+	// simplified. Intended for debugging.
+	DisableCaveatSimplification bool
+
+	// WellKnownCaveatContextEnabled provides the well-known caveat context values, such as the
+	// request time, to the caveats evaluated by CheckPermission calls.
+	WellKnownCaveatContextEnabled bool
+
 	// Additional Services
 	DashboardAPI util.HTTPServerConfig
 	MetricsAPI   util.HTTPServerConfig
@@ -127,6 +170,11 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		log.Trace().Msg("using preconfigured auth function")
 	}
 
+	validationPolicy, err := c.relationshipValidationPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	ds := c.Datastore
 	if ds == nil {
 		var err error
@@ -165,6 +213,14 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		concurrencyLimits := specificConcurrencyLimits.WithOverallDefaultLimit(c.GlobalDispatchConcurrencyLimit)
 		log.Info().EmbedObject(concurrencyLimits).Msg("configured dispatch concurrency limits")
 
+		materializedRelationsTTL := c.DispatchMaterializedRelationsTTL
+		if materializedRelationsTTL == 0 {
+			materializedRelationsTTL = c.DatastoreConfig.GCWindow
+		}
+		if len(c.DispatchMaterializedRelations) > 0 && c.DatastoreConfig.GCWindow > 0 && materializedRelationsTTL > c.DatastoreConfig.GCWindow {
+			return nil, fmt.Errorf("materialized relations TTL %s exceeds the datastore GC window %s", materializedRelationsTTL, c.DatastoreConfig.GCWindow)
+		}
+
 		dispatcher, err = combineddispatch.NewDispatcher(
 			combineddispatch.UpstreamAddr(c.DispatchUpstreamAddr),
 			combineddispatch.UpstreamCAPath(c.DispatchUpstreamCAPath),
@@ -176,6 +232,7 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 			combineddispatch.PrometheusSubsystem(c.DispatchClientMetricsPrefix),
 			combineddispatch.Cache(cc),
 			combineddispatch.ConcurrencyLimits(concurrencyLimits),
+			combineddispatch.MaterializedRelations(c.DispatchMaterializedRelations, materializedRelationsTTL),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create dispatcher: %w", err)
@@ -239,19 +296,29 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 	}
 
 	if len(c.UnaryMiddleware) == 0 && len(c.StreamingMiddleware) == 0 {
-		c.UnaryMiddleware, c.StreamingMiddleware = DefaultMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds)
+		// Only the requests dispatched by the API are admitted: the subproblems they dispatch, locally
+		// or from other nodes, are evaluated without waiting for a slot held by the request itself.
+		apiDispatcher := admission.NewAdmittingDispatcher(dispatcher, c.DispatchConcurrencyLimits.MaxConcurrentRequests)
+		c.UnaryMiddleware, c.StreamingMiddleware = DefaultMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, apiDispatcher, ds)
 	}
 
 	permSysConfig := v1svc.PermissionsServerConfig{
-		MaxPreconditionsCount: c.MaximumPreconditionCount,
-		MaxUpdatesPerWrite:    c.MaximumUpdatesPerWrite,
-		MaximumAPIDepth:       c.DispatchMaxDepth,
+		MaxPreconditionsCount:        c.MaximumPreconditionCount,
+		MaxUpdatesPerWrite:           c.MaximumUpdatesPerWrite,
+		MaximumAPIDepth:              c.DispatchMaxDepth,
+		MaxLookupResourcesResults:    c.MaximumLookupResourcesResults,
+		MaxExpandLeafSubjects:        c.MaximumExpandLeafSubjects,
+		RelationshipValidationPolicy: validationPolicy,
+		WellKnownCaveatContext:       c.WellKnownCaveatContextEnabled,
 	}
 
 	caveatsOption := services.CaveatsDisabled
 	if c.ExperimentalCaveatsEnabled {
 		log.Warn().Msg("experimental caveats support enabled")
 		caveatsOption = services.CaveatsEnabled
+		if c.DisableCaveatSimplification {
+			caveatsOption = services.CaveatsEnabledWithoutSimplification
+		}
 	}
 
 	healthManager := health.NewHealthManager(dispatcher, ds)
@@ -345,6 +412,34 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 	}, nil
 }
 
+// relationshipValidationPolicy returns the RelationshipValidationPolicy of the config, building an
+// ObjectValidationPolicy from the WriteRelationships fields if none is given and any of them is
+// set.
+func (c *Config) relationshipValidationPolicy() (v1svc.RelationshipValidationPolicy, error) {
+	if c.RelationshipValidationPolicy != nil {
+		return c.RelationshipValidationPolicy, nil
+	}
+
+	if len(c.WriteRelationshipsAllowedObjectTypes) == 0 && c.WriteRelationshipsObjectIDPattern == "" && c.WriteRelationshipsMinimumObjectIDLength == 0 {
+		return nil, nil
+	}
+
+	policy := v1svc.ObjectValidationPolicy{
+		AllowedObjectTypes:    c.WriteRelationshipsAllowedObjectTypes,
+		MinimumObjectIDLength: c.WriteRelationshipsMinimumObjectIDLength,
+	}
+
+	if c.WriteRelationshipsObjectIDPattern != "" {
+		pattern, err := regexp.Compile(c.WriteRelationshipsObjectIDPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object ID pattern for written relationships: %w", err)
+		}
+		policy.ObjectIDPattern = pattern
+	}
+
+	return policy, nil
+}
+
 // initializeGateway Configures the gateway to serve HTTP
 func (c *Config) initializeGateway(ctx context.Context) (util.RunnableHTTPServer, io.Closer, error) {
 	if len(c.HTTPGatewayUpstreamAddr) == 0 {
@@ -4,6 +4,7 @@ package server
 import (
 	dispatch "github.com/authzed/spicedb/internal/dispatch"
 	graph "github.com/authzed/spicedb/internal/dispatch/graph"
+	v1 "github.com/authzed/spicedb/internal/services/v1"
 	datastore "github.com/authzed/spicedb/pkg/cmd/datastore"
 	util "github.com/authzed/spicedb/pkg/cmd/util"
 	datastore1 "github.com/authzed/spicedb/pkg/datastore"
@@ -51,11 +52,21 @@ func (c *Config) ToOption() ConfigOption {
 		to.Dispatcher = c.Dispatcher
 		to.DispatchCacheConfig = c.DispatchCacheConfig
 		to.ClusterDispatchCacheConfig = c.ClusterDispatchCacheConfig
+		to.DispatchMaterializedRelations = c.DispatchMaterializedRelations
+		to.DispatchMaterializedRelationsTTL = c.DispatchMaterializedRelationsTTL
 		to.DisableV1SchemaAPI = c.DisableV1SchemaAPI
 		to.V1SchemaAdditiveOnly = c.V1SchemaAdditiveOnly
 		to.MaximumUpdatesPerWrite = c.MaximumUpdatesPerWrite
 		to.MaximumPreconditionCount = c.MaximumPreconditionCount
 		to.ExperimentalCaveatsEnabled = c.ExperimentalCaveatsEnabled
+		to.MaximumLookupResourcesResults = c.MaximumLookupResourcesResults
+		to.MaximumExpandLeafSubjects = c.MaximumExpandLeafSubjects
+		to.RelationshipValidationPolicy = c.RelationshipValidationPolicy
+		to.WriteRelationshipsAllowedObjectTypes = c.WriteRelationshipsAllowedObjectTypes
+		to.WriteRelationshipsObjectIDPattern = c.WriteRelationshipsObjectIDPattern
+		to.WriteRelationshipsMinimumObjectIDLength = c.WriteRelationshipsMinimumObjectIDLength
+		to.DisableCaveatSimplification = c.DisableCaveatSimplification
+		to.WellKnownCaveatContextEnabled = c.WellKnownCaveatContextEnabled
 		to.DashboardAPI = c.DashboardAPI
 		to.MetricsAPI = c.MetricsAPI
 		to.UnaryMiddleware = c.UnaryMiddleware
@@ -266,6 +277,27 @@ func WithClusterDispatchCacheConfig(clusterDispatchCacheConfig CacheConfig) Conf
 	}
 }
 
+// WithDispatchMaterializedRelations returns an option that can append DispatchMaterializedRelationss to Config.DispatchMaterializedRelations
+func WithDispatchMaterializedRelations(dispatchMaterializedRelations string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchMaterializedRelations = append(c.DispatchMaterializedRelations, dispatchMaterializedRelations)
+	}
+}
+
+// SetDispatchMaterializedRelations returns an option that can set DispatchMaterializedRelations on a Config
+func SetDispatchMaterializedRelations(dispatchMaterializedRelations []string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchMaterializedRelations = dispatchMaterializedRelations
+	}
+}
+
+// WithDispatchMaterializedRelationsTTL returns an option that can set DispatchMaterializedRelationsTTL on a Config
+func WithDispatchMaterializedRelationsTTL(dispatchMaterializedRelationsTTL time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchMaterializedRelationsTTL = dispatchMaterializedRelationsTTL
+	}
+}
+
 // WithDisableV1SchemaAPI returns an option that can set DisableV1SchemaAPI on a Config
 func WithDisableV1SchemaAPI(disableV1SchemaAPI bool) ConfigOption {
 	return func(c *Config) {
@@ -301,6 +333,69 @@ func WithExperimentalCaveatsEnabled(experimentalCaveatsEnabled bool) ConfigOptio
 	}
 }
 
+// WithMaximumLookupResourcesResults returns an option that can set MaximumLookupResourcesResults on a Config
+func WithMaximumLookupResourcesResults(maximumLookupResourcesResults uint32) ConfigOption {
+	return func(c *Config) {
+		c.MaximumLookupResourcesResults = maximumLookupResourcesResults
+	}
+}
+
+// WithMaximumExpandLeafSubjects returns an option that can set MaximumExpandLeafSubjects on a Config
+func WithMaximumExpandLeafSubjects(maximumExpandLeafSubjects uint32) ConfigOption {
+	return func(c *Config) {
+		c.MaximumExpandLeafSubjects = maximumExpandLeafSubjects
+	}
+}
+
+// WithRelationshipValidationPolicy returns an option that can set RelationshipValidationPolicy on a Config
+func WithRelationshipValidationPolicy(relationshipValidationPolicy v1.RelationshipValidationPolicy) ConfigOption {
+	return func(c *Config) {
+		c.RelationshipValidationPolicy = relationshipValidationPolicy
+	}
+}
+
+// WithWriteRelationshipsAllowedObjectTypes returns an option that can append WriteRelationshipsAllowedObjectTypess to Config.WriteRelationshipsAllowedObjectTypes
+func WithWriteRelationshipsAllowedObjectTypes(writeRelationshipsAllowedObjectTypes string) ConfigOption {
+	return func(c *Config) {
+		c.WriteRelationshipsAllowedObjectTypes = append(c.WriteRelationshipsAllowedObjectTypes, writeRelationshipsAllowedObjectTypes)
+	}
+}
+
+// SetWriteRelationshipsAllowedObjectTypes returns an option that can set WriteRelationshipsAllowedObjectTypes on a Config
+func SetWriteRelationshipsAllowedObjectTypes(writeRelationshipsAllowedObjectTypes []string) ConfigOption {
+	return func(c *Config) {
+		c.WriteRelationshipsAllowedObjectTypes = writeRelationshipsAllowedObjectTypes
+	}
+}
+
+// WithWriteRelationshipsObjectIDPattern returns an option that can set WriteRelationshipsObjectIDPattern on a Config
+func WithWriteRelationshipsObjectIDPattern(writeRelationshipsObjectIDPattern string) ConfigOption {
+	return func(c *Config) {
+		c.WriteRelationshipsObjectIDPattern = writeRelationshipsObjectIDPattern
+	}
+}
+
+// WithWriteRelationshipsMinimumObjectIDLength returns an option that can set WriteRelationshipsMinimumObjectIDLength on a Config
+func WithWriteRelationshipsMinimumObjectIDLength(writeRelationshipsMinimumObjectIDLength int) ConfigOption {
+	return func(c *Config) {
+		c.WriteRelationshipsMinimumObjectIDLength = writeRelationshipsMinimumObjectIDLength
+	}
+}
+
+// WithDisableCaveatSimplification returns an option that can set DisableCaveatSimplification on a Config
+func WithDisableCaveatSimplification(disableCaveatSimplification bool) ConfigOption {
+	return func(c *Config) {
+		c.DisableCaveatSimplification = disableCaveatSimplification
+	}
+}
+
+// WithWellKnownCaveatContextEnabled returns an option that can set WellKnownCaveatContextEnabled on a Config
+func WithWellKnownCaveatContextEnabled(wellKnownCaveatContextEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.WellKnownCaveatContextEnabled = wellKnownCaveatContextEnabled
+	}
+}
+
 // WithDashboardAPI returns an option that can set DashboardAPI on a Config
 func WithDashboardAPI(dashboardAPI util.HTTPServerConfig) ConfigOption {
 	return func(c *Config) {
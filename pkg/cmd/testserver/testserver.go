@@ -20,6 +20,7 @@ import (
 	"github.com/authzed/spicedb/internal/services/health"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/pkg/cmd/util"
+	"github.com/authzed/spicedb/pkg/datastore"
 )
 
 const maxDepth = 50
@@ -47,6 +48,10 @@ func (dr datastoreReady) IsReady(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+func (dr datastoreReady) HealthCheck(ctx context.Context) datastore.HealthCheckResult {
+	return datastore.Healthy()
+}
+
 func (c *Config) Complete() (RunnableTestServer, error) {
 	dispatcher := graph.NewLocalOnlyDispatcher(10)
 
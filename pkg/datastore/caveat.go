@@ -3,6 +3,7 @@ package datastore
 import (
 	"context"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
@@ -27,3 +28,26 @@ type CaveatStorer interface {
 	// DeleteCaveats deletes the provided caveats by name
 	DeleteCaveats(ctx context.Context, names []string) error
 }
+
+// CaveatPageLister is implemented by readers which can filter and paginate the caveats they list
+// as part of reading them. See ListCaveatsWithOptions.
+type CaveatPageLister interface {
+	// ListCaveatsWithOptions returns the caveats matching the options, ordered by name.
+	ListCaveatsWithOptions(ctx context.Context, opts ...options.ListCaveatsOptionsOption) ([]*core.CaveatDefinition, error)
+}
+
+// ListCaveatsWithOptions returns the caveats of the reader matching the options, filtered by name
+// prefix and paginated as ListNamespaces does for namespaces, ordered by name. Readers which
+// implement CaveatPageLister apply the options while reading; for all others, all of the caveats
+// are read and the options applied to them.
+func ListCaveatsWithOptions(ctx context.Context, reader CaveatReader, opts ...options.ListCaveatsOptionsOption) ([]*core.CaveatDefinition, error) {
+	if lister, ok := reader.(CaveatPageLister); ok {
+		return lister.ListCaveatsWithOptions(ctx, opts...)
+	}
+
+	caveatDefs, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return options.NewListCaveatsOptionsWithOptions(opts...).Apply(caveatDefs), nil
+}
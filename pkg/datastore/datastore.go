@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 
@@ -44,6 +45,48 @@ const FilterMaximumIDCount = 100
 type RevisionChanges struct {
 	Revision Revision
 	Changes  []*core.RelationTupleUpdate
+
+	// ChangedDefinitions are the namespace and caveat definitions written in the transaction,
+	// whether created or updated.
+	ChangedDefinitions []SchemaDefinition
+
+	// DeletedNamespaces are the names of the namespaces deleted in the transaction.
+	DeletedNamespaces []string
+
+	// DeletedCaveats are the names of the caveats deleted in the transaction.
+	DeletedCaveats []string
+
+	// IsCheckpoint is true if the changes are a checkpoint rather than a transaction: they are
+	// empty, and indicate that all changes through the revision have already been emitted. A
+	// watch emits checkpoints during quiet periods, so that consumers can advance past them.
+	IsCheckpoint bool
+}
+
+// HasSchemaChanges returns whether any namespace or caveat definition was written or deleted in
+// the transaction.
+func (rc RevisionChanges) HasSchemaChanges() bool {
+	return len(rc.ChangedDefinitions) > 0 || len(rc.DeletedNamespaces) > 0 || len(rc.DeletedCaveats) > 0
+}
+
+// SchemaDefinition is a definition found in a schema: either a *core.NamespaceDefinition or a
+// *core.CaveatDefinition.
+type SchemaDefinition interface {
+	GetName() string
+}
+
+// RevisionDiff represents the relationships which changed between two revisions.
+//
+// A relationship whose caveat was changed between the revisions is found in both Deleted, in its
+// earlier form, and Created, in its later form; applying Deleted before Created to the
+// relationships live at the earlier revision yields those live at the later revision.
+type RevisionDiff struct {
+	// Created are the relationships live at the later revision which were not live, in the same
+	// form, at the earlier revision.
+	Created []*core.RelationTuple
+
+	// Deleted are the relationships live at the earlier revision which are no longer live, in the
+	// same form, at the later revision.
+	Deleted []*core.RelationTuple
 }
 
 // RelationshipsFilter is a filter for relationships.
@@ -54,6 +97,10 @@ type RelationshipsFilter struct {
 	// OptionalResourceIds are the IDs of the resources to find. If nil empty, any resource ID will be allowed.
 	OptionalResourceIds []string
 
+	// OptionalResourceIDPrefix, if not empty, is a prefix which the IDs of the resources to find
+	// must start with. It is matched in addition to, rather than instead of, OptionalResourceIds.
+	OptionalResourceIDPrefix string
+
 	// OptionalResourceRelation is the relation of the resource to find. If empty, any relation is allowed.
 	OptionalResourceRelation string
 
@@ -113,6 +160,10 @@ type SubjectsFilter struct {
 	// OptionalSubjectIds are the IDs of the subjects to find. If nil or empty, any subject ID will be allowed.
 	OptionalSubjectIds []string
 
+	// IncludeWildcardSubjects, if true, indicates that relationships whose subject is the wildcard
+	// of the subject type should also be found when OptionalSubjectIds is specified.
+	IncludeWildcardSubjects bool
+
 	// RelationFilter is the filter to use for the relation(s) of the subjects. If neither field
 	// is set, any relation is allowed.
 	RelationFilter SubjectRelationFilter
@@ -157,6 +208,14 @@ type Reader interface {
 		options ...options.QueryOptionsOption,
 	) (RelationshipIterator, error)
 
+	// QueryRelationshipsForResourceTypes reads all relationships whose resource type is any of
+	// those given, as a single query. At least one resource type must be specified.
+	QueryRelationshipsForResourceTypes(
+		ctx context.Context,
+		resourceTypes []string,
+		options ...options.QueryOptionsOption,
+	) (RelationshipIterator, error)
+
 	// ReverseQueryRelationships reads relationships, starting from the subject.
 	ReverseQueryRelationships(
 		ctx context.Context,
@@ -168,10 +227,13 @@ type Reader interface {
 	// last written. It returns an instance of ErrNamespaceNotFound if not found.
 	ReadNamespace(ctx context.Context, nsName string) (ns *core.NamespaceDefinition, lastWritten Revision, err error)
 
-	// ListNamespaces lists all namespaces defined.
-	ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error)
+	// ListNamespaces lists the namespaces defined, ordered by name. By default, all namespaces
+	// are returned; the options can filter them by name prefix and paginate them.
+	ListNamespaces(ctx context.Context, options ...options.ListNamespacesOptionsOption) ([]*core.NamespaceDefinition, error)
 
-	// LookupNamespaces finds all namespaces with the matching names.
+	// LookupNamespaces finds all namespaces with the matching names. Names for which no namespace
+	// is found are omitted from the result, rather than reported with an error, so the missing
+	// names are those of the given names without a namespace in the result.
 	LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error)
 }
 
@@ -185,13 +247,39 @@ type ReadWriteTransaction interface {
 	// DeleteRelationships deletes all Relationships that match the provided filter.
 	DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error
 
+	// DeleteRelationshipsForSubject deletes all live relationships, of any resource type, whose
+	// subject is exactly the given subject, returning the number of relationships deleted.
+	DeleteRelationshipsForSubject(ctx context.Context, subject *core.ObjectAndRelation) (uint64, error)
+
+	// RewriteRelationshipsSubject repoints all live relationships, of any resource type, whose
+	// subject is exactly oldSubject to newSubject, returning the number of relationships of
+	// oldSubject which were repointed. Where newSubject already has the same relationship, the
+	// relationship of oldSubject is removed rather than duplicated.
+	RewriteRelationshipsSubject(ctx context.Context, oldSubject, newSubject *core.ObjectAndRelation) (uint64, error)
+
 	// WriteNamespaces takes proto namespace definitions and persists them.
 	WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error
 
-	// DeleteNamespaces deletes namespaces including associated relationships.
-	DeleteNamespaces(ctx context.Context, nsNames ...string) error
+	// DeleteNamespaces deletes namespaces. The delOption determines whether relationships that
+	// reference the namespaces, either as resource or as subject, are deleted alongside them or
+	// cause the deletion to be refused.
+	DeleteNamespaces(ctx context.Context, delOption DeleteNamespacesRelationshipsOption, nsNames ...string) error
 }
 
+// DeleteNamespacesRelationshipsOption is an option for how DeleteNamespaces handles the
+// relationships that reference the namespaces being deleted.
+type DeleteNamespacesRelationshipsOption int
+
+const (
+	// DeleteNamespacesOnlyIfEmpty refuses to delete a namespace if any live relationship has a
+	// resource or subject of that namespace, returning an ErrNamespaceHasRelationships.
+	DeleteNamespacesOnlyIfEmpty DeleteNamespacesRelationshipsOption = iota
+
+	// DeleteNamespacesAndRelationships deletes, in the same transaction, all live relationships
+	// whose resource or subject is of a deleted namespace.
+	DeleteNamespacesAndRelationships
+)
+
 // TxUserFunc is a type for the function that users supply when they invoke a read-write transaction.
 type TxUserFunc func(ReadWriteTransaction) error
 
@@ -203,7 +291,7 @@ type Datastore interface {
 
 	// ReadWriteTx tarts a read/write transaction, which will be committed if no error is
 	// returned and rolled back if an error is returned.
-	ReadWriteTx(context.Context, TxUserFunc) (Revision, error)
+	ReadWriteTx(context.Context, TxUserFunc, ...options.RWTOptionsOption) (Revision, error)
 
 	// OptimizedRevision gets a revision that will likely already be replicated
 	// and will likely be shared amongst many queries.
@@ -217,20 +305,36 @@ type Datastore interface {
 	// hasn't been garbage collected.
 	CheckRevision(ctx context.Context, revision Revision) error
 
+	// RevisionAtTime returns the latest revision committed at or before the specified
+	// point in time. If the point in time falls before the garbage collection window,
+	// ErrTimestampBeforeGCWindow is returned.
+	RevisionAtTime(ctx context.Context, at time.Time) (Revision, error)
+
 	// RevisionFromString will parse the revision text and return the specific type of Revision
 	// used by the specific datastore implementation.
 	RevisionFromString(serialized string) (Revision, error)
 
 	// Watch notifies the caller about all changes to tuples.
 	//
-	// All events following afterRevision will be sent to the caller.
+	// All events following afterRevision will be sent to the caller, interleaved with
+	// checkpoints during quiet periods. Checkpoints may be dropped if the caller falls behind.
 	Watch(ctx context.Context, afterRevision Revision) (<-chan *RevisionChanges, <-chan error)
 
+	// DiffRevisions returns the relationships created and deleted after startRevision, up to and
+	// including endRevision. Both revisions must be within the garbage collection window, or
+	// ErrInvalidRevision is returned. Datastores which cannot compute the changes return
+	// ErrRevisionDiffUnsupported.
+	DiffRevisions(ctx context.Context, startRevision, endRevision Revision) (*RevisionDiff, error)
+
 	// IsReady returns whether the datastore is ready to accept data. Datastores that require
 	// database schema creation will return false until the migrations have been run to create
 	// the necessary tables.
 	IsReady(ctx context.Context) (bool, error)
 
+	// HealthCheck checks that the backing store of the datastore can be reached with a cheap
+	// operation, returning whether it is healthy, reachable but degraded, or unreachable.
+	HealthCheck(ctx context.Context) HealthCheckResult
+
 	// Features returns an object representing what features this
 	// datastore can support.
 	Features(ctx context.Context) (*Features, error)
@@ -242,6 +346,56 @@ type Datastore interface {
 	Close() error
 }
 
+// HealthStatus is the health of the backing store of a datastore.
+type HealthStatus int
+
+const (
+	// HealthStatusHealthy indicates that the backing store is reachable and operating normally.
+	HealthStatusHealthy HealthStatus = iota
+
+	// HealthStatusDegraded indicates that the backing store is reachable, but may be unable to
+	// serve requests promptly.
+	HealthStatusDegraded
+
+	// HealthStatusUnreachable indicates that the backing store could not be reached.
+	HealthStatusUnreachable
+)
+
+func (hs HealthStatus) String() string {
+	switch hs {
+	case HealthStatusHealthy:
+		return "healthy"
+	case HealthStatusDegraded:
+		return "degraded"
+	case HealthStatusUnreachable:
+		return "unreachable"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(hs))
+	}
+}
+
+// HealthCheckResult is the result of a datastore health check, plus an optional message
+// explaining why the datastore is not healthy.
+type HealthCheckResult struct {
+	Status HealthStatus
+	Reason string
+}
+
+// Healthy returns a health check result indicating the datastore is healthy.
+func Healthy() HealthCheckResult {
+	return HealthCheckResult{Status: HealthStatusHealthy}
+}
+
+// Degraded returns a health check result indicating the datastore is reachable but degraded.
+func Degraded(reason string) HealthCheckResult {
+	return HealthCheckResult{Status: HealthStatusDegraded, Reason: reason}
+}
+
+// Unreachable returns a health check result indicating the datastore could not be reached.
+func Unreachable(err error) HealthCheckResult {
+	return HealthCheckResult{Status: HealthStatusUnreachable, Reason: err.Error()}
+}
+
 // Feature represents a capability that a datastore can support, plus an
 // optional message explaining the feature is available (or not).
 type Feature struct {
@@ -253,6 +407,10 @@ type Feature struct {
 type Features struct {
 	// Watch is enabled if the underlying datastore can support the Watch api.
 	Watch Feature
+
+	// WatchSchema is enabled if the changes reported by Watch include the changes made to
+	// namespace and caveat definitions.
+	WatchSchema Feature
 }
 
 // ObjectTypeStat represents statistics for a single object type (namespace).
@@ -279,6 +437,198 @@ type Stats struct {
 	ObjectTypeStatistics []ObjectTypeStat
 }
 
+// PoolStats represents the current state of the connection pool of a datastore.
+type PoolStats struct {
+	// AcquiredConns is the number of connections currently in use.
+	AcquiredConns int32
+
+	// IdleConns is the number of connections open but not in use.
+	IdleConns int32
+
+	// TotalConns is the number of connections open, including those still being established.
+	TotalConns int32
+
+	// MaxConns is the maximum size of the pool, or zero if unbounded.
+	MaxConns int32
+}
+
+// Saturated returns whether every connection the pool may open is in use, in which case
+// requests must wait for a connection to be released.
+func (ps PoolStats) Saturated() bool {
+	return ps.MaxConns > 0 && ps.AcquiredConns >= ps.MaxConns
+}
+
+// PoolStatsReporter is implemented by datastores backed by a connection pool, and reports the
+// current state of that pool, e.g. for metrics and alerting on pool exhaustion.
+type PoolStatsReporter interface {
+	// PoolStats returns the current state of the connection pool.
+	PoolStats() PoolStats
+}
+
+// PoolStatsOf returns the current state of the connection pool of the given datastore, and
+// whether the datastore reports it. Datastore proxies implement PoolStatsReporter by forwarding
+// to their delegate, and report the zero PoolStats if the delegate does not.
+func PoolStatsOf(ds Datastore) (PoolStats, bool) {
+	if reporter, ok := ds.(PoolStatsReporter); ok {
+		return reporter.PoolStats(), true
+	}
+	return PoolStats{}, false
+}
+
+// ConsistencyValidator is implemented by datastores which can validate that their stored
+// relationships uphold the invariants of the datastore. It scans every relationship and is
+// intended for tests, such as asserting the state of a datastore after a sequence of random
+// writes.
+type ConsistencyValidator interface {
+	// ValidateConsistency scans the datastore and returns an ErrConsistencyViolations
+	// describing every relationship found violating an invariant, or nil if none were found.
+	ValidateConsistency(ctx context.Context) error
+}
+
+// ValidateConsistency validates the consistency of the given datastore, as described by
+// ConsistencyValidator. Datastore proxies implement ConsistencyValidator by forwarding to their
+// delegate, and return an ErrConsistencyValidationUnsupported if the delegate cannot validate
+// its consistency.
+func ValidateConsistency(ctx context.Context, ds Datastore) error {
+	if validator, ok := ds.(ConsistencyValidator); ok {
+		return validator.ValidateConsistency(ctx)
+	}
+	return NewConsistencyValidationUnsupportedErr()
+}
+
+// ConsistencyViolation is a relationship found by ValidateConsistency violating an invariant of
+// the datastore.
+type ConsistencyViolation struct {
+	// Relationship is the relationship violating the invariant.
+	Relationship *core.RelationTuple
+
+	// Reason describes the invariant violated.
+	Reason string
+}
+
+// RelationshipHistoryReader is implemented by datastores which retain the versions of a
+// relationship until they are garbage collected, and can return them for auditing when a
+// relationship was created and deleted over time.
+type RelationshipHistoryReader interface {
+	// RelationshipHistory returns every version of the relationship with the same resource,
+	// relation and subject as the given tuple which is still retained within the garbage
+	// collection window, ordered by the revision at which each was created. The caveat of the
+	// tuple is ignored.
+	RelationshipHistory(ctx context.Context, tpl *core.RelationTuple) ([]RelationshipVersion, error)
+}
+
+// RelationshipHistory returns the versions of the relationship retained by the datastore, as
+// described by RelationshipHistoryReader. Datastore proxies implement RelationshipHistoryReader
+// by forwarding to their delegate, and return an ErrRelationshipHistoryUnsupported if the
+// delegate does not retain relationship history.
+func RelationshipHistory(ctx context.Context, ds Datastore, tpl *core.RelationTuple) ([]RelationshipVersion, error) {
+	if reader, ok := ds.(RelationshipHistoryReader); ok {
+		return reader.RelationshipHistory(ctx, tpl)
+	}
+	return nil, NewRelationshipHistoryUnsupportedErr()
+}
+
+// RelationshipVersion is a single version of a relationship, live from the revision which
+// created it until the revision which deleted it.
+type RelationshipVersion struct {
+	// Relationship is the relationship as written by the creating revision, including its caveat.
+	Relationship *core.RelationTuple
+
+	// CreatedAt is the revision at which the version was written.
+	CreatedAt Revision
+
+	// DeletedAt is the revision at which the version was deleted or replaced, or NoRevision if
+	// the version is still live.
+	DeletedAt Revision
+}
+
+// RelationshipRevisionsReader is implemented by readers which can return the revision at which
+// each relationship was written alongside the relationship itself. Reading the revisions widens
+// the query, so they are only read when requested through this interface.
+type RelationshipRevisionsReader interface {
+	// QueryRelationshipsWithRevisions reads the relationships matching the filter as
+	// QueryRelationships does, returning each as a RelationshipVersion. CreatedAt is the revision
+	// at which the relationship was written. DeletedAt is the revision at which it was later
+	// deleted or replaced, if the reader is reading a past revision and the datastore retains
+	// it, and NoRevision otherwise.
+	QueryRelationshipsWithRevisions(
+		ctx context.Context,
+		filter RelationshipsFilter,
+		options ...options.QueryOptionsOption,
+	) ([]RelationshipVersion, error)
+}
+
+// QueryRelationshipsWithRevisions reads the relationships matching the filter along with their
+// revisions, as described by RelationshipRevisionsReader. The readers of datastore proxies
+// implement RelationshipRevisionsReader by forwarding to their delegate, and return an
+// ErrRelationshipRevisionsUnsupported if the delegate cannot read the revisions.
+func QueryRelationshipsWithRevisions(
+	ctx context.Context,
+	reader Reader,
+	filter RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) ([]RelationshipVersion, error) {
+	if revisionsReader, ok := reader.(RelationshipRevisionsReader); ok {
+		return revisionsReader.QueryRelationshipsWithRevisions(ctx, filter, opts...)
+	}
+	return nil, NewRelationshipRevisionsUnsupportedErr()
+}
+
+// RelationshipExistenceChecker is implemented by readers which can check whether a single
+// relationship exists more cheaply than by querying for it. See RelationshipExists.
+type RelationshipExistenceChecker interface {
+	// RelationshipExists returns whether a live relationship with the same resource, relation and
+	// subject as the given tuple exists as of the reader's revision. The caveat of the tuple is
+	// ignored.
+	RelationshipExists(ctx context.Context, tpl *core.RelationTuple) (bool, error)
+}
+
+// NamespaceRelationshipsChecker is implemented by readers which can check whether any relationship
+// references a namespace more cheaply than by querying for it. See NamespaceHasRelationships.
+type NamespaceRelationshipsChecker interface {
+	// NamespaceHasRelationships returns whether any live relationship has a resource or subject
+	// of the namespace as of the reader's revision, stopping at the first found.
+	NamespaceHasRelationships(ctx context.Context, nsName string) (bool, error)
+}
+
+// RelationshipUpdateResult is the change in the stored relationships which resulted from applying
+// a single relationship update.
+type RelationshipUpdateResult int
+
+const (
+	// RelationshipUnchanged indicates that the update did not create or delete a relationship:
+	// either a TOUCH of a relationship which already existed, or a DELETE of one which did not.
+	RelationshipUnchanged RelationshipUpdateResult = iota
+
+	// RelationshipCreated indicates that the update created a relationship which did not exist.
+	RelationshipCreated
+
+	// RelationshipDeleted indicates that the update deleted a relationship which existed.
+	RelationshipDeleted
+)
+
+// String returns the lowercase name of the result.
+func (r RelationshipUpdateResult) String() string {
+	switch r {
+	case RelationshipCreated:
+		return "created"
+	case RelationshipDeleted:
+		return "deleted"
+	default:
+		return "unchanged"
+	}
+}
+
+// RelationshipUpdateReporter is implemented by read-write transactions which can report the
+// change resulting from each relationship update as part of writing them. See
+// WriteRelationshipsWithResults.
+type RelationshipUpdateReporter interface {
+	// WriteRelationshipsWithResults behaves as WriteRelationships, and additionally returns the
+	// result of each mutation, in the order given. A TOUCH of a relationship which already
+	// exists is reported as unchanged, even if its caveat or expiration was replaced.
+	WriteRelationshipsWithResults(ctx context.Context, mutations []*core.RelationTupleUpdate) ([]RelationshipUpdateResult, error)
+}
+
 // RelationshipIterator is an iterator over matched tuples.
 type RelationshipIterator interface {
 	// Next returns the next tuple in the result set.
@@ -307,6 +657,38 @@ type Revision interface {
 	LessThan(Revision) bool
 }
 
+// RevisionOrdering is the ordering of a revision relative to another revision.
+type RevisionOrdering int
+
+const (
+	// RevisionsUnordered indicates that the revisions cannot be provably ordered.
+	RevisionsUnordered RevisionOrdering = iota
+
+	// RevisionBefore indicates that the revision is provably before the other revision.
+	RevisionBefore
+
+	// RevisionEqual indicates that the revisions should be considered equal.
+	RevisionEqual
+
+	// RevisionAfter indicates that the revision is provably after the other revision.
+	RevisionAfter
+)
+
+// CompareRevisions returns the ordering of the left hand side revision relative to the right
+// hand side, without assuming anything about how either revision is represented.
+func CompareRevisions(lhs, rhs Revision) RevisionOrdering {
+	switch {
+	case lhs.Equal(rhs):
+		return RevisionEqual
+	case lhs.LessThan(rhs):
+		return RevisionBefore
+	case lhs.GreaterThan(rhs):
+		return RevisionAfter
+	default:
+		return RevisionsUnordered
+	}
+}
+
 type nilRevision struct{}
 
 func (nilRevision) Equal(rhs Revision) bool {
@@ -95,3 +95,22 @@ func TestRelationshipsFilterFromPublicFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestPoolStatsSaturated(t *testing.T) {
+	tests := []struct {
+		name      string
+		stats     PoolStats
+		saturated bool
+	}{
+		{"empty", PoolStats{MaxConns: 10}, false},
+		{"partially acquired", PoolStats{AcquiredConns: 5, IdleConns: 5, TotalConns: 10, MaxConns: 10}, false},
+		{"fully acquired", PoolStats{AcquiredConns: 10, TotalConns: 10, MaxConns: 10}, true},
+		{"unbounded", PoolStats{AcquiredConns: 10, TotalConns: 10}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.saturated, test.stats.Saturated())
+		})
+	}
+}
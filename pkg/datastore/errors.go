@@ -2,8 +2,13 @@ package datastore
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // ErrNamespaceNotFound occurs when a namespace was not found.
@@ -29,6 +34,85 @@ func (err ErrNamespaceNotFound) DetailsMetadata() map[string]string {
 	}
 }
 
+// ErrNamespaceHasRelationships occurs when a namespace cannot be deleted because live
+// relationships still reference it.
+type ErrNamespaceHasRelationships struct {
+	error
+	namespaceName     string
+	relationshipCount uint64
+}
+
+// NamespaceName is the name of the namespace that could not be deleted.
+func (err ErrNamespaceHasRelationships) NamespaceName() string {
+	return err.namespaceName
+}
+
+// RelationshipCount is the number of live relationships referencing the namespace.
+func (err ErrNamespaceHasRelationships) RelationshipCount() uint64 {
+	return err.relationshipCount
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrNamespaceHasRelationships) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Uint64("relationshipCount", err.relationshipCount)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrNamespaceHasRelationships) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name":    err.namespaceName,
+		"relationship_count": strconv.FormatUint(err.relationshipCount, 10),
+	}
+}
+
+// ErrRelationshipLimitExceeded occurs when a write would push the number of live relationships
+// of a namespace over the limit configured for it.
+type ErrRelationshipLimitExceeded struct {
+	error
+	namespaceName string
+	limit         uint64
+}
+
+// NamespaceName is the name of the namespace whose limit would be exceeded.
+func (err ErrRelationshipLimitExceeded) NamespaceName() string {
+	return err.namespaceName
+}
+
+// Limit is the maximum number of live relationships configured for the namespace.
+func (err ErrRelationshipLimitExceeded) Limit() uint64 {
+	return err.limit
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrRelationshipLimitExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Uint64("limit", err.limit)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrRelationshipLimitExceeded) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name":    err.namespaceName,
+		"relationship_limit": strconv.FormatUint(err.limit, 10),
+	}
+}
+
+// ErrConsistencyViolations occurs when ValidateConsistency finds relationships violating the
+// invariants of the datastore.
+type ErrConsistencyViolations struct {
+	error
+	violations []ConsistencyViolation
+}
+
+// Violations are the violations found.
+func (err ErrConsistencyViolations) Violations() []ConsistencyViolation {
+	return err.violations
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrConsistencyViolations) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Int("violationCount", len(err.violations))
+}
+
 // ErrWatchDisconnected occurs when a watch has fallen too far behind and was forcibly disconnected
 // as a result.
 type ErrWatchDisconnected struct{ error }
@@ -43,6 +127,74 @@ type ErrWatchDisabled struct{ error }
 // read-only mode.
 type ErrReadOnly struct{ error }
 
+// ErrIdempotencyKeysUnsupported is returned when a read-write transaction was given an idempotency
+// key, but the datastore does not support recording them.
+type ErrIdempotencyKeysUnsupported struct{ error }
+
+// ErrRelationshipExpirationUnsupported is returned when a relationship was written with an
+// expiration, but the datastore does not support expiring relationships.
+type ErrRelationshipExpirationUnsupported struct{ error }
+
+// ErrRevisionDiffUnsupported is returned when the changes between two revisions were requested,
+// but the datastore does not support computing them.
+type ErrRevisionDiffUnsupported struct{ error }
+
+// ErrRelationshipHistoryUnsupported is returned when the history of a relationship was
+// requested, but the datastore does not retain it.
+type ErrRelationshipHistoryUnsupported struct{ error }
+
+// ErrRelationshipRevisionsUnsupported is returned when the revisions of relationships were
+// requested, but the reader cannot read them.
+type ErrRelationshipRevisionsUnsupported struct{ error }
+
+// ErrConsistencyValidationUnsupported is returned when the consistency of a datastore was to
+// be validated, but the datastore cannot validate it.
+type ErrConsistencyValidationUnsupported struct{ error }
+
+// ErrRetryable occurs when an operation failed because it conflicted with a concurrent
+// operation, such as on a serialization failure or deadlock, and can be retried as is.
+type ErrRetryable struct{ error }
+
+// Unwrap returns the underlying error of the datastore.
+func (err ErrRetryable) Unwrap() error { return err.error }
+
+// ErrConflict occurs when an operation failed because it would have violated a constraint of the
+// stored data, such as a uniqueness constraint.
+type ErrConflict struct{ error }
+
+// Unwrap returns the underlying error of the datastore.
+func (err ErrConflict) Unwrap() error { return err.error }
+
+// ErrNotFound occurs when a row required by an operation was not found in the datastore.
+type ErrNotFound struct{ error }
+
+// Unwrap returns the underlying error of the datastore.
+func (err ErrNotFound) Unwrap() error { return err.error }
+
+// ErrUnavailable occurs when an operation failed because the datastore could not be reached or
+// was not accepting connections.
+type ErrUnavailable struct{ error }
+
+// Unwrap returns the underlying error of the datastore.
+func (err ErrUnavailable) Unwrap() error { return err.error }
+
+// ErrTimestampBeforeGCWindow occurs when a revision was requested for a point in time that
+// falls before the garbage collection window, and therefore can no longer be read.
+type ErrTimestampBeforeGCWindow struct {
+	error
+	timestamp time.Time
+}
+
+// Timestamp is the point in time that was requested.
+func (err ErrTimestampBeforeGCWindow) Timestamp() time.Time {
+	return err.timestamp
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrTimestampBeforeGCWindow) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Time("timestamp", err.timestamp)
+}
+
 // InvalidRevisionReason is the reason the revision could not be used.
 type InvalidRevisionReason int
 
@@ -93,6 +245,40 @@ func NewNamespaceNotFoundErr(nsName string) error {
 	}
 }
 
+// NewNamespaceHasRelationshipsErr constructs a new error indicating that a namespace could not
+// be deleted because relationships still reference it.
+func NewNamespaceHasRelationshipsErr(nsName string, relationshipCount uint64) error {
+	return ErrNamespaceHasRelationships{
+		error:             fmt.Errorf("cannot delete object definition `%s`, as %d relationship(s) reference it", nsName, relationshipCount),
+		namespaceName:     nsName,
+		relationshipCount: relationshipCount,
+	}
+}
+
+// NewRelationshipLimitExceededErr constructs a new error indicating that a write would push the
+// number of live relationships of a namespace over its limit.
+func NewRelationshipLimitExceededErr(nsName string, limit uint64) error {
+	return ErrRelationshipLimitExceeded{
+		error:         fmt.Errorf("write would exceed the limit of %d relationship(s) for object definition `%s`", limit, nsName),
+		namespaceName: nsName,
+		limit:         limit,
+	}
+}
+
+// NewConsistencyViolationsErr constructs a new error describing the given violations of the
+// invariants of the datastore.
+func NewConsistencyViolationsErr(violations []ConsistencyViolation) error {
+	described := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		described = append(described, fmt.Sprintf("`%s`: %s", tuple.MustString(violation.Relationship), violation.Reason))
+	}
+
+	return ErrConsistencyViolations{
+		error:      fmt.Errorf("found %d consistency violation(s): %s", len(violations), strings.Join(described, "; ")),
+		violations: violations,
+	}
+}
+
 // NewWatchDisconnectedErr constructs a new watch was disconnected error.
 func NewWatchDisconnectedErr() error {
 	return ErrWatchDisconnected{
@@ -122,6 +308,74 @@ func NewReadonlyErr() error {
 	}
 }
 
+// NewIdempotencyKeysUnsupportedErr constructs an error for when an idempotency key was given to
+// a datastore engine that does not support them.
+func NewIdempotencyKeysUnsupportedErr(engine string) error {
+	return ErrIdempotencyKeysUnsupported{
+		error: fmt.Errorf("idempotency keys are not supported by the %s datastore", engine),
+	}
+}
+
+// NewRelationshipExpirationUnsupportedErr constructs an error for when a relationship with an
+// expiration was written to a datastore engine that does not support them.
+func NewRelationshipExpirationUnsupportedErr(engine string) error {
+	return ErrRelationshipExpirationUnsupported{
+		error: fmt.Errorf("relationship expiration is not supported by the %s datastore", engine),
+	}
+}
+
+// NewRevisionDiffUnsupportedErr constructs an error for when the changes between two revisions
+// were requested from a datastore engine that does not support computing them.
+func NewRevisionDiffUnsupportedErr(engine string) error {
+	return ErrRevisionDiffUnsupported{
+		error: fmt.Errorf("diffing revisions is not supported by the %s datastore", engine),
+	}
+}
+
+// NewRelationshipHistoryUnsupportedErr constructs an error for when the history of a
+// relationship was requested from a datastore that does not retain it.
+func NewRelationshipHistoryUnsupportedErr() error {
+	return ErrRelationshipHistoryUnsupported{
+		error: fmt.Errorf("reading relationship history is not supported by the datastore"),
+	}
+}
+
+// NewRelationshipRevisionsUnsupportedErr constructs an error for when the revisions of
+// relationships were requested from a reader that cannot read them.
+func NewRelationshipRevisionsUnsupportedErr() error {
+	return ErrRelationshipRevisionsUnsupported{
+		error: fmt.Errorf("reading relationship revisions is not supported by the datastore"),
+	}
+}
+
+// NewConsistencyValidationUnsupportedErr constructs an error for when the consistency of a
+// datastore that cannot validate it was to be validated.
+func NewConsistencyValidationUnsupportedErr() error {
+	return ErrConsistencyValidationUnsupported{
+		error: fmt.Errorf("validating consistency is not supported by the datastore"),
+	}
+}
+
+// NewRetryableErr wraps an error of the datastore as an ErrRetryable.
+func NewRetryableErr(err error) error {
+	return ErrRetryable{err}
+}
+
+// NewConflictErr wraps an error of the datastore as an ErrConflict.
+func NewConflictErr(err error) error {
+	return ErrConflict{err}
+}
+
+// NewNotFoundErr wraps an error of the datastore as an ErrNotFound.
+func NewNotFoundErr(err error) error {
+	return ErrNotFound{err}
+}
+
+// NewUnavailableErr wraps an error of the datastore as an ErrUnavailable.
+func NewUnavailableErr(err error) error {
+	return ErrUnavailable{err}
+}
+
 // NewInvalidRevisionErr constructs a new invalid revision error.
 func NewInvalidRevisionErr(revision Revision, reason InvalidRevisionReason) error {
 	switch reason {
@@ -141,6 +395,15 @@ func NewInvalidRevisionErr(revision Revision, reason InvalidRevisionReason) erro
 	}
 }
 
+// NewTimestampBeforeGCWindowErr constructs a new error for a point in time that predates the
+// garbage collection window.
+func NewTimestampBeforeGCWindowErr(timestamp time.Time) error {
+	return ErrTimestampBeforeGCWindow{
+		error:     fmt.Errorf("no revision is available at %s, as it is before the garbage collection window", timestamp.UTC().Format(time.RFC3339Nano)),
+		timestamp: timestamp,
+	}
+}
+
 // ErrCaveatNameNotFound is the error returned when a caveat is not found by its name
 type ErrCaveatNameNotFound struct {
 	error
@@ -0,0 +1,118 @@
+// Package snapshot provides datastore-agnostic snapshots of the schema and relationships
+// stored in a datastore, which can be serialized and restored into another datastore.
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	implv1 "github.com/authzed/spicedb/pkg/proto/impl/v1"
+)
+
+// restoreBatchSize is the maximum number of relationships written in a single call to
+// WriteRelationships when restoring a snapshot.
+const restoreBatchSize = 1000
+
+// Take reads all namespaces, caveats and live relationships found in the datastore at the
+// given revision into a datastore-agnostic snapshot. Relationships are snapshotted along with
+// their caveats and expirations.
+func Take(ctx context.Context, ds datastore.Datastore, revision datastore.Revision) (*implv1.DatastoreSnapshot, error) {
+	reader := ds.SnapshotReader(revision)
+
+	namespaces, err := reader.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list namespaces: %w", err)
+	}
+
+	caveats, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list caveats: %w", err)
+	}
+
+	snapshot := &implv1.DatastoreSnapshot{
+		Namespaces: namespaces,
+		Caveats:    caveats,
+	}
+
+	if len(namespaces) == 0 {
+		return snapshot, nil
+	}
+
+	resourceTypes := make([]string, 0, len(namespaces))
+	for _, nsDef := range namespaces {
+		resourceTypes = append(resourceTypes, nsDef.Name)
+	}
+
+	iter, err := reader.QueryRelationshipsForResourceTypes(ctx, resourceTypes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query relationships: %w", err)
+	}
+	defer iter.Close()
+
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		snapshot.Relationships = append(snapshot.Relationships, tpl)
+	}
+	if iter.Err() != nil {
+		return nil, fmt.Errorf("unable to read relationships: %w", iter.Err())
+	}
+
+	return snapshot, nil
+}
+
+// Restore writes the contents of the snapshot into the datastore in a single transaction,
+// returning the revision at which the snapshot was restored. The datastore is expected to
+// not already contain any of the snapshot's relationships.
+func Restore(ctx context.Context, ds datastore.Datastore, snapshot *implv1.DatastoreSnapshot) (datastore.Revision, error) {
+	return ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if len(snapshot.Caveats) > 0 {
+			if err := rwt.WriteCaveats(ctx, snapshot.Caveats); err != nil {
+				return fmt.Errorf("unable to write caveats: %w", err)
+			}
+		}
+
+		if len(snapshot.Namespaces) > 0 {
+			if err := rwt.WriteNamespaces(ctx, snapshot.Namespaces...); err != nil {
+				return fmt.Errorf("unable to write namespaces: %w", err)
+			}
+		}
+
+		for start := 0; start < len(snapshot.Relationships); start += restoreBatchSize {
+			end := start + restoreBatchSize
+			if end > len(snapshot.Relationships) {
+				end = len(snapshot.Relationships)
+			}
+
+			updates := make([]*core.RelationTupleUpdate, 0, end-start)
+			for _, tpl := range snapshot.Relationships[start:end] {
+				updates = append(updates, &core.RelationTupleUpdate{
+					Operation: core.RelationTupleUpdate_CREATE,
+					Tuple:     tpl,
+				})
+			}
+
+			if err := rwt.WriteRelationships(ctx, updates); err != nil {
+				return fmt.Errorf("unable to write relationships: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Marshal serializes the snapshot into its portable binary form.
+func Marshal(snapshot *implv1.DatastoreSnapshot) ([]byte, error) {
+	return proto.Marshal(snapshot)
+}
+
+// Unmarshal parses a snapshot previously serialized with Marshal.
+func Unmarshal(data []byte) (*implv1.DatastoreSnapshot, error) {
+	snapshot := &implv1.DatastoreSnapshot{}
+	if err := proto.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal snapshot: %w", err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,135 @@
+package snapshot
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	implv1 "github.com/authzed/spicedb/pkg/proto/impl/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithCaveatedData(rawDS, require)
+
+	taken, err := Take(ctx, ds, revision)
+	require.NoError(err)
+	require.Len(taken.Relationships, len(testfixtures.StandardTuples))
+	require.NotEmpty(taken.Namespaces)
+	require.NotEmpty(taken.Caveats)
+
+	serialized, err := Marshal(taken)
+	require.NoError(err)
+
+	loaded, err := Unmarshal(serialized)
+	require.NoError(err)
+
+	freshDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	restoredRevision, err := Restore(ctx, freshDS, loaded)
+	require.NoError(err)
+
+	restored, err := Take(ctx, freshDS, restoredRevision)
+	require.NoError(err)
+
+	require.ElementsMatch(namespaceNames(taken.Namespaces), namespaceNames(restored.Namespaces))
+	require.Len(restored.Caveats, len(taken.Caveats))
+	require.Equal(sortedTuples(taken), sortedTuples(restored))
+}
+
+func TestSnapshotRoundTripExpiringRelationship(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	expiring := tuple.WithExpiration(tuple.MustParse("document:expiring#viewer@user:tom"), expiresAt)
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	require.NoError(err)
+
+	taken, err := Take(ctx, ds, revision)
+	require.NoError(err)
+
+	serialized, err := Marshal(taken)
+	require.NoError(err)
+
+	loaded, err := Unmarshal(serialized)
+	require.NoError(err)
+
+	freshDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	restoredRevision, err := Restore(ctx, freshDS, loaded)
+	require.NoError(err)
+
+	restored, err := Take(ctx, freshDS, restoredRevision)
+	require.NoError(err)
+
+	var found *core.RelationTuple
+	for _, tpl := range restored.Relationships {
+		if tuple.MustString(tpl) == tuple.MustString(expiring) {
+			found = tpl
+		}
+	}
+	require.NotNil(found, "expected the expiring relationship to be restored")
+	require.NotNil(found.ExpiresAt, "expected the restored relationship to expire")
+	require.True(expiresAt.Equal(found.ExpiresAt.AsTime()))
+}
+
+func TestSnapshotEmptyDatastore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	revision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	taken, err := Take(ctx, ds, revision)
+	require.NoError(err)
+	require.Empty(taken.Namespaces)
+	require.Empty(taken.Caveats)
+	require.Empty(taken.Relationships)
+
+	freshDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	_, err = Restore(ctx, freshDS, taken)
+	require.NoError(err)
+}
+
+func namespaceNames(defs []*core.NamespaceDefinition) []string {
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+func sortedTuples(snapshot *implv1.DatastoreSnapshot) []string {
+	strs := make([]string, 0, len(snapshot.GetRelationships()))
+	for _, tpl := range snapshot.GetRelationships() {
+		strs = append(strs, tuple.MustString(tpl))
+	}
+	sort.Strings(strs)
+	return strs
+}
@@ -7,7 +7,10 @@ import (
 	"testing"
 	"time"
 
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/caveats"
 	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
@@ -100,6 +103,62 @@ func WriteReadDeleteCaveatTest(t *testing.T, tester DatastoreTester) {
 	req.ErrorAs(err, &datastore.ErrCaveatNameNotFound{})
 }
 
+// ListCaveatsPaginationTest tests listing caveats filtered by name prefix and paginated.
+func ListCaveatsPaginationTest(t *testing.T, tester DatastoreTester) {
+	req := require.New(t)
+	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
+	req.NoError(err)
+
+	skipIfNotCaveatStorer(t, ds)
+
+	ctx := context.Background()
+
+	var caveatDefs []*core.CaveatDefinition
+	for _, name := range []string{"org/user", "other/user", "org/aab", "org/document", "org/a_b"} {
+		caveatDef := createCoreCaveat(t)
+		caveatDef.Name = name
+		caveatDefs = append(caveatDefs, caveatDef)
+	}
+
+	rev, err := writeCaveats(ctx, ds, caveatDefs...)
+	req.NoError(err)
+
+	reader := ds.SnapshotReader(rev)
+	listNames := func(opts ...options.ListCaveatsOptionsOption) []string {
+		listed, err := datastore.ListCaveatsWithOptions(ctx, reader, opts...)
+		req.NoError(err)
+
+		found := make([]string, 0, len(listed))
+		for _, caveatDef := range listed {
+			found = append(found, caveatDef.Name)
+		}
+		return found
+	}
+
+	req.Equal([]string{"org/a_b", "org/aab", "org/document", "org/user", "other/user"}, listNames())
+	req.Equal([]string{"org/a_b", "org/aab", "org/document", "org/user"}, listNames(options.WithCaveatPrefix("org/")))
+	req.Equal([]string{"org/a_b"}, listNames(options.WithCaveatPrefix("org/a_")))
+	req.Empty(listNames(options.WithCaveatPrefix("unknown/")))
+
+	limit := uint64(2)
+	var pages [][]string
+	after := ""
+	for {
+		page := listNames(
+			options.WithCaveatPrefix("org/"),
+			options.WithCaveatLimit(&limit),
+			options.WithAfterCaveat(after),
+		)
+		if len(page) == 0 {
+			break
+		}
+
+		pages = append(pages, page)
+		after = page[len(page)-1]
+	}
+	req.Equal([][]string{{"org/a_b", "org/aab"}, {"org/document", "org/user"}}, pages)
+}
+
 func WriteCaveatedRelationshipTest(t *testing.T, tester DatastoreTester) {
 	req := require.New(t)
 	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
@@ -173,6 +232,97 @@ func WriteCaveatedRelationshipTest(t *testing.T, tester DatastoreTester) {
 	req.NoError(err)
 }
 
+func UpdateRelationshipsCaveatTest(t *testing.T, tester DatastoreTester) {
+	req := require.New(t)
+	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
+	req.NoError(err)
+
+	skipIfNotCaveatStorer(t, ds)
+
+	sds, _ := testfixtures.StandardDatastoreWithSchema(ds, req)
+
+	coreCaveat := createCoreCaveat(t)
+	ctx := context.Background()
+	_, err = writeCaveats(ctx, ds, coreCaveat)
+	req.NoError(err)
+
+	firstTpl := tuple.MustParse("document:companyplan#parent@folder:company#...")
+	secondTpl := tuple.MustParse("document:anothercompanyplan#parent@folder:company#...")
+	otherTpl := tuple.MustParse("document:companyplan#viewer@user:tom#...")
+	beforeRev, err := common.WriteTuples(ctx, sds, core.RelationTupleUpdate_CREATE, firstTpl, secondTpl, otherTpl)
+	req.NoError(err)
+
+	filter := &v1.RelationshipFilter{
+		ResourceType:     "document",
+		OptionalRelation: "parent",
+	}
+	caveat := createTestCaveatedTuple(t, "document:companyplan#parent@folder:company#...", coreCaveat.Name).Caveat
+
+	updateCaveat := func(caveat *core.ContextualizedCaveat) (datastore.Revision, uint64, error) {
+		var count uint64
+		rev, err := sds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			var err error
+			count, err = common.UpdateRelationshipsCaveat(ctx, rwt, filter, caveat)
+			return err
+		})
+		return rev, count, err
+	}
+
+	readCaveated := func(rev datastore.Revision) []*core.RelationTuple {
+		iter, err := ds.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{
+			ResourceType:       "document",
+			OptionalCaveatName: coreCaveat.Name,
+		})
+		req.NoError(err)
+		defer iter.Close()
+
+		var found []*core.RelationTuple
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			found = append(found, tpl)
+		}
+		req.NoError(iter.Err())
+		return found
+	}
+
+	// Attach the caveat to the matching relationships.
+	attachedRev, count, err := updateCaveat(caveat)
+	req.NoError(err)
+	req.Equal(uint64(2), count)
+
+	attached := readCaveated(attachedRev)
+	req.Len(attached, 2)
+	for _, tpl := range attached {
+		req.Equal("parent", tpl.ResourceAndRelation.Relation)
+		req.Empty(cmp.Diff(caveat, tpl.Caveat, protocmp.Transform()))
+	}
+
+	// The previous revision still observes the relationships without the caveat.
+	req.Empty(readCaveated(beforeRev))
+
+	// Attaching the same caveat again changes nothing.
+	_, count, err = updateCaveat(caveat)
+	req.NoError(err)
+	req.Equal(uint64(0), count)
+
+	// Clear the caveat.
+	clearedRev, count, err := updateCaveat(nil)
+	req.NoError(err)
+	req.Equal(uint64(2), count)
+	req.Empty(readCaveated(clearedRev))
+
+	iter, err := ds.SnapshotReader(clearedRev).QueryRelationships(ctx, datastore.RelationshipsFilterFromPublicFilter(&v1.RelationshipFilter{
+		ResourceType:       "document",
+		OptionalResourceId: "companyplan",
+		OptionalRelation:   "parent",
+	}))
+	req.NoError(err)
+	expectTuple(req, iter, firstTpl)
+
+	// The named caveat must exist.
+	_, _, err = updateCaveat(&core.ContextualizedCaveat{CaveatName: "unknown"})
+	req.ErrorAs(err, &datastore.ErrCaveatNameNotFound{})
+}
+
 func CaveatedRelationshipFilterTest(t *testing.T, tester DatastoreTester) {
 	req := require.New(t)
 	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
@@ -318,15 +468,21 @@ func expectTupleChange(t *testing.T, ds datastore.Datastore, revBeforeWrite data
 	require.Zero(t, len(chanErr))
 
 	changeWait := time.NewTimer(waitForChangesTimeout)
-	select {
-	case change, ok := <-chanRevisionChanges:
-		require.True(t, ok)
-
-		// do not check length of change, may contain duplicates
-		foundDiff := cmp.Diff(expectedTuple, change.Changes[0].Tuple, protocmp.Transform())
-		require.Empty(t, foundDiff)
-	case <-changeWait.C:
-		require.Fail(t, "timed out waiting for relationship update via Watch API")
+	for {
+		select {
+		case change, ok := <-chanRevisionChanges:
+			require.True(t, ok)
+			if change.IsCheckpoint {
+				continue
+			}
+
+			// do not check length of change, may contain duplicates
+			foundDiff := cmp.Diff(expectedTuple, change.Changes[0].Tuple, protocmp.Transform())
+			require.Empty(t, foundDiff)
+		case <-changeWait.C:
+			require.Fail(t, "timed out waiting for relationship update via Watch API")
+		}
+		return
 	}
 }
 
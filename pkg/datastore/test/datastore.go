@@ -33,9 +33,14 @@ func (f DatastoreTesterFunc) New(revisionQuantization, gcWindow time.Duration, w
 // All runs all generic datastore tests on a DatastoreTester.
 func All(t *testing.T, tester DatastoreTester) {
 	t.Run("TestNamespaceWrite", func(t *testing.T) { NamespaceWriteTest(t, tester) })
+	t.Run("TestLookupNamespaces", func(t *testing.T) { LookupNamespacesTest(t, tester) })
 	t.Run("TestNamespaceDelete", func(t *testing.T) { NamespaceDeleteTest(t, tester) })
 	t.Run("TestNamespaceMultiDelete", func(t *testing.T) { NamespaceMultiDeleteTest(t, tester) })
 	t.Run("TestEmptyNamespaceDelete", func(t *testing.T) { EmptyNamespaceDeleteTest(t, tester) })
+	t.Run("TestNamespaceDeleteWithRelationshipsRefused", func(t *testing.T) { NamespaceDeleteWithRelationshipsRefusedTest(t, tester) })
+	t.Run("TestNamespaceHasRelationships", func(t *testing.T) { NamespaceHasRelationshipsTest(t, tester) })
+	t.Run("TestNamespaceDeleteCascadesToSubjects", func(t *testing.T) { NamespaceDeleteCascadesToSubjectsTest(t, tester) })
+	t.Run("TestListNamespacesPagination", func(t *testing.T) { ListNamespacesPaginationTest(t, tester) })
 	t.Run("TestStableNamespaceReadWrite", func(t *testing.T) { StableNamespaceReadWriteTest(t, tester) })
 
 	t.Run("TestSimple", func(t *testing.T) { SimpleTest(t, tester) })
@@ -45,22 +50,46 @@ func All(t *testing.T, tester DatastoreTester) {
 	t.Run("TestDeleteAlreadyDeleted", func(t *testing.T) { DeleteAlreadyDeletedTest(t, tester) })
 	t.Run("TestWriteDeleteWrite", func(t *testing.T) { WriteDeleteWriteTest(t, tester) })
 	t.Run("TestCreateAlreadyExisting", func(t *testing.T) { CreateAlreadyExistingTest(t, tester) })
+	t.Run("TestIdempotentWrite", func(t *testing.T) { IdempotentWriteTest(t, tester) })
 	t.Run("TestTouchAlreadyExisting", func(t *testing.T) { TouchAlreadyExistingTest(t, tester) })
+	t.Run("TestRelationshipExpiration", func(t *testing.T) { RelationshipExpirationTest(t, tester) })
+	t.Run("TestRelationshipExpirationChanges", func(t *testing.T) { RelationshipExpirationChangesTest(t, tester) })
+	t.Run("TestRelationshipExists", func(t *testing.T) { RelationshipExistsTest(t, tester) })
+	t.Run("TestWriteRelationshipsWithResults", func(t *testing.T) { WriteRelationshipsWithResultsTest(t, tester) })
+	t.Run("TestRemoveSubject", func(t *testing.T) { RemoveSubjectTest(t, tester) })
+	t.Run("TestMergeSubject", func(t *testing.T) { MergeSubjectTest(t, tester) })
+	t.Run("TestMergeSubjectExpiringRelationship", func(t *testing.T) { MergeSubjectExpiringRelationshipTest(t, tester) })
 	t.Run("TestUsersets", func(t *testing.T) { UsersetsTest(t, tester) })
+	t.Run("TestEllipsisRelationNormalization", func(t *testing.T) { EllipsisRelationNormalizationTest(t, tester) })
+	t.Run("TestQueryRelationshipsForResourceTypes", func(t *testing.T) { QueryRelationshipsForResourceTypesTest(t, tester) })
+	t.Run("TestQueryRelationshipsWithResourceIDs", func(t *testing.T) { QueryRelationshipsWithResourceIDsTest(t, tester) })
+	t.Run("TestQueryRelationshipsWithResourceIDPrefix", func(t *testing.T) { QueryRelationshipsWithResourceIDPrefixTest(t, tester) })
+	t.Run("TestQueryRelationshipsSorted", func(t *testing.T) { QueryRelationshipsSortedTest(t, tester) })
+	t.Run("TestReverseQueryWildcardSubjects", func(t *testing.T) { ReverseQueryWildcardSubjectsTest(t, tester) })
 	t.Run("TestMultipleReadsInRWT", func(t *testing.T) { MultipleReadsInRWTTest(t, tester) })
 	t.Run("TestConcurrentWriteSerialization", func(t *testing.T) { ConcurrentWriteSerializationTest(t, tester) })
+	t.Run("TestConsistencyValidation", func(t *testing.T) { ConsistencyValidationTest(t, tester) })
+	t.Run("TestRelationshipHistory", func(t *testing.T) { RelationshipHistoryTest(t, tester) })
+	t.Run("TestRelationshipRevisions", func(t *testing.T) { RelationshipRevisionsTest(t, tester) })
 
 	t.Run("TestRevisionQuantization", func(t *testing.T) { RevisionQuantizationTest(t, tester) })
 	t.Run("TestRevisionSerialization", func(t *testing.T) { RevisionSerializationTest(t, tester) })
+	t.Run("TestRevisionAtTime", func(t *testing.T) { RevisionAtTimeTest(t, tester) })
+	t.Run("TestDiffRevisions", func(t *testing.T) { DiffRevisionsTest(t, tester) })
+	t.Run("TestDiffRevisionsOutsideGCWindow", func(t *testing.T) { DiffRevisionsOutsideGCWindowTest(t, tester) })
 
 	t.Run("TestWatch", func(t *testing.T) { WatchTest(t, tester) })
 	t.Run("TestWatchCancel", func(t *testing.T) { WatchCancelTest(t, tester) })
+	t.Run("TestWatchSchema", func(t *testing.T) { WatchSchemaTest(t, tester) })
 
 	t.Run("TestStats", func(t *testing.T) { StatsTest(t, tester) })
+	t.Run("TestHealthCheck", func(t *testing.T) { HealthCheckTest(t, tester) })
 
 	t.Run("TestWriteReadDeleteCaveat", func(t *testing.T) { WriteReadDeleteCaveatTest(t, tester) })
+	t.Run("TestListCaveatsPagination", func(t *testing.T) { ListCaveatsPaginationTest(t, tester) })
 	t.Run("TestWriteCaveatedRelationship", func(t *testing.T) { WriteCaveatedRelationshipTest(t, tester) })
 	t.Run("TestCaveatedRelationshipFilter", func(t *testing.T) { CaveatedRelationshipFilterTest(t, tester) })
+	t.Run("TestUpdateRelationshipsCaveat", func(t *testing.T) { UpdateRelationshipsCaveatTest(t, tester) })
 	t.Run("TestCaveatSnapshotReads", func(t *testing.T) { CaveatSnapshotReadsTest(t, tester) })
 	t.Run("TestCaveatedRelationshipWatch", func(t *testing.T) { CaveatedRelationshipWatchTest(t, tester) })
 }
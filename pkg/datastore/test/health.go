@@ -0,0 +1,27 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+func HealthCheckTest(t *testing.T, tester DatastoreTester) {
+	ctx := context.Background()
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	result := ds.HealthCheck(ctx)
+	require.Equal(datastore.HealthStatusHealthy, result.Status, "unexpected health: %s", result.Reason)
+
+	require.NoError(ds.Close())
+
+	result = ds.HealthCheck(ctx)
+	require.Equal(datastore.HealthStatusUnreachable, result.Status)
+	require.NotEmpty(result.Reason)
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
 	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/generator"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
@@ -122,6 +125,51 @@ func NamespaceWriteTest(t *testing.T, tester DatastoreTester) {
 	require.Equal(0, len(emptyLookup))
 }
 
+// LookupNamespacesTest tests looking up several namespaces at once, including those which do not
+// exist at the revision read, which are omitted from the result.
+func LookupNamespacesTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+	defer ds.Close()
+
+	ctx := context.Background()
+
+	writtenRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, testUserNS)
+	})
+	require.NoError(err)
+
+	secondWritten, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, testNamespace)
+	})
+	require.NoError(err)
+
+	names := []string{testNamespace.Name, "anothername", testUserNS.Name, testNamespace.Name}
+
+	found, err := ds.SnapshotReader(secondWritten).LookupNamespaces(ctx, names)
+	require.NoError(err)
+	require.Len(found, 2)
+
+	foundByName := make(map[string]*core.NamespaceDefinition, len(found))
+	for _, nsDef := range found {
+		foundByName[nsDef.Name] = nsDef
+	}
+	require.Empty(cmp.Diff(testNamespace, foundByName[testNamespace.Name], protocmp.Transform()))
+	require.Empty(cmp.Diff(testUserNS, foundByName[testUserNS.Name], protocmp.Transform()))
+
+	// At the earlier revision, the second namespace has not yet been written.
+	found, err = ds.SnapshotReader(writtenRev).LookupNamespaces(ctx, names)
+	require.NoError(err)
+	require.Len(found, 1)
+	require.Equal(testUserNS.Name, found[0].Name)
+
+	found, err = ds.SnapshotReader(secondWritten).LookupNamespaces(ctx, nil)
+	require.NoError(err)
+	require.Empty(found)
+}
+
 // NamespaceDeleteTest tests whether or not the requirements for deleting
 // namespaces hold for a particular datastore.
 func NamespaceDeleteTest(t *testing.T, tester DatastoreTester) {
@@ -143,7 +191,7 @@ func NamespaceDeleteTest(t *testing.T, tester DatastoreTester) {
 	tRequire.TupleExists(ctx, folderTpl, revision)
 
 	deletedRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
-		return rwt.DeleteNamespaces(ctx, testfixtures.DocumentNS.Name)
+		return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, testfixtures.DocumentNS.Name)
 	})
 	require.NoError(err)
 	require.True(deletedRev.GreaterThan(revision))
@@ -190,7 +238,7 @@ func NamespaceMultiDeleteTest(t *testing.T, tester DatastoreTester) {
 	}
 
 	deletedRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
-		return rwt.DeleteNamespaces(ctx, nsNames...)
+		return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, nsNames...)
 	})
 	require.NoError(t, err)
 
@@ -206,11 +254,11 @@ func EmptyNamespaceDeleteTest(t *testing.T, tester DatastoreTester) {
 	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
 	require.NoError(err)
 
-	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ds, revision := testfixtures.StandardDatastoreWithSchema(rawDS, require)
 	ctx := context.Background()
 
 	deletedRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
-		return rwt.DeleteNamespaces(ctx, testfixtures.UserNS.Name)
+		return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesOnlyIfEmpty, testfixtures.UserNS.Name)
 	})
 	require.NoError(err)
 	require.True(deletedRev.GreaterThan(revision))
@@ -219,6 +267,184 @@ func EmptyNamespaceDeleteTest(t *testing.T, tester DatastoreTester) {
 	require.True(errors.As(err, &datastore.ErrNamespaceNotFound{}))
 }
 
+// NamespaceDeleteWithRelationshipsRefusedTest tests that deleting a namespace that is still
+// referenced by relationships fails unless the relationships are deleted alongside it.
+func NamespaceDeleteWithRelationshipsRefusedTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		nsName        string
+		expectedCount uint64
+	}{
+		{testfixtures.DocumentNS.Name, 9},
+		{testfixtures.UserNS.Name, 11},
+		{testfixtures.FolderNS.Name, 12},
+	} {
+		_, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesOnlyIfEmpty, tc.nsName)
+		})
+
+		var hasRelsErr datastore.ErrNamespaceHasRelationships
+		require.ErrorAs(err, &hasRelsErr)
+		require.Equal(tc.nsName, hasRelsErr.NamespaceName())
+		require.Equal(tc.expectedCount, hasRelsErr.RelationshipCount())
+
+		headRev, err := ds.HeadRevision(ctx)
+		require.NoError(err)
+
+		_, _, err = ds.SnapshotReader(headRev).ReadNamespace(ctx, tc.nsName)
+		require.NoError(err)
+	}
+
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+	headRev, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	for _, tplString := range testfixtures.StandardTuples {
+		tRequire.TupleExists(ctx, tuple.Parse(tplString), headRev)
+	}
+}
+
+// NamespaceHasRelationshipsTest tests checking whether any live relationship references a
+// namespace, as either the resource type or the subject type.
+func NamespaceHasRelationshipsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, emptyRev := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+	ctx := context.Background()
+
+	tpl := tuple.Parse("document:somedoc#viewer@user:tom")
+	writtenRev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	deletedRev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	require.NoError(err)
+
+	for _, tc := range []struct {
+		revision datastore.Revision
+		nsName   string
+		expected bool
+	}{
+		{emptyRev, testfixtures.DocumentNS.Name, false},
+		{emptyRev, testfixtures.UserNS.Name, false},
+		{writtenRev, testfixtures.DocumentNS.Name, true},
+		{writtenRev, testfixtures.UserNS.Name, true},
+		{writtenRev, testfixtures.FolderNS.Name, false},
+		{deletedRev, testfixtures.DocumentNS.Name, false},
+		{deletedRev, testfixtures.UserNS.Name, false},
+	} {
+		found, err := datastore.NamespaceHasRelationships(ctx, ds.SnapshotReader(tc.revision), tc.nsName)
+		require.NoError(err)
+		require.Equal(tc.expected, found, "%s at %s", tc.nsName, tc.revision)
+	}
+}
+
+// NamespaceDeleteCascadesToSubjectsTest tests that deleting a namespace along with its
+// relationships also removes relationships in which it appears only as the subject type.
+func NamespaceDeleteCascadesToSubjectsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	deletedRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, testfixtures.FolderNS.Name)
+	})
+	require.NoError(err)
+
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+	reader := ds.SnapshotReader(deletedRev)
+
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testfixtures.FolderNS.Name,
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter)
+
+	iter, err = reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType: testfixtures.FolderNS.Name,
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter)
+
+	for _, tplString := range testfixtures.StandardTuples {
+		tpl := tuple.Parse(tplString)
+		if tpl.ResourceAndRelation.Namespace == testfixtures.FolderNS.Name ||
+			tpl.Subject.Namespace == testfixtures.FolderNS.Name {
+			tRequire.NoTupleExists(ctx, tpl, deletedRev)
+		} else {
+			tRequire.TupleExists(ctx, tpl, deletedRev)
+		}
+	}
+}
+
+// ListNamespacesPaginationTest tests listing namespaces filtered by name prefix and paginated.
+func ListNamespacesPaginationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ctx := context.Background()
+
+	names := []string{"org/user", "other/user", "org/aab", "org/document", "org/a_b"}
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		for _, name := range names {
+			if err := rwt.WriteNamespaces(ctx, ns.Namespace(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(revision)
+	listNames := func(opts ...options.ListNamespacesOptionsOption) []string {
+		nsDefs, err := reader.ListNamespaces(ctx, opts...)
+		require.NoError(err)
+
+		found := make([]string, 0, len(nsDefs))
+		for _, nsDef := range nsDefs {
+			found = append(found, nsDef.Name)
+		}
+		return found
+	}
+
+	require.Equal([]string{"org/a_b", "org/aab", "org/document", "org/user", "other/user"}, listNames())
+	require.Equal([]string{"org/a_b", "org/aab", "org/document", "org/user"}, listNames(options.WithNamespacePrefix("org/")))
+	require.Equal([]string{"org/a_b"}, listNames(options.WithNamespacePrefix("org/a_")))
+	require.Empty(listNames(options.WithNamespacePrefix("unknown/")))
+
+	limit := uint64(2)
+	var pages [][]string
+	after := ""
+	for {
+		page := listNames(
+			options.WithNamespacePrefix("org/"),
+			options.WithNamespaceLimit(&limit),
+			options.WithAfterNamespace(after),
+		)
+		if len(page) == 0 {
+			break
+		}
+
+		pages = append(pages, page)
+		after = page[len(page)-1]
+	}
+	require.Equal([][]string{{"org/a_b", "org/aab"}, {"org/document", "org/user"}}, pages)
+}
+
 // StableNamespaceReadWriteTest tests writing a namespace to the datastore and reading it back,
 // ensuring that it does not change in any way and that the deserialized data matches that stored.
 func StableNamespaceReadWriteTest(t *testing.T, tester DatastoreTester) {
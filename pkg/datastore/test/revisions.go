@@ -2,6 +2,7 @@ package test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -9,9 +10,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	dispatch "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // RevisionQuantizationTest tests whether or not the requirements for revisions hold
@@ -88,3 +91,151 @@ func RevisionSerializationTest(t *testing.T, tester DatastoreTester) {
 	}
 	require.NoError(meta.Validate())
 }
+
+// RevisionAtTimeTest tests that a point in time maps to the latest revision committed at or
+// before it, and that points in time before the GC window are rejected.
+func RevisionAtTimeTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ctx := context.Background()
+	setupDatastore(ds, require)
+
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	firstTpl := makeTestTuple("first", "owner")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, firstTpl)
+	require.NoError(err)
+
+	time.Sleep(10 * time.Millisecond)
+	betweenWrites := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	secondTpl := makeTestTuple("second", "owner")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, secondTpl)
+	require.NoError(err)
+
+	betweenRev, err := ds.RevisionAtTime(ctx, betweenWrites)
+	require.NoError(err)
+	tRequire.TupleExists(ctx, firstTpl, betweenRev)
+	tRequire.NoTupleExists(ctx, secondTpl, betweenRev)
+
+	nowRev, err := ds.RevisionAtTime(ctx, time.Now())
+	require.NoError(err)
+	tRequire.TupleExists(ctx, firstTpl, nowRev)
+	tRequire.TupleExists(ctx, secondTpl, nowRev)
+
+	_, err = ds.RevisionAtTime(ctx, time.Now().Add(-2*veryLargeGCWindow))
+	require.ErrorAs(err, &datastore.ErrTimestampBeforeGCWindow{})
+}
+
+func DiffRevisionsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ctx := context.Background()
+	setupDatastore(ds, require)
+
+	unchanged := makeTestTuple("unchanged", "owner")
+	deleted := makeTestTuple("deleted", "owner")
+	startRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, unchanged, deleted)
+	require.NoError(err)
+
+	created := makeTestTuple("created", "owner")
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(created),
+			tuple.Delete(deleted),
+		})
+	})
+	require.NoError(err)
+
+	// A relationship both created and deleted between the revisions is not part of the diff.
+	transient := makeTestTuple("transient", "owner")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, transient)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, transient)
+	require.NoError(err)
+
+	// Touching an existing relationship without changing it is not part of the diff.
+	endRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, unchanged)
+	require.NoError(err)
+
+	diff, err := ds.DiffRevisions(ctx, startRevision, endRevision)
+	if errors.As(err, &datastore.ErrRevisionDiffUnsupported{}) {
+		t.Skip("datastore does not support diffing revisions")
+	}
+	require.NoError(err)
+
+	requireTuples := func(expected []*core.RelationTuple, found []*core.RelationTuple) {
+		expectedStrings := make([]string, 0, len(expected))
+		for _, tpl := range expected {
+			expectedStrings = append(expectedStrings, tuple.MustString(tpl))
+		}
+
+		foundStrings := make([]string, 0, len(found))
+		for _, tpl := range found {
+			foundStrings = append(foundStrings, tuple.MustString(tpl))
+		}
+
+		require.ElementsMatch(expectedStrings, foundStrings)
+	}
+
+	requireTuples([]*core.RelationTuple{created}, diff.Created)
+	requireTuples([]*core.RelationTuple{deleted}, diff.Deleted)
+
+	// Diffing a revision against itself finds no changes.
+	diff, err = ds.DiffRevisions(ctx, endRevision, endRevision)
+	require.NoError(err)
+	require.Empty(diff.Created)
+	require.Empty(diff.Deleted)
+
+	_, err = ds.DiffRevisions(ctx, endRevision, startRevision)
+	require.Error(err)
+
+	// A revision which only touched a relationship without changing it has an empty diff.
+	touchedRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, created)
+	require.NoError(err)
+
+	diff, err = ds.DiffRevisions(ctx, endRevision, touchedRevision)
+	require.NoError(err)
+	require.Empty(diff.Created)
+	require.Empty(diff.Deleted)
+}
+
+func DiffRevisionsOutsideGCWindowTest(t *testing.T, tester DatastoreTester) {
+	testGCDuration := 600 * time.Millisecond
+
+	require := require.New(t)
+
+	ds, err := tester.New(0, testGCDuration, 1)
+	require.NoError(err)
+	defer ds.Close()
+
+	ctx := context.Background()
+	setupDatastore(ds, require)
+
+	tpl := makeTestTuple("one", "one")
+	firstWrite, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	// Wait the duration required to allow the revision to expire
+	time.Sleep(testGCDuration * 2)
+
+	nextWrite, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	require.NoError(err)
+
+	_, err = ds.DiffRevisions(ctx, firstWrite, nextWrite)
+	if errors.As(err, &datastore.ErrRevisionDiffUnsupported{}) {
+		t.Skip("datastore does not support diffing revisions")
+	}
+
+	revisionErr := datastore.ErrInvalidRevision{}
+	require.ErrorAs(err, &revisionErr)
+	require.Equal(datastore.RevisionStale, revisionErr.Reason())
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 	"testing"
@@ -572,6 +574,426 @@ func CreateAlreadyExistingTest(t *testing.T, tester DatastoreTester) {
 	require.Contains(err.Error(), "could not CREATE")
 }
 
+// IdempotentWriteTest tests that retrying a write with the same idempotency key returns the
+// revision of the original write without re-applying it.
+func IdempotentWriteTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	tpl := makeTestTuple("foo", "tom")
+	writeWithKey := func(key string) (datastore.Revision, error) {
+		return ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tpl)})
+		}, options.WithIdempotencyKey(key))
+	}
+
+	firstRevision, err := writeWithKey("some-key")
+	if errors.As(err, &datastore.ErrIdempotencyKeysUnsupported{}) {
+		t.Skip("datastore does not support idempotency keys")
+	}
+	require.NoError(err)
+
+	retriedRevision, err := writeWithKey("some-key")
+	require.NoError(err)
+	require.True(firstRevision.Equal(retriedRevision))
+
+	_, err = writeWithKey("another-key")
+	require.ErrorAs(err, &common.CreateRelationshipExistsError{})
+}
+
+// RelationshipExpirationTest tests that relationships written with an expiration are treated as
+// deleted when read at revisions at or after the expiration.
+func RelationshipExpirationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	expiring := makeTestTuple("foo", "tom")
+	touched := makeTestTuple("bar", "tom")
+	permanent := makeTestTuple("foo", "sarah")
+	expiresAt := time.Now().Add(200 * time.Millisecond)
+
+	writtenRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.WithExpiration(expiring, expiresAt)),
+			tuple.Touch(tuple.WithExpiration(touched, expiresAt)),
+			tuple.Create(permanent),
+		})
+	})
+	if errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}) {
+		t.Skip("datastore does not support relationship expiration")
+	}
+	require.NoError(err)
+
+	tRequire.TupleExists(ctx, expiring, writtenRevision)
+	tRequire.TupleExists(ctx, touched, writtenRevision)
+	tRequire.TupleExists(ctx, permanent, writtenRevision)
+
+	// Touching the relationship again without an expiration removes its expiration.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, touched)
+	require.NoError(err)
+
+	time.Sleep(time.Until(expiresAt))
+	expiredRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, makeTestTuple("baz", "tom"))
+	require.NoError(err)
+
+	tRequire.NoTupleExists(ctx, expiring, expiredRevision)
+	tRequire.TupleExists(ctx, touched, expiredRevision)
+	tRequire.TupleExists(ctx, permanent, expiredRevision)
+
+	iter, err := ds.SnapshotReader(expiredRevision).ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        testUserNamespace,
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, touched, makeTestTuple("baz", "tom"))
+
+	// The relationship is still found at the revisions before its expiration.
+	tRequire.TupleExists(ctx, expiring, writtenRevision)
+
+	// An expired relationship can be created again.
+	recreatedRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	require.NoError(err)
+	tRequire.TupleExists(ctx, expiring, recreatedRevision)
+}
+
+// RelationshipExpirationChangesTest tests that relationships are read along with their
+// expiration, and that expiring relationships are reported as deleted by Watch and DiffRevisions
+// once they have expired.
+func RelationshipExpirationChangesTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	ds, startRevision := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(200 * time.Millisecond)
+	expiring := tuple.WithExpiration(makeTestTuple("foo", "tom"), expiresAt)
+	writtenRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	if errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}) {
+		t.Skip("datastore does not support relationship expiration")
+	}
+	require.NoError(err)
+
+	iter, err := ds.SnapshotReader(writtenRevision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:        testResourceNamespace,
+		OptionalResourceIds: []string{"foo"},
+	})
+	require.NoError(err)
+	read := iter.Next()
+	require.NoError(iter.Err())
+	iter.Close()
+	require.NotNil(read)
+	require.NotNil(read.ExpiresAt)
+	require.True(expiresAt.Equal(read.ExpiresAt.AsTime()))
+
+	changes, errchan := ds.Watch(ctx, writtenRevision)
+
+	time.Sleep(time.Until(expiresAt))
+	touched := makeTestTuple("bar", "tom")
+	expiredRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, touched)
+	require.NoError(err)
+
+	verifyUpdates(require, [][]*core.RelationTupleUpdate{
+		{tuple.Delete(expiring), tuple.Touch(touched)},
+	}, changes, errchan, false)
+
+	diff, err := ds.DiffRevisions(ctx, startRevision, writtenRevision)
+	if errors.As(err, &datastore.ErrRevisionDiffUnsupported{}) {
+		return
+	}
+	require.NoError(err)
+	require.Len(diff.Created, 1)
+	require.NotNil(diff.Created[0].ExpiresAt)
+	require.True(expiresAt.Equal(diff.Created[0].ExpiresAt.AsTime()))
+
+	diff, err = ds.DiffRevisions(ctx, writtenRevision, expiredRevision)
+	require.NoError(err)
+	require.Len(diff.Created, 1)
+	require.Equal(tuple.MustString(touched), tuple.MustString(diff.Created[0]))
+	require.Len(diff.Deleted, 1)
+	require.Equal(tuple.MustString(expiring), tuple.MustString(diff.Deleted[0]))
+}
+
+// RelationshipExistsTest tests checking for the existence of exact relationships, both at
+// snapshots and within read-write transactions.
+func RelationshipExistsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	tpl := makeTestTuple("foo", "tom")
+	usersetTpl := makeTestTuple("foo", "tom")
+	usersetTpl.Subject.Relation = "member"
+
+	writtenRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	deletedRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	require.NoError(err)
+
+	for _, tc := range []struct {
+		name     string
+		tpl      *core.RelationTuple
+		revision datastore.Revision
+		expected bool
+	}{
+		{"written", tpl, writtenRevision, true},
+		{"other subject relation", usersetTpl, writtenRevision, false},
+		{"other subject", makeTestTuple("foo", "sarah"), writtenRevision, false},
+		{"deleted", tpl, deletedRevision, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exists, err := datastore.RelationshipExists(ctx, ds.SnapshotReader(tc.revision), tc.tpl)
+			require.NoError(err)
+			require.Equal(tc.expected, exists)
+		})
+	}
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		exists, err := datastore.RelationshipExists(ctx, rwt, tpl)
+		require.NoError(err)
+		require.False(exists)
+
+		if err := rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tpl)}); err != nil {
+			return err
+		}
+
+		exists, err = datastore.RelationshipExists(ctx, rwt, tpl)
+		require.NoError(err)
+		require.True(exists)
+		return nil
+	})
+	require.NoError(err)
+}
+
+// WriteRelationshipsWithResultsTest tests that writing relationships reports whether each update
+// created, deleted or left unchanged a relationship.
+func WriteRelationshipsWithResultsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	existing := makeTestTuple("foo", "tom")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, existing)
+	require.NoError(err)
+
+	writeWithResults := func(mutations ...*core.RelationTupleUpdate) ([]datastore.RelationshipUpdateResult, datastore.Revision) {
+		var results []datastore.RelationshipUpdateResult
+		revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			var err error
+			results, err = datastore.WriteRelationshipsWithResults(ctx, rwt, mutations)
+			return err
+		})
+		require.NoError(err)
+		return results, revision
+	}
+
+	created := makeTestTuple("foo", "sarah")
+	missing := makeTestTuple("bar", "tom")
+	results, revision := writeWithResults(
+		tuple.Touch(existing),
+		tuple.Touch(created),
+		tuple.Delete(missing),
+	)
+	require.Equal([]datastore.RelationshipUpdateResult{
+		datastore.RelationshipUnchanged,
+		datastore.RelationshipCreated,
+		datastore.RelationshipUnchanged,
+	}, results)
+	tRequire.TupleExists(ctx, existing, revision)
+	tRequire.TupleExists(ctx, created, revision)
+
+	results, revision = writeWithResults(
+		tuple.Delete(existing),
+		tuple.Create(missing),
+	)
+	require.Equal([]datastore.RelationshipUpdateResult{
+		datastore.RelationshipDeleted,
+		datastore.RelationshipCreated,
+	}, results)
+	tRequire.NoTupleExists(ctx, existing, revision)
+	tRequire.TupleExists(ctx, missing, revision)
+
+	// A touch of an expired relationship creates it again.
+	expiring := makeTestTuple("baz", "tom")
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.WithExpiration(expiring, time.Now().Add(100*time.Millisecond))),
+		})
+	})
+	if errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}) {
+		return
+	}
+	require.NoError(err)
+	time.Sleep(200 * time.Millisecond)
+
+	results, _ = writeWithResults(tuple.Touch(expiring))
+	require.Equal([]datastore.RelationshipUpdateResult{datastore.RelationshipCreated}, results)
+}
+
+// RemoveSubjectTest tests deleting all relationships of a subject, across resource types.
+func RemoveSubjectTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	subject := tuple.ObjectAndRelation("folder", "company", tuple.Ellipsis)
+	removed := []*core.RelationTuple{
+		tuple.MustParse("document:companyplan#parent@folder:company#..."),
+		tuple.MustParse("folder:strategy#parent@folder:company#..."),
+	}
+	retained := []*core.RelationTuple{
+		tuple.MustParse("folder:company#owner@user:owner#..."),
+		tuple.MustParse("folder:company#viewer@folder:auditors#viewer"),
+	}
+
+	count, previewRevision, err := common.PreviewRemoveSubject(ctx, ds, subject)
+	require.NoError(err)
+	require.Equal(uint64(len(removed)), count)
+
+	// Previewing does not delete anything.
+	for _, tpl := range removed {
+		tRequire.TupleExists(ctx, tpl, previewRevision)
+	}
+
+	count, removedRevision, err := common.RemoveSubject(ctx, ds, subject)
+	require.NoError(err)
+	require.Equal(uint64(len(removed)), count)
+
+	for _, tpl := range removed {
+		tRequire.NoTupleExists(ctx, tpl, removedRevision)
+		tRequire.TupleExists(ctx, tpl, previewRevision)
+	}
+	for _, tpl := range retained {
+		tRequire.TupleExists(ctx, tpl, removedRevision)
+	}
+
+	// Removing the subject again finds nothing to delete.
+	count, _, err = common.RemoveSubject(ctx, ds, subject)
+	require.NoError(err)
+	require.Equal(uint64(0), count)
+}
+
+// MergeSubjectTest tests repointing all relationships of a subject to another subject.
+func MergeSubjectTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	oldSubject := tuple.ObjectAndRelation("user", "legal", tuple.Ellipsis)
+	newSubject := tuple.ObjectAndRelation("user", "merged", tuple.Ellipsis)
+
+	// The new subject already has one of the relationships of the old subject.
+	shared := tuple.MustParse("folder:company#viewer@user:merged")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, shared)
+	require.NoError(err)
+
+	beforeRevision, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	var oldRelationships []*core.RelationTuple
+	iter, err := ds.SnapshotReader(beforeRevision).ReverseQueryRelationships(ctx, onrToSubjectsFilter(oldSubject))
+	require.NoError(err)
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		oldRelationships = append(oldRelationships, tpl)
+	}
+	require.NoError(iter.Err())
+	iter.Close()
+	require.NotEmpty(oldRelationships)
+
+	count, mergedRevision, err := common.MergeSubject(ctx, ds, oldSubject, newSubject)
+	require.NoError(err)
+	require.Equal(uint64(len(oldRelationships)), count)
+
+	for _, tpl := range oldRelationships {
+		tRequire.NoTupleExists(ctx, tpl, mergedRevision)
+		tRequire.TupleExists(ctx, tpl, beforeRevision)
+
+		merged := tpl.CloneVT()
+		merged.Subject = newSubject
+		tRequire.TupleExists(ctx, merged, mergedRevision)
+	}
+
+	// The relationship the new subject already had was not duplicated.
+	iter, err = ds.SnapshotReader(mergedRevision).QueryRelationships(ctx, datastore.RelationshipsFilterFromTuple(shared))
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, shared)
+
+	// Merging again finds nothing to repoint.
+	count, _, err = common.MergeSubject(ctx, ds, oldSubject, newSubject)
+	require.NoError(err)
+	require.Equal(uint64(0), count)
+}
+
+// MergeSubjectExpiringRelationshipTest tests that repointing the relationships of a subject keeps
+// their expiration.
+func MergeSubjectExpiringRelationshipTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(1 * time.Hour)
+	expiring := tuple.WithExpiration(makeTestTuple("foo", "tom"), expiresAt)
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	if errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}) {
+		t.Skip("datastore does not support relationship expiration")
+	}
+	require.NoError(err)
+
+	newSubject := tuple.ObjectAndRelation("user", "merged", tuple.Ellipsis)
+	count, mergedRevision, err := common.MergeSubject(ctx, ds, expiring.Subject, newSubject)
+	require.NoError(err)
+	require.Equal(uint64(1), count)
+
+	merged := expiring.CloneVT()
+	merged.Subject = newSubject
+	iter, err := ds.SnapshotReader(mergedRevision).QueryRelationships(ctx, datastore.RelationshipsFilterFromTuple(merged))
+	require.NoError(err)
+	defer iter.Close()
+
+	found := iter.Next()
+	require.NoError(iter.Err())
+	require.NotNil(found)
+	require.NotNil(found.ExpiresAt)
+	require.True(expiresAt.Equal(found.ExpiresAt.AsTime()))
+}
+
 // TouchAlreadyExistingTest tests touching a relationship twice.
 func TouchAlreadyExistingTest(t *testing.T, tester DatastoreTester) {
 	require := require.New(t)
@@ -645,6 +1067,387 @@ func UsersetsTest(t *testing.T, tester DatastoreTester) {
 	})
 }
 
+// EllipsisRelationNormalizationTest tests that relationships written with an empty subject
+// relation and with the ellipsis are stored identically, and found by filters using either form.
+func EllipsisRelationNormalizationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+	defer ds.Close()
+
+	setupDatastore(ds, require)
+
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	withSubjectRelation := func(tpl *core.RelationTuple, relation string) *core.RelationTuple {
+		updated := tpl.CloneVT()
+		updated.Subject.Relation = relation
+		return updated
+	}
+
+	// Write one relationship with each form of the subject relation.
+	withEmpty := withSubjectRelation(makeTestTuple("first", "tom"), "")
+	withEllipsis := withSubjectRelation(makeTestTuple("second", "sarah"), ellipsis)
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, withEmpty, withEllipsis)
+	require.NoError(err)
+
+	// Both are stored with the ellipsis.
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testResourceNamespace,
+	})
+	require.NoError(err)
+	for found := iter.Next(); found != nil; found = iter.Next() {
+		require.Equal(ellipsis, found.Subject.Relation)
+	}
+	require.NoError(iter.Err())
+	iter.Close()
+
+	// Each is found by filters using either form.
+	for _, written := range []*core.RelationTuple{withEmpty, withEllipsis} {
+		stored := withSubjectRelation(written, ellipsis)
+
+		for _, relation := range []string{"", ellipsis} {
+			queried := withSubjectRelation(written, relation)
+
+			iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+				ResourceType: testResourceNamespace,
+			}, options.WithUsersets(queried.Subject))
+			require.NoError(err)
+			tRequire.VerifyIteratorResults(iter, stored)
+
+			iter, err = ds.SnapshotReader(revision).ReverseQueryRelationships(ctx, *datastore.RelationshipsFilterFromTuple(queried).OptionalSubjectsFilter)
+			require.NoError(err)
+			tRequire.VerifyIteratorResults(iter, stored)
+
+			iter, err = ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilterFromTuple(queried))
+			require.NoError(err)
+			tRequire.VerifyIteratorResults(iter, stored)
+
+			exists, err := datastore.RelationshipExists(ctx, ds.SnapshotReader(revision), queried)
+			require.NoError(err)
+			require.True(exists)
+		}
+	}
+
+	// Creating a relationship again with the other form fails, as it already exists.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, withSubjectRelation(withEllipsis, ""))
+	require.Error(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, withSubjectRelation(withEmpty, ellipsis))
+	require.Error(err)
+
+	// Deleting with the other form deletes the stored relationship.
+	deletedRevision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, withSubjectRelation(withEllipsis, ""))
+	require.NoError(err)
+	tRequire.NoTupleExists(ctx, withEllipsis, deletedRevision)
+	tRequire.TupleExists(ctx, withSubjectRelation(withEmpty, ellipsis), deletedRevision)
+}
+
+// QueryRelationshipsForResourceTypesTest tests that relationships for multiple resource types
+// can be read in a single query, respecting deletions and limits.
+func QueryRelationshipsForResourceTypesTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	readAll := func(rev datastore.Revision, resourceTypes ...string) []*core.RelationTuple {
+		var found []*core.RelationTuple
+		for _, resourceType := range resourceTypes {
+			iter, err := ds.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{
+				ResourceType: resourceType,
+			})
+			require.NoError(err)
+			for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+				found = append(found, tpl)
+			}
+			require.NoError(iter.Err())
+			iter.Close()
+		}
+		return found
+	}
+
+	expected := readAll(revision, "document", "folder")
+	require.NotEmpty(expected)
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationshipsForResourceTypes(ctx, []string{"document", "folder"})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, expected...)
+
+	// Ensure the limit applies across all of the resource types.
+	iter, err = ds.SnapshotReader(revision).QueryRelationshipsForResourceTypes(
+		ctx,
+		[]string{"document", "folder"},
+		options.WithLimit(options.LimitOne),
+	)
+	require.NoError(err)
+	tRequire.VerifyIteratorCount(iter, 1)
+
+	// Delete a relationship and ensure it is no longer returned.
+	deleted := expected[0]
+	deletedRev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, deleted)
+	require.NoError(err)
+
+	iter, err = ds.SnapshotReader(deletedRev).QueryRelationshipsForResourceTypes(ctx, []string{"document", "folder"})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, expected[1:]...)
+
+	// Ensure the original revision still sees the deleted relationship.
+	iter, err = ds.SnapshotReader(revision).QueryRelationshipsForResourceTypes(ctx, []string{"document", "folder"})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, expected...)
+}
+
+func QueryRelationshipsWithResourceIDsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+	reader := ds.SnapshotReader(revision)
+
+	readAll := func(filter datastore.RelationshipsFilter) []*core.RelationTuple {
+		iter, err := reader.QueryRelationships(ctx, filter)
+		require.NoError(err)
+		defer iter.Close()
+
+		var found []*core.RelationTuple
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			found = append(found, tpl)
+		}
+		require.NoError(iter.Err())
+		return found
+	}
+
+	resourceIDs := []string{"masterplan", "companyplan"}
+
+	var expected []*core.RelationTuple
+	for _, resourceID := range resourceIDs {
+		expected = append(expected, readAll(datastore.RelationshipsFilter{
+			ResourceType:             "document",
+			OptionalResourceIds:      []string{resourceID},
+			OptionalResourceRelation: "parent",
+		})...)
+	}
+	require.Len(expected, 3)
+
+	// A single query for all of the resources finds the relationships of each.
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIds:      resourceIDs,
+		OptionalResourceRelation: "parent",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, expected...)
+
+	// An empty set of resource IDs does not filter the resources, so the parent of the
+	// healthplan document is found as well.
+	iter, err = reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIds:      []string{},
+		OptionalResourceRelation: "parent",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, readAll(datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceRelation: "parent",
+	})...)
+}
+
+func QueryRelationshipsWithResourceIDPrefixTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	firstOwner := tuple.MustParse("document:2024_first#owner@user:tom")
+	firstViewer := tuple.MustParse("document:2024_first#viewer@user:sarah")
+	second := tuple.MustParse("document:2024_second#viewer@user:tom")
+	noUnderscore := tuple.MustParse("document:2024a#viewer@user:tom")
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		firstOwner,
+		firstViewer,
+		second,
+		noUnderscore,
+		tuple.MustParse("document:2023_first#viewer@user:tom"),
+	)
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(revision)
+
+	// The underscore of the prefix is matched literally.
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIDPrefix: "2024_",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, firstOwner, firstViewer, second)
+
+	iter, err = reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIDPrefix: "2024",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, firstOwner, firstViewer, second, noUnderscore)
+
+	// The prefix is combined with the other fields of the filter.
+	iter, err = reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIDPrefix: "2024_",
+		OptionalResourceRelation: "viewer",
+		OptionalSubjectsFilter: &datastore.SubjectsFilter{
+			SubjectType:        "user",
+			OptionalSubjectIds: []string{"tom"},
+		},
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, second)
+
+	iter, err = reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIds:      []string{"2024_first", "2024a"},
+		OptionalResourceIDPrefix: "2024_",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, firstOwner, firstViewer)
+
+	iter, err = reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceIDPrefix: "2025",
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter)
+}
+
+func QueryRelationshipsSortedTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+	reader := ds.SnapshotReader(revision)
+
+	readAll := func(opts ...options.QueryOptionsOption) []string {
+		iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+			ResourceType: testfixtures.DocumentNS.Name,
+		}, opts...)
+		require.NoError(err)
+		defer iter.Close()
+
+		var found []string
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			found = append(found, tuple.MustString(tpl))
+		}
+		require.NoError(iter.Err())
+		return found
+	}
+
+	unsorted := readAll()
+	require.Greater(len(unsorted), 2)
+
+	for name, order := range map[string]options.SortOrder{
+		"by resource": options.ByResource,
+		"by relation": options.ByRelation,
+		"by subject":  options.BySubject,
+	} {
+		order := order
+		t.Run(name, func(t *testing.T) {
+			expected := make([]*core.RelationTuple, 0, len(unsorted))
+			for _, tplStr := range unsorted {
+				expected = append(expected, tuple.MustParse(tplStr))
+			}
+			sort.Slice(expected, func(i, j int) bool {
+				return order.LessThan(expected[i], expected[j])
+			})
+
+			expectedStrs := make([]string, 0, len(expected))
+			for _, tpl := range expected {
+				expectedStrs = append(expectedStrs, tuple.MustString(tpl))
+			}
+
+			require.Equal(expectedStrs, readAll(options.WithSort(order)))
+
+			// Ensure a limited read returns the prefix of the full ordering.
+			limit := uint64(2)
+			require.Equal(expectedStrs[:limit], readAll(options.WithSort(order), options.WithLimit(&limit)))
+		})
+	}
+}
+
+func ReverseQueryWildcardSubjectsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+	ctx := context.Background()
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+
+	direct := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	wildcard := tuple.MustParse("document:publicdoc#viewer@user:*")
+	other := tuple.MustParse("document:seconddoc#viewer@user:fred")
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, direct, wildcard, other)
+	require.NoError(err)
+
+	reader := ds.SnapshotReader(revision)
+
+	// By default, only exact matches on the subject ID are returned.
+	iter, err := reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, direct)
+
+	// With wildcards included, relationships to the wildcard of the subject type are also returned.
+	iter, err = reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:             "user",
+		OptionalSubjectIds:      []string{"tom"},
+		IncludeWildcardSubjects: true,
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter, direct, wildcard)
+
+	// The resource relation filter still applies to wildcard relationships.
+	iter, err = reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:             "user",
+		OptionalSubjectIds:      []string{"tom"},
+		IncludeWildcardSubjects: true,
+	}, options.WithResRelation(&options.ResourceRelation{
+		Namespace: "document",
+		Relation:  "owner",
+	}))
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter)
+
+	// Wildcards of other subject types are never returned.
+	iter, err = reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:             "folder",
+		OptionalSubjectIds:      []string{"tom"},
+		IncludeWildcardSubjects: true,
+	})
+	require.NoError(err)
+	tRequire.VerifyIteratorResults(iter)
+}
+
 func MultipleReadsInRWTTest(t *testing.T, tester DatastoreTester) {
 	require := require.New(t)
 
@@ -672,6 +1475,206 @@ func MultipleReadsInRWTTest(t *testing.T, tester DatastoreTester) {
 	require.NoError(err)
 }
 
+// ConsistencyValidationTest tests that a random sequence of writes leaves a datastore which
+// supports validating its consistency in a consistent state.
+func ConsistencyValidationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+	defer ds.Close()
+
+	validator, ok := ds.(datastore.ConsistencyValidator)
+	if !ok {
+		t.Skip("datastore does not support validating its consistency")
+	}
+
+	setupDatastore(ds, require)
+	ctx := context.Background()
+
+	operations := []core.RelationTupleUpdate_Operation{
+		core.RelationTupleUpdate_TOUCH,
+		core.RelationTupleUpdate_DELETE,
+	}
+
+	// NOTE: the seed is logged so that a failing sequence of writes can be replayed.
+	seed := time.Now().UnixNano()
+	t.Logf("writing relationships with random seed %d", seed)
+
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < 50; i++ {
+		tpl := makeTestTuple(fmt.Sprintf("resource%d", rnd.Intn(5)), fmt.Sprintf("user%d", rnd.Intn(5)))
+		_, err := common.WriteTuples(ctx, ds, operations[rnd.Intn(len(operations))], tpl)
+		require.NoError(err)
+		require.NoError(validator.ValidateConsistency(ctx))
+	}
+}
+
+// RelationshipHistoryTest tests that a datastore which retains the versions of a relationship
+// returns each of them, ordered by creation.
+func RelationshipHistoryTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	historyReader, ok := ds.(datastore.RelationshipHistoryReader)
+	if !ok {
+		t.Skip("datastore does not support reading relationship history")
+	}
+
+	setupDatastore(ds, require)
+	ctx := context.Background()
+
+	tpl := makeTestTuple("resource", "user")
+	other := makeTestTuple("resource", "other")
+
+	firstCreated, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl, other)
+	require.NoError(err)
+
+	touched, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, tpl)
+	require.NoError(err)
+
+	deleted, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	require.NoError(err)
+
+	recreated, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	versions, err := historyReader.RelationshipHistory(ctx, tpl)
+	require.NoError(err)
+	require.Len(versions, 3)
+
+	expected := []struct {
+		createdAt datastore.Revision
+		deletedAt datastore.Revision
+	}{
+		{firstCreated, touched},
+		{touched, deleted},
+		{recreated, datastore.NoRevision},
+	}
+	for i, version := range versions {
+		require.Equal(tuple.MustString(tpl), tuple.MustString(version.Relationship))
+		require.True(expected[i].createdAt.Equal(version.CreatedAt), "version %d created at %s, expected %s", i, version.CreatedAt, expected[i].createdAt)
+		if expected[i].deletedAt == datastore.NoRevision {
+			require.Equal(datastore.NoRevision, version.DeletedAt)
+		} else {
+			require.True(expected[i].deletedAt.Equal(version.DeletedAt), "version %d deleted at %s, expected %s", i, version.DeletedAt, expected[i].deletedAt)
+		}
+	}
+
+	versions, err = historyReader.RelationshipHistory(ctx, makeTestTuple("resource", "unknown"))
+	require.NoError(err)
+	require.Empty(versions)
+}
+
+// RelationshipRevisionsTest tests that a reader which can read the revisions of relationships
+// returns the revision which wrote each, along with the relationship as QueryRelationships would.
+func RelationshipRevisionsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	setupDatastore(ds, require)
+	ctx := context.Background()
+
+	tpl := makeTestTuple("resource", "user")
+	other := makeTestTuple("resource", "other")
+
+	created, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl, other)
+	require.NoError(err)
+
+	touched, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, tpl)
+	require.NoError(err)
+
+	filter := datastore.RelationshipsFilter{ResourceType: testResourceNamespace}
+	versions, err := datastore.QueryRelationshipsWithRevisions(ctx, ds.SnapshotReader(touched), filter, options.WithSort(options.BySubject))
+	if errors.As(err, &datastore.ErrRelationshipRevisionsUnsupported{}) {
+		t.Skip("datastore does not support reading relationship revisions")
+	}
+	require.NoError(err)
+	require.Len(versions, 2)
+
+	require.Equal(tuple.MustString(other), tuple.MustString(versions[0].Relationship))
+	require.True(created.Equal(versions[0].CreatedAt), "created at %s, expected %s", versions[0].CreatedAt, created)
+	require.Equal(datastore.NoRevision, versions[0].DeletedAt)
+
+	require.Equal(tuple.MustString(tpl), tuple.MustString(versions[1].Relationship))
+	require.True(touched.Equal(versions[1].CreatedAt), "created at %s, expected %s", versions[1].CreatedAt, touched)
+	require.Equal(datastore.NoRevision, versions[1].DeletedAt)
+
+	versions, err = datastore.QueryRelationshipsWithRevisions(ctx, ds.SnapshotReader(touched), filter, options.WithSort(options.BySubject), options.WithLimit(options.LimitOne))
+	require.NoError(err)
+	require.Len(versions, 1)
+	require.Equal(tuple.MustString(other), tuple.MustString(versions[0].Relationship))
+
+	// The deleted relationship is no longer read at the revision which deleted it.
+	deleted, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, other)
+	require.NoError(err)
+
+	versions, err = datastore.QueryRelationshipsWithRevisions(ctx, ds.SnapshotReader(deleted), filter)
+	require.NoError(err)
+	require.Len(versions, 1)
+	require.Equal(tuple.MustString(tpl), tuple.MustString(versions[0].Relationship))
+	require.Equal(datastore.NoRevision, versions[0].DeletedAt)
+
+	// The expiration of a relationship is read along with its revision.
+	expiring := makeTestTuple("expiring", "user")
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	expiringRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.WithExpiration(expiring, expiresAt)),
+		})
+	})
+	if errors.As(err, &datastore.ErrRelationshipExpirationUnsupported{}) {
+		return
+	}
+	require.NoError(err)
+
+	versions, err = datastore.QueryRelationshipsWithRevisions(ctx, ds.SnapshotReader(expiringRevision), datastore.RelationshipsFilter{
+		ResourceType:        testResourceNamespace,
+		OptionalResourceIds: []string{"expiring"},
+	})
+	require.NoError(err)
+	require.Len(versions, 1)
+	require.True(expiringRevision.Equal(versions[0].CreatedAt), "created at %s, expected %s", versions[0].CreatedAt, expiringRevision)
+	require.NotNil(versions[0].Relationship.ExpiresAt)
+	require.True(expiresAt.Equal(versions[0].Relationship.ExpiresAt.AsTime()), "expires at %s, expected %s", versions[0].Relationship.ExpiresAt.AsTime(), expiresAt)
+}
+
+// RelationshipRevisionsDeletedAtTest tests the DeletedAt of a relationship read with its
+// revisions at a past revision, before it was deleted. Datastores which retain the rows of deleted
+// relationships, and so can tell when they were deleted, must return the deleting revision; all
+// others must return NoRevision.
+func RelationshipRevisionsDeletedAtTest(t *testing.T, tester DatastoreTester, retainsDeletions bool) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	setupDatastore(ds, require)
+	ctx := context.Background()
+
+	tpl := makeTestTuple("resource", "user")
+	created, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+
+	deleted, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	require.NoError(err)
+
+	versions, err := datastore.QueryRelationshipsWithRevisions(ctx, ds.SnapshotReader(created), datastore.RelationshipsFilter{ResourceType: testResourceNamespace})
+	require.NoError(err)
+	require.Len(versions, 1)
+	require.True(created.Equal(versions[0].CreatedAt), "created at %s, expected %s", versions[0].CreatedAt, created)
+
+	if !retainsDeletions {
+		require.Equal(datastore.NoRevision, versions[0].DeletedAt)
+		return
+	}
+	require.True(deleted.Equal(versions[0].DeletedAt), "deleted at %s, expected %s", versions[0].DeletedAt, deleted)
+}
+
 // ConcurrentWriteSerializationTest uses goroutines and channels to intentionally set up a
 // deadlocking dependency between transactions.
 func ConcurrentWriteSerializationTest(t *testing.T, tester DatastoreTester) {
@@ -12,10 +12,12 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/scylladb/go-set/strset"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/namespace"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
 )
@@ -125,33 +127,42 @@ func verifyUpdates(
 ) {
 	for _, expected := range testUpdates {
 		changeWait := time.NewTimer(waitForChangesTimeout)
-		select {
-		case change, ok := <-changes:
-			if !ok {
-				require.True(expectDisconnect, "unexpected disconnect")
-				errWait := time.NewTimer(waitForChangesTimeout)
-				select {
-				case err := <-errchan:
-					require.True(errors.As(err, &datastore.ErrWatchDisconnected{}))
+	waitForChange:
+		for {
+			select {
+			case change, ok := <-changes:
+				if ok && change.IsCheckpoint {
+					// Checkpoints carry no changes, and may be emitted between transactions.
+					continue
+				}
+
+				if !ok {
+					require.True(expectDisconnect, "unexpected disconnect")
+					errWait := time.NewTimer(waitForChangesTimeout)
+					select {
+					case err := <-errchan:
+						require.True(errors.As(err, &datastore.ErrWatchDisconnected{}))
+						return
+					case <-errWait.C:
+						require.Fail("Timed out waiting for ErrWatchDisconnected")
+					}
 					return
-				case <-errWait.C:
-					require.Fail("Timed out waiting for ErrWatchDisconnected")
 				}
-				return
-			}
 
-			expectedChangeSet := setOfChanges(expected)
-			actualChangeSet := setOfChanges(change.Changes)
+				expectedChangeSet := setOfChanges(expected)
+				actualChangeSet := setOfChanges(change.Changes)
 
-			missingExpected := strset.Difference(expectedChangeSet, actualChangeSet)
-			unexpected := strset.Difference(actualChangeSet, expectedChangeSet)
+				missingExpected := strset.Difference(expectedChangeSet, actualChangeSet)
+				unexpected := strset.Difference(actualChangeSet, expectedChangeSet)
 
-			require.True(missingExpected.IsEmpty(), "expected changes missing: %s", missingExpected)
-			require.True(unexpected.IsEmpty(), "unexpected changes: %s", unexpected)
+				require.True(missingExpected.IsEmpty(), "expected changes missing: %s", missingExpected)
+				require.True(unexpected.IsEmpty(), "unexpected changes: %s", unexpected)
 
-			time.Sleep(1 * time.Millisecond)
-		case <-changeWait.C:
-			require.Fail("Timed out", "waiting for changes: %s", expected)
+				time.Sleep(1 * time.Millisecond)
+			case <-changeWait.C:
+				require.Fail("Timed out", "waiting for changes: %s", expected)
+			}
+			break waitForChange
 		}
 	}
 
@@ -189,6 +200,10 @@ func WatchCancelTest(t *testing.T, tester DatastoreTester) {
 		changeWait := time.NewTimer(waitForChangesTimeout)
 		select {
 		case created, ok := <-changes:
+			if ok && created.IsCheckpoint {
+				continue
+			}
+
 			if ok {
 				foundDiff := cmp.Diff(
 					[]*core.RelationTupleUpdate{tuple.Touch(makeTestTuple("test", "test"))},
@@ -214,3 +229,97 @@ func WatchCancelTest(t *testing.T, tester DatastoreTester) {
 		}
 	}
 }
+
+// WatchSchemaTest tests whether or not the changes to namespace and caveat definitions are
+// reported by watches of a particular datastore.
+func WatchSchemaTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	features, err := ds.Features(ctx)
+	require.NoError(err)
+	if !features.WatchSchema.Enabled {
+		t.Skip("datastore does not report schema changes from watch")
+	}
+
+	skipIfNotCaveatStorer(t, ds)
+
+	startRevision := setupDatastore(ds, require)
+	changes, errchan := datastore.WatchSchema(ctx, ds, startRevision)
+
+	otherNS := namespace.Namespace("test/other")
+	updatedOtherNS := namespace.Namespace("test/other", namespace.Relation("viewer", nil))
+	otherCaveat := createCoreCaveat(t)
+	otherCaveat.Name = "test/othercaveat"
+
+	createRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteNamespaces(ctx, otherNS); err != nil {
+			return err
+		}
+		return rwt.WriteCaveats(ctx, []*core.CaveatDefinition{otherCaveat})
+	})
+	require.NoError(err)
+
+	// Writes of relationships alone are not reported.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("foo", "bar"))
+	require.NoError(err)
+
+	updateRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, updatedOtherNS)
+	})
+	require.NoError(err)
+
+	deleteRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.DeleteNamespaces(ctx, datastore.DeleteNamespacesAndRelationships, otherNS.Name); err != nil {
+			return err
+		}
+		return rwt.DeleteCaveats(ctx, []string{otherCaveat.Name})
+	})
+	require.NoError(err)
+
+	expected := []struct {
+		revision          datastore.Revision
+		changed           []datastore.SchemaDefinition
+		deletedNamespaces []string
+		deletedCaveats    []string
+	}{
+		{createRevision, []datastore.SchemaDefinition{otherNS, otherCaveat}, nil, nil},
+		{updateRevision, []datastore.SchemaDefinition{updatedOtherNS}, nil, nil},
+		{deleteRevision, nil, []string{otherNS.Name}, []string{otherCaveat.Name}},
+	}
+
+	for _, expectedChange := range expected {
+		changeWait := time.NewTimer(waitForChangesTimeout)
+		select {
+		case change, ok := <-changes:
+			require.True(ok, "changes closed early")
+			require.True(expectedChange.revision.Equal(change.Revision), "expected revision %s, found %s", expectedChange.revision, change.Revision)
+
+			require.Len(change.ChangedDefinitions, len(expectedChange.changed))
+			for _, expectedDef := range expectedChange.changed {
+				found := false
+				for _, changedDef := range change.ChangedDefinitions {
+					if proto.Equal(expectedDef.(proto.Message), changedDef.(proto.Message)) {
+						found = true
+						break
+					}
+				}
+				require.True(found, "missing changed definition %s", expectedDef.GetName())
+			}
+
+			require.Equal(expectedChange.deletedNamespaces, change.DeletedNamespaces)
+			require.Equal(expectedChange.deletedCaveats, change.DeletedCaveats)
+
+		case err := <-errchan:
+			require.Failf("Failed waiting for schema changes", "error: %v", err)
+
+		case <-changeWait.C:
+			require.Fail("Timed out waiting for schema changes")
+		}
+	}
+}
@@ -1,13 +1,158 @@
 package datastore
 
 import (
+	"context"
 	"errors"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
 var errClosedIterator = errors.New("unable to iterate: iterator closed")
 
+var limitOne uint64 = 1
+
+// RelationshipExists returns whether a live relationship with the same resource, relation and
+// subject as the given tuple exists in the given reader, regardless of its caveat. Readers which
+// implement RelationshipExistenceChecker answer without loading the relationship; for all others,
+// the relationship is queried.
+func RelationshipExists(ctx context.Context, reader Reader, tpl *core.RelationTuple) (bool, error) {
+	if checker, ok := reader.(RelationshipExistenceChecker); ok {
+		return checker.RelationshipExists(ctx, tpl)
+	}
+
+	iter, err := reader.QueryRelationships(ctx, RelationshipsFilterFromTuple(tpl), options.WithLimit(&limitOne))
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+
+	found := iter.Next() != nil
+	if iter.Err() != nil {
+		return false, iter.Err()
+	}
+	return found, nil
+}
+
+// NamespaceHasRelationships returns whether any live relationship in the given reader has a
+// resource or subject of the namespace, without counting them. Readers which implement
+// NamespaceRelationshipsChecker answer directly; for all others, the relationships of the
+// namespace are queried with a limit of one.
+func NamespaceHasRelationships(ctx context.Context, reader Reader, nsName string) (bool, error) {
+	if checker, ok := reader.(NamespaceRelationshipsChecker); ok {
+		return checker.NamespaceHasRelationships(ctx, nsName)
+	}
+
+	found, err := nonEmpty(reader.QueryRelationships(ctx, RelationshipsFilter{ResourceType: nsName}, options.WithLimit(&limitOne)))
+	if err != nil || found {
+		return found, err
+	}
+
+	return nonEmpty(reader.ReverseQueryRelationships(ctx, SubjectsFilter{SubjectType: nsName}, options.WithReverseLimit(&limitOne)))
+}
+
+func nonEmpty(iter RelationshipIterator, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+
+	found := iter.Next() != nil
+	if iter.Err() != nil {
+		return false, iter.Err()
+	}
+	return found, nil
+}
+
+// WriteRelationshipsWithResults writes the given mutations in the given transaction, returning
+// whether each created, deleted or left unchanged a relationship, in the order given.
+// Transactions which implement RelationshipUpdateReporter determine this as part of the write; for
+// all others, the existence of each touched or deleted relationship is checked before writing.
+func WriteRelationshipsWithResults(ctx context.Context, rwt ReadWriteTransaction, mutations []*core.RelationTupleUpdate) ([]RelationshipUpdateResult, error) {
+	if reporter, ok := rwt.(RelationshipUpdateReporter); ok {
+		return reporter.WriteRelationshipsWithResults(ctx, mutations)
+	}
+
+	results := make([]RelationshipUpdateResult, 0, len(mutations))
+	for _, mutation := range mutations {
+		if mutation.Operation == core.RelationTupleUpdate_CREATE {
+			results = append(results, RelationshipCreated)
+			continue
+		}
+
+		exists, err := RelationshipExists(ctx, rwt, mutation.Tuple)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, UpdateResultFor(mutation.Operation, exists))
+	}
+
+	if err := rwt.WriteRelationships(ctx, mutations); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateResultFor returns the result of applying an update with the given operation to a
+// relationship which did or did not exist beforehand.
+func UpdateResultFor(operation core.RelationTupleUpdate_Operation, existed bool) RelationshipUpdateResult {
+	switch {
+	case operation == core.RelationTupleUpdate_DELETE && existed:
+		return RelationshipDeleted
+	case operation != core.RelationTupleUpdate_DELETE && !existed:
+		return RelationshipCreated
+	default:
+		return RelationshipUnchanged
+	}
+}
+
+// RelationshipsFilterFromTuple constructs a RelationshipsFilter matching exactly the relationship
+// with the same resource, relation and subject as the given tuple.
+func RelationshipsFilterFromTuple(tpl *core.RelationTuple) RelationshipsFilter {
+	relationFilter := SubjectRelationFilter{}
+	if tpl.Subject.Relation == Ellipsis || tpl.Subject.Relation == "" {
+		relationFilter = relationFilter.WithEllipsisRelation()
+	} else {
+		relationFilter = relationFilter.WithNonEllipsisRelation(tpl.Subject.Relation)
+	}
+
+	return RelationshipsFilter{
+		ResourceType:             tpl.ResourceAndRelation.Namespace,
+		OptionalResourceIds:      []string{tpl.ResourceAndRelation.ObjectId},
+		OptionalResourceRelation: tpl.ResourceAndRelation.Relation,
+		OptionalSubjectsFilter: &SubjectsFilter{
+			SubjectType:        tpl.Subject.Namespace,
+			OptionalSubjectIds: []string{tpl.Subject.ObjectId},
+			RelationFilter:     relationFilter,
+		},
+	}
+}
+
+// CanonicalizeSubjectRelations returns the given mutations with each subject relation in the form
+// in which it is stored: an empty subject relation is replaced by Ellipsis, so that relationships
+// written with either form are stored, and read back, identically. Mutations which must be changed
+// are copied rather than modified in place.
+func CanonicalizeSubjectRelations(mutations []*core.RelationTupleUpdate) []*core.RelationTupleUpdate {
+	canonicalized := mutations
+	copied := false
+	for index, mutation := range mutations {
+		if mutation.Tuple.Subject.Relation != "" {
+			continue
+		}
+
+		if !copied {
+			canonicalized = make([]*core.RelationTupleUpdate, len(mutations))
+			copy(canonicalized, mutations)
+			copied = true
+		}
+
+		updated := mutation.CloneVT()
+		updated.Tuple.Subject.Relation = Ellipsis
+		canonicalized[index] = updated
+	}
+	return canonicalized
+}
+
 // NewSliceRelationshipIterator creates a datastore.TupleIterator instance from a materialized slice of tuples.
 func NewSliceRelationshipIterator(tuples []*core.RelationTuple) RelationshipIterator {
 	return &sliceRelationshipIterator{tuples: tuples}
@@ -0,0 +1,40 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestCanonicalizeSubjectRelations(t *testing.T) {
+	withEllipsis := &core.RelationTupleUpdate{
+		Operation: core.RelationTupleUpdate_CREATE,
+		Tuple:     tuple.MustParse("document:first#viewer@user:tom"),
+	}
+	withRelation := &core.RelationTupleUpdate{
+		Operation: core.RelationTupleUpdate_CREATE,
+		Tuple:     tuple.MustParse("document:first#viewer@group:editors#member"),
+	}
+
+	// Mutations already in their stored form are returned as is.
+	mutations := []*core.RelationTupleUpdate{withEllipsis, withRelation}
+	canonicalized := CanonicalizeSubjectRelations(mutations)
+	require.Equal(t, mutations, canonicalized)
+	require.Same(t, &mutations[0], &canonicalized[0])
+
+	// An empty subject relation is replaced by the ellipsis, without changing the given mutation.
+	withEmpty := withEllipsis.CloneVT()
+	withEmpty.Tuple.Subject.Relation = ""
+
+	mutations = []*core.RelationTupleUpdate{withRelation, withEmpty}
+	canonicalized = CanonicalizeSubjectRelations(mutations)
+	require.Len(t, canonicalized, 2)
+	require.Same(t, withRelation, canonicalized[0])
+	require.Equal(t, Ellipsis, canonicalized[1].Tuple.Subject.Relation)
+	require.True(t, withEllipsis.EqualVT(canonicalized[1]))
+	require.Equal(t, "", withEmpty.Tuple.Subject.Relation)
+	require.Same(t, withEmpty, mutations[1])
+}
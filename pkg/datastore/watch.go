@@ -0,0 +1,147 @@
+package datastore
+
+import (
+	"context"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// RelationshipChange represents a single change to a relationship, as found by
+// WatchRelationships.
+type RelationshipChange struct {
+	// Revision is the revision of the transaction in which the change was made.
+	Revision Revision
+
+	// Operation is the operation performed on the relationship. Datastores report writes of
+	// relationships, whether created or updated, as TOUCH.
+	Operation core.RelationTupleUpdate_Operation
+
+	// Relationship is the relationship that was written or deleted.
+	Relationship *core.RelationTuple
+}
+
+// WatchRelationships watches the datastore for changes following afterRevision, emitting each
+// change to a relationship individually, in the order in which it is found in its transaction.
+//
+// The changes channel is unbuffered, so a consumer which falls behind applies backpressure
+// to the underlying Watch, which will disconnect the watch as per its own buffering. Any error
+// from the underlying Watch is emitted on the error channel, after which both channels are
+// closed.
+func WatchRelationships(ctx context.Context, ds Datastore, afterRevision Revision) (<-chan RelationshipChange, <-chan error) {
+	changes := make(chan RelationshipChange)
+	errs := make(chan error, 1)
+
+	updates, updateErrs := ds.Watch(ctx, afterRevision)
+
+	go func() {
+		defer close(changes)
+		defer close(errs)
+
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					// Any error from the underlying watch remains readable once it has closed.
+					if err, ok := <-updateErrs; ok && err != nil {
+						errs <- err
+					}
+					return
+				}
+
+				for _, change := range update.Changes {
+					select {
+					case changes <- RelationshipChange{
+						Revision:     update.Revision,
+						Operation:    change.Operation,
+						Relationship: change.Tuple,
+					}:
+					case <-ctx.Done():
+						errs <- NewWatchCanceledErr()
+						return
+					}
+				}
+
+			case err, ok := <-updateErrs:
+				if ok && err != nil {
+					errs <- err
+				}
+				return
+			}
+		}
+	}()
+
+	return changes, errs
+}
+
+// SchemaChange represents the changes made to the schema in a single transaction, as found by
+// WatchSchema.
+type SchemaChange struct {
+	// Revision is the revision of the transaction in which the changes were made.
+	Revision Revision
+
+	// ChangedDefinitions are the namespace and caveat definitions written, whether created or
+	// updated.
+	ChangedDefinitions []SchemaDefinition
+
+	// DeletedNamespaces are the names of the namespaces deleted.
+	DeletedNamespaces []string
+
+	// DeletedCaveats are the names of the caveats deleted.
+	DeletedCaveats []string
+}
+
+// WatchSchema watches the datastore for changes following afterRevision, emitting the changes to
+// namespace and caveat definitions made by each transaction which changed the schema. Transactions
+// which only changed relationships are skipped, which allows a consumer to invalidate anything
+// computed from the schema precisely when it changes, rather than polling for changes.
+//
+// As with WatchRelationships, the changes channel is unbuffered, and any error from the
+// underlying Watch is emitted on the error channel, after which both channels are closed.
+func WatchSchema(ctx context.Context, ds Datastore, afterRevision Revision) (<-chan SchemaChange, <-chan error) {
+	changes := make(chan SchemaChange)
+	errs := make(chan error, 1)
+
+	updates, updateErrs := ds.Watch(ctx, afterRevision)
+
+	go func() {
+		defer close(changes)
+		defer close(errs)
+
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					// Any error from the underlying watch remains readable once it has closed.
+					if err, ok := <-updateErrs; ok && err != nil {
+						errs <- err
+					}
+					return
+				}
+
+				if !update.HasSchemaChanges() {
+					continue
+				}
+
+				select {
+				case changes <- SchemaChange{
+					Revision:           update.Revision,
+					ChangedDefinitions: update.ChangedDefinitions,
+					DeletedNamespaces:  update.DeletedNamespaces,
+					DeletedCaveats:     update.DeletedCaveats,
+				}:
+				case <-ctx.Done():
+					errs <- NewWatchCanceledErr()
+					return
+				}
+
+			case err, ok := <-updateErrs:
+				if ok && err != nil {
+					errs <- err
+				}
+				return
+			}
+		}
+	}()
+
+	return changes, errs
+}
@@ -0,0 +1,90 @@
+package datastore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const waitForChangesTimeout = 5 * time.Second
+
+func TestWatchRelationships(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 24*time.Hour, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, errs := datastore.WatchRelationships(ctx, ds, revision)
+
+	tom := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	fred := tuple.MustParse("document:firstdoc#viewer@user:fred")
+
+	writeRevisions := make([]datastore.Revision, 0, 2)
+	for _, updates := range [][]*core.RelationTupleUpdate{
+		{tuple.Create(tom), tuple.Touch(fred)},
+		{tuple.Delete(tom)},
+	} {
+		updates := updates
+		writeRevision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(ctx, updates)
+		})
+		require.NoError(err)
+		writeRevisions = append(writeRevisions, writeRevision)
+	}
+
+	expected := map[string]datastore.RelationshipChange{
+		"touch " + tuple.MustString(tom):  {Revision: writeRevisions[0], Operation: core.RelationTupleUpdate_TOUCH, Relationship: tom},
+		"touch " + tuple.MustString(fred): {Revision: writeRevisions[0], Operation: core.RelationTupleUpdate_TOUCH, Relationship: fred},
+		"delete " + tuple.MustString(tom): {Revision: writeRevisions[1], Operation: core.RelationTupleUpdate_DELETE, Relationship: tom},
+	}
+
+	for len(expected) > 0 {
+		select {
+		case change, ok := <-changes:
+			require.True(ok, "changes closed early")
+
+			key := "touch "
+			if change.Operation == core.RelationTupleUpdate_DELETE {
+				key = "delete "
+			}
+			key += tuple.MustString(change.Relationship)
+
+			expectedChange, ok := expected[key]
+			require.True(ok, "unexpected change %s", key)
+			require.True(expectedChange.Revision.Equal(change.Revision), "unexpected revision for %s", key)
+			delete(expected, key)
+
+		case err := <-errs:
+			require.FailNow("unexpected error", err)
+
+		case <-time.After(waitForChangesTimeout):
+			require.FailNow("timed out waiting for changes")
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-errs:
+		require.True(errors.As(err, &datastore.ErrWatchCanceled{}), "unexpected error %v", err)
+	case <-time.After(waitForChangesTimeout):
+		require.FailNow("timed out waiting for the watch to be canceled")
+	}
+
+	_, ok := <-changes
+	require.False(ok)
+}
@@ -0,0 +1,58 @@
+package development
+
+import (
+	"sort"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
+	"github.com/authzed/spicedb/pkg/datastore"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+)
+
+// ListCaveats returns the name and parameter signature of each caveat defined by the schema,
+// sorted by name, filtered by the prefix of the parameters and paginated by their limit and
+// after caveat.
+func ListCaveats(devContext *DevContext, params *devinterface.ListCaveatsParameters) (*devinterface.ListCaveatsResult, error) {
+	queryOpts := []options.ListCaveatsOptionsOption{
+		options.WithCaveatPrefix(params.Prefix),
+		options.WithAfterCaveat(params.AfterCaveat),
+	}
+	if params.Limit > 0 {
+		limit := params.Limit
+		queryOpts = append(queryOpts, options.WithCaveatLimit(&limit))
+	}
+
+	reader := devContext.Datastore.SnapshotReader(devContext.Revision)
+	caveats, err := datastore.ListCaveatsWithOptions(devContext.Ctx, reader, queryOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := make([]*devinterface.CaveatSignature, 0, len(caveats))
+	for _, caveat := range caveats {
+		parameterNames := maps.Keys(caveat.ParameterTypes)
+		sort.Strings(parameterNames)
+
+		parameters := make([]*devinterface.CaveatParameter, 0, len(parameterNames))
+		for _, paramName := range parameterNames {
+			decoded, err := caveattypes.DecodeParameterType(caveat.ParameterTypes[paramName])
+			if err != nil {
+				return nil, err
+			}
+
+			parameters = append(parameters, &devinterface.CaveatParameter{
+				Name: paramName,
+				Type: decoded.String(),
+			})
+		}
+
+		signatures = append(signatures, &devinterface.CaveatSignature{
+			Name:       caveat.Name,
+			Parameters: parameters,
+		})
+	}
+
+	return &devinterface.ListCaveatsResult{Caveats: signatures}, nil
+}
@@ -3,7 +3,9 @@ package development
 import (
 	"github.com/authzed/spicedb/internal/graph/computed"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // RunCheck performs a check against the data in the development context.
@@ -32,3 +34,40 @@ func RunCheck(devContext *DevContext, resource *core.ObjectAndRelation, subject
 
 	return cr.Membership, meta.DebugInfo, nil
 }
+
+// RunCheckOperation performs the check described by the given parameters against the data in
+// the development context. Errors caused by the user's input are returned as the check error of
+// the result, rather than as an error.
+func RunCheckOperation(devContext *DevContext, params *devinterface.CheckOperationParameters) (*devinterface.CheckOperationsResult, error) {
+	result, debug, err := RunCheck(devContext, params.Resource, params.Subject)
+	if err != nil {
+		devErr, wireErr := DistinguishGraphError(
+			devContext,
+			err,
+			devinterface.DeveloperError_CHECK_WATCH,
+			0, 0,
+			tuple.MustString(&core.RelationTuple{
+				ResourceAndRelation: params.Resource,
+				Subject:             params.Subject,
+			}),
+		)
+		if wireErr != nil {
+			return nil, wireErr
+		}
+
+		return &devinterface.CheckOperationsResult{
+			CheckError: devErr,
+		}, nil
+	}
+
+	// TODO(jschorr): Support caveats here.
+	membership := devinterface.CheckOperationsResult_NOT_MEMBER
+	if result == v1.ResourceCheckResult_MEMBER {
+		membership = devinterface.CheckOperationsResult_MEMBER
+	}
+
+	return &devinterface.CheckOperationsResult{
+		Membership:       membership,
+		DebugInformation: debug,
+	}, nil
+}
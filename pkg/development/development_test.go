@@ -2,6 +2,8 @@ package development
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -9,6 +11,8 @@ import (
 
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/testutil"
 	"github.com/authzed/spicedb/pkg/tuple"
 	"github.com/authzed/spicedb/pkg/validationfile/blocks"
 )
@@ -109,3 +113,485 @@ definition document {
 
 	require.Equal(t, "document:somedoc#viewer:\n- '[user:someuser[...]] is <document:somedoc#viewer>'\n", generated)
 }
+
+func TestDevelopmentDepthLimitedExpectedRels(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	// A chain of folders deeper than the maximum dispatch depth.
+	relationships := []*core.RelationTuple{
+		tuple.MustParse("folder:0#viewer@user:bottom"),
+		tuple.MustParse("folder:40#viewer@user:top"),
+	}
+	for i := 1; i <= 40; i++ {
+		relationships = append(relationships, tuple.MustParse(fmt.Sprintf("folder:%d#parent@folder:%d", i, i-1)))
+	}
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition folder {
+	relation parent: folder
+	relation viewer: user
+	permission view = viewer + parent->view
+}
+`,
+		Relationships: relationships,
+	})
+	require.Nil(t, err)
+	require.Nil(t, devErrs)
+
+	// The partial expansion is validated when it matches the expected subjects.
+	validation, devErr := ParseExpectedRelationsYAML("folder:40#view:\n- '[user:top] is <folder:40#viewer>'\n")
+	require.Nil(t, devErr)
+
+	_, failures, err := RunValidation(devCtx, validation)
+	require.Nil(t, err)
+	require.Empty(t, failures)
+
+	// Otherwise the subjects may be beyond the maximum depth, which is reported instead.
+	validation, devErr = ParseExpectedRelationsYAML("folder:40#view:\n- '[user:top] is <folder:40#viewer>'\n- '[user:bottom] is <folder:0#viewer>'\n")
+	require.Nil(t, devErr)
+
+	_, failures, err = RunValidation(devCtx, validation)
+	require.Nil(t, err)
+	require.Len(t, failures, 1)
+	require.Equal(t, devinterface.DeveloperError_MAXIMUM_RECURSION, failures[0].Kind)
+}
+
+func TestParseRelationship(t *testing.T) {
+	parsed, devErr := ParseRelationship("document:somedoc#viewer@user:someuser#...")
+	require.Nil(t, devErr)
+	require.Equal(t, "document:somedoc#viewer@user:someuser", tuple.MustString(parsed))
+
+	parsed, devErr = ParseRelationship("document:somedoc#viewer@user:someuser[somecaveat")
+	require.Nil(t, parsed)
+	testutil.RequireProtoEqual(t, &devinterface.DeveloperError{
+		Message: "invalid caveat: missing closing `]`",
+		Kind:    devinterface.DeveloperError_PARSE_ERROR,
+		Source:  devinterface.DeveloperError_RELATIONSHIP,
+		Path:    []string{"caveat"},
+		Context: "document:somedoc#viewer@user:someuser[somecaveat",
+	}, devErr, "found mismatching error")
+}
+
+func TestPreviewSchemaChange(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+	relation editor: user
+	permission view = viewer
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:someuser"),
+			tuple.MustParse("document:somedoc#editor@user:anotheruser"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	checks := []*devinterface.CheckOperationParameters{
+		{
+			Resource: tuple.ParseONR("document:somedoc#view"),
+			Subject:  tuple.ParseSubjectONR("user:someuser"),
+		},
+		{
+			Resource: tuple.ParseONR("document:somedoc#view"),
+			Subject:  tuple.ParseSubjectONR("user:anotheruser"),
+		},
+		{
+			Resource: tuple.ParseONR("document:somedoc#view"),
+			Subject:  tuple.ParseSubjectONR("user:unknownuser"),
+		},
+	}
+
+	result, err := PreviewSchemaChange(devCtx, `definition user {}
+
+definition document {
+	relation viewer: user
+	relation editor: user
+	permission view = editor
+}
+`, checks)
+	require.NoError(t, err)
+	require.Nil(t, result.ProposedSchemaErrors)
+	require.Len(t, result.CheckResults, len(checks))
+
+	expected := []struct {
+		current  devinterface.CheckOperationsResult_Membership
+		proposed devinterface.CheckOperationsResult_Membership
+	}{
+		{devinterface.CheckOperationsResult_MEMBER, devinterface.CheckOperationsResult_NOT_MEMBER},
+		{devinterface.CheckOperationsResult_NOT_MEMBER, devinterface.CheckOperationsResult_MEMBER},
+		{devinterface.CheckOperationsResult_NOT_MEMBER, devinterface.CheckOperationsResult_NOT_MEMBER},
+	}
+
+	for index, checkResult := range result.CheckResults {
+		testutil.RequireProtoEqual(t, checks[index], checkResult.Check, "mismatch in check")
+		require.Nil(t, checkResult.CurrentResult.CheckError)
+		require.Nil(t, checkResult.ProposedResult.CheckError)
+		require.Equal(t, expected[index].current, checkResult.CurrentResult.Membership)
+		require.Equal(t, expected[index].proposed, checkResult.ProposedResult.Membership)
+	}
+
+	require.Equal(t, []string{"document#view"}, relationReferenceStrings(result.AffectedRelations))
+
+	// Ensure the development context still uses its own schema.
+	membership, _, err := RunCheck(devCtx, checks[0].Resource, checks[0].Subject)
+	require.NoError(t, err)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, membership)
+}
+
+func TestPreviewSchemaChangeAffectedRelations(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition folder {
+	relation viewer: user
+	relation auditor: user
+	permission view = viewer
+	permission audit = auditor
+}
+
+definition document {
+	relation parent: folder
+	relation owner: user
+	permission view = owner + parent->view
+	permission audit = parent->audit
+}
+`,
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	result, err := PreviewSchemaChange(devCtx, `definition user {}
+
+definition folder {
+	relation viewer: user | folder#viewer
+	relation auditor: user
+	permission view = viewer
+	permission audit = auditor
+}
+
+definition document {
+	relation parent: folder
+	relation owner: user
+	permission view = owner + parent->view
+	permission audit = parent->audit
+}
+`, nil)
+	require.NoError(t, err)
+	require.Nil(t, result.ProposedSchemaErrors)
+	require.Equal(t, []string{"document#view", "folder#view", "folder#viewer"}, relationReferenceStrings(result.AffectedRelations))
+}
+
+func relationReferenceStrings(refs []*core.RelationReference) []string {
+	strs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		strs = append(strs, tuple.StringRR(ref))
+	}
+	return strs
+}
+
+func TestPreviewSchemaChangeBreakingRelations(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:someuser"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	result, err := PreviewSchemaChange(devCtx, `definition user {}
+
+definition document {
+	relation editor: user
+}
+`, []*devinterface.CheckOperationParameters{
+		{
+			Resource: tuple.ParseONR("document:somedoc#editor"),
+			Subject:  tuple.ParseSubjectONR("user:someuser"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.ProposedSchemaErrors)
+
+	// The removed relation still has a relationship, which is reported rather than failing the
+	// preview.
+	require.Len(t, result.BreakingRelations, 1)
+	require.Equal(t, "document#viewer", tuple.StringRR(result.BreakingRelations[0].Relation))
+	require.Equal(t, uint64(1), result.BreakingRelations[0].RelationshipCount)
+	require.NotEmpty(t, result.BreakingRelations[0].Reason)
+
+	require.Len(t, result.CheckResults, 1)
+	require.NotNil(t, result.CheckResults[0].CurrentResult.CheckError)
+	require.Nil(t, result.CheckResults[0].ProposedResult.CheckError)
+	require.Equal(t, devinterface.CheckOperationsResult_NOT_MEMBER, result.CheckResults[0].ProposedResult.Membership)
+
+	// An invalid proposed schema is still reported as errors.
+	result, err = PreviewSchemaChange(devCtx, `definition document {
+	relation viewer: unknown
+}
+`, nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.ProposedSchemaErrors)
+	require.NotEmpty(t, result.ProposedSchemaErrors.InputErrors)
+	require.Empty(t, result.CheckResults)
+}
+
+func TestDiffSubjectPermissions(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition group {
+	relation member: user
+}
+
+definition document {
+	relation viewer: user | group#member
+	relation editor: user
+	relation banned: user
+	permission view = viewer + editor
+	permission edit = editor - banned
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@group:staff#member"),
+			tuple.MustParse("group:staff#member@user:alice"),
+			tuple.MustParse("group:staff#member@user:bob"),
+			tuple.MustParse("document:somedoc#editor@user:alice"),
+			tuple.MustParse("document:somedoc#editor@user:bob"),
+			tuple.MustParse("document:somedoc#banned@user:bob"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	result, err := DiffSubjectPermissions(devCtx, &devinterface.DiffSubjectPermissionsParameters{
+		Resource:      tuple.ObjectAndRelation("document", "somedoc", tuple.Ellipsis),
+		FirstSubject:  tuple.ParseSubjectONR("user:alice"),
+		SecondSubject: tuple.ParseSubjectONR("user:bob"),
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.DiffError)
+
+	differences := make([]string, 0, len(result.Differences))
+	for _, difference := range result.Differences {
+		relationships := make([]string, 0, len(difference.Relationships))
+		for _, onr := range difference.Relationships {
+			relationships = append(relationships, tuple.StringONR(onr))
+		}
+		differences = append(differences, fmt.Sprintf("%s: %s via %v", difference.Permission, tuple.StringONR(difference.Subject), relationships))
+	}
+	require.Equal(t, []string{
+		"banned: user:bob via [document:somedoc#banned]",
+		"edit: user:alice via [document:somedoc#editor]",
+	}, differences)
+
+	// Only the given permissions are compared.
+	result, err = DiffSubjectPermissions(devCtx, &devinterface.DiffSubjectPermissionsParameters{
+		Resource:      tuple.ObjectAndRelation("document", "somedoc", tuple.Ellipsis),
+		Permissions:   []string{"view", "editor"},
+		FirstSubject:  tuple.ParseSubjectONR("user:alice"),
+		SecondSubject: tuple.ParseSubjectONR("user:bob"),
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.DiffError)
+	require.Empty(t, result.Differences)
+
+	// An unknown permission is reported as a developer error.
+	result, err = DiffSubjectPermissions(devCtx, &devinterface.DiffSubjectPermissionsParameters{
+		Resource:      tuple.ObjectAndRelation("document", "somedoc", tuple.Ellipsis),
+		Permissions:   []string{"unknown"},
+		FirstSubject:  tuple.ParseSubjectONR("user:alice"),
+		SecondSubject: tuple.ParseSubjectONR("user:bob"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.DiffError)
+	require.Equal(t, devinterface.DeveloperError_UNKNOWN_RELATION, result.DiffError.Kind)
+}
+
+func TestLookupSubjects(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition bot {}
+
+definition group {
+	relation member: user | bot
+}
+
+definition document {
+	relation viewer: user | user:* | group#member
+	relation editor: user | bot
+	relation banned: user
+	permission view = (viewer + editor) - banned
+	permission edit = editor & viewer
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@group:staff#member"),
+			tuple.MustParse("group:staff#member@user:alice"),
+			tuple.MustParse("group:staff#member@bot:builder"),
+			tuple.MustParse("document:somedoc#editor@user:bob"),
+			tuple.MustParse("document:somedoc#editor@bot:deployer"),
+			tuple.MustParse("document:somedoc#banned@user:bob"),
+			tuple.MustParse("document:public#viewer@user:*"),
+			tuple.MustParse("document:public#banned@user:mallory"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	lookup := func(resource string, subjectType *core.RelationReference) []string {
+		result, err := LookupSubjects(devCtx, &devinterface.LookupSubjectsParameters{
+			Resource:    tuple.ParseONR(resource),
+			SubjectType: subjectType,
+		})
+		require.NoError(t, err)
+		require.Nil(t, result.LookupError)
+
+		found := make([]string, 0, len(result.Subjects))
+		for _, subject := range result.Subjects {
+			subjectString := subject.SubjectId
+			for _, excluded := range subject.ExcludedSubjects {
+				subjectString += " - " + excluded.SubjectId
+			}
+			found = append(found, subjectString)
+		}
+		return found
+	}
+
+	users := &core.RelationReference{Namespace: "user", Relation: tuple.Ellipsis}
+	bots := &core.RelationReference{Namespace: "bot", Relation: tuple.Ellipsis}
+	members := &core.RelationReference{Namespace: "group", Relation: "member"}
+
+	require.Equal(t, []string{"alice"}, lookup("document:somedoc#view", users))
+	require.Equal(t, []string{"builder", "deployer"}, lookup("document:somedoc#view", bots))
+	require.Equal(t, []string{"staff"}, lookup("document:somedoc#view", members))
+	require.Empty(t, lookup("document:somedoc#edit", users))
+
+	// A wildcard grant is returned as the wildcard subject, with its exclusions.
+	require.Equal(t, []string{"* - mallory"}, lookup("document:public#view", users))
+	require.Empty(t, lookup("document:public#view", bots))
+
+	// Errors in the input are returned as the lookup error.
+	result, err := LookupSubjects(devCtx, &devinterface.LookupSubjectsParameters{
+		Resource:    tuple.ParseONR("document:somedoc#unknown"),
+		SubjectType: users,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.LookupError)
+	require.Equal(t, devinterface.DeveloperError_UNKNOWN_RELATION, result.LookupError.Kind)
+}
+
+func TestListCaveats(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `caveat is_weekday(day string) {
+	day != "saturday" && day != "sunday"
+}
+
+caveat has_ip(user_ip ipaddress, allowed list<ipaddress>) {
+	allowed.exists(ip, ip == user_ip)
+}
+
+caveat has_quota(used int, quota int) {
+	used < quota
+}
+
+definition user {}
+`,
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	list := func(params *devinterface.ListCaveatsParameters) []string {
+		result, err := ListCaveats(devCtx, params)
+		require.NoError(t, err)
+
+		found := make([]string, 0, len(result.Caveats))
+		for _, caveat := range result.Caveats {
+			parameters := make([]string, 0, len(caveat.Parameters))
+			for _, parameter := range caveat.Parameters {
+				parameters = append(parameters, parameter.Name+" "+parameter.Type)
+			}
+			found = append(found, fmt.Sprintf("%s(%s)", caveat.Name, strings.Join(parameters, ", ")))
+		}
+		return found
+	}
+
+	require.Equal(t, []string{
+		"has_ip(allowed list<ipaddress>, user_ip ipaddress)",
+		"has_quota(quota int, used int)",
+		"is_weekday(day string)",
+	}, list(&devinterface.ListCaveatsParameters{}))
+
+	require.Equal(t, []string{
+		"has_ip(allowed list<ipaddress>, user_ip ipaddress)",
+		"has_quota(quota int, used int)",
+	}, list(&devinterface.ListCaveatsParameters{Prefix: "has_"}))
+
+	require.Equal(t, []string{
+		"has_ip(allowed list<ipaddress>, user_ip ipaddress)",
+	}, list(&devinterface.ListCaveatsParameters{Limit: 1}))
+
+	require.Equal(t, []string{
+		"has_quota(quota int, used int)",
+		"is_weekday(day string)",
+	}, list(&devinterface.ListCaveatsParameters{AfterCaveat: "has_ip"}))
+}
+
+func TestGetWarnings(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+	permission empty = nil
+	permission view = viewer & empty
+	permission edit = viewer
+}
+`,
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	result := GetWarnings(devCtx)
+	require.Len(t, result.Warnings, 2)
+
+	require.Equal(t, "document#empty", result.Warnings[0].Context)
+	require.Equal(t, uint32(5), result.Warnings[0].Line)
+	require.Contains(t, result.Warnings[0].Message, "permission `empty` under definition `document` can never resolve")
+
+	require.Equal(t, "document#view", result.Warnings[1].Context)
+	require.Equal(t, uint32(6), result.Warnings[1].Line)
+}
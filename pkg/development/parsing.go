@@ -1,8 +1,12 @@
 package development
 
 import (
+	"errors"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
 	"github.com/authzed/spicedb/pkg/spiceerrors"
+	"github.com/authzed/spicedb/pkg/tuple"
 	"github.com/authzed/spicedb/pkg/validationfile"
 	"github.com/authzed/spicedb/pkg/validationfile/blocks"
 )
@@ -32,6 +36,25 @@ func ParseExpectedRelationsYAML(expectedRelationsYaml string) (*blocks.ParsedExp
 	return block, convertError(devinterface.DeveloperError_VALIDATION_YAML, err)
 }
 
+// ParseRelationship parses the string form of a relationship. If the string is invalid, the
+// returned error's path holds the segment of the relationship which could not be parsed.
+func ParseRelationship(relationship string) (*core.RelationTuple, *devinterface.DeveloperError) {
+	parsed, err := tuple.ParseWithError(relationship)
+	if err == nil {
+		return parsed, nil
+	}
+
+	devErr := convertError(devinterface.DeveloperError_RELATIONSHIP, err)
+	devErr.Context = relationship
+
+	var invalidErr tuple.ErrInvalidTuple
+	if errors.As(err, &invalidErr) {
+		devErr.Path = []string{invalidErr.Segment().String()}
+	}
+
+	return nil, devErr
+}
+
 func convertError(source devinterface.DeveloperError_Source, err error) *devinterface.DeveloperError {
 	if err == nil {
 		return nil
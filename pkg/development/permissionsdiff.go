@@ -0,0 +1,128 @@
+package development
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/authzed/spicedb/internal/developmentmembership"
+	"github.com/authzed/spicedb/internal/dispatch"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// DiffSubjectPermissions compares the permissions of two subjects on a resource, returning each
+// permission held by exactly one of them, along with the relationships via which it is held.
+// Each permission is fully expanded, so this is intended for audits rather than hot paths.
+//
+// A permission held by both subjects, even if only conditionally by either, is not a difference.
+// Errors caused by the user's input are returned as the diff error of the result, rather than as
+// an error.
+func DiffSubjectPermissions(devContext *DevContext, params *devinterface.DiffSubjectPermissionsParameters) (*devinterface.DiffSubjectPermissionsResult, error) {
+	permissions := params.Permissions
+	if len(permissions) == 0 {
+		var found bool
+		permissions, found = permissionsOfDefinition(devContext, params.Resource.Namespace)
+		if !found {
+			return &devinterface.DiffSubjectPermissionsResult{
+				DiffError: &devinterface.DeveloperError{
+					Message: fmt.Sprintf("object definition `%s` not found", params.Resource.Namespace),
+					Source:  devinterface.DeveloperError_CHECK_WATCH,
+					Kind:    devinterface.DeveloperError_UNKNOWN_OBJECT_TYPE,
+					Context: params.Resource.Namespace,
+				},
+			}, nil
+		}
+	}
+
+	firstSubject := canonicalSubject(params.FirstSubject)
+	secondSubject := canonicalSubject(params.SecondSubject)
+
+	var differences []*devinterface.PermissionDifference
+	for _, permission := range permissions {
+		onr := tuple.ObjectAndRelation(params.Resource.Namespace, params.Resource.ObjectId, permission)
+		accessible, err := expandAccessibleSubjects(devContext, onr)
+		if err != nil {
+			devErr, wireErr := DistinguishGraphError(devContext, err, devinterface.DeveloperError_CHECK_WATCH, 0, 0, tuple.StringONR(onr))
+			if wireErr != nil {
+				return nil, wireErr
+			}
+
+			return &devinterface.DiffSubjectPermissionsResult{
+				DiffError: devErr,
+			}, nil
+		}
+
+		first, firstHas := accessible.AccessibleSubject(firstSubject)
+		second, secondHas := accessible.AccessibleSubject(secondSubject)
+		switch {
+		case firstHas && !secondHas:
+			differences = append(differences, permissionDifference(permission, first))
+		case secondHas && !firstHas:
+			differences = append(differences, permissionDifference(permission, second))
+		}
+	}
+
+	return &devinterface.DiffSubjectPermissionsResult{
+		Differences: differences,
+	}, nil
+}
+
+func permissionsOfDefinition(devContext *DevContext, namespaceName string) ([]string, bool) {
+	for _, def := range devContext.CompiledSchema.ObjectDefinitions {
+		if def.Name != namespaceName {
+			continue
+		}
+
+		permissions := make([]string, 0, len(def.Relation))
+		for _, rel := range def.Relation {
+			permissions = append(permissions, rel.Name)
+		}
+		return permissions, true
+	}
+
+	return nil, false
+}
+
+func canonicalSubject(subject *core.ObjectAndRelation) *core.ObjectAndRelation {
+	if subject.Relation != "" {
+		return subject
+	}
+	return tuple.ObjectAndRelation(subject.Namespace, subject.ObjectId, tuple.Ellipsis)
+}
+
+func expandAccessibleSubjects(devContext *DevContext, onr *core.ObjectAndRelation) (*developmentmembership.TrackingSubjectSet, error) {
+	er, err := devContext.Dispatcher.DispatchExpand(devContext.Ctx, &v1.DispatchExpandRequest{
+		ResourceAndRelation: onr,
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     devContext.Revision.String(),
+			DepthRemaining: maxDispatchDepth,
+		},
+		ExpansionMode: v1.DispatchExpandRequest_RECURSIVE,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A partial expansion could miss the subjects, so report reaching the maximum depth.
+	if er.DepthLimited {
+		return nil, dispatch.ErrMaxDepth
+	}
+
+	return developmentmembership.AccessibleExpansionSubjects(er.TreeNode)
+}
+
+func permissionDifference(permission string, found developmentmembership.FoundSubject) *devinterface.PermissionDifference {
+	relationships := found.Relationships()
+	sort.Slice(relationships, func(i, j int) bool {
+		return tuple.StringONR(relationships[i]) < tuple.StringONR(relationships[j])
+	})
+
+	return &devinterface.PermissionDifference{
+		Permission:       permission,
+		Subject:          found.Subject(),
+		CaveatExpression: found.GetCaveatExpression(),
+		Relationships:    relationships,
+	}
+}
@@ -0,0 +1,204 @@
+package development
+
+import (
+	"sort"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// PreviewSchemaChange runs each of the given checks under both the schema of the development
+// context and the proposed schema, with the relationships of the development context. The
+// proposed schema is loaded into a separate development context, which is disposed of once the
+// checks have run, so the development context itself is left unchanged. The relations and
+// permissions affected by the change, as statically computed from the dependencies between them,
+// are returned alongside the results of the checks.
+//
+// If the proposed schema is invalid, its errors are returned on the result and no checks are
+// run. Relationships which cannot be stored under the proposed schema, such as those of a
+// relation it removes, are reported on the result as breaking relations, and the checks are run
+// under the proposed schema without them.
+func PreviewSchemaChange(devContext *DevContext, proposedSchema string, checks []*devinterface.CheckOperationParameters) (*devinterface.PreviewSchemaChangeResult, error) {
+	relationships, err := readAllRelationships(devContext)
+	if err != nil {
+		return nil, err
+	}
+
+	proposedContext, devErrs, err := NewDevContext(devContext.Ctx, &devinterface.RequestContext{
+		Schema: proposedSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if devErrs != nil {
+		return &devinterface.PreviewSchemaChangeResult{
+			ProposedSchemaErrors: devErrs,
+		}, nil
+	}
+	defer proposedContext.Dispose()
+
+	breaking, err := loadRelationshipsForProposedSchema(proposedContext, relationships)
+	if err != nil {
+		return nil, err
+	}
+
+	checkResults := make([]*devinterface.SchemaChangeCheckResult, 0, len(checks))
+	for _, check := range checks {
+		currentResult, err := RunCheckOperation(devContext, check)
+		if err != nil {
+			return nil, err
+		}
+
+		proposedResult, err := RunCheckOperation(proposedContext, check)
+		if err != nil {
+			return nil, err
+		}
+
+		checkResults = append(checkResults, &devinterface.SchemaChangeCheckResult{
+			Check:          check,
+			CurrentResult:  currentResult,
+			ProposedResult: proposedResult,
+		})
+	}
+
+	affected, err := affectedRelations(devContext.CompiledSchema.ObjectDefinitions, proposedContext.CompiledSchema.ObjectDefinitions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &devinterface.PreviewSchemaChangeResult{
+		CheckResults:      checkResults,
+		AffectedRelations: affected,
+		BreakingRelations: breaking,
+	}, nil
+}
+
+// loadRelationshipsForProposedSchema writes each of the relationships which can be stored under
+// the schema of the proposed development context into it, and returns the relations of those
+// which cannot, sorted by definition and then by name.
+func loadRelationshipsForProposedSchema(proposedContext *DevContext, relationships []*core.RelationTuple) ([]*devinterface.BreakingRelation, error) {
+	ctx := proposedContext.Ctx
+	breakingByRelation := map[string]*devinterface.BreakingRelation{}
+
+	revision, err := proposedContext.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		updates := make([]*core.RelationTupleUpdate, 0, len(relationships))
+		for _, tpl := range relationships {
+			verr := validateTupleWrite(ctx, tpl, rwt)
+			if verr == nil {
+				updates = append(updates, tuple.Touch(tpl))
+				continue
+			}
+
+			devErr, wireErr := distinguishGraphError(ctx, verr, devinterface.DeveloperError_RELATIONSHIP, 0, 0, tuple.StringWithoutCaveat(tpl))
+			if devErr == nil {
+				return wireErr
+			}
+
+			relation := tuple.RelationReference(tpl.ResourceAndRelation.Namespace, tpl.ResourceAndRelation.Relation)
+			key := tuple.StringRR(relation)
+			if _, ok := breakingByRelation[key]; !ok {
+				breakingByRelation[key] = &devinterface.BreakingRelation{
+					Relation: relation,
+					Reason:   devErr.Message,
+				}
+			}
+			breakingByRelation[key].RelationshipCount++
+		}
+
+		return rwt.WriteRelationships(ctx, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	proposedContext.Revision = revision
+
+	breaking := maps.Values(breakingByRelation)
+	sort.Slice(breaking, func(i, j int) bool {
+		return tuple.StringRR(breaking[i].Relation) < tuple.StringRR(breaking[j].Relation)
+	})
+	return breaking, nil
+}
+
+// affectedRelations returns the relations and permissions of the current definitions which are
+// changed or removed by the proposed definitions, along with those which transitively depend
+// upon them, sorted by definition and then by name.
+func affectedRelations(current []*core.NamespaceDefinition, proposed []*core.NamespaceDefinition) ([]*core.RelationReference, error) {
+	proposedByName := make(map[string]*core.NamespaceDefinition, len(proposed))
+	for _, def := range proposed {
+		proposedByName[def.Name] = def
+	}
+
+	var changed []*core.RelationReference
+	for _, def := range current {
+		diff, err := namespace.DiffNamespaces(def, proposedByName[def.Name])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, delta := range diff.Deltas() {
+			switch delta.Type {
+			case namespace.NamespaceRemoved:
+				for _, rel := range def.Relation {
+					changed = append(changed, tuple.RelationReference(def.Name, rel.Name))
+				}
+
+			case namespace.RemovedRelation,
+				namespace.RemovedPermission,
+				namespace.ChangedPermissionImpl,
+				namespace.LegacyChangedRelationImpl,
+				namespace.RelationAllowedTypeAdded,
+				namespace.RelationAllowedTypeRemoved:
+				changed = append(changed, tuple.RelationReference(def.Name, delta.RelationName))
+			}
+		}
+	}
+
+	dependencies := namespace.ComputeRelationDependencies(current)
+	affected := map[string]*core.RelationReference{}
+	for _, ref := range changed {
+		affected[tuple.StringRR(ref)] = ref
+		for _, dependent := range dependencies.AffectedBy(ref.Namespace, ref.Relation) {
+			affected[tuple.StringRR(dependent)] = dependent
+		}
+	}
+
+	sorted := maps.Values(affected)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Relation < sorted[j].Relation
+	})
+	return sorted, nil
+}
+
+func readAllRelationships(devContext *DevContext) ([]*core.RelationTuple, error) {
+	reader := devContext.Datastore.SnapshotReader(devContext.Revision)
+
+	var relationships []*core.RelationTuple
+	for _, def := range devContext.CompiledSchema.ObjectDefinitions {
+		iter, err := reader.QueryRelationships(devContext.Ctx, datastore.RelationshipsFilter{
+			ResourceType: def.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			relationships = append(relationships, tpl)
+		}
+		iter.Close()
+
+		if iter.Err() != nil {
+			return nil, iter.Err()
+		}
+	}
+
+	return relationships, nil
+}
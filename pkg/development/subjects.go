@@ -0,0 +1,56 @@
+package development
+
+import (
+	"sort"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// LookupSubjects returns the subjects of the given type and relation, such as `user#...`, which
+// have access to the given resource and permission, sorted by subject ID. A wildcard grant is
+// returned as the wildcard subject of the type, along with the subjects excluded from it.
+//
+// Subjects are found by the same dispatch as the LookupSubjects API, which only walks the
+// branches of the schema that can reach the subject type. Errors caused by the user's input are
+// returned as the lookup error of the result, rather than as an error.
+func LookupSubjects(devContext *DevContext, params *devinterface.LookupSubjectsParameters) (*devinterface.LookupSubjectsResult, error) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupSubjectsResponse](devContext.Ctx)
+	err := devContext.Dispatcher.DispatchLookupSubjects(&v1.DispatchLookupSubjectsRequest{
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     devContext.Revision.String(),
+			DepthRemaining: maxDispatchDepth,
+		},
+		ResourceRelation: &core.RelationReference{
+			Namespace: params.Resource.Namespace,
+			Relation:  params.Resource.Relation,
+		},
+		ResourceIds:     []string{params.Resource.ObjectId},
+		SubjectRelation: params.SubjectType,
+	}, stream)
+	if err != nil {
+		devErr, wireErr := DistinguishGraphError(devContext, err, devinterface.DeveloperError_CHECK_WATCH, 0, 0, tuple.StringONR(params.Resource))
+		if wireErr != nil {
+			return nil, wireErr
+		}
+
+		return &devinterface.LookupSubjectsResult{
+			LookupError: devErr,
+		}, nil
+	}
+
+	var subjects []*v1.FoundSubject
+	for _, result := range stream.Results() {
+		subjects = append(subjects, result.FoundSubjectsByResourceId[params.Resource.ObjectId].GetFoundSubjects()...)
+	}
+	sort.Slice(subjects, func(i, j int) bool {
+		return subjects[i].SubjectId < subjects[j].SubjectId
+	})
+
+	return &devinterface.LookupSubjectsResult{
+		Subjects: subjects,
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/authzed/spicedb/internal/developmentmembership"
+	"github.com/authzed/spicedb/internal/dispatch"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -35,6 +36,7 @@ func RunValidation(devContext *DevContext, validation *blocks.ParsedExpectedRela
 			},
 			ExpansionMode: v1.DispatchExpandRequest_RECURSIVE,
 		})
+
 		if derr != nil {
 			devErr, wireErr := DistinguishGraphError(devContext, derr, devinterface.DeveloperError_VALIDATION_YAML, 0, 0, onrKey.ObjectRelationString)
 			if wireErr != nil {
@@ -57,8 +59,19 @@ func RunValidation(devContext *DevContext, validation *blocks.ParsedExpectedRela
 			continue
 		}
 
-		// Compare the terminal subjects found to those specified.
+		// Compare the terminal subjects found to those specified. A partial expansion is missing
+		// the subjects of the subproblems which were not expanded, so if it does not match, the
+		// mismatch is reported as reaching the maximum depth, rather than as wrong subjects.
 		errs := validateSubjects(onrKey, foundSubjects, expectedSubjects)
+		if len(errs) > 0 && er.DepthLimited {
+			devErr, wireErr := DistinguishGraphError(devContext, dispatch.ErrMaxDepth, devinterface.DeveloperError_VALIDATION_YAML, 0, 0, onrKey.ObjectRelationString)
+			if wireErr != nil {
+				return nil, nil, wireErr
+			}
+
+			failures = append(failures, devErr)
+			continue
+		}
 		failures = append(failures, errs...)
 	}
 
@@ -0,0 +1,40 @@
+package development
+
+import (
+	"github.com/authzed/spicedb/internal/namespace"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// GetWarnings statically analyzes the schema of the development context and returns a warning
+// for each issue found which does not prevent the schema from being used, such as a permission
+// that can never resolve to any subject.
+func GetWarnings(devContext *DevContext) *devinterface.SchemaWarningsResult {
+	definitions := devContext.CompiledSchema.ObjectDefinitions
+	unreachable := namespace.FindUnreachablePermissions(definitions)
+
+	warnings := make([]*devinterface.DeveloperWarning, 0, len(unreachable))
+	for _, found := range unreachable {
+		warning := &devinterface.DeveloperWarning{
+			Message: found.String(),
+			Context: tuple.StringRR(tuple.RelationReference(found.Namespace, found.Permission)),
+		}
+
+		for _, def := range definitions {
+			if def.Name != found.Namespace {
+				continue
+			}
+
+			for _, rel := range def.Relation {
+				if rel.Name == found.Permission && rel.SourcePosition != nil {
+					warning.Line = uint32(rel.SourcePosition.ZeroIndexedLineNumber) + 1       // 0-indexed in parser.
+					warning.Column = uint32(rel.SourcePosition.ZeroIndexedColumnPosition) + 1 // 0-indexed in parser.
+				}
+			}
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	return &devinterface.SchemaWarningsResult{Warnings: warnings}
+}
@@ -8,11 +8,8 @@ import (
 	"strings"
 
 	"github.com/authzed/spicedb/pkg/development"
-	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
-	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/generator"
-	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 func runOperation(devContext *development.DevContext, operation *devinterface.Operation) (*devinterface.OperationResult, error) {
@@ -26,41 +23,72 @@ func runOperation(devContext *development.DevContext, operation *devinterface.Op
 			},
 		}, nil
 
+	case operation.ParseRelationshipParameters != nil:
+		parsed, devErr := development.ParseRelationship(operation.ParseRelationshipParameters.Relationship)
+		return &devinterface.OperationResult{
+			ParseRelationshipResult: &devinterface.ParseRelationshipResult{
+				Relationship: parsed,
+				InputError:   devErr,
+			},
+		}, nil
+
 	case operation.CheckParameters != nil:
-		result, debug, err := development.RunCheck(devContext, operation.CheckParameters.Resource, operation.CheckParameters.Subject)
+		checkResult, err := development.RunCheckOperation(devContext, operation.CheckParameters)
 		if err != nil {
-			devErr, wireErr := development.DistinguishGraphError(
-				devContext,
-				err,
-				devinterface.DeveloperError_CHECK_WATCH,
-				0, 0,
-				tuple.MustString(&core.RelationTuple{
-					ResourceAndRelation: operation.CheckParameters.Resource,
-					Subject:             operation.CheckParameters.Subject,
-				}),
-			)
-			if wireErr != nil {
-				return nil, wireErr
-			}
+			return nil, err
+		}
 
-			return &devinterface.OperationResult{
-				CheckResult: &devinterface.CheckOperationsResult{
-					CheckError: devErr,
-				},
-			}, nil
+		return &devinterface.OperationResult{
+			CheckResult: checkResult,
+		}, nil
+
+	case operation.PreviewSchemaChangeParameters != nil:
+		previewResult, err := development.PreviewSchemaChange(
+			devContext,
+			operation.PreviewSchemaChangeParameters.ProposedSchema,
+			operation.PreviewSchemaChangeParameters.Checks,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return &devinterface.OperationResult{
+			PreviewSchemaChangeResult: previewResult,
+		}, nil
+
+	case operation.DiffSubjectPermissionsParameters != nil:
+		diffResult, err := development.DiffSubjectPermissions(devContext, operation.DiffSubjectPermissionsParameters)
+		if err != nil {
+			return nil, err
 		}
 
-		// TODO(jschorr): Support caveats here.
-		membership := devinterface.CheckOperationsResult_NOT_MEMBER
-		if result == v1.ResourceCheckResult_MEMBER {
-			membership = devinterface.CheckOperationsResult_MEMBER
+		return &devinterface.OperationResult{
+			DiffSubjectPermissionsResult: diffResult,
+		}, nil
+
+	case operation.ListCaveatsParameters != nil:
+		listResult, err := development.ListCaveats(devContext, operation.ListCaveatsParameters)
+		if err != nil {
+			return nil, err
 		}
 
 		return &devinterface.OperationResult{
-			CheckResult: &devinterface.CheckOperationsResult{
-				Membership:       membership,
-				DebugInformation: debug,
-			},
+			ListCaveatsResult: listResult,
+		}, nil
+
+	case operation.LookupSubjectsParameters != nil:
+		lookupResult, err := development.LookupSubjects(devContext, operation.LookupSubjectsParameters)
+		if err != nil {
+			return nil, err
+		}
+
+		return &devinterface.OperationResult{
+			LookupSubjectsResult: lookupResult,
+		}, nil
+
+	case operation.SchemaWarningsParameters != nil:
+		return &devinterface.OperationResult{
+			SchemaWarningsResult: development.GetWarnings(devContext),
 		}, nil
 
 	case operation.AssertionsParameters != nil:
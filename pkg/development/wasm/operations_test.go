@@ -256,6 +256,36 @@ func TestFormatSchemaOperation(t *testing.T) {
 	require.Equal("/** hi there */\ndefinition foos {}\n\ndefinition bars {}", formatResult.FormattedSchema)
 }
 
+func TestParseRelationshipOperation(t *testing.T) {
+	require := require.New(t)
+	response := run(t, &devinterface.DeveloperRequest{
+		Context: &devinterface.RequestContext{
+			Schema: "definition user {}",
+		},
+		Operations: []*devinterface.Operation{
+			{
+				ParseRelationshipParameters: &devinterface.ParseRelationshipParameters{
+					Relationship: "document:somedoc#viewer@user:someuser",
+				},
+			},
+			{
+				ParseRelationshipParameters: &devinterface.ParseRelationshipParameters{
+					Relationship: "document:somedoc#viewer@user",
+				},
+			},
+		},
+	})
+
+	validResult := response.GetOperationsResults().Results[0].GetParseRelationshipResult()
+	require.Nil(validResult.InputError)
+	testutil.RequireProtoEqual(t, tuple.MustParse("document:somedoc#viewer@user:someuser"), validResult.Relationship, "found mismatching relationship")
+
+	invalidResult := response.GetOperationsResults().Results[1].GetParseRelationshipResult()
+	require.Nil(invalidResult.Relationship)
+	require.Equal(devinterface.DeveloperError_PARSE_ERROR, invalidResult.InputError.Kind)
+	require.Equal([]string{"subject ID"}, invalidResult.InputError.Path)
+}
+
 func TestRunAssertionsAndValidationOperations(t *testing.T) {
 	type testCase struct {
 		name                   string
@@ -57,6 +57,15 @@ func Relation(name string, rewrite *core.UsersetRewrite, allowedDirectRelations
 	return rel
 }
 
+// Alias creates a permission definition which is an alias of the given relation or permission.
+func Alias(name string, aliasedRelation string) *core.Relation {
+	rel := Relation(name, Union(ComputedUserset(aliasedRelation)))
+	if err := MarkAlias(rel, aliasedRelation); err != nil {
+		panic("failed to mark alias: " + err.Error())
+	}
+	return rel
+}
+
 // RelationWithComment creates a relation definition with an optional rewrite definition.
 func RelationWithComment(name string, comment string, rewrite *core.UsersetRewrite, allowedDirectRelations ...*core.AllowedRelation) *core.Relation {
 	rel := Relation(name, rewrite, allowedDirectRelations...)
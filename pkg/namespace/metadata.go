@@ -17,7 +17,8 @@ type WithSourcePosition interface {
 //  in the namespace proto. If placed here, FilterUserDefinedMetadataInPlace will remove the
 // metadata when called on the namespace.
 var userDefinedMetadataTypeUrls = map[string]struct{}{
-	"type.googleapis.com/impl.v1.DocComment": {},
+	"type.googleapis.com/impl.v1.DocComment":         {},
+	"type.googleapis.com/impl.v1.RelationFormatting": {},
 }
 
 // FilterUserDefinedMetadataInPlace removes user-defined metadata (e.g. comments) from the given namespace
@@ -108,3 +109,80 @@ func SetRelationKind(relation *core.Relation, kind iv1.RelationMetadata_Relation
 	metadata.MetadataMessage = append(metadata.MetadataMessage, encoded)
 	return nil
 }
+
+// IsPrecededByBlankLine returns whether the relation was separated from the relation before it
+// by a blank line in its source schema. Returns false if no formatting metadata is found.
+func IsPrecededByBlankLine(relation *core.Relation) bool {
+	metadata := relation.Metadata
+	if metadata == nil {
+		return false
+	}
+
+	for _, msg := range metadata.MetadataMessage {
+		var rf iv1.RelationFormatting
+		if err := msg.UnmarshalTo(&rf); err == nil {
+			return rf.PrecededByBlankLine
+		}
+	}
+
+	return false
+}
+
+// MarkPrecededByBlankLine marks the relation as being separated from the relation before it by
+// a blank line in its source schema.
+func MarkPrecededByBlankLine(relation *core.Relation) error {
+	metadata := relation.Metadata
+	if metadata == nil {
+		metadata = &core.Metadata{}
+		relation.Metadata = metadata
+	}
+
+	var rf iv1.RelationFormatting
+	rf.PrecededByBlankLine = true
+
+	encoded, err := anypb.New(&rf)
+	if err != nil {
+		return err
+	}
+
+	metadata.MetadataMessage = append(metadata.MetadataMessage, encoded)
+	return nil
+}
+
+// GetAliasedRelation returns the name of the relation or permission aliased by the permission,
+// if the permission was recognized as an alias.
+func GetAliasedRelation(relation *core.Relation) (string, bool) {
+	metadata := relation.Metadata
+	if metadata == nil {
+		return "", false
+	}
+
+	for _, msg := range metadata.MetadataMessage {
+		var ra iv1.RelationAlias
+		if err := msg.UnmarshalTo(&ra); err == nil {
+			return ra.AliasedRelation, true
+		}
+	}
+
+	return "", false
+}
+
+// MarkAlias marks the permission as an alias of the given relation or permission.
+func MarkAlias(relation *core.Relation, aliasedRelation string) error {
+	metadata := relation.Metadata
+	if metadata == nil {
+		metadata = &core.Metadata{}
+		relation.Metadata = metadata
+	}
+
+	var ra iv1.RelationAlias
+	ra.AliasedRelation = aliasedRelation
+
+	encoded, err := anypb.New(&ra)
+	if err != nil {
+		return err
+	}
+
+	metadata.MetadataMessage = append(metadata.MetadataMessage, encoded)
+	return nil
+}
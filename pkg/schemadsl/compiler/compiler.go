@@ -10,6 +10,7 @@ import (
 	"github.com/authzed/spicedb/pkg/schemadsl/dslshape"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
 	"github.com/authzed/spicedb/pkg/schemadsl/parser"
+	"github.com/authzed/spicedb/pkg/util"
 )
 
 // InputSchema defines the input for a Compile.
@@ -43,29 +44,152 @@ type CompiledSchema struct {
 
 // Compile compilers the input schema into a set of namespace definition protos.
 func Compile(schema InputSchema, objectTypePrefix *string) (*CompiledSchema, error) {
+	parsed, err := parse(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := translate(parsed.translationContext(objectTypePrefix), parsed.root, util.NewSet[string]())
+	if err != nil {
+		return nil, parsed.contextualize(err)
+	}
+
+	return compiled, nil
+}
+
+// CompileMultiple compiles the given input schemas into a single set of definitions, as if they
+// formed a single schema. Compilation is performed in two passes: the first collects the
+// definitions, relations, permissions and caveats found across all of the sources, and the
+// second resolves the type references of every source against them. A definition may therefore
+// reference a definition, relation or caveat found in any of the sources, regardless of the order
+// in which the sources are given. Unlike Compile, a type reference which does not resolve is an
+// error.
+//
+// The definitions are returned in the order in which the sources were given, followed by the
+// order in which they were found within each source.
+func CompileMultiple(schemas []InputSchema, objectTypePrefix *string) (*CompiledSchema, error) {
+	combined := &CompiledSchema{}
+	names := util.NewSet[string]()
+
+	parsedSchemas := make([]parsedSchema, 0, len(schemas))
+	for _, schema := range schemas {
+		parsed, err := parse(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled, err := translate(parsed.translationContext(objectTypePrefix), parsed.root, names)
+		if err != nil {
+			return nil, parsed.contextualize(err)
+		}
+
+		combined.ObjectDefinitions = append(combined.ObjectDefinitions, compiled.ObjectDefinitions...)
+		combined.CaveatDefinitions = append(combined.CaveatDefinitions, compiled.CaveatDefinitions...)
+		combined.OrderedDefinitions = append(combined.OrderedDefinitions, compiled.OrderedDefinitions...)
+		parsedSchemas = append(parsedSchemas, parsed)
+	}
+
+	relationsByDefinition := make(map[string]*util.Set[string], len(combined.ObjectDefinitions))
+	for _, def := range combined.ObjectDefinitions {
+		relationNames := util.NewSet[string]()
+		for _, relation := range def.Relation {
+			relationNames.Add(relation.Name)
+		}
+		relationsByDefinition[def.Name] = relationNames
+	}
+
+	caveatNames := util.NewSet[string]()
+	for _, caveat := range combined.CaveatDefinitions {
+		caveatNames.Add(caveat.Name)
+	}
+
+	for _, parsed := range parsedSchemas {
+		tctx := parsed.translationContext(objectTypePrefix)
+		for _, typeRefNode := range parsed.root.FindAll(dslshape.NodeTypeSpecificTypeReference) {
+			if err := resolveTypeReference(tctx, typeRefNode, relationsByDefinition, caveatNames); err != nil {
+				return nil, parsed.contextualize(err)
+			}
+		}
+	}
+
+	return combined, nil
+}
+
+// resolveTypeReference ensures that the definition, relation and caveat referenced by the type
+// reference node were found.
+func resolveTypeReference(
+	tctx translationContext,
+	typeRefNode *dslNode,
+	relationsByDefinition map[string]*util.Set[string],
+	caveatNames *util.Set[string],
+) error {
+	ref, err := translateSpecificTypeReference(tctx, typeRefNode)
+	if err != nil {
+		return err
+	}
+
+	typePath, _ := typeRefNode.GetString(dslshape.NodeSpecificReferencePredicateType)
+	relationNames, ok := relationsByDefinition[ref.Namespace]
+	if !ok {
+		return typeRefNode.ErrorWithSourcef(typePath, "definition `%s` not found", ref.Namespace)
+	}
+
+	if relationName := ref.GetRelation(); relationName != "" && relationName != Ellipsis && !relationNames.Has(relationName) {
+		return typeRefNode.ErrorWithSourcef(typePath, "relation or permission `%s` not found under definition `%s`", relationName, ref.Namespace)
+	}
+
+	if ref.RequiredCaveat != nil {
+		// Caveat references are not prefixed when translated, so the prefixed name is checked as well.
+		caveatName := ref.RequiredCaveat.CaveatName
+		caveatPath, err := tctx.prefixedPath(caveatName)
+		if err != nil {
+			caveatPath = caveatName
+		}
+
+		if !caveatNames.Has(caveatName) && !caveatNames.Has(caveatPath) {
+			return typeRefNode.ErrorWithSourcef(caveatName, "caveat `%s` not found", caveatName)
+		}
+	}
+
+	return nil
+}
+
+// parsedSchema is an input schema which has been parsed without error.
+type parsedSchema struct {
+	schema InputSchema
+	mapper input.PositionMapper
+	root   *dslNode
+}
+
+func parse(schema InputSchema) (parsedSchema, error) {
 	mapper := newPositionMapper(schema)
 	root := parser.Parse(createAstNode, schema.Source, schema.SchemaString).(*dslNode)
 	errs := root.FindAll(dslshape.NodeTypeError)
 	if len(errs) > 0 {
 		err := errorNodeToError(errs[0], mapper)
-		return nil, err
+		return parsedSchema{}, err
 	}
 
-	compiled, err := translate(translationContext{
+	return parsedSchema{schema: schema, mapper: mapper, root: root}, nil
+}
+
+func (ps parsedSchema) translationContext(objectTypePrefix *string) translationContext {
+	return translationContext{
 		objectTypePrefix: objectTypePrefix,
-		mapper:           mapper,
-		schemaString:     schema.SchemaString,
-	}, root)
-	if err != nil {
-		var errorWithNode errorWithNode
-		if errors.As(err, &errorWithNode) {
-			err = toContextError(errorWithNode.error.Error(), errorWithNode.errorSourceCode, errorWithNode.node, mapper)
-		}
+		mapper:           ps.mapper,
+		schemaRunes:      []rune(ps.schema.SchemaString),
+	}
+}
 
-		return nil, err
+// contextualize converts an error raised on a node of the schema into an error with the context
+// of its position within the schema.
+func (ps parsedSchema) contextualize(err error) error {
+	var errorWithNode errorWithNode
+	if errors.As(err, &errorWithNode) {
+		err = toContextError(errorWithNode.error.Error(), errorWithNode.errorSourceCode, errorWithNode.node, ps.mapper)
 	}
 
-	return compiled, nil
+	return err
 }
 
 func errorNodeToError(node *dslNode, mapper input.PositionMapper) error {
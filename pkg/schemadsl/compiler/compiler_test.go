@@ -207,6 +207,49 @@ func TestCompile(t *testing.T) {
 				),
 			},
 		},
+		{
+			"alias",
+			&someTenant,
+			`definition simple {
+				permission foos = alias bars;
+			}`,
+			"",
+			[]SchemaDefinition{
+				namespace.Namespace("sometenant/simple",
+					namespace.Alias("foos", "bars"),
+				),
+			},
+		},
+		{
+			"relation named alias",
+			&someTenant,
+			`definition simple {
+				relation alias: sometenant/user
+				permission foos = alias;
+			}`,
+			"",
+			[]SchemaDefinition{
+				namespace.Namespace("sometenant/simple",
+					namespace.Relation("alias", nil,
+						namespace.AllowedRelation("sometenant/user", "..."),
+					),
+					namespace.Relation("foos",
+						namespace.Union(
+							namespace.ComputedUserset("alias"),
+						),
+					),
+				),
+			},
+		},
+		{
+			"alias with set operation",
+			&someTenant,
+			`definition simple {
+				permission foos = alias bars + bazs;
+			}`,
+			"parse error in `alias with set operation`, line 2, column 5: alias foos must refer to exactly one relation or permission",
+			[]SchemaDefinition{},
+		},
 		{
 			"union permission",
 			&someTenant,
@@ -794,3 +837,129 @@ func filterSourcePositions(m protoreflect.Message) {
 		return true
 	})
 }
+
+func TestCompileBlankLineGrouping(t *testing.T) {
+	compiled, err := Compile(InputSchema{
+		input.Source("blank lines"),
+		`definition sometenant/document {
+			relation first: sometenant/user
+			relation second: sometenant/user
+
+			relation third: sometenant/user
+			/*
+			 a comment
+
+			 with a blank line
+			*/
+			relation fourth: sometenant/user; relation fifth: sometenant/user
+
+			// a comment
+			permission sixth = first
+		}`,
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, compiled.ObjectDefinitions, 1)
+
+	found := map[string]bool{}
+	for _, relation := range compiled.ObjectDefinitions[0].Relation {
+		found[relation.Name] = namespace.IsPrecededByBlankLine(relation)
+	}
+
+	require.Equal(t, map[string]bool{
+		"first":  false,
+		"second": false,
+		"third":  true,
+		"fourth": false,
+		"fifth":  false,
+		"sixth":  true,
+	}, found)
+}
+
+func TestCompileMultiple(t *testing.T) {
+	documentSchema := InputSchema{input.Source("document"), `definition document {
+		relation viewer: user | user:* | group#member | user with somecaveat
+		permission view = viewer
+	}`}
+	groupSchema := InputSchema{input.Source("group"), `definition group {
+		relation member: user | group#member
+	}`}
+	userSchema := InputSchema{input.Source("user"), `definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}`}
+
+	tests := []struct {
+		name          string
+		schemas       []InputSchema
+		expectedError string
+		expectedNames []string
+	}{
+		{
+			"references resolved from later sources",
+			[]InputSchema{documentSchema, groupSchema, userSchema},
+			"",
+			[]string{"document", "group", "user", "somecaveat"},
+		},
+		{
+			"references resolved from earlier sources",
+			[]InputSchema{userSchema, groupSchema, documentSchema},
+			"",
+			[]string{"user", "somecaveat", "group", "document"},
+		},
+		{
+			"missing definition",
+			[]InputSchema{documentSchema, userSchema},
+			"parse error in `document`, line 2, column 36: definition `group` not found",
+			nil,
+		},
+		{
+			"missing relation",
+			[]InputSchema{documentSchema, userSchema, {input.Source("othergroup"), `definition group {
+				relation admin: user
+			}`}},
+			"parse error in `document`, line 2, column 36: relation or permission `member` not found under definition `group`",
+			nil,
+		},
+		{
+			"missing caveat",
+			[]InputSchema{documentSchema, groupSchema, {input.Source("otheruser"), `definition user {}`}},
+			"parse error in `document`, line 2, column 51: caveat `somecaveat` not found",
+			nil,
+		},
+		{
+			"name reused across sources",
+			[]InputSchema{documentSchema, groupSchema, userSchema, {input.Source("duplicate"), `definition group {}`}},
+			"parse error in `duplicate`, line 1, column 1: found name reused between multiple definitions and/or caveats: group",
+			nil,
+		},
+		{
+			"parse error",
+			[]InputSchema{documentSchema, {input.Source("broken"), `definition user {`}},
+			"parse error in `broken`",
+			nil,
+		},
+	}
+
+	emptyPrefix := ""
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			compiled, err := CompileMultiple(test.schemas, &emptyPrefix)
+			if test.expectedError != "" {
+				require.ErrorContains(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(compiled.OrderedDefinitions))
+			for _, def := range compiled.OrderedDefinitions {
+				names = append(names, def.GetName())
+			}
+			require.Equal(t, test.expectedNames, names)
+			require.Len(t, compiled.ObjectDefinitions, 3)
+			require.Len(t, compiled.CaveatDefinitions, 1)
+		})
+	}
+}
@@ -17,12 +17,16 @@ import (
 	"github.com/authzed/spicedb/pkg/namespace"
 	"github.com/authzed/spicedb/pkg/schemadsl/dslshape"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
+	"github.com/authzed/spicedb/pkg/schemadsl/lexer"
 )
 
 type translationContext struct {
 	objectTypePrefix *string
 	mapper           input.PositionMapper
-	schemaString     string
+
+	// schemaRunes is the source of the schema, converted once so that node positions, which are
+	// rune offsets, can be sliced from it.
+	schemaRunes []rune
 }
 
 func (tctx translationContext) prefixedPath(definitionName string) (string, error) {
@@ -44,13 +48,13 @@ func (tctx translationContext) prefixedPath(definitionName string) (string, erro
 
 const Ellipsis = "..."
 
-func translate(tctx translationContext, root *dslNode) (*CompiledSchema, error) {
+// translate translates the definitions found under the root node. The names of the definitions
+// are added to the given set, and reusing a name already within it is an error.
+func translate(tctx translationContext, root *dslNode, names *util.Set[string]) (*CompiledSchema, error) {
 	orderedDefinitions := make([]SchemaDefinition, 0, len(root.GetChildren()))
 	var objectDefinitions []*core.NamespaceDefinition
 	var caveatDefinitions []*core.CaveatDefinition
 
-	names := util.NewSet[string]()
-
 	for _, definitionNode := range root.GetChildren() {
 		var definition SchemaDefinition
 
@@ -201,6 +205,7 @@ func translateObjectDefinition(tctx translationContext, defNode *dslNode) (*core
 	}
 
 	relationsAndPermissions := []*core.Relation{}
+	var previousNode *dslNode
 	for _, relationOrPermissionNode := range defNode.GetChildren() {
 		if relationOrPermissionNode.GetType() == dslshape.NodeTypeComment {
 			continue
@@ -211,7 +216,14 @@ func translateObjectDefinition(tctx translationContext, defNode *dslNode) (*core
 			return nil, err
 		}
 
+		if previousNode != nil && hasBlankLineBetween(tctx, previousNode, relationOrPermissionNode) {
+			if err := namespace.MarkPrecededByBlankLine(relationOrPermission); err != nil {
+				return nil, relationOrPermissionNode.Errorf("error adding formatting metadata: %w", err)
+			}
+		}
+
 		relationsAndPermissions = append(relationsAndPermissions, relationOrPermission)
+		previousNode = relationOrPermissionNode
 	}
 
 	nspath, err := tctx.prefixedPath(definitionName)
@@ -264,6 +276,49 @@ func getSourcePosition(dslNode *dslNode, mapper input.PositionMapper) *core.Sour
 	}
 }
 
+// hasBlankLineBetween returns whether there is at least one blank line in the source between
+// the end of the first node and the start of the second. Lines found within comments are not
+// considered to be blank.
+func hasBlankLineBetween(tctx translationContext, first *dslNode, second *dslNode) bool {
+	firstEnd, err := first.GetInt(dslshape.NodePredicateEndRune)
+	if err != nil {
+		return false
+	}
+
+	secondStart, err := second.GetInt(dslshape.NodePredicateStartRune)
+	if err != nil {
+		return false
+	}
+
+	if firstEnd+1 >= secondStart || secondStart > len(tctx.schemaRunes) {
+		return false
+	}
+
+	lx := lexer.NewPeekableLexer(lexer.Lex(input.Source("gap"), string(tctx.schemaRunes[firstEnd+1:secondStart])))
+	defer lx.Close()
+
+	atLineStart := false
+	for {
+		token := lx.NextToken()
+		switch token.Kind {
+		case lexer.TokenTypeEOF, lexer.TokenTypeError:
+			return false
+
+		case lexer.TokenTypeNewline, lexer.TokenTypeSyntheticSemicolon:
+			if atLineStart {
+				return true
+			}
+			atLineStart = true
+
+		case lexer.TokenTypeWhitespace:
+			continue
+
+		default:
+			atLineStart = false
+		}
+	}
+}
+
 func addComments(mdmsg *core.Metadata, dslNode *dslNode) *core.Metadata {
 	for _, child := range dslNode.GetChildren() {
 		if child.GetType() == dslshape.NodeTypeComment {
@@ -347,6 +402,12 @@ func translatePermission(tctx translationContext, permissionNode *dslNode) (*cor
 		return nil, permissionNode.Errorf("invalid permission expression: %w", err)
 	}
 
+	if permissionNode.Has(dslshape.NodePermissionPredicateIsAlias) {
+		if expressionNode.GetType() != dslshape.NodeTypeIdentifier {
+			return nil, permissionNode.Errorf("alias %s must refer to exactly one relation or permission", permissionName)
+		}
+	}
+
 	rewrite, err := translateExpression(tctx, expressionNode)
 	if err != nil {
 		return nil, err
@@ -358,6 +419,17 @@ func translatePermission(tctx translationContext, permissionNode *dslNode) (*cor
 		return nil, permissionNode.Errorf("error in permission %s: %w", permissionName, err)
 	}
 
+	if permissionNode.Has(dslshape.NodePermissionPredicateIsAlias) {
+		aliasedRelation, err := expressionNode.GetString(dslshape.NodeIdentiferPredicateValue)
+		if err != nil {
+			return nil, permissionNode.Errorf("invalid alias %s: %w", permissionName, err)
+		}
+
+		if err := namespace.MarkAlias(permission, aliasedRelation); err != nil {
+			return nil, permissionNode.Errorf("error in alias %s: %w", permissionName, err)
+		}
+	}
+
 	return permission, nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"github.com/authzed/spicedb/pkg/caveats"
 
 	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/reflect/protoreflect"
 
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 
@@ -17,6 +18,7 @@ import (
 	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
 	"github.com/authzed/spicedb/pkg/graph"
 	"github.com/authzed/spicedb/pkg/namespace"
+	"github.com/authzed/spicedb/pkg/util"
 )
 
 // Ellipsis is the relation name for terminal subjects.
@@ -25,19 +27,46 @@ const Ellipsis = "..."
 // MaxSingleLineCommentLength sets the maximum length for a comment to made single line.
 const MaxSingleLineCommentLength = 70 // 80 - the comment parts and some padding
 
+// GeneratedCommentPrefix is the prefix of the informational comments emitted by the generator.
+const GeneratedCommentPrefix = "// (generated) "
+
+// Option is an option for generating a DSL view.
+type Option func(sg *sourceGenerator)
+
+// WithTypeInformationComments emits a generated comment above each relation and permission,
+// listing the allowed types of relations and the permissions which reference it. Generated
+// comments found on the definitions are replaced, rather than emitted again.
+func WithTypeInformationComments() Option {
+	return func(sg *sourceGenerator) {
+		sg.emitTypeInformation = true
+	}
+}
+
+// WithIndentation emits each level of indentation as the given character repeated width times,
+// such as four spaces, rather than as a single tab. A width of less than one is treated as one.
+func WithIndentation(character rune, width int) Option {
+	if width < 1 {
+		width = 1
+	}
+
+	return func(sg *sourceGenerator) {
+		sg.indentation = strings.Repeat(string(character), width)
+	}
+}
+
 // GenerateSchema generates a DSL view of the given schema.
-func GenerateSchema(definitions []compiler.SchemaDefinition) (string, bool) {
+func GenerateSchema(definitions []compiler.SchemaDefinition, options ...Option) (string, bool) {
 	generated := make([]string, 0, len(definitions))
 	result := true
 	for _, definition := range definitions {
 		switch def := definition.(type) {
 		case *core.CaveatDefinition:
-			generatedCaveat, ok := GenerateCaveatSource(def)
+			generatedCaveat, ok := GenerateCaveatSource(def, options...)
 			result = result && ok
 			generated = append(generated, generatedCaveat)
 
 		case *core.NamespaceDefinition:
-			generatedSchema, ok := GenerateSource(def)
+			generatedSchema, ok := GenerateSource(def, options...)
 			result = result && ok
 			generated = append(generated, generatedSchema)
 
@@ -50,29 +79,32 @@ func GenerateSchema(definitions []compiler.SchemaDefinition) (string, bool) {
 }
 
 // GenerateCaveatSource generates a DSL view of the given caveat definition.
-func GenerateCaveatSource(caveat *core.CaveatDefinition) (string, bool) {
-	generator := &sourceGenerator{
-		indentationLevel: 0,
-		hasNewline:       true,
-		hasBlankline:     true,
-		hasNewScope:      true,
-	}
+func GenerateCaveatSource(caveat *core.CaveatDefinition, options ...Option) (string, bool) {
+	generator := newSourceGenerator(options...)
 
 	generator.emitCaveat(caveat)
 	return generator.buf.String(), !generator.hasIssue
 }
 
 // GenerateSource generates a DSL view of the given namespace definition.
-func GenerateSource(namespace *core.NamespaceDefinition) (string, bool) {
+func GenerateSource(namespace *core.NamespaceDefinition, options ...Option) (string, bool) {
+	generator := newSourceGenerator(options...)
+	generator.emitNamespace(namespace)
+	return generator.buf.String(), !generator.hasIssue
+}
+
+func newSourceGenerator(options ...Option) *sourceGenerator {
 	generator := &sourceGenerator{
 		indentationLevel: 0,
+		indentation:      "\t",
 		hasNewline:       true,
 		hasBlankline:     true,
 		hasNewScope:      true,
 	}
-
-	generator.emitNamespace(namespace)
-	return generator.buf.String(), !generator.hasIssue
+	for _, option := range options {
+		option(generator)
+	}
+	return generator
 }
 
 func (sg *sourceGenerator) emitCaveat(caveat *core.CaveatDefinition) {
@@ -138,6 +170,10 @@ func (sg *sourceGenerator) emitNamespace(namespace *core.NamespaceDefinition) {
 	sg.indent()
 	sg.markNewScope()
 
+	if sg.emitTypeInformation {
+		sg.referencingPermissions = referencingPermissions(namespace)
+	}
+
 	for _, relation := range namespace.Relation {
 		sg.emitRelation(relation)
 	}
@@ -150,7 +186,15 @@ func (sg *sourceGenerator) emitRelation(relation *core.Relation) {
 	hasThis := graph.HasThis(relation.UsersetRewrite)
 	isPermission := relation.UsersetRewrite != nil && !hasThis
 
-	sg.emitComments(relation.Metadata)
+	if namespace.IsPrecededByBlankLine(relation) {
+		sg.ensureBlankLineOrNewScope()
+	}
+
+	hasComments := sg.emitComments(relation.Metadata)
+	if sg.emitTypeInformation {
+		sg.emitTypeInformationComments(relation, isPermission, hasComments)
+	}
+
 	if isPermission {
 		sg.append("permission ")
 	} else {
@@ -176,25 +220,151 @@ func (sg *sourceGenerator) emitRelation(relation *core.Relation) {
 
 	if relation.UsersetRewrite != nil {
 		sg.append(" = ")
+		if _, isAlias := namespace.GetAliasedRelation(relation); isAlias && isPermission {
+			sg.append("alias ")
+		}
 		sg.emitRewrite(relation.UsersetRewrite)
 	}
 
 	sg.appendLine()
 }
 
+// emitTypeInformationComments emits the generated comments describing the given relation.
+// The comments follow those already emitted for the relation, if any.
+func (sg *sourceGenerator) emitTypeInformationComments(relation *core.Relation, isPermission bool, followsComments bool) {
+	var comments []string
+	if !isPermission && len(relation.GetTypeInformation().GetAllowedDirectRelations()) > 0 {
+		allowedTypes := make([]string, 0, len(relation.TypeInformation.AllowedDirectRelations))
+		for _, allowedRelation := range relation.TypeInformation.AllowedDirectRelations {
+			allowedTypes = append(allowedTypes, allowedRelationString(allowedRelation))
+		}
+		comments = append(comments, "allowed types: "+strings.Join(allowedTypes, ", "))
+	}
+
+	if permissions := sg.referencingPermissions[relation.Name]; len(permissions) > 0 {
+		comments = append(comments, "referenced by: "+strings.Join(permissions, ", "))
+	}
+
+	if len(comments) == 0 {
+		return
+	}
+
+	if !followsComments {
+		sg.ensureBlankLineOrNewScope()
+	}
+
+	for _, comment := range comments {
+		sg.append(GeneratedCommentPrefix)
+		sg.append(comment)
+		sg.appendLine()
+	}
+}
+
+// referencingPermissions returns the sorted names of the permissions referencing each relation or
+// permission of the namespace, either directly or as the tupleset of an arrow.
+func referencingPermissions(namespace *core.NamespaceDefinition) map[string][]string {
+	referencing := map[string]*util.Set[string]{}
+	addReference := func(relationName string, permissionName string) {
+		if _, ok := referencing[relationName]; !ok {
+			referencing[relationName] = util.NewSet[string]()
+		}
+		referencing[relationName].Add(permissionName)
+	}
+
+	var collect func(permissionName string, rewrite *core.UsersetRewrite)
+	collect = func(permissionName string, rewrite *core.UsersetRewrite) {
+		var setOp *core.SetOperation
+		switch rw := rewrite.RewriteOperation.(type) {
+		case *core.UsersetRewrite_Union:
+			setOp = rw.Union
+		case *core.UsersetRewrite_Intersection:
+			setOp = rw.Intersection
+		case *core.UsersetRewrite_Exclusion:
+			setOp = rw.Exclusion
+		}
+
+		for _, setOpChild := range setOp.GetChild() {
+			switch child := setOpChild.ChildType.(type) {
+			case *core.SetOperation_Child_UsersetRewrite:
+				collect(permissionName, child.UsersetRewrite)
+			case *core.SetOperation_Child_ComputedUserset:
+				addReference(child.ComputedUserset.Relation, permissionName)
+			case *core.SetOperation_Child_TupleToUserset:
+				addReference(child.TupleToUserset.Tupleset.Relation, permissionName)
+			}
+		}
+	}
+
+	for _, relation := range namespace.Relation {
+		if relation.UsersetRewrite != nil {
+			collect(relation.Name, relation.UsersetRewrite)
+		}
+	}
+
+	referencingPermissions := make(map[string][]string, len(referencing))
+	for relationName, permissionNames := range referencing {
+		sorted := permissionNames.AsSlice()
+		sort.Strings(sorted)
+		referencingPermissions[relationName] = sorted
+	}
+	return referencingPermissions
+}
+
 func (sg *sourceGenerator) emitAllowedRelation(allowedRelation *core.AllowedRelation) {
-	sg.append(allowedRelation.Namespace)
+	sg.append(allowedRelationString(allowedRelation))
+
+	// Fields which cannot be expressed in the DSL, such as those added by a newer version of the
+	// schema, would otherwise be lost silently, so they are reported as an issue instead.
+	if unsupported := unsupportedAllowedRelationFields(allowedRelation); len(unsupported) > 0 {
+		sg.append(" ")
+		sg.appendIssue("unsupported fields on allowed type: " + strings.Join(unsupported, ", "))
+	}
+}
+
+// emittedAllowedRelationFields are the fields of an allowed relation which are expressed in the
+// DSL, or which need not be.
+var emittedAllowedRelationFields = map[protoreflect.Name]struct{}{
+	"namespace":       {},
+	"relation":        {},
+	"public_wildcard": {},
+	"required_caveat": {},
+	"source_position": {},
+}
+
+// unsupportedAllowedRelationFields returns the sorted names of the fields set on the allowed
+// relation which the generator does not emit, including any fields unknown to this version.
+func unsupportedAllowedRelationFields(allowedRelation *core.AllowedRelation) []string {
+	var unsupported []string
+	message := allowedRelation.ProtoReflect()
+	message.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if _, ok := emittedAllowedRelationFields[field.Name()]; !ok {
+			unsupported = append(unsupported, string(field.Name()))
+		}
+		return true
+	})
+	sort.Strings(unsupported)
+
+	if len(message.GetUnknown()) > 0 || len(allowedRelation.GetRequiredCaveat().ProtoReflect().GetUnknown()) > 0 {
+		unsupported = append(unsupported, "unknown fields")
+	}
+	return unsupported
+}
+
+func allowedRelationString(allowedRelation *core.AllowedRelation) string {
+	var sb strings.Builder
+	sb.WriteString(allowedRelation.Namespace)
 	if allowedRelation.GetRelation() != "" && allowedRelation.GetRelation() != Ellipsis {
-		sg.append("#")
-		sg.append(allowedRelation.GetRelation())
+		sb.WriteString("#")
+		sb.WriteString(allowedRelation.GetRelation())
 	}
 	if allowedRelation.GetPublicWildcard() != nil {
-		sg.append(":*")
+		sb.WriteString(":*")
 	}
 	if allowedRelation.GetRequiredCaveat() != nil {
-		sg.append(" with ")
-		sg.append(allowedRelation.RequiredCaveat.CaveatName)
+		sb.WriteString(" with ")
+		sb.WriteString(allowedRelation.RequiredCaveat.CaveatName)
 	}
+	return sb.String()
 }
 
 func (sg *sourceGenerator) emitRewrite(rewrite *core.UsersetRewrite) {
@@ -265,14 +435,29 @@ func (sg *sourceGenerator) emitSetOpChild(setOpChild *core.SetOperation_Child) {
 	}
 }
 
-func (sg *sourceGenerator) emitComments(metadata *core.Metadata) {
-	if len(namespace.GetComments(metadata)) > 0 {
+// emitComments emits the comments found in the metadata, returning whether any were emitted.
+func (sg *sourceGenerator) emitComments(metadata *core.Metadata) bool {
+	comments := namespace.GetComments(metadata)
+	if sg.emitTypeInformation {
+		// Previously generated comments are replaced by the newly generated ones.
+		filtered := make([]string, 0, len(comments))
+		for _, comment := range comments {
+			if !strings.HasPrefix(strings.TrimSpace(comment), strings.TrimSpace(GeneratedCommentPrefix)) {
+				filtered = append(filtered, comment)
+			}
+		}
+		comments = filtered
+	}
+
+	if len(comments) > 0 {
 		sg.ensureBlankLineOrNewScope()
 	}
 
-	for _, comment := range namespace.GetComments(metadata) {
+	for _, comment := range comments {
 		sg.appendComment(comment)
 	}
+
+	return len(comments) > 0
 }
 
 func (sg *sourceGenerator) appendComment(comment string) {
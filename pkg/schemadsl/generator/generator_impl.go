@@ -7,11 +7,15 @@ import (
 type sourceGenerator struct {
 	buf                strings.Builder // The buffer for the new source code.
 	indentationLevel   int             // The current indentation level.
+	indentation        string          // The indentation emitted for each level.
 	hasNewline         bool            // Whether there is a newline at the end of the buffer.
 	hasBlankline       bool            // Whether there is a blank line at the end of the buffer.
 	hasIssue           bool            // Whether there is a translation issue.
 	hasNewScope        bool            // Whether there is a new scope at the end of the buffer.
 	existingLineLength int             // Length of the existing line.
+
+	emitTypeInformation    bool                // Whether to emit generated type information comments.
+	referencingPermissions map[string][]string // The permissions referencing each relation of the current namespace.
 }
 
 // ensureBlankLineOrNewScope ensures that there is a blank line or new scope at the tail of the buffer. If not,
@@ -58,9 +62,9 @@ func (sg *sourceGenerator) append(value string) {
 		sg.hasNewScope = false
 
 		if sg.hasNewline {
-			sg.buf.WriteString(strings.Repeat("\t", sg.indentationLevel))
+			sg.buf.WriteString(strings.Repeat(sg.indentation, sg.indentationLevel))
 			sg.hasNewline = false
-			sg.existingLineLength += sg.indentationLevel
+			sg.existingLineLength += sg.indentationLevel * len(sg.indentation)
 		}
 
 		sg.existingLineLength++
@@ -301,6 +301,45 @@ definition foos/test {
 }`,
 		},
 
+		{
+			"preserves blank line grouping",
+			`definition foos/test {
+				relation first: foos/bars
+				relation second: foos/bars
+
+
+				relation third: foos/bars
+				permission fourth = first + second
+
+				permission fifth = third
+			}`,
+			`definition foos/test {
+	relation first: foos/bars
+	relation second: foos/bars
+
+	relation third: foos/bars
+	permission fourth = first + second
+
+	permission fifth = third
+}`,
+		},
+
+		{
+			"alias",
+			`definition foos/test {
+				relation viewer: foos/bars
+				permission reader = alias viewer
+				permission view = reader
+				permission arrow = viewer->reader
+			}`,
+			`definition foos/test {
+	relation viewer: foos/bars
+	permission reader = alias viewer
+	permission view = reader
+	permission arrow = viewer->reader
+}`,
+		},
+
 		{
 			"full example",
 			`
@@ -365,3 +404,112 @@ definition foos/document {
 		})
 	}
 }
+
+func TestGenerateWithTypeInformationComments(t *testing.T) {
+	schema := `definition foos/user {}
+
+definition foos/document {
+	relation parent: foos/document
+	// some comment
+	relation reader: foos/user | foos/user:*
+	relation writer: foos/user
+	permission edit = writer
+	permission view = reader + edit + parent->view
+}`
+
+	expected := `definition foos/user {}
+
+definition foos/document {
+	// (generated) allowed types: foos/document
+	// (generated) referenced by: view
+	relation parent: foos/document
+
+	// some comment
+	// (generated) allowed types: foos/user, foos/user:*
+	// (generated) referenced by: view
+	relation reader: foos/user | foos/user:*
+
+	// (generated) allowed types: foos/user
+	// (generated) referenced by: edit
+	relation writer: foos/user
+
+	// (generated) referenced by: view
+	permission edit = writer
+	permission view = reader + edit + parent->view
+}`
+
+	require := require.New(t)
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, nil)
+	require.NoError(err)
+
+	source, ok := GenerateSchema(compiled.OrderedDefinitions, WithTypeInformationComments())
+	require.True(ok)
+	require.Equal(expected, source)
+
+	// Generating again from the generated schema must replace the generated comments.
+	recompiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: source,
+	}, nil)
+	require.NoError(err)
+
+	regenerated, _ := GenerateSchema(recompiled.OrderedDefinitions, WithTypeInformationComments())
+	require.Equal(expected, regenerated)
+
+	// Without the option, no generated comments are emitted.
+	plain, _ := GenerateSchema(compiled.OrderedDefinitions)
+	require.NotContains(plain, "(generated)")
+}
+
+func TestGenerateWithIndentation(t *testing.T) {
+	schema := `caveat foos/somecaveat(somecondition int) {
+	somecondition == 42
+}
+
+definition foos/user {}
+
+definition foos/document {
+	/**
+	 * a comment which spans
+	 * multiple lines
+	 */
+	relation reader: foos/user
+	relation writer: foos/user
+	permission view = reader + writer
+}`
+
+	expected := `caveat foos/somecaveat(somecondition int) {
+    somecondition == 42
+}
+
+definition foos/user {}
+
+definition foos/document {
+    /**
+     * a comment which spans
+     * multiple lines
+     */
+    relation reader: foos/user
+    relation writer: foos/user
+    permission view = reader + writer
+}`
+
+	require := require.New(t)
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source("schema"),
+		SchemaString: schema,
+	}, nil)
+	require.NoError(err)
+
+	source, ok := GenerateSchema(compiled.OrderedDefinitions, WithIndentation(' ', 4))
+	require.True(ok)
+	require.Equal(expected, source)
+
+	// The default indentation is a single tab.
+	source, ok = GenerateSchema(compiled.OrderedDefinitions)
+	require.True(ok)
+	require.Equal(schema, source)
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+// nonStructuralFields are the fields ignored when comparing a namespace definition to its
+// recompiled form, as they hold comments and positions rather than the structure of the schema.
+var nonStructuralFields = map[protoreflect.Name]struct{}{
+	"metadata":        {},
+	"source_position": {},
+}
+
+// ValidateSourceRoundTrip generates the DSL for the given namespace definition, recompiles it and
+// returns an error if the recompiled definition is not structurally equivalent to the original.
+// Comments and source positions are not compared. If the generator reported an issue or the
+// generated DSL fails to compile, the error includes the generated source.
+func ValidateSourceRoundTrip(namespace *core.NamespaceDefinition) error {
+	source, ok := GenerateSource(namespace)
+	if !ok {
+		return fmt.Errorf("issue found when generating source for definition `%s`:\n%s", namespace.Name, source)
+	}
+
+	emptyPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source:       input.Source(namespace.Name),
+		SchemaString: source,
+	}, &emptyPrefix)
+	if err != nil {
+		return fmt.Errorf("generated source for definition `%s` does not compile: %w\n%s", namespace.Name, err, source)
+	}
+
+	if len(compiled.ObjectDefinitions) != 1 {
+		return fmt.Errorf("generated source for definition `%s` compiled to %d definitions", namespace.Name, len(compiled.ObjectDefinitions))
+	}
+
+	expected := stripNonStructuralFields(namespace)
+	found := stripNonStructuralFields(compiled.ObjectDefinitions[0])
+	if diff := cmp.Diff(expected, found, protocmp.Transform()); diff != "" {
+		return fmt.Errorf("definition `%s` differs after round-trip (-original +recompiled):\n%s", namespace.Name, diff)
+	}
+
+	return nil
+}
+
+func stripNonStructuralFields(namespace *core.NamespaceDefinition) *core.NamespaceDefinition {
+	cloned := proto.Clone(namespace).(*core.NamespaceDefinition)
+	clearNonStructuralFields(cloned.ProtoReflect())
+	return cloned
+}
+
+func clearNonStructuralFields(msg protoreflect.Message) {
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if _, ok := nonStructuralFields[field.Name()]; ok {
+			msg.Clear(field)
+			return true
+		}
+
+		switch {
+		case field.IsList() && field.Message() != nil:
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				clearNonStructuralFields(list.Get(i).Message())
+			}
+		case field.Message() != nil && !field.IsMap():
+			clearNonStructuralFields(value.Message())
+		}
+		return true
+	})
+}
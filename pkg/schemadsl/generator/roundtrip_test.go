@@ -0,0 +1,177 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+
+	"github.com/authzed/spicedb/pkg/namespace"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+func TestValidateSourceRoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         *core.NamespaceDefinition
+		expectedError string
+	}{
+		{
+			"empty",
+			namespace.Namespace("foos/test"),
+			"",
+		},
+		{
+			"caveats and wildcards",
+			namespace.Namespace("foos/test",
+				namespace.Relation("viewer", nil,
+					namespace.AllowedRelation("foos/user", "..."),
+					namespace.AllowedPublicNamespace("foos/user"),
+					namespace.AllowedRelationWithCaveat("foos/user", "...", namespace.AllowedCaveat("somecaveat")),
+					namespace.AllowedPublicNamespaceWithCaveat("foos/user", namespace.AllowedCaveat("somecaveat")),
+					namespace.AllowedRelation("foos/group", "member"),
+				),
+			),
+			"",
+		},
+		{
+			"nested rewrites and exclusions",
+			namespace.Namespace("foos/test",
+				namespace.Relation("parent", nil, namespace.AllowedRelation("foos/test", "...")),
+				namespace.Relation("viewer", nil, namespace.AllowedRelation("foos/user", "...")),
+				namespace.Relation("banned", nil, namespace.AllowedRelation("foos/user", "...")),
+				namespace.Relation("view", namespace.Exclusion(
+					namespace.Rewrite(namespace.Union(
+						namespace.ComputedUserset("viewer"),
+						namespace.TupleToUserset("parent", "view"),
+					)),
+					namespace.Rewrite(namespace.Intersection(
+						namespace.ComputedUserset("banned"),
+						namespace.Nil(),
+					)),
+				)),
+			),
+			"",
+		},
+		{
+			"unsupported _this",
+			namespace.Namespace("foos/test",
+				namespace.Relation("viewer", namespace.Union(
+					&core.SetOperation_Child{ChildType: &core.SetOperation_Child_XThis{}},
+					namespace.ComputedUserset("editor"),
+				), namespace.AllowedRelation("foos/user", "...")),
+			),
+			"issue found when generating source for definition `foos/test`",
+		},
+		{
+			"unknown allowed type fields",
+			namespace.Namespace("foos/test",
+				namespace.Relation("viewer", nil, withUnknownField(namespace.AllowedRelation("foos/user", "..."))),
+			),
+			"unsupported fields on allowed type: unknown fields",
+		},
+		{
+			"ambiguous relation kind",
+			namespace.Namespace("foos/test",
+				namespace.Relation("viewer", namespace.Union(
+					namespace.ComputedUserset("editor"),
+				), namespace.AllowedRelation("foos/user", "...")),
+			),
+			"differs after round-trip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateSourceRoundTrip(test.input)
+			if test.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestValidateSourceRoundTripCompiled(t *testing.T) {
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `definition foos/user {}
+
+caveat foos/somecaveat(somecondition int) {
+	somecondition == 42
+}
+
+definition foos/document {
+	// the parent folder
+	relation parent: foos/document
+	relation viewer: foos/user | foos/user:* | foos/user with foos/somecaveat
+	relation banned: foos/user
+
+	permission view = (viewer + parent->view) - banned
+	permission read = alias view
+}`,
+	}, nil)
+	require.NoError(t, err)
+
+	for _, def := range compiled.ObjectDefinitions {
+		require.NoError(t, ValidateSourceRoundTrip(def), "round-trip failed for %s", def.Name)
+	}
+}
+
+func TestValidateSourceRoundTripCompiledMultiple(t *testing.T) {
+	compiled, err := compiler.CompileMultiple([]compiler.InputSchema{
+		{
+			Source: input.Source("document"),
+			SchemaString: `definition foos/document {
+	relation parent: foos/folder
+	relation viewer: foos/user | foos/user with foos/somecaveat
+	permission view = viewer + parent->view
+}`,
+		},
+		{
+			Source: input.Source("folder"),
+			SchemaString: `definition foos/folder {
+	relation viewer: foos/user | foos/folder#viewer
+	permission view = viewer
+}`,
+		},
+		{
+			Source: input.Source("user"),
+			SchemaString: `definition foos/user {}
+
+caveat foos/somecaveat(somecondition int) {
+	somecondition == 42
+}`,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	for _, def := range compiled.ObjectDefinitions {
+		require.NoError(t, ValidateSourceRoundTrip(def), "round-trip failed for %s", def.Name)
+	}
+
+	// The combined definitions generate a single schema, which compiles on its own.
+	source, ok := GenerateSchema(compiled.OrderedDefinitions)
+	require.True(t, ok)
+
+	recompiled, err := compiler.CompileMultiple([]compiler.InputSchema{{
+		Source:       input.Source("combined"),
+		SchemaString: source,
+	}}, nil)
+	require.NoError(t, err)
+	require.Len(t, recompiled.OrderedDefinitions, len(compiled.OrderedDefinitions))
+}
+
+// withUnknownField adds a field unknown to the allowed relation, as if written by a newer version.
+func withUnknownField(allowedRelation *core.AllowedRelation) *core.AllowedRelation {
+	unknown := protowire.AppendTag(nil, 100, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+	allowedRelation.ProtoReflect().SetUnknown(unknown)
+	return allowedRelation
+}
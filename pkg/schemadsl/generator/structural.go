@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/authzed/spicedb/pkg/caveats"
+	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
+	"github.com/authzed/spicedb/pkg/graph"
+	"github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+)
+
+// StructuralSchema is a structured view of a schema, mirroring what is expressed by its DSL.
+type StructuralSchema struct {
+	Definitions []StructuralDefinition `json:"definitions" yaml:"definitions"`
+	Caveats     []StructuralCaveat     `json:"caveats" yaml:"caveats"`
+}
+
+// StructuralDefinition is a structured view of an object definition.
+type StructuralDefinition struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Relations   []StructuralRelation   `json:"relations" yaml:"relations"`
+	Permissions []StructuralPermission `json:"permissions" yaml:"permissions"`
+}
+
+// StructuralRelation is a structured view of a relation, defined by the types of subjects it allows.
+type StructuralRelation struct {
+	Name         string                  `json:"name" yaml:"name"`
+	AllowedTypes []StructuralAllowedType `json:"allowedTypes" yaml:"allowedTypes"`
+}
+
+// StructuralAllowedType is a structured view of a subject type allowed on a relation.
+type StructuralAllowedType struct {
+	Type     string `json:"type" yaml:"type"`
+	Relation string `json:"relation,omitempty" yaml:"relation,omitempty"`
+	Wildcard bool   `json:"wildcard,omitempty" yaml:"wildcard,omitempty"`
+	Caveat   string `json:"caveat,omitempty" yaml:"caveat,omitempty"`
+}
+
+// StructuralPermission is a structured view of a permission, defined by its expression.
+type StructuralPermission struct {
+	Name       string `json:"name" yaml:"name"`
+	Expression string `json:"expression" yaml:"expression"`
+	IsAlias    bool   `json:"isAlias,omitempty" yaml:"isAlias,omitempty"`
+}
+
+// StructuralCaveat is a structured view of a caveat definition. Parameters map the name of each
+// parameter to its type, as written in the DSL.
+type StructuralCaveat struct {
+	Name       string            `json:"name" yaml:"name"`
+	Parameters map[string]string `json:"parameters" yaml:"parameters"`
+	Expression string            `json:"expression" yaml:"expression"`
+}
+
+// GenerateStructuralSchema generates a structured view of the given schema, suitable for
+// serialization as JSON or YAML. Definitions, relations and permissions are kept in the order given.
+// The returned boolean is false if an issue was found, in the same cases as GenerateSchema.
+func GenerateStructuralSchema(definitions []compiler.SchemaDefinition) (*StructuralSchema, bool) {
+	schema := &StructuralSchema{
+		Definitions: []StructuralDefinition{},
+		Caveats:     []StructuralCaveat{},
+	}
+
+	result := true
+	for _, definition := range definitions {
+		switch def := definition.(type) {
+		case *core.CaveatDefinition:
+			schema.Caveats = append(schema.Caveats, structuralCaveat(def))
+
+		case *core.NamespaceDefinition:
+			structural, ok := structuralDefinition(def)
+			result = result && ok
+			schema.Definitions = append(schema.Definitions, structural)
+
+		default:
+			panic(fmt.Sprintf("unknown type of definition %T in GenerateStructuralSchema", def))
+		}
+	}
+
+	return schema, result
+}
+
+// GenerateSchemaJSON generates an indented JSON view of the structure of the given schema. The
+// output is deterministic for a given list of definitions.
+func GenerateSchemaJSON(definitions []compiler.SchemaDefinition) (string, bool, error) {
+	schema, ok := GenerateStructuralSchema(definitions)
+
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), ok, nil
+}
+
+func structuralCaveat(caveat *core.CaveatDefinition) StructuralCaveat {
+	parameters := make(map[string]string, len(caveat.ParameterTypes))
+	for paramName, paramType := range caveat.ParameterTypes {
+		decoded, err := caveattypes.DecodeParameterType(paramType)
+		if err != nil {
+			panic("invalid parameter type on caveat")
+		}
+		parameters[paramName] = decoded.String()
+	}
+
+	deserializedExpression, err := caveats.DeserializeCaveat(caveat.SerializedExpression)
+	if err != nil {
+		panic("invalid caveat expression bytes")
+	}
+
+	exprString, err := deserializedExpression.ExprString()
+	if err != nil {
+		panic("invalid caveat expression")
+	}
+
+	return StructuralCaveat{
+		Name:       caveat.Name,
+		Parameters: parameters,
+		Expression: strings.TrimSpace(exprString),
+	}
+}
+
+func structuralDefinition(nsDef *core.NamespaceDefinition) (StructuralDefinition, bool) {
+	definition := StructuralDefinition{
+		Name:        nsDef.Name,
+		Relations:   []StructuralRelation{},
+		Permissions: []StructuralPermission{},
+	}
+
+	result := true
+	for _, relation := range nsDef.Relation {
+		isPermission := relation.UsersetRewrite != nil && !graph.HasThis(relation.UsersetRewrite)
+		if !isPermission {
+			if relation.UsersetRewrite != nil {
+				// Relations with rewrites cannot be expressed in the DSL.
+				result = false
+			}
+
+			allowedTypes := make([]StructuralAllowedType, 0, len(relation.GetTypeInformation().GetAllowedDirectRelations()))
+			for _, allowedRelation := range relation.GetTypeInformation().GetAllowedDirectRelations() {
+				allowedTypes = append(allowedTypes, structuralAllowedType(allowedRelation))
+			}
+			if len(allowedTypes) == 0 {
+				result = false
+			}
+
+			definition.Relations = append(definition.Relations, StructuralRelation{
+				Name:         relation.Name,
+				AllowedTypes: allowedTypes,
+			})
+			continue
+		}
+
+		// The expression is produced by the DSL generator, so that it reads exactly as in the DSL.
+		generator := &sourceGenerator{}
+		generator.emitRewrite(relation.UsersetRewrite)
+		result = result && !generator.hasIssue
+
+		_, isAlias := namespace.GetAliasedRelation(relation)
+		definition.Permissions = append(definition.Permissions, StructuralPermission{
+			Name:       relation.Name,
+			Expression: generator.buf.String(),
+			IsAlias:    isAlias,
+		})
+	}
+
+	return definition, result
+}
+
+func structuralAllowedType(allowedRelation *core.AllowedRelation) StructuralAllowedType {
+	allowedType := StructuralAllowedType{
+		Type:     allowedRelation.Namespace,
+		Wildcard: allowedRelation.GetPublicWildcard() != nil,
+		Caveat:   allowedRelation.GetRequiredCaveat().GetCaveatName(),
+	}
+	if allowedRelation.GetRelation() != Ellipsis {
+		allowedType.Relation = allowedRelation.GetRelation()
+	}
+	return allowedType
+}
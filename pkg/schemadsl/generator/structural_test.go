@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
+)
+
+func TestGenerateSchemaJSON(t *testing.T) {
+	require := require.New(t)
+
+	emptyPrefix := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `
+caveat only_on_tuesday(day_of_week string, allowed list<string>) {
+	day_of_week in allowed
+}
+
+definition user {}
+
+definition group {
+	relation member: user | group#member
+}
+
+definition document {
+	relation viewer: user:* | group#member | user with only_on_tuesday
+	relation banned: user
+	relation parent: document
+
+	permission view = (viewer + parent->view) - banned
+	permission read = alias view
+}`,
+	}, &emptyPrefix)
+	require.NoError(err)
+
+	generated, ok, err := GenerateSchemaJSON(compiled.OrderedDefinitions)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(`{
+  "definitions": [
+    {
+      "name": "user",
+      "relations": [],
+      "permissions": []
+    },
+    {
+      "name": "group",
+      "relations": [
+        {
+          "name": "member",
+          "allowedTypes": [
+            {
+              "type": "user"
+            },
+            {
+              "type": "group",
+              "relation": "member"
+            }
+          ]
+        }
+      ],
+      "permissions": []
+    },
+    {
+      "name": "document",
+      "relations": [
+        {
+          "name": "viewer",
+          "allowedTypes": [
+            {
+              "type": "user",
+              "wildcard": true
+            },
+            {
+              "type": "group",
+              "relation": "member"
+            },
+            {
+              "type": "user",
+              "caveat": "only_on_tuesday"
+            }
+          ]
+        },
+        {
+          "name": "banned",
+          "allowedTypes": [
+            {
+              "type": "user"
+            }
+          ]
+        },
+        {
+          "name": "parent",
+          "allowedTypes": [
+            {
+              "type": "document"
+            }
+          ]
+        }
+      ],
+      "permissions": [
+        {
+          "name": "view",
+          "expression": "viewer + parent->view - banned"
+        },
+        {
+          "name": "read",
+          "expression": "view",
+          "isAlias": true
+        }
+      ]
+    }
+  ],
+  "caveats": [
+    {
+      "name": "only_on_tuesday",
+      "parameters": {
+        "allowed": "list<string>",
+        "day_of_week": "string"
+      },
+      "expression": "day_of_week in allowed"
+    }
+  ]
+}`, generated)
+
+	// The output must be stable across invocations.
+	regenerated, _, err := GenerateSchemaJSON(compiled.OrderedDefinitions)
+	require.NoError(err)
+	require.Equal(generated, regenerated)
+}
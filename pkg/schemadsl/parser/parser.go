@@ -410,6 +410,7 @@ func (p *sourceParser) consumeTypePath() (string, bool) {
 
 // consumePermission consumes a permission.
 // ```permission foo = bar + baz```
+// ```permission foo = alias bar```
 func (p *sourceParser) consumePermission() AstNode {
 	permNode := p.startNode(dslshape.NodeTypePermission)
 	defer p.finishNode()
@@ -429,6 +430,15 @@ func (p *sourceParser) consumePermission() AstNode {
 		return permNode
 	}
 
+	// alias ...
+	// The alias keyword is contextual: it is only recognized when followed by the name of the
+	// aliased relation or permission, so that it remains usable as a relation name.
+	if p.isToken(lexer.TokenTypeIdentifier) && p.currentToken.Value == "alias" &&
+		p.peekSignificantToken().Kind == lexer.TokenTypeIdentifier {
+		p.consumeToken()
+		permNode.Decorate(dslshape.NodePermissionPredicateIsAlias, "true")
+	}
+
 	permNode.Connect(dslshape.NodePermissionPredicateComputeExpression, p.consumeComputeExpression())
 	return permNode
 }
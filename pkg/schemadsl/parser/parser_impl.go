@@ -153,6 +153,17 @@ func (p *sourceParser) consumeToken() commentedLexeme {
 	}
 }
 
+// peekSignificantToken returns the token following the current token, skipping any ignored
+// tokens, without consuming it.
+func (p *sourceParser) peekSignificantToken() lexer.Lexeme {
+	for count := 1; ; count++ {
+		token := p.lex.PeekToken(count)
+		if _, ok := ignoredTokenTypes[token.Kind]; !ok {
+			return token
+		}
+	}
+}
+
 // isToken returns true if the current token matches one of the types given.
 func (p *sourceParser) isToken(types ...lexer.TokenType) bool {
 	for _, kind := range types {
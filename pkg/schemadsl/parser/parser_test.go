@@ -113,6 +113,7 @@ func TestParser(t *testing.T) {
 		{"complex caveat test", "complexcaveat"},
 		{"empty caveat test", "emptycaveat"},
 		{"unclosed caveat test", "unclosedcaveat"},
+		{"alias test", "alias"},
 	}
 
 	for _, test := range parserTests {
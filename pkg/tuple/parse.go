@@ -0,0 +1,166 @@
+package tuple
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var (
+	namespaceNameRegex = regexp.MustCompile(fmt.Sprintf("^%s$", namespaceNameExpr))
+	relationRegex      = regexp.MustCompile(fmt.Sprintf("^%s$", relationExpr))
+	caveatNameRegex    = regexp.MustCompile(fmt.Sprintf("^%s$", caveatNameExpr))
+
+	// subjectIDSegmentRegex groups the alternatives of subjectIDExpr, so that the anchors apply
+	// to both.
+	subjectIDSegmentRegex = regexp.MustCompile(fmt.Sprintf("^(%s)$", subjectIDExpr))
+)
+
+// Segment identifies a segment of the string form of a tuple.
+type Segment int
+
+const (
+	// UnknownSegment is used when the failing segment could not be determined.
+	UnknownSegment Segment = iota
+
+	// ResourceTypeSegment is the object type of the resource.
+	ResourceTypeSegment
+
+	// ResourceIDSegment is the object ID of the resource.
+	ResourceIDSegment
+
+	// ResourceRelationSegment is the relation of the resource.
+	ResourceRelationSegment
+
+	// SubjectTypeSegment is the object type of the subject.
+	SubjectTypeSegment
+
+	// SubjectIDSegment is the object ID of the subject.
+	SubjectIDSegment
+
+	// SubjectRelationSegment is the optional relation of the subject.
+	SubjectRelationSegment
+
+	// CaveatSegment is the optional caveat, including its context.
+	CaveatSegment
+)
+
+func (s Segment) String() string {
+	switch s {
+	case ResourceTypeSegment:
+		return "resource type"
+	case ResourceIDSegment:
+		return "resource ID"
+	case ResourceRelationSegment:
+		return "resource relation"
+	case SubjectTypeSegment:
+		return "subject type"
+	case SubjectIDSegment:
+		return "subject ID"
+	case SubjectRelationSegment:
+		return "subject relation"
+	case CaveatSegment:
+		return "caveat"
+	default:
+		return "tuple"
+	}
+}
+
+// ErrInvalidTuple occurs when the string form of a tuple could not be parsed.
+type ErrInvalidTuple struct {
+	error
+	segment Segment
+}
+
+// Segment is the segment of the tuple string which could not be parsed.
+func (err ErrInvalidTuple) Segment() Segment {
+	return err.segment
+}
+
+func newInvalidTupleErr(segment Segment, format string, args ...any) ErrInvalidTuple {
+	return ErrInvalidTuple{
+		error:   fmt.Errorf("invalid %s: %s", segment, fmt.Sprintf(format, args...)),
+		segment: segment,
+	}
+}
+
+// ParseWithError unmarshals the string form of a Tuple. Unlike Parse, if the string is not a
+// valid tuple, an ErrInvalidTuple is returned identifying the segment which could not be parsed.
+//
+// This function treats both missing and Ellipsis relations equally.
+func ParseWithError(tpl string) (*core.RelationTuple, error) {
+	if parsed := Parse(tpl); parsed != nil {
+		return parsed, nil
+	}
+
+	return nil, diagnoseTuple(tpl)
+}
+
+// diagnoseTuple returns an error describing the first segment of the tuple string that is
+// invalid.
+func diagnoseTuple(tpl string) error {
+	resource, subjectAndCaveat, ok := strings.Cut(tpl, "@")
+	if !ok {
+		return newInvalidTupleErr(SubjectTypeSegment, "missing `@` separating the resource from the subject")
+	}
+
+	resourceType, resourceIDAndRelation, ok := strings.Cut(resource, ":")
+	if !ok {
+		return newInvalidTupleErr(ResourceIDSegment, "missing `:` separating the resource type from the resource ID")
+	}
+	if !namespaceNameRegex.MatchString(resourceType) {
+		return newInvalidTupleErr(ResourceTypeSegment, "`%s` is not a valid object type", resourceType)
+	}
+
+	resourceID, resourceRelation, ok := strings.Cut(resourceIDAndRelation, "#")
+	if !ok {
+		return newInvalidTupleErr(ResourceRelationSegment, "missing `#` separating the resource ID from the relation")
+	}
+	if !resourceIDRegex.MatchString(resourceID) {
+		return newInvalidTupleErr(ResourceIDSegment, "`%s` is not a valid object ID", resourceID)
+	}
+	if !relationRegex.MatchString(resourceRelation) {
+		return newInvalidTupleErr(ResourceRelationSegment, "`%s` is not a valid relation", resourceRelation)
+	}
+
+	subject, caveat, hasCaveat := strings.Cut(subjectAndCaveat, "[")
+
+	subjectType, subjectIDAndRelation, ok := strings.Cut(subject, ":")
+	if !ok {
+		return newInvalidTupleErr(SubjectIDSegment, "missing `:` separating the subject type from the subject ID")
+	}
+	if !namespaceNameRegex.MatchString(subjectType) {
+		return newInvalidTupleErr(SubjectTypeSegment, "`%s` is not a valid object type", subjectType)
+	}
+
+	subjectID, subjectRelation, hasSubjectRelation := strings.Cut(subjectIDAndRelation, "#")
+	if !subjectIDSegmentRegex.MatchString(subjectID) {
+		return newInvalidTupleErr(SubjectIDSegment, "`%s` is not a valid object ID", subjectID)
+	}
+	if hasSubjectRelation && subjectRelation != Ellipsis && !relationRegex.MatchString(subjectRelation) {
+		return newInvalidTupleErr(SubjectRelationSegment, "`%s` is not a valid relation", subjectRelation)
+	}
+
+	if hasCaveat {
+		if !strings.HasSuffix(caveat, "]") {
+			return newInvalidTupleErr(CaveatSegment, "missing closing `]`")
+		}
+
+		caveatName, caveatContext, hasContext := strings.Cut(strings.TrimSuffix(caveat, "]"), ":")
+		if !caveatNameRegex.MatchString(caveatName) {
+			return newInvalidTupleErr(CaveatSegment, "`%s` is not a valid caveat name", caveatName)
+		}
+
+		if hasContext {
+			contextMap := make(map[string]any, 1)
+			if err := json.Unmarshal([]byte(caveatContext), &contextMap); err != nil {
+				return newInvalidTupleErr(CaveatSegment, "invalid caveat context: %s", err)
+			}
+		}
+	}
+
+	return newInvalidTupleErr(UnknownSegment, "`%s` could not be parsed", tpl)
+}
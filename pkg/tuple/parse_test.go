@@ -0,0 +1,113 @@
+package tuple
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/testutil"
+)
+
+func TestParseWithError(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			parsed, err := ParseWithError(tc.input)
+			if tc.tupleFormat == nil {
+				require.Error(t, err)
+				require.ErrorAs(t, err, &ErrInvalidTuple{})
+				return
+			}
+
+			require.NoError(t, err)
+			testutil.RequireProtoEqual(t, tc.tupleFormat, parsed, "found difference in parsed tuple")
+		})
+	}
+}
+
+func TestParseWithErrorSegments(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedSegment Segment
+		expectedError   string
+	}{
+		{
+			"document:foo#viewer",
+			SubjectTypeSegment,
+			"invalid subject type: missing `@` separating the resource from the subject",
+		},
+		{
+			"document#viewer@user:tom",
+			ResourceIDSegment,
+			"invalid resource ID: missing `:` separating the resource type from the resource ID",
+		},
+		{
+			"Document:foo#viewer@user:tom",
+			ResourceTypeSegment,
+			"invalid resource type: `Document` is not a valid object type",
+		},
+		{
+			"document:foo@user:tom",
+			ResourceRelationSegment,
+			"invalid resource relation: missing `#` separating the resource ID from the relation",
+		},
+		{
+			"document:foo!bar#viewer@user:tom",
+			ResourceIDSegment,
+			"invalid resource ID: `foo!bar` is not a valid object ID",
+		},
+		{
+			"document:foo#v@user:tom",
+			ResourceRelationSegment,
+			"invalid resource relation: `v` is not a valid relation",
+		},
+		{
+			"document:foo#viewer@user",
+			SubjectIDSegment,
+			"invalid subject ID: missing `:` separating the subject type from the subject ID",
+		},
+		{
+			"document:foo#viewer@u:tom",
+			SubjectTypeSegment,
+			"invalid subject type: `u` is not a valid object type",
+		},
+		{
+			"document:foo#viewer@user:tom!",
+			SubjectIDSegment,
+			"invalid subject ID: `tom!` is not a valid object ID",
+		},
+		{
+			"document:foo#viewer@user:tom#Member",
+			SubjectRelationSegment,
+			"invalid subject relation: `Member` is not a valid relation",
+		},
+		{
+			"document:foo#viewer@user:tom[somecaveat",
+			CaveatSegment,
+			"invalid caveat: missing closing `]`",
+		},
+		{
+			"document:foo#viewer@user:tom[Some]",
+			CaveatSegment,
+			"invalid caveat: `Some` is not a valid caveat name",
+		},
+		{
+			"document:foo#viewer@user:tom[somecaveat:{\"hi\"}]",
+			CaveatSegment,
+			"invalid caveat: invalid caveat context: invalid character '}' after object key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require := require.New(t)
+
+			parsed, err := ParseWithError(tc.input)
+			require.Nil(parsed)
+			require.EqualError(err, tc.expectedError)
+
+			var invalidErr ErrInvalidTuple
+			require.ErrorAs(err, &invalidErr)
+			require.Equal(tc.expectedSegment, invalidErr.Segment())
+		})
+	}
+}
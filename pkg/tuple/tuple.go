@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/jzelinskie/stringz"
 	"golang.org/x/exp/maps"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -458,3 +460,10 @@ func WithCaveat(tpl *core.RelationTuple, caveatName string, contexts ...map[stri
 	}
 	return tpl
 }
+
+// WithExpiration returns a copy of the given tuple which expires at the given time.
+func WithExpiration(tpl *core.RelationTuple, expiresAt time.Time) *core.RelationTuple {
+	tpl = tpl.CloneVT()
+	tpl.ExpiresAt = timestamppb.New(expiresAt)
+	return tpl
+}
@@ -24,6 +24,10 @@ const (
 // zedtoken argument to Decode
 var ErrNilZedToken = errors.New("zedtoken pointer was nil")
 
+// ErrUnsupportedZedTokenVersion is returned as the base error when a zedtoken decodes, but was
+// encoded with a version which is not supported, such as one from a newer version of SpiceDB.
+var ErrUnsupportedZedTokenVersion = errors.New("unsupported zedtoken version")
+
 // NewFromRevision generates an encoded zedtoken from an integral revision.
 func NewFromRevision(revision datastore.Revision) *v1.ZedToken {
 	toEncode := &zedtoken.DecodedZedToken{
@@ -86,8 +90,24 @@ func DecodeRevision(encoded *v1.ZedToken, ds revisionDecoder) (datastore.Revisio
 		}
 		return parsed, nil
 	default:
-		return datastore.NoRevision, fmt.Errorf(errDecodeError, fmt.Errorf("unknown zookie version: %T", decoded.VersionOneof))
+		return datastore.NoRevision, fmt.Errorf(errDecodeError, ErrUnsupportedZedTokenVersion)
+	}
+}
+
+// Compare decodes the revisions of both zedtokens and returns the ordering of the first relative
+// to the second.
+func Compare(first, second *v1.ZedToken, ds revisionDecoder) (datastore.RevisionOrdering, error) {
+	firstRevision, err := DecodeRevision(first, ds)
+	if err != nil {
+		return datastore.RevisionsUnordered, err
 	}
+
+	secondRevision, err := DecodeRevision(second, ds)
+	if err != nil {
+		return datastore.RevisionsUnordered, err
+	}
+
+	return datastore.CompareRevisions(firstRevision, secondRevision), nil
 }
 
 type revisionDecoder interface {
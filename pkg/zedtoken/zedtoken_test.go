@@ -135,3 +135,67 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestCompare(t *testing.T) {
+	first := NewFromRevision(revision.NewFromDecimal(decimal.NewFromInt(1)))
+	second := NewFromRevision(revision.NewFromDecimal(decimal.New(12345, -2)))
+
+	// V1 Zookie for revision 1.
+	legacyFirst := &v1.ZedToken{Token: "CAESAggB"}
+
+	testCases := []struct {
+		name     string
+		first    *v1.ZedToken
+		second   *v1.ZedToken
+		expected datastore.RevisionOrdering
+	}{
+		{"before", first, second, datastore.RevisionBefore},
+		{"after", second, first, datastore.RevisionAfter},
+		{"equal", first, first, datastore.RevisionEqual},
+		{"equal to legacy zookie", first, legacyFirst, datastore.RevisionEqual},
+		{"legacy zookie before", legacyFirst, second, datastore.RevisionBefore},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			ordering, err := Compare(tc.first, tc.second, revision.DecimalDecoder{})
+			require.NoError(err)
+			require.Equal(tc.expected, ordering)
+		})
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"garbage", "!!not a zedtoken!!"},
+		{"garbage bytes", "/////w=="},
+		{"truncated", "CAIaCAoGMTIz"},
+		{"empty", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeRevision(&v1.ZedToken{Token: tc.token}, revision.DecimalDecoder{})
+			require.Error(t, err)
+		})
+	}
+
+	// A token encoded with an unknown version decodes without error, but has no known version.
+	_, err := DecodeRevision(&v1.ZedToken{Token: "KgIIAQ=="}, revision.DecimalDecoder{})
+	require.ErrorIs(t, err, ErrUnsupportedZedTokenVersion)
+}
+
+func TestCompareInvalid(t *testing.T) {
+	valid := NewFromRevision(revision.NewFromDecimal(decimal.NewFromInt(1)))
+
+	_, err := Compare(valid, &v1.ZedToken{Token: "abc"}, revision.DecimalDecoder{})
+	require.Error(t, err)
+
+	_, err = Compare(nil, valid, revision.DecimalDecoder{})
+	require.ErrorIs(t, err, ErrNilZedToken)
+}